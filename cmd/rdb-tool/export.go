@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"redis-go/internal/protocol"
+	"redis-go/internal/storage"
+)
+
+// respBatchSize limite le nombre d'éléments par commande RPUSH / SADD / HSET exportée
+const respBatchSize = 512
+
+// exportedKey est la représentation JSON d'une clé
+// value est une chaîne, un tableau (list, set) ou un objet (hash, stream) selon le type
+// Une chaîne qui n'est pas de l'UTF-8 valide (HyperLogLog, bitmap...) est exportée en base64 avec encoding "base64"
+type exportedKey struct {
+	Key         string          `json:"key"`
+	Type        string          `json:"type"`
+	ExpiresAtMs int64           `json:"expires_at_ms,omitempty"`
+	Encoding    string          `json:"encoding,omitempty"`
+	Value       json.RawMessage `json:"value"`
+}
+
+// base64ValueEncoding est la valeur du champ encoding pour une chaîne binaire
+const base64ValueEncoding = "base64"
+
+// exportedStream est la valeur JSON d'un stream
+// last_id est conservé : il peut dépasser l'identifiant de la dernière entrée après un XDEL
+type exportedStream struct {
+	LastID  string                `json:"last_id"`
+	Entries []exportedStreamEntry `json:"entries"`
+}
+
+// exportedStreamEntry est une entrée de stream : identifiant et paires champ / valeur à plat
+type exportedStreamEntry struct {
+	ID     string   `json:"id"`
+	Fields []string `json:"fields"`
+}
+
+// exportedSortedSetMember est un membre de sorted set ; le score est une chaîne pour conserver +inf et -inf
+type exportedSortedSetMember struct {
+	Member string `json:"member"`
+	Score  string `json:"score"`
+}
+
+// runExportCommand implémente "rdb-tool export --format json|resp [--output fichier] fichier.rdb"
+func runExportCommand(commandArguments []string) error {
+	flagSet := flag.NewFlagSet("export", flag.ContinueOnError)
+	exportFormat := flagSet.String("format", "json", "format d'export : json ou resp")
+	outputPath := flagSet.String("output", "-", "fichier de sortie (- = sortie standard)")
+	if parseError := flagSet.Parse(commandArguments); parseError != nil {
+		return parseError
+	}
+	if flagSet.NArg() != 1 {
+		return fmt.Errorf("usage : rdb-tool export --format json|resp [--output fichier] fichier.rdb")
+	}
+
+	var outputWriter io.Writer = os.Stdout
+	if *outputPath != "-" {
+		outputFile, createError := os.Create(*outputPath)
+		if createError != nil {
+			return createError
+		}
+		defer outputFile.Close()
+		outputWriter = outputFile
+	}
+	bufferedWriter := bufio.NewWriter(outputWriter)
+
+	var exportError error
+	var fileSummary snapshotFileSummary
+	switch *exportFormat {
+	case "json":
+		fileSummary, exportError = exportSnapshotAsJSON(flagSet.Arg(0), bufferedWriter)
+	case "resp":
+		fileSummary, exportError = exportSnapshotAsRESP(flagSet.Arg(0), bufferedWriter)
+	default:
+		return fmt.Errorf("format d'export inconnu '%s' (json ou resp)", *exportFormat)
+	}
+	if exportError != nil {
+		return fmt.Errorf("%s: %v", flagSet.Arg(0), exportError)
+	}
+	if flushError := bufferedWriter.Flush(); flushError != nil {
+		return flushError
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ %d clés exportées (%s)\n", fileSummary.RecordCount, *exportFormat)
+	return nil
+}
+
+// exportSnapshotAsJSON écrit un tableau JSON, une clé par ligne, sans charger le fichier en mémoire
+func exportSnapshotAsJSON(snapshotPath string, outputWriter *bufio.Writer) (snapshotFileSummary, error) {
+	if _, writeError := outputWriter.WriteString("["); writeError != nil {
+		return snapshotFileSummary{}, writeError
+	}
+
+	exportedCount := 0
+	fileSummary, readError := readSnapshotFile(snapshotPath, func(snapshotRecord storage.SnapshotRecord) error {
+		keyDocument, encodeError := encodeKeyAsJSON(snapshotRecord)
+		if encodeError != nil {
+			return encodeError
+		}
+		separator := ",\n"
+		if exportedCount == 0 {
+			separator = "\n"
+		}
+		exportedCount++
+		if _, writeError := outputWriter.WriteString(separator); writeError != nil {
+			return writeError
+		}
+		_, writeError := outputWriter.Write(keyDocument)
+		return writeError
+	})
+	if readError != nil {
+		return fileSummary, readError
+	}
+
+	_, writeError := outputWriter.WriteString("\n]\n")
+	return fileSummary, writeError
+}
+
+// encodeKeyAsJSON sérialise une clé au format d'export JSON
+func encodeKeyAsJSON(snapshotRecord storage.SnapshotRecord) ([]byte, error) {
+	var keyValue interface{}
+	var valueEncoding string
+	switch snapshotRecord.DataType {
+	case storage.RedisListType:
+		keyValue = snapshotRecord.ListElements
+	case storage.RedisSetType:
+		keyValue = snapshotRecord.SetMembers
+	case storage.RedisHashType:
+		keyValue = snapshotRecord.HashFields
+	case storage.RedisStreamType:
+		streamValue := exportedStream{
+			LastID:  snapshotRecord.StreamLastID.String(),
+			Entries: make([]exportedStreamEntry, 0, len(snapshotRecord.StreamEntries)),
+		}
+		for _, streamEntry := range snapshotRecord.StreamEntries {
+			streamValue.Entries = append(streamValue.Entries, exportedStreamEntry{ID: streamEntry.EntryID.String(), Fields: streamEntry.FieldValues})
+		}
+		keyValue = streamValue
+	case storage.RedisZSetType:
+		sortedSetValue := make([]exportedSortedSetMember, 0, len(snapshotRecord.SortedMembers))
+		for _, sortedSetMember := range snapshotRecord.SortedMembers {
+			sortedSetValue = append(sortedSetValue, exportedSortedSetMember{Member: sortedSetMember.Member, Score: formatSortedSetScore(sortedSetMember.Score)})
+		}
+		keyValue = sortedSetValue
+	case storage.RedisJSONType:
+		keyValue = json.RawMessage(snapshotRecord.JSONDocument)
+	default:
+		// json.Marshal remplacerait les octets invalides par U+FFFD
+		keyValue = snapshotRecord.StringValue
+		if !utf8.ValidString(snapshotRecord.StringValue) {
+			keyValue, valueEncoding = base64.StdEncoding.EncodeToString([]byte(snapshotRecord.StringValue)), base64ValueEncoding
+		}
+	}
+
+	encodedValue, encodeError := json.Marshal(keyValue)
+	if encodeError != nil {
+		return nil, fmt.Errorf("clé '%s': %v", snapshotRecord.Key, encodeError)
+	}
+
+	keyDocument := exportedKey{
+		Key:      snapshotRecord.Key,
+		Type:     snapshotRecord.DataType.TypeName(),
+		Encoding: valueEncoding,
+		Value:    encodedValue,
+	}
+	if snapshotRecord.ExpirationTime != nil {
+		keyDocument.ExpiresAtMs = snapshotRecord.ExpirationTime.UnixMilli()
+	}
+	return json.Marshal(keyDocument)
+}
+
+// exportSnapshotAsRESP écrit les commandes RESP qui recréent chaque clé (compatible redis-cli --pipe)
+// Les TTL sont exportés en PEXPIRE relatif à l'heure de l'export ; les clés déjà expirées sont omises
+func exportSnapshotAsRESP(snapshotPath string, outputWriter *bufio.Writer) (snapshotFileSummary, error) {
+	protocolEncoder := protocol.NewRedisSerializationProtocolEncoder(outputWriter)
+	exportTime := time.Now()
+
+	return readSnapshotFile(snapshotPath, func(snapshotRecord storage.SnapshotRecord) error {
+		if snapshotRecord.ExpirationTime != nil && !snapshotRecord.ExpirationTime.After(exportTime) {
+			return nil
+		}
+		for _, recreateCommand := range buildRecreateCommands(snapshotRecord, exportTime) {
+			if writeError := protocolEncoder.WriteArrayResponse(recreateCommand); writeError != nil {
+				return writeError
+			}
+		}
+		return nil
+	})
+}
+
+// buildRecreateCommands retourne les commandes qui recréent une clé, expiration comprise
+// Un DEL initial rend l'import idempotent sur une base non vide
+func buildRecreateCommands(snapshotRecord storage.SnapshotRecord, exportTime time.Time) [][]string {
+	recreateCommands := [][]string{{"DEL", snapshotRecord.Key}}
+
+	switch snapshotRecord.DataType {
+	case storage.RedisListType:
+		recreateCommands = appendBatchedCommands(recreateCommands, "RPUSH", snapshotRecord.Key, snapshotRecord.ListElements)
+	case storage.RedisSetType:
+		recreateCommands = appendBatchedCommands(recreateCommands, "SADD", snapshotRecord.Key, snapshotRecord.SetMembers)
+	case storage.RedisHashType:
+		fieldNames := make([]string, 0, len(snapshotRecord.HashFields))
+		for fieldName := range snapshotRecord.HashFields {
+			fieldNames = append(fieldNames, fieldName)
+		}
+		sort.Strings(fieldNames)
+
+		fieldPairs := make([]string, 0, 2*len(fieldNames))
+		for _, fieldName := range fieldNames {
+			fieldPairs = append(fieldPairs, fieldName, snapshotRecord.HashFields[fieldName])
+		}
+		recreateCommands = appendBatchedCommands(recreateCommands, "HSET", snapshotRecord.Key, fieldPairs)
+	case storage.RedisStreamType:
+		recreateCommands = appendStreamCommands(recreateCommands, snapshotRecord)
+	case storage.RedisZSetType:
+		scoreMemberPairs := make([]string, 0, 2*len(snapshotRecord.SortedMembers))
+		for _, sortedSetMember := range snapshotRecord.SortedMembers {
+			scoreMemberPairs = append(scoreMemberPairs, formatSortedSetScore(sortedSetMember.Score), sortedSetMember.Member)
+		}
+		recreateCommands = appendBatchedCommands(recreateCommands, "ZADD", snapshotRecord.Key, scoreMemberPairs)
+	case storage.RedisJSONType:
+		recreateCommands = append(recreateCommands, []string{"JSON.SET", snapshotRecord.Key, "$", snapshotRecord.JSONDocument})
+	default:
+		recreateCommands = append(recreateCommands, []string{"SET", snapshotRecord.Key, snapshotRecord.StringValue})
+	}
+
+	if snapshotRecord.ExpirationTime != nil {
+		remainingMilliseconds := max(snapshotRecord.ExpirationTime.Sub(exportTime).Milliseconds(), 1)
+		recreateCommands = append(recreateCommands, []string{
+			"PEXPIRE", snapshotRecord.Key, strconv.FormatInt(remainingMilliseconds, 10),
+		})
+	}
+	return recreateCommands
+}
+
+// appendStreamCommands recrée un stream entrée par entrée avec des XADD aux identifiants explicites
+// Si le dernier identifiant généré dépasse la dernière entrée (XDEL, stream vidé), une entrée temporaire
+// portant cet identifiant est ajoutée puis supprimée pour le restaurer
+func appendStreamCommands(recreateCommands [][]string, snapshotRecord storage.SnapshotRecord) [][]string {
+	for _, streamEntry := range snapshotRecord.StreamEntries {
+		xaddCommand := append([]string{"XADD", snapshotRecord.Key, streamEntry.EntryID.String()}, streamEntry.FieldValues...)
+		recreateCommands = append(recreateCommands, xaddCommand)
+	}
+
+	entryCount := len(snapshotRecord.StreamEntries)
+	if entryCount == 0 || snapshotRecord.StreamEntries[entryCount-1].EntryID != snapshotRecord.StreamLastID {
+		lastID := snapshotRecord.StreamLastID.String()
+		recreateCommands = append(recreateCommands,
+			[]string{"XADD", snapshotRecord.Key, lastID, "_", "_"},
+			[]string{"XDEL", snapshotRecord.Key, lastID})
+	}
+	return recreateCommands
+}
+
+// appendBatchedCommands découpe les éléments en commandes d'au plus respBatchSize éléments
+// Pour HSET et ZADD, les éléments sont des paires : la taille de lot reste paire
+func appendBatchedCommands(recreateCommands [][]string, commandName string, storageKey string, commandElements []string) [][]string {
+	for batchStart := 0; batchStart < len(commandElements); batchStart += respBatchSize {
+		batchEnd := min(batchStart+respBatchSize, len(commandElements))
+		batchCommand := append([]string{commandName, storageKey}, commandElements[batchStart:batchEnd]...)
+		recreateCommands = append(recreateCommands, batchCommand)
+	}
+	return recreateCommands
+}
+
+// formatSortedSetScore écrit un score sans perte de précision, en notation décimale
+// pour les valeurs usuelles (les geohash de 52 bits restent lisibles)
+func formatSortedSetScore(memberScore float64) string {
+	if math.Abs(memberScore) < 1e17 {
+		return strconv.FormatFloat(memberScore, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(memberScore, 'g', -1, 64)
+}
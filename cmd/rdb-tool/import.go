@@ -0,0 +1,409 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"redis-go/internal/protocol"
+	"redis-go/internal/storage"
+)
+
+// snapshotBuilder rassemble les clés importées avant l'écriture du snapshot
+// Les commandes RESP d'une même clé peuvent être réparties (RPUSH par lots puis PEXPIRE)
+type snapshotBuilder struct {
+	snapshotRecords map[string]*storage.SnapshotRecord
+	insertionOrder  []string
+}
+
+// runImportCommand implémente "rdb-tool import --format json|resp [--compression=false] entrée sortie.rdb"
+func runImportCommand(commandArguments []string) error {
+	flagSet := flag.NewFlagSet("import", flag.ContinueOnError)
+	importFormat := flagSet.String("format", "json", "format de l'entrée : json ou resp")
+	compressed := flagSet.Bool("compression", true, "compresser le snapshot avec gzip")
+	if parseError := flagSet.Parse(commandArguments); parseError != nil {
+		return parseError
+	}
+	if flagSet.NArg() != 2 {
+		return fmt.Errorf("usage : rdb-tool import --format json|resp [--compression=false] entrée sortie.rdb")
+	}
+
+	var inputReader io.Reader = os.Stdin
+	if flagSet.Arg(0) != "-" {
+		inputFile, openError := os.Open(flagSet.Arg(0))
+		if openError != nil {
+			return openError
+		}
+		defer inputFile.Close()
+		inputReader = inputFile
+	}
+
+	builder := &snapshotBuilder{snapshotRecords: make(map[string]*storage.SnapshotRecord)}
+	var importError error
+	switch *importFormat {
+	case "json":
+		importError = builder.importJSON(inputReader)
+	case "resp":
+		importError = builder.importRESP(inputReader)
+	default:
+		return fmt.Errorf("format d'import inconnu '%s' (json ou resp)", *importFormat)
+	}
+	if importError != nil {
+		return fmt.Errorf("%s: %v", flagSet.Arg(0), importError)
+	}
+
+	// Une clé supprimée puis recréée apparaît deux fois dans l'ordre d'insertion
+	snapshotRecords := make([]storage.SnapshotRecord, 0, len(builder.snapshotRecords))
+	for _, storageKey := range builder.insertionOrder {
+		if snapshotRecord, keyExists := builder.snapshotRecords[storageKey]; keyExists {
+			snapshotRecords = append(snapshotRecords, *snapshotRecord)
+			delete(builder.snapshotRecords, storageKey)
+		}
+	}
+
+	snapshotWriter, writeError := writeSnapshotFile(flagSet.Arg(1), *compressed, snapshotRecords)
+	if writeError != nil {
+		return fmt.Errorf("%s: %v", flagSet.Arg(1), writeError)
+	}
+	fmt.Fprintf(os.Stderr, "✅ %d clés importées dans %s (%d octets)\n",
+		snapshotWriter.RecordCount(), flagSet.Arg(1), snapshotWriter.FileBytes())
+	return nil
+}
+
+// importJSON lit un tableau produit par "export --format json", élément par élément
+func (builder *snapshotBuilder) importJSON(inputReader io.Reader) error {
+	jsonDecoder := json.NewDecoder(inputReader)
+	if openingToken, tokenError := jsonDecoder.Token(); tokenError != nil || openingToken != json.Delim('[') {
+		return fmt.Errorf("un tableau JSON est attendu")
+	}
+
+	for keyIndex := 1; jsonDecoder.More(); keyIndex++ {
+		var keyDocument exportedKey
+		if decodeError := jsonDecoder.Decode(&keyDocument); decodeError != nil {
+			return fmt.Errorf("élément %d: %v", keyIndex, decodeError)
+		}
+		snapshotRecord, convertError := decodeKeyFromJSON(keyDocument)
+		if convertError != nil {
+			return fmt.Errorf("élément %d: %v", keyIndex, convertError)
+		}
+		builder.replaceRecord(snapshotRecord)
+	}
+
+	if _, tokenError := jsonDecoder.Token(); tokenError != nil {
+		return fmt.Errorf("fin du tableau JSON: %v", tokenError)
+	}
+	return nil
+}
+
+// decodeKeyFromJSON convertit un élément JSON en enregistrement de snapshot
+func decodeKeyFromJSON(keyDocument exportedKey) (*storage.SnapshotRecord, error) {
+	dataType, typeError := parseDataTypeName(keyDocument.Type)
+	if typeError != nil {
+		return nil, fmt.Errorf("clé '%s': %v", keyDocument.Key, typeError)
+	}
+
+	snapshotRecord := &storage.SnapshotRecord{Key: keyDocument.Key, DataType: dataType}
+	if keyDocument.ExpiresAtMs > 0 {
+		expirationTime := time.UnixMilli(keyDocument.ExpiresAtMs)
+		snapshotRecord.ExpirationTime = &expirationTime
+	}
+
+	if keyDocument.Encoding != "" && (keyDocument.Encoding != base64ValueEncoding || dataType != storage.RedisStringType) {
+		return nil, fmt.Errorf("clé '%s': encodage '%s' non supporté pour le type %s", keyDocument.Key, keyDocument.Encoding, keyDocument.Type)
+	}
+
+	var valueError error
+	switch dataType {
+	case storage.RedisListType:
+		valueError = json.Unmarshal(keyDocument.Value, &snapshotRecord.ListElements)
+	case storage.RedisSetType:
+		valueError = json.Unmarshal(keyDocument.Value, &snapshotRecord.SetMembers)
+	case storage.RedisHashType:
+		valueError = json.Unmarshal(keyDocument.Value, &snapshotRecord.HashFields)
+	case storage.RedisStreamType:
+		valueError = decodeStreamFromJSON(keyDocument.Value, snapshotRecord)
+	case storage.RedisZSetType:
+		valueError = decodeSortedSetFromJSON(keyDocument.Value, snapshotRecord)
+	case storage.RedisJSONType:
+		snapshotRecord.JSONDocument, valueError = normalizeJSONDocument(string(keyDocument.Value))
+	default:
+		valueError = json.Unmarshal(keyDocument.Value, &snapshotRecord.StringValue)
+		if valueError == nil && keyDocument.Encoding == base64ValueEncoding {
+			var decodedValue []byte
+			decodedValue, valueError = base64.StdEncoding.DecodeString(snapshotRecord.StringValue)
+			snapshotRecord.StringValue = string(decodedValue)
+		}
+	}
+	if valueError != nil {
+		return nil, fmt.Errorf("clé '%s': valeur invalide pour le type %s: %v", keyDocument.Key, keyDocument.Type, valueError)
+	}
+	return snapshotRecord, nil
+}
+
+// decodeStreamFromJSON remplit les entrées et le dernier identifiant d'un stream exporté
+func decodeStreamFromJSON(encodedValue json.RawMessage, snapshotRecord *storage.SnapshotRecord) error {
+	var streamValue exportedStream
+	if decodeError := json.Unmarshal(encodedValue, &streamValue); decodeError != nil {
+		return decodeError
+	}
+	lastID, validID := storage.ParseStreamEntryID(streamValue.LastID, 0)
+	if !validID {
+		return fmt.Errorf("last_id '%s' invalide", streamValue.LastID)
+	}
+	snapshotRecord.StreamLastID = lastID
+
+	for _, exportedEntry := range streamValue.Entries {
+		entryID, validID := storage.ParseStreamEntryID(exportedEntry.ID, 0)
+		if !validID {
+			return fmt.Errorf("identifiant d'entrée '%s' invalide", exportedEntry.ID)
+		}
+		if len(exportedEntry.Fields) == 0 || len(exportedEntry.Fields)%2 != 0 {
+			return fmt.Errorf("entrée %s : paires champ valeur attendues", exportedEntry.ID)
+		}
+		if entryCount := len(snapshotRecord.StreamEntries); entryCount > 0 && entryID.Compare(snapshotRecord.StreamEntries[entryCount-1].EntryID) <= 0 {
+			return fmt.Errorf("entrée %s : identifiants non croissants", exportedEntry.ID)
+		}
+		if entryID.Compare(lastID) > 0 {
+			return fmt.Errorf("entrée %s : identifiant supérieur à last_id", exportedEntry.ID)
+		}
+		snapshotRecord.StreamEntries = append(snapshotRecord.StreamEntries, storage.StreamEntry{EntryID: entryID, FieldValues: exportedEntry.Fields})
+	}
+	return nil
+}
+
+// decodeSortedSetFromJSON remplit les membres d'un sorted set exporté
+func decodeSortedSetFromJSON(encodedValue json.RawMessage, snapshotRecord *storage.SnapshotRecord) error {
+	var sortedSetValue []exportedSortedSetMember
+	if decodeError := json.Unmarshal(encodedValue, &sortedSetValue); decodeError != nil {
+		return decodeError
+	}
+	for _, exportedMember := range sortedSetValue {
+		memberScore, parseError := parseSortedSetScore(exportedMember.Score)
+		if parseError != nil {
+			return fmt.Errorf("membre '%s' : %v", exportedMember.Member, parseError)
+		}
+		snapshotRecord.SortedMembers = append(snapshotRecord.SortedMembers, storage.SortedSetMember{Member: exportedMember.Member, Score: memberScore})
+	}
+	return nil
+}
+
+// parseSortedSetScore lit un score de sorted set (inf et -inf acceptés, NaN refusé)
+func parseSortedSetScore(scoreText string) (float64, error) {
+	memberScore, parseError := strconv.ParseFloat(scoreText, 64)
+	if parseError != nil || math.IsNaN(memberScore) {
+		return 0, fmt.Errorf("score '%s' invalide", scoreText)
+	}
+	return memberScore, nil
+}
+
+// normalizeJSONDocument vérifie un document JSON et le réécrit sous la forme compacte des snapshots
+func normalizeJSONDocument(documentText string) (string, error) {
+	documentRoot, parseError := storage.ParseJSONValue(documentText)
+	if parseError != nil {
+		return "", parseError
+	}
+	return storage.SerializeJSONValue(documentRoot, storage.JSONFormat{}), nil
+}
+
+// importRESP rejoue un flux de commandes RESP (celles produites par "export --format resp")
+func (builder *snapshotBuilder) importRESP(inputReader io.Reader) error {
+	protocolParser := protocol.NewRedisSerializationProtocolParser(inputReader)
+	for commandIndex := 1; ; commandIndex++ {
+		commandArguments, parseError := protocolParser.ParseIncomingCommand()
+		if parseError == io.EOF {
+			return nil
+		}
+		if parseError != nil {
+			return fmt.Errorf("commande %d: %v", commandIndex, parseError)
+		}
+		if applyError := builder.applyCommand(commandArguments); applyError != nil {
+			return fmt.Errorf("commande %d: %v", commandIndex, applyError)
+		}
+	}
+}
+
+// applyCommand applique une commande d'écriture au snapshot en construction
+// Commandes acceptées : DEL, SET, RPUSH, SADD, HSET, ZADD (sans option), XADD (identifiant explicite), XDEL,
+// JSON.SET (à la racine), PEXPIRE, EXPIRE, PEXPIREAT, EXPIREAT
+func (builder *snapshotBuilder) applyCommand(commandArguments []string) error {
+	if len(commandArguments) < 2 {
+		return fmt.Errorf("commande incomplète %v", commandArguments)
+	}
+	commandName := strings.ToUpper(commandArguments[0])
+	storageKey := commandArguments[1]
+	commandValues := commandArguments[2:]
+
+	switch commandName {
+	case "DEL":
+		for _, deletedKey := range commandArguments[1:] {
+			delete(builder.snapshotRecords, deletedKey)
+		}
+		return nil
+
+	case "SET":
+		if len(commandValues) != 1 {
+			return fmt.Errorf("SET attend exactement une valeur")
+		}
+		builder.replaceRecord(&storage.SnapshotRecord{Key: storageKey, DataType: storage.RedisStringType, StringValue: commandValues[0]})
+		return nil
+
+	case "RPUSH":
+		snapshotRecord, typeError := builder.recordOfType(storageKey, storage.RedisListType)
+		if typeError != nil {
+			return typeError
+		}
+		snapshotRecord.ListElements = append(snapshotRecord.ListElements, commandValues...)
+		return nil
+
+	case "SADD":
+		snapshotRecord, typeError := builder.recordOfType(storageKey, storage.RedisSetType)
+		if typeError != nil {
+			return typeError
+		}
+		snapshotRecord.SetMembers = append(snapshotRecord.SetMembers, commandValues...)
+		return nil
+
+	case "HSET":
+		if len(commandValues) == 0 || len(commandValues)%2 != 0 {
+			return fmt.Errorf("HSET attend des paires champ valeur")
+		}
+		snapshotRecord, typeError := builder.recordOfType(storageKey, storage.RedisHashType)
+		if typeError != nil {
+			return typeError
+		}
+		if snapshotRecord.HashFields == nil {
+			snapshotRecord.HashFields = make(map[string]string)
+		}
+		for pairIndex := 0; pairIndex < len(commandValues); pairIndex += 2 {
+			snapshotRecord.HashFields[commandValues[pairIndex]] = commandValues[pairIndex+1]
+		}
+		return nil
+
+	case "ZADD":
+		if len(commandValues) == 0 || len(commandValues)%2 != 0 {
+			return fmt.Errorf("ZADD attend des paires score membre")
+		}
+		snapshotRecord, typeError := builder.recordOfType(storageKey, storage.RedisZSetType)
+		if typeError != nil {
+			return typeError
+		}
+		// Un membre répété garde son dernier score au chargement du snapshot
+		for pairIndex := 0; pairIndex < len(commandValues); pairIndex += 2 {
+			memberScore, parseError := parseSortedSetScore(commandValues[pairIndex])
+			if parseError != nil {
+				return fmt.Errorf("ZADD: %v", parseError)
+			}
+			snapshotRecord.SortedMembers = append(snapshotRecord.SortedMembers, storage.SortedSetMember{Member: commandValues[pairIndex+1], Score: memberScore})
+		}
+		return nil
+
+	case "JSON.SET":
+		if len(commandValues) != 2 || (commandValues[0] != "$" && commandValues[0] != ".") {
+			return fmt.Errorf("JSON.SET n'est accepté qu'à la racine ($ ou .), sans option")
+		}
+		documentText, documentError := normalizeJSONDocument(commandValues[1])
+		if documentError != nil {
+			return fmt.Errorf("JSON.SET: %v", documentError)
+		}
+		builder.replaceRecord(&storage.SnapshotRecord{Key: storageKey, DataType: storage.RedisJSONType, JSONDocument: documentText})
+		return nil
+
+	case "XADD":
+		if len(commandValues) < 3 || len(commandValues)%2 == 0 {
+			return fmt.Errorf("XADD attend un identifiant explicite suivi de paires champ valeur")
+		}
+		entryID, validID := storage.ParseStreamEntryID(commandValues[0], 0)
+		if !validID {
+			return fmt.Errorf("XADD: identifiant '%s' invalide (seuls les identifiants explicites sont acceptés)", commandValues[0])
+		}
+		snapshotRecord, typeError := builder.recordOfType(storageKey, storage.RedisStreamType)
+		if typeError != nil {
+			return typeError
+		}
+		if entryID == (storage.StreamEntryID{}) || entryID.Compare(snapshotRecord.StreamLastID) <= 0 {
+			return fmt.Errorf("XADD: identifiant %s inférieur ou égal au dernier identifiant du stream", entryID)
+		}
+		snapshotRecord.StreamEntries = append(snapshotRecord.StreamEntries, storage.StreamEntry{
+			EntryID:     entryID,
+			FieldValues: append([]string(nil), commandValues[1:]...),
+		})
+		snapshotRecord.StreamLastID = entryID
+		return nil
+
+	case "XDEL":
+		snapshotRecord, typeError := builder.recordOfType(storageKey, storage.RedisStreamType)
+		if typeError != nil {
+			return typeError
+		}
+		for _, entryIDText := range commandValues {
+			entryID, validID := storage.ParseStreamEntryID(entryIDText, 0)
+			if !validID {
+				return fmt.Errorf("XDEL: identifiant '%s' invalide", entryIDText)
+			}
+			for entryIndex, streamEntry := range snapshotRecord.StreamEntries {
+				if streamEntry.EntryID == entryID {
+					snapshotRecord.StreamEntries = append(snapshotRecord.StreamEntries[:entryIndex], snapshotRecord.StreamEntries[entryIndex+1:]...)
+					break
+				}
+			}
+		}
+		return nil
+
+	case "PEXPIRE", "EXPIRE", "PEXPIREAT", "EXPIREAT":
+		if len(commandValues) != 1 {
+			return fmt.Errorf("%s attend exactement une durée", commandName)
+		}
+		snapshotRecord, keyExists := builder.snapshotRecords[storageKey]
+		if !keyExists {
+			return nil
+		}
+		expirationValue, parseError := strconv.ParseInt(commandValues[0], 10, 64)
+		if parseError != nil {
+			return fmt.Errorf("%s: '%s' n'est pas un entier", commandName, commandValues[0])
+		}
+		var expirationTime time.Time
+		switch commandName {
+		case "PEXPIRE":
+			expirationTime = time.Now().Add(time.Duration(expirationValue) * time.Millisecond)
+		case "EXPIRE":
+			expirationTime = time.Now().Add(time.Duration(expirationValue) * time.Second)
+		case "PEXPIREAT":
+			expirationTime = time.UnixMilli(expirationValue)
+		case "EXPIREAT":
+			expirationTime = time.Unix(expirationValue, 0)
+		}
+		snapshotRecord.ExpirationTime = &expirationTime
+		return nil
+
+	default:
+		return fmt.Errorf("commande '%s' non supportée à l'import", commandArguments[0])
+	}
+}
+
+// recordOfType retourne la clé à compléter, créée si besoin, en vérifiant son type
+func (builder *snapshotBuilder) recordOfType(storageKey string, dataType storage.RedisDataType) (*storage.SnapshotRecord, error) {
+	if snapshotRecord, keyExists := builder.snapshotRecords[storageKey]; keyExists {
+		if snapshotRecord.DataType != dataType {
+			return nil, fmt.Errorf("clé '%s' de type %s, %s attendu", storageKey, snapshotRecord.DataType.TypeName(), dataType.TypeName())
+		}
+		return snapshotRecord, nil
+	}
+	snapshotRecord := &storage.SnapshotRecord{Key: storageKey, DataType: dataType}
+	builder.replaceRecord(snapshotRecord)
+	return snapshotRecord, nil
+}
+
+// replaceRecord ajoute ou remplace une clé
+func (builder *snapshotBuilder) replaceRecord(snapshotRecord *storage.SnapshotRecord) {
+	if _, keyExists := builder.snapshotRecords[snapshotRecord.Key]; !keyExists {
+		builder.insertionOrder = append(builder.insertionOrder, snapshotRecord.Key)
+	}
+	builder.snapshotRecords[snapshotRecord.Key] = snapshotRecord
+}
@@ -0,0 +1,46 @@
+// rdb-tool inspecte et convertit les fichiers de snapshot Redis-Go sans démarrer de serveur
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const toolUsage = `Usage : rdb-tool <commande> [options]
+
+Commandes :
+  stats  [--top N] fichier.rdb                          Statistiques : types, plus grosses clés, TTL, mémoire estimée
+  export --format json|resp [--output fichier] fichier.rdb   Export JSON ou flux de commandes RESP (stdout par défaut)
+  import --format json|resp [--compression=false] entrée sortie.rdb   Construit un snapshot depuis un export ("-" = stdin)
+  verify fichier.rdb                                    Vérifie l'intégrité du fichier (code de sortie 1 si invalide)
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, toolUsage)
+		os.Exit(2)
+	}
+
+	var commandError error
+	switch os.Args[1] {
+	case "stats":
+		commandError = runStatsCommand(os.Args[2:])
+	case "export":
+		commandError = runExportCommand(os.Args[2:])
+	case "import":
+		commandError = runImportCommand(os.Args[2:])
+	case "verify":
+		commandError = runVerifyCommand(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Print(toolUsage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "commande inconnue '%s'\n\n%s", os.Args[1], toolUsage)
+		os.Exit(2)
+	}
+
+	if commandError != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", commandError)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"redis-go/internal/persistence"
+	"redis-go/internal/storage"
+)
+
+// snapshotFileSummary décrit le fichier lu par readSnapshotFile
+type snapshotFileSummary struct {
+	FilePath    string
+	FileFormat  string // flux, flux gzip ou historique
+	CreatedAt   time.Time
+	FileBytes   int64
+	RecordCount int
+}
+
+// readSnapshotFile parcourt toutes les clés d'un fichier de snapshot, quel que soit son format
+// Le fichier est lu en flux : seule la clé courante est en mémoire (sauf format historique)
+func readSnapshotFile(snapshotPath string, visitRecord func(storage.SnapshotRecord) error) (snapshotFileSummary, error) {
+	fileSummary := snapshotFileSummary{FilePath: snapshotPath}
+
+	snapshotFile, openError := os.Open(snapshotPath)
+	if openError != nil {
+		return fileSummary, openError
+	}
+	defer snapshotFile.Close()
+
+	fileStatus, statError := snapshotFile.Stat()
+	if statError != nil {
+		return fileSummary, statError
+	}
+	fileSummary.FileBytes = fileStatus.Size()
+
+	snapshotReader, readerError := persistence.NewSnapshotReader(snapshotFile)
+	if readerError == persistence.ErrLegacySnapshotFormat {
+		return readLegacySnapshotFile(snapshotFile, fileSummary, visitRecord)
+	}
+	if readerError != nil {
+		return fileSummary, readerError
+	}
+
+	fileSummary.FileFormat = "flux"
+	if snapshotReader.Compressed {
+		fileSummary.FileFormat = "flux gzip"
+	}
+	fileSummary.CreatedAt = snapshotReader.CreatedAt
+
+	for {
+		snapshotRecord, recordError := snapshotReader.Next()
+		if recordError == io.EOF {
+			break
+		}
+		if recordError != nil {
+			return fileSummary, recordError
+		}
+		if visitError := visitRecord(snapshotRecord); visitError != nil {
+			return fileSummary, visitError
+		}
+	}
+	fileSummary.RecordCount = snapshotReader.RecordCount()
+	return fileSummary, nil
+}
+
+// readLegacySnapshotFile relit un fichier écrit en un seul bloc gob par les premières versions du serveur
+func readLegacySnapshotFile(snapshotFile *os.File, fileSummary snapshotFileSummary, visitRecord func(storage.SnapshotRecord) error) (snapshotFileSummary, error) {
+	if _, seekError := snapshotFile.Seek(0, io.SeekStart); seekError != nil {
+		return fileSummary, seekError
+	}
+
+	var legacySnapshot storage.StorageSnapshot
+	if decodeError := gob.NewDecoder(bufio.NewReader(snapshotFile)).Decode(&legacySnapshot); decodeError != nil {
+		return fileSummary, fmt.Errorf("fichier illisible (ni flux ni format historique): %v", decodeError)
+	}
+	fileSummary.FileFormat = "historique"
+	fileSummary.CreatedAt = legacySnapshot.Timestamp
+
+	snapshotKeys := make([]string, 0, len(legacySnapshot.Data))
+	for storageKey := range legacySnapshot.Data {
+		snapshotKeys = append(snapshotKeys, storageKey)
+	}
+	sort.Strings(snapshotKeys)
+
+	for _, storageKey := range snapshotKeys {
+		if visitError := visitRecord(storage.NewSnapshotRecord(storageKey, legacySnapshot.Data[storageKey])); visitError != nil {
+			return fileSummary, visitError
+		}
+		fileSummary.RecordCount++
+	}
+	return fileSummary, nil
+}
+
+// writeSnapshotFile écrit des enregistrements dans un nouveau fichier de snapshot (remplacement atomique)
+func writeSnapshotFile(snapshotPath string, compressed bool, snapshotRecords []storage.SnapshotRecord) (*persistence.SnapshotWriter, error) {
+	tempFilePath := snapshotPath + ".tmp"
+	snapshotFile, createError := os.Create(tempFilePath)
+	if createError != nil {
+		return nil, createError
+	}
+	defer snapshotFile.Close()
+
+	snapshotWriter, writerError := persistence.NewSnapshotWriter(snapshotFile, time.Now(), compressed)
+	if writerError == nil {
+		for _, snapshotRecord := range snapshotRecords {
+			if writerError = snapshotWriter.WriteRecord(snapshotRecord); writerError != nil {
+				break
+			}
+		}
+	}
+	if writerError == nil {
+		writerError = snapshotWriter.Close()
+	}
+	if writerError == nil {
+		writerError = snapshotFile.Sync()
+	}
+	if writerError != nil {
+		os.Remove(tempFilePath)
+		return nil, writerError
+	}
+
+	snapshotFile.Close()
+	if renameError := os.Rename(tempFilePath, snapshotPath); renameError != nil {
+		os.Remove(tempFilePath)
+		return nil, renameError
+	}
+	return snapshotWriter, nil
+}
+
+// estimateRecordMemory estime l'occupation mémoire d'une clé une fois chargée dans le serveur
+// Approximation : taille des chaînes plus un surcoût fixe par entrée de map / élément de slice
+func estimateRecordMemory(snapshotRecord storage.SnapshotRecord) int64 {
+	const keyOverhead = 96    // Entrée de la map principale + RedisStorageValue
+	const stringOverhead = 16 // En-tête d'une chaîne Go
+	const mapEntryOverhead = 48
+
+	estimatedBytes := int64(keyOverhead + len(snapshotRecord.Key))
+	if snapshotRecord.ExpirationTime != nil {
+		estimatedBytes += 24
+	}
+
+	switch snapshotRecord.DataType {
+	case storage.RedisListType:
+		for _, listElement := range snapshotRecord.ListElements {
+			estimatedBytes += int64(stringOverhead + len(listElement))
+		}
+	case storage.RedisSetType:
+		for _, setMember := range snapshotRecord.SetMembers {
+			estimatedBytes += int64(mapEntryOverhead + len(setMember))
+		}
+	case storage.RedisHashType:
+		for fieldName, fieldValue := range snapshotRecord.HashFields {
+			estimatedBytes += int64(mapEntryOverhead + len(fieldName) + len(fieldValue))
+		}
+	case storage.RedisStreamType:
+		const streamEntryOverhead = 40 // Identifiant + en-tête du tableau de champs
+		for _, streamEntry := range snapshotRecord.StreamEntries {
+			estimatedBytes += streamEntryOverhead
+			for _, fieldOrValue := range streamEntry.FieldValues {
+				estimatedBytes += int64(stringOverhead + len(fieldOrValue))
+			}
+		}
+	case storage.RedisZSetType:
+		const sortedMemberOverhead = 24 // Score dans la map et entrée du tableau trié
+		for _, sortedSetMember := range snapshotRecord.SortedMembers {
+			estimatedBytes += int64(mapEntryOverhead + sortedMemberOverhead + len(sortedSetMember.Member))
+		}
+	case storage.RedisJSONType:
+		const jsonNodeExpansion = 3 // Nœuds, tableaux et maps d'objets pèsent environ trois fois le texte compact
+		estimatedBytes += int64(jsonNodeExpansion * len(snapshotRecord.JSONDocument))
+	default:
+		estimatedBytes += int64(stringOverhead + len(snapshotRecord.StringValue))
+	}
+	return estimatedBytes
+}
+
+// recordLength retourne le nombre d'éléments d'une clé (longueur pour une chaîne ou un document JSON)
+func recordLength(snapshotRecord storage.SnapshotRecord) int {
+	switch snapshotRecord.DataType {
+	case storage.RedisListType:
+		return len(snapshotRecord.ListElements)
+	case storage.RedisSetType:
+		return len(snapshotRecord.SetMembers)
+	case storage.RedisHashType:
+		return len(snapshotRecord.HashFields)
+	case storage.RedisStreamType:
+		return len(snapshotRecord.StreamEntries)
+	case storage.RedisZSetType:
+		return len(snapshotRecord.SortedMembers)
+	case storage.RedisJSONType:
+		return len(snapshotRecord.JSONDocument)
+	default:
+		return len(snapshotRecord.StringValue)
+	}
+}
+
+// supportedDataTypes liste les types que peut contenir un snapshot, dans l'ordre d'affichage
+var supportedDataTypes = []storage.RedisDataType{storage.RedisStringType, storage.RedisListType, storage.RedisSetType, storage.RedisHashType, storage.RedisZSetType, storage.RedisStreamType, storage.RedisJSONType}
+
+// parseDataTypeName convertit un nom de type (string, list, set, hash, zset, stream, ReJSON-RL) en type de stockage
+func parseDataTypeName(typeName string) (storage.RedisDataType, error) {
+	for _, dataType := range supportedDataTypes {
+		if dataType.TypeName() == typeName {
+			return dataType, nil
+		}
+	}
+	return 0, fmt.Errorf("type '%s' non supporté", typeName)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+
+	"redis-go/internal/storage"
+)
+
+// ttlBucket est une tranche de la distribution des TTL
+type ttlBucket struct {
+	bucketLabel string
+	upperBound  time.Duration // Borne exclusive (0 = sans limite)
+}
+
+// ttlBuckets liste les tranches affichées par "stats", dans l'ordre
+var ttlBuckets = []ttlBucket{
+	{"< 1 minute", time.Minute},
+	{"< 1 heure", time.Hour},
+	{"< 1 jour", 24 * time.Hour},
+	{"< 7 jours", 7 * 24 * time.Hour},
+	{">= 7 jours", 0},
+}
+
+// keySizeEntry décrit une clé du classement des plus grosses clés
+type keySizeEntry struct {
+	storageKey      string
+	dataType        storage.RedisDataType
+	elementCount    int
+	estimatedMemory int64
+}
+
+// snapshotStatistics accumule les statistiques d'un fichier clé par clé
+type snapshotStatistics struct {
+	keysByType       map[storage.RedisDataType]int
+	memoryByType     map[storage.RedisDataType]int64
+	keysWithoutTTL   int
+	expiredKeys      int
+	keysByTTLBucket  []int
+	biggestKeys      []keySizeEntry
+	biggestKeysLimit int
+	referenceTime    time.Time
+}
+
+// runStatsCommand implémente "rdb-tool stats [--top N] fichier.rdb"
+func runStatsCommand(commandArguments []string) error {
+	flagSet := flag.NewFlagSet("stats", flag.ContinueOnError)
+	biggestKeysLimit := flagSet.Int("top", 10, "nombre de plus grosses clés affichées")
+	if parseError := flagSet.Parse(commandArguments); parseError != nil {
+		return parseError
+	}
+	if flagSet.NArg() != 1 {
+		return fmt.Errorf("usage : rdb-tool stats [--top N] fichier.rdb")
+	}
+
+	statistics := &snapshotStatistics{
+		keysByType:       make(map[storage.RedisDataType]int),
+		memoryByType:     make(map[storage.RedisDataType]int64),
+		keysByTTLBucket:  make([]int, len(ttlBuckets)),
+		biggestKeysLimit: *biggestKeysLimit,
+		referenceTime:    time.Now(),
+	}
+
+	fileSummary, readError := readSnapshotFile(flagSet.Arg(0), func(snapshotRecord storage.SnapshotRecord) error {
+		statistics.addRecord(snapshotRecord)
+		return nil
+	})
+	if readError != nil {
+		return fmt.Errorf("%s: %v", flagSet.Arg(0), readError)
+	}
+
+	statistics.printReport(fileSummary)
+	return nil
+}
+
+// addRecord comptabilise une clé
+func (statistics *snapshotStatistics) addRecord(snapshotRecord storage.SnapshotRecord) {
+	estimatedMemory := estimateRecordMemory(snapshotRecord)
+	statistics.keysByType[snapshotRecord.DataType]++
+	statistics.memoryByType[snapshotRecord.DataType] += estimatedMemory
+
+	switch {
+	case snapshotRecord.ExpirationTime == nil:
+		statistics.keysWithoutTTL++
+	case !snapshotRecord.ExpirationTime.After(statistics.referenceTime):
+		statistics.expiredKeys++
+	default:
+		remainingTTL := snapshotRecord.ExpirationTime.Sub(statistics.referenceTime)
+		for bucketIndex, bucket := range ttlBuckets {
+			if bucket.upperBound == 0 || remainingTTL < bucket.upperBound {
+				statistics.keysByTTLBucket[bucketIndex]++
+				break
+			}
+		}
+	}
+
+	statistics.trackBiggestKey(keySizeEntry{
+		storageKey:      snapshotRecord.Key,
+		dataType:        snapshotRecord.DataType,
+		elementCount:    recordLength(snapshotRecord),
+		estimatedMemory: estimatedMemory,
+	})
+}
+
+// trackBiggestKey garde les N plus grosses clés, triées par mémoire estimée décroissante
+func (statistics *snapshotStatistics) trackBiggestKey(keyEntry keySizeEntry) {
+	if statistics.biggestKeysLimit <= 0 {
+		return
+	}
+	if len(statistics.biggestKeys) == statistics.biggestKeysLimit &&
+		keyEntry.estimatedMemory <= statistics.biggestKeys[len(statistics.biggestKeys)-1].estimatedMemory {
+		return
+	}
+
+	insertIndex := sort.Search(len(statistics.biggestKeys), func(entryIndex int) bool {
+		return statistics.biggestKeys[entryIndex].estimatedMemory < keyEntry.estimatedMemory
+	})
+	statistics.biggestKeys = append(statistics.biggestKeys, keySizeEntry{})
+	copy(statistics.biggestKeys[insertIndex+1:], statistics.biggestKeys[insertIndex:])
+	statistics.biggestKeys[insertIndex] = keyEntry
+
+	if len(statistics.biggestKeys) > statistics.biggestKeysLimit {
+		statistics.biggestKeys = statistics.biggestKeys[:statistics.biggestKeysLimit]
+	}
+}
+
+// printReport affiche le rapport sur la sortie standard
+func (statistics *snapshotStatistics) printReport(fileSummary snapshotFileSummary) {
+	fmt.Printf("📄 Fichier      : %s\n", fileSummary.FilePath)
+	fmt.Printf("   Format       : %s\n", fileSummary.FileFormat)
+	fmt.Printf("   Créé le      : %s\n", fileSummary.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("   Taille       : %d octets\n", fileSummary.FileBytes)
+	fmt.Printf("   Clés         : %d\n\n", fileSummary.RecordCount)
+
+	var totalMemory int64
+	fmt.Println("🔑 Clés par type")
+	for _, dataType := range supportedDataTypes {
+		fmt.Printf("   %-9s %9d clés   %12d octets estimés\n",
+			dataType.TypeName(), statistics.keysByType[dataType], statistics.memoryByType[dataType])
+		totalMemory += statistics.memoryByType[dataType]
+	}
+	fmt.Printf("\n🧠 Mémoire estimée une fois chargé : %d octets (%.1f Mo)\n\n", totalMemory, float64(totalMemory)/(1024*1024))
+
+	fmt.Println("⏱️  Distribution des TTL")
+	fmt.Printf("   %-12s %10d\n", "sans TTL", statistics.keysWithoutTTL)
+	for bucketIndex, bucket := range ttlBuckets {
+		fmt.Printf("   %-12s %10d\n", bucket.bucketLabel, statistics.keysByTTLBucket[bucketIndex])
+	}
+	fmt.Printf("   %-12s %10d\n\n", "expirées", statistics.expiredKeys)
+
+	if len(statistics.biggestKeys) > 0 {
+		fmt.Printf("📦 Plus grosses clés (top %d)\n", len(statistics.biggestKeys))
+		for _, keyEntry := range statistics.biggestKeys {
+			fmt.Printf("   %12d octets  %-9s  longueur %-8d %q\n",
+				keyEntry.estimatedMemory, keyEntry.dataType.TypeName(), keyEntry.elementCount, keyEntry.storageKey)
+		}
+	}
+}
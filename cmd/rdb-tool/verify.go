@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"redis-go/internal/storage"
+)
+
+// runVerifyCommand implémente "rdb-tool verify fichier.rdb"
+// Le fichier est relu en entier : en-tête, chaque clé, marqueur de fin et nombre de clés annoncé
+func runVerifyCommand(commandArguments []string) error {
+	if len(commandArguments) != 1 {
+		return fmt.Errorf("usage : rdb-tool verify fichier.rdb")
+	}
+	snapshotPath := commandArguments[0]
+
+	seenKeys := make(map[string]bool)
+	expiredKeys := 0
+	verificationTime := time.Now()
+
+	fileSummary, readError := readSnapshotFile(snapshotPath, func(snapshotRecord storage.SnapshotRecord) error {
+		if seenKeys[snapshotRecord.Key] {
+			return fmt.Errorf("clé '%s' présente plusieurs fois", snapshotRecord.Key)
+		}
+		seenKeys[snapshotRecord.Key] = true
+
+		if _, typeError := parseDataTypeName(snapshotRecord.DataType.TypeName()); typeError != nil {
+			return fmt.Errorf("clé '%s': type %d inconnu", snapshotRecord.Key, snapshotRecord.DataType)
+		}
+		if snapshotRecord.ExpirationTime != nil && !snapshotRecord.ExpirationTime.After(verificationTime) {
+			expiredKeys++
+		}
+		return nil
+	})
+	if readError != nil {
+		return fmt.Errorf("%s invalide: %v", snapshotPath, readError)
+	}
+
+	fmt.Printf("✅ %s valide (%s, %d clés dont %d déjà expirées, %d octets, créé le %s)\n",
+		snapshotPath, fileSummary.FileFormat, fileSummary.RecordCount, expiredKeys,
+		fileSummary.FileBytes, fileSummary.CreatedAt.Format("2006-01-02 15:04:05"))
+	return nil
+}
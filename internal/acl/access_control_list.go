@@ -0,0 +1,273 @@
+package acl
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// AccessControlList contient tous les utilisateurs ACL du serveur
+type AccessControlList struct {
+	registeredUsers   map[string]*AccessControlUser
+	commandCategories map[string][]string
+	aclFilePath       string
+	securityLog       *SecurityLog
+	listMutex         sync.RWMutex
+}
+
+// NewAccessControlList crée la liste ACL avec l'utilisateur "default" (tous les droits, sans mot de passe)
+// commandCategories associe chaque commande (CMD ou CMD|SOUS) à ses catégories
+func NewAccessControlList(commandCategories map[string][]string, aclFilePath string) *AccessControlList {
+	accessControlList := &AccessControlList{
+		registeredUsers:   make(map[string]*AccessControlUser),
+		commandCategories: commandCategories,
+		aclFilePath:       aclFilePath,
+		securityLog:       newSecurityLog(),
+	}
+
+	accessControlList.registeredUsers["default"] = accessControlList.newDefaultUser()
+	return accessControlList
+}
+
+// newDefaultUser construit l'utilisateur "default" initial
+func (accessControlList *AccessControlList) newDefaultUser() *AccessControlUser {
+	defaultUser := newAccessControlUser("default")
+	for _, defaultRule := range []string{"on", "nopass", "allkeys", "allchannels", "+@all"} {
+		defaultUser.applyRule(defaultRule, accessControlList.commandCategories)
+	}
+	return defaultUser
+}
+
+// cloneUser copie un utilisateur pour appliquer des règles sans modifier l'original
+func cloneUser(aclUser *AccessControlUser) *AccessControlUser {
+	clonedUser := &AccessControlUser{
+		UserName:        aclUser.UserName,
+		Enabled:         aclUser.Enabled,
+		NoPassword:      aclUser.NoPassword,
+		passwordHashes:  make(map[string]bool, len(aclUser.passwordHashes)),
+		allowedCommands: make(map[string]bool, len(aclUser.allowedCommands)),
+		commandRules:    append([]string(nil), aclUser.commandRules...),
+		keyPatterns:     append([]KeyPattern(nil), aclUser.keyPatterns...),
+		channelPatterns: append([]string(nil), aclUser.channelPatterns...),
+		allChannels:     aclUser.allChannels,
+	}
+	for passwordHash := range aclUser.passwordHashes {
+		clonedUser.passwordHashes[passwordHash] = true
+	}
+	for commandName := range aclUser.allowedCommands {
+		clonedUser.allowedCommands[commandName] = true
+	}
+	return clonedUser
+}
+
+// SetUser crée ou modifie un utilisateur (ACL SETUSER)
+// Les règles sont appliquées sur une copie : en cas d'erreur l'utilisateur reste inchangé
+func (accessControlList *AccessControlList) SetUser(userName string, aclRules []string) error {
+	accessControlList.listMutex.Lock()
+	defer accessControlList.listMutex.Unlock()
+
+	var modifiedUser *AccessControlUser
+	if existingUser, userExists := accessControlList.registeredUsers[userName]; userExists {
+		modifiedUser = cloneUser(existingUser)
+	} else {
+		modifiedUser = newAccessControlUser(userName)
+	}
+
+	for _, aclRule := range aclRules {
+		if ruleError := modifiedUser.applyRule(aclRule, accessControlList.commandCategories); ruleError != nil {
+			return ruleError
+		}
+	}
+
+	accessControlList.registeredUsers[userName] = modifiedUser
+	return nil
+}
+
+// GetUser retourne un utilisateur ou nil s'il n'existe pas
+// L'utilisateur retourné n'est jamais modifié (copy-on-write dans SetUser)
+func (accessControlList *AccessControlList) GetUser(userName string) *AccessControlUser {
+	accessControlList.listMutex.RLock()
+	defer accessControlList.listMutex.RUnlock()
+	return accessControlList.registeredUsers[userName]
+}
+
+// DeleteUsers supprime des utilisateurs et retourne le nombre supprimé
+func (accessControlList *AccessControlList) DeleteUsers(userNames []string) (int, error) {
+	accessControlList.listMutex.Lock()
+	defer accessControlList.listMutex.Unlock()
+
+	for _, userName := range userNames {
+		if userName == "default" {
+			return 0, fmt.Errorf("l'utilisateur 'default' ne peut pas être supprimé")
+		}
+	}
+
+	deletedUserCount := 0
+	for _, userName := range userNames {
+		if _, userExists := accessControlList.registeredUsers[userName]; userExists {
+			delete(accessControlList.registeredUsers, userName)
+			deletedUserCount++
+		}
+	}
+	return deletedUserCount, nil
+}
+
+// ListUsers retourne tous les utilisateurs triés par nom
+func (accessControlList *AccessControlList) ListUsers() []*AccessControlUser {
+	accessControlList.listMutex.RLock()
+	defer accessControlList.listMutex.RUnlock()
+
+	registeredUsers := make([]*AccessControlUser, 0, len(accessControlList.registeredUsers))
+	for _, aclUser := range accessControlList.registeredUsers {
+		registeredUsers = append(registeredUsers, aclUser)
+	}
+	sort.Slice(registeredUsers, func(firstIndex, secondIndex int) bool {
+		return registeredUsers[firstIndex].UserName < registeredUsers[secondIndex].UserName
+	})
+	return registeredUsers
+}
+
+// Authenticate vérifie les identifiants d'un utilisateur actif
+func (accessControlList *AccessControlList) Authenticate(userName string, candidatePassword string) bool {
+	aclUser := accessControlList.GetUser(userName)
+	if aclUser == nil || !aclUser.Enabled {
+		// Hachage factice pour garder un temps de réponse comparable
+		hashPassword(candidatePassword)
+		return false
+	}
+	return aclUser.CheckPassword(candidatePassword)
+}
+
+// SetDefaultUserPassword applique requirepass sur l'utilisateur "default" (vide = nopass)
+func (accessControlList *AccessControlList) SetDefaultUserPassword(requiredPassword string) {
+	if requiredPassword == "" {
+		accessControlList.SetUser("default", []string{"nopass"})
+		return
+	}
+	accessControlList.SetUser("default", []string{"resetpass", ">" + requiredPassword})
+}
+
+// DefaultUserRequiresAuthentication indique si une nouvelle connexion doit s'authentifier
+func (accessControlList *AccessControlList) DefaultUserRequiresAuthentication() bool {
+	defaultUser := accessControlList.GetUser("default")
+	return defaultUser == nil || !defaultUser.Enabled || !defaultUser.NoPassword
+}
+
+// ListCategories retourne toutes les catégories connues, triées
+func (accessControlList *AccessControlList) ListCategories() []string {
+	knownCategories := make(map[string]bool)
+	for _, categories := range accessControlList.commandCategories {
+		for _, category := range categories {
+			knownCategories[category] = true
+		}
+	}
+
+	sortedCategories := make([]string, 0, len(knownCategories))
+	for category := range knownCategories {
+		sortedCategories = append(sortedCategories, category)
+	}
+	sort.Strings(sortedCategories)
+	return sortedCategories
+}
+
+// ListCommandsInCategory retourne les commandes d'une catégorie, triées (nil si catégorie inconnue)
+func (accessControlList *AccessControlList) ListCommandsInCategory(categoryName string) []string {
+	var categoryCommands []string
+	for commandName, categories := range accessControlList.commandCategories {
+		if containsCategory(categories, categoryName) {
+			categoryCommands = append(categoryCommands, strings.ToLower(commandName))
+		}
+	}
+	sort.Strings(categoryCommands)
+	return categoryCommands
+}
+
+// GetSecurityLog retourne le journal des refus (ACL LOG)
+func (accessControlList *AccessControlList) GetSecurityLog() *SecurityLog {
+	return accessControlList.securityLog
+}
+
+// LoadFromFile recharge tous les utilisateurs depuis le fichier ACL (ACL LOAD)
+// Le fichier est validé entièrement avant de remplacer les utilisateurs existants
+func (accessControlList *AccessControlList) LoadFromFile() error {
+	if accessControlList.aclFilePath == "" {
+		return fmt.Errorf("aucun fichier ACL configuré")
+	}
+
+	aclFile, openError := os.Open(accessControlList.aclFilePath)
+	if openError != nil {
+		return fmt.Errorf("ouverture fichier ACL: %v", openError)
+	}
+	defer aclFile.Close()
+
+	loadedList := &AccessControlList{
+		registeredUsers:   make(map[string]*AccessControlUser),
+		commandCategories: accessControlList.commandCategories,
+	}
+
+	lineScanner := bufio.NewScanner(aclFile)
+	lineNumber := 0
+	for lineScanner.Scan() {
+		lineNumber++
+		trimmedLine := strings.TrimSpace(lineScanner.Text())
+		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
+			continue
+		}
+
+		lineFields := strings.Fields(trimmedLine)
+		if len(lineFields) < 2 || lineFields[0] != "user" {
+			return fmt.Errorf("%s:%d: ligne invalide, attendu 'user <nom> [règles ...]'", accessControlList.aclFilePath, lineNumber)
+		}
+		if _, alreadyDefined := loadedList.registeredUsers[lineFields[1]]; alreadyDefined {
+			return fmt.Errorf("%s:%d: utilisateur '%s' défini plusieurs fois", accessControlList.aclFilePath, lineNumber, lineFields[1])
+		}
+		if ruleError := loadedList.SetUser(lineFields[1], lineFields[2:]); ruleError != nil {
+			return fmt.Errorf("%s:%d: %v", accessControlList.aclFilePath, lineNumber, ruleError)
+		}
+	}
+	if scanError := lineScanner.Err(); scanError != nil {
+		return fmt.Errorf("lecture fichier ACL: %v", scanError)
+	}
+
+	// Sans définition explicite, l'utilisateur default garde ses droits par défaut
+	if _, defaultDefined := loadedList.registeredUsers["default"]; !defaultDefined {
+		loadedList.registeredUsers["default"] = accessControlList.newDefaultUser()
+	}
+
+	accessControlList.listMutex.Lock()
+	accessControlList.registeredUsers = loadedList.registeredUsers
+	accessControlList.listMutex.Unlock()
+	return nil
+}
+
+// SaveToFile écrit tous les utilisateurs dans le fichier ACL (ACL SAVE)
+func (accessControlList *AccessControlList) SaveToFile() error {
+	if accessControlList.aclFilePath == "" {
+		return fmt.Errorf("aucun fichier ACL configuré")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(accessControlList.aclFilePath), 0755); err != nil {
+		return fmt.Errorf("création dossier: %v", err)
+	}
+
+	// Écriture dans un fichier temporaire puis remplacement atomique
+	tempFilePath := accessControlList.aclFilePath + ".tmp"
+	var fileContent strings.Builder
+	for _, aclUser := range accessControlList.ListUsers() {
+		fileContent.WriteString(aclUser.DescribeUser())
+		fileContent.WriteString("\n")
+	}
+
+	if err := os.WriteFile(tempFilePath, []byte(fileContent.String()), 0600); err != nil {
+		return fmt.Errorf("écriture fichier ACL: %v", err)
+	}
+	if err := os.Rename(tempFilePath, accessControlList.aclFilePath); err != nil {
+		os.Remove(tempFilePath)
+		return fmt.Errorf("remplacement fichier ACL: %v", err)
+	}
+	return nil
+}
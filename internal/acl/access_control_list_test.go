@@ -0,0 +1,259 @@
+package acl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testCommandCategories est un extrait des catégories de commandes du serveur
+var testCommandCategories = map[string][]string{
+	"GET":            {"read", "string", "fast"},
+	"SET":            {"write", "string", "slow"},
+	"DEL":            {"write", "keyspace", "slow"},
+	"FLUSHALL":       {"write", "keyspace", "dangerous", "slow"},
+	"CLIENT":         {"slow", "connection"},
+	"CLIENT|LIST":    {"admin", "slow", "dangerous", "connection"},
+	"CLIENT|SETNAME": {"slow", "connection"},
+}
+
+// newTestUser crée un utilisateur en appliquant les règles données
+func newTestUser(t *testing.T, aclRules ...string) *AccessControlUser {
+	t.Helper()
+	accessControlList := NewAccessControlList(testCommandCategories, "")
+	if setError := accessControlList.SetUser("alice", aclRules); setError != nil {
+		t.Fatalf("SetUser(%v): %v", aclRules, setError)
+	}
+	return accessControlList.GetUser("alice")
+}
+
+func TestDefaultUserHasEveryPermission(t *testing.T) {
+	defaultUser := NewAccessControlList(testCommandCategories, "").GetUser("default")
+	if expectedDescription := "user default on nopass ~* &* +@all"; defaultUser.DescribeUser() != expectedDescription {
+		t.Fatalf("default décrit %q, attendu %q", defaultUser.DescribeUser(), expectedDescription)
+	}
+	for commandName := range testCommandCategories {
+		if !defaultUser.IsCommandAllowed(commandName) {
+			t.Errorf("%s refusée à default", commandName)
+		}
+	}
+	if !defaultUser.IsKeyAllowed("n'importe:quoi", KeyPermissionReadWrite) || !defaultUser.CheckPassword("") {
+		t.Fatalf("default doit accéder à toutes les clés sans mot de passe")
+	}
+}
+
+func TestKeyPatternRules(t *testing.T) {
+	testCases := []struct {
+		name               string
+		aclRules           []string
+		storageKey         string
+		requiredPermission KeyPermission
+		expectedAllowed    bool
+	}{
+		{"aucun motif", nil, "a", KeyPermissionRead, false},
+		{"motif lecture-écriture", []string{"~app:*"}, "app:1", KeyPermissionReadWrite, true},
+		{"motif non correspondant", []string{"~app:*"}, "autre:1", KeyPermissionRead, false},
+		{"%R autorise la lecture", []string{"%R~cache:*"}, "cache:1", KeyPermissionRead, true},
+		{"%R refuse l'écriture", []string{"%R~cache:*"}, "cache:1", KeyPermissionWrite, false},
+		{"%W autorise l'écriture", []string{"%W~log:*"}, "log:1", KeyPermissionWrite, true},
+		{"%W refuse la lecture", []string{"%W~log:*"}, "log:1", KeyPermissionRead, false},
+		{"%RW équivaut à ~", []string{"%RW~x"}, "x", KeyPermissionReadWrite, true},
+		{"minuscules acceptées", []string{"%rw~x"}, "x", KeyPermissionReadWrite, true},
+		{"%R puis %W ne donnent pas RW sur une même clé", []string{"%R~k", "%W~k"}, "k", KeyPermissionReadWrite, false},
+		{"allkeys", []string{"allkeys"}, "k", KeyPermissionReadWrite, true},
+		{"resetkeys", []string{"allkeys", "resetkeys"}, "k", KeyPermissionRead, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			aclUser := newTestUser(t, testCase.aclRules...)
+			if keyAllowed := aclUser.IsKeyAllowed(testCase.storageKey, testCase.requiredPermission); keyAllowed != testCase.expectedAllowed {
+				t.Fatalf("IsKeyAllowed(%q, %d) = %v, attendu %v", testCase.storageKey, testCase.requiredPermission, keyAllowed, testCase.expectedAllowed)
+			}
+		})
+	}
+}
+
+func TestCommandRules(t *testing.T) {
+	testCases := []struct {
+		name             string
+		aclRules         []string
+		allowedCommands  []string
+		rejectedCommands []string
+	}{
+		{"aucune règle", nil, nil, []string{"GET", "SET"}},
+		{"commande seule", []string{"+get"}, []string{"GET"}, []string{"SET"}},
+		{"catégorie", []string{"+@write"}, []string{"SET", "DEL", "FLUSHALL"}, []string{"GET"}},
+		{"catégorie moins une commande", []string{"+@write", "-flushall"}, []string{"SET", "DEL"}, []string{"FLUSHALL"}},
+		{"tout sauf une catégorie", []string{"+@all", "-@dangerous"}, []string{"GET", "SET", "CLIENT|SETNAME"}, []string{"FLUSHALL", "CLIENT|LIST"}},
+		{"commande avec sous-commandes", []string{"+client"}, []string{"CLIENT", "CLIENT|LIST", "CLIENT|SETNAME"}, nil},
+		{"sous-commande seule", []string{"+client|setname"}, []string{"CLIENT|SETNAME"}, []string{"CLIENT", "CLIENT|LIST"}},
+		{"allcommands puis nocommands", []string{"allcommands", "nocommands"}, nil, []string{"GET"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			aclUser := newTestUser(t, testCase.aclRules...)
+			for _, commandName := range testCase.allowedCommands {
+				if !aclUser.IsCommandAllowed(commandName) {
+					t.Errorf("%s refusée", commandName)
+				}
+			}
+			for _, commandName := range testCase.rejectedCommands {
+				if aclUser.IsCommandAllowed(commandName) {
+					t.Errorf("%s autorisée", commandName)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidRulesLeaveUserUnchanged(t *testing.T) {
+	testCases := []struct {
+		invalidRule   string
+		expectedError string
+	}{
+		{"+@inconnue", "catégorie ACL inconnue"},
+		{"+inconnue", "commande inconnue"},
+		{"%X~k", "permission de clé inconnue"},
+		{"%~k", "règle de clé invalide"},
+		{"#abc", "empreinte SHA-256 invalide"},
+		{"nimporte", "règle ACL inconnue"},
+	}
+
+	for _, testCase := range testCases {
+		accessControlList := NewAccessControlList(testCommandCategories, "")
+		accessControlList.SetUser("alice", []string{"on", ">secret", "~a:*", "+get"})
+		descriptionBefore := accessControlList.GetUser("alice").DescribeUser()
+
+		setError := accessControlList.SetUser("alice", []string{"+set", testCase.invalidRule})
+		if setError == nil || !strings.Contains(setError.Error(), testCase.expectedError) {
+			t.Errorf("%q: %v, attendu une erreur contenant %q", testCase.invalidRule, setError, testCase.expectedError)
+		}
+		if descriptionAfter := accessControlList.GetUser("alice").DescribeUser(); descriptionAfter != descriptionBefore {
+			t.Errorf("%q: utilisateur modifié malgré l'erreur : %q, attendu %q", testCase.invalidRule, descriptionAfter, descriptionBefore)
+		}
+	}
+}
+
+func TestPasswordRulesAndAuthentication(t *testing.T) {
+	accessControlList := NewAccessControlList(testCommandCategories, "")
+	accessControlList.SetUser("alice", []string{"on", ">premier", ">second"})
+	accessControlList.SetUser("bob", []string{"off", ">secret"})
+
+	testCases := []struct {
+		userName              string
+		candidatePassword     string
+		expectedAuthenticated bool
+	}{
+		{"alice", "premier", true},
+		{"alice", "second", true},
+		{"alice", "autre", false},
+		{"bob", "secret", false},
+		{"inconnu", "secret", false},
+	}
+	for _, testCase := range testCases {
+		if authenticated := accessControlList.Authenticate(testCase.userName, testCase.candidatePassword); authenticated != testCase.expectedAuthenticated {
+			t.Errorf("Authenticate(%s, %s) = %v, attendu %v", testCase.userName, testCase.candidatePassword, authenticated, testCase.expectedAuthenticated)
+		}
+	}
+
+	accessControlList.SetUser("alice", []string{"<premier", "#" + hashPassword("troisieme")})
+	if accessControlList.Authenticate("alice", "premier") || !accessControlList.Authenticate("alice", "troisieme") {
+		t.Fatalf("<premier doit retirer le mot de passe et #empreinte l'ajouter")
+	}
+
+	accessControlList.SetDefaultUserPassword("motdepasse")
+	if !accessControlList.DefaultUserRequiresAuthentication() || !accessControlList.Authenticate("default", "motdepasse") {
+		t.Fatalf("requirepass doit imposer le mot de passe à default")
+	}
+	accessControlList.SetDefaultUserPassword("")
+	if accessControlList.DefaultUserRequiresAuthentication() {
+		t.Fatalf("requirepass vide doit rendre default accessible sans mot de passe")
+	}
+}
+
+func TestDescribeUserFormatsRulesForACLList(t *testing.T) {
+	testCases := []struct {
+		name                string
+		aclRules            []string
+		expectedDescription string
+	}{
+		{"utilisateur vide", nil, "user alice off resetchannels -@all"},
+		{"motifs sélectifs", []string{"on", "nopass", "~a:*", "%R~b:*", "%W~c:*", "+get"}, "user alice on nopass ~a:* %R~b:* %W~c:* resetchannels +get"},
+		{"canaux conservés", []string{"&news:*", "&alertes"}, "user alice off &news:* &alertes -@all"},
+		{"allchannels", []string{"&news:*", "allchannels", "&ignoré"}, "user alice off &* -@all"},
+		{"+@all remplace l'historique", []string{"+get", "-set", "+@all"}, "user alice off resetchannels +@all"},
+		{"reset", []string{"on", ">x", "~*", "+@all", "reset"}, "user alice off resetchannels -@all"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			aclUser := newTestUser(t, testCase.aclRules...)
+			if description := aclUser.DescribeUser(); description != testCase.expectedDescription {
+				t.Fatalf("DescribeUser = %q, attendu %q", description, testCase.expectedDescription)
+			}
+		})
+	}
+}
+
+func TestSaveAndLoadFileKeepUsers(t *testing.T) {
+	aclFilePath := filepath.Join(t.TempDir(), "users.acl")
+	savedList := NewAccessControlList(testCommandCategories, aclFilePath)
+	savedList.SetUser("alice", []string{"on", ">secret", "%R~cache:*", "&news:*", "+@read", "-get"})
+	savedList.SetUser("bob", []string{"off", "nopass", "allkeys", "+client|setname"})
+	if saveError := savedList.SaveToFile(); saveError != nil {
+		t.Fatalf("SaveToFile: %v", saveError)
+	}
+
+	loadedList := NewAccessControlList(testCommandCategories, aclFilePath)
+	if loadError := loadedList.LoadFromFile(); loadError != nil {
+		t.Fatalf("LoadFromFile: %v", loadError)
+	}
+	describeUsers := func(accessControlList *AccessControlList) []string {
+		var userDescriptions []string
+		for _, aclUser := range accessControlList.ListUsers() {
+			userDescriptions = append(userDescriptions, aclUser.DescribeUser())
+		}
+		return userDescriptions
+	}
+	if !reflect.DeepEqual(describeUsers(loadedList), describeUsers(savedList)) {
+		t.Fatalf("utilisateurs rechargés %v, attendu %v", describeUsers(loadedList), describeUsers(savedList))
+	}
+	if !loadedList.Authenticate("alice", "secret") {
+		t.Fatalf("mot de passe d'alice perdu au rechargement")
+	}
+}
+
+func TestLoadFromFileRejectsInvalidFiles(t *testing.T) {
+	testCases := []struct {
+		name          string
+		fileContent   string
+		expectedError string
+	}{
+		{"ligne sans user", "alice on\n", ":1: ligne invalide"},
+		{"utilisateur en double", "user alice on\n\n# commentaire\nuser alice off\n", ":4: utilisateur 'alice' défini plusieurs fois"},
+		{"règle invalide", "user alice on +@inconnue\n", ":1: catégorie ACL inconnue"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			aclFilePath := filepath.Join(t.TempDir(), "users.acl")
+			if writeError := os.WriteFile(aclFilePath, []byte(testCase.fileContent), 0600); writeError != nil {
+				t.Fatalf("écriture fichier ACL: %v", writeError)
+			}
+			accessControlList := NewAccessControlList(testCommandCategories, aclFilePath)
+			accessControlList.SetUser("existant", []string{"on"})
+
+			loadError := accessControlList.LoadFromFile()
+			if loadError == nil || !strings.Contains(loadError.Error(), testCase.expectedError) {
+				t.Fatalf("LoadFromFile: %v, attendu une erreur contenant %q", loadError, testCase.expectedError)
+			}
+			if accessControlList.GetUser("existant") == nil {
+				t.Fatalf("les utilisateurs existants doivent être conservés après un échec de chargement")
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package acl
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"redis-go/internal/storage"
+)
+
+// KeyPermission indique si un motif de clé autorise la lecture, l'écriture ou les deux
+type KeyPermission int
+
+const (
+	KeyPermissionRead KeyPermission = 1 << iota
+	KeyPermissionWrite
+	KeyPermissionReadWrite = KeyPermissionRead | KeyPermissionWrite
+)
+
+// KeyPattern représente une règle ~motif, %R~motif, %W~motif ou %RW~motif
+type KeyPattern struct {
+	GlobPattern string
+	Permission  KeyPermission
+}
+
+// AccessControlUser représente un utilisateur ACL et ses permissions
+type AccessControlUser struct {
+	UserName        string
+	Enabled         bool
+	NoPassword      bool
+	passwordHashes  map[string]bool
+	allowedCommands map[string]bool
+	commandRules    []string
+	keyPatterns     []KeyPattern
+	channelPatterns []string // Conservés pour ACL LIST / ACL SAVE, non appliqués (pas de pub/sub)
+	allChannels     bool
+}
+
+// newAccessControlUser crée un utilisateur désactivé sans aucune permission (comme ACL SETUSER)
+func newAccessControlUser(userName string) *AccessControlUser {
+	return &AccessControlUser{
+		UserName:        userName,
+		passwordHashes:  make(map[string]bool),
+		allowedCommands: make(map[string]bool),
+	}
+}
+
+// hashPassword retourne l'empreinte SHA-256 hexadécimale d'un mot de passe
+func hashPassword(clearPassword string) string {
+	passwordDigest := sha256.Sum256([]byte(clearPassword))
+	return hex.EncodeToString(passwordDigest[:])
+}
+
+// CheckPassword vérifie un mot de passe en temps constant contre toutes les empreintes
+func (aclUser *AccessControlUser) CheckPassword(candidatePassword string) bool {
+	if aclUser.NoPassword {
+		return true
+	}
+
+	candidateHash := []byte(hashPassword(candidatePassword))
+	passwordMatched := 0
+	for storedHash := range aclUser.passwordHashes {
+		passwordMatched |= subtle.ConstantTimeCompare([]byte(storedHash), candidateHash)
+	}
+	return passwordMatched == 1
+}
+
+// applyRule applique une règle ACL (on, off, >pass, ~motif, +@cat, -cmd ...)
+func (aclUser *AccessControlUser) applyRule(aclRule string, commandCategories map[string][]string) error {
+	lowerRule := strings.ToLower(aclRule)
+
+	switch lowerRule {
+	case "on":
+		aclUser.Enabled = true
+		return nil
+	case "off":
+		aclUser.Enabled = false
+		return nil
+	case "nopass":
+		aclUser.NoPassword = true
+		aclUser.passwordHashes = make(map[string]bool)
+		return nil
+	case "resetpass":
+		aclUser.NoPassword = false
+		aclUser.passwordHashes = make(map[string]bool)
+		return nil
+	case "allkeys":
+		aclUser.keyPatterns = []KeyPattern{{GlobPattern: "*", Permission: KeyPermissionReadWrite}}
+		return nil
+	case "resetkeys":
+		aclUser.keyPatterns = nil
+		return nil
+	// Règles de canaux analysées pour la compatibilité ascendante des fichiers ACL Redis (&*, allchannels) :
+	// le serveur n'a pas de commandes pub/sub, elles ne sont donc appliquées nulle part
+	case "allchannels":
+		aclUser.allChannels = true
+		aclUser.channelPatterns = nil
+		return nil
+	case "resetchannels":
+		aclUser.allChannels = false
+		aclUser.channelPatterns = nil
+		return nil
+	case "allcommands":
+		return aclUser.applyRule("+@all", commandCategories)
+	case "nocommands":
+		return aclUser.applyRule("-@all", commandCategories)
+	case "reset":
+		for _, resetRule := range []string{"resetpass", "resetkeys", "resetchannels", "off", "-@all"} {
+			aclUser.applyRule(resetRule, commandCategories)
+		}
+		return nil
+	}
+
+	switch {
+	case strings.HasPrefix(aclRule, ">"):
+		aclUser.passwordHashes[hashPassword(aclRule[1:])] = true
+		aclUser.NoPassword = false
+	case strings.HasPrefix(aclRule, "<"):
+		delete(aclUser.passwordHashes, hashPassword(aclRule[1:]))
+	case strings.HasPrefix(aclRule, "#"):
+		passwordHash := strings.ToLower(aclRule[1:])
+		if _, decodeError := hex.DecodeString(passwordHash); decodeError != nil || len(passwordHash) != 64 {
+			return fmt.Errorf("empreinte SHA-256 invalide '%s'", aclRule)
+		}
+		aclUser.passwordHashes[passwordHash] = true
+		aclUser.NoPassword = false
+	case strings.HasPrefix(aclRule, "!"):
+		delete(aclUser.passwordHashes, strings.ToLower(aclRule[1:]))
+	case strings.HasPrefix(aclRule, "~"):
+		aclUser.keyPatterns = append(aclUser.keyPatterns, KeyPattern{GlobPattern: aclRule[1:], Permission: KeyPermissionReadWrite})
+	case strings.HasPrefix(aclRule, "%"):
+		return aclUser.applySelectiveKeyRule(aclRule)
+	case strings.HasPrefix(aclRule, "&"):
+		// Mémorisé seulement, voir allchannels
+		if !aclUser.allChannels {
+			aclUser.channelPatterns = append(aclUser.channelPatterns, aclRule[1:])
+		}
+	case strings.HasPrefix(aclRule, "+") || strings.HasPrefix(aclRule, "-"):
+		return aclUser.applyCommandRule(aclRule, commandCategories)
+	default:
+		return fmt.Errorf("règle ACL inconnue '%s'", aclRule)
+	}
+	return nil
+}
+
+// applySelectiveKeyRule applique %R~motif, %W~motif ou %RW~motif
+func (aclUser *AccessControlUser) applySelectiveKeyRule(aclRule string) error {
+	separatorIndex := strings.Index(aclRule, "~")
+	if separatorIndex < 2 {
+		return fmt.Errorf("règle de clé invalide '%s'", aclRule)
+	}
+
+	var keyPermission KeyPermission
+	for _, permissionLetter := range strings.ToUpper(aclRule[1:separatorIndex]) {
+		switch permissionLetter {
+		case 'R':
+			keyPermission |= KeyPermissionRead
+		case 'W':
+			keyPermission |= KeyPermissionWrite
+		default:
+			return fmt.Errorf("permission de clé inconnue '%c' dans '%s'", permissionLetter, aclRule)
+		}
+	}
+
+	aclUser.keyPatterns = append(aclUser.keyPatterns, KeyPattern{GlobPattern: aclRule[separatorIndex+1:], Permission: keyPermission})
+	return nil
+}
+
+// applyCommandRule applique +commande, -commande, +@catégorie, -@catégorie, +cmd|sous-commande
+func (aclUser *AccessControlUser) applyCommandRule(aclRule string, commandCategories map[string][]string) error {
+	grantPermission := aclRule[0] == '+'
+	ruleTarget := strings.ToUpper(aclRule[1:])
+
+	var affectedCommands []string
+	if strings.HasPrefix(ruleTarget, "@") {
+		categoryName := strings.ToLower(ruleTarget[1:])
+		if categoryName != "all" && !isKnownCategory(categoryName, commandCategories) {
+			return fmt.Errorf("catégorie ACL inconnue '@%s'", categoryName)
+		}
+		for commandName, categories := range commandCategories {
+			if categoryName == "all" || containsCategory(categories, categoryName) {
+				affectedCommands = append(affectedCommands, commandName)
+			}
+		}
+	} else {
+		if _, commandExists := commandCategories[ruleTarget]; !commandExists {
+			return fmt.Errorf("commande inconnue '%s'", aclRule[1:])
+		}
+		affectedCommands = append(affectedCommands, ruleTarget)
+		// +client autorise aussi toutes les sous-commandes client|*
+		if !strings.Contains(ruleTarget, "|") {
+			for commandName := range commandCategories {
+				if strings.HasPrefix(commandName, ruleTarget+"|") {
+					affectedCommands = append(affectedCommands, commandName)
+				}
+			}
+		}
+	}
+
+	for _, commandName := range affectedCommands {
+		if grantPermission {
+			aclUser.allowedCommands[commandName] = true
+		} else {
+			delete(aclUser.allowedCommands, commandName)
+		}
+	}
+
+	// Conserver l'historique des règles pour ACL GETUSER / ACL LIST
+	lowerRule := strings.ToLower(aclRule)
+	if lowerRule == "+@all" || lowerRule == "-@all" {
+		aclUser.commandRules = []string{lowerRule}
+	} else {
+		aclUser.commandRules = append(aclUser.commandRules, lowerRule)
+	}
+	return nil
+}
+
+// isKnownCategory vérifie qu'au moins une commande appartient à la catégorie
+func isKnownCategory(categoryName string, commandCategories map[string][]string) bool {
+	for _, categories := range commandCategories {
+		if containsCategory(categories, categoryName) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsCategory indique si la liste contient la catégorie donnée
+func containsCategory(categories []string, categoryName string) bool {
+	for _, category := range categories {
+		if category == categoryName {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCommandAllowed vérifie si l'utilisateur peut exécuter la commande (nom au format CMD ou CMD|SOUS)
+func (aclUser *AccessControlUser) IsCommandAllowed(aclCommandName string) bool {
+	return aclUser.allowedCommands[aclCommandName]
+}
+
+// IsKeyAllowed vérifie si une clé est accessible avec la permission demandée
+func (aclUser *AccessControlUser) IsKeyAllowed(storageKey string, requiredPermission KeyPermission) bool {
+	for _, keyPattern := range aclUser.keyPatterns {
+		if keyPattern.Permission&requiredPermission == requiredPermission && storage.MatchGlobPattern(keyPattern.GlobPattern, storageKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeCommandRules retourne les règles de commandes au format ACL LIST
+func (aclUser *AccessControlUser) describeCommandRules() string {
+	if len(aclUser.commandRules) == 0 {
+		return "-@all"
+	}
+	return strings.Join(aclUser.commandRules, " ")
+}
+
+// describeKeyPatterns retourne les motifs de clés au format ACL LIST
+func (aclUser *AccessControlUser) describeKeyPatterns() []string {
+	describedPatterns := make([]string, 0, len(aclUser.keyPatterns))
+	for _, keyPattern := range aclUser.keyPatterns {
+		switch keyPattern.Permission {
+		case KeyPermissionRead:
+			describedPatterns = append(describedPatterns, "%R~"+keyPattern.GlobPattern)
+		case KeyPermissionWrite:
+			describedPatterns = append(describedPatterns, "%W~"+keyPattern.GlobPattern)
+		default:
+			describedPatterns = append(describedPatterns, "~"+keyPattern.GlobPattern)
+		}
+	}
+	return describedPatterns
+}
+
+// describeChannelPatterns retourne les motifs de canaux au format ACL LIST
+func (aclUser *AccessControlUser) describeChannelPatterns() []string {
+	if aclUser.allChannels {
+		return []string{"&*"}
+	}
+	describedPatterns := make([]string, 0, len(aclUser.channelPatterns))
+	for _, channelPattern := range aclUser.channelPatterns {
+		describedPatterns = append(describedPatterns, "&"+channelPattern)
+	}
+	return describedPatterns
+}
+
+// sortedPasswordHashes retourne les empreintes triées (sortie déterministe)
+func (aclUser *AccessControlUser) sortedPasswordHashes() []string {
+	passwordHashes := make([]string, 0, len(aclUser.passwordHashes))
+	for passwordHash := range aclUser.passwordHashes {
+		passwordHashes = append(passwordHashes, passwordHash)
+	}
+	sort.Strings(passwordHashes)
+	return passwordHashes
+}
+
+// DescribeUser retourne la ligne "user <nom> <règles>" utilisée par ACL LIST et le fichier ACL
+func (aclUser *AccessControlUser) DescribeUser() string {
+	describedRules := []string{"user", aclUser.UserName}
+	if aclUser.Enabled {
+		describedRules = append(describedRules, "on")
+	} else {
+		describedRules = append(describedRules, "off")
+	}
+	if aclUser.NoPassword {
+		describedRules = append(describedRules, "nopass")
+	}
+	for _, passwordHash := range aclUser.sortedPasswordHashes() {
+		describedRules = append(describedRules, "#"+passwordHash)
+	}
+	describedRules = append(describedRules, aclUser.describeKeyPatterns()...)
+	if channelPatterns := aclUser.describeChannelPatterns(); len(channelPatterns) > 0 {
+		describedRules = append(describedRules, channelPatterns...)
+	} else {
+		describedRules = append(describedRules, "resetchannels")
+	}
+	describedRules = append(describedRules, aclUser.describeCommandRules())
+	return strings.Join(describedRules, " ")
+}
+
+// GetUserDetails retourne les informations affichées par ACL GETUSER (paires champ/valeur)
+func (aclUser *AccessControlUser) GetUserDetails() map[string][]string {
+	userFlags := []string{"off"}
+	if aclUser.Enabled {
+		userFlags = []string{"on"}
+	}
+	if aclUser.NoPassword {
+		userFlags = append(userFlags, "nopass")
+	}
+
+	return map[string][]string{
+		"flags":     userFlags,
+		"passwords": aclUser.sortedPasswordHashes(),
+		"commands":  {aclUser.describeCommandRules()},
+		"keys":      {strings.Join(aclUser.describeKeyPatterns(), " ")},
+		"channels":  {strings.Join(aclUser.describeChannelPatterns(), " ")},
+	}
+}
@@ -0,0 +1,90 @@
+package acl
+
+import (
+	"sync"
+	"time"
+)
+
+// maximumSecurityLogEntries limite la taille du journal ACL LOG
+const maximumSecurityLogEntries = 128
+
+// securityLogGroupingWindow regroupe les refus identiques rapprochés en une seule entrée
+const securityLogGroupingWindow = 60 * time.Second
+
+// SecurityLogEntry représente un refus d'accès enregistré dans ACL LOG
+type SecurityLogEntry struct {
+	EntryCount      int64
+	DenialReason    string // auth, command, key
+	DenialContext   string // toplevel
+	DeniedObject    string
+	UserName        string
+	ClientInfo      string
+	CreationTime    time.Time
+	LastUpdatedTime time.Time
+}
+
+// SecurityLog est le journal circulaire des refus d'accès
+type SecurityLog struct {
+	logEntries []*SecurityLogEntry // du plus récent au plus ancien
+	logMutex   sync.Mutex
+}
+
+// newSecurityLog crée un journal vide
+func newSecurityLog() *SecurityLog {
+	return &SecurityLog{}
+}
+
+// RecordDenial enregistre un refus, ou incrémente une entrée identique récente
+func (securityLog *SecurityLog) RecordDenial(denialReason string, deniedObject string, userName string, clientInfo string) {
+	securityLog.logMutex.Lock()
+	defer securityLog.logMutex.Unlock()
+
+	currentTime := time.Now()
+	for _, existingEntry := range securityLog.logEntries {
+		if existingEntry.DenialReason == denialReason && existingEntry.DeniedObject == deniedObject &&
+			existingEntry.UserName == userName && currentTime.Sub(existingEntry.LastUpdatedTime) < securityLogGroupingWindow {
+			existingEntry.EntryCount++
+			existingEntry.LastUpdatedTime = currentTime
+			existingEntry.ClientInfo = clientInfo
+			return
+		}
+	}
+
+	newEntry := &SecurityLogEntry{
+		EntryCount:      1,
+		DenialReason:    denialReason,
+		DenialContext:   "toplevel",
+		DeniedObject:    deniedObject,
+		UserName:        userName,
+		ClientInfo:      clientInfo,
+		CreationTime:    currentTime,
+		LastUpdatedTime: currentTime,
+	}
+	securityLog.logEntries = append([]*SecurityLogEntry{newEntry}, securityLog.logEntries...)
+	if len(securityLog.logEntries) > maximumSecurityLogEntries {
+		securityLog.logEntries = securityLog.logEntries[:maximumSecurityLogEntries]
+	}
+}
+
+// GetRecentEntries retourne au plus entryLimit entrées (copie), les plus récentes d'abord
+func (securityLog *SecurityLog) GetRecentEntries(entryLimit int) []SecurityLogEntry {
+	securityLog.logMutex.Lock()
+	defer securityLog.logMutex.Unlock()
+
+	if entryLimit < 0 || entryLimit > len(securityLog.logEntries) {
+		entryLimit = len(securityLog.logEntries)
+	}
+
+	recentEntries := make([]SecurityLogEntry, entryLimit)
+	for entryIndex := 0; entryIndex < entryLimit; entryIndex++ {
+		recentEntries[entryIndex] = *securityLog.logEntries[entryIndex]
+	}
+	return recentEntries
+}
+
+// Reset vide le journal (ACL LOG RESET)
+func (securityLog *SecurityLog) Reset() {
+	securityLog.logMutex.Lock()
+	defer securityLog.logMutex.Unlock()
+	securityLog.logEntries = nil
+}
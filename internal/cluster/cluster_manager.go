@@ -0,0 +1,49 @@
+package cluster
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+// ClusterManager détient la topologie courante du cluster
+// Les commandes lisent la topologie sans verrou : CLUSTER RELOAD la remplace d'un bloc
+type ClusterManager struct {
+	topologyFilePath string
+	myNodeIdentifier string
+	listeningPort    int
+	currentTopology  atomic.Pointer[ClusterTopology]
+}
+
+// NewClusterManager crée le gestionnaire ; la topologie est chargée par Reload
+func NewClusterManager(topologyFilePath, myNodeIdentifier string, listeningPort int) *ClusterManager {
+	return &ClusterManager{
+		topologyFilePath: topologyFilePath,
+		myNodeIdentifier: myNodeIdentifier,
+		listeningPort:    listeningPort,
+	}
+}
+
+// Reload relit le fichier de topologie ; en cas d'erreur, la topologie précédente reste en place
+func (clusterManager *ClusterManager) Reload() error {
+	loadedTopology, loadError := LoadClusterTopology(clusterManager.topologyFilePath, clusterManager.myNodeIdentifier, clusterManager.listeningPort)
+	if loadError != nil {
+		return loadError
+	}
+	clusterManager.currentTopology.Store(loadedTopology)
+
+	myselfNode := loadedTopology.Myself()
+	log.Printf("🧩 Topologie cluster chargée depuis %s : %d nœuds, %d slots assignés, ce nœud est %s (%s)",
+		clusterManager.topologyFilePath, len(loadedTopology.Nodes()), loadedTopology.AssignedSlotCount(),
+		myselfNode.NodeIdentifier, myselfNode.Address())
+	return nil
+}
+
+// Topology retourne la topologie courante
+func (clusterManager *ClusterManager) Topology() *ClusterTopology {
+	return clusterManager.currentTopology.Load()
+}
+
+// TopologyFilePath retourne le chemin du fichier de topologie
+func (clusterManager *ClusterManager) TopologyFilePath() string {
+	return clusterManager.topologyFilePath
+}
@@ -0,0 +1,310 @@
+package cluster
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ClusterNode décrit un nœud déclaré dans le fichier de topologie
+type ClusterNode struct {
+	NodeIdentifier       string
+	HostAddress          string
+	PortNumber           int
+	MasterNodeIdentifier string // Vide pour un maître
+}
+
+// IsMaster indique si le nœud est un maître (il peut alors servir des slots)
+func (clusterNode *ClusterNode) IsMaster() bool {
+	return clusterNode.MasterNodeIdentifier == ""
+}
+
+// Address retourne "hôte:port", l'adresse annoncée dans les redirections
+func (clusterNode *ClusterNode) Address() string {
+	return net.JoinHostPort(clusterNode.HostAddress, strconv.Itoa(clusterNode.PortNumber))
+}
+
+// SlotRange est un intervalle de slots contigus [StartSlot, EndSlot]
+type SlotRange struct {
+	StartSlot int
+	EndSlot   int
+}
+
+// ClusterTopology est une vue figée de la topologie : qui sert quel slot, quels slots migrent
+// Elle n'est jamais modifiée après chargement, CLUSTER RELOAD en construit une nouvelle
+type ClusterTopology struct {
+	clusterNodes   []*ClusterNode // Ordre du fichier
+	nodesByID      map[string]*ClusterNode
+	slotOwners     [HashSlotCount]*ClusterNode
+	migratingSlots map[int]*ClusterNode // Slot -> nœud cible (déclaré sur la ligne du propriétaire)
+	importingSlots map[int]*ClusterNode // Slot -> nœud source (déclaré sur la ligne du nœud qui importe)
+	myselfNode     *ClusterNode
+}
+
+// pendingSlotMigration est une migration lue avant que tous les nœuds soient connus
+type pendingSlotMigration struct {
+	lineNumber          int
+	slotNumber          int
+	declaringNode       *ClusterNode
+	otherNodeIdentifier string
+	isImporting         bool
+}
+
+// LoadClusterTopology lit le fichier de topologie. Une ligne par nœud :
+//
+//	node <id> <hôte:port> master [slots ...]
+//	node <id> <hôte:port> replica <id-du-maître>
+//
+// Les slots sont des numéros (42) ou des intervalles (0-5460). Pendant un resharding,
+// [42->-<id>] sur la ligne du propriétaire indique que le slot 42 migre vers <id>,
+// et [42-<-<id>] sur la ligne de la cible indique qu'elle l'importe depuis <id>.
+// Ce nœud est celui d'identifiant myNodeIdentifier, ou à défaut le seul dont le port vaut listeningPort
+func LoadClusterTopology(topologyFilePath, myNodeIdentifier string, listeningPort int) (*ClusterTopology, error) {
+	topologyFile, openError := os.Open(topologyFilePath)
+	if openError != nil {
+		return nil, fmt.Errorf("ouverture fichier de topologie: %v", openError)
+	}
+	defer topologyFile.Close()
+
+	loadedTopology := &ClusterTopology{
+		nodesByID:      make(map[string]*ClusterNode),
+		migratingSlots: make(map[int]*ClusterNode),
+		importingSlots: make(map[int]*ClusterNode),
+	}
+	var pendingMigrations []pendingSlotMigration
+	replicaLineNumbers := make(map[*ClusterNode]int)
+
+	lineScanner := bufio.NewScanner(topologyFile)
+	lineNumber := 0
+	for lineScanner.Scan() {
+		lineNumber++
+		trimmedLine := strings.TrimSpace(lineScanner.Text())
+		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
+			continue
+		}
+
+		lineFields := strings.Fields(trimmedLine)
+		if len(lineFields) < 4 || lineFields[0] != "node" {
+			return nil, fmt.Errorf("%s:%d: ligne invalide, attendu 'node <id> <hôte:port> master|replica ...'", topologyFilePath, lineNumber)
+		}
+		if _, alreadyDefined := loadedTopology.nodesByID[lineFields[1]]; alreadyDefined {
+			return nil, fmt.Errorf("%s:%d: nœud '%s' défini plusieurs fois", topologyFilePath, lineNumber, lineFields[1])
+		}
+		hostAddress, portText, splitError := net.SplitHostPort(lineFields[2])
+		portNumber, portError := strconv.Atoi(portText)
+		if splitError != nil || portError != nil || portNumber <= 0 || portNumber > 65535 {
+			return nil, fmt.Errorf("%s:%d: adresse '%s' invalide, attendu hôte:port", topologyFilePath, lineNumber, lineFields[2])
+		}
+
+		clusterNode := &ClusterNode{NodeIdentifier: lineFields[1], HostAddress: hostAddress, PortNumber: portNumber}
+		switch lineFields[3] {
+		case "master":
+			for _, slotToken := range lineFields[4:] {
+				if strings.HasPrefix(slotToken, "[") {
+					pendingMigration, parseError := parseSlotMigration(slotToken)
+					if parseError != nil {
+						return nil, fmt.Errorf("%s:%d: %v", topologyFilePath, lineNumber, parseError)
+					}
+					pendingMigration.lineNumber = lineNumber
+					pendingMigration.declaringNode = clusterNode
+					pendingMigrations = append(pendingMigrations, pendingMigration)
+					continue
+				}
+				slotRange, parseError := parseSlotRange(slotToken)
+				if parseError != nil {
+					return nil, fmt.Errorf("%s:%d: %v", topologyFilePath, lineNumber, parseError)
+				}
+				for slotNumber := slotRange.StartSlot; slotNumber <= slotRange.EndSlot; slotNumber++ {
+					if previousOwner := loadedTopology.slotOwners[slotNumber]; previousOwner != nil {
+						return nil, fmt.Errorf("%s:%d: slot %d déjà servi par '%s'", topologyFilePath, lineNumber, slotNumber, previousOwner.NodeIdentifier)
+					}
+					loadedTopology.slotOwners[slotNumber] = clusterNode
+				}
+			}
+		case "replica":
+			if len(lineFields) != 5 {
+				return nil, fmt.Errorf("%s:%d: un réplica attend uniquement l'identifiant de son maître", topologyFilePath, lineNumber)
+			}
+			clusterNode.MasterNodeIdentifier = lineFields[4]
+			replicaLineNumbers[clusterNode] = lineNumber
+		default:
+			return nil, fmt.Errorf("%s:%d: rôle '%s' inconnu (attendu master ou replica)", topologyFilePath, lineNumber, lineFields[3])
+		}
+
+		loadedTopology.clusterNodes = append(loadedTopology.clusterNodes, clusterNode)
+		loadedTopology.nodesByID[clusterNode.NodeIdentifier] = clusterNode
+	}
+	if scanError := lineScanner.Err(); scanError != nil {
+		return nil, fmt.Errorf("lecture fichier de topologie: %v", scanError)
+	}
+
+	// Les références entre nœuds ne sont résolues qu'une fois le fichier entièrement lu
+	for clusterNode, lineNumber := range replicaLineNumbers {
+		masterNode, masterExists := loadedTopology.nodesByID[clusterNode.MasterNodeIdentifier]
+		if !masterExists || !masterNode.IsMaster() {
+			return nil, fmt.Errorf("%s:%d: '%s' n'est pas un maître déclaré", topologyFilePath, lineNumber, clusterNode.MasterNodeIdentifier)
+		}
+	}
+	for _, pendingMigration := range pendingMigrations {
+		otherNode, otherExists := loadedTopology.nodesByID[pendingMigration.otherNodeIdentifier]
+		if !otherExists || !otherNode.IsMaster() || otherNode == pendingMigration.declaringNode {
+			return nil, fmt.Errorf("%s:%d: '%s' n'est pas un autre maître déclaré", topologyFilePath, pendingMigration.lineNumber, pendingMigration.otherNodeIdentifier)
+		}
+		slotOwner := loadedTopology.slotOwners[pendingMigration.slotNumber]
+		if pendingMigration.isImporting {
+			if slotOwner != otherNode {
+				return nil, fmt.Errorf("%s:%d: le slot %d importé n'est pas servi par '%s'", topologyFilePath, pendingMigration.lineNumber, pendingMigration.slotNumber, otherNode.NodeIdentifier)
+			}
+			loadedTopology.importingSlots[pendingMigration.slotNumber] = pendingMigration.declaringNode
+		} else {
+			if slotOwner != pendingMigration.declaringNode {
+				return nil, fmt.Errorf("%s:%d: le slot %d en migration n'est pas servi par ce nœud", topologyFilePath, pendingMigration.lineNumber, pendingMigration.slotNumber)
+			}
+			loadedTopology.migratingSlots[pendingMigration.slotNumber] = otherNode
+		}
+	}
+
+	if myNodeIdentifier != "" {
+		loadedTopology.myselfNode = loadedTopology.nodesByID[myNodeIdentifier]
+		if loadedTopology.myselfNode == nil {
+			return nil, fmt.Errorf("%s: nœud '%s' absent de la topologie", topologyFilePath, myNodeIdentifier)
+		}
+	} else {
+		for _, clusterNode := range loadedTopology.clusterNodes {
+			if clusterNode.PortNumber != listeningPort {
+				continue
+			}
+			if loadedTopology.myselfNode != nil {
+				return nil, fmt.Errorf("%s: plusieurs nœuds sur le port %d, définir cluster-node-id", topologyFilePath, listeningPort)
+			}
+			loadedTopology.myselfNode = clusterNode
+		}
+		if loadedTopology.myselfNode == nil {
+			return nil, fmt.Errorf("%s: aucun nœud sur le port %d, définir cluster-node-id", topologyFilePath, listeningPort)
+		}
+	}
+
+	return loadedTopology, nil
+}
+
+// parseSlotRange lit "42" ou "0-5460"
+func parseSlotRange(slotToken string) (SlotRange, error) {
+	startText, endText, isRange := strings.Cut(slotToken, "-")
+	if !isRange {
+		endText = startText
+	}
+	startSlot, startError := strconv.Atoi(startText)
+	endSlot, endError := strconv.Atoi(endText)
+	if startError != nil || endError != nil || startSlot < 0 || endSlot >= HashSlotCount || startSlot > endSlot {
+		return SlotRange{}, fmt.Errorf("slots '%s' invalides (0 à %d)", slotToken, HashSlotCount-1)
+	}
+	return SlotRange{StartSlot: startSlot, EndSlot: endSlot}, nil
+}
+
+// parseSlotMigration lit "[42->-<id>]" (migration sortante) ou "[42-<-<id>]" (import)
+func parseSlotMigration(slotToken string) (pendingSlotMigration, error) {
+	innerText := strings.TrimSuffix(strings.TrimPrefix(slotToken, "["), "]")
+	if len(innerText) != len(slotToken)-2 {
+		return pendingSlotMigration{}, fmt.Errorf("migration '%s' invalide", slotToken)
+	}
+
+	pendingMigration := pendingSlotMigration{}
+	slotText, otherNodeIdentifier, isMigrating := strings.Cut(innerText, "->-")
+	if !isMigrating {
+		slotText, otherNodeIdentifier, pendingMigration.isImporting = strings.Cut(innerText, "-<-")
+		if !pendingMigration.isImporting {
+			return pendingSlotMigration{}, fmt.Errorf("migration '%s' invalide, attendu [slot->-id] ou [slot-<-id]", slotToken)
+		}
+	}
+	slotNumber, parseError := strconv.Atoi(slotText)
+	if parseError != nil || slotNumber < 0 || slotNumber >= HashSlotCount || otherNodeIdentifier == "" {
+		return pendingSlotMigration{}, fmt.Errorf("migration '%s' invalide", slotToken)
+	}
+	pendingMigration.slotNumber = slotNumber
+	pendingMigration.otherNodeIdentifier = otherNodeIdentifier
+	return pendingMigration, nil
+}
+
+// Myself retourne le nœud correspondant à ce serveur
+func (clusterTopology *ClusterTopology) Myself() *ClusterNode {
+	return clusterTopology.myselfNode
+}
+
+// Nodes retourne les nœuds dans l'ordre du fichier
+func (clusterTopology *ClusterTopology) Nodes() []*ClusterNode {
+	return clusterTopology.clusterNodes
+}
+
+// SlotOwner retourne le maître qui sert un slot (nil si aucun)
+func (clusterTopology *ClusterTopology) SlotOwner(slotNumber int) *ClusterNode {
+	return clusterTopology.slotOwners[slotNumber]
+}
+
+// MigratingTarget retourne la cible d'un slot en migration sortante (nil sinon)
+func (clusterTopology *ClusterTopology) MigratingTarget(slotNumber int) *ClusterNode {
+	return clusterTopology.migratingSlots[slotNumber]
+}
+
+// ImportingSource retourne la source d'un slot importé par ce nœud (nil sinon)
+func (clusterTopology *ClusterTopology) ImportingSource(slotNumber int) *ClusterNode {
+	if importingNode := clusterTopology.importingSlots[slotNumber]; importingNode != clusterTopology.myselfNode {
+		return nil
+	}
+	return clusterTopology.slotOwners[slotNumber]
+}
+
+// MigratingSlotsOf retourne les migrations sortantes et entrantes déclarées pour un nœud,
+// formatées comme dans CLUSTER NODES ([slot->-id] et [slot-<-id])
+func (clusterTopology *ClusterTopology) MigratingSlotsOf(clusterNode *ClusterNode) []string {
+	var migrationDescriptions []string
+	for slotNumber := 0; slotNumber < HashSlotCount; slotNumber++ {
+		if targetNode := clusterTopology.migratingSlots[slotNumber]; targetNode != nil && clusterTopology.slotOwners[slotNumber] == clusterNode {
+			migrationDescriptions = append(migrationDescriptions, fmt.Sprintf("[%d->-%s]", slotNumber, targetNode.NodeIdentifier))
+		}
+		if clusterTopology.importingSlots[slotNumber] == clusterNode {
+			migrationDescriptions = append(migrationDescriptions, fmt.Sprintf("[%d-<-%s]", slotNumber, clusterTopology.slotOwners[slotNumber].NodeIdentifier))
+		}
+	}
+	return migrationDescriptions
+}
+
+// SlotRangesOf retourne les intervalles de slots servis par un maître
+func (clusterTopology *ClusterTopology) SlotRangesOf(clusterNode *ClusterNode) []SlotRange {
+	var slotRanges []SlotRange
+	for slotNumber := 0; slotNumber < HashSlotCount; slotNumber++ {
+		if clusterTopology.slotOwners[slotNumber] != clusterNode {
+			continue
+		}
+		if lastIndex := len(slotRanges) - 1; lastIndex >= 0 && slotRanges[lastIndex].EndSlot == slotNumber-1 {
+			slotRanges[lastIndex].EndSlot = slotNumber
+		} else {
+			slotRanges = append(slotRanges, SlotRange{StartSlot: slotNumber, EndSlot: slotNumber})
+		}
+	}
+	return slotRanges
+}
+
+// ReplicasOf retourne les réplicas déclarés d'un maître
+func (clusterTopology *ClusterTopology) ReplicasOf(masterNode *ClusterNode) []*ClusterNode {
+	var replicaNodes []*ClusterNode
+	for _, clusterNode := range clusterTopology.clusterNodes {
+		if clusterNode.MasterNodeIdentifier == masterNode.NodeIdentifier {
+			replicaNodes = append(replicaNodes, clusterNode)
+		}
+	}
+	return replicaNodes
+}
+
+// AssignedSlotCount retourne le nombre de slots servis par un maître
+func (clusterTopology *ClusterTopology) AssignedSlotCount() int {
+	assignedSlots := 0
+	for _, slotOwner := range clusterTopology.slotOwners {
+		if slotOwner != nil {
+			assignedSlots++
+		}
+	}
+	return assignedSlots
+}
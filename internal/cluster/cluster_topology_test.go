@@ -0,0 +1,88 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTopologyFile écrit un fichier de topologie dans un dossier temporaire
+func writeTopologyFile(t *testing.T, topologyContent string) string {
+	t.Helper()
+	topologyFilePath := filepath.Join(t.TempDir(), "nodes.conf")
+	if writeError := os.WriteFile(topologyFilePath, []byte(topologyContent), 0644); writeError != nil {
+		t.Fatalf("écriture topologie: %v", writeError)
+	}
+	return topologyFilePath
+}
+
+const reshardingTopology = `
+# Trois maîtres, un réplica, le slot 5000 migre de n1 vers n2
+node n1 127.0.0.1:7001 master 0-5460 [5000->-n2]
+node n2 127.0.0.1:7002 master 5461-10922 [5000-<-n1]
+node n3 127.0.0.1:7003 master 10923-16383
+node r1 127.0.0.1:7004 replica n1
+`
+
+func TestLoadClusterTopologyResolvesOwnersReplicasAndMigrations(t *testing.T) {
+	clusterTopology, loadError := LoadClusterTopology(writeTopologyFile(t, reshardingTopology), "", 7002)
+	if loadError != nil {
+		t.Fatalf("LoadClusterTopology: %v", loadError)
+	}
+
+	if myself := clusterTopology.Myself(); myself.NodeIdentifier != "n2" {
+		t.Fatalf("Myself = %s, attendu n2 (déduit du port)", myself.NodeIdentifier)
+	}
+	for _, slotCase := range []struct {
+		slotNumber    int
+		expectedOwner string
+	}{{0, "n1"}, {5000, "n1"}, {5460, "n1"}, {5461, "n2"}, {10923, "n3"}, {16383, "n3"}} {
+		if slotOwner := clusterTopology.SlotOwner(slotCase.slotNumber); slotOwner == nil || slotOwner.NodeIdentifier != slotCase.expectedOwner {
+			t.Errorf("SlotOwner(%d) = %v, attendu %s", slotCase.slotNumber, slotOwner, slotCase.expectedOwner)
+		}
+	}
+	if migratingTarget := clusterTopology.MigratingTarget(5000); migratingTarget == nil || migratingTarget.NodeIdentifier != "n2" {
+		t.Fatalf("MigratingTarget(5000) = %v, attendu n2", migratingTarget)
+	}
+	if importingSource := clusterTopology.ImportingSource(5000); importingSource == nil {
+		t.Fatalf("ImportingSource(5000) = nil sur n2, attendu un import en cours")
+	}
+	if clusterTopology.AssignedSlotCount() != HashSlotCount {
+		t.Fatalf("AssignedSlotCount = %d, attendu %d", clusterTopology.AssignedSlotCount(), HashSlotCount)
+	}
+	masterNode := clusterTopology.SlotOwner(0)
+	if replicaNodes := clusterTopology.ReplicasOf(masterNode); len(replicaNodes) != 1 || replicaNodes[0].NodeIdentifier != "r1" {
+		t.Fatalf("ReplicasOf(n1) = %v, attendu [r1]", replicaNodes)
+	}
+}
+
+func TestLoadClusterTopologyRejectsInvalidFiles(t *testing.T) {
+	testCases := []struct {
+		name            string
+		topologyContent string
+		expectedError   string
+	}{
+		{"ligne invalide", "noeud n1 127.0.0.1:7001 master 0-16383\n", "ligne invalide"},
+		{"nœud en double", "node n1 127.0.0.1:7001 master 0\nnode n1 127.0.0.1:7002 master 1\n", "plusieurs fois"},
+		{"adresse sans port", "node n1 127.0.0.1 master 0\n", "adresse"},
+		{"slot hors limites", "node n1 127.0.0.1:7001 master 0-16384\n", "invalides"},
+		{"intervalle inversé", "node n1 127.0.0.1:7001 master 10-5\n", "invalides"},
+		{"slot servi deux fois", "node n1 127.0.0.1:7001 master 0-10\nnode n2 127.0.0.1:7002 master 10\n", "déjà servi"},
+		{"rôle inconnu", "node n1 127.0.0.1:7001 primary 0\n", "rôle"},
+		{"maître du réplica absent", "node n1 127.0.0.1:7001 master 0\nnode r1 127.0.0.1:7002 replica n9\n", "n'est pas un maître"},
+		{"migration vers un inconnu", "node n1 127.0.0.1:7001 master 0 [0->-n9]\n", "n'est pas un autre maître"},
+		{"migration d'un slot non servi", "node n1 127.0.0.1:7001 master 0 [1->-n2]\nnode n2 127.0.0.1:7002 master 1\n", "n'est pas servi"},
+		{"migration mal formée", "node n1 127.0.0.1:7001 master 0 [0=>n2]\n", "migration"},
+		{"aucun nœud sur le port", "node n1 127.0.0.1:7009 master 0-16383\n", "aucun nœud"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, loadError := LoadClusterTopology(writeTopologyFile(t, testCase.topologyContent), "", 7001)
+			if loadError == nil || !strings.Contains(loadError.Error(), testCase.expectedError) {
+				t.Fatalf("LoadClusterTopology: %v, attendu une erreur contenant %q", loadError, testCase.expectedError)
+			}
+		})
+	}
+}
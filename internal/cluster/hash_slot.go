@@ -0,0 +1,47 @@
+package cluster
+
+import "strings"
+
+// HashSlotCount est le nombre de slots entre lesquels les clés sont réparties
+const HashSlotCount = 16384
+
+// crc16Table est la table du CRC16 XMODEM (polynôme 0x1021), celui utilisé par Redis Cluster
+var crc16Table = buildCRC16Table()
+
+// buildCRC16Table précalcule le CRC16 de chaque octet
+func buildCRC16Table() [256]uint16 {
+	var table [256]uint16
+	for byteValue := 0; byteValue < 256; byteValue++ {
+		crcValue := uint16(byteValue) << 8
+		for bitIndex := 0; bitIndex < 8; bitIndex++ {
+			if crcValue&0x8000 != 0 {
+				crcValue = crcValue<<1 ^ 0x1021
+			} else {
+				crcValue <<= 1
+			}
+		}
+		table[byteValue] = crcValue
+	}
+	return table
+}
+
+// computeCRC16 calcule le CRC16 XMODEM d'une chaîne
+func computeCRC16(data string) uint16 {
+	crcValue := uint16(0)
+	for index := 0; index < len(data); index++ {
+		crcValue = crcValue<<8 ^ crc16Table[byte(crcValue>>8)^data[index]]
+	}
+	return crcValue
+}
+
+// KeyHashSlot retourne le slot d'une clé : CRC16(clé) mod 16384
+// Si la clé contient un hashtag non vide ({...}), seul son contenu est haché :
+// "{user:1}:profil" et "{user:1}:panier" tombent dans le même slot
+func KeyHashSlot(storageKey string) int {
+	if openingBrace := strings.IndexByte(storageKey, '{'); openingBrace >= 0 {
+		if closingBrace := strings.IndexByte(storageKey[openingBrace+1:], '}'); closingBrace > 0 {
+			storageKey = storageKey[openingBrace+1 : openingBrace+1+closingBrace]
+		}
+	}
+	return int(computeCRC16(storageKey) % HashSlotCount)
+}
@@ -0,0 +1,47 @@
+package cluster
+
+import "testing"
+
+func TestComputeCRC16MatchesXModemReference(t *testing.T) {
+	// Valeur de contrôle du CRC16 XMODEM, reprise dans l'annexe de la spécification Redis Cluster
+	if crcValue := computeCRC16("123456789"); crcValue != 0x31C3 {
+		t.Fatalf("CRC16(\"123456789\") = %#04x, attendu 0x31c3", crcValue)
+	}
+}
+
+func TestKeyHashSlot(t *testing.T) {
+	testCases := []struct {
+		storageKey   string
+		expectedSlot int
+	}{
+		// Slots renvoyés par CLUSTER KEYSLOT sur un vrai Redis
+		{"foo", 12182},
+		{"bar", 5061},
+		{"hello", 866},
+		{"somekey", 11058},
+		{"", 0},
+		// Hashtag : seul le contenu de la première paire {...} non vide est haché
+		{"{user1000}.following", KeyHashSlot("user1000")},
+		{"{user1000}.followers", KeyHashSlot("user1000")},
+		{"foo{bar}{zap}", KeyHashSlot("bar")},
+		{"foo{{bar}}zap", KeyHashSlot("{bar")},
+		// Hashtag vide ou non fermé : toute la clé est hachée
+		{"foo{}{bar}", int(computeCRC16("foo{}{bar}") % HashSlotCount)},
+		{"foo{bar", int(computeCRC16("foo{bar") % HashSlotCount)},
+		{"}foo{", int(computeCRC16("}foo{") % HashSlotCount)},
+	}
+
+	for _, testCase := range testCases {
+		if hashSlot := KeyHashSlot(testCase.storageKey); hashSlot != testCase.expectedSlot {
+			t.Errorf("KeyHashSlot(%q) = %d, attendu %d", testCase.storageKey, hashSlot, testCase.expectedSlot)
+		}
+	}
+}
+
+func TestKeyHashSlotStaysInRange(t *testing.T) {
+	for _, storageKey := range []string{"a", "clé:é", "\x00\xff", "{x}", "très-longue-clé-de-test-0123456789"} {
+		if hashSlot := KeyHashSlot(storageKey); hashSlot < 0 || hashSlot >= HashSlotCount {
+			t.Fatalf("KeyHashSlot(%q) = %d hors de [0, %d)", storageKey, hashSlot, HashSlotCount)
+		}
+	}
+}
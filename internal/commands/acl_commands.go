@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"redis-go/internal/acl"
+	"redis-go/internal/protocol"
+	"redis-go/internal/session"
+	"redis-go/internal/storage"
+)
+
+// ConfigureAccessControl initialise les ACL depuis le fichier ACL (si configuré) puis applique requirepass
+func (commandRegistry *RedisCommandRegistry) ConfigureAccessControl(requiredPassword string, aclFilePath string) error {
+	commandRegistry.accessControlList = acl.NewAccessControlList(buildCommandCategoryIndex(), aclFilePath)
+
+	if aclFilePath != "" {
+		if loadError := commandRegistry.accessControlList.LoadFromFile(); loadError != nil {
+			return loadError
+		}
+		log.Printf("🔐 ACL: utilisateurs chargés depuis %s", aclFilePath)
+	}
+
+	if requiredPassword != "" {
+		commandRegistry.accessControlList.SetDefaultUserPassword(requiredPassword)
+	}
+	return nil
+}
+
+// checkCommandPermission vérifie les droits ACL de la session pour une commande et ses clés
+// Retourne un message d'erreur vide si la commande est autorisée
+func (commandRegistry *RedisCommandRegistry) checkCommandPermission(clientSession *session.ClientSession, upperCommandName string, commandArguments []string) string {
+	if commandsAllowedWithoutAuthentication[upperCommandName] {
+		return ""
+	}
+
+	userName := clientSession.GetUserName()
+	aclUser := commandRegistry.accessControlList.GetUser(userName)
+	if aclUser == nil {
+		return fmt.Sprintf("NOPERM l'utilisateur '%s' n'existe plus", userName)
+	}
+
+	aclCommandName, commandMetadata, metadataExists := lookupCommandMetadata(upperCommandName, commandArguments)
+	if !metadataExists || !aclUser.IsCommandAllowed(aclCommandName) {
+		deniedCommand := strings.ToLower(aclCommandName)
+		commandRegistry.accessControlList.GetSecurityLog().RecordDenial("command", deniedCommand, userName, clientSession.FormatClientInfo())
+		return fmt.Sprintf("NOPERM l'utilisateur '%s' n'a pas la permission d'exécuter la commande '%s'", userName, deniedCommand)
+	}
+
+	// Permission de clé requise : lecture pour @read, écriture pour @write
+	requiredPermission := acl.KeyPermissionReadWrite
+	if commandMetadata.hasCategory("write") {
+		requiredPermission = acl.KeyPermissionWrite
+	} else if commandMetadata.hasCategory("read") {
+		requiredPermission = acl.KeyPermissionRead
+	}
+
+	for _, commandKey := range extractCommandKeys(commandMetadata, commandArguments) {
+		if !aclUser.IsKeyAllowed(commandKey, requiredPermission) {
+			commandRegistry.accessControlList.GetSecurityLog().RecordDenial("key", commandKey, userName, clientSession.FormatClientInfo())
+			return fmt.Sprintf("NOPERM aucune permission pour accéder à la clé '%s'", commandKey)
+		}
+	}
+
+	return ""
+}
+
+// handleAclCommand implémente ACL <sous-commande> [arguments ...]
+func (commandRegistry *RedisCommandRegistry) handleAclCommand(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) == 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'ACL' (attendu: ACL sous-commande [arguments ...])")
+	}
+
+	subcommandArguments := commandArguments[1:]
+
+	switch strings.ToUpper(commandArguments[0]) {
+	case "WHOAMI":
+		return protocolEncoder.WriteBulkStringResponse(clientSession.GetUserName())
+
+	case "CAT":
+		if len(subcommandArguments) == 0 {
+			return protocolEncoder.WriteArrayResponse(commandRegistry.accessControlList.ListCategories())
+		}
+		categoryName := strings.TrimPrefix(strings.ToLower(subcommandArguments[0]), "@")
+		categoryCommands := commandRegistry.accessControlList.ListCommandsInCategory(categoryName)
+		if categoryCommands == nil {
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : catégorie ACL inconnue '%s'", subcommandArguments[0]))
+		}
+		return protocolEncoder.WriteArrayResponse(categoryCommands)
+
+	case "SETUSER":
+		if len(subcommandArguments) == 0 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'ACL SETUSER' (attendu: ACL SETUSER utilisateur [règle ...])")
+		}
+		if setError := commandRegistry.accessControlList.SetUser(subcommandArguments[0], subcommandArguments[1:]); setError != nil {
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : ACL SETUSER invalide: %v", setError))
+		}
+		return protocolEncoder.WriteSimpleStringResponse("OK")
+
+	case "GETUSER":
+		if len(subcommandArguments) != 1 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'ACL GETUSER' (attendu: ACL GETUSER utilisateur)")
+		}
+		aclUser := commandRegistry.accessControlList.GetUser(subcommandArguments[0])
+		if aclUser == nil {
+			return protocolEncoder.WriteNullBulkStringResponse()
+		}
+		return commandRegistry.writeAclUserDetails(aclUser, protocolEncoder)
+
+	case "DELUSER":
+		if len(subcommandArguments) == 0 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'ACL DELUSER' (attendu: ACL DELUSER utilisateur [utilisateur ...])")
+		}
+		deletedUserCount, deleteError := commandRegistry.accessControlList.DeleteUsers(subcommandArguments)
+		if deleteError != nil {
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : %v", deleteError))
+		}
+		commandRegistry.disconnectDeletedUsers(clientSession, subcommandArguments)
+		return protocolEncoder.WriteIntegerResponse(int64(deletedUserCount))
+
+	case "LIST":
+		describedUsers := make([]string, 0)
+		for _, aclUser := range commandRegistry.accessControlList.ListUsers() {
+			describedUsers = append(describedUsers, aclUser.DescribeUser())
+		}
+		return protocolEncoder.WriteArrayResponse(describedUsers)
+
+	case "USERS":
+		userNames := make([]string, 0)
+		for _, aclUser := range commandRegistry.accessControlList.ListUsers() {
+			userNames = append(userNames, aclUser.UserName)
+		}
+		return protocolEncoder.WriteArrayResponse(userNames)
+
+	case "LOG":
+		return commandRegistry.handleAclLogSubcommand(subcommandArguments, protocolEncoder)
+
+	case "LOAD":
+		if loadError := commandRegistry.accessControlList.LoadFromFile(); loadError != nil {
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : ACL LOAD échoué: %v", loadError))
+		}
+		return protocolEncoder.WriteSimpleStringResponse("OK")
+
+	case "SAVE":
+		if saveError := commandRegistry.accessControlList.SaveToFile(); saveError != nil {
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : ACL SAVE échoué: %v", saveError))
+		}
+		return protocolEncoder.WriteSimpleStringResponse("OK")
+
+	default:
+		return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : sous-commande ACL inconnue '%s'", commandArguments[0]))
+	}
+}
+
+// writeAclUserDetails écrit la réponse de ACL GETUSER (paires champ / valeur)
+func (commandRegistry *RedisCommandRegistry) writeAclUserDetails(aclUser *acl.AccessControlUser, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	userDetails := aclUser.GetUserDetails()
+	if err := protocolEncoder.WriteArrayHeaderResponse(10); err != nil {
+		return err
+	}
+
+	// flags et passwords sont des listes, les autres champs des chaînes
+	for _, fieldName := range []string{"flags", "passwords", "commands", "keys", "channels"} {
+		if err := protocolEncoder.WriteBulkStringResponse(fieldName); err != nil {
+			return err
+		}
+		if fieldName == "flags" || fieldName == "passwords" {
+			if err := protocolEncoder.WriteArrayResponse(userDetails[fieldName]); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := protocolEncoder.WriteBulkStringResponse(userDetails[fieldName][0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleAclLogSubcommand implémente ACL LOG [count | RESET]
+func (commandRegistry *RedisCommandRegistry) handleAclLogSubcommand(subcommandArguments []string, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	entryLimit := 10
+	if len(subcommandArguments) > 1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'ACL LOG' (attendu: ACL LOG [nombre | RESET])")
+	}
+	if len(subcommandArguments) == 1 {
+		if strings.ToUpper(subcommandArguments[0]) == "RESET" {
+			commandRegistry.accessControlList.GetSecurityLog().Reset()
+			return protocolEncoder.WriteSimpleStringResponse("OK")
+		}
+		parsedLimit, parseError := strconv.Atoi(subcommandArguments[0])
+		if parseError != nil || parsedLimit < 0 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : le nombre d'entrées doit être un entier positif")
+		}
+		entryLimit = parsedLimit
+	}
+
+	recentEntries := commandRegistry.accessControlList.GetSecurityLog().GetRecentEntries(entryLimit)
+	if err := protocolEncoder.WriteArrayHeaderResponse(len(recentEntries)); err != nil {
+		return err
+	}
+
+	currentTime := time.Now()
+	for _, logEntry := range recentEntries {
+		if err := protocolEncoder.WriteArrayHeaderResponse(18); err != nil {
+			return err
+		}
+		entryFields := []string{
+			"count", strconv.FormatInt(logEntry.EntryCount, 10),
+			"reason", logEntry.DenialReason,
+			"context", logEntry.DenialContext,
+			"object", logEntry.DeniedObject,
+			"username", logEntry.UserName,
+			"age-seconds", strconv.FormatFloat(currentTime.Sub(logEntry.CreationTime).Seconds(), 'f', 3, 64),
+			"client-info", logEntry.ClientInfo,
+			"timestamp-created", strconv.FormatInt(logEntry.CreationTime.UnixMilli(), 10),
+			"timestamp-last-updated", strconv.FormatInt(logEntry.LastUpdatedTime.UnixMilli(), 10),
+		}
+		for _, entryField := range entryFields {
+			if err := protocolEncoder.WriteBulkStringResponse(entryField); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// disconnectDeletedUsers ferme les connexions authentifiées avec un utilisateur supprimé
+func (commandRegistry *RedisCommandRegistry) disconnectDeletedUsers(callingSession *session.ClientSession, deletedUserNames []string) {
+	if commandRegistry.clientSessionManager == nil {
+		return
+	}
+
+	deletedUsers := make(map[string]bool, len(deletedUserNames))
+	for _, userName := range deletedUserNames {
+		deletedUsers[userName] = true
+	}
+
+	for _, activeSession := range commandRegistry.clientSessionManager.ListSessions() {
+		if deletedUsers[activeSession.GetUserName()] {
+			commandRegistry.killClientSession(callingSession, activeSession)
+		}
+	}
+}
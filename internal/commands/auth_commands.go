@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"redis-go/internal/protocol"
+	"redis-go/internal/session"
+	"redis-go/internal/storage"
+)
+
+// commandsAllowedWithoutAuthentication liste les commandes acceptées avant AUTH
+var commandsAllowedWithoutAuthentication = map[string]bool{
+	"AUTH":  true,
+	"HELLO": true,
+	"PING":  true,
+	"QUIT":  true,
+}
+
+// SetRequiredPassword configure le mot de passe de l'utilisateur "default" (requirepass, vide = désactivé)
+func (commandRegistry *RedisCommandRegistry) SetRequiredPassword(requiredPassword string) {
+	commandRegistry.accessControlList.SetDefaultUserPassword(requiredPassword)
+}
+
+// RequiresAuthentication indique si les nouvelles connexions doivent s'authentifier
+func (commandRegistry *RedisCommandRegistry) RequiresAuthentication() bool {
+	return commandRegistry.accessControlList.DefaultUserRequiresAuthentication()
+}
+
+// authenticateSession vérifie les identifiants et met à jour la session
+// Retourne un message d'erreur vide si l'authentification réussit
+func (commandRegistry *RedisCommandRegistry) authenticateSession(clientSession *session.ClientSession, userName string, candidatePassword string) string {
+	if !commandRegistry.accessControlList.Authenticate(userName, candidatePassword) {
+		commandRegistry.commandStatistics.rejectedAuthenticationCount.Add(1)
+		commandRegistry.accessControlList.GetSecurityLog().RecordDenial("auth", "AUTH", userName, clientSession.FormatClientInfo())
+		return "WRONGPASS mot de passe invalide ou utilisateur inconnu"
+	}
+
+	clientSession.SetUserName(userName)
+	clientSession.SetAuthenticated(true)
+	return ""
+}
+
+// handleAuthCommand implémente AUTH [username] password
+func (commandRegistry *RedisCommandRegistry) handleAuthCommand(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	var userName, candidatePassword string
+	switch len(commandArguments) {
+	case 1:
+		// Forme historique : AUTH password n'a de sens que si default exige un mot de passe
+		if !commandRegistry.RequiresAuthentication() {
+			return protocolEncoder.WriteErrorResponse("ERREUR : AUTH appelé alors qu'aucun mot de passe n'est configuré pour l'utilisateur default")
+		}
+		userName, candidatePassword = "default", commandArguments[0]
+	case 2:
+		userName, candidatePassword = commandArguments[0], commandArguments[1]
+	default:
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'AUTH' (attendu: AUTH [utilisateur] mot_de_passe)")
+	}
+
+	if authenticationError := commandRegistry.authenticateSession(clientSession, userName, candidatePassword); authenticationError != "" {
+		return protocolEncoder.WriteErrorResponse(authenticationError)
+	}
+	return protocolEncoder.WriteSimpleStringResponse("OK")
+}
+
+// handleHelloCommand implémente HELLO [protover [AUTH username password] [SETNAME clientname]]
+func (commandRegistry *RedisCommandRegistry) handleHelloCommand(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) > 0 {
+		protocolVersion, parseError := strconv.Atoi(commandArguments[0])
+		if parseError != nil {
+			return protocolEncoder.WriteErrorResponse("ERREUR : la version du protocole doit être un nombre entier")
+		}
+		// Seul RESP2 est implémenté
+		if protocolVersion != 2 {
+			return protocolEncoder.WriteErrorResponse("NOPROTO version de protocole non supportée (seul RESP2 est disponible)")
+		}
+	}
+
+	clientName := ""
+	for argumentIndex := 1; argumentIndex < len(commandArguments); argumentIndex++ {
+		switch strings.ToUpper(commandArguments[argumentIndex]) {
+		case "AUTH":
+			if argumentIndex+2 >= len(commandArguments) {
+				return protocolEncoder.WriteErrorResponse("ERREUR : HELLO AUTH attend un utilisateur et un mot de passe")
+			}
+			if authenticationError := commandRegistry.authenticateSession(clientSession, commandArguments[argumentIndex+1], commandArguments[argumentIndex+2]); authenticationError != "" {
+				return protocolEncoder.WriteErrorResponse(authenticationError)
+			}
+			argumentIndex += 2
+		case "SETNAME":
+			if argumentIndex+1 >= len(commandArguments) {
+				return protocolEncoder.WriteErrorResponse("ERREUR : valeur manquante après 'SETNAME'")
+			}
+			clientName = commandArguments[argumentIndex+1]
+			argumentIndex++
+		default:
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : option inconnue '%s' pour HELLO", commandArguments[argumentIndex]))
+		}
+	}
+
+	if !clientSession.IsAuthenticated() {
+		commandRegistry.commandStatistics.unauthenticatedCommandCount.Add(1)
+		return protocolEncoder.WriteErrorResponse("NOAUTH HELLO doit être appelé avec AUTH lorsque l'authentification est requise")
+	}
+
+	if clientName != "" {
+		clientSession.SetClientName(clientName)
+	}
+
+	serverRole := "master"
+	if commandRegistry.replicationManager != nil {
+		serverRole = commandRegistry.replicationManager.RoleName()
+	}
+
+	return protocolEncoder.WriteArrayResponse([]string{
+		"server", "redis-go",
+		"version", "1.0",
+		"proto", "2",
+		"id", strconv.FormatInt(clientSession.ClientIdentifier, 10),
+		"mode", "standalone",
+		"role", serverRole,
+	})
+}
+
+// handleQuitCommand implémente QUIT (répond OK puis ferme la connexion)
+func (commandRegistry *RedisCommandRegistry) handleQuitCommand(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	clientSession.RequestCloseAfterReply()
+	return protocolEncoder.WriteSimpleStringResponse("OK")
+}
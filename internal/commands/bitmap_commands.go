@@ -0,0 +1,303 @@
+package commands
+
+import (
+	"strconv"
+	"strings"
+
+	"redis-go/internal/protocol"
+	"redis-go/internal/storage"
+)
+
+// parseBitOffset lit un offset de bit (0 à 2^32-1, comme Redis)
+func parseBitOffset(offsetArgument string) (uint64, bool) {
+	bitOffset, parseError := strconv.ParseUint(offsetArgument, 10, 64)
+	if parseError != nil || bitOffset >= storage.MaximumBitmapBits {
+		return 0, false
+	}
+	return bitOffset, true
+}
+
+// parseBitmapRangeUnit lit l'unité optionnelle des bornes de BITCOUNT / BITPOS (BYTE par défaut)
+func parseBitmapRangeUnit(unitArgument string) (bitUnit bool, validUnit bool) {
+	switch strings.ToUpper(unitArgument) {
+	case "BYTE":
+		return false, true
+	case "BIT":
+		return true, true
+	}
+	return false, false
+}
+
+// handleSetBitCommand implémente SETBIT key offset 0|1
+func (commandRegistry *RedisCommandRegistry) handleSetBitCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 3 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'SETBIT' (attendu: SETBIT clé offset 0|1)")
+	}
+
+	bitOffset, validOffset := parseBitOffset(commandArguments[1])
+	if !validOffset {
+		return protocolEncoder.WriteErrorResponse("ERREUR : l'offset de bit doit être un entier entre 0 et 4294967295")
+	}
+	if commandArguments[2] != "0" && commandArguments[2] != "1" {
+		return protocolEncoder.WriteErrorResponse("ERREUR : la valeur du bit doit être 0 ou 1")
+	}
+
+	previousBit, setError := redisStorage.SetBit(commandArguments[0], bitOffset, commandArguments[2] == "1")
+	if setError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + setError.Error())
+	}
+	return protocolEncoder.WriteIntegerResponse(int64(previousBit))
+}
+
+// handleGetBitCommand implémente GETBIT key offset
+func (commandRegistry *RedisCommandRegistry) handleGetBitCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'GETBIT' (attendu: GETBIT clé offset)")
+	}
+
+	bitOffset, validOffset := parseBitOffset(commandArguments[1])
+	if !validOffset {
+		return protocolEncoder.WriteErrorResponse("ERREUR : l'offset de bit doit être un entier entre 0 et 4294967295")
+	}
+
+	bitValue, getError := redisStorage.GetBit(commandArguments[0], bitOffset)
+	if getError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + getError.Error())
+	}
+	return protocolEncoder.WriteIntegerResponse(int64(bitValue))
+}
+
+// handleBitCountCommand implémente BITCOUNT key [start end [BYTE|BIT]]
+func (commandRegistry *RedisCommandRegistry) handleBitCountCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 1 && len(commandArguments) != 3 && len(commandArguments) != 4 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'BITCOUNT' (attendu: BITCOUNT clé [début fin [BYTE|BIT]])")
+	}
+
+	hasRange := len(commandArguments) > 1
+	var rangeStart, rangeEnd int64
+	bitUnit := false
+	if hasRange {
+		var startError, endError error
+		rangeStart, startError = strconv.ParseInt(commandArguments[1], 10, 64)
+		rangeEnd, endError = strconv.ParseInt(commandArguments[2], 10, 64)
+		if startError != nil || endError != nil {
+			return protocolEncoder.WriteErrorResponse("ERREUR : les bornes doivent être des nombres entiers")
+		}
+		if len(commandArguments) == 4 {
+			var validUnit bool
+			if bitUnit, validUnit = parseBitmapRangeUnit(commandArguments[3]); !validUnit {
+				return protocolEncoder.WriteErrorResponse("ERREUR : unité inconnue '" + commandArguments[3] + "' (BYTE ou BIT)")
+			}
+		}
+	}
+
+	bitCount, countError := redisStorage.CountBits(commandArguments[0], hasRange, rangeStart, rangeEnd, bitUnit)
+	if countError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + countError.Error())
+	}
+	return protocolEncoder.WriteIntegerResponse(bitCount)
+}
+
+// handleBitPositionCommand implémente BITPOS key 0|1 [start [end [BYTE|BIT]]]
+func (commandRegistry *RedisCommandRegistry) handleBitPositionCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 2 || len(commandArguments) > 5 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'BITPOS' (attendu: BITPOS clé 0|1 [début [fin [BYTE|BIT]]])")
+	}
+	if commandArguments[1] != "0" && commandArguments[1] != "1" {
+		return protocolEncoder.WriteErrorResponse("ERREUR : le bit recherché doit être 0 ou 1")
+	}
+
+	hasStart, hasEnd := len(commandArguments) >= 3, len(commandArguments) >= 4
+	var rangeStart, rangeEnd int64
+	var parseError error
+	if hasStart {
+		if rangeStart, parseError = strconv.ParseInt(commandArguments[2], 10, 64); parseError != nil {
+			return protocolEncoder.WriteErrorResponse("ERREUR : les bornes doivent être des nombres entiers")
+		}
+	}
+	if hasEnd {
+		if rangeEnd, parseError = strconv.ParseInt(commandArguments[3], 10, 64); parseError != nil {
+			return protocolEncoder.WriteErrorResponse("ERREUR : les bornes doivent être des nombres entiers")
+		}
+	}
+	bitUnit := false
+	if len(commandArguments) == 5 {
+		var validUnit bool
+		if bitUnit, validUnit = parseBitmapRangeUnit(commandArguments[4]); !validUnit {
+			return protocolEncoder.WriteErrorResponse("ERREUR : unité inconnue '" + commandArguments[4] + "' (BYTE ou BIT)")
+		}
+	}
+
+	bitPosition, positionError := redisStorage.FindBitPosition(commandArguments[0], commandArguments[1] == "1", hasStart, rangeStart, hasEnd, rangeEnd, bitUnit)
+	if positionError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + positionError.Error())
+	}
+	return protocolEncoder.WriteIntegerResponse(bitPosition)
+}
+
+// handleBitOperationCommand implémente BITOP AND|OR|XOR|NOT destination key [key ...]
+func (commandRegistry *RedisCommandRegistry) handleBitOperationCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 3 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'BITOP' (attendu: BITOP AND|OR|XOR|NOT destination clé [clé ...])")
+	}
+
+	var bitwiseOperation storage.BitwiseOperation
+	switch strings.ToUpper(commandArguments[0]) {
+	case "AND":
+		bitwiseOperation = storage.BitwiseAnd
+	case "OR":
+		bitwiseOperation = storage.BitwiseOr
+	case "XOR":
+		bitwiseOperation = storage.BitwiseXor
+	case "NOT":
+		bitwiseOperation = storage.BitwiseNot
+		if len(commandArguments) != 3 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : BITOP NOT prend une seule clé source")
+		}
+	default:
+		return protocolEncoder.WriteErrorResponse("ERREUR : opération BITOP inconnue '" + commandArguments[0] + "' (AND, OR, XOR, NOT)")
+	}
+
+	resultLength, operationError := redisStorage.ApplyBitwiseOperation(bitwiseOperation, commandArguments[1], commandArguments[2:])
+	if operationError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + operationError.Error())
+	}
+	return protocolEncoder.WriteIntegerResponse(resultLength)
+}
+
+// handleBitfieldCommand implémente BITFIELD key [GET type offset] [SET type offset valeur]
+// [INCRBY type offset incrément] [OVERFLOW WRAP|SAT|FAIL] ...
+func (commandRegistry *RedisCommandRegistry) handleBitfieldCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	return executeBitfield("BITFIELD", commandArguments, false, redisStorage, protocolEncoder)
+}
+
+// handleBitfieldReadOnlyCommand implémente BITFIELD_RO key GET type offset [GET type offset ...]
+func (commandRegistry *RedisCommandRegistry) handleBitfieldReadOnlyCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	return executeBitfield("BITFIELD_RO", commandArguments, true, redisStorage, protocolEncoder)
+}
+
+// executeBitfield analyse toutes les sous-commandes avant d'en exécuter une seule, puis les applique atomiquement
+func executeBitfield(commandName string, commandArguments []string, readOnly bool, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour '" + commandName + "' (attendu: " + commandName + " clé [GET type offset] ...)")
+	}
+
+	var bitfieldOperations []storage.BitfieldOperation
+	currentOverflow := storage.BitfieldOverflowWrap
+	for argumentIndex := 1; argumentIndex < len(commandArguments); {
+		subcommandName := strings.ToUpper(commandArguments[argumentIndex])
+		if readOnly && subcommandName != "GET" {
+			return protocolEncoder.WriteErrorResponse("ERREUR : BITFIELD_RO n'accepte que des sous-commandes GET")
+		}
+
+		switch subcommandName {
+		case "OVERFLOW":
+			if argumentIndex+1 >= len(commandArguments) {
+				return protocolEncoder.WriteErrorResponse("ERREUR : OVERFLOW attend WRAP, SAT ou FAIL")
+			}
+			switch strings.ToUpper(commandArguments[argumentIndex+1]) {
+			case "WRAP":
+				currentOverflow = storage.BitfieldOverflowWrap
+			case "SAT":
+				currentOverflow = storage.BitfieldOverflowSaturate
+			case "FAIL":
+				currentOverflow = storage.BitfieldOverflowFail
+			default:
+				return protocolEncoder.WriteErrorResponse("ERREUR : mode OVERFLOW inconnu '" + commandArguments[argumentIndex+1] + "' (WRAP, SAT, FAIL)")
+			}
+			argumentIndex += 2
+
+		case "GET", "SET", "INCRBY":
+			operationArity := 3
+			if subcommandName == "GET" {
+				operationArity = 2
+			}
+			if argumentIndex+operationArity >= len(commandArguments) {
+				return protocolEncoder.WriteErrorResponse("ERREUR : arguments manquants pour la sous-commande BITFIELD " + subcommandName)
+			}
+			bitfieldOperation, parseErrorMessage := parseBitfieldOperation(subcommandName, commandArguments[argumentIndex+1:argumentIndex+1+operationArity])
+			if parseErrorMessage != "" {
+				return protocolEncoder.WriteErrorResponse("ERREUR : " + parseErrorMessage)
+			}
+			bitfieldOperation.Overflow = currentOverflow
+			bitfieldOperations = append(bitfieldOperations, bitfieldOperation)
+			argumentIndex += 1 + operationArity
+
+		default:
+			return protocolEncoder.WriteErrorResponse("ERREUR : sous-commande BITFIELD inconnue '" + commandArguments[argumentIndex] + "' (GET, SET, INCRBY, OVERFLOW)")
+		}
+	}
+
+	bitfieldResults, bitfieldError := redisStorage.ApplyBitfieldOperations(commandArguments[0], bitfieldOperations)
+	if bitfieldError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + bitfieldError.Error())
+	}
+
+	if err := protocolEncoder.WriteArrayHeaderResponse(len(bitfieldResults)); err != nil {
+		return err
+	}
+	for _, bitfieldResult := range bitfieldResults {
+		if bitfieldResult.Failed {
+			if err := protocolEncoder.WriteNullBulkStringResponse(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := protocolEncoder.WriteIntegerResponse(bitfieldResult.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseBitfieldOperation lit "type offset [valeur]" : type i1-i64 ou u1-u63, offset absolu ou #n (n × largeur)
+// Retourne un message d'erreur non vide si un argument est invalide
+func parseBitfieldOperation(subcommandName string, operationArguments []string) (storage.BitfieldOperation, string) {
+	var bitfieldOperation storage.BitfieldOperation
+	switch subcommandName {
+	case "GET":
+		bitfieldOperation.OperationKind = storage.BitfieldGet
+	case "SET":
+		bitfieldOperation.OperationKind = storage.BitfieldSet
+	case "INCRBY":
+		bitfieldOperation.OperationKind = storage.BitfieldIncrementBy
+	}
+
+	typeArgument := strings.ToLower(operationArguments[0])
+	if len(typeArgument) < 2 || (typeArgument[0] != 'i' && typeArgument[0] != 'u') {
+		return bitfieldOperation, "type BITFIELD invalide '" + operationArguments[0] + "' (i1-i64 ou u1-u63)"
+	}
+	bitfieldOperation.Signed = typeArgument[0] == 'i'
+	fieldWidth, widthError := strconv.Atoi(typeArgument[1:])
+	maximumWidth := 63
+	if bitfieldOperation.Signed {
+		maximumWidth = 64
+	}
+	if widthError != nil || fieldWidth < 1 || fieldWidth > maximumWidth {
+		return bitfieldOperation, "type BITFIELD invalide '" + operationArguments[0] + "' (i1-i64 ou u1-u63)"
+	}
+	bitfieldOperation.Width = uint(fieldWidth)
+
+	offsetArgument := operationArguments[1]
+	multiplyByWidth := strings.HasPrefix(offsetArgument, "#")
+	bitOffset, offsetError := strconv.ParseUint(strings.TrimPrefix(offsetArgument, "#"), 10, 64)
+	if offsetError != nil || bitOffset >= storage.MaximumBitmapBits {
+		return bitfieldOperation, "l'offset BITFIELD doit être un entier entre 0 et 4294967295 (ou #n)"
+	}
+	if multiplyByWidth {
+		bitOffset *= uint64(fieldWidth)
+	}
+	if bitOffset+uint64(fieldWidth) > storage.MaximumBitmapBits {
+		return bitfieldOperation, "l'offset BITFIELD doit être un entier entre 0 et 4294967295 (ou #n)"
+	}
+	bitfieldOperation.BitOffset = bitOffset
+
+	if bitfieldOperation.OperationKind != storage.BitfieldGet {
+		fieldValue, valueError := strconv.ParseInt(operationArguments[2], 10, 64)
+		if valueError != nil {
+			return bitfieldOperation, "la valeur BITFIELD doit être un nombre entier"
+		}
+		bitfieldOperation.Value = fieldValue
+	}
+	return bitfieldOperation, ""
+}
@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"redis-go/internal/protocol"
+	"redis-go/internal/session"
+	"redis-go/internal/storage"
+)
+
+// SetClientSessionManager configure le gestionnaire de sessions pour les commandes CLIENT
+func (commandRegistry *RedisCommandRegistry) SetClientSessionManager(sessionManager *session.ClientSessionManager) {
+	commandRegistry.clientSessionManager = sessionManager
+
+	commandRegistry.registeredSessionCommands["CLIENT"] = commandRegistry.handleClientCommand
+}
+
+// handleClientCommand implémente CLIENT <sous-commande> [arguments ...]
+func (commandRegistry *RedisCommandRegistry) handleClientCommand(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) == 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'CLIENT' (attendu: CLIENT sous-commande [arguments ...])")
+	}
+
+	subcommandName := strings.ToUpper(commandArguments[0])
+	subcommandArguments := commandArguments[1:]
+
+	switch subcommandName {
+	case "LIST":
+		return commandRegistry.handleClientListSubcommand(subcommandArguments, protocolEncoder)
+	case "KILL":
+		return commandRegistry.handleClientKillSubcommand(clientSession, subcommandArguments, protocolEncoder)
+	case "SETNAME":
+		if len(subcommandArguments) != 1 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'CLIENT SETNAME' (attendu: CLIENT SETNAME nom)")
+		}
+		if strings.ContainsAny(subcommandArguments[0], " \n\r") {
+			return protocolEncoder.WriteErrorResponse("ERREUR : le nom du client ne peut pas contenir d'espaces ou de retours à la ligne")
+		}
+		clientSession.SetClientName(subcommandArguments[0])
+		return protocolEncoder.WriteSimpleStringResponse("OK")
+	case "GETNAME":
+		clientName := clientSession.GetClientName()
+		if clientName == "" {
+			return protocolEncoder.WriteNullBulkStringResponse()
+		}
+		return protocolEncoder.WriteBulkStringResponse(clientName)
+	case "ID":
+		return protocolEncoder.WriteIntegerResponse(clientSession.ClientIdentifier)
+	case "INFO":
+		return protocolEncoder.WriteBulkStringResponse(clientSession.FormatClientInfo() + "\n")
+	case "NO-EVICT":
+		if len(subcommandArguments) != 1 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'CLIENT NO-EVICT' (attendu: CLIENT NO-EVICT ON|OFF)")
+		}
+		switch strings.ToUpper(subcommandArguments[0]) {
+		case "ON":
+			clientSession.SetNoEvict(true)
+		case "OFF":
+			clientSession.SetNoEvict(false)
+		default:
+			return protocolEncoder.WriteErrorResponse("ERREUR : CLIENT NO-EVICT attend ON ou OFF")
+		}
+		return protocolEncoder.WriteSimpleStringResponse("OK")
+	case "DURABILITY":
+		return commandRegistry.handleClientDurabilitySubcommand(clientSession, subcommandArguments, protocolEncoder)
+	case "REPLY":
+		if len(subcommandArguments) != 1 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'CLIENT REPLY' (attendu: CLIENT REPLY ON|OFF|SKIP)")
+		}
+		switch strings.ToUpper(subcommandArguments[0]) {
+		case "ON":
+			clientSession.SetReplyMode(session.ClientReplyOn)
+			return protocolEncoder.WriteSimpleStringResponse("OK")
+		case "OFF":
+			clientSession.SetReplyMode(session.ClientReplyOff)
+			return nil
+		case "SKIP":
+			clientSession.SetReplyMode(session.ClientReplySkip)
+			return nil
+		default:
+			return protocolEncoder.WriteErrorResponse("ERREUR : CLIENT REPLY attend ON, OFF ou SKIP")
+		}
+	default:
+		return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : sous-commande CLIENT inconnue '%s'", commandArguments[0]))
+	}
+}
+
+// handleClientListSubcommand implémente CLIENT LIST [TYPE normal|master|replica|pubsub] [ID id [id ...]]
+func (commandRegistry *RedisCommandRegistry) handleClientListSubcommand(subcommandArguments []string, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	typeFilter := ""
+	var identifierFilter map[int64]bool
+
+	for argumentIndex := 0; argumentIndex < len(subcommandArguments); argumentIndex++ {
+		switch strings.ToUpper(subcommandArguments[argumentIndex]) {
+		case "TYPE":
+			if argumentIndex+1 >= len(subcommandArguments) {
+				return protocolEncoder.WriteErrorResponse("ERREUR : valeur manquante après 'TYPE'")
+			}
+			typeFilter = strings.ToLower(subcommandArguments[argumentIndex+1])
+			switch typeFilter {
+			case "normal", "master", "replica", "slave", "pubsub":
+			default:
+				return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : type de client inconnu '%s'", subcommandArguments[argumentIndex+1]))
+			}
+			if typeFilter == "slave" {
+				typeFilter = "replica"
+			}
+			argumentIndex++
+		case "ID":
+			if argumentIndex+1 >= len(subcommandArguments) {
+				return protocolEncoder.WriteErrorResponse("ERREUR : au moins un ID est attendu après 'ID'")
+			}
+			identifierFilter = make(map[int64]bool)
+			for argumentIndex+1 < len(subcommandArguments) {
+				clientIdentifier, parseError := strconv.ParseInt(subcommandArguments[argumentIndex+1], 10, 64)
+				if parseError != nil {
+					return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : ID de client invalide '%s'", subcommandArguments[argumentIndex+1]))
+				}
+				identifierFilter[clientIdentifier] = true
+				argumentIndex++
+			}
+		default:
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : option inconnue '%s' pour CLIENT LIST", subcommandArguments[argumentIndex]))
+		}
+	}
+
+	var clientListResponse strings.Builder
+	for _, listedSession := range commandRegistry.clientSessionManager.ListSessions() {
+		if typeFilter != "" && session.ClientTypeName(listedSession.GetClientType()) != typeFilter {
+			continue
+		}
+		if identifierFilter != nil && !identifierFilter[listedSession.ClientIdentifier] {
+			continue
+		}
+		clientListResponse.WriteString(listedSession.FormatClientInfo())
+		clientListResponse.WriteString("\n")
+	}
+
+	return protocolEncoder.WriteBulkStringResponse(clientListResponse.String())
+}
+
+// handleClientKillSubcommand implémente CLIENT KILL ip:port | [ID id] [TYPE type] [ADDR ip:port] [LADDR ip:port] [USER nom] [SKIPME yes|no]
+func (commandRegistry *RedisCommandRegistry) handleClientKillSubcommand(clientSession *session.ClientSession, subcommandArguments []string, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(subcommandArguments) == 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'CLIENT KILL' (attendu: CLIENT KILL ip:port | filtre valeur [filtre valeur ...])")
+	}
+
+	// Ancienne syntaxe : CLIENT KILL ip:port, répond OK ou une erreur
+	if len(subcommandArguments) == 1 {
+		for _, candidateSession := range commandRegistry.clientSessionManager.ListSessions() {
+			if candidateSession.RemoteAddress == subcommandArguments[0] {
+				commandRegistry.killClientSession(clientSession, candidateSession)
+				return protocolEncoder.WriteSimpleStringResponse("OK")
+			}
+		}
+		return protocolEncoder.WriteErrorResponse("ERREUR : aucun client avec cette adresse")
+	}
+
+	if len(subcommandArguments)%2 != 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : les filtres de CLIENT KILL vont par paires (filtre valeur)")
+	}
+
+	var identifierFilter *int64
+	typeFilter := ""
+	addressFilter := ""
+	localAddressFilter := ""
+	userFilter := ""
+	skipCallingClient := true
+
+	for argumentIndex := 0; argumentIndex < len(subcommandArguments); argumentIndex += 2 {
+		filterValue := subcommandArguments[argumentIndex+1]
+		switch strings.ToUpper(subcommandArguments[argumentIndex]) {
+		case "ID":
+			clientIdentifier, parseError := strconv.ParseInt(filterValue, 10, 64)
+			if parseError != nil {
+				return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : ID de client invalide '%s'", filterValue))
+			}
+			identifierFilter = &clientIdentifier
+		case "TYPE":
+			typeFilter = strings.ToLower(filterValue)
+			switch typeFilter {
+			case "normal", "master", "replica", "slave", "pubsub":
+			default:
+				return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : type de client inconnu '%s'", filterValue))
+			}
+			if typeFilter == "slave" {
+				typeFilter = "replica"
+			}
+		case "ADDR":
+			addressFilter = filterValue
+		case "LADDR":
+			localAddressFilter = filterValue
+		case "USER":
+			userFilter = filterValue
+		case "SKIPME":
+			switch strings.ToLower(filterValue) {
+			case "yes":
+				skipCallingClient = true
+			case "no":
+				skipCallingClient = false
+			default:
+				return protocolEncoder.WriteErrorResponse("ERREUR : SKIPME attend yes ou no")
+			}
+		default:
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : filtre inconnu '%s' pour CLIENT KILL", subcommandArguments[argumentIndex]))
+		}
+	}
+
+	killedClientCount := int64(0)
+	for _, candidateSession := range commandRegistry.clientSessionManager.ListSessions() {
+		if identifierFilter != nil && candidateSession.ClientIdentifier != *identifierFilter {
+			continue
+		}
+		if typeFilter != "" && session.ClientTypeName(candidateSession.GetClientType()) != typeFilter {
+			continue
+		}
+		if addressFilter != "" && candidateSession.RemoteAddress != addressFilter {
+			continue
+		}
+		if localAddressFilter != "" && candidateSession.LocalAddress != localAddressFilter {
+			continue
+		}
+		if userFilter != "" && candidateSession.GetUserName() != userFilter {
+			continue
+		}
+		if skipCallingClient && candidateSession == clientSession {
+			continue
+		}
+
+		commandRegistry.killClientSession(clientSession, candidateSession)
+		killedClientCount++
+	}
+
+	return protocolEncoder.WriteIntegerResponse(killedClientCount)
+}
+
+// killClientSession ferme la connexion ciblée
+// Le client appelant n'est fermé qu'après avoir reçu sa réponse
+func (commandRegistry *RedisCommandRegistry) killClientSession(callingSession *session.ClientSession, targetSession *session.ClientSession) {
+	if targetSession == callingSession {
+		targetSession.RequestCloseAfterReply()
+		return
+	}
+	targetSession.Close()
+}
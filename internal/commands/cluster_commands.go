@@ -0,0 +1,309 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"redis-go/internal/cluster"
+	"redis-go/internal/protocol"
+	"redis-go/internal/session"
+	"redis-go/internal/storage"
+)
+
+// SetClusterManager active le mode cluster : routage des clés par slot, CLUSTER et ASKING
+func (commandRegistry *RedisCommandRegistry) SetClusterManager(manager *cluster.ClusterManager) {
+	commandRegistry.clusterManager = manager
+
+	commandRegistry.registeredCommands["CLUSTER"] = commandRegistry.handleClusterCommand
+	commandRegistry.registeredSessionCommands["ASKING"] = commandRegistry.handleAskingCommand
+}
+
+// checkClusterRouting vérifie que les clés d'une commande peuvent être servies par ce nœud
+// Retourne l'erreur à renvoyer au client (CROSSSLOT, MOVED, ASK, TRYAGAIN, CLUSTERDOWN), ou "" si la commande s'exécute ici
+func (commandRegistry *RedisCommandRegistry) checkClusterRouting(clientSession *session.ClientSession, upperCommandName string, commandArguments []string, redisStorage *storage.RedisInMemoryStorage) string {
+	if upperCommandName == "ASKING" {
+		return ""
+	}
+	// ASKING ne vaut que pour la commande qui le suit, quelle qu'elle soit ; RESTORE-ASKING l'implique
+	askingRequested := clientSession.ConsumeAsking() || upperCommandName == "RESTORE-ASKING"
+
+	_, commandMetadata, metadataExists := lookupCommandMetadata(upperCommandName, commandArguments)
+	if !metadataExists {
+		return ""
+	}
+	commandKeys := extractCommandKeys(commandMetadata, commandArguments)
+	if len(commandKeys) == 0 {
+		return ""
+	}
+
+	hashSlot := cluster.KeyHashSlot(commandKeys[0])
+	for _, commandKey := range commandKeys[1:] {
+		if cluster.KeyHashSlot(commandKey) != hashSlot {
+			return "CROSSSLOT les clés de la commande ne sont pas dans le même slot (utiliser un hashtag {...})"
+		}
+	}
+
+	clusterTopology := commandRegistry.clusterManager.Topology()
+	slotOwner := clusterTopology.SlotOwner(hashSlot)
+	if slotOwner == nil {
+		return fmt.Sprintf("CLUSTERDOWN le slot %d n'est servi par aucun nœud", hashSlot)
+	}
+
+	if slotOwner == clusterTopology.Myself() {
+		migrationTarget := clusterTopology.MigratingTarget(hashSlot)
+		if migrationTarget == nil {
+			return ""
+		}
+		// Slot en migration : les clés absentes ont déjà été déplacées (ou seront créées) sur la cible
+		missingKeyCount := 0
+		for _, commandKey := range commandKeys {
+			if !redisStorage.CheckKeyExists(commandKey) {
+				missingKeyCount++
+			}
+		}
+		switch missingKeyCount {
+		case 0:
+			return ""
+		case len(commandKeys):
+			return fmt.Sprintf("ASK %d %s", hashSlot, migrationTarget.Address())
+		default:
+			return "TRYAGAIN clés réparties entre deux nœuds pendant la migration du slot, réessayer plus tard"
+		}
+	}
+
+	// Slot en import : accepté uniquement juste après ASKING (redirection ASK de la source)
+	if askingRequested && clusterTopology.ImportingSource(hashSlot) != nil {
+		return ""
+	}
+	return fmt.Sprintf("MOVED %d %s", hashSlot, slotOwner.Address())
+}
+
+// handleAskingCommand implémente ASKING
+func (commandRegistry *RedisCommandRegistry) handleAskingCommand(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : ASKING ne prend aucun argument")
+	}
+	clientSession.SetAsking()
+	return protocolEncoder.WriteSimpleStringResponse("OK")
+}
+
+// handleClusterCommand implémente CLUSTER <sous-commande> [arguments ...]
+func (commandRegistry *RedisCommandRegistry) handleClusterCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) == 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'CLUSTER' (attendu: CLUSTER <sous-commande> [arguments ...])")
+	}
+
+	subcommandArguments := commandArguments[1:]
+	switch strings.ToUpper(commandArguments[0]) {
+	case "KEYSLOT":
+		if len(subcommandArguments) != 1 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'CLUSTER KEYSLOT' (attendu: CLUSTER KEYSLOT clé)")
+		}
+		return protocolEncoder.WriteIntegerResponse(int64(cluster.KeyHashSlot(subcommandArguments[0])))
+	case "COUNTKEYSINSLOT":
+		if len(subcommandArguments) != 1 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'CLUSTER COUNTKEYSINSLOT' (attendu: CLUSTER COUNTKEYSINSLOT slot)")
+		}
+		hashSlot, parseError := parseHashSlot(subcommandArguments[0])
+		if parseError != "" {
+			return protocolEncoder.WriteErrorResponse(parseError)
+		}
+		return protocolEncoder.WriteIntegerResponse(int64(len(findKeysInSlot(redisStorage, hashSlot, 0))))
+	case "GETKEYSINSLOT":
+		if len(subcommandArguments) != 2 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'CLUSTER GETKEYSINSLOT' (attendu: CLUSTER GETKEYSINSLOT slot nombre)")
+		}
+		hashSlot, parseError := parseHashSlot(subcommandArguments[0])
+		if parseError != "" {
+			return protocolEncoder.WriteErrorResponse(parseError)
+		}
+		maximumKeys, countError := strconv.Atoi(subcommandArguments[1])
+		if countError != nil || maximumKeys < 0 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : le nombre de clés doit être un entier positif")
+		}
+		if maximumKeys == 0 {
+			return protocolEncoder.WriteArrayHeaderResponse(0)
+		}
+		return protocolEncoder.WriteArrayResponse(findKeysInSlot(redisStorage, hashSlot, maximumKeys))
+	case "MYID":
+		return protocolEncoder.WriteBulkStringResponse(commandRegistry.clusterManager.Topology().Myself().NodeIdentifier)
+	case "INFO":
+		return protocolEncoder.WriteBulkStringResponse(commandRegistry.formatClusterInfo(commandRegistry.clusterManager.Topology()))
+	case "NODES":
+		return protocolEncoder.WriteBulkStringResponse(formatClusterNodes(commandRegistry.clusterManager.Topology()))
+	case "SLOTS":
+		return writeClusterSlots(commandRegistry.clusterManager.Topology(), protocolEncoder)
+	case "SHARDS":
+		return writeClusterShards(commandRegistry.clusterManager.Topology(), protocolEncoder)
+	case "RELOAD":
+		if reloadError := commandRegistry.clusterManager.Reload(); reloadError != nil {
+			log.Printf("⚠️ CLUSTER RELOAD refusé, topologie précédente conservée : %v", reloadError)
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : topologie invalide, précédente conservée: %v", reloadError))
+		}
+		return protocolEncoder.WriteSimpleStringResponse("OK")
+	default:
+		return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : sous-commande CLUSTER inconnue '%s' (KEYSLOT, COUNTKEYSINSLOT, GETKEYSINSLOT, MYID, INFO, NODES, SLOTS, SHARDS, RELOAD)", commandArguments[0]))
+	}
+}
+
+// parseHashSlot lit un numéro de slot ; retourne le message d'erreur s'il est invalide
+func parseHashSlot(slotText string) (int, string) {
+	hashSlot, parseError := strconv.Atoi(slotText)
+	if parseError != nil || hashSlot < 0 || hashSlot >= cluster.HashSlotCount {
+		return 0, fmt.Sprintf("ERREUR : slot invalide '%s' (0 à %d)", slotText, cluster.HashSlotCount-1)
+	}
+	return hashSlot, ""
+}
+
+// findKeysInSlot parcourt les clés de ce nœud et retourne celles du slot (maximumKeys = 0 : toutes)
+func findKeysInSlot(redisStorage *storage.RedisInMemoryStorage, hashSlot int, maximumKeys int) []string {
+	return redisStorage.FindKeysMatching(func(storageKey string) bool {
+		return cluster.KeyHashSlot(storageKey) == hashSlot
+	}, maximumKeys)
+}
+
+// formatClusterInfo produit la réponse de CLUSTER INFO
+func (commandRegistry *RedisCommandRegistry) formatClusterInfo(clusterTopology *cluster.ClusterTopology) string {
+	assignedSlots := clusterTopology.AssignedSlotCount()
+	clusterState := "ok"
+	if assignedSlots < cluster.HashSlotCount {
+		clusterState = "fail"
+	}
+	clusterSize := 0
+	for _, clusterNode := range clusterTopology.Nodes() {
+		if clusterNode.IsMaster() && len(clusterTopology.SlotRangesOf(clusterNode)) > 0 {
+			clusterSize++
+		}
+	}
+
+	var clusterInfo strings.Builder
+	fmt.Fprintf(&clusterInfo, "cluster_enabled:1\r\n")
+	fmt.Fprintf(&clusterInfo, "cluster_state:%s\r\n", clusterState)
+	fmt.Fprintf(&clusterInfo, "cluster_slots_assigned:%d\r\n", assignedSlots)
+	fmt.Fprintf(&clusterInfo, "cluster_slots_ok:%d\r\n", assignedSlots)
+	fmt.Fprintf(&clusterInfo, "cluster_slots_pfail:0\r\n")
+	fmt.Fprintf(&clusterInfo, "cluster_slots_fail:0\r\n")
+	fmt.Fprintf(&clusterInfo, "cluster_known_nodes:%d\r\n", len(clusterTopology.Nodes()))
+	fmt.Fprintf(&clusterInfo, "cluster_size:%d\r\n", clusterSize)
+	fmt.Fprintf(&clusterInfo, "cluster_current_epoch:0\r\n")
+	fmt.Fprintf(&clusterInfo, "cluster_my_epoch:0\r\n")
+	fmt.Fprintf(&clusterInfo, "cluster_topology_file:%s\r\n", commandRegistry.clusterManager.TopologyFilePath())
+	return clusterInfo.String()
+}
+
+// formatClusterNodes produit la réponse de CLUSTER NODES, une ligne par nœud :
+// <id> <hôte:port@port-bus> <flags> <maître|-> <ping> <pong> <epoch> <état> <slots ...>
+// La topologie étant statique, il n'y a pas de bus : le port annoncé est port+10000 comme dans Redis
+func formatClusterNodes(clusterTopology *cluster.ClusterTopology) string {
+	var clusterNodes strings.Builder
+	for _, clusterNode := range clusterTopology.Nodes() {
+		nodeFlags := "master"
+		masterIdentifier := "-"
+		if !clusterNode.IsMaster() {
+			nodeFlags = "slave"
+			masterIdentifier = clusterNode.MasterNodeIdentifier
+		}
+		if clusterNode == clusterTopology.Myself() {
+			nodeFlags = "myself," + nodeFlags
+		}
+
+		fmt.Fprintf(&clusterNodes, "%s %s@%d %s %s 0 0 0 connected", clusterNode.NodeIdentifier, clusterNode.Address(),
+			clusterNode.PortNumber+10000, nodeFlags, masterIdentifier)
+		for _, slotRange := range clusterTopology.SlotRangesOf(clusterNode) {
+			if slotRange.StartSlot == slotRange.EndSlot {
+				fmt.Fprintf(&clusterNodes, " %d", slotRange.StartSlot)
+			} else {
+				fmt.Fprintf(&clusterNodes, " %d-%d", slotRange.StartSlot, slotRange.EndSlot)
+			}
+		}
+		for _, migrationDescription := range clusterTopology.MigratingSlotsOf(clusterNode) {
+			clusterNodes.WriteString(" " + migrationDescription)
+		}
+		clusterNodes.WriteString("\n")
+	}
+	return clusterNodes.String()
+}
+
+// writeClusterSlots écrit la réponse de CLUSTER SLOTS :
+// pour chaque intervalle, [début, fin, [hôte, port, id] du maître, [hôte, port, id] de chaque réplica]
+func writeClusterSlots(clusterTopology *cluster.ClusterTopology, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	type ownedSlotRange struct {
+		slotRange  cluster.SlotRange
+		masterNode *cluster.ClusterNode
+	}
+	var ownedSlotRanges []ownedSlotRange
+	for _, clusterNode := range clusterTopology.Nodes() {
+		for _, slotRange := range clusterTopology.SlotRangesOf(clusterNode) {
+			ownedSlotRanges = append(ownedSlotRanges, ownedSlotRange{slotRange: slotRange, masterNode: clusterNode})
+		}
+	}
+
+	protocolEncoder.WriteArrayHeaderResponse(len(ownedSlotRanges))
+	for _, ownedRange := range ownedSlotRanges {
+		replicaNodes := clusterTopology.ReplicasOf(ownedRange.masterNode)
+		protocolEncoder.WriteArrayHeaderResponse(3 + len(replicaNodes))
+		protocolEncoder.WriteIntegerResponse(int64(ownedRange.slotRange.StartSlot))
+		protocolEncoder.WriteIntegerResponse(int64(ownedRange.slotRange.EndSlot))
+		for _, clusterNode := range append([]*cluster.ClusterNode{ownedRange.masterNode}, replicaNodes...) {
+			protocolEncoder.WriteArrayHeaderResponse(3)
+			protocolEncoder.WriteBulkStringResponse(clusterNode.HostAddress)
+			protocolEncoder.WriteIntegerResponse(int64(clusterNode.PortNumber))
+			if writeError := protocolEncoder.WriteBulkStringResponse(clusterNode.NodeIdentifier); writeError != nil {
+				return writeError
+			}
+		}
+	}
+	return nil
+}
+
+// writeClusterShards écrit la réponse de CLUSTER SHARDS : un shard par maître, avec ses slots et ses nœuds
+func writeClusterShards(clusterTopology *cluster.ClusterTopology, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	var masterNodes []*cluster.ClusterNode
+	for _, clusterNode := range clusterTopology.Nodes() {
+		if clusterNode.IsMaster() {
+			masterNodes = append(masterNodes, clusterNode)
+		}
+	}
+
+	protocolEncoder.WriteArrayHeaderResponse(len(masterNodes))
+	for _, masterNode := range masterNodes {
+		slotRanges := clusterTopology.SlotRangesOf(masterNode)
+		protocolEncoder.WriteArrayHeaderResponse(4)
+		protocolEncoder.WriteBulkStringResponse("slots")
+		protocolEncoder.WriteArrayHeaderResponse(2 * len(slotRanges))
+		for _, slotRange := range slotRanges {
+			protocolEncoder.WriteIntegerResponse(int64(slotRange.StartSlot))
+			protocolEncoder.WriteIntegerResponse(int64(slotRange.EndSlot))
+		}
+
+		shardNodes := append([]*cluster.ClusterNode{masterNode}, clusterTopology.ReplicasOf(masterNode)...)
+		protocolEncoder.WriteBulkStringResponse("nodes")
+		protocolEncoder.WriteArrayHeaderResponse(len(shardNodes))
+		for _, clusterNode := range shardNodes {
+			nodeRole := "master"
+			if !clusterNode.IsMaster() {
+				nodeRole = "replica"
+			}
+			protocolEncoder.WriteArrayHeaderResponse(14)
+			protocolEncoder.WriteBulkStringResponse("id")
+			protocolEncoder.WriteBulkStringResponse(clusterNode.NodeIdentifier)
+			protocolEncoder.WriteBulkStringResponse("port")
+			protocolEncoder.WriteIntegerResponse(int64(clusterNode.PortNumber))
+			protocolEncoder.WriteBulkStringResponse("ip")
+			protocolEncoder.WriteBulkStringResponse(clusterNode.HostAddress)
+			protocolEncoder.WriteBulkStringResponse("endpoint")
+			protocolEncoder.WriteBulkStringResponse(clusterNode.HostAddress)
+			protocolEncoder.WriteBulkStringResponse("role")
+			protocolEncoder.WriteBulkStringResponse(nodeRole)
+			protocolEncoder.WriteBulkStringResponse("replication-offset")
+			protocolEncoder.WriteIntegerResponse(0)
+			protocolEncoder.WriteBulkStringResponse("health")
+			if writeError := protocolEncoder.WriteBulkStringResponse("online"); writeError != nil {
+				return writeError
+			}
+		}
+	}
+	return nil
+}
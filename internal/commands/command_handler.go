@@ -3,23 +3,74 @@ package commands
 import (
 	"fmt"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"redis-go/internal/acl"
+	"redis-go/internal/cluster"
+	"redis-go/internal/config"
+	"redis-go/internal/consensus"
+	"redis-go/internal/persistence"
 	"redis-go/internal/protocol"
+	"redis-go/internal/replication"
+	"redis-go/internal/session"
 	"redis-go/internal/storage"
 )
 
 // RedisCommandHandler représente une fonction qui traite une commande Redis
 type RedisCommandHandler func(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error
 
+// RedisSessionCommandHandler représente une commande qui a besoin de la session du client appelant
+type RedisSessionCommandHandler func(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error
+
 // RedisCommandRegistry contient toutes les commandes supportées
 type RedisCommandRegistry struct {
-	registeredCommands map[string]RedisCommandHandler
+	registeredCommands        map[string]RedisCommandHandler
+	registeredSessionCommands map[string]RedisSessionCommandHandler
+
+	// Gestionnaire de sessions du serveur (CLIENT LIST/KILL, déconnexion des utilisateurs ACL supprimés)
+	clientSessionManager *session.ClientSessionManager
+
+	// Utilisateurs ACL (l'utilisateur "default" a tous les droits par défaut)
+	accessControlList *acl.AccessControlList
+
+	// Gestionnaire de réplication (rôle, flux vers les réplicas), configuré par SetReplicationManager
+	replicationManager *replication.ReplicationManager
+
+	// Persistance RDB (SAVE, BGSAVE, LASTSAVE, DEBUG RELOAD), configurée par SetRDBPersistence
+	rdbPersistence RDBPersistenceInterface
+
+	// Paramètres de configuration du serveur (CONFIG GET/SET/REWRITE)
+	parameterRegistry *config.ParameterRegistry
+
+	// Journal des écritures (nil si durable-log no)
+	durableWriteLog *persistence.DurableWriteLog
+
+	// Topologie du cluster (nil si cluster-enabled no)
+	clusterManager *cluster.ClusterManager
+
+	// Nœud Raft (nil si raft-enabled no), lectures confirmées par la majorité (modifiable à chaud)
+	// et attente maximale de cette confirmation
+	raftNode              *consensus.RaftNode
+	raftLinearizableReads atomic.Bool
+	raftReadTimeout       time.Duration
+
+	// max-savepoints et hll-sparse-max-bytes (taille au-delà de laquelle une HyperLogLog passe en
+	// encodage dense), modifiables à chaud
+	maximumSavepoints             atomic.Int64
+	hyperLogLogSparseMaximumBytes atomic.Int64
+
+	// Compteurs exposés dans la section INFO stats
+	commandStatistics *serverStatistics
 }
 
 // NewRedisCommandRegistry crée un nouveau registre de commandes
 func NewRedisCommandRegistry() *RedisCommandRegistry {
 	commandRegistry := &RedisCommandRegistry{
-		registeredCommands: make(map[string]RedisCommandHandler),
+		registeredCommands:        make(map[string]RedisCommandHandler),
+		registeredSessionCommands: make(map[string]RedisSessionCommandHandler),
+		accessControlList:         acl.NewAccessControlList(buildCommandCategoryIndex(), ""),
+		commandStatistics:         &serverStatistics{},
 	}
 
 	// Enregistrement des commandes
@@ -32,16 +83,18 @@ func NewRedisCommandRegistry() *RedisCommandRegistry {
 func (commandRegistry *RedisCommandRegistry) registerAllCommands() {
 	commands := map[string]RedisCommandHandler{
 		// Commandes String basiques
-		"SET":    commandRegistry.handleSetCommand,
-		"GET":    commandRegistry.handleGetCommand,
-		"DEL":    commandRegistry.handleDeleteCommand,
-		"EXISTS": commandRegistry.handleExistsCommand,
-		"KEYS":   commandRegistry.handleKeysCommand,
-		"TYPE":   commandRegistry.handleTypeCommand,
-		"INCR":   commandRegistry.handleIncrementCommand,
-		"DECR":   commandRegistry.handleDecrementCommand,
-		"INCRBY": commandRegistry.handleIncrementByCommand,
-		"DECRBY": commandRegistry.handleDecrementByCommand,
+		"SET":      commandRegistry.handleSetCommand,
+		"GET":      commandRegistry.handleGetCommand,
+		"DEL":      commandRegistry.handleDeleteCommand,
+		"EXISTS":   commandRegistry.handleExistsCommand,
+		"KEYS":     commandRegistry.handleKeysCommand,
+		"TYPE":     commandRegistry.handleTypeCommand,
+		"RENAME":   commandRegistry.handleRenameCommand,
+		"RENAMENX": commandRegistry.handleRenameNxCommand,
+		"INCR":     commandRegistry.handleIncrementCommand,
+		"DECR":     commandRegistry.handleDecrementCommand,
+		"INCRBY":   commandRegistry.handleIncrementByCommand,
+		"DECRBY":   commandRegistry.handleDecrementByCommand,
 		// =====================================
 		"SETNX": commandRegistry.handleSetNxCommand, // SET if Not eXists
 		"SETEX": commandRegistry.handleSetExCommand, // SET with EXpiration
@@ -61,12 +114,61 @@ func (commandRegistry *RedisCommandRegistry) registerAllCommands() {
 		"MSETNX": commandRegistry.handleMultiSetNxCommand, // Multi-set si aucune clé existe
 		"GETDEL": commandRegistry.handleGetDelCommand,     // Get puis delete atomique
 
+		// Commandes Bitmap (sur les valeurs string, vues comme des tableaux d'octets)
+		"SETBIT":      commandRegistry.handleSetBitCommand,
+		"GETBIT":      commandRegistry.handleGetBitCommand,
+		"BITCOUNT":    commandRegistry.handleBitCountCommand,
+		"BITPOS":      commandRegistry.handleBitPositionCommand,
+		"BITOP":       commandRegistry.handleBitOperationCommand,
+		"BITFIELD":    commandRegistry.handleBitfieldCommand,
+		"BITFIELD_RO": commandRegistry.handleBitfieldReadOnlyCommand,
+
+		// Commandes HyperLogLog (valeurs string au format Redis)
+		"PFADD":   commandRegistry.handleHyperLogLogAddCommand,
+		"PFCOUNT": commandRegistry.handleHyperLogLogCountCommand,
+		"PFMERGE": commandRegistry.handleHyperLogLogMergeCommand,
+
+		// Commandes Sorted Set (scores flottants, base des index géographiques)
+		"ZADD":   commandRegistry.handleSortedSetAddCommand,
+		"ZREM":   commandRegistry.handleSortedSetRemoveCommand,
+		"ZSCORE": commandRegistry.handleSortedSetScoreCommand,
+		"ZCARD":  commandRegistry.handleSortedSetCardinalityCommand,
+
+		// Commandes géographiques (sorted sets dont les scores sont des geohash de 52 bits)
+		"GEOADD":         commandRegistry.handleGeoAddCommand,
+		"GEOPOS":         commandRegistry.handleGeoPositionCommand,
+		"GEODIST":        commandRegistry.handleGeoDistanceCommand,
+		"GEOHASH":        commandRegistry.handleGeoHashCommand,
+		"GEOSEARCH":      commandRegistry.handleGeoSearchCommand,
+		"GEOSEARCHSTORE": commandRegistry.handleGeoSearchStoreCommand,
+
+		// Commandes JSON (documents modifiés sur place par chemin, comme RedisJSON)
+		"JSON.SET":       commandRegistry.handleJSONSetCommand,
+		"JSON.GET":       commandRegistry.handleJSONGetCommand,
+		"JSON.MGET":      commandRegistry.handleJSONMultiGetCommand,
+		"JSON.DEL":       commandRegistry.handleJSONDeleteCommand,
+		"JSON.FORGET":    commandRegistry.handleJSONDeleteCommand, // Alias pour JSON.DEL
+		"JSON.NUMINCRBY": commandRegistry.handleJSONNumberIncrementByCommand,
+		"JSON.ARRAPPEND": commandRegistry.handleJSONArrayAppendCommand,
+		"JSON.ARRLEN":    commandRegistry.handleJSONArrayLengthCommand,
+		"JSON.ARRPOP":    commandRegistry.handleJSONArrayPopCommand,
+		"JSON.OBJKEYS":   commandRegistry.handleJSONObjectKeysCommand,
+		"JSON.STRAPPEND": commandRegistry.handleJSONStringAppendCommand,
+		"JSON.TYPE":      commandRegistry.handleJSONTypeCommand,
+
+		// Commandes de recherche (index secondaires sur les hashes, comme RediSearch)
+		"FT.CREATE":    commandRegistry.handleSearchCreateCommand,
+		"FT.DROPINDEX": commandRegistry.handleSearchDropIndexCommand,
+		"FT.INFO":      commandRegistry.handleSearchInfoCommand,
+		"FT._LIST":     commandRegistry.handleSearchListCommand,
+
 		// Commandes TTL
-		"TTL":     commandRegistry.handleTtlCommand,
-		"PTTL":    commandRegistry.handlePttlCommand,
-		"EXPIRE":  commandRegistry.handleExpireCommand,
-		"PEXPIRE": commandRegistry.handlePexpireCommand,
-		"PERSIST": commandRegistry.handlePersistCommand,
+		"TTL":       commandRegistry.handleTtlCommand,
+		"PTTL":      commandRegistry.handlePttlCommand,
+		"EXPIRE":    commandRegistry.handleExpireCommand,
+		"PEXPIRE":   commandRegistry.handlePexpireCommand,
+		"PEXPIREAT": commandRegistry.handlePexpireAtCommand,
+		"PERSIST":   commandRegistry.handlePersistCommand,
 
 		// Commandes List
 		"LPUSH":  commandRegistry.handleLeftPushCommand,
@@ -108,25 +210,67 @@ func (commandRegistry *RedisCommandRegistry) registerAllCommands() {
 		"HINCRBY":      commandRegistry.handleHashIncrementByCommand,      // Incrément entier
 		"HINCRBYFLOAT": commandRegistry.handleHashIncrementByFloatCommand, // Incrément float
 
+		// Commandes Stream
+		"XADD":      commandRegistry.handleStreamAddCommand,
+		"XRANGE":    commandRegistry.handleStreamRangeCommand,
+		"XREVRANGE": commandRegistry.handleStreamReverseRangeCommand,
+		"XLEN":      commandRegistry.handleStreamLengthCommand,
+		"XDEL":      commandRegistry.handleStreamDeleteCommand,
+		"XTRIM":     commandRegistry.handleStreamTrimCommand,
+
+		// Sérialisation des valeurs (DUMP / RESTORE, utilisées par MIGRATE)
+		"DUMP":           commandRegistry.handleDumpCommand,
+		"RESTORE":        commandRegistry.handleRestoreCommand,
+		"RESTORE-ASKING": commandRegistry.handleRestoreCommand, // Envoyée par MIGRATE en mode cluster
+
 		// Commandes utilitaires
 		"PING":     commandRegistry.handlePingCommand,
 		"ECHO":     commandRegistry.handleEchoCommand,
 		"DBSIZE":   commandRegistry.handleDatabaseSizeCommand,
 		"FLUSHALL": commandRegistry.handleFlushAllCommand,
+		"INFO":     commandRegistry.handleInfoCommand,
 		"ALAIDE":   commandRegistry.handleHelpCommand,
 	}
 
 	for commandName, handler := range commands {
 		commandRegistry.registeredCommands[commandName] = handler
 	}
+
+	// Commandes liées à la connexion
+	commandRegistry.registeredSessionCommands["AUTH"] = commandRegistry.handleAuthCommand
+	commandRegistry.registeredSessionCommands["HELLO"] = commandRegistry.handleHelloCommand
+	commandRegistry.registeredSessionCommands["QUIT"] = commandRegistry.handleQuitCommand
+	commandRegistry.registeredSessionCommands["ACL"] = commandRegistry.handleAclCommand
+
+	// MIGRATE gère elle-même sa propagation : réplicas et journal reçoivent DEL des clés transférées
+	commandRegistry.registeredSessionCommands["MIGRATE"] = commandRegistry.handleMigrateCommand
+
+	// XREAD BLOCK met la connexion en attente (exemptée du timeout d'inactivité)
+	commandRegistry.registeredSessionCommands["XREAD"] = commandRegistry.handleStreamReadCommand
+
+	// Savepoints en mémoire (SNAPSHOT CREATE / ROLLBACK / DROP / LIST) : locaux, hors du flux de réplication
+	commandRegistry.registeredSessionCommands["SNAPSHOT"] = commandRegistry.handleSnapshotCommand
+
+	// FT.SEARCH filtre les hashes trouvés selon les clés lisibles par l'utilisateur de la session
+	commandRegistry.registeredSessionCommands["FT.SEARCH"] = commandRegistry.handleSearchCommand
 }
 
-// ExecuteCommand exécute une commande donnée
-func (commandRegistry *RedisCommandRegistry) ExecuteCommand(commandName string, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+// ExecuteCommand exécute une commande donnée pour le compte d'une session client
+func (commandRegistry *RedisCommandRegistry) ExecuteCommand(clientSession *session.ClientSession, commandName string, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
 	upperCommandName := strings.ToUpper(commandName)
+	commandRegistry.commandStatistics.totalCommandsProcessed.Add(1)
+
+	// Connexion non authentifiée : seules quelques commandes sont autorisées
+	if !clientSession.IsAuthenticated() && !commandsAllowedWithoutAuthentication[upperCommandName] {
+		commandRegistry.commandStatistics.unauthenticatedCommandCount.Add(1)
+		return protocolEncoder.WriteErrorResponse("NOAUTH Authentification requise")
+	}
+
+	// Les commandes liées à la session sont prioritaires
+	sessionCommandHandler, sessionCommandExists := commandRegistry.registeredSessionCommands[upperCommandName]
 	commandHandler, commandExists := commandRegistry.registeredCommands[upperCommandName]
 
-	if !commandExists {
+	if !sessionCommandExists && !commandExists {
 		suggestion := commandRegistry.findSimilarCommand(upperCommandName)
 		if suggestion != "" {
 			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : commande inconnue '%s'. Vouliez-vous dire '%s' ?", commandName, suggestion))
@@ -134,6 +278,55 @@ func (commandRegistry *RedisCommandRegistry) ExecuteCommand(commandName string,
 		return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : commande inconnue '%s'", commandName))
 	}
 
+	// Le flux envoyé par le maître est appliqué sans contrôle ACL ni restriction de lecture seule
+	// Il est relayé aux réplicas de ce serveur par le lien de réplication lui-même
+	if clientSession.GetClientType() == session.MasterClientType {
+		if sessionCommandExists {
+			return sessionCommandHandler(clientSession, commandArguments, redisStorage, protocolEncoder)
+		}
+		return commandHandler(commandArguments, redisStorage, protocolEncoder)
+	}
+
+	// Vérification des droits ACL (commande, catégorie, clés)
+	if permissionError := commandRegistry.checkCommandPermission(clientSession, upperCommandName, commandArguments); permissionError != "" {
+		return protocolEncoder.WriteErrorResponse(permissionError)
+	}
+
+	// Mode cluster : les clés d'un slot servi par un autre nœud sont redirigées
+	if commandRegistry.clusterManager != nil {
+		if routingError := commandRegistry.checkClusterRouting(clientSession, upperCommandName, commandArguments, redisStorage); routingError != "" {
+			return protocolEncoder.WriteErrorResponse(routingError)
+		}
+	}
+
+	if sessionCommandExists {
+		return sessionCommandHandler(clientSession, commandArguments, redisStorage, protocolEncoder)
+	}
+
+	// XADD * : l'horloge est fixée avant propagation pour que tous les nœuds génèrent le même identifiant
+	commandArguments = pinStreamAutoID(upperCommandName, commandArguments)
+	// TTL relatifs (EXPIRE, SET ... EX, SETEX, RESTORE) : même chose pour la date d'expiration
+	upperCommandName, commandArguments = pinRelativeExpiration(upperCommandName, commandArguments)
+	commandHandler = commandRegistry.registeredCommands[upperCommandName]
+
+	// Mode raft : les écritures sont validées par la majorité du groupe avant d'être appliquées
+	if commandRegistry.raftNode != nil {
+		if isWriteCommand(upperCommandName, commandArguments) {
+			return commandRegistry.executeConsensusWrite(upperCommandName, commandArguments, protocolEncoder)
+		}
+		if readError := commandRegistry.waitForLinearizableRead(upperCommandName, commandArguments); readError != "" {
+			return protocolEncoder.WriteErrorResponse(readError)
+		}
+		return commandHandler(commandArguments, redisStorage, protocolEncoder)
+	}
+
+	// Écritures : refusées sur un réplica en lecture seule, sinon ajoutées au flux de réplication
+	if commandRegistry.replicationManager != nil && isWriteCommand(upperCommandName, commandArguments) {
+		if commandRegistry.replicationManager.IsReadOnlyReplica() {
+			return protocolEncoder.WriteErrorResponse("READONLY impossible d'écrire sur un réplica en lecture seule")
+		}
+		return commandRegistry.executeReplicatedWrite(clientSession, commandHandler, upperCommandName, commandArguments, redisStorage, protocolEncoder)
+	}
 	return commandHandler(commandArguments, redisStorage, protocolEncoder)
 }
 
@@ -149,6 +342,13 @@ func (commandRegistry *RedisCommandRegistry) findSimilarCommand(input string) st
 			bestMatch = commandName
 		}
 	}
+	for commandName := range commandRegistry.registeredSessionCommands {
+		distance := levenshteinDistance(input, commandName)
+		if distance < minDistance {
+			minDistance = distance
+			bestMatch = commandName
+		}
+	}
 
 	return bestMatch
 }
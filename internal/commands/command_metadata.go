@@ -0,0 +1,305 @@
+package commands
+
+import "strings"
+
+// RedisCommandMetadata décrit une commande : catégories ACL et position des clés
+// Les positions suivent la convention Redis : 1 = premier argument après le nom de la commande,
+// une position négative compte depuis la fin (-1 = dernier argument), 0 = aucune clé
+type RedisCommandMetadata struct {
+	CommandCategories []string
+	FirstKeyPosition  int
+	LastKeyPosition   int
+	KeyStep           int
+	KeyExtractor      func(commandArguments []string) []string // Clés non décrites par des positions (MIGRATE)
+}
+
+// noKeys est utilisé pour les commandes qui ne manipulent aucune clé
+var noKeys = [3]int{0, 0, 0}
+
+// singleKey est utilisé pour les commandes dont le premier argument est l'unique clé
+var singleKey = [3]int{1, 1, 1}
+
+// allArgumentKeys est utilisé pour les commandes dont tous les arguments sont des clés
+var allArgumentKeys = [3]int{1, -1, 1}
+
+// newCommandMetadata construit une entrée de la table de métadonnées
+func newCommandMetadata(keyPositions [3]int, commandCategories ...string) RedisCommandMetadata {
+	return RedisCommandMetadata{
+		CommandCategories: commandCategories,
+		FirstKeyPosition:  keyPositions[0],
+		LastKeyPosition:   keyPositions[1],
+		KeyStep:           keyPositions[2],
+	}
+}
+
+// withKeyExtractor remplace les positions de clés par une fonction d'extraction
+func (commandMetadata RedisCommandMetadata) withKeyExtractor(keyExtractor func(commandArguments []string) []string) RedisCommandMetadata {
+	commandMetadata.KeyExtractor = keyExtractor
+	return commandMetadata
+}
+
+// commandMetadataTable contient les métadonnées de toutes les commandes
+// Les entrées "COMMANDE|SOUS-COMMANDE" précisent les catégories d'une sous-commande
+var commandMetadataTable = map[string]RedisCommandMetadata{
+	// Commandes String
+	"SET":      newCommandMetadata(singleKey, "write", "string", "slow"),
+	"GET":      newCommandMetadata(singleKey, "read", "string", "fast"),
+	"SETNX":    newCommandMetadata(singleKey, "write", "string", "fast"),
+	"SETEX":    newCommandMetadata(singleKey, "write", "string", "slow"),
+	"APPEND":   newCommandMetadata(singleKey, "write", "string", "fast"),
+	"STRLEN":   newCommandMetadata(singleKey, "read", "string", "fast"),
+	"GETRANGE": newCommandMetadata(singleKey, "read", "string", "slow"),
+	"SUBSTR":   newCommandMetadata(singleKey, "read", "string", "slow"),
+	"SETRANGE": newCommandMetadata(singleKey, "write", "string", "slow"),
+	"MSET":     newCommandMetadata([3]int{1, -1, 2}, "write", "string", "slow"),
+	"MGET":     newCommandMetadata(allArgumentKeys, "read", "string", "fast"),
+	"GETSET":   newCommandMetadata(singleKey, "write", "string", "fast"),
+	"MSETNX":   newCommandMetadata([3]int{1, -1, 2}, "write", "string", "slow"),
+	"GETDEL":   newCommandMetadata(singleKey, "write", "string", "fast"),
+	"INCR":     newCommandMetadata(singleKey, "write", "string", "fast"),
+	"DECR":     newCommandMetadata(singleKey, "write", "string", "fast"),
+	"INCRBY":   newCommandMetadata(singleKey, "write", "string", "fast"),
+	"DECRBY":   newCommandMetadata(singleKey, "write", "string", "fast"),
+
+	// Commandes Bitmap
+	"SETBIT":      newCommandMetadata(singleKey, "write", "bitmap", "slow"),
+	"GETBIT":      newCommandMetadata(singleKey, "read", "bitmap", "fast"),
+	"BITCOUNT":    newCommandMetadata(singleKey, "read", "bitmap", "slow"),
+	"BITPOS":      newCommandMetadata(singleKey, "read", "bitmap", "slow"),
+	"BITOP":       newCommandMetadata([3]int{2, -1, 1}, "write", "bitmap", "slow"),
+	"BITFIELD":    newCommandMetadata(singleKey, "write", "bitmap", "slow"),
+	"BITFIELD_RO": newCommandMetadata(singleKey, "read", "bitmap", "fast"),
+
+	// Commandes HyperLogLog
+	"PFADD":   newCommandMetadata(singleKey, "write", "hyperloglog", "fast"),
+	"PFCOUNT": newCommandMetadata(allArgumentKeys, "read", "hyperloglog", "slow"),
+	"PFMERGE": newCommandMetadata(allArgumentKeys, "write", "hyperloglog", "slow"),
+
+	// Commandes Sorted Set
+	"ZADD":   newCommandMetadata(singleKey, "write", "sortedset", "fast"),
+	"ZREM":   newCommandMetadata(singleKey, "write", "sortedset", "fast"),
+	"ZSCORE": newCommandMetadata(singleKey, "read", "sortedset", "fast"),
+	"ZCARD":  newCommandMetadata(singleKey, "read", "sortedset", "fast"),
+
+	// Commandes géographiques
+	"GEOADD":         newCommandMetadata(singleKey, "write", "geo", "slow"),
+	"GEOPOS":         newCommandMetadata(singleKey, "read", "geo", "slow"),
+	"GEODIST":        newCommandMetadata(singleKey, "read", "geo", "slow"),
+	"GEOHASH":        newCommandMetadata(singleKey, "read", "geo", "slow"),
+	"GEOSEARCH":      newCommandMetadata(singleKey, "read", "geo", "slow"),
+	"GEOSEARCHSTORE": newCommandMetadata([3]int{1, 2, 1}, "write", "geo", "slow"),
+
+	// Commandes JSON
+	"JSON.SET":       newCommandMetadata(singleKey, "write", "json", "slow"),
+	"JSON.GET":       newCommandMetadata(singleKey, "read", "json", "slow"),
+	"JSON.MGET":      newCommandMetadata([3]int{1, -2, 1}, "read", "json", "slow"),
+	"JSON.DEL":       newCommandMetadata(singleKey, "write", "json", "slow"),
+	"JSON.FORGET":    newCommandMetadata(singleKey, "write", "json", "slow"),
+	"JSON.NUMINCRBY": newCommandMetadata(singleKey, "write", "json", "slow"),
+	"JSON.ARRAPPEND": newCommandMetadata(singleKey, "write", "json", "slow"),
+	"JSON.ARRLEN":    newCommandMetadata(singleKey, "read", "json", "fast"),
+	"JSON.ARRPOP":    newCommandMetadata(singleKey, "write", "json", "slow"),
+	"JSON.OBJKEYS":   newCommandMetadata(singleKey, "read", "json", "slow"),
+	"JSON.STRAPPEND": newCommandMetadata(singleKey, "write", "json", "slow"),
+	"JSON.TYPE":      newCommandMetadata(singleKey, "read", "json", "fast"),
+
+	// Commandes de recherche : elles lisent les hashes via l'index, sans clé nommée
+	// (FT.SEARCH filtre elle-même ses résultats selon les motifs de clés de l'utilisateur)
+	"FT.CREATE":    newCommandMetadata(noKeys, "write", "search", "slow"),
+	"FT.SEARCH":    newCommandMetadata(noKeys, "read", "search", "slow"),
+	"FT.DROPINDEX": newCommandMetadata(noKeys, "write", "search", "slow"),
+	"FT.INFO":      newCommandMetadata(noKeys, "read", "search", "slow"),
+	"FT._LIST":     newCommandMetadata(noKeys, "read", "search", "slow"),
+
+	// Commandes génériques sur l'espace de clés
+	"DEL":            newCommandMetadata(allArgumentKeys, "write", "keyspace", "slow"),
+	"EXISTS":         newCommandMetadata(allArgumentKeys, "read", "keyspace", "fast"),
+	"KEYS":           newCommandMetadata(noKeys, "read", "keyspace", "slow", "dangerous"),
+	"TYPE":           newCommandMetadata(singleKey, "read", "keyspace", "fast"),
+	"TTL":            newCommandMetadata(singleKey, "read", "keyspace", "fast"),
+	"PTTL":           newCommandMetadata(singleKey, "read", "keyspace", "fast"),
+	"EXPIRE":         newCommandMetadata(singleKey, "write", "keyspace", "fast"),
+	"PEXPIRE":        newCommandMetadata(singleKey, "write", "keyspace", "fast"),
+	"PEXPIREAT":      newCommandMetadata(singleKey, "write", "keyspace", "fast"),
+	"PERSIST":        newCommandMetadata(singleKey, "write", "keyspace", "fast"),
+	"RENAME":         newCommandMetadata([3]int{1, 2, 1}, "write", "keyspace", "slow"),
+	"RENAMENX":       newCommandMetadata([3]int{1, 2, 1}, "write", "keyspace", "fast"),
+	"DUMP":           newCommandMetadata(singleKey, "read", "keyspace", "slow"),
+	"RESTORE":        newCommandMetadata(singleKey, "write", "keyspace", "slow", "dangerous"),
+	"RESTORE-ASKING": newCommandMetadata(singleKey, "write", "keyspace", "slow", "dangerous"),
+	"MIGRATE":        newCommandMetadata(noKeys, "write", "keyspace", "slow", "dangerous").withKeyExtractor(migrateCommandKeys),
+
+	// Commandes List
+	"LPUSH":   newCommandMetadata(singleKey, "write", "list", "fast"),
+	"RPUSH":   newCommandMetadata(singleKey, "write", "list", "fast"),
+	"LPOP":    newCommandMetadata(singleKey, "write", "list", "fast"),
+	"RPOP":    newCommandMetadata(singleKey, "write", "list", "fast"),
+	"LLEN":    newCommandMetadata(singleKey, "read", "list", "fast"),
+	"LRANGE":  newCommandMetadata(singleKey, "read", "list", "slow"),
+	"LSET":    newCommandMetadata(singleKey, "write", "list", "slow"),
+	"LREM":    newCommandMetadata(singleKey, "write", "list", "slow"),
+	"LINSERT": newCommandMetadata(singleKey, "write", "list", "slow"),
+	"LTRIM":   newCommandMetadata(singleKey, "write", "list", "slow"),
+
+	// Commandes Set
+	"SADD":      newCommandMetadata(singleKey, "write", "set", "fast"),
+	"SMEMBERS":  newCommandMetadata(singleKey, "read", "set", "slow"),
+	"SISMEMBER": newCommandMetadata(singleKey, "read", "set", "fast"),
+	"SREM":      newCommandMetadata(singleKey, "write", "set", "fast"),
+	"SCARD":     newCommandMetadata(singleKey, "read", "set", "fast"),
+	"SDIFF":     newCommandMetadata(allArgumentKeys, "read", "set", "slow"),
+	"SINTER":    newCommandMetadata(allArgumentKeys, "read", "set", "slow"),
+	"SUNION":    newCommandMetadata(allArgumentKeys, "read", "set", "slow"),
+
+	// Commandes Hash
+	"HSET":         newCommandMetadata(singleKey, "write", "hash", "fast"),
+	"HGET":         newCommandMetadata(singleKey, "read", "hash", "fast"),
+	"HGETALL":      newCommandMetadata(singleKey, "read", "hash", "slow"),
+	"HEXISTS":      newCommandMetadata(singleKey, "read", "hash", "fast"),
+	"HDEL":         newCommandMetadata(singleKey, "write", "hash", "fast"),
+	"HLEN":         newCommandMetadata(singleKey, "read", "hash", "fast"),
+	"HKEYS":        newCommandMetadata(singleKey, "read", "hash", "slow"),
+	"HVALS":        newCommandMetadata(singleKey, "read", "hash", "slow"),
+	"HINCRBY":      newCommandMetadata(singleKey, "write", "hash", "fast"),
+	"HINCRBYFLOAT": newCommandMetadata(singleKey, "write", "hash", "fast"),
+
+	// Commandes Stream
+	"XADD":      newCommandMetadata(singleKey, "write", "stream", "fast"),
+	"XRANGE":    newCommandMetadata(singleKey, "read", "stream", "slow"),
+	"XREVRANGE": newCommandMetadata(singleKey, "read", "stream", "slow"),
+	"XLEN":      newCommandMetadata(singleKey, "read", "stream", "fast"),
+	"XDEL":      newCommandMetadata(singleKey, "write", "stream", "fast"),
+	"XTRIM":     newCommandMetadata(singleKey, "write", "stream", "slow"),
+	"XREAD":     newCommandMetadata(noKeys, "read", "stream", "slow", "blocking").withKeyExtractor(xreadCommandKeys),
+
+	// Commandes utilitaires et serveur
+	"PING":     newCommandMetadata(noKeys, "connection", "fast"),
+	"ECHO":     newCommandMetadata(noKeys, "connection", "fast"),
+	"DBSIZE":   newCommandMetadata(noKeys, "read", "keyspace", "fast"),
+	"FLUSHALL": newCommandMetadata(noKeys, "write", "keyspace", "slow", "dangerous"),
+	"ALAIDE":   newCommandMetadata(noKeys, "connection", "fast"),
+	"INFO":     newCommandMetadata(noKeys, "slow", "dangerous"),
+	"SAVE":     newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"BGSAVE":   newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"LASTSAVE": newCommandMetadata(noKeys, "admin", "fast", "dangerous"),
+	"CONFIG":   newCommandMetadata(noKeys, "slow"),
+	"DEBUG":    newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+
+	// Sous-commandes CONFIG
+	"CONFIG|GET":       newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"CONFIG|SET":       newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"CONFIG|RESETSTAT": newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"CONFIG|REWRITE":   newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+
+	// Sous-commandes DEBUG
+	"DEBUG|RELOAD":    newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"DEBUG|SNAPSHOTS": newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+
+	// Savepoints en mémoire, locaux au nœud : rien n'est transmis aux réplicas ni journalisé
+	// ROLLBACK reste dans @write parce qu'il remplace tout le jeu de données : un utilisateur -@write ne doit
+	// pas pouvoir l'exécuter. CREATE et DROP ne modifient aucune clé.
+	"SNAPSHOT":          newCommandMetadata(noKeys, "slow"),
+	"SNAPSHOT|CREATE":   newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"SNAPSHOT|ROLLBACK": newCommandMetadata(noKeys, "write", "admin", "slow", "dangerous"),
+	"SNAPSHOT|DROP":     newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"SNAPSHOT|LIST":     newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+
+	// Commandes de réplication
+	"REPLICAOF": newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"SLAVEOF":   newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"REPLCONF":  newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"PSYNC":     newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"SYNC":      newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"ROLE":      newCommandMetadata(noKeys, "admin", "fast", "dangerous"),
+
+	// Commandes cluster
+	"CLUSTER":                 newCommandMetadata(noKeys, "slow"),
+	"CLUSTER|RELOAD":          newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"CLUSTER|COUNTKEYSINSLOT": newCommandMetadata(noKeys, "slow"),
+	"CLUSTER|GETKEYSINSLOT":   newCommandMetadata(noKeys, "slow"),
+	"ASKING":                  newCommandMetadata(noKeys, "fast"),
+
+	// Écritures durables
+	"WAITDURABLE": newCommandMetadata(noKeys, "connection", "slow"),
+
+	// Commandes de connexion
+	"AUTH":            newCommandMetadata(noKeys, "connection", "fast"),
+	"HELLO":           newCommandMetadata(noKeys, "connection", "fast"),
+	"QUIT":            newCommandMetadata(noKeys, "connection", "fast"),
+	"CLIENT":          newCommandMetadata(noKeys, "connection", "slow"),
+	"CLIENT|KILL":     newCommandMetadata(noKeys, "admin", "connection", "slow", "dangerous"),
+	"CLIENT|NO-EVICT": newCommandMetadata(noKeys, "admin", "connection", "slow", "dangerous"),
+	"ACL":             newCommandMetadata(noKeys, "slow"),
+	"ACL|WHOAMI":      newCommandMetadata(noKeys, "slow"),
+	"ACL|CAT":         newCommandMetadata(noKeys, "slow"),
+	"ACL|SETUSER":     newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"ACL|GETUSER":     newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"ACL|DELUSER":     newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"ACL|LIST":        newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"ACL|USERS":       newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"ACL|LOG":         newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"ACL|LOAD":        newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+	"ACL|SAVE":        newCommandMetadata(noKeys, "admin", "slow", "dangerous"),
+}
+
+// lookupCommandMetadata retourne les métadonnées d'une commande, en privilégiant la sous-commande
+// Le nom retourné est celui utilisé par les ACL (ex: CLIENT|KILL)
+func lookupCommandMetadata(upperCommandName string, commandArguments []string) (string, RedisCommandMetadata, bool) {
+	if len(commandArguments) > 0 {
+		subcommandName := upperCommandName + "|" + strings.ToUpper(commandArguments[0])
+		if subcommandMetadata, subcommandExists := commandMetadataTable[subcommandName]; subcommandExists {
+			return subcommandName, subcommandMetadata, true
+		}
+	}
+
+	commandMetadata, commandExists := commandMetadataTable[upperCommandName]
+	return upperCommandName, commandMetadata, commandExists
+}
+
+// extractCommandKeys retourne les clés manipulées par une commande d'après ses métadonnées
+func extractCommandKeys(commandMetadata RedisCommandMetadata, commandArguments []string) []string {
+	if commandMetadata.KeyExtractor != nil {
+		return commandMetadata.KeyExtractor(commandArguments)
+	}
+	if commandMetadata.FirstKeyPosition <= 0 || len(commandArguments) == 0 {
+		return nil
+	}
+
+	lastKeyPosition := commandMetadata.LastKeyPosition
+	if lastKeyPosition < 0 {
+		lastKeyPosition = len(commandArguments) + 1 + lastKeyPosition
+	}
+	if lastKeyPosition > len(commandArguments) {
+		lastKeyPosition = len(commandArguments)
+	}
+
+	keyStep := commandMetadata.KeyStep
+	if keyStep <= 0 {
+		keyStep = 1
+	}
+
+	var commandKeys []string
+	for keyPosition := commandMetadata.FirstKeyPosition; keyPosition <= lastKeyPosition; keyPosition += keyStep {
+		commandKeys = append(commandKeys, commandArguments[keyPosition-1])
+	}
+	return commandKeys
+}
+
+// hasCategory indique si les métadonnées déclarent la catégorie donnée
+func (commandMetadata RedisCommandMetadata) hasCategory(categoryName string) bool {
+	for _, commandCategory := range commandMetadata.CommandCategories {
+		if commandCategory == categoryName {
+			return true
+		}
+	}
+	return false
+}
+
+// buildCommandCategoryIndex retourne la table nom de commande -> catégories, utilisée par les ACL
+func buildCommandCategoryIndex() map[string][]string {
+	categoryIndex := make(map[string][]string, len(commandMetadataTable))
+	for commandName, commandMetadata := range commandMetadataTable {
+		categoryIndex[commandName] = commandMetadata.CommandCategories
+	}
+	return categoryIndex
+}
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"redis-go/internal/config"
+	"redis-go/internal/protocol"
+	"redis-go/internal/storage"
+)
+
+// SetParameterRegistry configure le registre des paramètres et enregistre la commande CONFIG
+func (commandRegistry *RedisCommandRegistry) SetParameterRegistry(registry *config.ParameterRegistry) {
+	commandRegistry.parameterRegistry = registry
+	commandRegistry.registeredCommands["CONFIG"] = commandRegistry.handleConfigCommand
+}
+
+// handleConfigCommand implémente CONFIG GET / SET / RESETSTAT / REWRITE
+func (commandRegistry *RedisCommandRegistry) handleConfigCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) == 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'CONFIG' (attendu: CONFIG GET|SET|RESETSTAT|REWRITE [arguments ...])")
+	}
+
+	subcommandArguments := commandArguments[1:]
+
+	switch strings.ToUpper(commandArguments[0]) {
+	case "GET":
+		if len(subcommandArguments) == 0 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'CONFIG GET' (attendu: CONFIG GET motif [motif ...])")
+		}
+		return protocolEncoder.WriteArrayResponse(commandRegistry.collectMatchingParameters(subcommandArguments))
+
+	case "SET":
+		if len(subcommandArguments) == 0 || len(subcommandArguments)%2 != 0 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'CONFIG SET' (attendu: CONFIG SET paramètre valeur [paramètre valeur ...])")
+		}
+		if setError := commandRegistry.parameterRegistry.SetParameters(subcommandArguments); setError != nil {
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : CONFIG SET invalide: %v", setError))
+		}
+		return protocolEncoder.WriteSimpleStringResponse("OK")
+
+	case "RESETSTAT":
+		if len(subcommandArguments) != 0 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : CONFIG RESETSTAT ne prend aucun argument")
+		}
+		commandRegistry.commandStatistics.resetStatistics()
+		return protocolEncoder.WriteSimpleStringResponse("OK")
+
+	case "REWRITE":
+		if len(subcommandArguments) != 0 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : CONFIG REWRITE ne prend aucun argument")
+		}
+		if rewriteError := commandRegistry.parameterRegistry.RewriteConfigurationFile(); rewriteError != nil {
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : CONFIG REWRITE échoué: %v", rewriteError))
+		}
+		return protocolEncoder.WriteSimpleStringResponse("OK")
+
+	default:
+		return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : sous-commande CONFIG inconnue '%s'", commandArguments[0]))
+	}
+}
+
+// collectMatchingParameters retourne les paires nom / valeur dont le nom correspond à l'un des motifs
+func (commandRegistry *RedisCommandRegistry) collectMatchingParameters(parameterPatterns []string) []string {
+	matchingParameters := make([]string, 0)
+	for _, parameterName := range commandRegistry.parameterRegistry.ListParameterNames() {
+		for _, parameterPattern := range parameterPatterns {
+			if storage.MatchGlobPattern(strings.ToLower(parameterPattern), parameterName) {
+				parameterValue, _ := commandRegistry.parameterRegistry.GetParameterValue(parameterName)
+				matchingParameters = append(matchingParameters, parameterName, parameterValue)
+				break
+			}
+		}
+	}
+	return matchingParameters
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"redis-go/internal/persistence"
+	"redis-go/internal/protocol"
+	"redis-go/internal/session"
+	"redis-go/internal/storage"
+)
+
+// SetDurableWriteLog configure le journal des écritures pour CLIENT DURABILITY et WAITDURABLE
+func (commandRegistry *RedisCommandRegistry) SetDurableWriteLog(writeLog *persistence.DurableWriteLog) {
+	commandRegistry.durableWriteLog = writeLog
+
+	commandRegistry.registeredSessionCommands["WAITDURABLE"] = commandRegistry.handleWaitDurableCommand
+}
+
+// ReplayLoggedCommand réapplique une écriture du journal au démarrage, sans contrôle ACL ni réponse
+// Retourne false si la commande est inconnue
+func (commandRegistry *RedisCommandRegistry) ReplayLoggedCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage) bool {
+	commandHandler, commandExists := commandRegistry.registeredCommands[strings.ToUpper(commandArguments[0])]
+	if !commandExists {
+		return false
+	}
+	commandHandler(commandArguments[1:], redisStorage, protocol.NewRedisSerializationProtocolEncoder(io.Discard))
+	return true
+}
+
+// waitForDurableWrites bloque le client jusqu'à ce que ses écritures soient sur disque
+// Seul le client appelant attend : le journal continue d'accepter les écritures des autres
+func (commandRegistry *RedisCommandRegistry) waitForDurableWrites(clientSession *session.ClientSession, timeout time.Duration) (bool, error) {
+	clientSession.SetBlocked(true)
+	defer clientSession.SetBlocked(false)
+	return commandRegistry.durableWriteLog.WaitDurable(clientSession.GetWriteLogOffset(), timeout)
+}
+
+// handleClientDurabilitySubcommand implémente CLIENT DURABILITY SYNC|ASYNC
+// SYNC : chaque écriture de la connexion n'est acquittée qu'une fois fsync par le journal
+func (commandRegistry *RedisCommandRegistry) handleClientDurabilitySubcommand(clientSession *session.ClientSession, subcommandArguments []string, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(subcommandArguments) != 1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'CLIENT DURABILITY' (attendu: CLIENT DURABILITY SYNC|ASYNC)")
+	}
+	if commandRegistry.durableWriteLog == nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : journal des écritures désactivé (durable-log no)")
+	}
+
+	switch strings.ToUpper(subcommandArguments[0]) {
+	case "SYNC":
+		clientSession.SetDurableWrites(true)
+	case "ASYNC":
+		clientSession.SetDurableWrites(false)
+	default:
+		return protocolEncoder.WriteErrorResponse("ERREUR : CLIENT DURABILITY attend SYNC ou ASYNC")
+	}
+	return protocolEncoder.WriteSimpleStringResponse("OK")
+}
+
+// handleWaitDurableCommand implémente WAITDURABLE timeout
+// Attend que toutes les écritures de la connexion soient sur disque : 1 si c'est le cas, 0 si le délai
+// (en millisecondes, 0 = illimité) expire avant
+func (commandRegistry *RedisCommandRegistry) handleWaitDurableCommand(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'WAITDURABLE' (attendu: WAITDURABLE timeout)")
+	}
+	timeoutMilliseconds, parseError := strconv.ParseInt(commandArguments[0], 10, 64)
+	if parseError != nil || timeoutMilliseconds < 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : le timeout doit être un entier positif (millisecondes)")
+	}
+	if commandRegistry.durableWriteLog == nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : journal des écritures désactivé (durable-log no)")
+	}
+
+	writesDurable, waitError := commandRegistry.waitForDurableWrites(clientSession, time.Duration(timeoutMilliseconds)*time.Millisecond)
+	if waitError != nil {
+		return protocolEncoder.WriteErrorResponse(fmt.Sprintf("IOERR journal des écritures en erreur: %v", waitError))
+	}
+	if writesDurable {
+		return protocolEncoder.WriteIntegerResponse(1)
+	}
+	return protocolEncoder.WriteIntegerResponse(0)
+}
@@ -0,0 +1,385 @@
+package commands
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"redis-go/internal/protocol"
+	"redis-go/internal/storage"
+)
+
+// geoSearchOptions regroupe une requête GEOSEARCH analysée et ses options de réponse
+type geoSearchOptions struct {
+	searchQuery     storage.GeoSearchQuery
+	metersPerUnit   float64 // Unité de BYRADIUS / BYBOX, utilisée aussi pour WITHDIST et STOREDIST
+	withCoordinates bool
+	withDistance    bool
+	withHash        bool
+	storeDistance   bool
+}
+
+// geoUnitToMeters retourne le nombre de mètres d'une unité de distance (m, km, mi, ft)
+func geoUnitToMeters(unitName string) (float64, bool) {
+	switch strings.ToLower(unitName) {
+	case "m":
+		return 1, true
+	case "km":
+		return 1000, true
+	case "mi":
+		return 1609.34, true
+	case "ft":
+		return 0.3048, true
+	}
+	return 0, false
+}
+
+// formatGeoCoordinate écrit une coordonnée avec 17 décimales, sans les zéros finaux
+func formatGeoCoordinate(coordinateValue float64) string {
+	formattedCoordinate := strings.TrimRight(strconv.FormatFloat(coordinateValue, 'f', 17, 64), "0")
+	return strings.TrimSuffix(formattedCoordinate, ".")
+}
+
+// formatGeoDistance écrit une distance avec 4 décimales, comme Redis
+func formatGeoDistance(distanceMeters float64, metersPerUnit float64) string {
+	return strconv.FormatFloat(distanceMeters/metersPerUnit, 'f', 4, 64)
+}
+
+// parseGeoCoordinates lit une paire longitude latitude et vérifie qu'elle est indexable
+func parseGeoCoordinates(longitudeArgument string, latitudeArgument string) (storage.GeoCoordinates, string) {
+	longitudeValue, longitudeError := strconv.ParseFloat(longitudeArgument, 64)
+	latitudeValue, latitudeError := strconv.ParseFloat(latitudeArgument, 64)
+	if longitudeError != nil || latitudeError != nil {
+		return storage.GeoCoordinates{}, "ERREUR : la longitude et la latitude doivent être des nombres flottants"
+	}
+	geoCoordinates := storage.GeoCoordinates{Longitude: longitudeValue, Latitude: latitudeValue}
+	if !storage.ValidGeoCoordinates(geoCoordinates) {
+		return geoCoordinates, "ERREUR : position invalide " + longitudeArgument + "," + latitudeArgument + " (longitude de -180 à 180, latitude de -85.05112878 à 85.05112878)"
+	}
+	return geoCoordinates, ""
+}
+
+// handleGeoAddCommand implémente GEOADD key [NX|XX] [CH] longitude latitude member [longitude latitude member ...]
+func (commandRegistry *RedisCommandRegistry) handleGeoAddCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 4 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'GEOADD' (attendu: GEOADD clé [NX|XX] [CH] longitude latitude membre [...])")
+	}
+
+	addOptions, countChangedMembers, argumentIndex, syntaxError := parseSortedSetAddOptions(commandArguments)
+	if syntaxError != "" {
+		return protocolEncoder.WriteErrorResponse(syntaxError)
+	}
+	positionArguments := commandArguments[argumentIndex:]
+	if len(positionArguments) == 0 || len(positionArguments)%3 != 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : erreur de syntaxe, triplets longitude latitude membre attendus")
+	}
+
+	// Le score de chaque membre est le geohash de 52 bits de sa position
+	sortedSetMembers := make([]storage.SortedSetMember, 0, len(positionArguments)/3)
+	for tripletIndex := 0; tripletIndex < len(positionArguments); tripletIndex += 3 {
+		geoCoordinates, coordinatesError := parseGeoCoordinates(positionArguments[tripletIndex], positionArguments[tripletIndex+1])
+		if coordinatesError != "" {
+			return protocolEncoder.WriteErrorResponse(coordinatesError)
+		}
+		sortedSetMembers = append(sortedSetMembers, storage.SortedSetMember{
+			Member: positionArguments[tripletIndex+2],
+			Score:  float64(storage.EncodeGeohash(geoCoordinates)),
+		})
+	}
+
+	addedCount, updatedCount, addError := redisStorage.AddSortedSetMembers(commandArguments[0], sortedSetMembers, addOptions)
+	if addError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + addError.Error())
+	}
+	if countChangedMembers {
+		return protocolEncoder.WriteIntegerResponse(int64(addedCount + updatedCount))
+	}
+	return protocolEncoder.WriteIntegerResponse(int64(addedCount))
+}
+
+// handleGeoPositionCommand implémente GEOPOS key [member ...]
+func (commandRegistry *RedisCommandRegistry) handleGeoPositionCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'GEOPOS' (attendu: GEOPOS clé [membre ...])")
+	}
+
+	memberScores, memberFound, scoreError := redisStorage.GetSortedSetScores(commandArguments[0], commandArguments[1:])
+	if scoreError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + scoreError.Error())
+	}
+
+	if writeError := protocolEncoder.WriteArrayHeaderResponse(len(memberScores)); writeError != nil {
+		return writeError
+	}
+	for memberIndex, memberScore := range memberScores {
+		if !memberFound[memberIndex] {
+			if writeError := protocolEncoder.WriteNullArrayResponse(); writeError != nil {
+				return writeError
+			}
+			continue
+		}
+		memberPosition := storage.DecodeGeohash(storage.GeohashFromScore(memberScore))
+		if writeError := protocolEncoder.WriteArrayResponse([]string{formatGeoCoordinate(memberPosition.Longitude), formatGeoCoordinate(memberPosition.Latitude)}); writeError != nil {
+			return writeError
+		}
+	}
+	return nil
+}
+
+// handleGeoDistanceCommand implémente GEODIST key member1 member2 [M|KM|FT|MI]
+func (commandRegistry *RedisCommandRegistry) handleGeoDistanceCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 3 && len(commandArguments) != 4 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'GEODIST' (attendu: GEODIST clé membre1 membre2 [M|KM|FT|MI])")
+	}
+
+	metersPerUnit := 1.0
+	if len(commandArguments) == 4 {
+		var validUnit bool
+		if metersPerUnit, validUnit = geoUnitToMeters(commandArguments[3]); !validUnit {
+			return protocolEncoder.WriteErrorResponse("ERREUR : unité inconnue, utilisez M, KM, FT ou MI")
+		}
+	}
+
+	memberScores, memberFound, scoreError := redisStorage.GetSortedSetScores(commandArguments[0], commandArguments[1:3])
+	if scoreError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + scoreError.Error())
+	}
+	if !memberFound[0] || !memberFound[1] {
+		return protocolEncoder.WriteNullBulkStringResponse()
+	}
+
+	distanceMeters := storage.GeoDistanceMeters(
+		storage.DecodeGeohash(storage.GeohashFromScore(memberScores[0])),
+		storage.DecodeGeohash(storage.GeohashFromScore(memberScores[1])),
+	)
+	return protocolEncoder.WriteBulkStringResponse(formatGeoDistance(distanceMeters, metersPerUnit))
+}
+
+// handleGeoHashCommand implémente GEOHASH key [member ...]
+func (commandRegistry *RedisCommandRegistry) handleGeoHashCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'GEOHASH' (attendu: GEOHASH clé [membre ...])")
+	}
+
+	memberScores, memberFound, scoreError := redisStorage.GetSortedSetScores(commandArguments[0], commandArguments[1:])
+	if scoreError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + scoreError.Error())
+	}
+
+	if writeError := protocolEncoder.WriteArrayHeaderResponse(len(memberScores)); writeError != nil {
+		return writeError
+	}
+	for memberIndex, memberScore := range memberScores {
+		if !memberFound[memberIndex] {
+			if writeError := protocolEncoder.WriteNullBulkStringResponse(); writeError != nil {
+				return writeError
+			}
+			continue
+		}
+		if writeError := protocolEncoder.WriteBulkStringResponse(storage.StandardGeohashString(storage.GeohashFromScore(memberScore))); writeError != nil {
+			return writeError
+		}
+	}
+	return nil
+}
+
+// handleGeoSearchCommand implémente GEOSEARCH key FROMMEMBER member|FROMLONLAT longitude latitude
+// BYRADIUS radius unit|BYBOX width height unit [ASC|DESC] [COUNT count [ANY]] [WITHCOORD] [WITHDIST] [WITHHASH]
+func (commandRegistry *RedisCommandRegistry) handleGeoSearchCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 4 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'GEOSEARCH' (attendu: GEOSEARCH clé FROMMEMBER membre|FROMLONLAT lon lat BYRADIUS rayon unité|BYBOX largeur hauteur unité [options])")
+	}
+
+	searchOptions, syntaxError := parseGeoSearchOptions(commandArguments[1:], false)
+	if syntaxError != "" {
+		return protocolEncoder.WriteErrorResponse(syntaxError)
+	}
+
+	searchResults, searchError := redisStorage.SearchGeoMembers(commandArguments[0], searchOptions.searchQuery)
+	if searchError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + searchError.Error())
+	}
+
+	if writeError := protocolEncoder.WriteArrayHeaderResponse(len(searchResults)); writeError != nil {
+		return writeError
+	}
+	for _, searchResult := range searchResults {
+		if writeError := writeGeoSearchResult(protocolEncoder, searchResult, searchOptions); writeError != nil {
+			return writeError
+		}
+	}
+	return nil
+}
+
+// handleGeoSearchStoreCommand implémente GEOSEARCHSTORE destination source <recherche GEOSEARCH> [STOREDIST]
+// Les membres trouvés sont rangés avec leur geohash, ou leur distance au centre avec STOREDIST
+func (commandRegistry *RedisCommandRegistry) handleGeoSearchStoreCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 5 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'GEOSEARCHSTORE' (attendu: GEOSEARCHSTORE destination source <recherche> [STOREDIST])")
+	}
+
+	searchOptions, syntaxError := parseGeoSearchOptions(commandArguments[2:], true)
+	if syntaxError != "" {
+		return protocolEncoder.WriteErrorResponse(syntaxError)
+	}
+
+	storedCount, storeError := redisStorage.SearchAndStoreGeoMembers(commandArguments[0], commandArguments[1], searchOptions.searchQuery, searchOptions.storeDistance, searchOptions.metersPerUnit)
+	if storeError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + storeError.Error())
+	}
+	return protocolEncoder.WriteIntegerResponse(int64(storedCount))
+}
+
+// writeGeoSearchResult écrit un résultat : le nom seul, ou [nom, distance, geohash, [longitude, latitude]] selon les options
+func writeGeoSearchResult(protocolEncoder *protocol.RedisSerializationProtocolEncoder, searchResult storage.GeoSearchResult, searchOptions geoSearchOptions) error {
+	if !searchOptions.withCoordinates && !searchOptions.withDistance && !searchOptions.withHash {
+		return protocolEncoder.WriteBulkStringResponse(searchResult.Member)
+	}
+
+	elementCount := 1
+	for _, optionEnabled := range []bool{searchOptions.withDistance, searchOptions.withHash, searchOptions.withCoordinates} {
+		if optionEnabled {
+			elementCount++
+		}
+	}
+	if writeError := protocolEncoder.WriteArrayHeaderResponse(elementCount); writeError != nil {
+		return writeError
+	}
+	if writeError := protocolEncoder.WriteBulkStringResponse(searchResult.Member); writeError != nil {
+		return writeError
+	}
+	if searchOptions.withDistance {
+		if writeError := protocolEncoder.WriteBulkStringResponse(formatGeoDistance(searchResult.DistanceMeters, searchOptions.metersPerUnit)); writeError != nil {
+			return writeError
+		}
+	}
+	if searchOptions.withHash {
+		if writeError := protocolEncoder.WriteIntegerResponse(int64(searchResult.Geohash)); writeError != nil {
+			return writeError
+		}
+	}
+	if searchOptions.withCoordinates {
+		return protocolEncoder.WriteArrayResponse([]string{formatGeoCoordinate(searchResult.Coordinates.Longitude), formatGeoCoordinate(searchResult.Coordinates.Latitude)})
+	}
+	return nil
+}
+
+// parseGeoSearchOptions analyse les arguments de GEOSEARCH qui suivent la clé
+// storeMode accepte STOREDIST et refuse les options WITH* (GEOSEARCHSTORE) ; retourne un message d'erreur vide si valide
+func parseGeoSearchOptions(searchArguments []string, storeMode bool) (geoSearchOptions, string) {
+	var searchOptions geoSearchOptions
+	centerGiven, shapeGiven := false, false
+
+	for argumentIndex := 0; argumentIndex < len(searchArguments); argumentIndex++ {
+		remainingArguments := len(searchArguments) - argumentIndex - 1
+		switch optionName := strings.ToUpper(searchArguments[argumentIndex]); optionName {
+		case "FROMMEMBER":
+			if centerGiven || remainingArguments < 1 {
+				return searchOptions, "ERREUR : erreur de syntaxe, un seul FROMMEMBER ou FROMLONLAT est attendu"
+			}
+			searchOptions.searchQuery.FromMember = true
+			searchOptions.searchQuery.CenterMember = searchArguments[argumentIndex+1]
+			centerGiven = true
+			argumentIndex++
+		case "FROMLONLAT":
+			if centerGiven || remainingArguments < 2 {
+				return searchOptions, "ERREUR : erreur de syntaxe, un seul FROMMEMBER ou FROMLONLAT est attendu"
+			}
+			centerCoordinates, coordinatesError := parseGeoCoordinates(searchArguments[argumentIndex+1], searchArguments[argumentIndex+2])
+			if coordinatesError != "" {
+				return searchOptions, coordinatesError
+			}
+			searchOptions.searchQuery.CenterCoordinates = centerCoordinates
+			centerGiven = true
+			argumentIndex += 2
+		case "BYRADIUS":
+			if shapeGiven || remainingArguments < 2 {
+				return searchOptions, "ERREUR : erreur de syntaxe, un seul BYRADIUS ou BYBOX est attendu"
+			}
+			radiusValue, parseError := parseGeoDistanceArgument(searchArguments[argumentIndex+1])
+			if parseError != nil {
+				return searchOptions, "ERREUR : le rayon doit être un nombre positif"
+			}
+			metersPerUnit, validUnit := geoUnitToMeters(searchArguments[argumentIndex+2])
+			if !validUnit {
+				return searchOptions, "ERREUR : unité inconnue, utilisez M, KM, FT ou MI"
+			}
+			searchOptions.searchQuery.RadiusMeters = radiusValue * metersPerUnit
+			searchOptions.metersPerUnit = metersPerUnit
+			shapeGiven = true
+			argumentIndex += 2
+		case "BYBOX":
+			if shapeGiven || remainingArguments < 3 {
+				return searchOptions, "ERREUR : erreur de syntaxe, un seul BYRADIUS ou BYBOX est attendu"
+			}
+			widthValue, widthError := parseGeoDistanceArgument(searchArguments[argumentIndex+1])
+			heightValue, heightError := parseGeoDistanceArgument(searchArguments[argumentIndex+2])
+			if widthError != nil || heightError != nil {
+				return searchOptions, "ERREUR : la largeur et la hauteur doivent être des nombres positifs"
+			}
+			metersPerUnit, validUnit := geoUnitToMeters(searchArguments[argumentIndex+3])
+			if !validUnit {
+				return searchOptions, "ERREUR : unité inconnue, utilisez M, KM, FT ou MI"
+			}
+			searchOptions.searchQuery.ByBox = true
+			searchOptions.searchQuery.WidthMeters = widthValue * metersPerUnit
+			searchOptions.searchQuery.HeightMeters = heightValue * metersPerUnit
+			searchOptions.metersPerUnit = metersPerUnit
+			shapeGiven = true
+			argumentIndex += 3
+		case "ASC":
+			searchOptions.searchQuery.SortOrder = storage.GeoSortAscending
+		case "DESC":
+			searchOptions.searchQuery.SortOrder = storage.GeoSortDescending
+		case "COUNT":
+			if remainingArguments < 1 {
+				return searchOptions, "ERREUR : erreur de syntaxe, COUNT attend un nombre"
+			}
+			countValue, parseError := strconv.Atoi(searchArguments[argumentIndex+1])
+			if parseError != nil || countValue <= 0 {
+				return searchOptions, "ERREUR : COUNT doit être strictement positif"
+			}
+			searchOptions.searchQuery.Count = countValue
+			argumentIndex++
+			if argumentIndex+1 < len(searchArguments) && strings.EqualFold(searchArguments[argumentIndex+1], "ANY") {
+				searchOptions.searchQuery.AnyMatches = true
+				argumentIndex++
+			}
+		case "WITHCOORD", "WITHDIST", "WITHHASH":
+			if storeMode {
+				return searchOptions, "ERREUR : l'option " + optionName + " n'est pas acceptée par GEOSEARCHSTORE"
+			}
+			searchOptions.withCoordinates = searchOptions.withCoordinates || optionName == "WITHCOORD"
+			searchOptions.withDistance = searchOptions.withDistance || optionName == "WITHDIST"
+			searchOptions.withHash = searchOptions.withHash || optionName == "WITHHASH"
+		case "STOREDIST":
+			if !storeMode {
+				return searchOptions, "ERREUR : l'option STOREDIST n'est acceptée que par GEOSEARCHSTORE"
+			}
+			searchOptions.storeDistance = true
+		default:
+			return searchOptions, "ERREUR : erreur de syntaxe, option inconnue '" + searchArguments[argumentIndex] + "'"
+		}
+	}
+
+	if !centerGiven {
+		return searchOptions, "ERREUR : erreur de syntaxe, FROMMEMBER ou FROMLONLAT est obligatoire"
+	}
+	if !shapeGiven {
+		return searchOptions, "ERREUR : erreur de syntaxe, BYRADIUS ou BYBOX est obligatoire"
+	}
+	return searchOptions, ""
+}
+
+// parseGeoDistanceArgument lit un rayon ou une dimension de rectangle (nombre fini positif ou nul)
+func parseGeoDistanceArgument(distanceArgument string) (float64, error) {
+	distanceValue, parseError := strconv.ParseFloat(distanceArgument, 64)
+	if parseError != nil {
+		return 0, parseError
+	}
+	if distanceValue < 0 || math.IsNaN(distanceValue) || math.IsInf(distanceValue, 0) {
+		return 0, errors.New("distance invalide")
+	}
+	return distanceValue, nil
+}
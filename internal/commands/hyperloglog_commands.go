@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"redis-go/internal/protocol"
+	"redis-go/internal/storage"
+)
+
+// SetHyperLogLogSparseMaximumBytes configure la taille maximale de l'encodage sparse, en-tête compris
+// Les HyperLogLog existantes ne changent d'encodage qu'à leur prochaine modification
+func (commandRegistry *RedisCommandRegistry) SetHyperLogLogSparseMaximumBytes(sparseMaximumBytes int) {
+	commandRegistry.hyperLogLogSparseMaximumBytes.Store(int64(sparseMaximumBytes))
+}
+
+// handleHyperLogLogAddCommand implémente PFADD key [element ...]
+func (commandRegistry *RedisCommandRegistry) handleHyperLogLogAddCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'PFADD' (attendu: PFADD clé [élément ...])")
+	}
+
+	registersChanged, addError := redisStorage.AddHyperLogLogElements(commandArguments[0], commandArguments[1:], int(commandRegistry.hyperLogLogSparseMaximumBytes.Load()))
+	if addError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + addError.Error())
+	}
+	if registersChanged {
+		return protocolEncoder.WriteIntegerResponse(1)
+	}
+	return protocolEncoder.WriteIntegerResponse(0)
+}
+
+// handleHyperLogLogCountCommand implémente PFCOUNT key [key ...]
+// Avec plusieurs clés, la cardinalité de leur union est estimée sans rien écrire
+func (commandRegistry *RedisCommandRegistry) handleHyperLogLogCountCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'PFCOUNT' (attendu: PFCOUNT clé [clé ...])")
+	}
+
+	estimatedCardinality, countError := redisStorage.CountHyperLogLogs(commandArguments)
+	if countError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + countError.Error())
+	}
+	return protocolEncoder.WriteIntegerResponse(estimatedCardinality)
+}
+
+// handleHyperLogLogMergeCommand implémente PFMERGE destination [source ...]
+func (commandRegistry *RedisCommandRegistry) handleHyperLogLogMergeCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'PFMERGE' (attendu: PFMERGE destination [source ...])")
+	}
+
+	if mergeError := redisStorage.MergeHyperLogLogs(commandArguments[0], commandArguments[1:], int(commandRegistry.hyperLogLogSparseMaximumBytes.Load())); mergeError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + mergeError.Error())
+	}
+	return protocolEncoder.WriteSimpleStringResponse("OK")
+}
@@ -0,0 +1,473 @@
+package commands
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"redis-go/internal/protocol"
+	"redis-go/internal/storage"
+)
+
+// legacyJSONRootPath est le chemin par défaut des commandes JSON : la racine, en syntaxe historique
+const legacyJSONRootPath = "."
+
+// parseJSONPathArgument analyse un chemin et retourne un message d'erreur (vide si valide)
+func parseJSONPathArgument(pathText string) (*storage.JSONPath, string) {
+	jsonPath, parseError := storage.ParseJSONPath(pathText)
+	if parseError != nil {
+		return nil, "ERREUR : " + parseError.Error() + " '" + pathText + "'"
+	}
+	return jsonPath, ""
+}
+
+// parseJSONValueArgument analyse une valeur JSON passée en argument et retourne un message d'erreur (vide si valide)
+func parseJSONValueArgument(valueText string) (interface{}, string) {
+	jsonValue, parseError := storage.ParseJSONValue(valueText)
+	if parseError != nil {
+		return nil, "ERREUR : " + parseError.Error()
+	}
+	return jsonValue, ""
+}
+
+// legacyJSONResultError vérifie la première valeur d'un chemin historique et retourne un message d'erreur
+// si elle est absente ou n'a pas le type attendu par la commande (vide sinon)
+func legacyJSONResultError(jsonPath *storage.JSONPath, pathResults []storage.JSONPathResult, expectedTypeDescription string) string {
+	if len(pathResults) == 0 {
+		return "ERREUR : le chemin '" + jsonPath.PathText + "' n'existe pas"
+	}
+	if !pathResults[0].Applicable {
+		return "ERREUR : la valeur au chemin '" + jsonPath.PathText + "' n'est pas " + expectedTypeDescription
+	}
+	return ""
+}
+
+// writeJSONLengthResults répond une longueur par valeur trouvée, nulle si la valeur n'a pas le type attendu
+// Avec un chemin historique, la réponse est la longueur de la première valeur
+func writeJSONLengthResults(protocolEncoder *protocol.RedisSerializationProtocolEncoder, jsonPath *storage.JSONPath, pathResults []storage.JSONPathResult, expectedTypeDescription string) error {
+	if jsonPath.IsLegacy {
+		if resultError := legacyJSONResultError(jsonPath, pathResults, expectedTypeDescription); resultError != "" {
+			return protocolEncoder.WriteErrorResponse(resultError)
+		}
+		return protocolEncoder.WriteIntegerResponse(int64(pathResults[0].Length))
+	}
+
+	if writeError := protocolEncoder.WriteArrayHeaderResponse(len(pathResults)); writeError != nil {
+		return writeError
+	}
+	for _, pathResult := range pathResults {
+		var writeError error
+		if pathResult.Applicable {
+			writeError = protocolEncoder.WriteIntegerResponse(int64(pathResult.Length))
+		} else {
+			writeError = protocolEncoder.WriteNullBulkStringResponse()
+		}
+		if writeError != nil {
+			return writeError
+		}
+	}
+	return nil
+}
+
+// handleJSONSetCommand implémente JSON.SET key path value [NX|XX]
+func (commandRegistry *RedisCommandRegistry) handleJSONSetCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 3 && len(commandArguments) != 4 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'JSON.SET' (attendu: JSON.SET clé chemin valeur [NX|XX])")
+	}
+
+	jsonPath, pathError := parseJSONPathArgument(commandArguments[1])
+	if pathError != "" {
+		return protocolEncoder.WriteErrorResponse(pathError)
+	}
+	newValue, valueError := parseJSONValueArgument(commandArguments[2])
+	if valueError != "" {
+		return protocolEncoder.WriteErrorResponse(valueError)
+	}
+
+	setCondition := storage.JSONSetAlways
+	if len(commandArguments) == 4 {
+		switch strings.ToUpper(commandArguments[3]) {
+		case "NX":
+			setCondition = storage.JSONSetIfMissing
+		case "XX":
+			setCondition = storage.JSONSetIfExists
+		default:
+			return protocolEncoder.WriteErrorResponse("ERREUR : erreur de syntaxe, NX ou XX attendu")
+		}
+	}
+
+	valueSet, setError := redisStorage.SetJSONValue(commandArguments[0], jsonPath, newValue, setCondition)
+	if setError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + setError.Error())
+	}
+	if !valueSet {
+		return protocolEncoder.WriteNullBulkStringResponse()
+	}
+	return protocolEncoder.WriteSimpleStringResponse("OK")
+}
+
+// handleJSONGetCommand implémente JSON.GET key [INDENT indent] [NEWLINE newline] [SPACE space] [path ...]
+// Avec plusieurs chemins, la réponse est un objet dont les membres sont les chemins demandés
+func (commandRegistry *RedisCommandRegistry) handleJSONGetCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'JSON.GET' (attendu: JSON.GET clé [INDENT i] [NEWLINE n] [SPACE s] [chemin ...])")
+	}
+
+	var jsonFormat storage.JSONFormat
+	argumentIndex := 1
+formatOptions:
+	for ; argumentIndex+1 < len(commandArguments); argumentIndex += 2 {
+		switch strings.ToUpper(commandArguments[argumentIndex]) {
+		case "INDENT":
+			jsonFormat.Indent = commandArguments[argumentIndex+1]
+		case "NEWLINE":
+			jsonFormat.Newline = commandArguments[argumentIndex+1]
+		case "SPACE":
+			jsonFormat.Space = commandArguments[argumentIndex+1]
+		default:
+			break formatOptions
+		}
+	}
+
+	pathTexts := commandArguments[argumentIndex:]
+	if len(pathTexts) == 0 {
+		pathTexts = []string{legacyJSONRootPath}
+	}
+	jsonPaths := make([]*storage.JSONPath, len(pathTexts))
+	allPathsLegacy := true
+	for pathIndex, pathText := range pathTexts {
+		jsonPath, pathError := parseJSONPathArgument(pathText)
+		if pathError != "" {
+			return protocolEncoder.WriteErrorResponse(pathError)
+		}
+		jsonPaths[pathIndex] = jsonPath
+		allPathsLegacy = allPathsLegacy && jsonPath.IsLegacy
+	}
+
+	pathValues, documentExists, getError := redisStorage.GetJSONValues(commandArguments[0], jsonPaths)
+	if getError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + getError.Error())
+	}
+	if !documentExists {
+		return protocolEncoder.WriteNullBulkStringResponse()
+	}
+
+	// Dès qu'un chemin JSONPath est demandé, chaque chemin répond par le tableau de ses valeurs
+	responseValues := make([]interface{}, len(jsonPaths))
+	for pathIndex, jsonPath := range jsonPaths {
+		if !allPathsLegacy {
+			responseValues[pathIndex] = &storage.JSONArray{Elements: pathValues[pathIndex]}
+			continue
+		}
+		if len(pathValues[pathIndex]) == 0 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : le chemin '" + jsonPath.PathText + "' n'existe pas")
+		}
+		responseValues[pathIndex] = pathValues[pathIndex][0]
+	}
+
+	if len(jsonPaths) == 1 {
+		return protocolEncoder.WriteBulkStringResponse(storage.SerializeJSONValue(responseValues[0], jsonFormat))
+	}
+	responseObject := storage.NewJSONObject()
+	for pathIndex, pathText := range pathTexts {
+		responseObject.SetMember(pathText, responseValues[pathIndex])
+	}
+	return protocolEncoder.WriteBulkStringResponse(storage.SerializeJSONValue(responseObject, jsonFormat))
+}
+
+// handleJSONMultiGetCommand implémente JSON.MGET key [key ...] path
+func (commandRegistry *RedisCommandRegistry) handleJSONMultiGetCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'JSON.MGET' (attendu: JSON.MGET clé [clé ...] chemin)")
+	}
+
+	jsonPath, pathError := parseJSONPathArgument(commandArguments[len(commandArguments)-1])
+	if pathError != "" {
+		return protocolEncoder.WriteErrorResponse(pathError)
+	}
+
+	keyValues, documentFound := redisStorage.GetJSONValuesForKeys(commandArguments[:len(commandArguments)-1], jsonPath)
+	if writeError := protocolEncoder.WriteArrayHeaderResponse(len(keyValues)); writeError != nil {
+		return writeError
+	}
+	for keyIndex, matchedValues := range keyValues {
+		var writeError error
+		switch {
+		case !documentFound[keyIndex] || (jsonPath.IsLegacy && len(matchedValues) == 0):
+			writeError = protocolEncoder.WriteNullBulkStringResponse()
+		case jsonPath.IsLegacy:
+			writeError = protocolEncoder.WriteBulkStringResponse(storage.SerializeJSONValue(matchedValues[0], storage.JSONFormat{}))
+		default:
+			writeError = protocolEncoder.WriteBulkStringResponse(storage.SerializeJSONValue(&storage.JSONArray{Elements: matchedValues}, storage.JSONFormat{}))
+		}
+		if writeError != nil {
+			return writeError
+		}
+	}
+	return nil
+}
+
+// handleJSONDeleteCommand implémente JSON.DEL key [path] (et son alias JSON.FORGET)
+func (commandRegistry *RedisCommandRegistry) handleJSONDeleteCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 1 && len(commandArguments) != 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'JSON.DEL' (attendu: JSON.DEL clé [chemin])")
+	}
+
+	pathText := legacyJSONRootPath
+	if len(commandArguments) == 2 {
+		pathText = commandArguments[1]
+	}
+	jsonPath, pathError := parseJSONPathArgument(pathText)
+	if pathError != "" {
+		return protocolEncoder.WriteErrorResponse(pathError)
+	}
+
+	deletedCount, deleteError := redisStorage.DeleteJSONValues(commandArguments[0], jsonPath)
+	if deleteError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + deleteError.Error())
+	}
+	return protocolEncoder.WriteIntegerResponse(int64(deletedCount))
+}
+
+// handleJSONNumberIncrementByCommand implémente JSON.NUMINCRBY key path value
+// Avec un chemin JSONPath, la réponse est le tableau JSON des nouvelles valeurs (null pour les non-nombres)
+func (commandRegistry *RedisCommandRegistry) handleJSONNumberIncrementByCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 3 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'JSON.NUMINCRBY' (attendu: JSON.NUMINCRBY clé chemin nombre)")
+	}
+
+	jsonPath, pathError := parseJSONPathArgument(commandArguments[1])
+	if pathError != "" {
+		return protocolEncoder.WriteErrorResponse(pathError)
+	}
+	incrementValue, valueError := storage.ParseJSONValue(commandArguments[2])
+	increment, isNumber := incrementValue.(json.Number)
+	if valueError != nil || !isNumber {
+		return protocolEncoder.WriteErrorResponse("ERREUR : l'incrément doit être un nombre JSON")
+	}
+
+	pathResults, incrementError := redisStorage.IncrementJSONNumbers(commandArguments[0], jsonPath, increment)
+	if incrementError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + incrementError.Error())
+	}
+
+	if jsonPath.IsLegacy {
+		if resultError := legacyJSONResultError(jsonPath, pathResults, "un nombre"); resultError != "" {
+			return protocolEncoder.WriteErrorResponse(resultError)
+		}
+		return protocolEncoder.WriteBulkStringResponse(storage.SerializeJSONValue(pathResults[0].Value, storage.JSONFormat{}))
+	}
+	incrementedNumbers := &storage.JSONArray{Elements: make([]interface{}, len(pathResults))}
+	for resultIndex, pathResult := range pathResults {
+		incrementedNumbers.Elements[resultIndex] = pathResult.Value
+	}
+	return protocolEncoder.WriteBulkStringResponse(storage.SerializeJSONValue(incrementedNumbers, storage.JSONFormat{}))
+}
+
+// handleJSONArrayAppendCommand implémente JSON.ARRAPPEND key path value [value ...]
+func (commandRegistry *RedisCommandRegistry) handleJSONArrayAppendCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 3 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'JSON.ARRAPPEND' (attendu: JSON.ARRAPPEND clé chemin valeur [valeur ...])")
+	}
+
+	jsonPath, pathError := parseJSONPathArgument(commandArguments[1])
+	if pathError != "" {
+		return protocolEncoder.WriteErrorResponse(pathError)
+	}
+	appendedValues := make([]interface{}, 0, len(commandArguments)-2)
+	for _, valueText := range commandArguments[2:] {
+		appendedValue, valueError := parseJSONValueArgument(valueText)
+		if valueError != "" {
+			return protocolEncoder.WriteErrorResponse(valueError)
+		}
+		appendedValues = append(appendedValues, appendedValue)
+	}
+
+	pathResults, appendError := redisStorage.AppendJSONArrayValues(commandArguments[0], jsonPath, appendedValues)
+	if appendError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + appendError.Error())
+	}
+	return writeJSONLengthResults(protocolEncoder, jsonPath, pathResults, "un tableau")
+}
+
+// handleJSONArrayLengthCommand implémente JSON.ARRLEN key [path]
+func (commandRegistry *RedisCommandRegistry) handleJSONArrayLengthCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 1 && len(commandArguments) != 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'JSON.ARRLEN' (attendu: JSON.ARRLEN clé [chemin])")
+	}
+
+	pathText := legacyJSONRootPath
+	if len(commandArguments) == 2 {
+		pathText = commandArguments[1]
+	}
+	jsonPath, pathError := parseJSONPathArgument(pathText)
+	if pathError != "" {
+		return protocolEncoder.WriteErrorResponse(pathError)
+	}
+
+	pathResults, documentExists, lengthError := redisStorage.GetJSONArrayLengths(commandArguments[0], jsonPath)
+	if lengthError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + lengthError.Error())
+	}
+	if !documentExists {
+		return protocolEncoder.WriteNullBulkStringResponse()
+	}
+	return writeJSONLengthResults(protocolEncoder, jsonPath, pathResults, "un tableau")
+}
+
+// handleJSONArrayPopCommand implémente JSON.ARRPOP key [path [index]] (index -1 par défaut : dernier élément)
+// Chaque élément retiré est répondu sérialisé en JSON ; un tableau vide répond nil
+func (commandRegistry *RedisCommandRegistry) handleJSONArrayPopCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 1 || len(commandArguments) > 3 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'JSON.ARRPOP' (attendu: JSON.ARRPOP clé [chemin [index]])")
+	}
+
+	pathText := legacyJSONRootPath
+	if len(commandArguments) >= 2 {
+		pathText = commandArguments[1]
+	}
+	jsonPath, pathError := parseJSONPathArgument(pathText)
+	if pathError != "" {
+		return protocolEncoder.WriteErrorResponse(pathError)
+	}
+	popIndex := -1
+	if len(commandArguments) == 3 {
+		var parseError error
+		if popIndex, parseError = strconv.Atoi(commandArguments[2]); parseError != nil {
+			return protocolEncoder.WriteErrorResponse("ERREUR : l'index doit être un entier")
+		}
+	}
+
+	pathResults, popError := redisStorage.PopJSONArrayValues(commandArguments[0], jsonPath, popIndex)
+	if popError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + popError.Error())
+	}
+
+	writePoppedValue := func(pathResult storage.JSONPathResult) error {
+		if !pathResult.Applicable || pathResult.Length == 0 {
+			return protocolEncoder.WriteNullBulkStringResponse()
+		}
+		return protocolEncoder.WriteBulkStringResponse(storage.SerializeJSONValue(pathResult.Value, storage.JSONFormat{}))
+	}
+	if jsonPath.IsLegacy {
+		if resultError := legacyJSONResultError(jsonPath, pathResults, "un tableau"); resultError != "" {
+			return protocolEncoder.WriteErrorResponse(resultError)
+		}
+		return writePoppedValue(pathResults[0])
+	}
+	if writeError := protocolEncoder.WriteArrayHeaderResponse(len(pathResults)); writeError != nil {
+		return writeError
+	}
+	for _, pathResult := range pathResults {
+		if writeError := writePoppedValue(pathResult); writeError != nil {
+			return writeError
+		}
+	}
+	return nil
+}
+
+// handleJSONObjectKeysCommand implémente JSON.OBJKEYS key [path]
+func (commandRegistry *RedisCommandRegistry) handleJSONObjectKeysCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 1 && len(commandArguments) != 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'JSON.OBJKEYS' (attendu: JSON.OBJKEYS clé [chemin])")
+	}
+
+	pathText := legacyJSONRootPath
+	if len(commandArguments) == 2 {
+		pathText = commandArguments[1]
+	}
+	jsonPath, pathError := parseJSONPathArgument(pathText)
+	if pathError != "" {
+		return protocolEncoder.WriteErrorResponse(pathError)
+	}
+
+	pathResults, documentExists, keysError := redisStorage.GetJSONObjectKeys(commandArguments[0], jsonPath)
+	if keysError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + keysError.Error())
+	}
+	if !documentExists {
+		return protocolEncoder.WriteNullArrayResponse()
+	}
+
+	if jsonPath.IsLegacy {
+		if resultError := legacyJSONResultError(jsonPath, pathResults, "un objet"); resultError != "" {
+			return protocolEncoder.WriteErrorResponse(resultError)
+		}
+		return protocolEncoder.WriteArrayResponse(pathResults[0].Value.([]string))
+	}
+	if writeError := protocolEncoder.WriteArrayHeaderResponse(len(pathResults)); writeError != nil {
+		return writeError
+	}
+	for _, pathResult := range pathResults {
+		var writeError error
+		if pathResult.Applicable {
+			writeError = protocolEncoder.WriteArrayResponse(pathResult.Value.([]string))
+		} else {
+			writeError = protocolEncoder.WriteNullArrayResponse()
+		}
+		if writeError != nil {
+			return writeError
+		}
+	}
+	return nil
+}
+
+// handleJSONStringAppendCommand implémente JSON.STRAPPEND key [path] value ; value est une chaîne JSON ("...")
+func (commandRegistry *RedisCommandRegistry) handleJSONStringAppendCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 2 && len(commandArguments) != 3 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'JSON.STRAPPEND' (attendu: JSON.STRAPPEND clé [chemin] chaîne_json)")
+	}
+
+	pathText := legacyJSONRootPath
+	if len(commandArguments) == 3 {
+		pathText = commandArguments[1]
+	}
+	jsonPath, pathError := parseJSONPathArgument(pathText)
+	if pathError != "" {
+		return protocolEncoder.WriteErrorResponse(pathError)
+	}
+	suffixValue, valueError := storage.ParseJSONValue(commandArguments[len(commandArguments)-1])
+	stringSuffix, isString := suffixValue.(string)
+	if valueError != nil || !isString {
+		return protocolEncoder.WriteErrorResponse("ERREUR : la valeur doit être une chaîne JSON, entre guillemets")
+	}
+
+	pathResults, appendError := redisStorage.AppendJSONStrings(commandArguments[0], jsonPath, stringSuffix)
+	if appendError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + appendError.Error())
+	}
+	return writeJSONLengthResults(protocolEncoder, jsonPath, pathResults, "une chaîne")
+}
+
+// handleJSONTypeCommand implémente JSON.TYPE key [path]
+func (commandRegistry *RedisCommandRegistry) handleJSONTypeCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 1 && len(commandArguments) != 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'JSON.TYPE' (attendu: JSON.TYPE clé [chemin])")
+	}
+
+	pathText := legacyJSONRootPath
+	if len(commandArguments) == 2 {
+		pathText = commandArguments[1]
+	}
+	jsonPath, pathError := parseJSONPathArgument(pathText)
+	if pathError != "" {
+		return protocolEncoder.WriteErrorResponse(pathError)
+	}
+
+	pathResults, documentExists, typeError := redisStorage.GetJSONTypes(commandArguments[0], jsonPath)
+	if typeError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + typeError.Error())
+	}
+	if !documentExists || (jsonPath.IsLegacy && len(pathResults) == 0) {
+		return protocolEncoder.WriteNullBulkStringResponse()
+	}
+
+	if jsonPath.IsLegacy {
+		return protocolEncoder.WriteSimpleStringResponse(pathResults[0].Value.(string))
+	}
+	typeNames := make([]string, len(pathResults))
+	for resultIndex, pathResult := range pathResults {
+		typeNames[resultIndex] = pathResult.Value.(string)
+	}
+	return protocolEncoder.WriteArrayResponse(typeNames)
+}
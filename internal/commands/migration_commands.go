@@ -0,0 +1,304 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"redis-go/internal/persistence"
+	"redis-go/internal/protocol"
+	"redis-go/internal/session"
+	"redis-go/internal/storage"
+)
+
+// migrateRequest regroupe les options de MIGRATE
+type migrateRequest struct {
+	targetAddress  string
+	migratedKeys   []string
+	ioTimeout      time.Duration
+	copyKeys       bool // COPY : les clés restent aussi sur ce serveur
+	replaceKeys    bool // REPLACE : écraser les clés existantes sur la cible
+	targetUser     string
+	targetPassword string
+}
+
+// migrateCommandKeys retourne les clés de MIGRATE : l'argument clé, ou les arguments suivant KEYS s'il est vide
+func migrateCommandKeys(commandArguments []string) []string {
+	if len(commandArguments) < 3 {
+		return nil
+	}
+	if commandArguments[2] != "" {
+		return commandArguments[2:3]
+	}
+	for argumentIndex := 5; argumentIndex < len(commandArguments); argumentIndex++ {
+		if strings.EqualFold(commandArguments[argumentIndex], "KEYS") {
+			return commandArguments[argumentIndex+1:]
+		}
+	}
+	return nil
+}
+
+// handleDumpCommand implémente DUMP key
+// Le payload reprend l'encodage des snapshots, suivi de sa version et d'un CRC64 ; il ne contient pas le TTL
+func (commandRegistry *RedisCommandRegistry) handleDumpCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'DUMP' (attendu: DUMP clé)")
+	}
+
+	snapshotRecord, keyExists := redisStorage.DumpKeyRecord(commandArguments[0])
+	if !keyExists {
+		return protocolEncoder.WriteNullBulkStringResponse()
+	}
+	dumpPayload, encodeError := persistence.EncodeDumpPayload(snapshotRecord)
+	if encodeError != nil {
+		return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : sérialisation impossible: %v", encodeError))
+	}
+	return protocolEncoder.WriteBulkStringResponse(string(dumpPayload))
+}
+
+// handleRestoreCommand implémente RESTORE key ttl payload [REPLACE] [ABSTTL]
+// ttl en millisecondes (0 = sans expiration), ou timestamp Unix en millisecondes avec ABSTTL
+func (commandRegistry *RedisCommandRegistry) handleRestoreCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 3 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'RESTORE' (attendu: RESTORE clé ttl payload [REPLACE] [ABSTTL])")
+	}
+
+	storageKey := commandArguments[0]
+	timeToLive, parseError := strconv.ParseInt(commandArguments[1], 10, 64)
+	if parseError != nil || timeToLive < 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : le TTL doit être un entier positif (millisecondes)")
+	}
+
+	replaceExisting, absoluteExpiration := false, false
+	for _, restoreOption := range commandArguments[3:] {
+		switch strings.ToUpper(restoreOption) {
+		case "REPLACE":
+			replaceExisting = true
+		case "ABSTTL":
+			absoluteExpiration = true
+		default:
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : option RESTORE inconnue '%s' (REPLACE, ABSTTL)", restoreOption))
+		}
+	}
+
+	snapshotRecord, decodeError := persistence.DecodeDumpPayload([]byte(commandArguments[2]))
+	if decodeError != nil {
+		return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : payload DUMP invalide (version ou checksum): %v", decodeError))
+	}
+	if !replaceExisting && redisStorage.CheckKeyExists(storageKey) {
+		return protocolEncoder.WriteErrorResponse("BUSYKEY la clé cible existe déjà")
+	}
+
+	if timeToLive > 0 {
+		expirationTime := time.Now().Add(time.Duration(timeToLive) * time.Millisecond)
+		if absoluteExpiration {
+			expirationTime = time.UnixMilli(timeToLive)
+		}
+		// Déjà expirée : la clé n'est pas créée, et l'ancienne valeur disparaît avec REPLACE
+		if !expirationTime.After(time.Now()) {
+			redisStorage.DeleteKeyValue(storageKey)
+			return protocolEncoder.WriteSimpleStringResponse("OK")
+		}
+		snapshotRecord.ExpirationTime = &expirationTime
+	}
+
+	snapshotRecord.Key = storageKey
+	if !redisStorage.RestoreKeyValue(storageKey, snapshotRecord.ToStorageValue(), replaceExisting) {
+		return protocolEncoder.WriteErrorResponse("BUSYKEY la clé cible existe déjà")
+	}
+	return protocolEncoder.WriteSimpleStringResponse("OK")
+}
+
+// handleMigrateCommand implémente MIGRATE host port key|"" db timeout [COPY] [REPLACE] [AUTH password] [AUTH2 username password] [KEYS key ...]
+// Les clés sont envoyées avec RESTORE puis supprimées ici, sans qu'aucune autre écriture ne puisse s'intercaler :
+// comme dans Redis, les écritures des autres clients attendent la fin du transfert (au plus timeout par échange)
+func (commandRegistry *RedisCommandRegistry) handleMigrateCommand(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 5 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'MIGRATE' (attendu: MIGRATE hôte port clé|\"\" base timeout [COPY] [REPLACE] [AUTH mot_de_passe] [AUTH2 utilisateur mot_de_passe] [KEYS clé ...])")
+	}
+
+	if commandRegistry.raftNode != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : MIGRATE indisponible en mode raft")
+	}
+
+	targetPort, portError := strconv.Atoi(commandArguments[1])
+	if portError != nil || targetPort < 1 || targetPort > 65535 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : port cible invalide")
+	}
+	if commandArguments[3] != "0" {
+		return protocolEncoder.WriteErrorResponse("ERREUR : seule la base 0 existe")
+	}
+	timeoutMilliseconds, timeoutError := strconv.ParseInt(commandArguments[4], 10, 64)
+	if timeoutError != nil || timeoutMilliseconds < 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : le timeout doit être un entier positif (millisecondes)")
+	}
+	if timeoutMilliseconds == 0 {
+		timeoutMilliseconds = 1000 // Comme Redis : 0 n'est pas un délai infini
+	}
+
+	migration := migrateRequest{
+		targetAddress: net.JoinHostPort(commandArguments[0], commandArguments[1]),
+		ioTimeout:     time.Duration(timeoutMilliseconds) * time.Millisecond,
+	}
+	usesKeysOption := false
+	for argumentIndex := 5; argumentIndex < len(commandArguments) && !usesKeysOption; argumentIndex++ {
+		switch strings.ToUpper(commandArguments[argumentIndex]) {
+		case "COPY":
+			migration.copyKeys = true
+		case "REPLACE":
+			migration.replaceKeys = true
+		case "AUTH":
+			if argumentIndex+1 >= len(commandArguments) {
+				return protocolEncoder.WriteErrorResponse("ERREUR : AUTH attend un mot de passe")
+			}
+			migration.targetPassword = commandArguments[argumentIndex+1]
+			argumentIndex++
+		case "AUTH2":
+			if argumentIndex+2 >= len(commandArguments) {
+				return protocolEncoder.WriteErrorResponse("ERREUR : AUTH2 attend un utilisateur et un mot de passe")
+			}
+			migration.targetUser = commandArguments[argumentIndex+1]
+			migration.targetPassword = commandArguments[argumentIndex+2]
+			argumentIndex += 2
+		case "KEYS":
+			usesKeysOption = true
+		default:
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : option MIGRATE inconnue '%s'", commandArguments[argumentIndex]))
+		}
+	}
+	if usesKeysOption && commandArguments[2] != "" {
+		return protocolEncoder.WriteErrorResponse("ERREUR : avec KEYS, l'argument clé doit être la chaîne vide \"\"")
+	}
+	migration.migratedKeys = migrateCommandKeys(commandArguments)
+	if len(migration.migratedKeys) == 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : aucune clé à migrer")
+	}
+
+	if commandRegistry.replicationManager.IsReadOnlyReplica() {
+		return protocolEncoder.WriteErrorResponse("READONLY impossible d'écrire sur un réplica en lecture seule")
+	}
+
+	// Réplicas et journal des écritures reçoivent la suppression des clés transférées, pas MIGRATE
+	return commandRegistry.executeRewrittenWrite(clientSession, func(replyEncoder *protocol.RedisSerializationProtocolEncoder) ([]string, error) {
+		return commandRegistry.migrateKeys(migration, redisStorage, replyEncoder), nil
+	}, protocolEncoder)
+}
+
+// migrateKeys transfère les clés vers la cible et écrit la réponse de MIGRATE
+// Retourne la commande DEL équivalente pour les clés supprimées ici (nil si aucune)
+func (commandRegistry *RedisCommandRegistry) migrateKeys(migration migrateRequest, redisStorage *storage.RedisInMemoryStorage, replyEncoder *protocol.RedisSerializationProtocolEncoder) []string {
+	var migratedRecords []storage.SnapshotRecord
+	for _, migratedKey := range migration.migratedKeys {
+		if snapshotRecord, keyExists := redisStorage.DumpKeyRecord(migratedKey); keyExists {
+			migratedRecords = append(migratedRecords, snapshotRecord)
+		}
+	}
+	if len(migratedRecords) == 0 {
+		replyEncoder.WriteSimpleStringResponse("NOKEY")
+		return nil
+	}
+
+	// Pendant une migration de slot, la cible n'accepte les clés qu'avec ASKING : RESTORE-ASKING l'implique
+	restoreCommandName := "RESTORE"
+	if commandRegistry.clusterManager != nil {
+		restoreCommandName = "RESTORE-ASKING"
+	}
+
+	var migrationRequest strings.Builder
+	requestEncoder := protocol.NewRedisSerializationProtocolEncoder(&migrationRequest)
+	if migration.targetPassword != "" {
+		if migration.targetUser != "" {
+			requestEncoder.WriteArrayResponse([]string{"AUTH", migration.targetUser, migration.targetPassword})
+		} else {
+			requestEncoder.WriteArrayResponse([]string{"AUTH", migration.targetPassword})
+		}
+	}
+	for _, snapshotRecord := range migratedRecords {
+		remainingMilliseconds := int64(0)
+		if snapshotRecord.ExpirationTime != nil {
+			remainingMilliseconds = max(time.Until(*snapshotRecord.ExpirationTime).Milliseconds(), 1)
+		}
+		dumpPayload, encodeError := persistence.EncodeDumpPayload(snapshotRecord)
+		if encodeError != nil {
+			replyEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : sérialisation de '%s' impossible: %v", snapshotRecord.Key, encodeError))
+			return nil
+		}
+		restoreCommand := []string{restoreCommandName, snapshotRecord.Key, strconv.FormatInt(remainingMilliseconds, 10), string(dumpPayload)}
+		if migration.replaceKeys {
+			restoreCommand = append(restoreCommand, "REPLACE")
+		}
+		requestEncoder.WriteArrayResponse(restoreCommand)
+	}
+
+	targetConnection, dialError := net.DialTimeout("tcp", migration.targetAddress, migration.ioTimeout)
+	if dialError != nil {
+		replyEncoder.WriteErrorResponse(fmt.Sprintf("IOERR connexion à l'instance cible %s impossible: %v", migration.targetAddress, dialError))
+		return nil
+	}
+	defer targetConnection.Close()
+
+	targetConnection.SetDeadline(time.Now().Add(migration.ioTimeout))
+	if _, writeError := targetConnection.Write([]byte(migrationRequest.String())); writeError != nil {
+		replyEncoder.WriteErrorResponse(fmt.Sprintf("IOERR envoi vers l'instance cible %s: %v", migration.targetAddress, writeError))
+		return nil
+	}
+
+	targetReader := bufio.NewReader(targetConnection)
+	if migration.targetPassword != "" {
+		authenticationReply, readError := readMigrateReply(targetConnection, targetReader, migration.ioTimeout)
+		if readError != nil {
+			replyEncoder.WriteErrorResponse(fmt.Sprintf("IOERR réponse de l'instance cible %s: %v", migration.targetAddress, readError))
+			return nil
+		}
+		if strings.HasPrefix(authenticationReply, "-") {
+			replyEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : l'instance cible a refusé AUTH: %s", strings.TrimPrefix(authenticationReply, "-")))
+			return nil
+		}
+	}
+
+	// Une clé n'est supprimée ici qu'une fois acceptée par la cible
+	var restoredKeys []string
+	firstTargetError := ""
+	for _, snapshotRecord := range migratedRecords {
+		restoreReply, readError := readMigrateReply(targetConnection, targetReader, migration.ioTimeout)
+		if readError != nil {
+			firstTargetError = fmt.Sprintf("IOERR réponse de l'instance cible %s: %v", migration.targetAddress, readError)
+			break
+		}
+		if strings.HasPrefix(restoreReply, "-") {
+			if firstTargetError == "" {
+				firstTargetError = fmt.Sprintf("ERREUR : l'instance cible a répondu par une erreur: %s", strings.TrimPrefix(restoreReply, "-"))
+			}
+			continue
+		}
+		restoredKeys = append(restoredKeys, snapshotRecord.Key)
+	}
+
+	var deleteCommand []string
+	if !migration.copyKeys && len(restoredKeys) > 0 {
+		for _, restoredKey := range restoredKeys {
+			redisStorage.DeleteKeyValue(restoredKey)
+		}
+		deleteCommand = append([]string{"DEL"}, restoredKeys...)
+	}
+
+	if firstTargetError != "" {
+		replyEncoder.WriteErrorResponse(firstTargetError)
+	} else {
+		replyEncoder.WriteSimpleStringResponse("OK")
+	}
+	return deleteCommand
+}
+
+// readMigrateReply lit une réponse d'une ligne de l'instance cible (+OK, -ERR ...)
+func readMigrateReply(targetConnection net.Conn, targetReader *bufio.Reader, ioTimeout time.Duration) (string, error) {
+	targetConnection.SetReadDeadline(time.Now().Add(ioTimeout))
+	replyLine, readError := targetReader.ReadString('\n')
+	if readError != nil {
+		return "", readError
+	}
+	return strings.TrimRight(replyLine, "\r\n"), nil
+}
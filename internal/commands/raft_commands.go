@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"redis-go/internal/consensus"
+	"redis-go/internal/protocol"
+	"redis-go/internal/storage"
+)
+
+// SetRaftNode active le mode consensus : les écritures des clients sont proposées au groupe
+func (commandRegistry *RedisCommandRegistry) SetRaftNode(node *consensus.RaftNode, readTimeout time.Duration) {
+	commandRegistry.raftNode = node
+	commandRegistry.raftReadTimeout = readTimeout
+}
+
+// SetRaftLinearizableReads active ou désactive les lectures linéarisables
+func (commandRegistry *RedisCommandRegistry) SetRaftLinearizableReads(linearizableReads bool) {
+	commandRegistry.raftLinearizableReads.Store(linearizableReads)
+}
+
+// ApplyCommittedCommand applique une écriture validée par le groupe et retourne la réponse destinée au client
+func (commandRegistry *RedisCommandRegistry) ApplyCommittedCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage) []byte {
+	var commandReply bytes.Buffer
+	replyEncoder := protocol.NewRedisSerializationProtocolEncoder(&commandReply)
+	commandHandler, commandExists := commandRegistry.registeredCommands[commandArguments[0]]
+	if !commandExists {
+		replyEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : commande inconnue '%s'", commandArguments[0]))
+		return commandReply.Bytes()
+	}
+	commandHandler(commandArguments[1:], redisStorage, replyEncoder)
+	return commandReply.Bytes()
+}
+
+// executeConsensusWrite propose une écriture au groupe et renvoie la réponse produite par son application
+// Un suiveur redirige le client vers le leader
+// Les arguments sont déjà figés par ExecuteCommand (pinStreamAutoID, pinRelativeExpiration) : chaque nœud,
+// y compris lors d'une relecture du journal après redémarrage, applique la même date d'expiration
+func (commandRegistry *RedisCommandRegistry) executeConsensusWrite(upperCommandName string, commandArguments []string, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	proposedCommand := append([]string{upperCommandName}, commandArguments...)
+	commandReply, proposeError := commandRegistry.raftNode.Propose(proposedCommand)
+	if proposeError != nil {
+		return protocolEncoder.WriteErrorResponse(formatRaftError(proposeError))
+	}
+	return protocolEncoder.WriteRawResponse(commandReply)
+}
+
+// waitForLinearizableRead confirme auprès de la majorité que ce nœud est le leader avant une lecture
+// Retourne le message d'erreur à envoyer au client, vide si la lecture peut être servie
+func (commandRegistry *RedisCommandRegistry) waitForLinearizableRead(upperCommandName string, commandArguments []string) string {
+	if !commandRegistry.raftLinearizableReads.Load() || !isReadCommand(upperCommandName, commandArguments) {
+		return ""
+	}
+	if readError := commandRegistry.raftNode.WaitLinearizableRead(commandRegistry.raftReadTimeout); readError != nil {
+		return formatRaftError(readError)
+	}
+	return ""
+}
+
+// isReadCommand indique si une commande lit les données (catégorie @read)
+func isReadCommand(upperCommandName string, commandArguments []string) bool {
+	_, commandMetadata, metadataExists := lookupCommandMetadata(upperCommandName, commandArguments)
+	return metadataExists && commandMetadata.hasCategory("read")
+}
+
+// formatRaftError traduit une erreur du nœud Raft en réponse d'erreur RESP
+func formatRaftError(raftError error) string {
+	var notLeaderError *consensus.NotLeaderError
+	switch {
+	case errors.As(raftError, &notLeaderError) && notLeaderError.LeaderAddress != "":
+		return "REDIRECT " + notLeaderError.LeaderAddress
+	case errors.As(raftError, &notLeaderError):
+		return "TRYAGAIN aucun leader élu pour le moment"
+	case errors.Is(raftError, consensus.ErrLeadershipLost), errors.Is(raftError, consensus.ErrRaftTimeout):
+		return "TRYAGAIN " + raftError.Error()
+	default:
+		return fmt.Sprintf("ERREUR : raft: %v", raftError)
+	}
+}
+
+// getRaftInfoFields retourne les champs de la section INFO raft
+func (commandRegistry *RedisCommandRegistry) getRaftInfoFields() []string {
+	if commandRegistry.raftNode == nil {
+		return []string{"raft_enabled:0"}
+	}
+	raftStatus := commandRegistry.raftNode.Status()
+	linearizableReads := 0
+	if commandRegistry.raftLinearizableReads.Load() {
+		linearizableReads = 1
+	}
+	return []string{
+		"raft_enabled:1",
+		"raft_node_id:" + raftStatus.NodeID,
+		"raft_role:" + raftStatus.Role,
+		fmt.Sprintf("raft_current_term:%d", raftStatus.CurrentTerm),
+		"raft_leader_id:" + raftStatus.LeaderID,
+		"raft_leader_address:" + raftStatus.LeaderAddress,
+		fmt.Sprintf("raft_members:%d", raftStatus.MemberCount),
+		fmt.Sprintf("raft_commit_index:%d", raftStatus.CommitIndex),
+		fmt.Sprintf("raft_last_applied:%d", raftStatus.LastApplied),
+		fmt.Sprintf("raft_last_log_index:%d", raftStatus.LastLogIndex),
+		fmt.Sprintf("raft_snapshot_index:%d", raftStatus.SnapshotIndex),
+		fmt.Sprintf("raft_snapshot_term:%d", raftStatus.SnapshotTerm),
+		fmt.Sprintf("raft_elections_started:%d", raftStatus.ElectionCount),
+		fmt.Sprintf("raft_linearizable_reads:%d", linearizableReads),
+	}
+}
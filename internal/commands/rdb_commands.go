@@ -2,8 +2,11 @@ package commands
 
 import (
 	"fmt"
+	"redis-go/internal/persistence"
 	"redis-go/internal/protocol"
 	"redis-go/internal/storage"
+	"strconv"
+	"strings"
 )
 
 // RDBPersistenceInterface définit l'interface pour les commandes RDB
@@ -13,20 +16,20 @@ type RDBPersistenceInterface interface {
 	IsSaveInProgress() bool
 	GetLastSaveTime() int64
 	GetStats() map[string]interface{}
+	ListSnapshots() ([]persistence.SnapshotInfo, error)
+	RestoreSnapshot(snapshotName string) error
+	Reload() error
 }
 
-// rdbPersistence stocke la référence vers le système RDB
-var rdbPersistence RDBPersistenceInterface
-
 // SetRDBPersistence configure la persistence RDB pour les commandes
 func (commandRegistry *RedisCommandRegistry) SetRDBPersistence(rdb RDBPersistenceInterface) {
-	rdbPersistence = rdb
+	commandRegistry.rdbPersistence = rdb
 
 	// Ajouter les commandes RDB au registre existant
 	commandRegistry.registeredCommands["SAVE"] = commandRegistry.handleSaveCommand
 	commandRegistry.registeredCommands["BGSAVE"] = commandRegistry.handleBackgroundSaveCommand
 	commandRegistry.registeredCommands["LASTSAVE"] = commandRegistry.handleLastSaveCommand
-	commandRegistry.registeredCommands["INFO"] = commandRegistry.handleInfoCommand
+	commandRegistry.registeredCommands["DEBUG"] = commandRegistry.handleDebugCommand
 }
 
 // handleSaveCommand implémente SAVE (sauvegarde synchrone bloquante)
@@ -35,11 +38,11 @@ func (commandRegistry *RedisCommandRegistry) handleSaveCommand(commandArguments
 		return protocolEncoder.WriteErrorResponse("ERREUR : SAVE ne prend aucun argument")
 	}
 
-	if rdbPersistence == nil {
+	if commandRegistry.rdbPersistence == nil {
 		return protocolEncoder.WriteErrorResponse("ERREUR : persistence RDB non configurée")
 	}
 
-	if err := rdbPersistence.Save(); err != nil {
+	if err := commandRegistry.rdbPersistence.Save(); err != nil {
 		return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : sauvegarde échouée: %v", err))
 	}
 
@@ -52,16 +55,16 @@ func (commandRegistry *RedisCommandRegistry) handleBackgroundSaveCommand(command
 		return protocolEncoder.WriteErrorResponse("ERREUR : BGSAVE ne prend aucun argument")
 	}
 
-	if rdbPersistence == nil {
+	if commandRegistry.rdbPersistence == nil {
 		return protocolEncoder.WriteErrorResponse("ERREUR : persistence RDB non configurée")
 	}
 
 	// Vérifier si une sauvegarde est déjà en cours
-	if rdbPersistence.IsSaveInProgress() {
+	if commandRegistry.rdbPersistence.IsSaveInProgress() {
 		return protocolEncoder.WriteErrorResponse("ERREUR : sauvegarde en arrière-plan déjà en cours")
 	}
 
-	if err := rdbPersistence.BackgroundSave(); err != nil {
+	if err := commandRegistry.rdbPersistence.BackgroundSave(); err != nil {
 		return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : impossible de démarrer BGSAVE: %v", err))
 	}
 
@@ -74,14 +77,87 @@ func (commandRegistry *RedisCommandRegistry) handleLastSaveCommand(commandArgume
 		return protocolEncoder.WriteErrorResponse("ERREUR : LASTSAVE ne prend aucun argument")
 	}
 
-	if rdbPersistence == nil {
+	if commandRegistry.rdbPersistence == nil {
 		return protocolEncoder.WriteErrorResponse("ERREUR : persistence RDB non configurée")
 	}
 
-	lastSaveTime := rdbPersistence.GetLastSaveTime()
+	lastSaveTime := commandRegistry.rdbPersistence.GetLastSaveTime()
 	return protocolEncoder.WriteIntegerResponse(lastSaveTime)
 }
 
+// handleDebugCommand implémente DEBUG RELOAD [snapshot] et DEBUG SNAPSHOTS
+func (commandRegistry *RedisCommandRegistry) handleDebugCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) == 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'DEBUG' (attendu: DEBUG RELOAD [snapshot] | DEBUG SNAPSHOTS)")
+	}
+
+	if commandRegistry.rdbPersistence == nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : persistence RDB non configurée")
+	}
+
+	subcommandArguments := commandArguments[1:]
+
+	switch strings.ToUpper(commandArguments[0]) {
+	case "RELOAD":
+		// Sans argument : sauvegarde puis rechargement du fichier courant, comme Redis
+		if len(subcommandArguments) == 0 {
+			if err := commandRegistry.rdbPersistence.Reload(); err != nil {
+				return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : rechargement échoué: %v", err))
+			}
+			return protocolEncoder.WriteSimpleStringResponse("OK")
+		}
+		if len(subcommandArguments) != 1 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'DEBUG RELOAD' (attendu: DEBUG RELOAD [snapshot])")
+		}
+		if err := commandRegistry.rdbPersistence.RestoreSnapshot(subcommandArguments[0]); err != nil {
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : restauration échouée: %v", err))
+		}
+		// Les données ont changé hors du flux de réplication : les réplicas doivent tout resynchroniser
+		if commandRegistry.replicationManager != nil {
+			commandRegistry.replicationManager.InvalidateReplicationStream()
+		}
+		return protocolEncoder.WriteSimpleStringResponse("OK")
+
+	case "SNAPSHOTS":
+		if len(subcommandArguments) != 0 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : DEBUG SNAPSHOTS ne prend aucun argument")
+		}
+		return commandRegistry.writeSnapshotList(protocolEncoder)
+
+	default:
+		return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : sous-commande DEBUG inconnue '%s'", commandArguments[0]))
+	}
+}
+
+// writeSnapshotList répond avec la description des snapshots conservés, du plus récent au plus ancien
+func (commandRegistry *RedisCommandRegistry) writeSnapshotList(protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	snapshotInfos, err := commandRegistry.rdbPersistence.ListSnapshots()
+	if err != nil {
+		return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : lecture des snapshots impossible: %v", err))
+	}
+
+	if err := protocolEncoder.WriteArrayHeaderResponse(len(snapshotInfos)); err != nil {
+		return err
+	}
+	for _, snapshotInfo := range snapshotInfos {
+		compressedValue := "no"
+		if snapshotInfo.Compressed {
+			compressedValue = "yes"
+		}
+		snapshotFields := []string{
+			"name", snapshotInfo.Name,
+			"created", strconv.FormatInt(snapshotInfo.CreatedAt.Unix(), 10),
+			"keys", strconv.Itoa(snapshotInfo.KeyCount),
+			"bytes", strconv.FormatInt(snapshotInfo.FileBytes, 10),
+			"compressed", compressedValue,
+		}
+		if err := protocolEncoder.WriteArrayResponse(snapshotFields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // handleInfoCommand implémente INFO [section]
 func (commandRegistry *RedisCommandRegistry) handleInfoCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
 	if len(commandArguments) > 1 {
@@ -99,15 +175,21 @@ func (commandRegistry *RedisCommandRegistry) handleInfoCommand(commandArguments
 	case "all", "server":
 		infoResponse += "# Server\r\n"
 		infoResponse += "redis_version:Redis-Go-1.0\r\n"
-		infoResponse += "redis_mode:standalone\r\n"
+		if commandRegistry.clusterManager != nil {
+			infoResponse += "redis_mode:cluster\r\n"
+		} else {
+			infoResponse += "redis_mode:standalone\r\n"
+		}
 		infoResponse += "uptime_in_seconds:unknown\r\n"
 		infoResponse += "\r\n"
 		fallthrough
 
 	case "persistence":
-		if rdbPersistence != nil {
+		if commandRegistry.rdbPersistence != nil || commandRegistry.durableWriteLog != nil {
 			infoResponse += "# Persistence\r\n"
-			stats := rdbPersistence.GetStats()
+		}
+		if commandRegistry.rdbPersistence != nil {
+			stats := commandRegistry.rdbPersistence.GetStats()
 
 			for key, value := range stats {
 				switch v := value.(type) {
@@ -123,6 +205,45 @@ func (commandRegistry *RedisCommandRegistry) handleInfoCommand(commandArguments
 					infoResponse += fmt.Sprintf("%s:%s\r\n", key, v)
 				}
 			}
+		}
+		if commandRegistry.durableWriteLog != nil {
+			for _, infoField := range commandRegistry.durableWriteLog.GetInfoFields() {
+				infoResponse += infoField + "\r\n"
+			}
+		}
+		if commandRegistry.rdbPersistence != nil || commandRegistry.durableWriteLog != nil {
+			infoResponse += "\r\n"
+		}
+		fallthrough
+
+	case "replication":
+		if commandRegistry.replicationManager != nil && (section == "replication" || section == "all") {
+			infoResponse += "# Replication\r\n"
+			for _, infoField := range commandRegistry.replicationManager.GetInfoFields() {
+				infoResponse += infoField + "\r\n"
+			}
+			infoResponse += "\r\n"
+		}
+		fallthrough
+
+	case "cluster":
+		if section == "cluster" || section == "all" {
+			infoResponse += "# Cluster\r\n"
+			if commandRegistry.clusterManager != nil {
+				infoResponse += "cluster_enabled:1\r\n"
+			} else {
+				infoResponse += "cluster_enabled:0\r\n"
+			}
+			infoResponse += "\r\n"
+		}
+		fallthrough
+
+	case "raft":
+		if section == "raft" || section == "all" {
+			infoResponse += "# Raft\r\n"
+			for _, infoField := range commandRegistry.getRaftInfoFields() {
+				infoResponse += infoField + "\r\n"
+			}
 			infoResponse += "\r\n"
 		}
 		fallthrough
@@ -131,6 +252,23 @@ func (commandRegistry *RedisCommandRegistry) handleInfoCommand(commandArguments
 		if section == "memory" || section == "all" {
 			infoResponse += "# Memory\r\n"
 			infoResponse += fmt.Sprintf("used_memory_keys:%d\r\n", redisStorage.GetStorageSize())
+			savepointInfos := redisStorage.ListSavepoints()
+			preservedValueCount := 0
+			for _, savepointInfo := range savepointInfos {
+				preservedValueCount += savepointInfo.PreservedCount
+			}
+			infoResponse += fmt.Sprintf("savepoints:%d\r\n", len(savepointInfos))
+			infoResponse += fmt.Sprintf("savepoints_preserved_values:%d\r\n", preservedValueCount)
+			infoResponse += "\r\n"
+		}
+		fallthrough
+
+	case "stats":
+		if section == "stats" || section == "all" {
+			infoResponse += "# Stats\r\n"
+			infoResponse += fmt.Sprintf("total_commands_processed:%d\r\n", commandRegistry.commandStatistics.totalCommandsProcessed.Load())
+			infoResponse += fmt.Sprintf("acl_access_denied_auth:%d\r\n", commandRegistry.commandStatistics.rejectedAuthenticationCount.Load())
+			infoResponse += fmt.Sprintf("rejected_unauthenticated_commands:%d\r\n", commandRegistry.commandStatistics.unauthenticatedCommandCount.Load())
 			infoResponse += "\r\n"
 		}
 
@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"redis-go/internal/protocol"
+	"redis-go/internal/replication"
+	"redis-go/internal/session"
+	"redis-go/internal/storage"
+)
+
+// SetReplicationManager configure la réplication pour les commandes REPLICAOF, PSYNC, REPLCONF et ROLE
+func (commandRegistry *RedisCommandRegistry) SetReplicationManager(manager *replication.ReplicationManager) {
+	commandRegistry.replicationManager = manager
+
+	commandRegistry.registeredCommands["ROLE"] = commandRegistry.handleRoleCommand
+	commandRegistry.registeredSessionCommands["REPLICAOF"] = commandRegistry.handleReplicaOfCommand
+	commandRegistry.registeredSessionCommands["SLAVEOF"] = commandRegistry.handleReplicaOfCommand // Alias historique
+	commandRegistry.registeredSessionCommands["REPLCONF"] = commandRegistry.handleReplicationConfigCommand
+	commandRegistry.registeredSessionCommands["PSYNC"] = commandRegistry.handlePartialSyncCommand
+	commandRegistry.registeredSessionCommands["SYNC"] = commandRegistry.handlePartialSyncCommand
+}
+
+// isWriteCommand indique si une commande modifie les données (catégorie @write)
+func isWriteCommand(upperCommandName string, commandArguments []string) bool {
+	_, commandMetadata, metadataExists := lookupCommandMetadata(upperCommandName, commandArguments)
+	return metadataExists && commandMetadata.hasCategory("write")
+}
+
+// executeReplicatedWrite exécute une écriture dans l'ordre du flux de réplication (et du journal des écritures)
+func (commandRegistry *RedisCommandRegistry) executeReplicatedWrite(clientSession *session.ClientSession, commandHandler RedisCommandHandler, upperCommandName string, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	replicatedCommand := append([]string{upperCommandName}, commandArguments...)
+	return commandRegistry.executeRewrittenWrite(clientSession, func(replyEncoder *protocol.RedisSerializationProtocolEncoder) ([]string, error) {
+		return replicatedCommand, commandHandler(commandArguments, redisStorage, replyEncoder)
+	}, protocolEncoder)
+}
+
+// executeRewrittenWrite exécute une écriture dont la commande transmise aux réplicas et journalisée est
+// retournée par executeCommand (vide = rien à transmettre)
+// La réponse est préparée dans un tampon et envoyée hors verrou : un client lent ne bloque pas les écritures
+func (commandRegistry *RedisCommandRegistry) executeRewrittenWrite(clientSession *session.ClientSession, executeCommand func(replyEncoder *protocol.RedisSerializationProtocolEncoder) ([]string, error), protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if commandRegistry.durableWriteLog != nil {
+		if logFailure := commandRegistry.durableWriteLog.Failure(); logFailure != nil {
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("MISCONF écritures refusées, journal des écritures en erreur: %v", logFailure))
+		}
+	}
+
+	var commandReply bytes.Buffer
+	replyEncoder := protocol.NewRedisSerializationProtocolEncoder(&commandReply)
+
+	executionError := commandRegistry.replicationManager.ExecuteWriteCommand(func() ([]string, error) {
+		if commandRegistry.durableWriteLog == nil {
+			return executeCommand(replyEncoder)
+		}
+		var replicatedCommand []string
+		writeLogOffset, logError := commandRegistry.durableWriteLog.LogWrite(func() ([]string, error) {
+			var executionError error
+			replicatedCommand, executionError = executeCommand(replyEncoder)
+			return replicatedCommand, executionError
+		})
+		clientSession.RecordWriteLogOffset(writeLogOffset)
+		return replicatedCommand, logError
+	})
+	if executionError != nil {
+		return executionError
+	}
+
+	// CLIENT DURABILITY SYNC : la réponse n'est envoyée qu'une fois l'écriture sur disque
+	if commandRegistry.durableWriteLog != nil && clientSession.DurableWritesEnabled() {
+		if _, waitError := commandRegistry.waitForDurableWrites(clientSession, 0); waitError != nil {
+			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("IOERR écriture appliquée mais non persistée: %v", waitError))
+		}
+	}
+	return protocolEncoder.WriteRawResponse(commandReply.Bytes())
+}
+
+// handleReplicaOfCommand implémente REPLICAOF hôte port | REPLICAOF NO ONE
+func (commandRegistry *RedisCommandRegistry) handleReplicaOfCommand(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'REPLICAOF' (attendu: REPLICAOF hôte port | REPLICAOF NO ONE)")
+	}
+	if commandRegistry.raftNode != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : REPLICAOF indisponible en mode raft, le groupe se réplique par son journal")
+	}
+
+	if strings.EqualFold(commandArguments[0], "NO") && strings.EqualFold(commandArguments[1], "ONE") {
+		commandRegistry.replicationManager.PromoteToMaster()
+		return protocolEncoder.WriteSimpleStringResponse("OK")
+	}
+
+	masterPort, parseError := strconv.Atoi(commandArguments[1])
+	if parseError != nil || masterPort < 1 || masterPort > 65535 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : port du maître invalide")
+	}
+	if commandRegistry.replicationManager.ReplicateFrom(commandArguments[0], masterPort) {
+		return protocolEncoder.WriteSimpleStringResponse("OK déjà réplica de ce maître")
+	}
+	return protocolEncoder.WriteSimpleStringResponse("OK")
+}
+
+// handleReplicationConfigCommand implémente REPLCONF, envoyé par un réplica à son maître
+// listening-port et capa pendant la poignée de main, ACK <offset> ensuite (sans réponse)
+func (commandRegistry *RedisCommandRegistry) handleReplicationConfigCommand(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) == 0 || len(commandArguments)%2 != 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'REPLCONF' (attendu: REPLCONF option valeur [option valeur ...])")
+	}
+
+	for optionIndex := 0; optionIndex < len(commandArguments); optionIndex += 2 {
+		optionValue := commandArguments[optionIndex+1]
+		switch strings.ToLower(commandArguments[optionIndex]) {
+		case "listening-port":
+			listeningPort, parseError := strconv.Atoi(optionValue)
+			if parseError != nil || listeningPort < 0 || listeningPort > 65535 {
+				return protocolEncoder.WriteErrorResponse("ERREUR : port d'écoute invalide")
+			}
+			commandRegistry.replicationManager.RecordListeningPort(clientSession, listeningPort)
+		case "capa":
+			// Capacités annoncées par le réplica : seul le transfert $EOF est utilisé
+		case "ack":
+			ackOffset, parseError := strconv.ParseInt(optionValue, 10, 64)
+			if parseError == nil {
+				commandRegistry.replicationManager.AcknowledgeReplicaOffset(clientSession, ackOffset)
+			}
+			return nil
+		case "getack":
+			// Seul un maître envoie GETACK : le lien de réplication y répond directement
+			return nil
+		default:
+			return protocolEncoder.WriteErrorResponse("ERREUR : option REPLCONF inconnue '" + commandArguments[optionIndex] + "'")
+		}
+	}
+	return protocolEncoder.WriteSimpleStringResponse("OK")
+}
+
+// handlePartialSyncCommand implémente PSYNC replid offset (et SYNC, équivalent à PSYNC ? -1)
+// La connexion devient celle d'un réplica : elle ne reçoit plus que le flux de réplication
+func (commandRegistry *RedisCommandRegistry) handlePartialSyncCommand(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	requestedReplicationID, requestedOffset := "?", int64(-1)
+	switch len(commandArguments) {
+	case 0:
+	case 2:
+		parsedOffset, parseError := strconv.ParseInt(commandArguments[1], 10, 64)
+		if parseError != nil {
+			return protocolEncoder.WriteErrorResponse("ERREUR : l'offset de PSYNC doit être un entier")
+		}
+		requestedReplicationID, requestedOffset = commandArguments[0], parsedOffset
+	default:
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'PSYNC' (attendu: PSYNC replid offset)")
+	}
+
+	if refusalMessage := commandRegistry.replicationManager.CheckReplicaSyncAllowed(); refusalMessage != "" {
+		return protocolEncoder.WriteErrorResponse(refusalMessage)
+	}
+	if clientSession.GetClientType() == session.ReplicaClientType {
+		return protocolEncoder.WriteErrorResponse("ERREUR : cette connexion est déjà celle d'un réplica")
+	}
+
+	clientSession.SetClientType(session.ReplicaClientType)
+	clientSession.SetReplyMode(session.ClientReplyOff)
+	if syncError := commandRegistry.replicationManager.HandleReplicaSync(clientSession, requestedReplicationID, requestedOffset); syncError != nil {
+		log.Printf("❌ Synchronisation du réplica %s échouée: %v", clientSession.RemoteAddress, syncError)
+		clientSession.RequestCloseAfterReply()
+	}
+	return nil
+}
+
+// handleRoleCommand implémente ROLE
+// Maître : [master, offset, [[ip, port, offset acquitté] ...]]
+// Réplica : [slave, hôte du maître, port, état du lien, offset]
+func (commandRegistry *RedisCommandRegistry) handleRoleCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : ROLE ne prend aucun argument")
+	}
+
+	roleDescription := commandRegistry.replicationManager.DescribeRole()
+	if roleDescription.Role == replication.ReplicaRole {
+		protocolEncoder.WriteArrayHeaderResponse(5)
+		protocolEncoder.WriteBulkStringResponse("slave")
+		protocolEncoder.WriteBulkStringResponse(roleDescription.MasterHost)
+		protocolEncoder.WriteIntegerResponse(int64(roleDescription.MasterPort))
+		protocolEncoder.WriteBulkStringResponse(roleDescription.LinkState)
+		return protocolEncoder.WriteIntegerResponse(roleDescription.ReplicationOffset)
+	}
+
+	protocolEncoder.WriteArrayHeaderResponse(3)
+	protocolEncoder.WriteBulkStringResponse("master")
+	protocolEncoder.WriteIntegerResponse(roleDescription.ReplicationOffset)
+	protocolEncoder.WriteArrayHeaderResponse(len(roleDescription.Replicas))
+	for _, replicaDescription := range roleDescription.Replicas {
+		if writeError := protocolEncoder.WriteArrayResponse([]string{
+			replicaDescription.ReplicaAddress,
+			strconv.Itoa(replicaDescription.ListeningPort),
+			strconv.FormatInt(replicaDescription.AckOffset, 10),
+		}); writeError != nil {
+			return writeError
+		}
+	}
+	return nil
+}
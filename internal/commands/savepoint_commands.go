@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"redis-go/internal/protocol"
+	"redis-go/internal/session"
+	"redis-go/internal/storage"
+)
+
+// SetMaximumSavepoints configure le nombre maximal de savepoints (0 = SNAPSHOT CREATE refusée)
+// Les savepoints existants au-delà de la nouvelle limite sont conservés
+func (commandRegistry *RedisCommandRegistry) SetMaximumSavepoints(savepointLimit int) {
+	commandRegistry.maximumSavepoints.Store(int64(savepointLimit))
+}
+
+// handleSnapshotCommand implémente SNAPSHOT CREATE|ROLLBACK|DROP nom et SNAPSHOT LIST
+// Savepoints en mémoire pour remettre rapidement le jeu de données dans un état connu (tests d'intégration)
+// Les savepoints restent locaux : rien n'est transmis aux réplicas ni journalisé, et ROLLBACK, qui change
+// les données hors du flux d'écritures, force une synchronisation complète des réplicas (comme DEBUG RELOAD)
+func (commandRegistry *RedisCommandRegistry) handleSnapshotCommand(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) == 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'SNAPSHOT' (attendu: SNAPSHOT CREATE|ROLLBACK|DROP nom | SNAPSHOT LIST)")
+	}
+	// Les savepoints ne font pas partie de l'état transmis par les snapshots raft : un nœud rattrapé n'en aurait pas
+	if commandRegistry.raftNode != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : SNAPSHOT indisponible en mode raft")
+	}
+	// Le journal des écritures ne peut pas rejouer un retour en arrière qu'aucun fichier RDB ne contient
+	if commandRegistry.durableWriteLog != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : SNAPSHOT indisponible avec durable-log")
+	}
+
+	subcommandName := strings.ToUpper(commandArguments[0])
+	if subcommandName == "LIST" {
+		if len(commandArguments) != 1 {
+			return protocolEncoder.WriteErrorResponse("ERREUR : SNAPSHOT LIST ne prend aucun argument")
+		}
+		return writeSavepointList(redisStorage.ListSavepoints(), protocolEncoder)
+	}
+
+	if len(commandArguments) != 2 || commandArguments[1] == "" {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'SNAPSHOT " + subcommandName + "' (attendu: SNAPSHOT " + subcommandName + " nom)")
+	}
+	savepointName := commandArguments[1]
+
+	switch subcommandName {
+	case "CREATE":
+		createError := redisStorage.CreateSavepoint(savepointName, int(commandRegistry.maximumSavepoints.Load()))
+		if errors.Is(createError, storage.ErrSavepointLimitExceeded) {
+			return protocolEncoder.WriteErrorResponse("ERREUR : " + createError.Error() + " (max-savepoints " + strconv.FormatInt(commandRegistry.maximumSavepoints.Load(), 10) + ")")
+		}
+		if createError != nil {
+			return protocolEncoder.WriteErrorResponse("ERREUR : " + createError.Error() + " '" + savepointName + "'")
+		}
+		return protocolEncoder.WriteSimpleStringResponse("OK")
+
+	case "ROLLBACK":
+		if commandRegistry.replicationManager.IsReadOnlyReplica() {
+			return protocolEncoder.WriteErrorResponse("READONLY impossible d'écrire sur un réplica en lecture seule")
+		}
+		if _, rollbackError := redisStorage.RollbackToSavepoint(savepointName); rollbackError != nil {
+			return protocolEncoder.WriteErrorResponse("ERREUR : " + rollbackError.Error() + " '" + savepointName + "'")
+		}
+		commandRegistry.replicationManager.InvalidateReplicationStream()
+		return protocolEncoder.WriteSimpleStringResponse("OK")
+
+	case "DROP":
+		if redisStorage.DropSavepoint(savepointName) {
+			return protocolEncoder.WriteIntegerResponse(1)
+		}
+		return protocolEncoder.WriteIntegerResponse(0)
+
+	default:
+		return protocolEncoder.WriteErrorResponse("ERREUR : sous-commande SNAPSHOT inconnue '" + commandArguments[0] + "' (CREATE, ROLLBACK, DROP, LIST)")
+	}
+}
+
+// writeSavepointList écrit un array par savepoint, du plus ancien au plus récent
+// preserved compte les valeurs copiées parce qu'elles ont été modifiées depuis la création
+func writeSavepointList(savepointInfos []storage.SavepointInfo, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if err := protocolEncoder.WriteArrayHeaderResponse(len(savepointInfos)); err != nil {
+		return err
+	}
+	for _, savepointInfo := range savepointInfos {
+		savepointFields := []string{
+			"name", savepointInfo.Name,
+			"created_ms", strconv.FormatInt(savepointInfo.CreatedAt.UnixMilli(), 10),
+			"keys", strconv.Itoa(savepointInfo.KeyCount),
+			"preserved", strconv.Itoa(savepointInfo.PreservedCount),
+		}
+		if err := protocolEncoder.WriteArrayResponse(savepointFields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
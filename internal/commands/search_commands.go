@@ -0,0 +1,328 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"redis-go/internal/acl"
+	"redis-go/internal/protocol"
+	"redis-go/internal/session"
+	"redis-go/internal/storage"
+)
+
+// defaultSearchResultLimit est le nombre de résultats retournés par FT.SEARCH sans LIMIT, comme RediSearch
+const defaultSearchResultLimit = 10
+
+// searchIndexError formate une erreur d'index ; le nom de l'index est ajouté pour un index inconnu
+func searchIndexError(indexName string, indexError error) string {
+	if errors.Is(indexError, storage.ErrSearchIndexUnknown) || errors.Is(indexError, storage.ErrSearchIndexExists) {
+		return "ERREUR : " + indexError.Error() + " '" + indexName + "'"
+	}
+	return "ERREUR : " + indexError.Error()
+}
+
+// parseSearchCount lit le nombre qui précède une liste d'arguments (PREFIX n, RETURN n)
+// et vérifie que la liste tient dans les arguments restants
+func parseSearchCount(commandArguments []string, countIndex int, optionName string) (int, string) {
+	if countIndex >= len(commandArguments) {
+		return 0, "ERREUR : nombre attendu après " + optionName
+	}
+	argumentCount, parseError := strconv.Atoi(commandArguments[countIndex])
+	if parseError != nil || argumentCount < 0 || argumentCount > len(commandArguments)-countIndex-1 {
+		return 0, "ERREUR : nombre d'arguments invalide pour " + optionName
+	}
+	return argumentCount, ""
+}
+
+// parseSearchSchema lit les champs qui suivent SCHEMA : nom TEXT|TAG [SEPARATOR s]|NUMERIC [SORTABLE]
+func parseSearchSchema(schemaArguments []string) ([]storage.SearchFieldDefinition, string) {
+	var schemaFields []storage.SearchFieldDefinition
+	declaredFields := make(map[string]struct{})
+	for argumentIndex := 0; argumentIndex < len(schemaArguments); {
+		if argumentIndex+1 >= len(schemaArguments) {
+			return nil, "ERREUR : type manquant pour le champ '" + schemaArguments[argumentIndex] + "'"
+		}
+		fieldDefinition := storage.SearchFieldDefinition{FieldName: schemaArguments[argumentIndex]}
+		if _, alreadyDeclared := declaredFields[fieldDefinition.FieldName]; alreadyDeclared {
+			return nil, "ERREUR : champ '" + fieldDefinition.FieldName + "' déclaré plusieurs fois"
+		}
+		declaredFields[fieldDefinition.FieldName] = struct{}{}
+
+		switch strings.ToUpper(schemaArguments[argumentIndex+1]) {
+		case "TEXT":
+			fieldDefinition.FieldType = storage.SearchTextField
+		case "TAG":
+			fieldDefinition.FieldType, fieldDefinition.TagSeparator = storage.SearchTagField, ","
+		case "NUMERIC":
+			fieldDefinition.FieldType = storage.SearchNumericField
+		default:
+			return nil, "ERREUR : type de champ inconnu '" + schemaArguments[argumentIndex+1] + "' (TEXT, TAG ou NUMERIC)"
+		}
+		argumentIndex += 2
+
+	fieldOptions:
+		for argumentIndex < len(schemaArguments) {
+			switch strings.ToUpper(schemaArguments[argumentIndex]) {
+			case "SEPARATOR":
+				if fieldDefinition.FieldType != storage.SearchTagField {
+					return nil, "ERREUR : SEPARATOR n'est accepté que pour un champ TAG"
+				}
+				if argumentIndex+1 >= len(schemaArguments) || len(schemaArguments[argumentIndex+1]) != 1 {
+					return nil, "ERREUR : SEPARATOR attend un seul caractère"
+				}
+				fieldDefinition.TagSeparator = schemaArguments[argumentIndex+1]
+				argumentIndex += 2
+			case "SORTABLE":
+				fieldDefinition.Sortable = true
+				argumentIndex++
+			default:
+				break fieldOptions
+			}
+		}
+		schemaFields = append(schemaFields, fieldDefinition)
+	}
+	if len(schemaFields) == 0 {
+		return nil, "ERREUR : le schéma doit déclarer au moins un champ"
+	}
+	return schemaFields, ""
+}
+
+// handleSearchCreateCommand implémente FT.CREATE index [ON HASH] [PREFIX n préfixe ...] SCHEMA champ type [options] ...
+func (commandRegistry *RedisCommandRegistry) handleSearchCreateCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 4 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'FT.CREATE' (attendu: FT.CREATE index [ON HASH] [PREFIX n préfixe ...] SCHEMA champ TEXT|TAG|NUMERIC [...])")
+	}
+
+	indexDefinition := storage.SearchIndexDefinition{IndexName: commandArguments[0]}
+	argumentIndex := 1
+	for argumentIndex < len(commandArguments) && !strings.EqualFold(commandArguments[argumentIndex], "SCHEMA") {
+		switch strings.ToUpper(commandArguments[argumentIndex]) {
+		case "ON":
+			if argumentIndex+1 >= len(commandArguments) || !strings.EqualFold(commandArguments[argumentIndex+1], "HASH") {
+				return protocolEncoder.WriteErrorResponse("ERREUR : seuls les index sur des hashes sont supportés (ON HASH)")
+			}
+			argumentIndex += 2
+		case "PREFIX":
+			prefixCount, countError := parseSearchCount(commandArguments, argumentIndex+1, "PREFIX")
+			if countError != "" {
+				return protocolEncoder.WriteErrorResponse(countError)
+			}
+			indexDefinition.KeyPrefixes = append(indexDefinition.KeyPrefixes, commandArguments[argumentIndex+2:argumentIndex+2+prefixCount]...)
+			argumentIndex += 2 + prefixCount
+		default:
+			return protocolEncoder.WriteErrorResponse("ERREUR : option inconnue '" + commandArguments[argumentIndex] + "' (ON HASH, PREFIX ou SCHEMA attendu)")
+		}
+	}
+	if argumentIndex >= len(commandArguments) {
+		return protocolEncoder.WriteErrorResponse("ERREUR : SCHEMA manquant")
+	}
+
+	schemaFields, schemaError := parseSearchSchema(commandArguments[argumentIndex+1:])
+	if schemaError != "" {
+		return protocolEncoder.WriteErrorResponse(schemaError)
+	}
+	indexDefinition.SchemaFields = schemaFields
+
+	if createError := redisStorage.CreateSearchIndex(indexDefinition); createError != nil {
+		return protocolEncoder.WriteErrorResponse(searchIndexError(indexDefinition.IndexName, createError))
+	}
+	return protocolEncoder.WriteSimpleStringResponse("OK")
+}
+
+// handleSearchCommand implémente FT.SEARCH index requête [NOCONTENT] [RETURN n champ ...] [SORTBY champ [ASC|DESC]] [LIMIT début nombre]
+// Réponse : le nombre total de hashes trouvés, puis pour chaque hash de la page sa clé et ses champs
+// Les clés ne sont pas nommées dans la commande : les hashes que l'utilisateur ne peut pas lire (règles ~motif)
+// sont écartés des résultats et du total
+func (commandRegistry *RedisCommandRegistry) handleSearchCommand(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'FT.SEARCH' (attendu: FT.SEARCH index requête [NOCONTENT] [RETURN n champ ...] [SORTBY champ [ASC|DESC]] [LIMIT début nombre])")
+	}
+
+	searchQuery, queryError := storage.ParseSearchQuery(commandArguments[1])
+	if queryError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + queryError.Error())
+	}
+
+	searchOptions := storage.SearchOptions{ResultLimit: defaultSearchResultLimit}
+	for argumentIndex := 2; argumentIndex < len(commandArguments); {
+		switch strings.ToUpper(commandArguments[argumentIndex]) {
+		case "NOCONTENT":
+			searchOptions.NoContent = true
+			argumentIndex++
+		case "RETURN":
+			fieldCount, countError := parseSearchCount(commandArguments, argumentIndex+1, "RETURN")
+			if countError != "" {
+				return protocolEncoder.WriteErrorResponse(countError)
+			}
+			// RETURN 0 : clés seulement, comme NOCONTENT
+			searchOptions.ReturnFields = append([]string{}, commandArguments[argumentIndex+2:argumentIndex+2+fieldCount]...)
+			searchOptions.NoContent = searchOptions.NoContent || fieldCount == 0
+			argumentIndex += 2 + fieldCount
+		case "SORTBY":
+			if argumentIndex+1 >= len(commandArguments) {
+				return protocolEncoder.WriteErrorResponse("ERREUR : nom de champ attendu après SORTBY")
+			}
+			searchOptions.SortFieldName = commandArguments[argumentIndex+1]
+			argumentIndex += 2
+			if argumentIndex < len(commandArguments) {
+				switch strings.ToUpper(commandArguments[argumentIndex]) {
+				case "ASC":
+					argumentIndex++
+				case "DESC":
+					searchOptions.SortDescending = true
+					argumentIndex++
+				}
+			}
+		case "LIMIT":
+			if argumentIndex+2 >= len(commandArguments) {
+				return protocolEncoder.WriteErrorResponse("ERREUR : LIMIT attend un début et un nombre de résultats")
+			}
+			resultOffset, offsetError := strconv.Atoi(commandArguments[argumentIndex+1])
+			resultLimit, limitError := strconv.Atoi(commandArguments[argumentIndex+2])
+			if offsetError != nil || limitError != nil || resultOffset < 0 || resultLimit < 0 {
+				return protocolEncoder.WriteErrorResponse("ERREUR : LIMIT attend deux entiers positifs")
+			}
+			searchOptions.ResultOffset, searchOptions.ResultLimit = resultOffset, resultLimit
+			argumentIndex += 3
+		default:
+			return protocolEncoder.WriteErrorResponse("ERREUR : option inconnue '" + commandArguments[argumentIndex] + "'")
+		}
+	}
+
+	// FT.SEARCH est traitée comme session : en mode raft elle applique elle-même la règle des lectures
+	if commandRegistry.raftNode != nil {
+		if readError := commandRegistry.waitForLinearizableRead("FT.SEARCH", commandArguments); readError != "" {
+			return protocolEncoder.WriteErrorResponse(readError)
+		}
+	}
+
+	aclUser := commandRegistry.accessControlList.GetUser(clientSession.GetUserName())
+	if aclUser == nil {
+		return protocolEncoder.WriteErrorResponse("NOPERM l'utilisateur '" + clientSession.GetUserName() + "' n'existe plus")
+	}
+	searchOptions.KeyFilter = func(storageKey string) bool {
+		return aclUser.IsKeyAllowed(storageKey, acl.KeyPermissionRead)
+	}
+
+	searchResult, searchError := redisStorage.SearchIndex(commandArguments[0], searchQuery, searchOptions)
+	if searchError != nil {
+		return protocolEncoder.WriteErrorResponse(searchIndexError(commandArguments[0], searchError))
+	}
+
+	replyLength := 1 + len(searchResult.Documents)
+	if !searchOptions.NoContent {
+		replyLength += len(searchResult.Documents)
+	}
+	if writeError := protocolEncoder.WriteArrayHeaderResponse(replyLength); writeError != nil {
+		return writeError
+	}
+	if writeError := protocolEncoder.WriteIntegerResponse(int64(searchResult.TotalCount)); writeError != nil {
+		return writeError
+	}
+	for _, searchDocument := range searchResult.Documents {
+		if writeError := protocolEncoder.WriteBulkStringResponse(searchDocument.Key); writeError != nil {
+			return writeError
+		}
+		if searchOptions.NoContent {
+			continue
+		}
+		documentFields := make([]string, 0, 2*len(searchDocument.DocumentFields))
+		for _, documentField := range searchDocument.DocumentFields {
+			documentFields = append(documentFields, documentField.FieldName, documentField.FieldValue)
+		}
+		if writeError := protocolEncoder.WriteArrayResponse(documentFields); writeError != nil {
+			return writeError
+		}
+	}
+	return nil
+}
+
+// handleSearchDropIndexCommand implémente FT.DROPINDEX index [DD] ; DD supprime aussi les hashes indexés
+func (commandRegistry *RedisCommandRegistry) handleSearchDropIndexCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 1 && len(commandArguments) != 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'FT.DROPINDEX' (attendu: FT.DROPINDEX index [DD])")
+	}
+	deleteDocuments := len(commandArguments) == 2
+	if deleteDocuments && !strings.EqualFold(commandArguments[1], "DD") {
+		return protocolEncoder.WriteErrorResponse("ERREUR : erreur de syntaxe, DD attendu")
+	}
+
+	if dropError := redisStorage.DropSearchIndex(commandArguments[0], deleteDocuments); dropError != nil {
+		return protocolEncoder.WriteErrorResponse(searchIndexError(commandArguments[0], dropError))
+	}
+	return protocolEncoder.WriteSimpleStringResponse("OK")
+}
+
+// handleSearchInfoCommand implémente FT.INFO index : nom, préfixes, schéma et nombre de hashes indexés
+func (commandRegistry *RedisCommandRegistry) handleSearchInfoCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'FT.INFO' (attendu: FT.INFO index)")
+	}
+
+	indexDefinition, documentCount, infoError := redisStorage.GetSearchIndexInfo(commandArguments[0])
+	if infoError != nil {
+		return protocolEncoder.WriteErrorResponse(searchIndexError(commandArguments[0], infoError))
+	}
+
+	if writeError := protocolEncoder.WriteArrayHeaderResponse(8); writeError != nil {
+		return writeError
+	}
+	if writeError := protocolEncoder.WriteBulkStringResponse("index_name"); writeError != nil {
+		return writeError
+	}
+	if writeError := protocolEncoder.WriteBulkStringResponse(indexDefinition.IndexName); writeError != nil {
+		return writeError
+	}
+
+	if writeError := protocolEncoder.WriteBulkStringResponse("index_definition"); writeError != nil {
+		return writeError
+	}
+	if writeError := protocolEncoder.WriteArrayHeaderResponse(4); writeError != nil {
+		return writeError
+	}
+	for _, definitionEntry := range []string{"key_type", "HASH", "prefixes"} {
+		if writeError := protocolEncoder.WriteBulkStringResponse(definitionEntry); writeError != nil {
+			return writeError
+		}
+	}
+	keyPrefixes := indexDefinition.KeyPrefixes
+	if len(keyPrefixes) == 0 {
+		keyPrefixes = []string{""}
+	}
+	if writeError := protocolEncoder.WriteArrayResponse(keyPrefixes); writeError != nil {
+		return writeError
+	}
+
+	if writeError := protocolEncoder.WriteBulkStringResponse("attributes"); writeError != nil {
+		return writeError
+	}
+	if writeError := protocolEncoder.WriteArrayHeaderResponse(len(indexDefinition.SchemaFields)); writeError != nil {
+		return writeError
+	}
+	for _, fieldDefinition := range indexDefinition.SchemaFields {
+		fieldAttributes := []string{"identifier", fieldDefinition.FieldName, "type", fieldDefinition.FieldType.TypeName()}
+		if fieldDefinition.FieldType == storage.SearchTagField {
+			fieldAttributes = append(fieldAttributes, "SEPARATOR", fieldDefinition.TagSeparator)
+		}
+		if fieldDefinition.Sortable {
+			fieldAttributes = append(fieldAttributes, "SORTABLE")
+		}
+		if writeError := protocolEncoder.WriteArrayResponse(fieldAttributes); writeError != nil {
+			return writeError
+		}
+	}
+
+	if writeError := protocolEncoder.WriteBulkStringResponse("num_docs"); writeError != nil {
+		return writeError
+	}
+	return protocolEncoder.WriteIntegerResponse(int64(documentCount))
+}
+
+// handleSearchListCommand implémente FT._LIST : noms des index existants
+func (commandRegistry *RedisCommandRegistry) handleSearchListCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'FT._LIST'")
+	}
+	return protocolEncoder.WriteArrayResponse(redisStorage.ListSearchIndexes())
+}
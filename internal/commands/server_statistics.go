@@ -0,0 +1,17 @@
+package commands
+
+import "sync/atomic"
+
+// serverStatistics regroupe les compteurs exposés dans la section INFO stats
+type serverStatistics struct {
+	totalCommandsProcessed      atomic.Int64
+	rejectedAuthenticationCount atomic.Int64
+	unauthenticatedCommandCount atomic.Int64
+}
+
+// resetStatistics remet tous les compteurs à zéro
+func (statistics *serverStatistics) resetStatistics() {
+	statistics.totalCommandsProcessed.Store(0)
+	statistics.rejectedAuthenticationCount.Store(0)
+	statistics.unauthenticatedCommandCount.Store(0)
+}
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"redis-go/internal/protocol"
+	"redis-go/internal/storage"
+)
+
+// parseSortedSetAddOptions lit les options [NX|XX] [CH] communes à ZADD et GEOADD
+// Retourne les options, CH, la position du premier argument suivant et un message d'erreur (vide si valide)
+func parseSortedSetAddOptions(commandArguments []string) (storage.SortedSetAddOptions, bool, int, string) {
+	var addOptions storage.SortedSetAddOptions
+	countChangedMembers := false
+	argumentIndex := 1
+	for ; argumentIndex < len(commandArguments); argumentIndex++ {
+		switch strings.ToUpper(commandArguments[argumentIndex]) {
+		case "NX":
+			addOptions.OnlyNewMembers = true
+		case "XX":
+			addOptions.OnlyExistingMembers = true
+		case "CH":
+			countChangedMembers = true
+		default:
+			if addOptions.OnlyNewMembers && addOptions.OnlyExistingMembers {
+				return addOptions, false, argumentIndex, "ERREUR : les options NX et XX sont incompatibles"
+			}
+			return addOptions, countChangedMembers, argumentIndex, ""
+		}
+	}
+	if addOptions.OnlyNewMembers && addOptions.OnlyExistingMembers {
+		return addOptions, false, argumentIndex, "ERREUR : les options NX et XX sont incompatibles"
+	}
+	return addOptions, countChangedMembers, argumentIndex, ""
+}
+
+// handleSortedSetAddCommand implémente ZADD key [NX|XX] [CH] score member [score member ...]
+func (commandRegistry *RedisCommandRegistry) handleSortedSetAddCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 3 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'ZADD' (attendu: ZADD clé [NX|XX] [CH] score membre [score membre ...])")
+	}
+
+	addOptions, countChangedMembers, argumentIndex, syntaxError := parseSortedSetAddOptions(commandArguments)
+	if syntaxError != "" {
+		return protocolEncoder.WriteErrorResponse(syntaxError)
+	}
+	memberArguments := commandArguments[argumentIndex:]
+	if len(memberArguments) == 0 || len(memberArguments)%2 != 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : erreur de syntaxe, paires score membre attendues")
+	}
+
+	sortedSetMembers := make([]storage.SortedSetMember, 0, len(memberArguments)/2)
+	for pairIndex := 0; pairIndex < len(memberArguments); pairIndex += 2 {
+		memberScore, parseError := strconv.ParseFloat(memberArguments[pairIndex], 64)
+		if parseError != nil || math.IsNaN(memberScore) {
+			return protocolEncoder.WriteErrorResponse("ERREUR : le score doit être un nombre flottant")
+		}
+		sortedSetMembers = append(sortedSetMembers, storage.SortedSetMember{Member: memberArguments[pairIndex+1], Score: memberScore})
+	}
+
+	addedCount, updatedCount, addError := redisStorage.AddSortedSetMembers(commandArguments[0], sortedSetMembers, addOptions)
+	if addError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + addError.Error())
+	}
+	if countChangedMembers {
+		return protocolEncoder.WriteIntegerResponse(int64(addedCount + updatedCount))
+	}
+	return protocolEncoder.WriteIntegerResponse(int64(addedCount))
+}
+
+// handleSortedSetRemoveCommand implémente ZREM key member [member ...]
+func (commandRegistry *RedisCommandRegistry) handleSortedSetRemoveCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'ZREM' (attendu: ZREM clé membre [membre ...])")
+	}
+
+	removedCount, removeError := redisStorage.RemoveSortedSetMembers(commandArguments[0], commandArguments[1:])
+	if removeError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + removeError.Error())
+	}
+	return protocolEncoder.WriteIntegerResponse(int64(removedCount))
+}
+
+// handleSortedSetScoreCommand implémente ZSCORE key member
+func (commandRegistry *RedisCommandRegistry) handleSortedSetScoreCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'ZSCORE' (attendu: ZSCORE clé membre)")
+	}
+
+	memberScores, memberFound, scoreError := redisStorage.GetSortedSetScores(commandArguments[0], commandArguments[1:])
+	if scoreError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + scoreError.Error())
+	}
+	if !memberFound[0] {
+		return protocolEncoder.WriteNullBulkStringResponse()
+	}
+	return protocolEncoder.WriteBulkStringResponse(formatSortedSetScore(memberScores[0]))
+}
+
+// formatSortedSetScore écrit un score comme Redis : inf et -inf pour les infinis, sinon sans perte de précision
+func formatSortedSetScore(memberScore float64) string {
+	switch {
+	case math.IsInf(memberScore, 1):
+		return "inf"
+	case math.IsInf(memberScore, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(memberScore, 'f', -1, 64)
+}
+
+// handleSortedSetCardinalityCommand implémente ZCARD key
+func (commandRegistry *RedisCommandRegistry) handleSortedSetCardinalityCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'ZCARD' (attendu: ZCARD clé)")
+	}
+
+	memberCount, cardinalityError := redisStorage.GetSortedSetCardinality(commandArguments[0])
+	if cardinalityError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + cardinalityError.Error())
+	}
+	return protocolEncoder.WriteIntegerResponse(int64(memberCount))
+}
@@ -0,0 +1,469 @@
+package commands
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"redis-go/internal/protocol"
+	"redis-go/internal/session"
+	"redis-go/internal/storage"
+)
+
+// pinnedAutoIDPrefix marque un identifiant "*" dont l'horloge a été fixée avant propagation ("*@1700000000000")
+// Réplicas, journal et membres du groupe raft rejouent ainsi XADD avec la même horloge et génèrent le même identifiant
+const pinnedAutoIDPrefix = "*@"
+
+// pinStreamAutoID remplace l'identifiant "*" de XADD par sa forme à horloge fixée
+// Les autres commandes sont retournées telles quelles
+func pinStreamAutoID(upperCommandName string, commandArguments []string) []string {
+	if upperCommandName != "XADD" {
+		return commandArguments
+	}
+	idPosition := findStreamAddIDPosition(commandArguments)
+	if idPosition < 0 || commandArguments[idPosition] != "*" {
+		return commandArguments
+	}
+	pinnedArguments := append([]string(nil), commandArguments...)
+	pinnedArguments[idPosition] = pinnedAutoIDPrefix + strconv.FormatInt(time.Now().UnixMilli(), 10)
+	return pinnedArguments
+}
+
+// findStreamAddIDPosition retourne la position de l'identifiant dans les arguments de XADD, -1 si la syntaxe est invalide
+func findStreamAddIDPosition(commandArguments []string) int {
+	argumentIndex := 1
+	for argumentIndex < len(commandArguments) {
+		switch strings.ToUpper(commandArguments[argumentIndex]) {
+		case "NOMKSTREAM":
+			argumentIndex++
+		case "MAXLEN", "MINID":
+			_, nextIndex, trimError := parseStreamTrimArguments(commandArguments, argumentIndex)
+			if trimError != "" {
+				return -1
+			}
+			argumentIndex = nextIndex
+		default:
+			return argumentIndex
+		}
+	}
+	return -1
+}
+
+// parseStreamTrimArguments lit MAXLEN|MINID [=|~] seuil [LIMIT nombre] à partir de startIndex
+// Retourne les options, la position suivante et un message d'erreur (vide si la syntaxe est valide)
+func parseStreamTrimArguments(commandArguments []string, startIndex int) (storage.StreamTrimOptions, int, string) {
+	trimOptions := storage.StreamTrimOptions{
+		TrimEnabled:     true,
+		TrimByMinimumID: strings.EqualFold(commandArguments[startIndex], "MINID"),
+	}
+	argumentIndex := startIndex + 1
+
+	approximateTrim := false
+	if argumentIndex < len(commandArguments) && (commandArguments[argumentIndex] == "=" || commandArguments[argumentIndex] == "~") {
+		approximateTrim = commandArguments[argumentIndex] == "~"
+		argumentIndex++
+	}
+	if argumentIndex >= len(commandArguments) {
+		return trimOptions, argumentIndex, "ERREUR : erreur de syntaxe, seuil de plafonnement manquant"
+	}
+
+	thresholdText := commandArguments[argumentIndex]
+	if trimOptions.TrimByMinimumID {
+		minimumID, validID := storage.ParseStreamEntryID(thresholdText, 0)
+		if !validID {
+			return trimOptions, argumentIndex, "ERREUR : identifiant de stream invalide"
+		}
+		trimOptions.MinimumID = minimumID
+	} else {
+		maximumLength, parseError := strconv.ParseInt(thresholdText, 10, 64)
+		if parseError != nil || maximumLength < 0 {
+			return trimOptions, argumentIndex, "ERREUR : MAXLEN attend un entier positif ou nul"
+		}
+		trimOptions.MaximumLength = maximumLength
+	}
+	argumentIndex++
+
+	if argumentIndex < len(commandArguments) && strings.EqualFold(commandArguments[argumentIndex], "LIMIT") {
+		if !approximateTrim {
+			return trimOptions, argumentIndex, "ERREUR : erreur de syntaxe, LIMIT n'est accepté qu'avec ~"
+		}
+		if argumentIndex+1 >= len(commandArguments) {
+			return trimOptions, argumentIndex, "ERREUR : erreur de syntaxe, LIMIT attend un nombre"
+		}
+		trimLimit, parseError := strconv.ParseInt(commandArguments[argumentIndex+1], 10, 64)
+		if parseError != nil || trimLimit < 0 {
+			return trimOptions, argumentIndex, "ERREUR : LIMIT attend un entier positif ou nul"
+		}
+		trimOptions.Limit = trimLimit
+		argumentIndex += 2
+	}
+	return trimOptions, argumentIndex, ""
+}
+
+// parseStreamAddID interprète l'identifiant de XADD : "*", "*@horloge" (forme propagée), "ms-*", "ms-seq" ou "ms"
+func parseStreamAddID(idText string) (storage.StreamIDRequest, bool) {
+	if idText == "*" {
+		return storage.StreamIDRequest{AutoGenerate: true, ClockMilliseconds: uint64(time.Now().UnixMilli())}, true
+	}
+	if clockText, isPinned := strings.CutPrefix(idText, pinnedAutoIDPrefix); isPinned {
+		clockMilliseconds, parseError := strconv.ParseUint(clockText, 10, 64)
+		return storage.StreamIDRequest{AutoGenerate: true, ClockMilliseconds: clockMilliseconds}, parseError == nil
+	}
+	if millisecondsText, isAutoSequence := strings.CutSuffix(idText, "-*"); isAutoSequence {
+		milliseconds, parseError := strconv.ParseUint(millisecondsText, 10, 64)
+		return storage.StreamIDRequest{AutoSequence: true, EntryID: storage.StreamEntryID{Milliseconds: milliseconds}}, parseError == nil
+	}
+	entryID, validID := storage.ParseStreamEntryID(idText, 0)
+	return storage.StreamIDRequest{EntryID: entryID}, validID
+}
+
+// parseStreamRangeBound interprète une borne de XRANGE : "-", "+", "ms", "ms-seq", préfixée de "(" si exclusive
+// Une borne de fin sans séquence couvre toute la milliseconde
+func parseStreamRangeBound(boundText string, isEndBound bool) (storage.StreamEntryID, bool) {
+	switch boundText {
+	case "-":
+		return storage.StreamEntryID{}, true
+	case "+":
+		return storage.MaximumStreamEntryID, true
+	}
+
+	exclusiveText, isExclusive := strings.CutPrefix(boundText, "(")
+	missingSequence := uint64(0)
+	if isEndBound {
+		missingSequence = math.MaxUint64
+	}
+	boundID, validID := storage.ParseStreamEntryID(exclusiveText, missingSequence)
+	if !validID || !isExclusive {
+		return boundID, validID
+	}
+	if isEndBound {
+		return boundID.Previous()
+	}
+	return boundID.Next()
+}
+
+// writeStreamEntries écrit des entrées au format [[id, [champ, valeur, ...]], ...]
+func writeStreamEntries(protocolEncoder *protocol.RedisSerializationProtocolEncoder, streamEntries []storage.StreamEntry) error {
+	if writeError := protocolEncoder.WriteArrayHeaderResponse(len(streamEntries)); writeError != nil {
+		return writeError
+	}
+	for _, streamEntry := range streamEntries {
+		protocolEncoder.WriteArrayHeaderResponse(2)
+		protocolEncoder.WriteBulkStringResponse(streamEntry.EntryID.String())
+		if writeError := protocolEncoder.WriteArrayResponse(streamEntry.FieldValues); writeError != nil {
+			return writeError
+		}
+	}
+	return nil
+}
+
+// handleStreamAddCommand implémente XADD key [NOMKSTREAM] [MAXLEN|MINID [=|~] seuil [LIMIT nombre]] id|* field value [field value ...]
+func (commandRegistry *RedisCommandRegistry) handleStreamAddCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 4 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'XADD' (attendu: XADD clé [NOMKSTREAM] [MAXLEN|MINID [=|~] seuil [LIMIT nombre]] id|* champ valeur [champ valeur ...])")
+	}
+
+	streamKey := commandArguments[0]
+	createIfMissing := true
+	var trimOptions storage.StreamTrimOptions
+
+	argumentIndex := 1
+	for parsingOptions := true; parsingOptions && argumentIndex < len(commandArguments); {
+		switch strings.ToUpper(commandArguments[argumentIndex]) {
+		case "NOMKSTREAM":
+			createIfMissing = false
+			argumentIndex++
+		case "MAXLEN", "MINID":
+			var trimError string
+			trimOptions, argumentIndex, trimError = parseStreamTrimArguments(commandArguments, argumentIndex)
+			if trimError != "" {
+				return protocolEncoder.WriteErrorResponse(trimError)
+			}
+		default:
+			parsingOptions = false
+		}
+	}
+
+	fieldValues := commandArguments[min(argumentIndex+1, len(commandArguments)):]
+	if argumentIndex >= len(commandArguments) || len(fieldValues) == 0 || len(fieldValues)%2 != 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'XADD' (attendu: XADD clé [options] id|* champ valeur [champ valeur ...])")
+	}
+	idRequest, validID := parseStreamAddID(commandArguments[argumentIndex])
+	if !validID {
+		return protocolEncoder.WriteErrorResponse("ERREUR : identifiant de stream invalide")
+	}
+
+	newEntryID, entryAdded, addError := redisStorage.AddStreamEntry(streamKey, idRequest, fieldValues, createIfMissing, trimOptions)
+	if addError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : " + addError.Error())
+	}
+	if !entryAdded {
+		return protocolEncoder.WriteNullBulkStringResponse()
+	}
+	return protocolEncoder.WriteBulkStringResponse(newEntryID.String())
+}
+
+// handleStreamRangeCommand implémente XRANGE key start end [COUNT count]
+func (commandRegistry *RedisCommandRegistry) handleStreamRangeCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	return commandRegistry.executeStreamRange("XRANGE", commandArguments, redisStorage, protocolEncoder, false)
+}
+
+// handleStreamReverseRangeCommand implémente XREVRANGE key end start [COUNT count]
+func (commandRegistry *RedisCommandRegistry) handleStreamReverseRangeCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	return commandRegistry.executeStreamRange("XREVRANGE", commandArguments, redisStorage, protocolEncoder, true)
+}
+
+// executeStreamRange est la logique commune de XRANGE et XREVRANGE (bornes inversées pour XREVRANGE)
+func (commandRegistry *RedisCommandRegistry) executeStreamRange(commandName string, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder, reverse bool) error {
+	if len(commandArguments) != 3 && len(commandArguments) != 5 {
+		expectedUsage := "XRANGE clé début fin [COUNT nombre]"
+		if reverse {
+			expectedUsage = "XREVRANGE clé fin début [COUNT nombre]"
+		}
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour '" + commandName + "' (attendu: " + expectedUsage + ")")
+	}
+
+	startText, endText := commandArguments[1], commandArguments[2]
+	if reverse {
+		startText, endText = endText, startText
+	}
+	startID, validStart := parseStreamRangeBound(startText, false)
+	endID, validEnd := parseStreamRangeBound(endText, true)
+	if !validStart || !validEnd {
+		return protocolEncoder.WriteErrorResponse("ERREUR : identifiant de stream invalide")
+	}
+
+	maximumCount := 0
+	if len(commandArguments) == 5 {
+		if !strings.EqualFold(commandArguments[3], "COUNT") {
+			return protocolEncoder.WriteErrorResponse("ERREUR : erreur de syntaxe, COUNT attendu")
+		}
+		requestedCount, parseError := strconv.Atoi(commandArguments[4])
+		if parseError != nil {
+			return protocolEncoder.WriteErrorResponse("ERREUR : COUNT attend un nombre entier")
+		}
+		if requestedCount <= 0 {
+			return protocolEncoder.WriteArrayResponse([]string{})
+		}
+		maximumCount = requestedCount
+	}
+
+	rangeEntries, isStream := redisStorage.GetStreamEntriesInRange(commandArguments[0], startID, endID, maximumCount, reverse)
+	if !isStream {
+		return protocolEncoder.WriteErrorResponse("ERREUR : cette clé ne contient pas un stream")
+	}
+	return writeStreamEntries(protocolEncoder, rangeEntries)
+}
+
+// handleStreamLengthCommand implémente XLEN key
+func (commandRegistry *RedisCommandRegistry) handleStreamLengthCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'XLEN' (attendu: XLEN clé)")
+	}
+
+	streamLength := redisStorage.GetStreamLength(commandArguments[0])
+	if streamLength == -1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : cette clé ne contient pas un stream")
+	}
+	return protocolEncoder.WriteIntegerResponse(int64(streamLength))
+}
+
+// handleStreamDeleteCommand implémente XDEL key id [id ...]
+func (commandRegistry *RedisCommandRegistry) handleStreamDeleteCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'XDEL' (attendu: XDEL clé id [id ...])")
+	}
+
+	entryIDs := make([]storage.StreamEntryID, 0, len(commandArguments)-1)
+	for _, idText := range commandArguments[1:] {
+		entryID, validID := storage.ParseStreamEntryID(idText, 0)
+		if !validID {
+			return protocolEncoder.WriteErrorResponse("ERREUR : identifiant de stream invalide")
+		}
+		entryIDs = append(entryIDs, entryID)
+	}
+
+	deletedCount := redisStorage.DeleteStreamEntries(commandArguments[0], entryIDs)
+	if deletedCount == -1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : cette clé ne contient pas un stream")
+	}
+	return protocolEncoder.WriteIntegerResponse(int64(deletedCount))
+}
+
+// handleStreamTrimCommand implémente XTRIM key MAXLEN|MINID [=|~] seuil [LIMIT nombre]
+func (commandRegistry *RedisCommandRegistry) handleStreamTrimCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) < 3 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'XTRIM' (attendu: XTRIM clé MAXLEN|MINID [=|~] seuil [LIMIT nombre])")
+	}
+	if !strings.EqualFold(commandArguments[1], "MAXLEN") && !strings.EqualFold(commandArguments[1], "MINID") {
+		return protocolEncoder.WriteErrorResponse("ERREUR : erreur de syntaxe, MAXLEN ou MINID attendu")
+	}
+
+	trimOptions, nextIndex, trimError := parseStreamTrimArguments(commandArguments, 1)
+	if trimError != "" {
+		return protocolEncoder.WriteErrorResponse(trimError)
+	}
+	if nextIndex != len(commandArguments) {
+		return protocolEncoder.WriteErrorResponse("ERREUR : erreur de syntaxe")
+	}
+
+	removedCount := redisStorage.TrimStream(commandArguments[0], trimOptions)
+	if removedCount == -1 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : cette clé ne contient pas un stream")
+	}
+	return protocolEncoder.WriteIntegerResponse(int64(removedCount))
+}
+
+// xreadCommandKeys extrait les clés de XREAD : la première moitié des arguments qui suivent STREAMS
+func xreadCommandKeys(commandArguments []string) []string {
+	for argumentIndex, commandArgument := range commandArguments {
+		if strings.EqualFold(commandArgument, "STREAMS") {
+			streamArguments := commandArguments[argumentIndex+1:]
+			return streamArguments[:len(streamArguments)/2]
+		}
+	}
+	return nil
+}
+
+// handleStreamReadCommand implémente XREAD [COUNT count] [BLOCK milliseconds] STREAMS key [key ...] id [id ...]
+// "$" désigne le dernier identifiant du stream au moment de l'appel : seules les entrées ajoutées ensuite sont lues
+// Avec BLOCK, le client attend qu'une entrée arrive sur l'un des streams (0 = sans délai maximum)
+func (commandRegistry *RedisCommandRegistry) handleStreamReadCommand(clientSession *session.ClientSession, commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	maximumCount := 0
+	blockTimeout := time.Duration(-1)
+	argumentIndex := 0
+	for argumentIndex < len(commandArguments) && !strings.EqualFold(commandArguments[argumentIndex], "STREAMS") {
+		if argumentIndex+1 >= len(commandArguments) {
+			return protocolEncoder.WriteErrorResponse("ERREUR : erreur de syntaxe")
+		}
+		optionValue, parseError := strconv.ParseInt(commandArguments[argumentIndex+1], 10, 64)
+		switch strings.ToUpper(commandArguments[argumentIndex]) {
+		case "COUNT":
+			if parseError != nil {
+				return protocolEncoder.WriteErrorResponse("ERREUR : COUNT attend un nombre entier")
+			}
+			maximumCount = int(max(optionValue, 0))
+		case "BLOCK":
+			if parseError != nil || optionValue < 0 {
+				return protocolEncoder.WriteErrorResponse("ERREUR : BLOCK attend un délai en millisecondes positif ou nul")
+			}
+			blockTimeout = time.Duration(optionValue) * time.Millisecond
+		default:
+			return protocolEncoder.WriteErrorResponse("ERREUR : erreur de syntaxe")
+		}
+		argumentIndex += 2
+	}
+
+	streamArguments := commandArguments[min(argumentIndex+1, len(commandArguments)):]
+	if argumentIndex >= len(commandArguments) || len(streamArguments) == 0 || len(streamArguments)%2 != 0 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'XREAD' (attendu: XREAD [COUNT nombre] [BLOCK ms] STREAMS clé [clé ...] id [id ...])")
+	}
+
+	// XREAD est traitée comme session : en mode raft elle applique elle-même la règle des lectures
+	if commandRegistry.raftNode != nil {
+		if readError := commandRegistry.waitForLinearizableRead("XREAD", commandArguments); readError != "" {
+			return protocolEncoder.WriteErrorResponse(readError)
+		}
+	}
+
+	streamKeys := streamArguments[:len(streamArguments)/2]
+	afterIDs := make([]storage.StreamEntryID, len(streamKeys))
+	for keyIndex, idText := range streamArguments[len(streamKeys):] {
+		if idText == "$" {
+			lastID, isStream := redisStorage.GetStreamLastID(streamKeys[keyIndex])
+			if !isStream {
+				return protocolEncoder.WriteErrorResponse("ERREUR : cette clé ne contient pas un stream")
+			}
+			afterIDs[keyIndex] = lastID
+			continue
+		}
+		afterID, validID := storage.ParseStreamEntryID(idText, 0)
+		if !validID {
+			return protocolEncoder.WriteErrorResponse("ERREUR : identifiant de stream invalide")
+		}
+		afterIDs[keyIndex] = afterID
+	}
+
+	if blockTimeout < 0 {
+		return writeStreamReadResult(streamKeys, afterIDs, maximumCount, redisStorage, protocolEncoder)
+	}
+
+	// Inscription avant la première lecture : un XADD concurrent ne peut pas être manqué
+	streamWaiter := redisStorage.WatchStreams(streamKeys)
+	defer redisStorage.UnwatchStreams(streamWaiter)
+	clientSession.SetBlocked(true)
+	defer clientSession.SetBlocked(false)
+
+	var timeoutChannel <-chan time.Time
+	if blockTimeout > 0 {
+		timeoutTimer := time.NewTimer(blockTimeout)
+		defer timeoutTimer.Stop()
+		timeoutChannel = timeoutTimer.C
+	}
+
+	for {
+		streamResults, allStreams := readStreamsAfter(streamKeys, afterIDs, maximumCount, redisStorage)
+		if !allStreams || len(streamResults) > 0 {
+			return writeStreamResults(streamKeys, streamResults, allStreams, protocolEncoder)
+		}
+		select {
+		case <-streamWaiter.Notifications:
+		case <-timeoutChannel:
+			return protocolEncoder.WriteNullArrayResponse()
+		case <-clientSession.Closed():
+			return nil
+		}
+	}
+}
+
+// readStreamsAfter lit, pour chaque stream, les entrées postérieures à l'identifiant correspondant
+// Le résultat est indexé par position de clé ; les streams sans nouvelle entrée en sont absents
+// Retourne false si l'une des clés n'est pas un stream
+func readStreamsAfter(streamKeys []string, afterIDs []storage.StreamEntryID, maximumCount int, redisStorage *storage.RedisInMemoryStorage) (map[int][]storage.StreamEntry, bool) {
+	streamResults := make(map[int][]storage.StreamEntry)
+	for keyIndex, streamKey := range streamKeys {
+		startID, hasNext := afterIDs[keyIndex].Next()
+		if !hasNext {
+			continue
+		}
+		streamEntries, isStream := redisStorage.GetStreamEntriesInRange(streamKey, startID, storage.MaximumStreamEntryID, maximumCount, false)
+		if !isStream {
+			return nil, false
+		}
+		if len(streamEntries) > 0 {
+			streamResults[keyIndex] = streamEntries
+		}
+	}
+	return streamResults, true
+}
+
+// writeStreamReadResult lit les streams puis écrit la réponse de XREAD
+func writeStreamReadResult(streamKeys []string, afterIDs []storage.StreamEntryID, maximumCount int, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	streamResults, allStreams := readStreamsAfter(streamKeys, afterIDs, maximumCount, redisStorage)
+	return writeStreamResults(streamKeys, streamResults, allStreams, protocolEncoder)
+}
+
+// writeStreamResults écrit [[clé, entrées], ...] dans l'ordre des clés, ou un array null si rien n'a été lu
+func writeStreamResults(streamKeys []string, streamResults map[int][]storage.StreamEntry, allStreams bool, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if !allStreams {
+		return protocolEncoder.WriteErrorResponse("ERREUR : cette clé ne contient pas un stream")
+	}
+	if len(streamResults) == 0 {
+		return protocolEncoder.WriteNullArrayResponse()
+	}
+
+	protocolEncoder.WriteArrayHeaderResponse(len(streamResults))
+	for keyIndex, streamKey := range streamKeys {
+		streamEntries, hasEntries := streamResults[keyIndex]
+		if !hasEntries {
+			continue
+		}
+		protocolEncoder.WriteArrayHeaderResponse(2)
+		protocolEncoder.WriteBulkStringResponse(streamKey)
+		if writeError := writeStreamEntries(protocolEncoder, streamEntries); writeError != nil {
+			return writeError
+		}
+	}
+	return nil
+}
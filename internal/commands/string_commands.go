@@ -10,16 +10,16 @@ import (
 	"redis-go/internal/storage"
 )
 
-// handleSetCommand implémente SET key value [EX seconds]
+// handleSetCommand implémente SET key value [EX seconds | PXAT unix-time-milliseconds]
 func (commandRegistry *RedisCommandRegistry) handleSetCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
 	if len(commandArguments) < 2 {
-		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'SET' (attendu: SET clé valeur [EX secondes])")
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'SET' (attendu: SET clé valeur [EX secondes | PXAT timestamp-ms])")
 	}
 
 	storageKey := commandArguments[0]
 	storageValue := commandArguments[1]
 
-	// Parsing des options (EX pour TTL)
+	// Parsing des options (EX pour TTL relatif, PXAT pour expiration absolue)
 	for argumentIndex := 2; argumentIndex < len(commandArguments); argumentIndex++ {
 		switch strings.ToUpper(commandArguments[argumentIndex]) {
 		case "EX":
@@ -36,6 +36,25 @@ func (commandRegistry *RedisCommandRegistry) handleSetCommand(commandArguments [
 			timeToLive := time.Duration(expirationSeconds) * time.Second
 			redisStorage.SetKeyValue(storageKey, storageValue, storage.RedisStringType, &timeToLive)
 			return protocolEncoder.WriteSimpleStringResponse("OK")
+		case "PXAT":
+			if argumentIndex+1 >= len(commandArguments) {
+				return protocolEncoder.WriteErrorResponse("ERREUR : valeur manquante après 'PXAT'")
+			}
+			expirationTimestamp, parseError := strconv.ParseInt(commandArguments[argumentIndex+1], 10, 64)
+			if parseError != nil {
+				return protocolEncoder.WriteErrorResponse("ERREUR : la valeur après 'PXAT' doit être un nombre entier")
+			}
+			if expirationTimestamp <= 0 {
+				return protocolEncoder.WriteErrorResponse("ERREUR : le délai d'expiration doit être positif")
+			}
+			// Date déjà passée (relecture du journal, réplica en retard) : la valeur a expiré
+			timeToLive := time.Until(time.UnixMilli(expirationTimestamp))
+			if timeToLive <= 0 {
+				redisStorage.DeleteKeyValue(storageKey)
+				return protocolEncoder.WriteSimpleStringResponse("OK")
+			}
+			redisStorage.SetKeyValue(storageKey, storageValue, storage.RedisStringType, &timeToLive)
+			return protocolEncoder.WriteSimpleStringResponse("OK")
 		default:
 			return protocolEncoder.WriteErrorResponse(fmt.Sprintf("ERREUR : option inconnue '%s' pour SET", commandArguments[argumentIndex]))
 		}
@@ -125,21 +144,33 @@ func (commandRegistry *RedisCommandRegistry) handleTypeCommand(commandArguments
 	storageKey := commandArguments[0]
 	keyDataType := redisStorage.GetKeyDataType(storageKey)
 
-	var dataTypeString string
-	switch keyDataType {
-	case storage.RedisStringType:
-		dataTypeString = "string"
-	case storage.RedisListType:
-		dataTypeString = "list"
-	case storage.RedisSetType:
-		dataTypeString = "set"
-	case storage.RedisHashType:
-		dataTypeString = "hash"
-	case storage.RedisZSetType:
-		dataTypeString = "zset"
-	default:
-		dataTypeString = "none"
-	}
-
-	return protocolEncoder.WriteSimpleStringResponse(dataTypeString)
+	return protocolEncoder.WriteSimpleStringResponse(keyDataType.TypeName())
+}
+
+// handleRenameCommand implémente RENAME key newkey
+func (commandRegistry *RedisCommandRegistry) handleRenameCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'RENAME' (attendu: RENAME clé nouvelle_clé)")
+	}
+
+	if _, sourceExists := redisStorage.RenameKey(commandArguments[0], commandArguments[1], false); !sourceExists {
+		return protocolEncoder.WriteErrorResponse("ERREUR : clé inexistante")
+	}
+	return protocolEncoder.WriteSimpleStringResponse("OK")
+}
+
+// handleRenameNxCommand implémente RENAMENX key newkey (renomme seulement si newkey n'existe pas)
+func (commandRegistry *RedisCommandRegistry) handleRenameNxCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'RENAMENX' (attendu: RENAMENX clé nouvelle_clé)")
+	}
+
+	keyRenamed, sourceExists := redisStorage.RenameKey(commandArguments[0], commandArguments[1], true)
+	if !sourceExists {
+		return protocolEncoder.WriteErrorResponse("ERREUR : clé inexistante")
+	}
+	if !keyRenamed {
+		return protocolEncoder.WriteIntegerResponse(0)
+	}
+	return protocolEncoder.WriteIntegerResponse(1)
 }
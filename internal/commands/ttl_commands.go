@@ -2,6 +2,7 @@ package commands
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	"redis-go/internal/protocol"
@@ -80,6 +81,82 @@ func (commandRegistry *RedisCommandRegistry) handlePexpireCommand(commandArgumen
 	return protocolEncoder.WriteIntegerResponse(0) // Clé n'existe pas
 }
 
+// handlePexpireAtCommand implémente PEXPIREAT key unix-time-milliseconds
+// Une date déjà passée supprime la clé
+func (commandRegistry *RedisCommandRegistry) handlePexpireAtCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
+	if len(commandArguments) != 2 {
+		return protocolEncoder.WriteErrorResponse("ERREUR : nombre d'arguments incorrect pour 'PEXPIREAT' (attendu: PEXPIREAT clé timestamp-ms)")
+	}
+
+	storageKey := commandArguments[0]
+	expirationTimestamp, parseError := strconv.ParseInt(commandArguments[1], 10, 64)
+	if parseError != nil {
+		return protocolEncoder.WriteErrorResponse("ERREUR : le timestamp doit être un nombre entier")
+	}
+
+	success := redisStorage.SetKeyExpirationAt(storageKey, time.UnixMilli(expirationTimestamp))
+	if success {
+		return protocolEncoder.WriteIntegerResponse(1)
+	}
+	return protocolEncoder.WriteIntegerResponse(0) // Clé n'existe pas
+}
+
+// pinRelativeExpiration convertit les TTL relatifs en date d'expiration absolue avant journalisation,
+// réplication ou proposition Raft : SET ... EX et SETEX deviennent SET ... PXAT, EXPIRE et PEXPIRE
+// deviennent PEXPIREAT, RESTORE reçoit ABSTTL. Sans cela, chaque réplica ou relecture du journal
+// repartirait de sa propre horloge et ferait revivre des clés déjà expirées.
+// Les arguments invalides sont laissés tels quels pour que le handler renvoie son erreur habituelle.
+func pinRelativeExpiration(upperCommandName string, commandArguments []string) (string, []string) {
+	switch upperCommandName {
+	case "SET":
+		if len(commandArguments) == 4 && strings.EqualFold(commandArguments[2], "EX") {
+			if expirationSeconds, parseError := strconv.ParseInt(commandArguments[3], 10, 64); parseError == nil && expirationSeconds > 0 {
+				return "SET", []string{commandArguments[0], commandArguments[1], "PXAT", absoluteExpirationMilliseconds(time.Duration(expirationSeconds) * time.Second)}
+			}
+		}
+	case "SETEX":
+		if len(commandArguments) == 3 {
+			if expirationSeconds, parseError := strconv.ParseInt(commandArguments[1], 10, 64); parseError == nil && expirationSeconds > 0 {
+				return "SET", []string{commandArguments[0], commandArguments[2], "PXAT", absoluteExpirationMilliseconds(time.Duration(expirationSeconds) * time.Second)}
+			}
+		}
+	case "EXPIRE", "PEXPIRE":
+		if len(commandArguments) == 2 {
+			if expirationValue, parseError := strconv.ParseInt(commandArguments[1], 10, 64); parseError == nil && expirationValue > 0 {
+				timeUnit := time.Millisecond
+				if upperCommandName == "EXPIRE" {
+					timeUnit = time.Second
+				}
+				return "PEXPIREAT", []string{commandArguments[0], absoluteExpirationMilliseconds(time.Duration(expirationValue) * timeUnit)}
+			}
+		}
+	case "RESTORE":
+		if len(commandArguments) >= 3 && !containsArgument(commandArguments[3:], "ABSTTL") {
+			if timeToLiveMilliseconds, parseError := strconv.ParseInt(commandArguments[1], 10, 64); parseError == nil && timeToLiveMilliseconds > 0 {
+				pinnedArguments := append([]string{}, commandArguments...)
+				pinnedArguments[1] = absoluteExpirationMilliseconds(time.Duration(timeToLiveMilliseconds) * time.Millisecond)
+				return "RESTORE", append(pinnedArguments, "ABSTTL")
+			}
+		}
+	}
+	return upperCommandName, commandArguments
+}
+
+// absoluteExpirationMilliseconds retourne la date (timestamp Unix en ms) atteinte après timeToLive
+func absoluteExpirationMilliseconds(timeToLive time.Duration) string {
+	return strconv.FormatInt(time.Now().Add(timeToLive).UnixMilli(), 10)
+}
+
+// containsArgument indique si une option (insensible à la casse) figure parmi les arguments
+func containsArgument(commandArguments []string, optionName string) bool {
+	for _, commandArgument := range commandArguments {
+		if strings.EqualFold(commandArgument, optionName) {
+			return true
+		}
+	}
+	return false
+}
+
 // handlePersistCommand implémente PERSIST key (supprime le TTL)
 func (commandRegistry *RedisCommandRegistry) handlePersistCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
 	if len(commandArguments) != 1 {
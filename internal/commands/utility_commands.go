@@ -48,7 +48,7 @@ func (commandRegistry *RedisCommandRegistry) handleFlushAllCommand(commandArgume
 func (commandRegistry *RedisCommandRegistry) handleHelpCommand(commandArguments []string, redisStorage *storage.RedisInMemoryStorage, protocolEncoder *protocol.RedisSerializationProtocolEncoder) error {
 	if len(commandArguments) == 0 {
 		// Liste toutes les commandes séparées par des virgules
-		return protocolEncoder.WriteSimpleStringResponse("ALAIDE Redis-Go: SET, GET, DEL, EXISTS, TYPE, INCR, DECR, INCRBY, DECRBY, APPEND, STRLEN, GETRANGE, SETRANGE, MSET, MGET, GETSET, MSETNX, GETDEL, TTL, PTTL, EXPIRE, PEXPIRE, PERSIST, LPUSH, RPUSH, LPOP, RPOP, LLEN, LRANGE, LSET, LREM, LINSERT, LTRIM, SADD, SMEMBERS, SISMEMBER, SREM, SCARD, SDIFF, SINTER, SUNION, HSET, HGET, HGETALL, HEXISTS, HDEL, HLEN, HKEYS, HVALS, HINCRBY, HINCRBYFLOAT, SAVE, BGSAVE, LASTSAVE, INFO, PING, ECHO, KEYS, DBSIZE, FLUSHALL - Tapez ALAIDE <commande> pour details")
+		return protocolEncoder.WriteSimpleStringResponse("ALAIDE Redis-Go: SET, GET, DEL, EXISTS, TYPE, RENAME, RENAMENX, DUMP, RESTORE, MIGRATE, INCR, DECR, INCRBY, DECRBY, APPEND, STRLEN, GETRANGE, SETRANGE, MSET, MGET, GETSET, MSETNX, GETDEL, SETBIT, GETBIT, BITCOUNT, BITPOS, BITOP, BITFIELD, BITFIELD_RO, PFADD, PFCOUNT, PFMERGE, ZADD, ZREM, ZSCORE, ZCARD, GEOADD, GEOPOS, GEODIST, GEOHASH, GEOSEARCH, GEOSEARCHSTORE, JSON.SET, JSON.GET, JSON.MGET, JSON.DEL, JSON.NUMINCRBY, JSON.ARRAPPEND, JSON.ARRLEN, JSON.ARRPOP, JSON.OBJKEYS, JSON.STRAPPEND, JSON.TYPE, FT.CREATE, FT.SEARCH, FT.DROPINDEX, FT.INFO, FT._LIST, TTL, PTTL, EXPIRE, PEXPIRE, PEXPIREAT, PERSIST, LPUSH, RPUSH, LPOP, RPOP, LLEN, LRANGE, LSET, LREM, LINSERT, LTRIM, SADD, SMEMBERS, SISMEMBER, SREM, SCARD, SDIFF, SINTER, SUNION, HSET, HGET, HGETALL, HEXISTS, HDEL, HLEN, HKEYS, HVALS, HINCRBY, HINCRBYFLOAT, XADD, XRANGE, XREVRANGE, XLEN, XDEL, XTRIM, XREAD, SAVE, BGSAVE, LASTSAVE, DEBUG, SNAPSHOT, REPLICAOF, ROLE, CLUSTER, ASKING, WAITDURABLE, INFO, CONFIG, CLIENT, AUTH, HELLO, QUIT, ACL, PING, ECHO, KEYS, DBSIZE, FLUSHALL - Tapez ALAIDE <commande> pour details")
 	}
 
 	// Aide détaillée pour une commande spécifique
@@ -56,7 +56,7 @@ func (commandRegistry *RedisCommandRegistry) handleHelpCommand(commandArguments
 
 	switch requestedCommand {
 	case "SET":
-		return protocolEncoder.WriteSimpleStringResponse("SET key value [EX seconds] - Stocke une valeur avec TTL optionnel en secondes")
+		return protocolEncoder.WriteSimpleStringResponse("SET key value [EX seconds | PXAT timestamp-ms] - Stocke une valeur avec TTL optionnel (secondes, ou date absolue en ms)")
 	case "GET":
 		return protocolEncoder.WriteSimpleStringResponse("GET key - Recupere une valeur. Retourne (nil) si la cle n'existe pas")
 	case "SETNX":
@@ -69,6 +69,16 @@ func (commandRegistry *RedisCommandRegistry) handleHelpCommand(commandArguments
 		return protocolEncoder.WriteSimpleStringResponse("EXISTS key [key ...] - Verifie l'existence de cles")
 	case "TYPE":
 		return protocolEncoder.WriteSimpleStringResponse("TYPE key - Retourne le type de donnees (string, list, set, hash, none)")
+	case "RENAME":
+		return protocolEncoder.WriteSimpleStringResponse("RENAME key newkey - Renomme une cle (valeur et TTL conserves), ecrase newkey si elle existe")
+	case "RENAMENX":
+		return protocolEncoder.WriteSimpleStringResponse("RENAMENX key newkey - Renomme seulement si newkey n'existe pas. Retourne 1 si renommee, 0 sinon")
+	case "DUMP":
+		return protocolEncoder.WriteSimpleStringResponse("DUMP key - Valeur serialisee (versionnee, avec checksum) a recreer avec RESTORE. Retourne (nil) si la cle n'existe pas")
+	case "RESTORE", "RESTORE-ASKING":
+		return protocolEncoder.WriteSimpleStringResponse("RESTORE key ttl payload [REPLACE] [ABSTTL] - Recree une cle depuis DUMP (ttl en ms, 0 = aucun ; ABSTTL = timestamp Unix en ms)")
+	case "MIGRATE":
+		return protocolEncoder.WriteSimpleStringResponse("MIGRATE host port key|\"\" 0 timeout [COPY] [REPLACE] [AUTH pw] [AUTH2 user pw] [KEYS key ...] - Deplace des cles vers une autre instance (ex: MIGRATE 10.0.0.2 6379 \"\" 0 5000 KEYS a b)")
 	case "INCR":
 		return protocolEncoder.WriteSimpleStringResponse("INCR key - Incremente un compteur de 1")
 	case "DECR":
@@ -95,6 +105,76 @@ func (commandRegistry *RedisCommandRegistry) handleHelpCommand(commandArguments
 		return protocolEncoder.WriteSimpleStringResponse("MSETNX key value [key value ...] - Multi-set si AUCUNE des cles n'existe")
 	case "GETDEL":
 		return protocolEncoder.WriteSimpleStringResponse("GETDEL key - Atomique: recupere la valeur puis supprime la cle")
+	case "SETBIT":
+		return protocolEncoder.WriteSimpleStringResponse("SETBIT key offset 0|1 - Ecrit un bit (etend la chaine avec des zeros) et retourne l'ancien")
+	case "GETBIT":
+		return protocolEncoder.WriteSimpleStringResponse("GETBIT key offset - Lit un bit (0 au-dela de la fin)")
+	case "BITCOUNT":
+		return protocolEncoder.WriteSimpleStringResponse("BITCOUNT key [debut fin [BYTE|BIT]] - Compte les bits a 1")
+	case "BITPOS":
+		return protocolEncoder.WriteSimpleStringResponse("BITPOS key 0|1 [debut [fin [BYTE|BIT]]] - Position du premier bit a 0 ou 1")
+	case "BITOP":
+		return protocolEncoder.WriteSimpleStringResponse("BITOP AND|OR|XOR|NOT destination key [key ...] - Operation bit a bit entre chaines")
+	case "BITFIELD", "BITFIELD_RO":
+		return protocolEncoder.WriteSimpleStringResponse("BITFIELD key [GET type offset] [SET type offset valeur] [INCRBY type offset increment] [OVERFLOW WRAP|SAT|FAIL] - Entiers i1-i64/u1-u63, atomique")
+	case "PFADD":
+		return protocolEncoder.WriteSimpleStringResponse("PFADD key [element ...] - Ajoute des elements a une HyperLogLog (1 si l'estimation change)")
+	case "PFCOUNT":
+		return protocolEncoder.WriteSimpleStringResponse("PFCOUNT key [key ...] - Nombre approximatif d'elements distincts (erreur ~0,81%), union si plusieurs cles")
+	case "PFMERGE":
+		return protocolEncoder.WriteSimpleStringResponse("PFMERGE destination [source ...] - Union de HyperLogLog dans la destination")
+	case "ZADD":
+		return protocolEncoder.WriteSimpleStringResponse("ZADD key [NX|XX] [CH] score member [score member ...] - Ajoute des membres ou met a jour leur score")
+	case "ZREM":
+		return protocolEncoder.WriteSimpleStringResponse("ZREM key member [member ...] - Retire des membres d'un sorted set (ou d'un index geographique)")
+	case "ZSCORE":
+		return protocolEncoder.WriteSimpleStringResponse("ZSCORE key member - Score d'un membre (nil si absent)")
+	case "ZCARD":
+		return protocolEncoder.WriteSimpleStringResponse("ZCARD key - Nombre de membres d'un sorted set")
+	case "GEOADD":
+		return protocolEncoder.WriteSimpleStringResponse("GEOADD key [NX|XX] [CH] longitude latitude member [...] - Indexe des positions (score = geohash 52 bits)")
+	case "GEOPOS":
+		return protocolEncoder.WriteSimpleStringResponse("GEOPOS key [member ...] - Longitude et latitude de chaque membre (nil si absent)")
+	case "GEODIST":
+		return protocolEncoder.WriteSimpleStringResponse("GEODIST key member1 member2 [M|KM|FT|MI] - Distance entre deux membres (nil si l'un est absent)")
+	case "GEOHASH":
+		return protocolEncoder.WriteSimpleStringResponse("GEOHASH key [member ...] - Geohash texte de 11 caracteres de chaque membre")
+	case "GEOSEARCH":
+		return protocolEncoder.WriteSimpleStringResponse("GEOSEARCH key FROMMEMBER member|FROMLONLAT lon lat BYRADIUS rayon unite|BYBOX largeur hauteur unite [ASC|DESC] [COUNT n [ANY]] [WITHCOORD] [WITHDIST] [WITHHASH] - Membres dans une zone")
+	case "GEOSEARCHSTORE":
+		return protocolEncoder.WriteSimpleStringResponse("GEOSEARCHSTORE destination source <recherche GEOSEARCH> [STOREDIST] - Range les membres trouves (geohash ou distance) dans destination")
+	case "JSON.SET":
+		return protocolEncoder.WriteSimpleStringResponse("JSON.SET key chemin valeur [NX|XX] - Cree le document ($ ou .) ou remplace / ajoute la valeur au chemin")
+	case "JSON.GET":
+		return protocolEncoder.WriteSimpleStringResponse("JSON.GET key [INDENT i] [NEWLINE n] [SPACE s] [chemin ...] - Valeurs au chemin ($.a.b[0]) serialisees en JSON")
+	case "JSON.MGET":
+		return protocolEncoder.WriteSimpleStringResponse("JSON.MGET key [key ...] chemin - Valeurs au chemin dans plusieurs documents (nil si absent)")
+	case "JSON.DEL", "JSON.FORGET":
+		return protocolEncoder.WriteSimpleStringResponse("JSON.DEL key [chemin] - Supprime les valeurs au chemin (la racine supprime la cle)")
+	case "JSON.NUMINCRBY":
+		return protocolEncoder.WriteSimpleStringResponse("JSON.NUMINCRBY key chemin nombre - Incremente les nombres au chemin")
+	case "JSON.ARRAPPEND":
+		return protocolEncoder.WriteSimpleStringResponse("JSON.ARRAPPEND key chemin valeur [valeur ...] - Ajoute des valeurs JSON a la fin des tableaux")
+	case "JSON.ARRLEN":
+		return protocolEncoder.WriteSimpleStringResponse("JSON.ARRLEN key [chemin] - Longueur des tableaux au chemin")
+	case "JSON.ARRPOP":
+		return protocolEncoder.WriteSimpleStringResponse("JSON.ARRPOP key [chemin [index]] - Retire et retourne un element (dernier par defaut)")
+	case "JSON.OBJKEYS":
+		return protocolEncoder.WriteSimpleStringResponse("JSON.OBJKEYS key [chemin] - Noms des membres des objets au chemin")
+	case "JSON.STRAPPEND":
+		return protocolEncoder.WriteSimpleStringResponse("JSON.STRAPPEND key [chemin] chaine_json - Ajoute a la fin des chaines (valeur entre guillemets)")
+	case "JSON.TYPE":
+		return protocolEncoder.WriteSimpleStringResponse("JSON.TYPE key [chemin] - Type des valeurs au chemin (object, array, string, integer, number, boolean, null)")
+	case "FT.CREATE":
+		return protocolEncoder.WriteSimpleStringResponse("FT.CREATE index [ON HASH] [PREFIX n prefixe ...] SCHEMA champ TEXT|TAG [SEPARATOR c]|NUMERIC [SORTABLE] ... - Cree un index sur les hashes")
+	case "FT.SEARCH":
+		return protocolEncoder.WriteSimpleStringResponse("FT.SEARCH index requete [NOCONTENT] [RETURN n champ ...] [SORTBY champ [ASC|DESC]] [LIMIT debut nombre] - Ex: '@statut:{actif} @age:[(30 +inf]'")
+	case "FT.DROPINDEX":
+		return protocolEncoder.WriteSimpleStringResponse("FT.DROPINDEX index [DD] - Supprime l'index (DD : supprime aussi les hashes indexes)")
+	case "FT.INFO":
+		return protocolEncoder.WriteSimpleStringResponse("FT.INFO index - Prefixes, schema et nombre de hashes indexes")
+	case "FT._LIST":
+		return protocolEncoder.WriteSimpleStringResponse("FT._LIST - Liste les index")
 	case "TTL":
 		return protocolEncoder.WriteSimpleStringResponse("TTL key - Retourne le TTL en secondes (-2=inexistante, -1=pas de TTL)")
 	case "PTTL":
@@ -103,6 +183,8 @@ func (commandRegistry *RedisCommandRegistry) handleHelpCommand(commandArguments
 		return protocolEncoder.WriteSimpleStringResponse("EXPIRE key seconds - Definit un TTL en secondes sur une cle existante")
 	case "PEXPIRE":
 		return protocolEncoder.WriteSimpleStringResponse("PEXPIRE key milliseconds - Definit un TTL en millisecondes sur une cle existante")
+	case "PEXPIREAT":
+		return protocolEncoder.WriteSimpleStringResponse("PEXPIREAT key timestamp-ms - Definit une date d'expiration absolue (Unix en ms) ; une date passee supprime la cle")
 	case "PERSIST":
 		return protocolEncoder.WriteSimpleStringResponse("PERSIST key - Supprime le TTL d'une cle (la rend permanente)")
 	case "LPUSH":
@@ -161,6 +243,20 @@ func (commandRegistry *RedisCommandRegistry) handleHelpCommand(commandArguments
 		return protocolEncoder.WriteSimpleStringResponse("HINCRBY key field increment - Incremente un champ entier dans un hash")
 	case "HINCRBYFLOAT":
 		return protocolEncoder.WriteSimpleStringResponse("HINCRBYFLOAT key field increment - Incremente un champ flottant dans un hash")
+	case "XADD":
+		return protocolEncoder.WriteSimpleStringResponse("XADD key [NOMKSTREAM] [MAXLEN|MINID [=|~] seuil [LIMIT n]] id|* field value [field value ...] - Ajoute une entree a un stream")
+	case "XRANGE":
+		return protocolEncoder.WriteSimpleStringResponse("XRANGE key start end [COUNT n] - Entrees d'un stream entre deux identifiants (- et + pour les extremites)")
+	case "XREVRANGE":
+		return protocolEncoder.WriteSimpleStringResponse("XREVRANGE key end start [COUNT n] - Comme XRANGE, du plus recent au plus ancien")
+	case "XLEN":
+		return protocolEncoder.WriteSimpleStringResponse("XLEN key - Nombre d'entrees d'un stream")
+	case "XDEL":
+		return protocolEncoder.WriteSimpleStringResponse("XDEL key id [id ...] - Supprime des entrees d'un stream")
+	case "XTRIM":
+		return protocolEncoder.WriteSimpleStringResponse("XTRIM key MAXLEN|MINID [=|~] seuil [LIMIT n] - Retire les entrees les plus anciennes d'un stream")
+	case "XREAD":
+		return protocolEncoder.WriteSimpleStringResponse("XREAD [COUNT n] [BLOCK ms] STREAMS key [key ...] id [id ...] - Lit les entrees posterieures aux identifiants ($ = nouvelles entrees), en attendant si BLOCK")
 	case "SAVE":
 		return protocolEncoder.WriteSimpleStringResponse("SAVE - Sauvegarde synchrone (bloquante) des donnees sur disque")
 	case "BGSAVE":
@@ -168,7 +264,33 @@ func (commandRegistry *RedisCommandRegistry) handleHelpCommand(commandArguments
 	case "LASTSAVE":
 		return protocolEncoder.WriteSimpleStringResponse("LASTSAVE - Retourne le timestamp Unix de la derniere sauvegarde")
 	case "INFO":
-		return protocolEncoder.WriteSimpleStringResponse("INFO [section] - Informations sur le serveur (sections: server, persistence, memory)")
+		return protocolEncoder.WriteSimpleStringResponse("INFO [section] - Informations sur le serveur (sections: server, persistence, replication, cluster, raft, memory, stats)")
+	case "DEBUG":
+		return protocolEncoder.WriteSimpleStringResponse("DEBUG RELOAD [snapshot] | SNAPSHOTS - Recharge le fichier RDB ou restaure un snapshot conserve (voir rdb-retention)")
+	case "SNAPSHOT":
+		return protocolEncoder.WriteSimpleStringResponse("SNAPSHOT CREATE|ROLLBACK|DROP nom | LIST - Savepoints en memoire (copy-on-write), ROLLBACK restaure toutes les cles et leurs TTL relatifs")
+	case "REPLICAOF", "SLAVEOF":
+		return protocolEncoder.WriteSimpleStringResponse("REPLICAOF hote port | NO ONE - Devient replica d'un maitre (synchronisation complete puis flux des ecritures) ou redevient maitre")
+	case "ROLE":
+		return protocolEncoder.WriteSimpleStringResponse("ROLE - Role de replication : master avec offset et replicas, ou slave avec maitre, etat du lien et offset")
+	case "CLUSTER":
+		return protocolEncoder.WriteSimpleStringResponse("CLUSTER KEYSLOT|COUNTKEYSINSLOT|GETKEYSINSLOT|MYID|INFO|NODES|SLOTS|SHARDS|RELOAD - Mode cluster (ex: CLUSTER KEYSLOT {user:1}:panier, CLUSTER RELOAD apres modification de la topologie)")
+	case "ASKING":
+		return protocolEncoder.WriteSimpleStringResponse("ASKING - Autorise la commande suivante sur un slot en cours d'import (apres une redirection ASK)")
+	case "WAITDURABLE":
+		return protocolEncoder.WriteSimpleStringResponse("WAITDURABLE timeout - Attend que les ecritures de la connexion soient sur disque (1) ou l'expiration du delai en ms (0), voir CLIENT DURABILITY SYNC")
+	case "CONFIG":
+		return protocolEncoder.WriteSimpleStringResponse("CONFIG GET motif | SET parametre valeur [...] | RESETSTAT | REWRITE - Configuration a chaud (ex: CONFIG SET timeout 300, CONFIG GET tls-*)")
+	case "CLIENT":
+		return protocolEncoder.WriteSimpleStringResponse("CLIENT LIST|KILL|SETNAME|GETNAME|ID|INFO|NO-EVICT|REPLY|DURABILITY - Gestion des connexions clients (ex: CLIENT LIST TYPE normal, CLIENT KILL ID 12, CLIENT DURABILITY SYNC)")
+	case "AUTH":
+		return protocolEncoder.WriteSimpleStringResponse("AUTH [utilisateur] mot_de_passe - Authentifie la connexion (requirepass)")
+	case "HELLO":
+		return protocolEncoder.WriteSimpleStringResponse("HELLO [protover [AUTH utilisateur mot_de_passe] [SETNAME nom]] - Handshake de connexion (RESP2 uniquement)")
+	case "QUIT":
+		return protocolEncoder.WriteSimpleStringResponse("QUIT - Ferme la connexion apres avoir repondu OK")
+	case "ACL":
+		return protocolEncoder.WriteSimpleStringResponse("ACL SETUSER|GETUSER|DELUSER|LIST|USERS|WHOAMI|CAT|LOG|LOAD|SAVE - Gestion des utilisateurs (ex: ACL SETUSER analytics on >pass +@read ~analytics:*)")
 	case "PING":
 		return protocolEncoder.WriteSimpleStringResponse("PING [message] - Test de connexion. Retourne PONG ou le message")
 	case "ECHO":
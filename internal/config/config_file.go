@@ -0,0 +1,263 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maximumIncludeDepth limite l'imbrication des directives include (protection contre les cycles)
+const maximumIncludeDepth = 16
+
+// LoadServerConfigurationFromArguments construit la configuration du serveur
+// Ordre de priorité : valeurs par défaut < variables REDIS_* < fichier de configuration < arguments --param valeur
+// Usage : redis-go [/chemin/redis.conf] [--param valeur ...]
+func LoadServerConfigurationFromArguments(commandLineArguments []string) (*ServerConfiguration, error) {
+	configuration := LoadServerConfiguration()
+	parameterRegistry := NewParameterRegistry(configuration)
+
+	// Le chemin passé en argument remplace REDIS_CONFIG_FILE
+	configurationFilePath := configuration.ConfigurationFilePath
+	explicitConfigurationFile := false
+	if len(commandLineArguments) > 0 && !strings.HasPrefix(commandLineArguments[0], "--") {
+		configurationFilePath = commandLineArguments[0]
+		explicitConfigurationFile = true
+		commandLineArguments = commandLineArguments[1:]
+	}
+
+	if configurationFilePath != "" {
+		absoluteFilePath, pathError := filepath.Abs(configurationFilePath)
+		if pathError != nil {
+			return nil, fmt.Errorf("chemin de configuration invalide '%s': %v", configurationFilePath, pathError)
+		}
+		configuration.ConfigurationFilePath = absoluteFilePath
+
+		// Un REDIS_CONFIG_FILE encore absent sera créé par CONFIG REWRITE
+		if _, statError := os.Stat(absoluteFilePath); explicitConfigurationFile || statError == nil {
+			if loadError := parameterRegistry.loadConfigurationFile(absoluteFilePath, 0); loadError != nil {
+				return nil, loadError
+			}
+		}
+	}
+
+	if overrideError := parameterRegistry.applyCommandLineOverrides(commandLineArguments); overrideError != nil {
+		return nil, overrideError
+	}
+	return configuration, nil
+}
+
+// applyCommandLineOverrides applique les arguments --param valeur [valeur ...]
+func (parameterRegistry *ParameterRegistry) applyCommandLineOverrides(commandLineArguments []string) error {
+	for argumentIndex := 0; argumentIndex < len(commandLineArguments); {
+		optionArgument := commandLineArguments[argumentIndex]
+		if !strings.HasPrefix(optionArgument, "--") || len(optionArgument) == 2 {
+			return fmt.Errorf("argument inattendu '%s' (attendu: --param valeur)", optionArgument)
+		}
+
+		// Les valeurs vont jusqu'à la prochaine option (ex: --save 900 1)
+		valueEndIndex := argumentIndex + 1
+		for valueEndIndex < len(commandLineArguments) && !strings.HasPrefix(commandLineArguments[valueEndIndex], "--") {
+			valueEndIndex++
+		}
+
+		parameterName := strings.TrimPrefix(optionArgument, "--")
+		if applyError := parameterRegistry.applyStartupParameter(parameterName, commandLineArguments[argumentIndex+1:valueEndIndex]); applyError != nil {
+			return fmt.Errorf("ligne de commande %s: %v", optionArgument, applyError)
+		}
+		argumentIndex = valueEndIndex
+	}
+	return nil
+}
+
+// loadConfigurationFile lit un fichier au format redis.conf (directive valeur, commentaires #, include)
+func (parameterRegistry *ParameterRegistry) loadConfigurationFile(configurationFilePath string, includeDepth int) error {
+	if includeDepth > maximumIncludeDepth {
+		return fmt.Errorf("%s: trop d'include imbriqués (maximum %d)", configurationFilePath, maximumIncludeDepth)
+	}
+
+	configurationFile, openError := os.Open(configurationFilePath)
+	if openError != nil {
+		return fmt.Errorf("ouverture fichier de configuration: %v", openError)
+	}
+	defer configurationFile.Close()
+
+	lineScanner := bufio.NewScanner(configurationFile)
+	lineNumber := 0
+	for lineScanner.Scan() {
+		lineNumber++
+		trimmedLine := strings.TrimSpace(lineScanner.Text())
+		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
+			continue
+		}
+
+		lineArguments, splitError := splitConfigurationLine(trimmedLine)
+		if splitError != nil {
+			return fmt.Errorf("%s:%d: %v", configurationFilePath, lineNumber, splitError)
+		}
+
+		directiveName := strings.ToLower(lineArguments[0])
+		if directiveName == "include" {
+			if len(lineArguments) != 2 {
+				return fmt.Errorf("%s:%d: include attend exactement un chemin", configurationFilePath, lineNumber)
+			}
+			if includeError := parameterRegistry.loadIncludedFiles(configurationFilePath, lineArguments[1], includeDepth); includeError != nil {
+				return fmt.Errorf("%s:%d: %v", configurationFilePath, lineNumber, includeError)
+			}
+			continue
+		}
+
+		if applyError := parameterRegistry.applyStartupParameter(directiveName, lineArguments[1:]); applyError != nil {
+			return fmt.Errorf("%s:%d: %v", configurationFilePath, lineNumber, applyError)
+		}
+	}
+	if scanError := lineScanner.Err(); scanError != nil {
+		return fmt.Errorf("lecture %s: %v", configurationFilePath, scanError)
+	}
+	return nil
+}
+
+// loadIncludedFiles charge les fichiers d'une directive include (motifs * ? [] acceptés)
+// Un chemin relatif est résolu depuis le dossier du fichier qui l'inclut
+func (parameterRegistry *ParameterRegistry) loadIncludedFiles(includingFilePath string, includePattern string, includeDepth int) error {
+	if !filepath.IsAbs(includePattern) {
+		includePattern = filepath.Join(filepath.Dir(includingFilePath), includePattern)
+	}
+
+	includedFilePaths, globError := filepath.Glob(includePattern)
+	if globError != nil {
+		return fmt.Errorf("motif include invalide '%s': %v", includePattern, globError)
+	}
+	// Sans caractère générique, un fichier absent est une erreur
+	if len(includedFilePaths) == 0 && !strings.ContainsAny(includePattern, "*?[") {
+		return fmt.Errorf("fichier inclus introuvable '%s'", includePattern)
+	}
+
+	for _, includedFilePath := range includedFilePaths {
+		if loadError := parameterRegistry.loadConfigurationFile(includedFilePath, includeDepth+1); loadError != nil {
+			return loadError
+		}
+	}
+	return nil
+}
+
+// applyStartupParameter applique un paramètre lu au démarrage (fichier ou ligne de commande)
+// Les paramètres non modifiables à chaud sont acceptés ici ; plusieurs valeurs sont jointes par des espaces
+// Une directive répétable remplace la valeur par défaut à sa première occurrence puis s'accumule
+func (parameterRegistry *ParameterRegistry) applyStartupParameter(parameterName string, parameterValues []string) error {
+	configurationParameter, parameterExists := parameterRegistry.parameters[strings.ToLower(parameterName)]
+	if !parameterExists {
+		return fmt.Errorf("paramètre inconnu '%s'", parameterName)
+	}
+	if len(parameterValues) == 0 {
+		return fmt.Errorf("valeur manquante pour '%s'", configurationParameter.ParameterName)
+	}
+
+	applyValue := configurationParameter.setValue
+	if configurationParameter.appendValue != nil && parameterRegistry.startupParameters[configurationParameter.ParameterName] {
+		applyValue = configurationParameter.appendValue
+	}
+	if setError := applyValue(parameterRegistry.configuration, strings.Join(parameterValues, " ")); setError != nil {
+		return fmt.Errorf("'%s': %v", configurationParameter.ParameterName, setError)
+	}
+	parameterRegistry.startupParameters[configurationParameter.ParameterName] = true
+	return nil
+}
+
+// splitConfigurationLine découpe une ligne comme redis.conf : espaces, "chaînes \"échappées\"" et 'chaînes brutes'
+func splitConfigurationLine(configurationLine string) ([]string, error) {
+	var lineArguments []string
+	lineRunes := []rune(configurationLine)
+
+	for runeIndex := 0; runeIndex < len(lineRunes); {
+		if lineRunes[runeIndex] == ' ' || lineRunes[runeIndex] == '\t' {
+			runeIndex++
+			continue
+		}
+
+		var currentArgument strings.Builder
+		switch lineRunes[runeIndex] {
+		case '"':
+			runeIndex++
+			for {
+				if runeIndex >= len(lineRunes) {
+					return nil, fmt.Errorf("guillemets non fermés")
+				}
+				if lineRunes[runeIndex] == '"' {
+					runeIndex++
+					break
+				}
+				if lineRunes[runeIndex] == '\\' && runeIndex+1 < len(lineRunes) {
+					escapedValue, consumedRunes, escapeError := decodeEscapeSequence(lineRunes[runeIndex:])
+					if escapeError != nil {
+						return nil, escapeError
+					}
+					currentArgument.WriteString(escapedValue)
+					runeIndex += consumedRunes
+					continue
+				}
+				currentArgument.WriteRune(lineRunes[runeIndex])
+				runeIndex++
+			}
+		case '\'':
+			runeIndex++
+			for {
+				if runeIndex >= len(lineRunes) {
+					return nil, fmt.Errorf("apostrophes non fermées")
+				}
+				if lineRunes[runeIndex] == '\'' {
+					runeIndex++
+					break
+				}
+				if lineRunes[runeIndex] == '\\' && runeIndex+1 < len(lineRunes) && lineRunes[runeIndex+1] == '\'' {
+					runeIndex++
+				}
+				currentArgument.WriteRune(lineRunes[runeIndex])
+				runeIndex++
+			}
+		default:
+			for runeIndex < len(lineRunes) && lineRunes[runeIndex] != ' ' && lineRunes[runeIndex] != '\t' {
+				currentArgument.WriteRune(lineRunes[runeIndex])
+				runeIndex++
+			}
+			lineArguments = append(lineArguments, currentArgument.String())
+			continue
+		}
+
+		// Une chaîne entre guillemets doit être suivie d'un espace ou de la fin de ligne
+		if runeIndex < len(lineRunes) && lineRunes[runeIndex] != ' ' && lineRunes[runeIndex] != '\t' {
+			return nil, fmt.Errorf("une chaîne entre guillemets doit être suivie d'un espace")
+		}
+		lineArguments = append(lineArguments, currentArgument.String())
+	}
+	return lineArguments, nil
+}
+
+// decodeEscapeSequence décode \n \r \t \b \a \\ \" et \xHH ; retourne la valeur et le nombre de runes lues
+func decodeEscapeSequence(escapeRunes []rune) (string, int, error) {
+	switch escapeRunes[1] {
+	case 'n':
+		return "\n", 2, nil
+	case 'r':
+		return "\r", 2, nil
+	case 't':
+		return "\t", 2, nil
+	case 'b':
+		return "\b", 2, nil
+	case 'a':
+		return "\a", 2, nil
+	case 'x':
+		if len(escapeRunes) < 4 {
+			return "", 0, fmt.Errorf("séquence \\x incomplète")
+		}
+		byteValue, parseError := strconv.ParseUint(string(escapeRunes[2:4]), 16, 8)
+		if parseError != nil {
+			return "", 0, fmt.Errorf("séquence \\x%s invalide", string(escapeRunes[2:4]))
+		}
+		return string([]byte{byte(byteValue)}), 4, nil
+	default:
+		return string(escapeRunes[1]), 2, nil
+	}
+}
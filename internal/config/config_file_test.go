@@ -0,0 +1,168 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigurationFiles écrit des fichiers de configuration dans un dossier temporaire et retourne ce dossier
+func writeConfigurationFiles(t *testing.T, fileContents map[string]string) string {
+	t.Helper()
+	configurationDirectory := t.TempDir()
+	for fileName, fileContent := range fileContents {
+		filePath := filepath.Join(configurationDirectory, fileName)
+		if mkdirError := os.MkdirAll(filepath.Dir(filePath), 0755); mkdirError != nil {
+			t.Fatalf("création dossier: %v", mkdirError)
+		}
+		if writeError := os.WriteFile(filePath, []byte(fileContent), 0644); writeError != nil {
+			t.Fatalf("écriture %s: %v", fileName, writeError)
+		}
+	}
+	return configurationDirectory
+}
+
+func TestSplitConfigurationLine(t *testing.T) {
+	testCases := []struct {
+		configurationLine string
+		expectedArguments []string
+		expectedError     string
+	}{
+		{"port 6380", []string{"port", "6380"}, ""},
+		{"save   900 1\t300 10", []string{"save", "900", "1", "300", "10"}, ""},
+		{`requirepass "mot de passe"`, []string{"requirepass", "mot de passe"}, ""},
+		{`masterauth "a\"b\\c\n\x41"`, []string{"masterauth", "a\"b\\c\nA"}, ""},
+		{`save ""`, []string{"save", ""}, ""},
+		{`requirepass 'brut \n'`, []string{"requirepass", `brut \n`}, ""},
+		{`requirepass 'l\'apostrophe'`, []string{"requirepass", "l'apostrophe"}, ""},
+		{`requirepass "ouvert`, nil, "guillemets non fermés"},
+		{`requirepass 'ouvert`, nil, "apostrophes non fermées"},
+		{`requirepass "collé"suite`, nil, "suivie d'un espace"},
+		{`requirepass "\x4"`, nil, "invalide"},
+	}
+
+	for _, testCase := range testCases {
+		lineArguments, splitError := splitConfigurationLine(testCase.configurationLine)
+		if testCase.expectedError != "" {
+			if splitError == nil || !strings.Contains(splitError.Error(), testCase.expectedError) {
+				t.Errorf("%s: %v, attendu une erreur contenant %q", testCase.configurationLine, splitError, testCase.expectedError)
+			}
+			continue
+		}
+		if splitError != nil || !reflect.DeepEqual(lineArguments, testCase.expectedArguments) {
+			t.Errorf("%s: %q (%v), attendu %q", testCase.configurationLine, lineArguments, splitError, testCase.expectedArguments)
+		}
+	}
+}
+
+func TestConfigurationFileWithIncludesAndOverrides(t *testing.T) {
+	t.Setenv("REDIS_CONFIG_FILE", "")
+	configurationDirectory := writeConfigurationFiles(t, map[string]string{
+		"redis.conf": "# Fichier principal\nport 6390\ntimeout 10\ninclude conf.d/*.conf\nmaxclients 200\nsave 900 1\nsave 300 10\n",
+		// Les fichiers d'un motif sont inclus dans l'ordre alphabétique : 20 remplace 10
+		"conf.d/10-reseau.conf":  "timeout 20\nbind 0.0.0.0\n",
+		"conf.d/20-reseau.conf":  "timeout 30\n",
+		"conf.d/ignore.conf.bak": "port 1\n",
+	})
+
+	configuration, loadError := LoadServerConfigurationFromArguments([]string{
+		filepath.Join(configurationDirectory, "redis.conf"),
+		"--maxclients", "300",
+		"--save", "60", "5",
+	})
+	if loadError != nil {
+		t.Fatalf("LoadServerConfigurationFromArguments: %v", loadError)
+	}
+
+	if configuration.NetworkConfiguration.PortNumber != 6390 || configuration.NetworkConfiguration.HostAddress != "0.0.0.0" {
+		t.Errorf("port %d, bind %q, attendu 6390 et 0.0.0.0", configuration.NetworkConfiguration.PortNumber, configuration.NetworkConfiguration.HostAddress)
+	}
+	if configuration.NetworkConfiguration.ClientIdleTimeout != 30*time.Second {
+		t.Errorf("timeout %v, attendu 30s (dernier fichier inclus)", configuration.NetworkConfiguration.ClientIdleTimeout)
+	}
+	if configuration.PerformanceConfiguration.MaximumConnections != 300 {
+		t.Errorf("maxclients %d, attendu 300 (ligne de commande prioritaire)", configuration.PerformanceConfiguration.MaximumConnections)
+	}
+	// Les lignes save du fichier s'accumulent ; la ligne de commande, appliquée ensuite, s'y ajoute aussi
+	if savedRules := FormatRDBSaveRules(configuration.PersistenceConfiguration.RDBSaveRules); savedRules != "900 1 300 10 60 5" {
+		t.Errorf("save %q, attendu \"900 1 300 10 60 5\"", savedRules)
+	}
+	if expectedPath := filepath.Join(configurationDirectory, "redis.conf"); configuration.ConfigurationFilePath != expectedPath {
+		t.Errorf("chemin de configuration %q, attendu %q", configuration.ConfigurationFilePath, expectedPath)
+	}
+}
+
+func TestConfigurationFileSaveEmptyClearsRules(t *testing.T) {
+	t.Setenv("REDIS_CONFIG_FILE", "")
+	configurationDirectory := writeConfigurationFiles(t, map[string]string{
+		"redis.conf": "save 900 1\nsave \"\"\n",
+	})
+	configuration, loadError := LoadServerConfigurationFromArguments([]string{filepath.Join(configurationDirectory, "redis.conf")})
+	if loadError != nil {
+		t.Fatalf("LoadServerConfigurationFromArguments: %v", loadError)
+	}
+	if len(configuration.PersistenceConfiguration.RDBSaveRules) != 0 {
+		t.Fatalf("save \"\" doit effacer les règles, obtenu %v", configuration.PersistenceConfiguration.RDBSaveRules)
+	}
+}
+
+func TestConfigurationFileErrors(t *testing.T) {
+	testCases := []struct {
+		name           string
+		fileContents   map[string]string
+		extraArguments []string
+		expectedError  string
+	}{
+		{"paramètre inconnu", map[string]string{"redis.conf": "port 6390\ninconnu 1\n"}, nil, "redis.conf:2: paramètre inconnu 'inconnu'"},
+		{"valeur manquante", map[string]string{"redis.conf": "port\n"}, nil, "redis.conf:1: valeur manquante"},
+		{"valeur invalide", map[string]string{"redis.conf": "port abc\n"}, nil, "redis.conf:1: 'port'"},
+		{"include introuvable", map[string]string{"redis.conf": "include absent.conf\n"}, nil, "fichier inclus introuvable"},
+		{"motif include sans fichier", map[string]string{"redis.conf": "include absent/*.conf\nport 6390\n"}, nil, ""},
+		{"erreur dans un fichier inclus", map[string]string{"redis.conf": "include autre.conf\n", "autre.conf": "\n\ntimeout -5\n"}, nil, "autre.conf:3:"},
+		{"include cyclique", map[string]string{"redis.conf": "include redis.conf\n"}, nil, "trop d'include imbriqués"},
+		{"argument sans --", map[string]string{"redis.conf": ""}, []string{"port", "6390"}, "argument inattendu 'port'"},
+		{"option inconnue", map[string]string{"redis.conf": ""}, []string{"--inconnu", "1"}, "ligne de commande --inconnu"},
+		{"option sans valeur", map[string]string{"redis.conf": ""}, []string{"--port"}, "valeur manquante"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Setenv("REDIS_CONFIG_FILE", "")
+			configurationDirectory := writeConfigurationFiles(t, testCase.fileContents)
+			commandLineArguments := append([]string{filepath.Join(configurationDirectory, "redis.conf")}, testCase.extraArguments...)
+
+			_, loadError := LoadServerConfigurationFromArguments(commandLineArguments)
+			if testCase.expectedError == "" {
+				if loadError != nil {
+					t.Fatalf("LoadServerConfigurationFromArguments: %v", loadError)
+				}
+				return
+			}
+			if loadError == nil || !strings.Contains(loadError.Error(), testCase.expectedError) {
+				t.Fatalf("LoadServerConfigurationFromArguments: %v, attendu une erreur contenant %q", loadError, testCase.expectedError)
+			}
+		})
+	}
+}
+
+func TestMissingEnvironmentConfigurationFileIsAccepted(t *testing.T) {
+	missingFilePath := filepath.Join(t.TempDir(), "absent.conf")
+	t.Setenv("REDIS_CONFIG_FILE", missingFilePath)
+
+	// Un fichier désigné par REDIS_CONFIG_FILE peut ne pas encore exister : CONFIG REWRITE le créera
+	configuration, loadError := LoadServerConfigurationFromArguments([]string{"--port", "6391"})
+	if loadError != nil {
+		t.Fatalf("LoadServerConfigurationFromArguments: %v", loadError)
+	}
+	if configuration.ConfigurationFilePath != missingFilePath || configuration.NetworkConfiguration.PortNumber != 6391 {
+		t.Fatalf("chemin %q port %d", configuration.ConfigurationFilePath, configuration.NetworkConfiguration.PortNumber)
+	}
+
+	// Passé explicitement en argument, un fichier absent est une erreur
+	if _, explicitError := LoadServerConfigurationFromArguments([]string{missingFilePath}); explicitError == nil {
+		t.Fatalf("un fichier de configuration explicite absent doit être refusé")
+	}
+}
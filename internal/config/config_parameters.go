@@ -0,0 +1,310 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigurationParameter décrit un paramètre accessible par CONFIG GET / CONFIG SET
+type ConfigurationParameter struct {
+	ParameterName string
+	Mutable       bool // Modifiable à chaud par CONFIG SET
+	getValue      func(configuration *ServerConfiguration) string
+	setValue      func(configuration *ServerConfiguration, rawValue string) error
+	appendValue   func(configuration *ServerConfiguration, rawValue string) error // Directive répétable (ex: save)
+}
+
+// newIntegerParameter crée un paramètre entier borné
+func newIntegerParameter(parameterName string, mutable bool, minimumValue int, maximumValue int, field func(*ServerConfiguration) *int) *ConfigurationParameter {
+	return &ConfigurationParameter{
+		ParameterName: parameterName,
+		Mutable:       mutable,
+		getValue: func(configuration *ServerConfiguration) string {
+			return strconv.Itoa(*field(configuration))
+		},
+		setValue: func(configuration *ServerConfiguration, rawValue string) error {
+			integerValue, parseError := strconv.Atoi(rawValue)
+			if parseError != nil {
+				return fmt.Errorf("'%s' n'est pas un entier", rawValue)
+			}
+			if integerValue < minimumValue || integerValue > maximumValue {
+				return fmt.Errorf("valeur %d hors limites [%d, %d]", integerValue, minimumValue, maximumValue)
+			}
+			*field(configuration) = integerValue
+			return nil
+		},
+	}
+}
+
+// newSecondsParameter crée un paramètre de durée exprimé en secondes entières
+func newSecondsParameter(parameterName string, mutable bool, minimumSeconds int, field func(*ServerConfiguration) *time.Duration) *ConfigurationParameter {
+	return &ConfigurationParameter{
+		ParameterName: parameterName,
+		Mutable:       mutable,
+		getValue: func(configuration *ServerConfiguration) string {
+			return strconv.FormatInt(int64(*field(configuration)/time.Second), 10)
+		},
+		setValue: func(configuration *ServerConfiguration, rawValue string) error {
+			secondsValue, parseError := strconv.Atoi(rawValue)
+			if parseError != nil {
+				return fmt.Errorf("'%s' n'est pas un nombre de secondes", rawValue)
+			}
+			if secondsValue < minimumSeconds {
+				return fmt.Errorf("valeur %d inférieure au minimum %d", secondsValue, minimumSeconds)
+			}
+			*field(configuration) = time.Duration(secondsValue) * time.Second
+			return nil
+		},
+	}
+}
+
+// newBooleanParameter crée un paramètre yes/no
+func newBooleanParameter(parameterName string, mutable bool, field func(*ServerConfiguration) *bool) *ConfigurationParameter {
+	return &ConfigurationParameter{
+		ParameterName: parameterName,
+		Mutable:       mutable,
+		getValue: func(configuration *ServerConfiguration) string {
+			if *field(configuration) {
+				return "yes"
+			}
+			return "no"
+		},
+		setValue: func(configuration *ServerConfiguration, rawValue string) error {
+			switch strings.ToLower(rawValue) {
+			case "yes":
+				*field(configuration) = true
+			case "no":
+				*field(configuration) = false
+			default:
+				return fmt.Errorf("'%s' invalide, attendu yes ou no", rawValue)
+			}
+			return nil
+		},
+	}
+}
+
+// newStringParameter crée un paramètre texte, éventuellement restreint à une liste de valeurs
+func newStringParameter(parameterName string, mutable bool, field func(*ServerConfiguration) *string, allowedValues ...string) *ConfigurationParameter {
+	return &ConfigurationParameter{
+		ParameterName: parameterName,
+		Mutable:       mutable,
+		getValue: func(configuration *ServerConfiguration) string {
+			return *field(configuration)
+		},
+		setValue: func(configuration *ServerConfiguration, rawValue string) error {
+			if len(allowedValues) > 0 {
+				valueAllowed := false
+				for _, allowedValue := range allowedValues {
+					if strings.EqualFold(rawValue, allowedValue) {
+						rawValue = allowedValue
+						valueAllowed = true
+						break
+					}
+				}
+				if !valueAllowed {
+					return fmt.Errorf("'%s' invalide, valeurs possibles: %s", rawValue, strings.Join(allowedValues, ", "))
+				}
+			}
+			*field(configuration) = rawValue
+			return nil
+		},
+	}
+}
+
+// newFileModeParameter crée un paramètre de permissions en octal
+func newFileModeParameter(parameterName string, mutable bool, field func(*ServerConfiguration) *os.FileMode) *ConfigurationParameter {
+	return &ConfigurationParameter{
+		ParameterName: parameterName,
+		Mutable:       mutable,
+		getValue: func(configuration *ServerConfiguration) string {
+			return strconv.FormatUint(uint64(*field(configuration)), 8)
+		},
+		setValue: func(configuration *ServerConfiguration, rawValue string) error {
+			modeValue, parseError := strconv.ParseUint(rawValue, 8, 32)
+			if parseError != nil || modeValue > 0777 {
+				return fmt.Errorf("'%s' n'est pas une permission octale valide", rawValue)
+			}
+			*field(configuration) = os.FileMode(modeValue)
+			return nil
+		},
+	}
+}
+
+// newListParameter crée un paramètre liste (valeurs séparées par des virgules)
+func newListParameter(parameterName string, mutable bool, field func(*ServerConfiguration) *[]string) *ConfigurationParameter {
+	return &ConfigurationParameter{
+		ParameterName: parameterName,
+		Mutable:       mutable,
+		getValue: func(configuration *ServerConfiguration) string {
+			return strings.Join(*field(configuration), ",")
+		},
+		setValue: func(configuration *ServerConfiguration, rawValue string) error {
+			*field(configuration) = splitListValue(rawValue)
+			return nil
+		},
+	}
+}
+
+// newSaveRulesParameter crée le paramètre "save" (paires secondes / changements, "" = désactivé)
+func newSaveRulesParameter(parameterName string, mutable bool, field func(*ServerConfiguration) *[]RDBSaveRule) *ConfigurationParameter {
+	return &ConfigurationParameter{
+		ParameterName: parameterName,
+		Mutable:       mutable,
+		getValue: func(configuration *ServerConfiguration) string {
+			return FormatRDBSaveRules(*field(configuration))
+		},
+		setValue: func(configuration *ServerConfiguration, rawValue string) error {
+			saveRules, parseError := ParseRDBSaveRules(rawValue)
+			if parseError != nil {
+				return parseError
+			}
+			*field(configuration) = saveRules
+			return nil
+		},
+		// Dans un fichier, chaque ligne "save" ajoute une règle et save "" les efface toutes
+		appendValue: func(configuration *ServerConfiguration, rawValue string) error {
+			saveRules, parseError := ParseRDBSaveRules(rawValue)
+			if parseError != nil {
+				return parseError
+			}
+			if len(saveRules) == 0 {
+				*field(configuration) = nil
+				return nil
+			}
+			*field(configuration) = append(*field(configuration), saveRules...)
+			return nil
+		},
+	}
+}
+
+// newReplicaOfParameter crée le paramètre "replicaof hôte port" ("no one" ou "" = serveur maître)
+func newReplicaOfParameter(parameterName string, mutable bool, hostField func(*ServerConfiguration) *string, portField func(*ServerConfiguration) *int) *ConfigurationParameter {
+	return &ConfigurationParameter{
+		ParameterName: parameterName,
+		Mutable:       mutable,
+		getValue: func(configuration *ServerConfiguration) string {
+			if *hostField(configuration) == "" {
+				return ""
+			}
+			return fmt.Sprintf("%s %d", *hostField(configuration), *portField(configuration))
+		},
+		setValue: func(configuration *ServerConfiguration, rawValue string) error {
+			replicaOfHost, replicaOfPort, parseError := ParseReplicaOf(rawValue)
+			if parseError != nil {
+				return parseError
+			}
+			*hostField(configuration) = replicaOfHost
+			*portField(configuration) = replicaOfPort
+			return nil
+		},
+	}
+}
+
+// ParseReplicaOf analyse une valeur "hôte port" ; "no one" et "" désignent un serveur maître (hôte vide)
+func ParseReplicaOf(rawValue string) (string, int, error) {
+	valueFields := strings.Fields(rawValue)
+	if len(valueFields) == 0 || (len(valueFields) == 2 && strings.EqualFold(valueFields[0], "no") && strings.EqualFold(valueFields[1], "one")) {
+		return "", 0, nil
+	}
+	if len(valueFields) != 2 {
+		return "", 0, fmt.Errorf("'%s' invalide, attendu: hôte port ou no one", rawValue)
+	}
+	portNumber, parseError := strconv.Atoi(valueFields[1])
+	if parseError != nil || portNumber < 1 || portNumber > 65535 {
+		return "", 0, fmt.Errorf("port '%s' invalide", valueFields[1])
+	}
+	return valueFields[0], portNumber, nil
+}
+
+// splitListValue découpe une liste séparée par des virgules en ignorant les éléments vides
+func splitListValue(rawValue string) []string {
+	var listValues []string
+	for _, listValue := range strings.Split(rawValue, ",") {
+		if trimmedValue := strings.TrimSpace(listValue); trimmedValue != "" {
+			listValues = append(listValues, trimmedValue)
+		}
+	}
+	return listValues
+}
+
+// buildConfigurationParameters retourne la définition de tous les paramètres connus
+func buildConfigurationParameters() []*ConfigurationParameter {
+	return []*ConfigurationParameter{
+		// Réseau (pris en compte au démarrage uniquement)
+		newStringParameter("bind", false, func(c *ServerConfiguration) *string { return &c.NetworkConfiguration.HostAddress }),
+		newIntegerParameter("port", false, 0, 65535, func(c *ServerConfiguration) *int { return &c.NetworkConfiguration.PortNumber }),
+		newStringParameter("unixsocket", false, func(c *ServerConfiguration) *string { return &c.NetworkConfiguration.UnixSocketPath }),
+		newFileModeParameter("unixsocketperm", false, func(c *ServerConfiguration) *os.FileMode { return &c.NetworkConfiguration.UnixSocketPermission }),
+		newIntegerParameter("tls-port", false, 0, 65535, func(c *ServerConfiguration) *int { return &c.NetworkConfiguration.TLSConfiguration.PortNumber }),
+		newStringParameter("tls-cert-file", false, func(c *ServerConfiguration) *string { return &c.NetworkConfiguration.TLSConfiguration.CertificateFile }),
+		newStringParameter("tls-key-file", false, func(c *ServerConfiguration) *string { return &c.NetworkConfiguration.TLSConfiguration.KeyFile }),
+		newStringParameter("tls-ca-cert-file", false, func(c *ServerConfiguration) *string {
+			return &c.NetworkConfiguration.TLSConfiguration.CACertificateFile
+		}),
+		newStringParameter("tls-min-version", false, func(c *ServerConfiguration) *string { return &c.NetworkConfiguration.TLSConfiguration.MinimumVersion }, "1.2", "1.3"),
+		newListParameter("tls-ciphers", false, func(c *ServerConfiguration) *[]string { return &c.NetworkConfiguration.TLSConfiguration.CipherSuites }),
+		newStringParameter("tls-auth-clients", false, func(c *ServerConfiguration) *string {
+			return &c.NetworkConfiguration.TLSConfiguration.ClientAuthentication
+		}, "no", "optional", "yes"),
+
+		// Connexions clients (modifiables à chaud)
+		newSecondsParameter("timeout", true, 0, func(c *ServerConfiguration) *time.Duration { return &c.NetworkConfiguration.ClientIdleTimeout }),
+		newSecondsParameter("tcp-keepalive", true, 0, func(c *ServerConfiguration) *time.Duration { return &c.NetworkConfiguration.TCPKeepAlivePeriod }),
+		newIntegerParameter("maxclients", true, 1, 1000000, func(c *ServerConfiguration) *int { return &c.PerformanceConfiguration.MaximumConnections }),
+
+		// Encodage des valeurs
+		newIntegerParameter("hll-sparse-max-bytes", true, 0, 16000, func(c *ServerConfiguration) *int {
+			return &c.PerformanceConfiguration.HyperLogLogSparseMaximumBytes
+		}),
+
+		// Maintenance
+		newSecondsParameter("expiry-check-interval", true, 1, func(c *ServerConfiguration) *time.Duration {
+			return &c.MaintenanceConfiguration.ExpirationCheckInterval
+		}),
+		newIntegerParameter("max-savepoints", true, 0, 1024, func(c *ServerConfiguration) *int { return &c.MaintenanceConfiguration.MaximumSavepoints }),
+
+		// Persistence RDB
+		newBooleanParameter("rdb-enabled", false, func(c *ServerConfiguration) *bool { return &c.PersistenceConfiguration.RDBEnabled }),
+		newStringParameter("rdb-file", false, func(c *ServerConfiguration) *string { return &c.PersistenceConfiguration.RDBFilePath }),
+		newSaveRulesParameter("save", true, func(c *ServerConfiguration) *[]RDBSaveRule { return &c.PersistenceConfiguration.RDBSaveRules }),
+		newBooleanParameter("rdb-save-on-exit", false, func(c *ServerConfiguration) *bool { return &c.PersistenceConfiguration.RDBSaveOnExit }),
+		newBooleanParameter("rdbcompression", true, func(c *ServerConfiguration) *bool { return &c.PersistenceConfiguration.RDBCompression }),
+		newIntegerParameter("rdb-retention", true, 0, 10000, func(c *ServerConfiguration) *int { return &c.PersistenceConfiguration.RDBRetention }),
+		newBooleanParameter("durable-log", false, func(c *ServerConfiguration) *bool { return &c.PersistenceConfiguration.DurableLog }),
+		newStringParameter("durable-log-file", false, func(c *ServerConfiguration) *string { return &c.PersistenceConfiguration.DurableLogFile }),
+
+		// Sécurité
+		newStringParameter("requirepass", true, func(c *ServerConfiguration) *string { return &c.SecurityConfiguration.RequirePassword }),
+		newStringParameter("aclfile", false, func(c *ServerConfiguration) *string { return &c.SecurityConfiguration.ACLFilePath }),
+
+		// Réplication (replicaof au démarrage uniquement : REPLICAOF change de maître à chaud)
+		newReplicaOfParameter("replicaof", false,
+			func(c *ServerConfiguration) *string { return &c.ReplicationConfiguration.ReplicaOfHost },
+			func(c *ServerConfiguration) *int { return &c.ReplicationConfiguration.ReplicaOfPort }),
+		newStringParameter("masteruser", true, func(c *ServerConfiguration) *string { return &c.ReplicationConfiguration.MasterUser }),
+		newStringParameter("masterauth", true, func(c *ServerConfiguration) *string { return &c.ReplicationConfiguration.MasterPassword }),
+		newBooleanParameter("replica-read-only", true, func(c *ServerConfiguration) *bool { return &c.ReplicationConfiguration.ReplicaReadOnly }),
+		newIntegerParameter("repl-backlog-size", true, 16*1024, 1024*1024*1024, func(c *ServerConfiguration) *int { return &c.ReplicationConfiguration.BacklogSize }),
+		newSecondsParameter("repl-ping-replica-period", true, 1, func(c *ServerConfiguration) *time.Duration {
+			return &c.ReplicationConfiguration.PingReplicaPeriod
+		}),
+		newSecondsParameter("repl-timeout", true, 1, func(c *ServerConfiguration) *time.Duration { return &c.ReplicationConfiguration.ReplicationTimeout }),
+
+		// Cluster (CLUSTER RELOAD relit la topologie sans redémarrer)
+		newBooleanParameter("cluster-enabled", false, func(c *ServerConfiguration) *bool { return &c.ClusterConfiguration.ClusterEnabled }),
+		newStringParameter("cluster-config-file", false, func(c *ServerConfiguration) *string { return &c.ClusterConfiguration.ClusterTopologyFile }),
+		newStringParameter("cluster-node-id", false, func(c *ServerConfiguration) *string { return &c.ClusterConfiguration.ClusterNodeIdentifier }),
+
+		// Consensus Raft (les membres sont fixés au démarrage)
+		newBooleanParameter("raft-enabled", false, func(c *ServerConfiguration) *bool { return &c.RaftConfiguration.RaftEnabled }),
+		newStringParameter("raft-node-id", false, func(c *ServerConfiguration) *string { return &c.RaftConfiguration.RaftNodeIdentifier }),
+		newStringParameter("raft-peers", false, func(c *ServerConfiguration) *string { return &c.RaftConfiguration.RaftPeers }),
+		newStringParameter("raft-dir", false, func(c *ServerConfiguration) *string { return &c.RaftConfiguration.RaftDataDirectory }),
+		newIntegerParameter("raft-election-timeout", false, 50, 60000, func(c *ServerConfiguration) *int { return &c.RaftConfiguration.RaftElectionTimeout }),
+		newIntegerParameter("raft-snapshot-threshold", true, 0, 1000000000, func(c *ServerConfiguration) *int { return &c.RaftConfiguration.RaftSnapshotThreshold }),
+		newBooleanParameter("raft-linearizable-reads", true, func(c *ServerConfiguration) *bool { return &c.RaftConfiguration.RaftLinearizableReads }),
+	}
+}
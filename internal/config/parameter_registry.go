@@ -0,0 +1,205 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ParameterChangeHandler applique une nouvelle valeur aux sous-systèmes en cours d'exécution
+type ParameterChangeHandler func(configuration *ServerConfiguration) error
+
+// ParameterRegistry donne accès aux paramètres typés de la configuration (CONFIG GET / SET / REWRITE)
+type ParameterRegistry struct {
+	configuration      *ServerConfiguration
+	parameters         map[string]*ConfigurationParameter
+	changeHandlers     map[string]ParameterChangeHandler
+	modifiedParameters map[string]bool
+	startupParameters  map[string]bool // Paramètres déjà lus au démarrage (fichier ou ligne de commande)
+	registryMutex      sync.Mutex
+}
+
+// NewParameterRegistry crée le registre des paramètres d'une configuration
+func NewParameterRegistry(configuration *ServerConfiguration) *ParameterRegistry {
+	parameterRegistry := &ParameterRegistry{
+		configuration:      configuration,
+		parameters:         make(map[string]*ConfigurationParameter),
+		changeHandlers:     make(map[string]ParameterChangeHandler),
+		modifiedParameters: make(map[string]bool),
+		startupParameters:  make(map[string]bool),
+	}
+	for _, configurationParameter := range buildConfigurationParameters() {
+		parameterRegistry.parameters[configurationParameter.ParameterName] = configurationParameter
+	}
+	return parameterRegistry
+}
+
+// OnParameterChange enregistre la fonction appelée après chaque CONFIG SET du paramètre
+func (parameterRegistry *ParameterRegistry) OnParameterChange(parameterName string, changeHandler ParameterChangeHandler) {
+	parameterRegistry.registryMutex.Lock()
+	defer parameterRegistry.registryMutex.Unlock()
+	parameterRegistry.changeHandlers[parameterName] = changeHandler
+}
+
+// ListParameterNames retourne le nom de tous les paramètres, triés
+func (parameterRegistry *ParameterRegistry) ListParameterNames() []string {
+	parameterNames := make([]string, 0, len(parameterRegistry.parameters))
+	for parameterName := range parameterRegistry.parameters {
+		parameterNames = append(parameterNames, parameterName)
+	}
+	sort.Strings(parameterNames)
+	return parameterNames
+}
+
+// GetParameterValue retourne la valeur courante d'un paramètre
+func (parameterRegistry *ParameterRegistry) GetParameterValue(parameterName string) (string, bool) {
+	parameterRegistry.registryMutex.Lock()
+	defer parameterRegistry.registryMutex.Unlock()
+
+	configurationParameter, parameterExists := parameterRegistry.parameters[strings.ToLower(parameterName)]
+	if !parameterExists {
+		return "", false
+	}
+	return configurationParameter.getValue(parameterRegistry.configuration), true
+}
+
+// SetParameters applique une liste de paires nom / valeur de façon atomique
+// Si une valeur est invalide ou qu'un sous-système la refuse, toutes les valeurs précédentes sont restaurées
+func (parameterRegistry *ParameterRegistry) SetParameters(parameterPairs []string) error {
+	if len(parameterPairs) == 0 || len(parameterPairs)%2 != 0 {
+		return fmt.Errorf("nombre d'arguments incorrect (attendu: paramètre valeur [paramètre valeur ...])")
+	}
+
+	parameterRegistry.registryMutex.Lock()
+	defer parameterRegistry.registryMutex.Unlock()
+
+	// Validation des noms avant toute modification
+	seenParameters := make(map[string]bool)
+	for pairIndex := 0; pairIndex < len(parameterPairs); pairIndex += 2 {
+		parameterName := strings.ToLower(parameterPairs[pairIndex])
+		configurationParameter, parameterExists := parameterRegistry.parameters[parameterName]
+		if !parameterExists {
+			return fmt.Errorf("paramètre inconnu '%s'", parameterPairs[pairIndex])
+		}
+		if !configurationParameter.Mutable {
+			return fmt.Errorf("le paramètre '%s' ne peut pas être modifié à chaud", parameterName)
+		}
+		if seenParameters[parameterName] {
+			return fmt.Errorf("paramètre '%s' spécifié plusieurs fois", parameterName)
+		}
+		seenParameters[parameterName] = true
+	}
+
+	previousValues := make(map[string]string)
+	appliedParameters := make([]*ConfigurationParameter, 0, len(parameterPairs)/2)
+	restorePreviousValues := func() {
+		for _, appliedParameter := range appliedParameters {
+			appliedParameter.setValue(parameterRegistry.configuration, previousValues[appliedParameter.ParameterName])
+			if changeHandler := parameterRegistry.changeHandlers[appliedParameter.ParameterName]; changeHandler != nil {
+				changeHandler(parameterRegistry.configuration)
+			}
+		}
+	}
+
+	for pairIndex := 0; pairIndex < len(parameterPairs); pairIndex += 2 {
+		configurationParameter := parameterRegistry.parameters[strings.ToLower(parameterPairs[pairIndex])]
+		previousValues[configurationParameter.ParameterName] = configurationParameter.getValue(parameterRegistry.configuration)
+
+		if setError := configurationParameter.setValue(parameterRegistry.configuration, parameterPairs[pairIndex+1]); setError != nil {
+			restorePreviousValues()
+			return fmt.Errorf("'%s': %v", configurationParameter.ParameterName, setError)
+		}
+		appliedParameters = append(appliedParameters, configurationParameter)
+
+		if changeHandler := parameterRegistry.changeHandlers[configurationParameter.ParameterName]; changeHandler != nil {
+			if applyError := changeHandler(parameterRegistry.configuration); applyError != nil {
+				restorePreviousValues()
+				return fmt.Errorf("'%s': %v", configurationParameter.ParameterName, applyError)
+			}
+		}
+	}
+
+	for _, appliedParameter := range appliedParameters {
+		parameterRegistry.modifiedParameters[appliedParameter.ParameterName] = true
+	}
+	return nil
+}
+
+// RewriteConfigurationFile reporte les paramètres modifiés par CONFIG SET dans le fichier de configuration
+// Les lignes existantes sont mises à jour sur place, les commentaires et l'ordre sont conservés
+func (parameterRegistry *ParameterRegistry) RewriteConfigurationFile() error {
+	parameterRegistry.registryMutex.Lock()
+	defer parameterRegistry.registryMutex.Unlock()
+
+	configurationFilePath := parameterRegistry.configuration.ConfigurationFilePath
+	if configurationFilePath == "" {
+		return fmt.Errorf("le serveur fonctionne sans fichier de configuration")
+	}
+
+	var existingLines []string
+	if configurationFile, openError := os.Open(configurationFilePath); openError == nil {
+		lineScanner := bufio.NewScanner(configurationFile)
+		for lineScanner.Scan() {
+			existingLines = append(existingLines, lineScanner.Text())
+		}
+		configurationFile.Close()
+		if scanError := lineScanner.Err(); scanError != nil {
+			return fmt.Errorf("lecture %s: %v", configurationFilePath, scanError)
+		}
+	} else if !os.IsNotExist(openError) {
+		return fmt.Errorf("ouverture %s: %v", configurationFilePath, openError)
+	}
+
+	rewrittenParameters := make(map[string]bool)
+	rewrittenLines := make([]string, 0, len(existingLines))
+	for _, existingLine := range existingLines {
+		lineFields := strings.Fields(existingLine)
+		if len(lineFields) == 0 || strings.HasPrefix(lineFields[0], "#") {
+			rewrittenLines = append(rewrittenLines, existingLine)
+			continue
+		}
+
+		parameterName := strings.ToLower(lineFields[0])
+		if !parameterRegistry.modifiedParameters[parameterName] {
+			rewrittenLines = append(rewrittenLines, existingLine)
+			continue
+		}
+
+		// Première occurrence remplacée, les suivantes supprimées
+		if !rewrittenParameters[parameterName] {
+			rewrittenLines = append(rewrittenLines, parameterRegistry.formatParameterLine(parameterName))
+			rewrittenParameters[parameterName] = true
+		}
+	}
+
+	for _, parameterName := range parameterRegistry.ListParameterNames() {
+		if parameterRegistry.modifiedParameters[parameterName] && !rewrittenParameters[parameterName] {
+			rewrittenLines = append(rewrittenLines, parameterRegistry.formatParameterLine(parameterName))
+		}
+	}
+
+	// Écriture dans un fichier temporaire puis remplacement atomique
+	tempFilePath := configurationFilePath + ".tmp"
+	fileContent := strings.Join(rewrittenLines, "\n") + "\n"
+	if err := os.WriteFile(tempFilePath, []byte(fileContent), 0644); err != nil {
+		return fmt.Errorf("écriture %s: %v", tempFilePath, err)
+	}
+	if err := os.Rename(tempFilePath, configurationFilePath); err != nil {
+		os.Remove(tempFilePath)
+		return fmt.Errorf("remplacement %s: %v", configurationFilePath, err)
+	}
+	return nil
+}
+
+// formatParameterLine produit la ligne "nom valeur" d'un paramètre (valeur entre guillemets si nécessaire)
+func (parameterRegistry *ParameterRegistry) formatParameterLine(parameterName string) string {
+	parameterValue := parameterRegistry.parameters[parameterName].getValue(parameterRegistry.configuration)
+	if parameterValue == "" || strings.ContainsAny(parameterValue, " \t\"'#") {
+		parameterValue = strconv.Quote(parameterValue)
+	}
+	return parameterName + " " + parameterValue
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestParameterRegistry crée un registre sur une configuration par défaut, sans fichier
+func newTestParameterRegistry(t *testing.T) (*ParameterRegistry, *ServerConfiguration) {
+	t.Helper()
+	configuration := LoadServerConfiguration()
+	configuration.ConfigurationFilePath = ""
+	return NewParameterRegistry(configuration), configuration
+}
+
+func TestSetParametersValidatesAndFormatsValues(t *testing.T) {
+	testCases := []struct {
+		parameterName string
+		rawValue      string
+		expectedValue string
+		expectedError string
+	}{
+		{"maxclients", "500", "500", ""},
+		{"maxclients", "0", "", "hors limites"},
+		{"maxclients", "beaucoup", "", "n'est pas un entier"},
+		{"timeout", "30", "30", ""},
+		{"timeout", "-1", "", "inférieure au minimum"},
+		{"replica-read-only", "NO", "no", ""},
+		{"replica-read-only", "peut-être", "", "attendu yes ou no"},
+		{"save", "900 1 300 10", "900 1 300 10", ""},
+		{"save", "", "", ""},
+		{"save", "900", "", "règles de sauvegarde invalides"},
+		{"save", "900 0", "", "nombre de changements invalide"},
+		{"MAXCLIENTS", "42", "42", ""},
+		{"port", "7000", "", "ne peut pas être modifié à chaud"},
+		{"inconnu", "1", "", "paramètre inconnu"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.parameterName+"="+testCase.rawValue, func(t *testing.T) {
+			parameterRegistry, _ := newTestParameterRegistry(t)
+			setError := parameterRegistry.SetParameters([]string{testCase.parameterName, testCase.rawValue})
+			if testCase.expectedError != "" {
+				if setError == nil || !strings.Contains(setError.Error(), testCase.expectedError) {
+					t.Fatalf("SetParameters: %v, attendu une erreur contenant %q", setError, testCase.expectedError)
+				}
+				return
+			}
+			if setError != nil {
+				t.Fatalf("SetParameters: %v", setError)
+			}
+			if parameterValue, _ := parameterRegistry.GetParameterValue(testCase.parameterName); parameterValue != testCase.expectedValue {
+				t.Fatalf("%s = %q, attendu %q", testCase.parameterName, parameterValue, testCase.expectedValue)
+			}
+		})
+	}
+}
+
+func TestSetParametersIsAtomic(t *testing.T) {
+	testCases := []struct {
+		name           string
+		parameterPairs []string
+		expectedError  string
+	}{
+		{"valeur invalide en second", []string{"maxclients", "77", "timeout", "abc"}, "n'est pas un nombre de secondes"},
+		{"paramètre répété", []string{"maxclients", "77", "maxclients", "78"}, "spécifié plusieurs fois"},
+		{"nombre impair d'arguments", []string{"maxclients", "77", "timeout"}, "nombre d'arguments incorrect"},
+		{"sous-système qui refuse", []string{"maxclients", "77", "requirepass", "refusé"}, "refusé par le sous-système"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			parameterRegistry, configuration := newTestParameterRegistry(t)
+			configuration.PerformanceConfiguration.MaximumConnections = 10
+			appliedMaximumConnections := 10
+			parameterRegistry.OnParameterChange("maxclients", func(configuration *ServerConfiguration) error {
+				appliedMaximumConnections = configuration.PerformanceConfiguration.MaximumConnections
+				return nil
+			})
+			parameterRegistry.OnParameterChange("requirepass", func(configuration *ServerConfiguration) error {
+				if configuration.SecurityConfiguration.RequirePassword == "refusé" {
+					return errors.New("mot de passe refusé par le sous-système")
+				}
+				return nil
+			})
+
+			setError := parameterRegistry.SetParameters(testCase.parameterPairs)
+			if setError == nil || !strings.Contains(setError.Error(), testCase.expectedError) {
+				t.Fatalf("SetParameters: %v, attendu une erreur contenant %q", setError, testCase.expectedError)
+			}
+			// La première valeur, déjà appliquée, est restaurée et le sous-système en est informé
+			if configuration.PerformanceConfiguration.MaximumConnections != 10 || appliedMaximumConnections != 10 {
+				t.Fatalf("maxclients = %d (appliqué %d) après l'échec, attendu 10", configuration.PerformanceConfiguration.MaximumConnections, appliedMaximumConnections)
+			}
+		})
+	}
+}
+
+func TestRewriteConfigurationFileKeepsLayout(t *testing.T) {
+	configurationFilePath := filepath.Join(t.TempDir(), "redis.conf")
+	originalContent := "# Réglages\nport 6380\n\ntimeout 10\nmaxclients 100\ntimeout 20\n"
+	if writeError := os.WriteFile(configurationFilePath, []byte(originalContent), 0644); writeError != nil {
+		t.Fatalf("écriture configuration: %v", writeError)
+	}
+
+	parameterRegistry, configuration := newTestParameterRegistry(t)
+	configuration.ConfigurationFilePath = configurationFilePath
+	if setError := parameterRegistry.SetParameters([]string{"timeout", "60", "masterauth", "mot de passe", "save", "900 1"}); setError != nil {
+		t.Fatalf("SetParameters: %v", setError)
+	}
+	if rewriteError := parameterRegistry.RewriteConfigurationFile(); rewriteError != nil {
+		t.Fatalf("RewriteConfigurationFile: %v", rewriteError)
+	}
+
+	rewrittenContent, readError := os.ReadFile(configurationFilePath)
+	if readError != nil {
+		t.Fatalf("lecture configuration: %v", readError)
+	}
+	// Ligne existante remplacée sur place, doublon retiré, nouveaux paramètres ajoutés en fin de fichier dans l'ordre alphabétique
+	expectedContent := "# Réglages\nport 6380\n\ntimeout 60\nmaxclients 100\nmasterauth \"mot de passe\"\nsave \"900 1\"\n"
+	if string(rewrittenContent) != expectedContent {
+		t.Fatalf("fichier réécrit:\n%s\nattendu:\n%s", rewrittenContent, expectedContent)
+	}
+
+	reloadedConfiguration, loadError := LoadServerConfigurationFromArguments([]string{configurationFilePath})
+	if loadError != nil {
+		t.Fatalf("relecture du fichier réécrit: %v", loadError)
+	}
+	if reloadedConfiguration.ReplicationConfiguration.MasterPassword != "mot de passe" || reloadedConfiguration.NetworkConfiguration.ClientIdleTimeout != 60*time.Second {
+		t.Fatalf("valeurs relues masterauth=%q timeout=%v", reloadedConfiguration.ReplicationConfiguration.MasterPassword, reloadedConfiguration.NetworkConfiguration.ClientIdleTimeout)
+	}
+}
+
+func TestRewriteConfigurationFileRequiresFile(t *testing.T) {
+	parameterRegistry, _ := newTestParameterRegistry(t)
+	if rewriteError := parameterRegistry.RewriteConfigurationFile(); rewriteError == nil {
+		t.Fatalf("RewriteConfigurationFile sans fichier de configuration doit échouer")
+	}
+}
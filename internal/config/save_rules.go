@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultRDBSaveRules reprend les règles par défaut de Redis
+const defaultRDBSaveRules = "3600 1 300 100 60 10000"
+
+// RDBSaveRule déclenche un BGSAVE quand MinimumChanges écritures ont eu lieu depuis au moins Interval
+type RDBSaveRule struct {
+	Interval       time.Duration
+	MinimumChanges int64
+}
+
+// ParseRDBSaveRules lit des règles "secondes changements [secondes changements ...]" (vide = aucune règle)
+func ParseRDBSaveRules(rawRules string) ([]RDBSaveRule, error) {
+	ruleFields := strings.Fields(rawRules)
+	if len(ruleFields)%2 != 0 {
+		return nil, fmt.Errorf("règles de sauvegarde invalides '%s' (attendu: secondes changements [...])", rawRules)
+	}
+
+	saveRules := make([]RDBSaveRule, 0, len(ruleFields)/2)
+	for fieldIndex := 0; fieldIndex < len(ruleFields); fieldIndex += 2 {
+		intervalSeconds, intervalError := strconv.Atoi(ruleFields[fieldIndex])
+		if intervalError != nil || intervalSeconds <= 0 {
+			return nil, fmt.Errorf("durée de sauvegarde invalide '%s'", ruleFields[fieldIndex])
+		}
+		minimumChanges, changesError := strconv.ParseInt(ruleFields[fieldIndex+1], 10, 64)
+		if changesError != nil || minimumChanges <= 0 {
+			return nil, fmt.Errorf("nombre de changements invalide '%s'", ruleFields[fieldIndex+1])
+		}
+		saveRules = append(saveRules, RDBSaveRule{
+			Interval:       time.Duration(intervalSeconds) * time.Second,
+			MinimumChanges: minimumChanges,
+		})
+	}
+	return saveRules, nil
+}
+
+// FormatRDBSaveRules produit la forme texte des règles (CONFIG GET save)
+func FormatRDBSaveRules(saveRules []RDBSaveRule) string {
+	formattedRules := make([]string, 0, len(saveRules)*2)
+	for _, saveRule := range saveRules {
+		formattedRules = append(formattedRules,
+			strconv.FormatInt(int64(saveRule.Interval/time.Second), 10),
+			strconv.FormatInt(saveRule.MinimumChanges, 10))
+	}
+	return strings.Join(formattedRules, " ")
+}
+
+// getEnvironmentSaveRules lit REDIS_SAVE (une valeur vide désactive les règles)
+// REDIS_RDB_SAVE_INTERVAL reste accepté : N secondes équivaut à "save N 1"
+func getEnvironmentSaveRules() []RDBSaveRule {
+	rawRules, rulesDefined := os.LookupEnv("REDIS_SAVE")
+	if !rulesDefined {
+		rawRules = defaultRDBSaveRules
+		if legacyInterval := getEnvironmentInteger("REDIS_RDB_SAVE_INTERVAL", 0); legacyInterval > 0 {
+			rawRules = fmt.Sprintf("%d 1", legacyInterval)
+		}
+	}
+
+	saveRules, parseError := ParseRDBSaveRules(strings.Trim(rawRules, "\""))
+	if parseError != nil {
+		saveRules, _ = ParseRDBSaveRules(defaultRDBSaveRules)
+	}
+	return saveRules
+}
@@ -12,30 +12,93 @@ type ServerConfiguration struct {
 	PerformanceConfiguration PerformanceConfiguration
 	MaintenanceConfiguration MaintenanceConfiguration
 	PersistenceConfiguration PersistenceConfiguration // Nouveau
+	SecurityConfiguration    SecurityConfiguration
+	ReplicationConfiguration ReplicationConfiguration
+	ClusterConfiguration     ClusterConfiguration
+	RaftConfiguration        RaftConfiguration
+	ConfigurationFilePath    string // Fichier utilisé par CONFIG REWRITE (vide = aucun)
 }
 
 // NetworkConfiguration gère les paramètres réseau
 type NetworkConfiguration struct {
-	HostAddress string
-	PortNumber  int
+	HostAddress          string
+	PortNumber           int           // Port en clair (0 = désactivé, comme "port 0" dans Redis)
+	UnixSocketPath       string        // Socket Unix (vide = désactivé)
+	UnixSocketPermission os.FileMode   // Permissions du socket Unix (0 = umask par défaut)
+	ClientIdleTimeout    time.Duration // Fermeture des clients inactifs (0 = jamais)
+	TCPKeepAlivePeriod   time.Duration // Intervalle keepalive TCP des sockets acceptés (0 = désactivé)
+	TLSConfiguration     TLSConfiguration
+}
+
+// TLSConfiguration gère le port TLS optionnel et le mutual-TLS
+type TLSConfiguration struct {
+	PortNumber           int      // Port TLS (0 = désactivé)
+	CertificateFile      string   // Certificat serveur (PEM)
+	KeyFile              string   // Clé privée du certificat serveur (PEM)
+	CACertificateFile    string   // Autorité de confiance pour les certificats clients (PEM)
+	MinimumVersion       string   // Version minimale : 1.2 ou 1.3
+	CipherSuites         []string // Suites autorisées pour TLS 1.2 (vide = défauts Go)
+	ClientAuthentication string   // Certificat client : no, optional ou yes
 }
 
 // PerformanceConfiguration gère les paramètres de performance
 type PerformanceConfiguration struct {
-	MaximumConnections int
+	MaximumConnections            int
+	HyperLogLogSparseMaximumBytes int // Taille (en-tête compris) au-delà de laquelle une HyperLogLog passe en dense
 }
 
 // MaintenanceConfiguration gère les paramètres de maintenance
 type MaintenanceConfiguration struct {
 	ExpirationCheckInterval time.Duration
+	MaximumSavepoints       int // Nombre maximal de savepoints SNAPSHOT en mémoire (0 = désactivés)
 }
 
 // PersistenceConfiguration gère les paramètres de persistence RDB
 type PersistenceConfiguration struct {
-	RDBEnabled      bool          // Activer/désactiver RDB
-	RDBFilePath     string        // Chemin du fichier RDB
-	RDBSaveInterval time.Duration // Intervalle de sauvegarde auto
-	RDBSaveOnExit   bool          // Sauvegarder à l'arrêt
+	RDBEnabled     bool          // Activer/désactiver RDB
+	RDBFilePath    string        // Chemin du fichier RDB
+	RDBSaveRules   []RDBSaveRule // Règles "save <secondes> <changements>" (vide = pas de sauvegarde auto)
+	RDBSaveOnExit  bool          // Sauvegarder à l'arrêt
+	RDBCompression bool          // Compression gzip des fichiers RDB
+	RDBRetention   int           // Nombre de snapshots horodatés conservés (0 = aucun)
+	DurableLog     bool          // Journal des écritures avec fsync groupé (CLIENT DURABILITY SYNC, WAITDURABLE)
+	DurableLogFile string        // Chemin du journal des écritures
+}
+
+// SecurityConfiguration gère les paramètres d'authentification
+type SecurityConfiguration struct {
+	RequirePassword string // Mot de passe exigé via AUTH (vide = pas d'authentification)
+	ACLFilePath     string // Fichier d'utilisateurs ACL (vide = utilisateurs en mémoire uniquement)
+}
+
+// ReplicationConfiguration gère la réplication maître / réplica
+type ReplicationConfiguration struct {
+	ReplicaOfHost      string        // Maître à répliquer au démarrage (vide = serveur maître)
+	ReplicaOfPort      int           // Port du maître
+	MasterUser         string        // Utilisateur ACL pour s'authentifier auprès du maître (vide = default)
+	MasterPassword     string        // Mot de passe envoyé au maître (vide = pas d'AUTH)
+	ReplicaReadOnly    bool          // Refuser les écritures des clients sur un réplica
+	BacklogSize        int           // Taille du backlog de réplication en octets
+	PingReplicaPeriod  time.Duration // Intervalle des PING envoyés aux réplicas
+	ReplicationTimeout time.Duration // Délai sans échange avant de considérer le lien rompu
+}
+
+// ClusterConfiguration gère le mode cluster (topologie statique des slots)
+type ClusterConfiguration struct {
+	ClusterEnabled        bool   // Répartir les clés sur 16384 slots et rediriger vers le nœud propriétaire
+	ClusterTopologyFile   string // Fichier décrivant les nœuds et leurs slots
+	ClusterNodeIdentifier string // Identifiant de ce nœud dans la topologie (vide = nœud dont le port est celui du serveur)
+}
+
+// RaftConfiguration gère le mode consensus (écritures validées par une majorité de nœuds)
+type RaftConfiguration struct {
+	RaftEnabled           bool   // Faire passer les écritures par le journal Raft du groupe
+	RaftNodeIdentifier    string // Identifiant de ce nœud parmi les membres
+	RaftPeers             string // Membres du groupe, nœud local compris : id=hôte:port@port-raft,...
+	RaftDataDirectory     string // Journal, vote et snapshots Raft
+	RaftElectionTimeout   int    // Délai minimal (millisecondes) sans nouvelles du leader avant une élection
+	RaftSnapshotThreshold int    // Entrées appliquées entre deux compactions du journal (0 = jamais)
+	RaftLinearizableReads bool   // Lectures servies par le leader après confirmation de la majorité
 }
 
 // LoadServerConfiguration charge la configuration depuis les variables d'environnement
@@ -43,21 +106,73 @@ type PersistenceConfiguration struct {
 func LoadServerConfiguration() *ServerConfiguration {
 	configuration := &ServerConfiguration{
 		NetworkConfiguration: NetworkConfiguration{
-			HostAddress: getEnvironmentString("REDIS_HOST", "localhost"),
-			PortNumber:  getEnvironmentInteger("REDIS_PORT", 6379),
+			HostAddress:          getEnvironmentString("REDIS_HOST", "localhost"),
+			PortNumber:           getEnvironmentInteger("REDIS_PORT", 6379),
+			UnixSocketPath:       getEnvironmentString("REDIS_UNIXSOCKET", ""),
+			UnixSocketPermission: getEnvironmentFileMode("REDIS_UNIXSOCKETPERM", 0),
+			ClientIdleTimeout:    time.Duration(getEnvironmentInteger("REDIS_TIMEOUT", 0)) * time.Second,
+			TCPKeepAlivePeriod:   time.Duration(getEnvironmentInteger("REDIS_TCP_KEEPALIVE", 300)) * time.Second,
+			TLSConfiguration: TLSConfiguration{
+				PortNumber:           getEnvironmentInteger("REDIS_TLS_PORT", 0),
+				CertificateFile:      getEnvironmentString("REDIS_TLS_CERT_FILE", ""),
+				KeyFile:              getEnvironmentString("REDIS_TLS_KEY_FILE", ""),
+				CACertificateFile:    getEnvironmentString("REDIS_TLS_CA_CERT_FILE", ""),
+				MinimumVersion:       getEnvironmentString("REDIS_TLS_MIN_VERSION", "1.2"),
+				CipherSuites:         getEnvironmentList("REDIS_TLS_CIPHERS"),
+				ClientAuthentication: getEnvironmentString("REDIS_TLS_AUTH_CLIENTS", "yes"),
+			},
 		},
 		PerformanceConfiguration: PerformanceConfiguration{
-			MaximumConnections: getEnvironmentInteger("REDIS_MAX_CONNECTIONS", 1000),
+			MaximumConnections:            getEnvironmentInteger("REDIS_MAX_CONNECTIONS", 1000),
+			HyperLogLogSparseMaximumBytes: getEnvironmentInteger("REDIS_HLL_SPARSE_MAX_BYTES", 3000),
 		},
 		MaintenanceConfiguration: MaintenanceConfiguration{
 			ExpirationCheckInterval: time.Duration(getEnvironmentInteger("REDIS_EXPIRATION_CHECK_INTERVAL", 1)) * time.Second,
+			MaximumSavepoints:       getEnvironmentInteger("REDIS_MAX_SAVEPOINTS", 16),
 		},
 		PersistenceConfiguration: PersistenceConfiguration{
-			RDBEnabled:      getEnvironmentBool("REDIS_RDB_ENABLED", true),
-			RDBFilePath:     getEnvironmentString("REDIS_RDB_FILE", "./data/dump.rdb"),
-			RDBSaveInterval: time.Duration(getEnvironmentInteger("REDIS_RDB_SAVE_INTERVAL", 300)) * time.Second, // 5 minutes par défaut
-			RDBSaveOnExit:   getEnvironmentBool("REDIS_RDB_SAVE_ON_EXIT", true),
+			RDBEnabled:     getEnvironmentBool("REDIS_RDB_ENABLED", true),
+			RDBFilePath:    getEnvironmentString("REDIS_RDB_FILE", "./data/dump.rdb"),
+			RDBSaveRules:   getEnvironmentSaveRules(),
+			RDBSaveOnExit:  getEnvironmentBool("REDIS_RDB_SAVE_ON_EXIT", true),
+			RDBCompression: getEnvironmentBool("REDIS_RDB_COMPRESSION", true),
+			RDBRetention:   getEnvironmentInteger("REDIS_RDB_RETENTION", 0),
+			DurableLog:     getEnvironmentBool("REDIS_DURABLE_LOG", false),
+			DurableLogFile: getEnvironmentString("REDIS_DURABLE_LOG_FILE", "./data/writes.log"),
+		},
+		SecurityConfiguration: SecurityConfiguration{
+			RequirePassword: getEnvironmentString("REDIS_REQUIREPASS", ""),
+			ACLFilePath:     getEnvironmentString("REDIS_ACLFILE", ""),
+		},
+		ReplicationConfiguration: ReplicationConfiguration{
+			MasterUser:         getEnvironmentString("REDIS_MASTERUSER", ""),
+			MasterPassword:     getEnvironmentString("REDIS_MASTERAUTH", ""),
+			ReplicaReadOnly:    getEnvironmentBool("REDIS_REPLICA_READ_ONLY", true),
+			BacklogSize:        getEnvironmentInteger("REDIS_REPL_BACKLOG_SIZE", 1024*1024),
+			PingReplicaPeriod:  time.Duration(getEnvironmentInteger("REDIS_REPL_PING_REPLICA_PERIOD", 10)) * time.Second,
+			ReplicationTimeout: time.Duration(getEnvironmentInteger("REDIS_REPL_TIMEOUT", 60)) * time.Second,
 		},
+		ClusterConfiguration: ClusterConfiguration{
+			ClusterEnabled:        getEnvironmentBool("REDIS_CLUSTER_ENABLED", false),
+			ClusterTopologyFile:   getEnvironmentString("REDIS_CLUSTER_TOPOLOGY_FILE", "./cluster-topology.conf"),
+			ClusterNodeIdentifier: getEnvironmentString("REDIS_CLUSTER_NODE_ID", ""),
+		},
+		RaftConfiguration: RaftConfiguration{
+			RaftEnabled:           getEnvironmentBool("REDIS_RAFT_ENABLED", false),
+			RaftNodeIdentifier:    getEnvironmentString("REDIS_RAFT_NODE_ID", ""),
+			RaftPeers:             getEnvironmentString("REDIS_RAFT_PEERS", ""),
+			RaftDataDirectory:     getEnvironmentString("REDIS_RAFT_DIR", "./data/raft"),
+			RaftElectionTimeout:   getEnvironmentInteger("REDIS_RAFT_ELECTION_TIMEOUT", 1000),
+			RaftSnapshotThreshold: getEnvironmentInteger("REDIS_RAFT_SNAPSHOT_THRESHOLD", 10000),
+			RaftLinearizableReads: getEnvironmentBool("REDIS_RAFT_LINEARIZABLE_READS", false),
+		},
+		ConfigurationFilePath: getEnvironmentString("REDIS_CONFIG_FILE", ""),
+	}
+
+	// REDIS_REPLICAOF="hôte port" démarre le serveur en réplica
+	if replicaOfHost, replicaOfPort, parseError := ParseReplicaOf(os.Getenv("REDIS_REPLICAOF")); parseError == nil {
+		configuration.ReplicationConfiguration.ReplicaOfHost = replicaOfHost
+		configuration.ReplicationConfiguration.ReplicaOfPort = replicaOfPort
 	}
 
 	return configuration
@@ -90,3 +205,18 @@ func getEnvironmentBool(environmentKey string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+// getEnvironmentFileMode récupère des permissions en octal (ex: 770) avec valeur par défaut
+func getEnvironmentFileMode(environmentKey string, defaultValue os.FileMode) os.FileMode {
+	if environmentValue := os.Getenv(environmentKey); environmentValue != "" {
+		if modeValue, parseError := strconv.ParseUint(environmentValue, 8, 32); parseError == nil && modeValue <= 0777 {
+			return os.FileMode(modeValue)
+		}
+	}
+	return defaultValue
+}
+
+// getEnvironmentList récupère une liste séparée par des virgules (liste vide par défaut)
+func getEnvironmentList(environmentKey string) []string {
+	return splitListValue(os.Getenv(environmentKey))
+}
@@ -0,0 +1,412 @@
+package consensus
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	raftStateFileName    = "raft-state"
+	raftLogFileName      = "raft-log"
+	raftSnapshotPrefix   = "snapshot-"
+	raftSnapshotSuffix   = ".rdb"
+	raftFrameHeaderSize  = 4 + 4 // Longueur du corps puis CRC32 du corps
+	raftMaximumFrameSize = 512 * 1024 * 1024
+)
+
+// raftLogStore conserve sur disque ce que Raft doit retrouver après un redémarrage :
+// le mandat courant et le vote (raft-state), les entrées qui suivent le dernier snapshot (raft-log)
+// et le dernier snapshot lui-même (snapshot-<index>-<mandat>.rdb, renommé atomiquement)
+type raftLogStore struct {
+	dataDirectory string
+
+	fileMutex sync.Mutex // Protège le fichier du journal et son tampon
+	logFile   *os.File
+	logWriter *bufio.Writer
+}
+
+// raftPersistedState est l'état relu au démarrage
+type raftPersistedState struct {
+	currentTerm      int64
+	votedFor         string
+	logEntries       []RaftLogEntry
+	snapshotIndex    int64
+	snapshotTerm     int64
+	snapshotFilePath string
+}
+
+// openRaftLogStore crée le répertoire de données si besoin et relit l'état persistant
+// Une entrée incomplète en fin de journal (crash pendant l'écriture) est ignorée et tronquée
+func openRaftLogStore(dataDirectory string) (*raftLogStore, raftPersistedState, error) {
+	var persistedState raftPersistedState
+	if err := os.MkdirAll(dataDirectory, 0755); err != nil {
+		return nil, persistedState, fmt.Errorf("création du répertoire %s: %v", dataDirectory, err)
+	}
+	logStore := &raftLogStore{dataDirectory: dataDirectory}
+
+	if err := logStore.readState(&persistedState); err != nil {
+		return nil, persistedState, err
+	}
+	if err := logStore.findLatestSnapshot(&persistedState); err != nil {
+		return nil, persistedState, err
+	}
+
+	logFilePath := filepath.Join(dataDirectory, raftLogFileName)
+	logFile, openError := os.OpenFile(logFilePath, os.O_RDWR|os.O_CREATE, 0644)
+	if openError != nil {
+		return nil, persistedState, fmt.Errorf("ouverture du journal raft: %v", openError)
+	}
+	validLength, logEntries, readError := readRaftLogFrames(logFile)
+	if readError != nil {
+		logFile.Close()
+		return nil, persistedState, readError
+	}
+	if err := logFile.Truncate(validLength); err != nil {
+		logFile.Close()
+		return nil, persistedState, fmt.Errorf("troncature du journal raft: %v", err)
+	}
+	if _, err := logFile.Seek(validLength, io.SeekStart); err != nil {
+		logFile.Close()
+		return nil, persistedState, err
+	}
+
+	// Les entrées couvertes par le snapshot (compaction interrompue) sont écartées
+	for len(logEntries) > 0 && logEntries[0].Index <= persistedState.snapshotIndex {
+		logEntries = logEntries[1:]
+	}
+	if len(logEntries) > 0 && logEntries[0].Index != persistedState.snapshotIndex+1 {
+		logFile.Close()
+		return nil, persistedState, fmt.Errorf("journal raft incohérent : première entrée %d après le snapshot %d",
+			logEntries[0].Index, persistedState.snapshotIndex)
+	}
+	persistedState.logEntries = logEntries
+
+	logStore.logFile = logFile
+	logStore.logWriter = bufio.NewWriterSize(logFile, 64*1024)
+	return logStore, persistedState, nil
+}
+
+// readState relit le mandat et le vote ("term <n>" puis "vote <id>")
+func (logStore *raftLogStore) readState(persistedState *raftPersistedState) error {
+	stateContent, readError := os.ReadFile(filepath.Join(logStore.dataDirectory, raftStateFileName))
+	if os.IsNotExist(readError) {
+		return nil
+	}
+	if readError != nil {
+		return fmt.Errorf("lecture de l'état raft: %v", readError)
+	}
+	for _, stateLine := range strings.Split(string(stateContent), "\n") {
+		fieldName, fieldValue, _ := strings.Cut(strings.TrimSpace(stateLine), " ")
+		switch fieldName {
+		case "term":
+			parsedTerm, parseError := strconv.ParseInt(fieldValue, 10, 64)
+			if parseError != nil {
+				return fmt.Errorf("état raft invalide: mandat '%s'", fieldValue)
+			}
+			persistedState.currentTerm = parsedTerm
+		case "vote":
+			persistedState.votedFor = fieldValue
+		}
+	}
+	return nil
+}
+
+// saveState écrit le mandat et le vote de façon atomique et durable (fichier temporaire, fsync, renommage)
+// Un nœud ne doit jamais voter deux fois dans le même mandat, même après un crash
+func (logStore *raftLogStore) saveState(currentTerm int64, votedFor string) error {
+	stateContent := fmt.Sprintf("term %d\nvote %s\n", currentTerm, votedFor)
+	return writeFileDurably(filepath.Join(logStore.dataDirectory, raftStateFileName), []byte(stateContent))
+}
+
+// findLatestSnapshot repère le snapshot le plus récent et supprime les plus anciens
+func (logStore *raftLogStore) findLatestSnapshot(persistedState *raftPersistedState) error {
+	directoryEntries, readError := os.ReadDir(logStore.dataDirectory)
+	if readError != nil {
+		return fmt.Errorf("lecture du répertoire raft: %v", readError)
+	}
+	type snapshotFile struct {
+		path  string
+		index int64
+		term  int64
+	}
+	var snapshotFiles []snapshotFile
+	for _, directoryEntry := range directoryEntries {
+		snapshotIndex, snapshotTerm, isSnapshot := parseSnapshotFileName(directoryEntry.Name())
+		if isSnapshot {
+			snapshotFiles = append(snapshotFiles, snapshotFile{
+				path:  filepath.Join(logStore.dataDirectory, directoryEntry.Name()),
+				index: snapshotIndex,
+				term:  snapshotTerm,
+			})
+		}
+	}
+	if len(snapshotFiles) == 0 {
+		return nil
+	}
+	sort.Slice(snapshotFiles, func(i, j int) bool { return snapshotFiles[i].index > snapshotFiles[j].index })
+	persistedState.snapshotIndex = snapshotFiles[0].index
+	persistedState.snapshotTerm = snapshotFiles[0].term
+	persistedState.snapshotFilePath = snapshotFiles[0].path
+	for _, obsoleteSnapshot := range snapshotFiles[1:] {
+		os.Remove(obsoleteSnapshot.path)
+	}
+	return nil
+}
+
+// parseSnapshotFileName lit l'index et le mandat encodés dans le nom d'un fichier snapshot
+func parseSnapshotFileName(fileName string) (int64, int64, bool) {
+	if !strings.HasPrefix(fileName, raftSnapshotPrefix) || !strings.HasSuffix(fileName, raftSnapshotSuffix) {
+		return 0, 0, false
+	}
+	snapshotPosition := strings.TrimSuffix(strings.TrimPrefix(fileName, raftSnapshotPrefix), raftSnapshotSuffix)
+	indexText, termText, hasTerm := strings.Cut(snapshotPosition, "-")
+	if !hasTerm {
+		return 0, 0, false
+	}
+	snapshotIndex, indexError := strconv.ParseInt(indexText, 10, 64)
+	snapshotTerm, termError := strconv.ParseInt(termText, 10, 64)
+	if indexError != nil || termError != nil {
+		return 0, 0, false
+	}
+	return snapshotIndex, snapshotTerm, true
+}
+
+// saveSnapshot écrit un snapshot durablement puis supprime le précédent
+func (logStore *raftLogStore) saveSnapshot(snapshotIndex, snapshotTerm int64, snapshotData []byte, previousSnapshotPath string) (string, error) {
+	snapshotFilePath := filepath.Join(logStore.dataDirectory,
+		fmt.Sprintf("%s%d-%d%s", raftSnapshotPrefix, snapshotIndex, snapshotTerm, raftSnapshotSuffix))
+	if err := writeFileDurably(snapshotFilePath, snapshotData); err != nil {
+		return "", fmt.Errorf("écriture du snapshot raft: %v", err)
+	}
+	if previousSnapshotPath != "" && previousSnapshotPath != snapshotFilePath {
+		os.Remove(previousSnapshotPath)
+	}
+	return snapshotFilePath, nil
+}
+
+// appendEntries ajoute des entrées à la fin du journal (en tampon : sync les rend durables)
+func (logStore *raftLogStore) appendEntries(logEntries []RaftLogEntry) error {
+	logStore.fileMutex.Lock()
+	defer logStore.fileMutex.Unlock()
+	for _, logEntry := range logEntries {
+		if _, err := logStore.logWriter.Write(encodeRaftLogFrame(logEntry)); err != nil {
+			return fmt.Errorf("écriture du journal raft: %v", err)
+		}
+	}
+	return nil
+}
+
+// sync écrit le tampon et fsync le journal
+func (logStore *raftLogStore) sync() error {
+	logStore.fileMutex.Lock()
+	flushError := logStore.logWriter.Flush()
+	logFile := logStore.logFile
+	logStore.fileMutex.Unlock()
+	if flushError != nil {
+		return fmt.Errorf("écriture du journal raft: %v", flushError)
+	}
+	if err := logFile.Sync(); err != nil {
+		return fmt.Errorf("fsync du journal raft: %v", err)
+	}
+	return nil
+}
+
+// rewrite remplace tout le journal par les entrées données (conflit avec le leader ou compaction)
+// Le nouveau fichier est écrit à côté puis renommé : un crash laisse l'ancien ou le nouveau journal
+func (logStore *raftLogStore) rewrite(logEntries []RaftLogEntry) error {
+	logStore.fileMutex.Lock()
+	defer logStore.fileMutex.Unlock()
+
+	logFilePath := filepath.Join(logStore.dataDirectory, raftLogFileName)
+	temporaryPath := logFilePath + ".tmp"
+	temporaryFile, createError := os.OpenFile(temporaryPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if createError != nil {
+		return fmt.Errorf("réécriture du journal raft: %v", createError)
+	}
+	temporaryWriter := bufio.NewWriterSize(temporaryFile, 64*1024)
+	for _, logEntry := range logEntries {
+		if _, err := temporaryWriter.Write(encodeRaftLogFrame(logEntry)); err != nil {
+			temporaryFile.Close()
+			return fmt.Errorf("réécriture du journal raft: %v", err)
+		}
+	}
+	if err := temporaryWriter.Flush(); err != nil {
+		temporaryFile.Close()
+		return fmt.Errorf("réécriture du journal raft: %v", err)
+	}
+	if err := temporaryFile.Sync(); err != nil {
+		temporaryFile.Close()
+		return fmt.Errorf("fsync du journal raft: %v", err)
+	}
+	if err := os.Rename(temporaryPath, logFilePath); err != nil {
+		temporaryFile.Close()
+		return fmt.Errorf("remplacement du journal raft: %v", err)
+	}
+	syncDirectory(logStore.dataDirectory)
+
+	logStore.logFile.Close()
+	logStore.logFile = temporaryFile
+	logStore.logWriter = bufio.NewWriterSize(temporaryFile, 64*1024)
+	return nil
+}
+
+// close écrit le tampon et ferme le journal
+func (logStore *raftLogStore) close() {
+	logStore.fileMutex.Lock()
+	defer logStore.fileMutex.Unlock()
+	logStore.logWriter.Flush()
+	logStore.logFile.Sync()
+	logStore.logFile.Close()
+}
+
+// encodeRaftLogFrame encode une entrée : [longueur][crc32] puis index, mandat et arguments (varints)
+// Une entrée vide (leader) est encodée avec un nombre d'arguments négatif
+func encodeRaftLogFrame(logEntry RaftLogEntry) []byte {
+	frameBody := binary.AppendVarint(nil, logEntry.Index)
+	frameBody = binary.AppendVarint(frameBody, logEntry.Term)
+	if logEntry.Command == nil {
+		frameBody = binary.AppendVarint(frameBody, -1)
+	} else {
+		frameBody = binary.AppendVarint(frameBody, int64(len(logEntry.Command)))
+		for _, commandArgument := range logEntry.Command {
+			frameBody = binary.AppendUvarint(frameBody, uint64(len(commandArgument)))
+			frameBody = append(frameBody, commandArgument...)
+		}
+	}
+	encodedFrame := make([]byte, raftFrameHeaderSize, raftFrameHeaderSize+len(frameBody))
+	binary.LittleEndian.PutUint32(encodedFrame[0:4], uint32(len(frameBody)))
+	binary.LittleEndian.PutUint32(encodedFrame[4:8], crc32.ChecksumIEEE(frameBody))
+	return append(encodedFrame, frameBody...)
+}
+
+// decodeRaftLogFrameBody décode le corps d'une entrée
+func decodeRaftLogFrameBody(frameBody []byte) (RaftLogEntry, error) {
+	var logEntry RaftLogEntry
+	bodyReader := &frameBodyReader{remaining: frameBody}
+	logEntry.Index = bodyReader.readVarint()
+	logEntry.Term = bodyReader.readVarint()
+	argumentCount := bodyReader.readVarint()
+	if argumentCount >= 0 {
+		logEntry.Command = make([]string, 0, argumentCount)
+		for argumentNumber := int64(0); argumentNumber < argumentCount && bodyReader.failure == nil; argumentNumber++ {
+			logEntry.Command = append(logEntry.Command, bodyReader.readString())
+		}
+	}
+	if bodyReader.failure != nil || len(bodyReader.remaining) != 0 {
+		return logEntry, fmt.Errorf("entrée du journal raft illisible")
+	}
+	return logEntry, nil
+}
+
+// frameBodyReader lit les champs d'un corps d'entrée en retenant la première erreur
+type frameBodyReader struct {
+	remaining []byte
+	failure   error
+}
+
+func (bodyReader *frameBodyReader) readVarint() int64 {
+	if bodyReader.failure != nil {
+		return 0
+	}
+	decodedValue, readLength := binary.Varint(bodyReader.remaining)
+	if readLength <= 0 {
+		bodyReader.failure = io.ErrUnexpectedEOF
+		return 0
+	}
+	bodyReader.remaining = bodyReader.remaining[readLength:]
+	return decodedValue
+}
+
+func (bodyReader *frameBodyReader) readString() string {
+	if bodyReader.failure != nil {
+		return ""
+	}
+	stringLength, readLength := binary.Uvarint(bodyReader.remaining)
+	if readLength <= 0 || uint64(len(bodyReader.remaining)-readLength) < stringLength {
+		bodyReader.failure = io.ErrUnexpectedEOF
+		return ""
+	}
+	bodyReader.remaining = bodyReader.remaining[readLength:]
+	decodedString := string(bodyReader.remaining[:stringLength])
+	bodyReader.remaining = bodyReader.remaining[stringLength:]
+	return decodedString
+}
+
+// readRaftLogFrames relit toutes les entrées du journal et retourne la longueur de la partie valide
+// Seule la dernière entrée peut être incomplète ; une entrée corrompue suivie d'autres est une erreur
+func readRaftLogFrames(logFile *os.File) (int64, []RaftLogEntry, error) {
+	fileStatus, statError := logFile.Stat()
+	if statError != nil {
+		return 0, nil, statError
+	}
+	fileReader := bufio.NewReaderSize(logFile, 64*1024)
+	var logEntries []RaftLogEntry
+	var validLength int64
+	frameHeader := make([]byte, raftFrameHeaderSize)
+	for {
+		if _, err := io.ReadFull(fileReader, frameHeader); err != nil {
+			return validLength, logEntries, nil
+		}
+		frameLength := binary.LittleEndian.Uint32(frameHeader[0:4])
+		frameChecksum := binary.LittleEndian.Uint32(frameHeader[4:8])
+		frameEnd := validLength + raftFrameHeaderSize + int64(frameLength)
+		if frameLength > raftMaximumFrameSize || frameEnd > fileStatus.Size() {
+			return validLength, logEntries, nil
+		}
+		frameBody := make([]byte, frameLength)
+		if _, err := io.ReadFull(fileReader, frameBody); err != nil {
+			return validLength, logEntries, nil
+		}
+		logEntry, decodeError := decodeRaftLogFrameBody(frameBody)
+		if crc32.ChecksumIEEE(frameBody) != frameChecksum || decodeError != nil {
+			if frameEnd == fileStatus.Size() {
+				return validLength, logEntries, nil
+			}
+			return 0, nil, fmt.Errorf("journal raft corrompu à l'octet %d", validLength)
+		}
+		logEntries = append(logEntries, logEntry)
+		validLength = frameEnd
+	}
+}
+
+// writeFileDurably écrit un fichier via un fichier temporaire fsync puis renommé
+func writeFileDurably(filePath string, fileContent []byte) error {
+	temporaryPath := filePath + ".tmp"
+	temporaryFile, createError := os.OpenFile(temporaryPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if createError != nil {
+		return createError
+	}
+	if _, err := temporaryFile.Write(fileContent); err != nil {
+		temporaryFile.Close()
+		return err
+	}
+	if err := temporaryFile.Sync(); err != nil {
+		temporaryFile.Close()
+		return err
+	}
+	if err := temporaryFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temporaryPath, filePath); err != nil {
+		return err
+	}
+	syncDirectory(filepath.Dir(filePath))
+	return nil
+}
+
+// syncDirectory rend durable un renommage dans le répertoire (sans effet sur les systèmes qui l'ignorent)
+func syncDirectory(directoryPath string) {
+	if directoryHandle, err := os.Open(directoryPath); err == nil {
+		directoryHandle.Sync()
+		directoryHandle.Close()
+	}
+}
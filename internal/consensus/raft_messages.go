@@ -0,0 +1,102 @@
+package consensus
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// RaftLogEntry est une entrée du journal Raft : une commande d'écriture, ou une entrée vide
+// ajoutée par chaque nouveau leader pour valider les entrées des mandats précédents
+type RaftLogEntry struct {
+	Index   int64
+	Term    int64
+	Command []string // nil pour l'entrée vide d'un nouveau leader
+}
+
+// RequestVoteRequest est envoyée par un candidat à chaque nœud
+type RequestVoteRequest struct {
+	Term         int64
+	CandidateID  string
+	LastLogIndex int64
+	LastLogTerm  int64
+}
+
+// RequestVoteResponse est la réponse à RequestVote
+type RequestVoteResponse struct {
+	Term        int64
+	VoteGranted bool
+}
+
+// AppendEntriesRequest réplique des entrées (ou sert de heartbeat quand Entries est vide)
+type AppendEntriesRequest struct {
+	Term         int64
+	LeaderID     string
+	PrevLogIndex int64
+	PrevLogTerm  int64
+	Entries      []RaftLogEntry
+	LeaderCommit int64
+}
+
+// AppendEntriesResponse est la réponse à AppendEntries
+// En cas de refus, ConflictIndex indique où le leader doit reprendre (évite de reculer entrée par entrée)
+type AppendEntriesResponse struct {
+	Term          int64
+	Success       bool
+	ConflictIndex int64
+}
+
+// InstallSnapshotRequest transmet l'état complet à un nœud trop en retard pour être rattrapé par le journal
+type InstallSnapshotRequest struct {
+	Term              int64
+	LeaderID          string
+	LastIncludedIndex int64
+	LastIncludedTerm  int64
+	SnapshotData      []byte
+}
+
+// InstallSnapshotResponse est la réponse à InstallSnapshot
+type InstallSnapshotResponse struct {
+	Term int64
+}
+
+// RaftPeer décrit un membre du groupe : son adresse client (redirections) et son adresse Raft
+type RaftPeer struct {
+	NodeID        string
+	ClientAddress string
+	RaftAddress   string
+}
+
+// ParseRaftPeers lit la liste des membres "id=hôte:port@port-raft,..." (le nœud local compris)
+func ParseRaftPeers(peerList string) ([]RaftPeer, error) {
+	var raftPeers []RaftPeer
+	knownNodeIDs := make(map[string]bool)
+	for _, peerDescription := range strings.Split(peerList, ",") {
+		peerDescription = strings.TrimSpace(peerDescription)
+		if peerDescription == "" {
+			continue
+		}
+		nodeID, addresses, hasNodeID := strings.Cut(peerDescription, "=")
+		clientAddress, raftPort, hasRaftPort := strings.Cut(addresses, "@")
+		if !hasNodeID || !hasRaftPort || nodeID == "" {
+			return nil, fmt.Errorf("membre '%s' invalide, attendu id=hôte:port@port-raft", peerDescription)
+		}
+		clientHost, _, splitError := net.SplitHostPort(clientAddress)
+		if splitError != nil {
+			return nil, fmt.Errorf("membre '%s' : adresse client invalide: %v", peerDescription, splitError)
+		}
+		if knownNodeIDs[nodeID] {
+			return nil, fmt.Errorf("membre '%s' déclaré plusieurs fois", nodeID)
+		}
+		knownNodeIDs[nodeID] = true
+		raftPeers = append(raftPeers, RaftPeer{
+			NodeID:        nodeID,
+			ClientAddress: clientAddress,
+			RaftAddress:   net.JoinHostPort(clientHost, raftPort),
+		})
+	}
+	if len(raftPeers) == 0 {
+		return nil, fmt.Errorf("aucun membre déclaré")
+	}
+	return raftPeers, nil
+}
@@ -0,0 +1,1054 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RaftRole est le rôle courant d'un nœud
+type RaftRole int
+
+const (
+	RaftFollower RaftRole = iota
+	RaftCandidate
+	RaftLeader
+)
+
+// String retourne le nom du rôle tel qu'affiché par INFO
+func (raftRole RaftRole) String() string {
+	switch raftRole {
+	case RaftLeader:
+		return "leader"
+	case RaftCandidate:
+		return "candidate"
+	default:
+		return "follower"
+	}
+}
+
+// maximumEntriesPerAppend limite la taille d'un envoi AppendEntries vers un nœud en retard
+const maximumEntriesPerAppend = 512
+
+// RaftStateMachine est l'état répliqué : les commandes validées y sont appliquées dans l'ordre du journal
+type RaftStateMachine interface {
+	Apply(command []string) []byte     // Exécute une commande validée et retourne la réponse RESP
+	Snapshot() ([]byte, error)         // Sérialise l'état complet (compaction du journal)
+	Restore(snapshotData []byte) error // Remplace l'état par un snapshot
+}
+
+// RaftConfiguration décrit un nœud et son groupe
+type RaftConfiguration struct {
+	NodeID            string
+	Peers             []RaftPeer // Tous les membres, nœud local compris
+	DataDirectory     string
+	ElectionTimeout   time.Duration // Délai minimal sans nouvelles du leader avant une élection
+	HeartbeatInterval time.Duration
+	SnapshotThreshold int64 // Entrées appliquées depuis le dernier snapshot avant compaction (0 = jamais)
+}
+
+// NotLeaderError est retournée par un nœud qui n'est pas leader ; LeaderAddress est vide si aucun leader n'est connu
+type NotLeaderError struct {
+	LeaderID      string
+	LeaderAddress string
+}
+
+func (notLeaderError *NotLeaderError) Error() string {
+	if notLeaderError.LeaderAddress == "" {
+		return "aucun leader élu"
+	}
+	return fmt.Sprintf("le leader est %s (%s)", notLeaderError.LeaderID, notLeaderError.LeaderAddress)
+}
+
+var (
+	// ErrLeadershipLost : le leader a perdu son mandat avant que l'écriture soit validée ; elle a pu l'être ou non
+	ErrLeadershipLost = errors.New("leadership perdu avant validation, l'écriture a pu être appliquée ou non")
+	// ErrRaftStopped : le nœud est arrêté
+	ErrRaftStopped = errors.New("nœud raft arrêté")
+	// ErrRaftTimeout : délai dépassé en attendant la majorité
+	ErrRaftTimeout = errors.New("délai dépassé en attendant la majorité")
+)
+
+// raftProposal attend l'application d'une écriture proposée par ce nœud
+type raftProposal struct {
+	term          int64
+	resultChannel chan raftProposalResult
+}
+
+type raftProposalResult struct {
+	commandReply []byte
+	err          error
+}
+
+// RaftStatus est l'état d'un nœud tel qu'affiché par INFO
+type RaftStatus struct {
+	NodeID        string
+	Role          string
+	CurrentTerm   int64
+	LeaderID      string
+	LeaderAddress string
+	CommitIndex   int64
+	LastApplied   int64
+	LastLogIndex  int64
+	SnapshotIndex int64
+	SnapshotTerm  int64
+	MemberCount   int
+	ElectionCount int64
+}
+
+// RaftNode est un membre d'un groupe Raft
+// Un mutex unique protège l'état du nœud ; les appels réseau, l'application des entrées et les fsync du
+// leader se font hors verrou. applyMutex sérialise l'application, la compaction et l'installation d'un snapshot.
+type RaftNode struct {
+	configuration RaftConfiguration
+	transport     RaftTransport
+	stateMachine  RaftStateMachine
+	logStore      *raftLogStore
+	otherPeers    []RaftPeer
+	peersByID     map[string]RaftPeer
+	quorumSize    int
+
+	nodeMutex         sync.Mutex
+	stateChanged      *sync.Cond
+	role              RaftRole
+	currentTerm       int64
+	votedFor          string
+	leaderID          string
+	logEntries        []RaftLogEntry // Entrées qui suivent le snapshot : logEntries[i].Index == snapshotIndex+1+i
+	snapshotIndex     int64
+	snapshotTerm      int64
+	snapshotFilePath  string
+	commitIndex       int64
+	lastApplied       int64
+	persistedIndex    int64 // Dernière entrée écrite et fsync localement
+	termStartIndex    int64 // Leader : index de l'entrée vide de son mandat
+	nextIndex         map[string]int64
+	matchIndex        map[string]int64
+	lastPeerContact   map[string]time.Time // Leader : dernière réponse reçue de chaque nœud
+	lastLeaderContact time.Time
+	electionDeadline  time.Time
+	pendingProposals  map[int64]*raftProposal
+	replicationSignal map[string]chan struct{}
+
+	applyMutex sync.Mutex
+
+	applySignal chan struct{}
+	syncSignal  chan struct{}
+	stopSignal  chan struct{}
+	stopOnce    sync.Once
+
+	snapshotThreshold atomic.Int64
+	electionCount     atomic.Int64
+}
+
+// NewRaftNode prépare un nœud ; Start relit l'état persistant et rejoint le groupe
+func NewRaftNode(configuration RaftConfiguration, transport RaftTransport, stateMachine RaftStateMachine) (*RaftNode, error) {
+	raftNode := &RaftNode{
+		configuration:     configuration,
+		transport:         transport,
+		stateMachine:      stateMachine,
+		peersByID:         make(map[string]RaftPeer),
+		pendingProposals:  make(map[int64]*raftProposal),
+		replicationSignal: make(map[string]chan struct{}),
+		applySignal:       make(chan struct{}, 1),
+		syncSignal:        make(chan struct{}, 1),
+		stopSignal:        make(chan struct{}),
+	}
+	raftNode.stateChanged = sync.NewCond(&raftNode.nodeMutex)
+	raftNode.snapshotThreshold.Store(configuration.SnapshotThreshold)
+
+	for _, raftPeer := range configuration.Peers {
+		raftNode.peersByID[raftPeer.NodeID] = raftPeer
+		if raftPeer.NodeID != configuration.NodeID {
+			raftNode.otherPeers = append(raftNode.otherPeers, raftPeer)
+		}
+	}
+	if _, isMember := raftNode.peersByID[configuration.NodeID]; !isMember {
+		return nil, fmt.Errorf("le nœud '%s' ne fait pas partie des membres déclarés", configuration.NodeID)
+	}
+	raftNode.quorumSize = len(configuration.Peers)/2 + 1
+	return raftNode, nil
+}
+
+// Start relit le snapshot, le journal et le vote, restaure l'état puis démarre les échanges avec le groupe
+// Les entrées du journal qui suivent le snapshot sont réappliquées dès qu'un leader confirme leur validation
+func (raftNode *RaftNode) Start() error {
+	logStore, persistedState, openError := openRaftLogStore(raftNode.configuration.DataDirectory)
+	if openError != nil {
+		return openError
+	}
+	if persistedState.snapshotFilePath != "" {
+		snapshotData, readError := os.ReadFile(persistedState.snapshotFilePath)
+		if readError != nil {
+			logStore.close()
+			return fmt.Errorf("lecture du snapshot raft: %v", readError)
+		}
+		if err := raftNode.stateMachine.Restore(snapshotData); err != nil {
+			logStore.close()
+			return fmt.Errorf("restauration du snapshot raft: %v", err)
+		}
+	}
+
+	raftNode.nodeMutex.Lock()
+	raftNode.logStore = logStore
+	raftNode.currentTerm = persistedState.currentTerm
+	raftNode.votedFor = persistedState.votedFor
+	raftNode.logEntries = persistedState.logEntries
+	raftNode.snapshotIndex = persistedState.snapshotIndex
+	raftNode.snapshotTerm = persistedState.snapshotTerm
+	raftNode.snapshotFilePath = persistedState.snapshotFilePath
+	raftNode.commitIndex = persistedState.snapshotIndex
+	raftNode.lastApplied = persistedState.snapshotIndex
+	raftNode.persistedIndex = raftNode.lastLogIndex()
+	raftNode.resetElectionDeadline()
+	raftNode.nodeMutex.Unlock()
+
+	if err := raftNode.transport.Serve(raftNode); err != nil {
+		logStore.close()
+		return err
+	}
+
+	go raftNode.runElectionTimer()
+	go raftNode.runApplier()
+	go raftNode.runLogSync()
+
+	log.Printf("🗳️ Nœud raft %s démarré : %d membres, mandat %d, snapshot à l'index %d, %d entrées dans le journal",
+		raftNode.configuration.NodeID, len(raftNode.configuration.Peers), persistedState.currentTerm,
+		persistedState.snapshotIndex, len(persistedState.logEntries))
+	return nil
+}
+
+// Stop arrête le nœud ; les écritures en attente échouent
+func (raftNode *RaftNode) Stop() {
+	raftNode.stopOnce.Do(func() {
+		close(raftNode.stopSignal)
+		raftNode.transport.Close()
+
+		raftNode.nodeMutex.Lock()
+		raftNode.role = RaftFollower
+		raftNode.failPendingProposals(ErrRaftStopped)
+		raftNode.stateChanged.Broadcast()
+		raftNode.nodeMutex.Unlock()
+
+		raftNode.applyMutex.Lock()
+		raftNode.logStore.close()
+		raftNode.applyMutex.Unlock()
+	})
+}
+
+// SetSnapshotThreshold modifie le seuil de compaction (0 = jamais)
+func (raftNode *RaftNode) SetSnapshotThreshold(snapshotThreshold int64) {
+	raftNode.snapshotThreshold.Store(snapshotThreshold)
+}
+
+// NodeID retourne l'identifiant du nœud local
+func (raftNode *RaftNode) NodeID() string {
+	return raftNode.configuration.NodeID
+}
+
+// Status retourne l'état courant du nœud
+func (raftNode *RaftNode) Status() RaftStatus {
+	raftNode.nodeMutex.Lock()
+	defer raftNode.nodeMutex.Unlock()
+	return RaftStatus{
+		NodeID:        raftNode.configuration.NodeID,
+		Role:          raftNode.role.String(),
+		CurrentTerm:   raftNode.currentTerm,
+		LeaderID:      raftNode.leaderID,
+		LeaderAddress: raftNode.peersByID[raftNode.leaderID].ClientAddress,
+		CommitIndex:   raftNode.commitIndex,
+		LastApplied:   raftNode.lastApplied,
+		LastLogIndex:  raftNode.lastLogIndex(),
+		SnapshotIndex: raftNode.snapshotIndex,
+		SnapshotTerm:  raftNode.snapshotTerm,
+		MemberCount:   len(raftNode.configuration.Peers),
+		ElectionCount: raftNode.electionCount.Load(),
+	}
+}
+
+// Propose ajoute une écriture au journal du leader et attend son application
+// La réponse est celle produite par l'application de l'entrée sur ce nœud
+func (raftNode *RaftNode) Propose(command []string) ([]byte, error) {
+	raftNode.nodeMutex.Lock()
+	if raftNode.role != RaftLeader {
+		notLeaderError := raftNode.notLeaderError()
+		raftNode.nodeMutex.Unlock()
+		return nil, notLeaderError
+	}
+	proposedEntry := RaftLogEntry{Index: raftNode.lastLogIndex() + 1, Term: raftNode.currentTerm, Command: command}
+	if err := raftNode.appendLeaderEntry(proposedEntry); err != nil {
+		raftNode.nodeMutex.Unlock()
+		return nil, err
+	}
+	proposal := &raftProposal{term: proposedEntry.Term, resultChannel: make(chan raftProposalResult, 1)}
+	raftNode.pendingProposals[proposedEntry.Index] = proposal
+	raftNode.signalReplicators()
+	raftNode.nodeMutex.Unlock()
+
+	select {
+	case proposalResult := <-proposal.resultChannel:
+		return proposalResult.commandReply, proposalResult.err
+	case <-raftNode.stopSignal:
+		return nil, ErrRaftStopped
+	}
+}
+
+// WaitLinearizableRead garantit qu'une lecture exécutée ensuite sur ce nœud voit toutes les écritures validées
+// (ReadIndex) : le leader retient son index de validation, confirme auprès d'une majorité qu'il est toujours
+// leader, puis attend que cet index soit appliqué
+func (raftNode *RaftNode) WaitLinearizableRead(waitTimeout time.Duration) error {
+	raftNode.nodeMutex.Lock()
+	defer raftNode.nodeMutex.Unlock()
+	if raftNode.role != RaftLeader {
+		return raftNode.notLeaderError()
+	}
+
+	waitDeadline := time.Now().Add(waitTimeout)
+	deadlineTimer := time.AfterFunc(waitTimeout, func() {
+		raftNode.nodeMutex.Lock()
+		raftNode.stateChanged.Broadcast()
+		raftNode.nodeMutex.Unlock()
+	})
+	defer deadlineTimer.Stop()
+
+	readTerm := raftNode.currentTerm
+	checkWaitCondition := func() error {
+		if raftNode.role != RaftLeader || raftNode.currentTerm != readTerm {
+			return raftNode.notLeaderError()
+		}
+		if !time.Now().Before(waitDeadline) {
+			return ErrRaftTimeout
+		}
+		return nil
+	}
+
+	// Un nouveau leader ne connaît l'index de validation qu'une fois l'entrée vide de son mandat validée
+	for raftNode.commitIndex < raftNode.termStartIndex {
+		if err := checkWaitCondition(); err != nil {
+			return err
+		}
+		raftNode.stateChanged.Wait()
+	}
+	readIndex := raftNode.commitIndex
+
+	confirmationRequestTime := time.Now()
+	raftNode.signalReplicators()
+	for raftNode.countPeerContactsSince(confirmationRequestTime) < raftNode.quorumSize {
+		if err := checkWaitCondition(); err != nil {
+			return err
+		}
+		raftNode.stateChanged.Wait()
+	}
+
+	for raftNode.lastApplied < readIndex {
+		if err := checkWaitCondition(); err != nil {
+			return err
+		}
+		raftNode.stateChanged.Wait()
+	}
+	return nil
+}
+
+// lastLogIndex retourne l'index de la dernière entrée (verrou tenu)
+func (raftNode *RaftNode) lastLogIndex() int64 {
+	if len(raftNode.logEntries) == 0 {
+		return raftNode.snapshotIndex
+	}
+	return raftNode.logEntries[len(raftNode.logEntries)-1].Index
+}
+
+// lastLogTerm retourne le mandat de la dernière entrée (verrou tenu)
+func (raftNode *RaftNode) lastLogTerm() int64 {
+	if len(raftNode.logEntries) == 0 {
+		return raftNode.snapshotTerm
+	}
+	return raftNode.logEntries[len(raftNode.logEntries)-1].Term
+}
+
+// termAt retourne le mandat de l'entrée index ; false si elle est compactée ou absente (verrou tenu)
+func (raftNode *RaftNode) termAt(entryIndex int64) (int64, bool) {
+	if entryIndex == raftNode.snapshotIndex {
+		return raftNode.snapshotTerm, true
+	}
+	entryPosition := entryIndex - raftNode.snapshotIndex - 1
+	if entryPosition < 0 || entryPosition >= int64(len(raftNode.logEntries)) {
+		return 0, false
+	}
+	return raftNode.logEntries[entryPosition].Term, true
+}
+
+// entriesBetween copie les entrées de firstIndex à lastIndex inclus (verrou tenu)
+func (raftNode *RaftNode) entriesBetween(firstIndex, lastIndex int64) []RaftLogEntry {
+	if lastIndex < firstIndex {
+		return nil
+	}
+	firstPosition := firstIndex - raftNode.snapshotIndex - 1
+	lastPosition := lastIndex - raftNode.snapshotIndex - 1
+	return append([]RaftLogEntry(nil), raftNode.logEntries[firstPosition:lastPosition+1]...)
+}
+
+// notLeaderError décrit le leader connu (verrou tenu)
+func (raftNode *RaftNode) notLeaderError() *NotLeaderError {
+	return &NotLeaderError{
+		LeaderID:      raftNode.leaderID,
+		LeaderAddress: raftNode.peersByID[raftNode.leaderID].ClientAddress,
+	}
+}
+
+// resetElectionDeadline tire un nouveau délai d'élection entre ElectionTimeout et 2×ElectionTimeout (verrou tenu)
+func (raftNode *RaftNode) resetElectionDeadline() {
+	electionTimeout := raftNode.configuration.ElectionTimeout
+	raftNode.electionDeadline = time.Now().Add(electionTimeout + time.Duration(rand.Int63n(int64(electionTimeout))))
+}
+
+// persistState écrit le mandat et le vote avant toute réponse qui en dépend (verrou tenu)
+func (raftNode *RaftNode) persistState() error {
+	if err := raftNode.logStore.saveState(raftNode.currentTerm, raftNode.votedFor); err != nil {
+		log.Printf("🚨 Impossible d'écrire l'état raft: %v", err)
+		return err
+	}
+	return nil
+}
+
+// signalReplicators réveille les goroutines de réplication du leader (verrou tenu)
+func (raftNode *RaftNode) signalReplicators() {
+	for _, replicationSignal := range raftNode.replicationSignal {
+		select {
+		case replicationSignal <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// signalApplier réveille la goroutine d'application
+func (raftNode *RaftNode) signalApplier() {
+	select {
+	case raftNode.applySignal <- struct{}{}:
+	default:
+	}
+}
+
+// failPendingProposals termine en erreur toutes les écritures en attente (verrou tenu)
+func (raftNode *RaftNode) failPendingProposals(failure error) {
+	for entryIndex, proposal := range raftNode.pendingProposals {
+		proposal.resultChannel <- raftProposalResult{err: failure}
+		delete(raftNode.pendingProposals, entryIndex)
+	}
+}
+
+// countPeerContactsSince compte les membres (leader compris) qui ont répondu depuis sinceTime (verrou tenu)
+func (raftNode *RaftNode) countPeerContactsSince(sinceTime time.Time) int {
+	contactCount := 1
+	for _, raftPeer := range raftNode.otherPeers {
+		if raftNode.lastPeerContact[raftPeer.NodeID].After(sinceTime) {
+			contactCount++
+		}
+	}
+	return contactCount
+}
+
+// becomeFollower passe suiveur, dans un mandat plus récent le cas échéant (verrou tenu)
+func (raftNode *RaftNode) becomeFollower(newTerm int64) {
+	if newTerm > raftNode.currentTerm {
+		raftNode.currentTerm = newTerm
+		raftNode.votedFor = ""
+		raftNode.leaderID = ""
+		raftNode.persistState()
+	}
+	if raftNode.role == RaftLeader {
+		log.Printf("🗳️ Nœud raft %s : fin du leadership (mandat %d)", raftNode.configuration.NodeID, raftNode.currentTerm)
+		raftNode.leaderID = ""
+		raftNode.failPendingProposals(ErrLeadershipLost)
+		raftNode.replicationSignal = make(map[string]chan struct{})
+		raftNode.resetElectionDeadline()
+	}
+	raftNode.role = RaftFollower
+	raftNode.stateChanged.Broadcast()
+}
+
+// becomeLeader prend le leadership du mandat courant (verrou tenu)
+// L'entrée vide ajoutée au journal permet de valider les entrées laissées par les mandats précédents
+func (raftNode *RaftNode) becomeLeader() {
+	raftNode.role = RaftLeader
+	raftNode.leaderID = raftNode.configuration.NodeID
+	log.Printf("👑 Nœud raft %s élu leader pour le mandat %d", raftNode.configuration.NodeID, raftNode.currentTerm)
+
+	leaderStartTime := time.Now()
+	raftNode.nextIndex = make(map[string]int64)
+	raftNode.matchIndex = make(map[string]int64)
+	raftNode.lastPeerContact = make(map[string]time.Time)
+	for _, raftPeer := range raftNode.otherPeers {
+		raftNode.nextIndex[raftPeer.NodeID] = raftNode.lastLogIndex() + 1
+		raftNode.lastPeerContact[raftPeer.NodeID] = leaderStartTime
+	}
+
+	leaderEntry := RaftLogEntry{Index: raftNode.lastLogIndex() + 1, Term: raftNode.currentTerm}
+	raftNode.termStartIndex = leaderEntry.Index
+	if err := raftNode.appendLeaderEntry(leaderEntry); err != nil {
+		raftNode.becomeFollower(raftNode.currentTerm)
+		return
+	}
+
+	raftNode.replicationSignal = make(map[string]chan struct{})
+	for _, raftPeer := range raftNode.otherPeers {
+		replicationSignal := make(chan struct{}, 1)
+		raftNode.replicationSignal[raftPeer.NodeID] = replicationSignal
+		go raftNode.runReplicator(raftPeer, raftNode.currentTerm, replicationSignal)
+	}
+	raftNode.stateChanged.Broadcast()
+}
+
+// appendLeaderEntry ajoute une entrée au journal du leader ; le fsync est fait par runLogSync (verrou tenu)
+func (raftNode *RaftNode) appendLeaderEntry(logEntry RaftLogEntry) error {
+	if err := raftNode.logStore.appendEntries([]RaftLogEntry{logEntry}); err != nil {
+		log.Printf("🚨 Nœud raft %s : %v", raftNode.configuration.NodeID, err)
+		return err
+	}
+	raftNode.logEntries = append(raftNode.logEntries, logEntry)
+	select {
+	case raftNode.syncSignal <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// advanceCommitIndex valide la dernière entrée du mandat courant présente sur une majorité (verrou tenu)
+// Une entrée d'un mandat précédent n'est jamais validée par comptage : elle l'est avec celles du mandat courant
+func (raftNode *RaftNode) advanceCommitIndex() {
+	for candidateIndex := raftNode.lastLogIndex(); candidateIndex > raftNode.commitIndex; candidateIndex-- {
+		if entryTerm, _ := raftNode.termAt(candidateIndex); entryTerm != raftNode.currentTerm {
+			return
+		}
+		replicaCount := 0
+		if raftNode.persistedIndex >= candidateIndex {
+			replicaCount++
+		}
+		for _, raftPeer := range raftNode.otherPeers {
+			if raftNode.matchIndex[raftPeer.NodeID] >= candidateIndex {
+				replicaCount++
+			}
+		}
+		if replicaCount >= raftNode.quorumSize {
+			raftNode.commitIndex = candidateIndex
+			raftNode.signalApplier()
+			raftNode.signalReplicators() // Les suiveurs apprennent la validation sans attendre le heartbeat
+			raftNode.stateChanged.Broadcast()
+			return
+		}
+	}
+}
+
+// runElectionTimer déclenche une élection quand le leader se tait, et destitue un leader coupé de la majorité
+func (raftNode *RaftNode) runElectionTimer() {
+	timerTicker := time.NewTicker(raftNode.configuration.HeartbeatInterval / 2)
+	defer timerTicker.Stop()
+	for {
+		select {
+		case <-raftNode.stopSignal:
+			return
+		case <-timerTicker.C:
+		}
+
+		raftNode.nodeMutex.Lock()
+		currentTime := time.Now()
+		if raftNode.role == RaftLeader {
+			quorumSince := currentTime.Add(-raftNode.configuration.ElectionTimeout)
+			if raftNode.countPeerContactsSince(quorumSince) < raftNode.quorumSize {
+				log.Printf("⚠️ Nœud raft %s : plus de contact avec la majorité, abandon du leadership", raftNode.configuration.NodeID)
+				raftNode.becomeFollower(raftNode.currentTerm)
+			}
+		} else if currentTime.After(raftNode.electionDeadline) {
+			raftNode.startElection()
+		}
+		raftNode.nodeMutex.Unlock()
+	}
+}
+
+// startElection ouvre un nouveau mandat et demande le vote des autres membres (verrou tenu)
+func (raftNode *RaftNode) startElection() {
+	raftNode.resetElectionDeadline()
+	raftNode.role = RaftCandidate
+	raftNode.currentTerm++
+	raftNode.votedFor = raftNode.configuration.NodeID
+	raftNode.leaderID = ""
+	if err := raftNode.persistState(); err != nil {
+		raftNode.role = RaftFollower
+		return
+	}
+	raftNode.electionCount.Add(1)
+	log.Printf("🗳️ Nœud raft %s candidat pour le mandat %d", raftNode.configuration.NodeID, raftNode.currentTerm)
+
+	if raftNode.quorumSize == 1 {
+		raftNode.becomeLeader()
+		return
+	}
+
+	electionTerm := raftNode.currentTerm
+	voteRequest := &RequestVoteRequest{
+		Term:         electionTerm,
+		CandidateID:  raftNode.configuration.NodeID,
+		LastLogIndex: raftNode.lastLogIndex(),
+		LastLogTerm:  raftNode.lastLogTerm(),
+	}
+	receivedVotes := 1
+	for _, raftPeer := range raftNode.otherPeers {
+		go func(raftPeer RaftPeer) {
+			voteResponse, sendError := raftNode.transport.SendRequestVote(raftPeer, voteRequest)
+			if sendError != nil {
+				return
+			}
+			raftNode.nodeMutex.Lock()
+			defer raftNode.nodeMutex.Unlock()
+			if voteResponse.Term > raftNode.currentTerm {
+				raftNode.becomeFollower(voteResponse.Term)
+				return
+			}
+			if raftNode.role != RaftCandidate || raftNode.currentTerm != electionTerm || !voteResponse.VoteGranted {
+				return
+			}
+			receivedVotes++
+			if receivedVotes >= raftNode.quorumSize {
+				raftNode.becomeLeader()
+			}
+		}(raftPeer)
+	}
+}
+
+// runReplicator envoie au membre les entrées qui lui manquent, ou un heartbeat, tant que le mandat dure
+func (raftNode *RaftNode) runReplicator(raftPeer RaftPeer, leaderTerm int64, replicationSignal chan struct{}) {
+	heartbeatTicker := time.NewTicker(raftNode.configuration.HeartbeatInterval)
+	defer heartbeatTicker.Stop()
+	for {
+		stillLeader, moreToSend := raftNode.replicateToPeer(raftPeer, leaderTerm)
+		if !stillLeader {
+			return
+		}
+		if moreToSend {
+			select {
+			case <-raftNode.stopSignal:
+				return
+			default:
+				continue
+			}
+		}
+		select {
+		case <-raftNode.stopSignal:
+			return
+		case <-replicationSignal:
+		case <-heartbeatTicker.C:
+		}
+	}
+}
+
+// replicateToPeer fait un envoi vers un membre : AppendEntries, ou InstallSnapshot si les entrées
+// dont il a besoin sont compactées. Retourne si le nœud est toujours leader et s'il reste à envoyer.
+func (raftNode *RaftNode) replicateToPeer(raftPeer RaftPeer, leaderTerm int64) (bool, bool) {
+	raftNode.nodeMutex.Lock()
+	if raftNode.role != RaftLeader || raftNode.currentTerm != leaderTerm {
+		raftNode.nodeMutex.Unlock()
+		return false, false
+	}
+	nextIndex := raftNode.nextIndex[raftPeer.NodeID]
+	if nextIndex <= raftNode.snapshotIndex {
+		snapshotRequest := &InstallSnapshotRequest{
+			Term:              leaderTerm,
+			LeaderID:          raftNode.configuration.NodeID,
+			LastIncludedIndex: raftNode.snapshotIndex,
+			LastIncludedTerm:  raftNode.snapshotTerm,
+		}
+		snapshotFilePath := raftNode.snapshotFilePath
+		raftNode.nodeMutex.Unlock()
+		return raftNode.sendSnapshotToPeer(raftPeer, leaderTerm, snapshotRequest, snapshotFilePath)
+	}
+
+	previousIndex := nextIndex - 1
+	previousTerm, _ := raftNode.termAt(previousIndex)
+	lastIndexToSend := raftNode.lastLogIndex()
+	if lastIndexToSend-previousIndex > maximumEntriesPerAppend {
+		lastIndexToSend = previousIndex + maximumEntriesPerAppend
+	}
+	appendRequest := &AppendEntriesRequest{
+		Term:         leaderTerm,
+		LeaderID:     raftNode.configuration.NodeID,
+		PrevLogIndex: previousIndex,
+		PrevLogTerm:  previousTerm,
+		Entries:      raftNode.entriesBetween(nextIndex, lastIndexToSend),
+		LeaderCommit: raftNode.commitIndex,
+	}
+	raftNode.nodeMutex.Unlock()
+
+	appendResponse, sendError := raftNode.transport.SendAppendEntries(raftPeer, appendRequest)
+
+	raftNode.nodeMutex.Lock()
+	defer raftNode.nodeMutex.Unlock()
+	if sendError != nil {
+		return raftNode.role == RaftLeader && raftNode.currentTerm == leaderTerm, false
+	}
+	if appendResponse.Term > raftNode.currentTerm {
+		raftNode.becomeFollower(appendResponse.Term)
+		return false, false
+	}
+	if raftNode.role != RaftLeader || raftNode.currentTerm != leaderTerm {
+		return false, false
+	}
+	raftNode.lastPeerContact[raftPeer.NodeID] = time.Now()
+	raftNode.stateChanged.Broadcast()
+
+	if appendResponse.Success {
+		matchedIndex := previousIndex + int64(len(appendRequest.Entries))
+		if matchedIndex > raftNode.matchIndex[raftPeer.NodeID] {
+			raftNode.matchIndex[raftPeer.NodeID] = matchedIndex
+			raftNode.advanceCommitIndex()
+		}
+		raftNode.nextIndex[raftPeer.NodeID] = matchedIndex + 1
+		return true, matchedIndex < raftNode.lastLogIndex()
+	}
+
+	// Journal divergent : reprise à l'index indiqué par le membre
+	retryIndex := nextIndex - 1
+	if appendResponse.ConflictIndex > 0 && appendResponse.ConflictIndex < nextIndex {
+		retryIndex = appendResponse.ConflictIndex
+	}
+	if retryIndex < 1 {
+		retryIndex = 1
+	}
+	raftNode.nextIndex[raftPeer.NodeID] = retryIndex
+	return true, true
+}
+
+// sendSnapshotToPeer transmet le dernier snapshot à un membre trop en retard
+func (raftNode *RaftNode) sendSnapshotToPeer(raftPeer RaftPeer, leaderTerm int64, snapshotRequest *InstallSnapshotRequest, snapshotFilePath string) (bool, bool) {
+	snapshotData, readError := os.ReadFile(snapshotFilePath)
+	if readError != nil {
+		// Le snapshot vient d'être remplacé par une compaction : le prochain envoi prendra le nouveau
+		return true, false
+	}
+	snapshotRequest.SnapshotData = snapshotData
+
+	snapshotResponse, sendError := raftNode.transport.SendInstallSnapshot(raftPeer, snapshotRequest)
+
+	raftNode.nodeMutex.Lock()
+	defer raftNode.nodeMutex.Unlock()
+	if sendError != nil {
+		return raftNode.role == RaftLeader && raftNode.currentTerm == leaderTerm, false
+	}
+	if snapshotResponse.Term > raftNode.currentTerm {
+		raftNode.becomeFollower(snapshotResponse.Term)
+		return false, false
+	}
+	if raftNode.role != RaftLeader || raftNode.currentTerm != leaderTerm {
+		return false, false
+	}
+	raftNode.lastPeerContact[raftPeer.NodeID] = time.Now()
+	log.Printf("📤 Nœud raft %s : snapshot (index %d, %d octets) transmis à %s",
+		raftNode.configuration.NodeID, snapshotRequest.LastIncludedIndex, len(snapshotData), raftPeer.NodeID)
+	if snapshotRequest.LastIncludedIndex > raftNode.matchIndex[raftPeer.NodeID] {
+		raftNode.matchIndex[raftPeer.NodeID] = snapshotRequest.LastIncludedIndex
+		raftNode.advanceCommitIndex()
+	}
+	raftNode.nextIndex[raftPeer.NodeID] = snapshotRequest.LastIncludedIndex + 1
+	raftNode.stateChanged.Broadcast()
+	return true, true
+}
+
+// runLogSync fsync le journal du leader par lots : toutes les écritures proposées pendant un fsync partagent le suivant
+func (raftNode *RaftNode) runLogSync() {
+	for {
+		select {
+		case <-raftNode.stopSignal:
+			return
+		case <-raftNode.syncSignal:
+		}
+
+		raftNode.nodeMutex.Lock()
+		targetIndex := raftNode.lastLogIndex()
+		raftNode.nodeMutex.Unlock()
+
+		syncError := raftNode.logStore.sync()
+
+		raftNode.nodeMutex.Lock()
+		if syncError != nil {
+			log.Printf("🚨 Nœud raft %s : %v", raftNode.configuration.NodeID, syncError)
+		} else if targetIndex > raftNode.persistedIndex {
+			raftNode.persistedIndex = min(targetIndex, raftNode.lastLogIndex())
+			if raftNode.role == RaftLeader {
+				raftNode.advanceCommitIndex()
+			}
+		}
+		raftNode.nodeMutex.Unlock()
+	}
+}
+
+// runApplier applique les entrées validées dans l'ordre du journal et répond aux écritures en attente
+func (raftNode *RaftNode) runApplier() {
+	for {
+		select {
+		case <-raftNode.stopSignal:
+			return
+		case <-raftNode.applySignal:
+		}
+		raftNode.applyCommittedEntries()
+		raftNode.compactLogIfNeeded()
+	}
+}
+
+// applyCommittedEntries applique les entrées validées pas encore appliquées
+func (raftNode *RaftNode) applyCommittedEntries() {
+	raftNode.applyMutex.Lock()
+	defer raftNode.applyMutex.Unlock()
+
+	raftNode.nodeMutex.Lock()
+	entriesToApply := raftNode.entriesBetween(raftNode.lastApplied+1, raftNode.commitIndex)
+	raftNode.nodeMutex.Unlock()
+
+	for _, committedEntry := range entriesToApply {
+		var commandReply []byte
+		if committedEntry.Command != nil {
+			commandReply = raftNode.stateMachine.Apply(committedEntry.Command)
+		}
+
+		raftNode.nodeMutex.Lock()
+		raftNode.lastApplied = committedEntry.Index
+		if proposal, exists := raftNode.pendingProposals[committedEntry.Index]; exists {
+			delete(raftNode.pendingProposals, committedEntry.Index)
+			if proposal.term == committedEntry.Term {
+				proposal.resultChannel <- raftProposalResult{commandReply: commandReply}
+			} else {
+				proposal.resultChannel <- raftProposalResult{err: ErrLeadershipLost}
+			}
+		}
+		raftNode.stateChanged.Broadcast()
+		raftNode.nodeMutex.Unlock()
+	}
+}
+
+// compactLogIfNeeded remplace les entrées appliquées par un snapshot une fois le seuil atteint
+// Le snapshot est écrit avant la réécriture du journal : un crash entre les deux laisse des entrées déjà
+// couvertes, ignorées au redémarrage
+func (raftNode *RaftNode) compactLogIfNeeded() {
+	snapshotThreshold := raftNode.snapshotThreshold.Load()
+	if snapshotThreshold <= 0 {
+		return
+	}
+	raftNode.applyMutex.Lock()
+	defer raftNode.applyMutex.Unlock()
+
+	raftNode.nodeMutex.Lock()
+	snapshotIndex := raftNode.lastApplied
+	snapshotTerm, _ := raftNode.termAt(snapshotIndex)
+	previousSnapshotPath := raftNode.snapshotFilePath
+	shouldCompact := snapshotIndex-raftNode.snapshotIndex >= snapshotThreshold
+	raftNode.nodeMutex.Unlock()
+	if !shouldCompact {
+		return
+	}
+
+	compactionStart := time.Now()
+	snapshotData, snapshotError := raftNode.stateMachine.Snapshot()
+	if snapshotError != nil {
+		log.Printf("🚨 Nœud raft %s : snapshot impossible: %v", raftNode.configuration.NodeID, snapshotError)
+		return
+	}
+	snapshotFilePath, saveError := raftNode.logStore.saveSnapshot(snapshotIndex, snapshotTerm, snapshotData, previousSnapshotPath)
+	if saveError != nil {
+		log.Printf("🚨 Nœud raft %s : %v", raftNode.configuration.NodeID, saveError)
+		return
+	}
+
+	raftNode.nodeMutex.Lock()
+	defer raftNode.nodeMutex.Unlock()
+	remainingEntries := raftNode.entriesBetween(snapshotIndex+1, raftNode.lastLogIndex())
+	if err := raftNode.logStore.rewrite(remainingEntries); err != nil {
+		log.Printf("🚨 Nœud raft %s : %v", raftNode.configuration.NodeID, err)
+		return
+	}
+	raftNode.logEntries = remainingEntries
+	raftNode.snapshotIndex = snapshotIndex
+	raftNode.snapshotTerm = snapshotTerm
+	raftNode.snapshotFilePath = snapshotFilePath
+	raftNode.persistedIndex = raftNode.lastLogIndex()
+	log.Printf("📸 Nœud raft %s : journal compacté jusqu'à l'index %d (%d octets, %v)",
+		raftNode.configuration.NodeID, snapshotIndex, len(snapshotData), time.Since(compactionStart))
+}
+
+// HandleRequestVote traite une demande de vote
+// Un membre qui a des nouvelles récentes du leader refuse sans changer de mandat : un nœud isolé qui
+// revient avec un mandat gonflé ne peut pas destituer un leader qui fonctionne
+func (raftNode *RaftNode) HandleRequestVote(voteRequest *RequestVoteRequest) *RequestVoteResponse {
+	raftNode.nodeMutex.Lock()
+	defer raftNode.nodeMutex.Unlock()
+
+	voteResponse := &RequestVoteResponse{Term: raftNode.currentTerm}
+	if voteRequest.Term < raftNode.currentTerm {
+		return voteResponse
+	}
+	leaderIsAlive := raftNode.role == RaftLeader ||
+		(raftNode.leaderID != "" && time.Since(raftNode.lastLeaderContact) < raftNode.configuration.ElectionTimeout)
+	if voteRequest.Term > raftNode.currentTerm && leaderIsAlive {
+		return voteResponse
+	}
+	if voteRequest.Term > raftNode.currentTerm {
+		raftNode.becomeFollower(voteRequest.Term)
+	}
+	voteResponse.Term = raftNode.currentTerm
+
+	candidateIsUpToDate := voteRequest.LastLogTerm > raftNode.lastLogTerm() ||
+		(voteRequest.LastLogTerm == raftNode.lastLogTerm() && voteRequest.LastLogIndex >= raftNode.lastLogIndex())
+	if (raftNode.votedFor == "" || raftNode.votedFor == voteRequest.CandidateID) && candidateIsUpToDate {
+		raftNode.votedFor = voteRequest.CandidateID
+		if raftNode.persistState() != nil {
+			return voteResponse
+		}
+		raftNode.resetElectionDeadline()
+		voteResponse.VoteGranted = true
+	}
+	return voteResponse
+}
+
+// HandleAppendEntries traite un envoi d'entrées du leader ; elles sont fsync avant la réponse
+func (raftNode *RaftNode) HandleAppendEntries(appendRequest *AppendEntriesRequest) *AppendEntriesResponse {
+	raftNode.nodeMutex.Lock()
+	defer raftNode.nodeMutex.Unlock()
+
+	appendResponse := &AppendEntriesResponse{Term: raftNode.currentTerm}
+	if appendRequest.Term < raftNode.currentTerm {
+		return appendResponse
+	}
+	if appendRequest.Term > raftNode.currentTerm || raftNode.role != RaftFollower {
+		raftNode.becomeFollower(appendRequest.Term)
+	}
+	raftNode.leaderID = appendRequest.LeaderID
+	raftNode.lastLeaderContact = time.Now()
+	raftNode.resetElectionDeadline()
+	appendResponse.Term = raftNode.currentTerm
+
+	previousIndex, previousTerm, receivedEntries := appendRequest.PrevLogIndex, appendRequest.PrevLogTerm, appendRequest.Entries
+	if previousIndex < raftNode.snapshotIndex {
+		// Les entrées couvertes par le snapshot sont validées : seules les suivantes sont examinées
+		coveredCount := raftNode.snapshotIndex - previousIndex
+		if coveredCount >= int64(len(receivedEntries)) {
+			receivedEntries = nil
+		} else {
+			receivedEntries = receivedEntries[coveredCount:]
+		}
+		previousIndex, previousTerm = raftNode.snapshotIndex, raftNode.snapshotTerm
+	}
+	if previousIndex > raftNode.lastLogIndex() {
+		appendResponse.ConflictIndex = raftNode.lastLogIndex() + 1
+		return appendResponse
+	}
+	if localTerm, _ := raftNode.termAt(previousIndex); localTerm != previousTerm {
+		// Le leader reprendra au début du mandat divergent
+		conflictIndex := previousIndex
+		for conflictIndex-1 > raftNode.snapshotIndex {
+			if earlierTerm, _ := raftNode.termAt(conflictIndex - 1); earlierTerm != localTerm {
+				break
+			}
+			conflictIndex--
+		}
+		appendResponse.ConflictIndex = conflictIndex
+		return appendResponse
+	}
+
+	for entryPosition, receivedEntry := range receivedEntries {
+		if receivedEntry.Index <= raftNode.lastLogIndex() {
+			if localTerm, _ := raftNode.termAt(receivedEntry.Index); localTerm == receivedEntry.Term {
+				continue
+			}
+			// Entrées divergentes (jamais validées) : le journal est tronqué avant d'ajouter celles du leader
+			raftNode.logEntries = raftNode.entriesBetween(raftNode.snapshotIndex+1, receivedEntry.Index-1)
+			if err := raftNode.logStore.rewrite(raftNode.logEntries); err != nil {
+				log.Printf("🚨 Nœud raft %s : %v", raftNode.configuration.NodeID, err)
+				return appendResponse
+			}
+			raftNode.persistedIndex = raftNode.lastLogIndex()
+		}
+		newEntries := append([]RaftLogEntry(nil), receivedEntries[entryPosition:]...)
+		if err := raftNode.logStore.appendEntries(newEntries); err != nil {
+			log.Printf("🚨 Nœud raft %s : %v", raftNode.configuration.NodeID, err)
+			return appendResponse
+		}
+		raftNode.logEntries = append(raftNode.logEntries, newEntries...)
+		if err := raftNode.logStore.sync(); err != nil {
+			log.Printf("🚨 Nœud raft %s : %v", raftNode.configuration.NodeID, err)
+			return appendResponse
+		}
+		raftNode.persistedIndex = raftNode.lastLogIndex()
+		break
+	}
+
+	lastReceivedIndex := previousIndex + int64(len(receivedEntries))
+	if appendRequest.LeaderCommit > raftNode.commitIndex {
+		raftNode.commitIndex = min(appendRequest.LeaderCommit, lastReceivedIndex)
+		raftNode.signalApplier()
+	}
+	appendResponse.Success = true
+	return appendResponse
+}
+
+// HandleInstallSnapshot remplace l'état local par le snapshot du leader
+// Les entrées qui suivent le snapshot sont conservées si le journal local lui est cohérent
+func (raftNode *RaftNode) HandleInstallSnapshot(snapshotRequest *InstallSnapshotRequest) *InstallSnapshotResponse {
+	raftNode.nodeMutex.Lock()
+	snapshotResponse := &InstallSnapshotResponse{Term: raftNode.currentTerm}
+	if snapshotRequest.Term < raftNode.currentTerm {
+		raftNode.nodeMutex.Unlock()
+		return snapshotResponse
+	}
+	if snapshotRequest.Term > raftNode.currentTerm || raftNode.role != RaftFollower {
+		raftNode.becomeFollower(snapshotRequest.Term)
+	}
+	raftNode.leaderID = snapshotRequest.LeaderID
+	raftNode.lastLeaderContact = time.Now()
+	raftNode.resetElectionDeadline()
+	snapshotResponse.Term = raftNode.currentTerm
+	raftNode.nodeMutex.Unlock()
+
+	raftNode.applyMutex.Lock()
+	defer raftNode.applyMutex.Unlock()
+
+	raftNode.nodeMutex.Lock()
+	alreadyCovered := snapshotRequest.LastIncludedIndex <= raftNode.snapshotIndex || snapshotRequest.LastIncludedIndex <= raftNode.lastApplied
+	previousSnapshotPath := raftNode.snapshotFilePath
+	raftNode.nodeMutex.Unlock()
+	if alreadyCovered {
+		return snapshotResponse
+	}
+
+	snapshotFilePath, saveError := raftNode.logStore.saveSnapshot(snapshotRequest.LastIncludedIndex, snapshotRequest.LastIncludedTerm,
+		snapshotRequest.SnapshotData, previousSnapshotPath)
+	if saveError != nil {
+		log.Printf("🚨 Nœud raft %s : %v", raftNode.configuration.NodeID, saveError)
+		return snapshotResponse
+	}
+	if err := raftNode.stateMachine.Restore(snapshotRequest.SnapshotData); err != nil {
+		log.Printf("🚨 Nœud raft %s : restauration du snapshot reçu impossible: %v", raftNode.configuration.NodeID, err)
+		return snapshotResponse
+	}
+
+	raftNode.nodeMutex.Lock()
+	defer raftNode.nodeMutex.Unlock()
+	var remainingEntries []RaftLogEntry
+	if localTerm, exists := raftNode.termAt(snapshotRequest.LastIncludedIndex); exists && localTerm == snapshotRequest.LastIncludedTerm {
+		remainingEntries = raftNode.entriesBetween(snapshotRequest.LastIncludedIndex+1, raftNode.lastLogIndex())
+	}
+	if err := raftNode.logStore.rewrite(remainingEntries); err != nil {
+		log.Printf("🚨 Nœud raft %s : %v", raftNode.configuration.NodeID, err)
+	}
+	raftNode.logEntries = remainingEntries
+	raftNode.snapshotIndex = snapshotRequest.LastIncludedIndex
+	raftNode.snapshotTerm = snapshotRequest.LastIncludedTerm
+	raftNode.snapshotFilePath = snapshotFilePath
+	raftNode.lastApplied = snapshotRequest.LastIncludedIndex
+	raftNode.commitIndex = max(raftNode.commitIndex, snapshotRequest.LastIncludedIndex)
+	raftNode.persistedIndex = raftNode.lastLogIndex()
+	raftNode.stateChanged.Broadcast()
+	log.Printf("📥 Nœud raft %s : snapshot du leader installé (index %d, %d octets)",
+		raftNode.configuration.NodeID, snapshotRequest.LastIncludedIndex, len(snapshotRequest.SnapshotData))
+	return snapshotResponse
+}
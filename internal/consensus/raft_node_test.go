@@ -0,0 +1,394 @@
+package consensus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// inMemoryRaftNetwork relie des nœuds d'un même processus ; une partition coupe un nœud de tous les autres
+type inMemoryRaftNetwork struct {
+	networkMutex  sync.Mutex
+	rpcHandlers   map[string]RaftRPCHandler
+	isolatedNodes map[string]bool
+}
+
+func newInMemoryRaftNetwork() *inMemoryRaftNetwork {
+	return &inMemoryRaftNetwork{rpcHandlers: make(map[string]RaftRPCHandler), isolatedNodes: make(map[string]bool)}
+}
+
+func (raftNetwork *inMemoryRaftNetwork) isolate(nodeID string) {
+	raftNetwork.networkMutex.Lock()
+	defer raftNetwork.networkMutex.Unlock()
+	raftNetwork.isolatedNodes[nodeID] = true
+}
+
+func (raftNetwork *inMemoryRaftNetwork) heal(nodeID string) {
+	raftNetwork.networkMutex.Lock()
+	defer raftNetwork.networkMutex.Unlock()
+	delete(raftNetwork.isolatedNodes, nodeID)
+}
+
+// route retourne le handler du destinataire, ou une erreur si le lien est coupé
+func (raftNetwork *inMemoryRaftNetwork) route(sourceNodeID, targetNodeID string) (RaftRPCHandler, error) {
+	raftNetwork.networkMutex.Lock()
+	defer raftNetwork.networkMutex.Unlock()
+	if raftNetwork.isolatedNodes[sourceNodeID] || raftNetwork.isolatedNodes[targetNodeID] {
+		return nil, fmt.Errorf("lien %s -> %s coupé", sourceNodeID, targetNodeID)
+	}
+	rpcHandler, isServing := raftNetwork.rpcHandlers[targetNodeID]
+	if !isServing {
+		return nil, fmt.Errorf("nœud %s arrêté", targetNodeID)
+	}
+	return rpcHandler, nil
+}
+
+// inMemoryRaftTransport est le transport d'un nœud sur le réseau en mémoire
+type inMemoryRaftTransport struct {
+	raftNetwork *inMemoryRaftNetwork
+	localNodeID string
+}
+
+func (memoryTransport *inMemoryRaftTransport) SendRequestVote(targetPeer RaftPeer, request *RequestVoteRequest) (*RequestVoteResponse, error) {
+	rpcHandler, routeError := memoryTransport.raftNetwork.route(memoryTransport.localNodeID, targetPeer.NodeID)
+	if routeError != nil {
+		return nil, routeError
+	}
+	return rpcHandler.HandleRequestVote(request), nil
+}
+
+func (memoryTransport *inMemoryRaftTransport) SendAppendEntries(targetPeer RaftPeer, request *AppendEntriesRequest) (*AppendEntriesResponse, error) {
+	rpcHandler, routeError := memoryTransport.raftNetwork.route(memoryTransport.localNodeID, targetPeer.NodeID)
+	if routeError != nil {
+		return nil, routeError
+	}
+	return rpcHandler.HandleAppendEntries(request), nil
+}
+
+func (memoryTransport *inMemoryRaftTransport) SendInstallSnapshot(targetPeer RaftPeer, request *InstallSnapshotRequest) (*InstallSnapshotResponse, error) {
+	rpcHandler, routeError := memoryTransport.raftNetwork.route(memoryTransport.localNodeID, targetPeer.NodeID)
+	if routeError != nil {
+		return nil, routeError
+	}
+	return rpcHandler.HandleInstallSnapshot(request), nil
+}
+
+func (memoryTransport *inMemoryRaftTransport) Serve(rpcHandler RaftRPCHandler) error {
+	memoryTransport.raftNetwork.networkMutex.Lock()
+	defer memoryTransport.raftNetwork.networkMutex.Unlock()
+	memoryTransport.raftNetwork.rpcHandlers[memoryTransport.localNodeID] = rpcHandler
+	return nil
+}
+
+func (memoryTransport *inMemoryRaftTransport) Close() {
+	memoryTransport.raftNetwork.networkMutex.Lock()
+	defer memoryTransport.raftNetwork.networkMutex.Unlock()
+	delete(memoryTransport.raftNetwork.rpcHandlers, memoryTransport.localNodeID)
+}
+
+// keyValueStateMachine applique des commandes SET clé valeur sur une map
+type keyValueStateMachine struct {
+	stateMutex   sync.Mutex
+	storedValues map[string]string
+	restoreCount int
+}
+
+func newKeyValueStateMachine() *keyValueStateMachine {
+	return &keyValueStateMachine{storedValues: make(map[string]string)}
+}
+
+func (stateMachine *keyValueStateMachine) Apply(command []string) []byte {
+	stateMachine.stateMutex.Lock()
+	defer stateMachine.stateMutex.Unlock()
+	if len(command) != 3 || command[0] != "SET" {
+		return []byte("-ERR commande inconnue\r\n")
+	}
+	stateMachine.storedValues[command[1]] = command[2]
+	return []byte("+OK\r\n")
+}
+
+func (stateMachine *keyValueStateMachine) Snapshot() ([]byte, error) {
+	stateMachine.stateMutex.Lock()
+	defer stateMachine.stateMutex.Unlock()
+	return json.Marshal(stateMachine.storedValues)
+}
+
+func (stateMachine *keyValueStateMachine) Restore(snapshotData []byte) error {
+	restoredValues := make(map[string]string)
+	if err := json.Unmarshal(snapshotData, &restoredValues); err != nil {
+		return err
+	}
+	stateMachine.stateMutex.Lock()
+	defer stateMachine.stateMutex.Unlock()
+	stateMachine.storedValues = restoredValues
+	stateMachine.restoreCount++
+	return nil
+}
+
+func (stateMachine *keyValueStateMachine) value(key string) (string, bool) {
+	stateMachine.stateMutex.Lock()
+	defer stateMachine.stateMutex.Unlock()
+	storedValue, exists := stateMachine.storedValues[key]
+	return storedValue, exists
+}
+
+func (stateMachine *keyValueStateMachine) restores() int {
+	stateMachine.stateMutex.Lock()
+	defer stateMachine.stateMutex.Unlock()
+	return stateMachine.restoreCount
+}
+
+// testRaftCluster regroupe les nœuds d'un groupe en mémoire, chacun avec son répertoire de données
+type testRaftCluster struct {
+	t              *testing.T
+	raftNetwork    *inMemoryRaftNetwork
+	raftPeers      []RaftPeer
+	dataDirectory  map[string]string
+	raftNodes      map[string]*RaftNode
+	stateMachines  map[string]*keyValueStateMachine
+	snapshotPeriod int64
+}
+
+func newTestRaftCluster(t *testing.T, nodeCount int, snapshotThreshold int64) *testRaftCluster {
+	t.Helper()
+	raftCluster := &testRaftCluster{
+		t:              t,
+		raftNetwork:    newInMemoryRaftNetwork(),
+		dataDirectory:  make(map[string]string),
+		raftNodes:      make(map[string]*RaftNode),
+		stateMachines:  make(map[string]*keyValueStateMachine),
+		snapshotPeriod: snapshotThreshold,
+	}
+	for nodeNumber := 1; nodeNumber <= nodeCount; nodeNumber++ {
+		nodeID := fmt.Sprintf("n%d", nodeNumber)
+		raftCluster.raftPeers = append(raftCluster.raftPeers, RaftPeer{
+			NodeID:        nodeID,
+			ClientAddress: fmt.Sprintf("127.0.0.1:%d", 7000+nodeNumber),
+			RaftAddress:   fmt.Sprintf("127.0.0.1:%d", 17000+nodeNumber),
+		})
+		raftCluster.dataDirectory[nodeID] = t.TempDir()
+	}
+	for _, raftPeer := range raftCluster.raftPeers {
+		raftCluster.startNode(raftPeer.NodeID)
+	}
+	t.Cleanup(func() {
+		for _, raftNode := range raftCluster.raftNodes {
+			raftNode.Stop()
+		}
+	})
+	return raftCluster
+}
+
+// startNode démarre (ou redémarre) un nœud sur son répertoire de données
+func (raftCluster *testRaftCluster) startNode(nodeID string) {
+	raftCluster.t.Helper()
+	stateMachine := newKeyValueStateMachine()
+	raftNode, createError := NewRaftNode(RaftConfiguration{
+		NodeID:            nodeID,
+		Peers:             raftCluster.raftPeers,
+		DataDirectory:     raftCluster.dataDirectory[nodeID],
+		ElectionTimeout:   150 * time.Millisecond,
+		HeartbeatInterval: 30 * time.Millisecond,
+		SnapshotThreshold: raftCluster.snapshotPeriod,
+	}, &inMemoryRaftTransport{raftNetwork: raftCluster.raftNetwork, localNodeID: nodeID}, stateMachine)
+	if createError != nil {
+		raftCluster.t.Fatalf("NewRaftNode(%s): %v", nodeID, createError)
+	}
+	if startError := raftNode.Start(); startError != nil {
+		raftCluster.t.Fatalf("Start(%s): %v", nodeID, startError)
+	}
+	raftCluster.raftNodes[nodeID] = raftNode
+	raftCluster.stateMachines[nodeID] = stateMachine
+}
+
+// waitForLeader attend qu'un seul des nœuds indiqués soit leader et le retourne
+func (raftCluster *testRaftCluster) waitForLeader(candidateNodeIDs ...string) *RaftNode {
+	raftCluster.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		var leaderNodes []*RaftNode
+		for _, nodeID := range candidateNodeIDs {
+			if raftNode := raftCluster.raftNodes[nodeID]; raftNode.Status().Role == "leader" {
+				leaderNodes = append(leaderNodes, raftNode)
+			}
+		}
+		if len(leaderNodes) == 1 {
+			return leaderNodes[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	raftCluster.t.Fatalf("aucun leader unique élu parmi %v", candidateNodeIDs)
+	return nil
+}
+
+// waitForValue attend que la valeur d'une clé soit appliquée sur un nœud
+func (raftCluster *testRaftCluster) waitForValue(nodeID, key, expectedValue string) {
+	raftCluster.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if storedValue, _ := raftCluster.stateMachines[nodeID].value(key); storedValue == expectedValue {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	storedValue, _ := raftCluster.stateMachines[nodeID].value(key)
+	raftCluster.t.Fatalf("nœud %s : %s = %q, attendu %q", nodeID, key, storedValue, expectedValue)
+}
+
+func (raftCluster *testRaftCluster) allNodeIDs() []string {
+	nodeIDs := make([]string, 0, len(raftCluster.raftPeers))
+	for _, raftPeer := range raftCluster.raftPeers {
+		nodeIDs = append(nodeIDs, raftPeer.NodeID)
+	}
+	return nodeIDs
+}
+
+func (raftCluster *testRaftCluster) otherNodeIDs(excludedNodeID string) []string {
+	var nodeIDs []string
+	for _, nodeID := range raftCluster.allNodeIDs() {
+		if nodeID != excludedNodeID {
+			nodeIDs = append(nodeIDs, nodeID)
+		}
+	}
+	return nodeIDs
+}
+
+func proposeSet(t *testing.T, raftNode *RaftNode, key, value string) {
+	t.Helper()
+	commandReply, proposeError := raftNode.Propose([]string{"SET", key, value})
+	if proposeError != nil {
+		t.Fatalf("Propose(SET %s %s) sur %s: %v", key, value, raftNode.NodeID(), proposeError)
+	}
+	if string(commandReply) != "+OK\r\n" {
+		t.Fatalf("réponse inattendue %q", commandReply)
+	}
+}
+
+func TestRaftElectsLeaderAndReplicatesWrites(t *testing.T) {
+	raftCluster := newTestRaftCluster(t, 3, 0)
+	leaderNode := raftCluster.waitForLeader(raftCluster.allNodeIDs()...)
+
+	proposeSet(t, leaderNode, "compteur", "1")
+	for _, nodeID := range raftCluster.allNodeIDs() {
+		raftCluster.waitForValue(nodeID, "compteur", "1")
+	}
+
+	followerNodeID := raftCluster.otherNodeIDs(leaderNode.NodeID())[0]
+	_, proposeError := raftCluster.raftNodes[followerNodeID].Propose([]string{"SET", "compteur", "2"})
+	var notLeaderError *NotLeaderError
+	if !errors.As(proposeError, &notLeaderError) {
+		t.Fatalf("un suiveur doit refuser l'écriture avec NotLeaderError, obtenu %v", proposeError)
+	}
+	if notLeaderError.LeaderID != leaderNode.NodeID() || notLeaderError.LeaderAddress == "" {
+		t.Fatalf("redirection vers %s (%s), attendu %s", notLeaderError.LeaderID, notLeaderError.LeaderAddress, leaderNode.NodeID())
+	}
+}
+
+func TestRaftPartitionedLeaderStepsDownAndRejoins(t *testing.T) {
+	raftCluster := newTestRaftCluster(t, 3, 0)
+	oldLeader := raftCluster.waitForLeader(raftCluster.allNodeIDs()...)
+	proposeSet(t, oldLeader, "couleur", "rouge")
+	oldTerm := oldLeader.Status().CurrentTerm
+
+	// Le leader isolé ne peut plus valider : son écriture échoue et il abandonne le leadership
+	raftCluster.raftNetwork.isolate(oldLeader.NodeID())
+	isolatedWrite := make(chan error, 1)
+	go func() {
+		_, proposeError := oldLeader.Propose([]string{"SET", "couleur", "perdue"})
+		isolatedWrite <- proposeError
+	}()
+
+	majorityNodeIDs := raftCluster.otherNodeIDs(oldLeader.NodeID())
+	newLeader := raftCluster.waitForLeader(majorityNodeIDs...)
+	if newLeader.Status().CurrentTerm <= oldTerm {
+		t.Fatalf("le nouveau leader doit avoir un mandat supérieur à %d", oldTerm)
+	}
+	proposeSet(t, newLeader, "couleur", "vert")
+
+	select {
+	case proposeError := <-isolatedWrite:
+		if !errors.Is(proposeError, ErrLeadershipLost) {
+			t.Fatalf("écriture du leader isolé : attendu ErrLeadershipLost, obtenu %v", proposeError)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("l'écriture du leader isolé n'a pas échoué")
+	}
+	if oldLeader.Status().Role == "leader" {
+		t.Fatal("le leader isolé aurait dû abandonner le leadership")
+	}
+
+	// Après la réparation, l'ancien leader rejoint le groupe et abandonne son entrée non validée
+	raftCluster.raftNetwork.heal(oldLeader.NodeID())
+	raftCluster.waitForValue(oldLeader.NodeID(), "couleur", "vert")
+	proposeSet(t, newLeader, "forme", "carré")
+	for _, nodeID := range raftCluster.allNodeIDs() {
+		raftCluster.waitForValue(nodeID, "forme", "carré")
+		raftCluster.waitForValue(nodeID, "couleur", "vert")
+	}
+}
+
+func TestRaftCompactsLogAndCatchesUpLaggingFollowerWithSnapshot(t *testing.T) {
+	raftCluster := newTestRaftCluster(t, 3, 5)
+	leaderNode := raftCluster.waitForLeader(raftCluster.allNodeIDs()...)
+	laggingNodeID := raftCluster.otherNodeIDs(leaderNode.NodeID())[0]
+	raftCluster.raftNetwork.isolate(laggingNodeID)
+
+	for writeNumber := 0; writeNumber < 20; writeNumber++ {
+		proposeSet(t, leaderNode, fmt.Sprintf("cle:%d", writeNumber), fmt.Sprintf("valeur:%d", writeNumber))
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for leaderNode.Status().SnapshotIndex < 15 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	leaderStatus := leaderNode.Status()
+	if leaderStatus.SnapshotIndex < 15 {
+		t.Fatalf("journal du leader non compacté : snapshot à l'index %d", leaderStatus.SnapshotIndex)
+	}
+	if retainedEntries := leaderStatus.LastLogIndex - leaderStatus.SnapshotIndex; retainedEntries >= 5 {
+		t.Fatalf("le leader conserve %d entrées après compaction", retainedEntries)
+	}
+
+	// Les entrées manquantes sont compactées : le suiveur est rattrapé par InstallSnapshot
+	raftCluster.raftNetwork.heal(laggingNodeID)
+	for writeNumber := 0; writeNumber < 20; writeNumber++ {
+		raftCluster.waitForValue(laggingNodeID, fmt.Sprintf("cle:%d", writeNumber), fmt.Sprintf("valeur:%d", writeNumber))
+	}
+	if raftCluster.stateMachines[laggingNodeID].restores() == 0 {
+		t.Fatal("le suiveur en retard aurait dû recevoir un snapshot")
+	}
+	if laggingStatus := raftCluster.raftNodes[laggingNodeID].Status(); laggingStatus.SnapshotIndex == 0 {
+		t.Fatal("le snapshot reçu n'a pas été installé")
+	}
+}
+
+func TestRaftRestartedNodeRestoresSnapshotAndLog(t *testing.T) {
+	raftCluster := newTestRaftCluster(t, 3, 4)
+	leaderNode := raftCluster.waitForLeader(raftCluster.allNodeIDs()...)
+	for writeNumber := 0; writeNumber < 10; writeNumber++ {
+		proposeSet(t, leaderNode, "cle", fmt.Sprintf("v%d", writeNumber))
+	}
+	restartedNodeID := raftCluster.otherNodeIDs(leaderNode.NodeID())[0]
+	raftCluster.waitForValue(restartedNodeID, "cle", "v9")
+
+	raftCluster.raftNodes[restartedNodeID].Stop()
+	proposeSet(t, leaderNode, "pendant-arret", "oui")
+	raftCluster.startNode(restartedNodeID)
+
+	// Le snapshot local est relu au démarrage, puis le journal est complété par le leader
+	if raftCluster.stateMachines[restartedNodeID].restores() == 0 {
+		t.Fatal("le snapshot local n'a pas été relu au redémarrage")
+	}
+	raftCluster.waitForValue(restartedNodeID, "pendant-arret", "oui")
+	raftCluster.waitForValue(restartedNodeID, "cle", "v9")
+}
@@ -0,0 +1,248 @@
+package consensus
+
+import (
+	"encoding/gob"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RaftRPCHandler traite les appels reçus d'un autre nœud (implémenté par RaftNode)
+type RaftRPCHandler interface {
+	HandleRequestVote(request *RequestVoteRequest) *RequestVoteResponse
+	HandleAppendEntries(request *AppendEntriesRequest) *AppendEntriesResponse
+	HandleInstallSnapshot(request *InstallSnapshotRequest) *InstallSnapshotResponse
+}
+
+// RaftTransport achemine les appels entre nœuds
+// Le serveur utilise le transport TCP ; un transport en mémoire peut le remplacer pour faire tourner plusieurs
+// nœuds dans un même processus et simuler des partitions
+type RaftTransport interface {
+	SendRequestVote(targetPeer RaftPeer, request *RequestVoteRequest) (*RequestVoteResponse, error)
+	SendAppendEntries(targetPeer RaftPeer, request *AppendEntriesRequest) (*AppendEntriesResponse, error)
+	SendInstallSnapshot(targetPeer RaftPeer, request *InstallSnapshotRequest) (*InstallSnapshotResponse, error)
+	Serve(rpcHandler RaftRPCHandler) error
+	Close()
+}
+
+// raftRequestEnvelope transporte un appel ; un seul champ est renseigné
+type raftRequestEnvelope struct {
+	RequestVote     *RequestVoteRequest
+	AppendEntries   *AppendEntriesRequest
+	InstallSnapshot *InstallSnapshotRequest
+}
+
+// raftResponseEnvelope transporte la réponse correspondante
+type raftResponseEnvelope struct {
+	RequestVote     *RequestVoteResponse
+	AppendEntries   *AppendEntriesResponse
+	InstallSnapshot *InstallSnapshotResponse
+}
+
+// raftPeerConnection est la connexion persistante vers un nœud ; les appels y sont sérialisés
+type raftPeerConnection struct {
+	connectionMutex sync.Mutex
+	connection      net.Conn
+	encoder         *gob.Encoder
+	decoder         *gob.Decoder
+}
+
+// TCPRaftTransport échange des messages gob sur des connexions TCP persistantes
+type TCPRaftTransport struct {
+	bindAddress     string
+	rpcTimeout      time.Duration
+	snapshotTimeout time.Duration
+
+	peerConnectionsMutex sync.Mutex
+	peerConnections      map[string]*raftPeerConnection
+
+	listenerMutex     sync.Mutex
+	listener          net.Listener
+	activeConnections map[net.Conn]struct{}
+	closed            atomic.Bool
+}
+
+// NewTCPRaftTransport crée le transport ; Serve ouvre l'écoute sur bindAddress
+func NewTCPRaftTransport(bindAddress string, rpcTimeout time.Duration) *TCPRaftTransport {
+	return &TCPRaftTransport{
+		bindAddress:       bindAddress,
+		rpcTimeout:        rpcTimeout,
+		snapshotTimeout:   rpcTimeout * 50,
+		peerConnections:   make(map[string]*raftPeerConnection),
+		activeConnections: make(map[net.Conn]struct{}),
+	}
+}
+
+// Serve ouvre l'écoute et traite les appels entrants en arrière-plan
+func (tcpTransport *TCPRaftTransport) Serve(rpcHandler RaftRPCHandler) error {
+	listener, listenError := net.Listen("tcp", tcpTransport.bindAddress)
+	if listenError != nil {
+		return fmt.Errorf("écoute raft sur %s: %v", tcpTransport.bindAddress, listenError)
+	}
+	tcpTransport.listenerMutex.Lock()
+	tcpTransport.listener = listener
+	tcpTransport.listenerMutex.Unlock()
+
+	go func() {
+		for {
+			connection, acceptError := listener.Accept()
+			if acceptError != nil {
+				if !tcpTransport.closed.Load() {
+					log.Printf("⚠️ Erreur d'acceptation raft: %v", acceptError)
+				}
+				return
+			}
+			tcpTransport.listenerMutex.Lock()
+			if tcpTransport.closed.Load() {
+				tcpTransport.listenerMutex.Unlock()
+				connection.Close()
+				return
+			}
+			tcpTransport.activeConnections[connection] = struct{}{}
+			tcpTransport.listenerMutex.Unlock()
+			go tcpTransport.serveConnection(connection, rpcHandler)
+		}
+	}()
+	return nil
+}
+
+// serveConnection traite les appels d'un nœud dans l'ordre de réception
+func (tcpTransport *TCPRaftTransport) serveConnection(connection net.Conn, rpcHandler RaftRPCHandler) {
+	defer func() {
+		connection.Close()
+		tcpTransport.listenerMutex.Lock()
+		delete(tcpTransport.activeConnections, connection)
+		tcpTransport.listenerMutex.Unlock()
+	}()
+
+	decoder := gob.NewDecoder(connection)
+	encoder := gob.NewEncoder(connection)
+	for {
+		var requestEnvelope raftRequestEnvelope
+		if err := decoder.Decode(&requestEnvelope); err != nil {
+			return
+		}
+		var responseEnvelope raftResponseEnvelope
+		switch {
+		case requestEnvelope.RequestVote != nil:
+			responseEnvelope.RequestVote = rpcHandler.HandleRequestVote(requestEnvelope.RequestVote)
+		case requestEnvelope.AppendEntries != nil:
+			responseEnvelope.AppendEntries = rpcHandler.HandleAppendEntries(requestEnvelope.AppendEntries)
+		case requestEnvelope.InstallSnapshot != nil:
+			responseEnvelope.InstallSnapshot = rpcHandler.HandleInstallSnapshot(requestEnvelope.InstallSnapshot)
+		default:
+			return
+		}
+		connection.SetWriteDeadline(time.Now().Add(tcpTransport.rpcTimeout))
+		if err := encoder.Encode(&responseEnvelope); err != nil {
+			return
+		}
+	}
+}
+
+// SendRequestVote envoie une demande de vote
+func (tcpTransport *TCPRaftTransport) SendRequestVote(targetPeer RaftPeer, request *RequestVoteRequest) (*RequestVoteResponse, error) {
+	responseEnvelope, callError := tcpTransport.call(targetPeer, &raftRequestEnvelope{RequestVote: request}, tcpTransport.rpcTimeout)
+	if callError != nil {
+		return nil, callError
+	}
+	if responseEnvelope.RequestVote == nil {
+		return nil, fmt.Errorf("réponse raft inattendue de %s", targetPeer.NodeID)
+	}
+	return responseEnvelope.RequestVote, nil
+}
+
+// SendAppendEntries envoie des entrées ou un heartbeat
+func (tcpTransport *TCPRaftTransport) SendAppendEntries(targetPeer RaftPeer, request *AppendEntriesRequest) (*AppendEntriesResponse, error) {
+	responseEnvelope, callError := tcpTransport.call(targetPeer, &raftRequestEnvelope{AppendEntries: request}, tcpTransport.rpcTimeout)
+	if callError != nil {
+		return nil, callError
+	}
+	if responseEnvelope.AppendEntries == nil {
+		return nil, fmt.Errorf("réponse raft inattendue de %s", targetPeer.NodeID)
+	}
+	return responseEnvelope.AppendEntries, nil
+}
+
+// SendInstallSnapshot envoie un snapshot complet (délai plus long que les autres appels)
+func (tcpTransport *TCPRaftTransport) SendInstallSnapshot(targetPeer RaftPeer, request *InstallSnapshotRequest) (*InstallSnapshotResponse, error) {
+	responseEnvelope, callError := tcpTransport.call(targetPeer, &raftRequestEnvelope{InstallSnapshot: request}, tcpTransport.snapshotTimeout)
+	if callError != nil {
+		return nil, callError
+	}
+	if responseEnvelope.InstallSnapshot == nil {
+		return nil, fmt.Errorf("réponse raft inattendue de %s", targetPeer.NodeID)
+	}
+	return responseEnvelope.InstallSnapshot, nil
+}
+
+// call envoie un appel sur la connexion persistante du nœud, ouverte à la demande
+// Toute erreur ferme la connexion : la suivante repartira d'un flux gob neuf
+func (tcpTransport *TCPRaftTransport) call(targetPeer RaftPeer, requestEnvelope *raftRequestEnvelope, callTimeout time.Duration) (*raftResponseEnvelope, error) {
+	tcpTransport.peerConnectionsMutex.Lock()
+	if tcpTransport.closed.Load() {
+		tcpTransport.peerConnectionsMutex.Unlock()
+		return nil, fmt.Errorf("transport raft fermé")
+	}
+	peerConnection, exists := tcpTransport.peerConnections[targetPeer.NodeID]
+	if !exists {
+		peerConnection = &raftPeerConnection{}
+		tcpTransport.peerConnections[targetPeer.NodeID] = peerConnection
+	}
+	tcpTransport.peerConnectionsMutex.Unlock()
+
+	peerConnection.connectionMutex.Lock()
+	defer peerConnection.connectionMutex.Unlock()
+
+	if peerConnection.connection == nil {
+		connection, dialError := net.DialTimeout("tcp", targetPeer.RaftAddress, tcpTransport.rpcTimeout)
+		if dialError != nil {
+			return nil, dialError
+		}
+		peerConnection.connection = connection
+		peerConnection.encoder = gob.NewEncoder(connection)
+		peerConnection.decoder = gob.NewDecoder(connection)
+	}
+
+	peerConnection.connection.SetDeadline(time.Now().Add(callTimeout))
+	var responseEnvelope raftResponseEnvelope
+	callError := peerConnection.encoder.Encode(requestEnvelope)
+	if callError == nil {
+		callError = peerConnection.decoder.Decode(&responseEnvelope)
+	}
+	if callError != nil {
+		peerConnection.connection.Close()
+		peerConnection.connection = nil
+		return nil, callError
+	}
+	return &responseEnvelope, nil
+}
+
+// Close arrête l'écoute et ferme toutes les connexions
+func (tcpTransport *TCPRaftTransport) Close() {
+	tcpTransport.closed.Store(true)
+	tcpTransport.listenerMutex.Lock()
+	if tcpTransport.listener != nil {
+		tcpTransport.listener.Close()
+	}
+	for connection := range tcpTransport.activeConnections {
+		connection.Close()
+	}
+	tcpTransport.listenerMutex.Unlock()
+
+	tcpTransport.peerConnectionsMutex.Lock()
+	peerConnections := tcpTransport.peerConnections
+	tcpTransport.peerConnections = make(map[string]*raftPeerConnection)
+	tcpTransport.peerConnectionsMutex.Unlock()
+	for _, peerConnection := range peerConnections {
+		peerConnection.connectionMutex.Lock()
+		if peerConnection.connection != nil {
+			peerConnection.connection.Close()
+			peerConnection.connection = nil
+		}
+		peerConnection.connectionMutex.Unlock()
+	}
+}
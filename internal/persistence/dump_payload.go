@@ -0,0 +1,253 @@
+package persistence
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash/crc64"
+	"math"
+	"sort"
+
+	"redis-go/internal/storage"
+)
+
+// dumpPayloadTrailerSize est la taille du pied d'un payload DUMP : version (2 octets) puis CRC64 (8 octets)
+const dumpPayloadTrailerSize = 2 + 8
+
+// dumpPayloadVersion est la version courante du corps des payloads DUMP
+// Versions 1 à 5 : élément gob du flux de snapshot (numérotées comme snapshotStreamVersion), toujours lues
+// Version 6 : encodage binaire compact, sans les descripteurs de types que gob répétait dans chaque payload
+const dumpPayloadVersion = 6
+
+// dumpChecksumTable est la table CRC64 (polynôme ECMA) des payloads DUMP
+var dumpChecksumTable = crc64.MakeTable(crc64.ECMA)
+
+// EncodeDumpPayload sérialise une valeur pour DUMP / MIGRATE
+// Le corps est le type de la valeur (1 octet) suivi de son contenu (longueurs en varint), sans nom de clé
+// ni TTL, puis la version du format et un CRC64 sur l'ensemble, comme le payload de Redis
+func EncodeDumpPayload(snapshotRecord storage.SnapshotRecord) ([]byte, error) {
+	dumpPayload := []byte{byte(snapshotRecord.DataType)}
+
+	switch snapshotRecord.DataType {
+	case storage.RedisStringType:
+		dumpPayload = appendDumpString(dumpPayload, snapshotRecord.StringValue)
+	case storage.RedisListType:
+		dumpPayload = appendDumpStrings(dumpPayload, snapshotRecord.ListElements)
+	case storage.RedisSetType:
+		dumpPayload = appendDumpStrings(dumpPayload, snapshotRecord.SetMembers)
+	case storage.RedisHashType:
+		// Champs triés : une même valeur donne toujours le même payload
+		fieldNames := make([]string, 0, len(snapshotRecord.HashFields))
+		for fieldName := range snapshotRecord.HashFields {
+			fieldNames = append(fieldNames, fieldName)
+		}
+		sort.Strings(fieldNames)
+		dumpPayload = binary.AppendUvarint(dumpPayload, uint64(len(fieldNames)))
+		for _, fieldName := range fieldNames {
+			dumpPayload = appendDumpString(dumpPayload, fieldName)
+			dumpPayload = appendDumpString(dumpPayload, snapshotRecord.HashFields[fieldName])
+		}
+	case storage.RedisZSetType:
+		dumpPayload = binary.AppendUvarint(dumpPayload, uint64(len(snapshotRecord.SortedMembers)))
+		for _, sortedMember := range snapshotRecord.SortedMembers {
+			dumpPayload = appendDumpString(dumpPayload, sortedMember.Member)
+			dumpPayload = binary.LittleEndian.AppendUint64(dumpPayload, math.Float64bits(sortedMember.Score))
+		}
+	case storage.RedisStreamType:
+		dumpPayload = appendDumpStreamID(dumpPayload, snapshotRecord.StreamLastID)
+		dumpPayload = binary.AppendUvarint(dumpPayload, uint64(len(snapshotRecord.StreamEntries)))
+		for _, streamEntry := range snapshotRecord.StreamEntries {
+			dumpPayload = appendDumpStreamID(dumpPayload, streamEntry.EntryID)
+			dumpPayload = appendDumpStrings(dumpPayload, streamEntry.FieldValues)
+		}
+	case storage.RedisJSONType:
+		dumpPayload = appendDumpString(dumpPayload, snapshotRecord.JSONDocument)
+	default:
+		return nil, fmt.Errorf("type de valeur %d non sérialisable", snapshotRecord.DataType)
+	}
+
+	dumpPayload = binary.LittleEndian.AppendUint16(dumpPayload, dumpPayloadVersion)
+	return binary.LittleEndian.AppendUint64(dumpPayload, crc64.Checksum(dumpPayload, dumpChecksumTable)), nil
+}
+
+// DecodeDumpPayload vérifie la version et le checksum d'un payload DUMP puis reconstruit la valeur
+// L'enregistrement retourné n'a ni clé ni TTL : RESTORE les fournit
+func DecodeDumpPayload(dumpPayload []byte) (storage.SnapshotRecord, error) {
+	if len(dumpPayload) <= dumpPayloadTrailerSize {
+		return storage.SnapshotRecord{}, fmt.Errorf("payload trop court")
+	}
+
+	checksumOffset := len(dumpPayload) - 8
+	if crc64.Checksum(dumpPayload[:checksumOffset], dumpChecksumTable) != binary.LittleEndian.Uint64(dumpPayload[checksumOffset:]) {
+		return storage.SnapshotRecord{}, fmt.Errorf("checksum invalide")
+	}
+	versionOffset := checksumOffset - 2
+	payloadVersion := binary.LittleEndian.Uint16(dumpPayload[versionOffset:])
+	if payloadVersion > dumpPayloadVersion {
+		return storage.SnapshotRecord{}, fmt.Errorf("version de payload %d non supportée", payloadVersion)
+	}
+	if payloadVersion < dumpPayloadVersion {
+		return decodeGobDumpPayload(dumpPayload[:versionOffset])
+	}
+
+	payloadReader := &dumpPayloadReader{remainingBytes: dumpPayload[:versionOffset]}
+	snapshotRecord := storage.SnapshotRecord{DataType: storage.RedisDataType(payloadReader.readByte())}
+
+	switch snapshotRecord.DataType {
+	case storage.RedisStringType:
+		snapshotRecord.StringValue = payloadReader.readString()
+	case storage.RedisListType:
+		snapshotRecord.ListElements = payloadReader.readStrings()
+	case storage.RedisSetType:
+		snapshotRecord.SetMembers = payloadReader.readStrings()
+	case storage.RedisHashType:
+		fieldCount := payloadReader.readCount()
+		snapshotRecord.HashFields = make(map[string]string, fieldCount)
+		for fieldIndex := 0; fieldIndex < fieldCount; fieldIndex++ {
+			fieldName := payloadReader.readString()
+			snapshotRecord.HashFields[fieldName] = payloadReader.readString()
+		}
+	case storage.RedisZSetType:
+		memberCount := payloadReader.readCount()
+		snapshotRecord.SortedMembers = make([]storage.SortedSetMember, 0, memberCount)
+		for memberIndex := 0; memberIndex < memberCount; memberIndex++ {
+			memberName := payloadReader.readString()
+			memberScore := math.Float64frombits(payloadReader.readUint64())
+			snapshotRecord.SortedMembers = append(snapshotRecord.SortedMembers, storage.SortedSetMember{Member: memberName, Score: memberScore})
+		}
+	case storage.RedisStreamType:
+		snapshotRecord.StreamLastID = payloadReader.readStreamID()
+		entryCount := payloadReader.readCount()
+		snapshotRecord.StreamEntries = make([]storage.StreamEntry, 0, entryCount)
+		for entryIndex := 0; entryIndex < entryCount; entryIndex++ {
+			entryID := payloadReader.readStreamID()
+			snapshotRecord.StreamEntries = append(snapshotRecord.StreamEntries, storage.StreamEntry{EntryID: entryID, FieldValues: payloadReader.readStrings()})
+		}
+	case storage.RedisJSONType:
+		snapshotRecord.JSONDocument = payloadReader.readString()
+	default:
+		return storage.SnapshotRecord{}, fmt.Errorf("type de valeur inconnu")
+	}
+
+	if payloadReader.malformed || len(payloadReader.remainingBytes) != 0 {
+		return storage.SnapshotRecord{}, fmt.Errorf("décodage valeur: payload tronqué ou mal formé")
+	}
+	return snapshotRecord, nil
+}
+
+// decodeGobDumpPayload lit le corps d'un payload des versions 1 à 5 (élément gob du flux de snapshot)
+func decodeGobDumpPayload(payloadBody []byte) (storage.SnapshotRecord, error) {
+	var streamEntry snapshotStreamEntry
+	if err := gob.NewDecoder(bytes.NewReader(payloadBody)).Decode(&streamEntry); err != nil {
+		return storage.SnapshotRecord{}, fmt.Errorf("décodage valeur: %v", err)
+	}
+	if streamEntry.EndOfStream || streamEntry.Record.DataType.TypeName() == "none" {
+		return storage.SnapshotRecord{}, fmt.Errorf("type de valeur inconnu")
+	}
+	streamEntry.Record.Key = ""
+	streamEntry.Record.ExpirationTime = nil
+	return streamEntry.Record, nil
+}
+
+// appendDumpString ajoute une chaîne précédée de sa longueur
+func appendDumpString(dumpPayload []byte, stringValue string) []byte {
+	dumpPayload = binary.AppendUvarint(dumpPayload, uint64(len(stringValue)))
+	return append(dumpPayload, stringValue...)
+}
+
+// appendDumpStrings ajoute le nombre de chaînes puis chacune d'elles
+func appendDumpStrings(dumpPayload []byte, stringValues []string) []byte {
+	dumpPayload = binary.AppendUvarint(dumpPayload, uint64(len(stringValues)))
+	for _, stringValue := range stringValues {
+		dumpPayload = appendDumpString(dumpPayload, stringValue)
+	}
+	return dumpPayload
+}
+
+// appendDumpStreamID ajoute un identifiant de stream (millisecondes puis séquence)
+func appendDumpStreamID(dumpPayload []byte, streamID storage.StreamEntryID) []byte {
+	dumpPayload = binary.AppendUvarint(dumpPayload, streamID.Milliseconds)
+	return binary.AppendUvarint(dumpPayload, streamID.Sequence)
+}
+
+// dumpPayloadReader lit le corps d'un payload DUMP ; malformed passe à true à la première lecture impossible
+// et les lectures suivantes retournent des valeurs vides
+type dumpPayloadReader struct {
+	remainingBytes []byte
+	malformed      bool
+}
+
+// readByte lit un octet
+func (payloadReader *dumpPayloadReader) readByte() byte {
+	if payloadReader.malformed || len(payloadReader.remainingBytes) < 1 {
+		payloadReader.malformed = true
+		return 0
+	}
+	readValue := payloadReader.remainingBytes[0]
+	payloadReader.remainingBytes = payloadReader.remainingBytes[1:]
+	return readValue
+}
+
+// readUvarint lit un entier non signé de longueur variable
+func (payloadReader *dumpPayloadReader) readUvarint() uint64 {
+	if payloadReader.malformed {
+		return 0
+	}
+	readValue, readLength := binary.Uvarint(payloadReader.remainingBytes)
+	if readLength <= 0 {
+		payloadReader.malformed = true
+		return 0
+	}
+	payloadReader.remainingBytes = payloadReader.remainingBytes[readLength:]
+	return readValue
+}
+
+// readCount lit un nombre d'éléments, borné par les octets restants pour qu'un payload altéré
+// ne provoque pas d'allocation démesurée (chaque élément occupe au moins un octet)
+func (payloadReader *dumpPayloadReader) readCount() int {
+	elementCount := payloadReader.readUvarint()
+	if elementCount > uint64(len(payloadReader.remainingBytes)) {
+		payloadReader.malformed = true
+		return 0
+	}
+	return int(elementCount)
+}
+
+// readUint64 lit un entier de 8 octets (little-endian)
+func (payloadReader *dumpPayloadReader) readUint64() uint64 {
+	if payloadReader.malformed || len(payloadReader.remainingBytes) < 8 {
+		payloadReader.malformed = true
+		return 0
+	}
+	readValue := binary.LittleEndian.Uint64(payloadReader.remainingBytes)
+	payloadReader.remainingBytes = payloadReader.remainingBytes[8:]
+	return readValue
+}
+
+// readString lit une chaîne précédée de sa longueur
+func (payloadReader *dumpPayloadReader) readString() string {
+	stringLength := payloadReader.readCount()
+	if payloadReader.malformed {
+		return ""
+	}
+	stringValue := string(payloadReader.remainingBytes[:stringLength])
+	payloadReader.remainingBytes = payloadReader.remainingBytes[stringLength:]
+	return stringValue
+}
+
+// readStrings lit un nombre de chaînes puis chacune d'elles
+func (payloadReader *dumpPayloadReader) readStrings() []string {
+	stringCount := payloadReader.readCount()
+	stringValues := make([]string, 0, stringCount)
+	for stringIndex := 0; stringIndex < stringCount && !payloadReader.malformed; stringIndex++ {
+		stringValues = append(stringValues, payloadReader.readString())
+	}
+	return stringValues
+}
+
+// readStreamID lit un identifiant de stream
+func (payloadReader *dumpPayloadReader) readStreamID() storage.StreamEntryID {
+	milliseconds := payloadReader.readUvarint()
+	return storage.StreamEntryID{Milliseconds: milliseconds, Sequence: payloadReader.readUvarint()}
+}
@@ -0,0 +1,127 @@
+package persistence
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"hash/crc64"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"redis-go/internal/storage"
+)
+
+// withDumpTrailer ajoute la version et le CRC64 à un corps de payload
+func withDumpTrailer(payloadBody []byte, payloadVersion uint16) []byte {
+	dumpPayload := binary.LittleEndian.AppendUint16(append([]byte(nil), payloadBody...), payloadVersion)
+	return binary.LittleEndian.AppendUint64(dumpPayload, crc64.Checksum(dumpPayload, dumpChecksumTable))
+}
+
+func TestDumpPayloadRoundTripsEveryType(t *testing.T) {
+	testCases := []struct {
+		name           string
+		snapshotRecord storage.SnapshotRecord
+	}{
+		{"string", storage.SnapshotRecord{DataType: storage.RedisStringType, StringValue: "valeur\x00binaire"}},
+		{"string vide", storage.SnapshotRecord{DataType: storage.RedisStringType}},
+		{"list", storage.SnapshotRecord{DataType: storage.RedisListType, ListElements: []string{"a", "", "c"}}},
+		{"set", storage.SnapshotRecord{DataType: storage.RedisSetType, SetMembers: []string{"m1", "m2"}}},
+		{"hash", storage.SnapshotRecord{DataType: storage.RedisHashType, HashFields: map[string]string{"champ": "v", "autre": ""}}},
+		{"zset", storage.SnapshotRecord{DataType: storage.RedisZSetType, SortedMembers: []storage.SortedSetMember{{Member: "a", Score: -1.5}, {Member: "b", Score: 3e10}}}},
+		{"stream", storage.SnapshotRecord{
+			DataType:      storage.RedisStreamType,
+			StreamLastID:  storage.StreamEntryID{Milliseconds: 1700000000000, Sequence: 7},
+			StreamEntries: []storage.StreamEntry{{EntryID: storage.StreamEntryID{Milliseconds: 1700000000000, Sequence: 1}, FieldValues: []string{"k", "v"}}},
+		}},
+		{"json", storage.SnapshotRecord{DataType: storage.RedisJSONType, JSONDocument: `{"a":[1,2]}`}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			dumpPayload, encodeError := EncodeDumpPayload(testCase.snapshotRecord)
+			if encodeError != nil {
+				t.Fatalf("EncodeDumpPayload: %v", encodeError)
+			}
+			decodedRecord, decodeError := DecodeDumpPayload(dumpPayload)
+			if decodeError != nil {
+				t.Fatalf("DecodeDumpPayload: %v", decodeError)
+			}
+			if !reflect.DeepEqual(decodedRecord.ToStorageValue(), testCase.snapshotRecord.ToStorageValue()) {
+				t.Fatalf("valeur décodée %+v, attendu %+v", decodedRecord, testCase.snapshotRecord)
+			}
+		})
+	}
+}
+
+func TestDumpPayloadOmitsKeyAndTTLAndStaysCompact(t *testing.T) {
+	expirationTime := time.Now().Add(time.Hour)
+	dumpPayload, encodeError := EncodeDumpPayload(storage.SnapshotRecord{Key: "clé", DataType: storage.RedisStringType, StringValue: "x", ExpirationTime: &expirationTime})
+	if encodeError != nil {
+		t.Fatalf("EncodeDumpPayload: %v", encodeError)
+	}
+	// Type, longueur, valeur, puis version et CRC64
+	if expectedSize := 1 + 1 + 1 + dumpPayloadTrailerSize; len(dumpPayload) != expectedSize {
+		t.Fatalf("payload de %d octets pour une chaîne d'un octet, attendu %d", len(dumpPayload), expectedSize)
+	}
+	decodedRecord, decodeError := DecodeDumpPayload(dumpPayload)
+	if decodeError != nil {
+		t.Fatalf("DecodeDumpPayload: %v", decodeError)
+	}
+	if decodedRecord.Key != "" || decodedRecord.ExpirationTime != nil {
+		t.Fatalf("clé %q et TTL %v transmis, attendu aucun", decodedRecord.Key, decodedRecord.ExpirationTime)
+	}
+}
+
+func TestDumpPayloadRejectsInvalidPayloads(t *testing.T) {
+	validPayload, encodeError := EncodeDumpPayload(storage.SnapshotRecord{DataType: storage.RedisListType, ListElements: []string{"a", "b"}})
+	if encodeError != nil {
+		t.Fatalf("EncodeDumpPayload: %v", encodeError)
+	}
+	payloadBody := validPayload[:len(validPayload)-dumpPayloadTrailerSize]
+
+	alteredPayload := append([]byte(nil), validPayload...)
+	alteredPayload[2] ^= 0x01
+
+	testCases := []struct {
+		name          string
+		dumpPayload   []byte
+		expectedError string
+	}{
+		{"vide", nil, "trop court"},
+		{"pied seul", validPayload[len(validPayload)-dumpPayloadTrailerSize:], "trop court"},
+		{"octet altéré", alteredPayload, "checksum"},
+		{"checksum tronqué", validPayload[:len(validPayload)-1], "checksum"},
+		{"version future", withDumpTrailer(payloadBody, dumpPayloadVersion+1), "version de payload"},
+		{"type inconnu", withDumpTrailer([]byte{0x7f, 0x00}, dumpPayloadVersion), "type de valeur inconnu"},
+		{"corps tronqué", withDumpTrailer(payloadBody[:len(payloadBody)-1], dumpPayloadVersion), "mal formé"},
+		{"octets en trop", withDumpTrailer(append(append([]byte(nil), payloadBody...), 0x00), dumpPayloadVersion), "mal formé"},
+		{"longueur démesurée", withDumpTrailer([]byte{byte(storage.RedisStringType), 0xff, 0xff, 0xff, 0xff, 0x0f}, dumpPayloadVersion), "mal formé"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, decodeError := DecodeDumpPayload(testCase.dumpPayload)
+			if decodeError == nil || !strings.Contains(decodeError.Error(), testCase.expectedError) {
+				t.Fatalf("DecodeDumpPayload: %v, attendu une erreur contenant %q", decodeError, testCase.expectedError)
+			}
+		})
+	}
+}
+
+func TestDumpPayloadReadsLegacyGobPayloads(t *testing.T) {
+	var legacyBody bytes.Buffer
+	legacyRecord := storage.SnapshotRecord{DataType: storage.RedisHashType, HashFields: map[string]string{"f": "v"}}
+	if encodeError := gob.NewEncoder(&legacyBody).Encode(snapshotStreamEntry{Record: legacyRecord}); encodeError != nil {
+		t.Fatalf("encodage gob: %v", encodeError)
+	}
+
+	decodedRecord, decodeError := DecodeDumpPayload(withDumpTrailer(legacyBody.Bytes(), 5))
+	if decodeError != nil {
+		t.Fatalf("DecodeDumpPayload: %v", decodeError)
+	}
+	if !reflect.DeepEqual(decodedRecord.HashFields, legacyRecord.HashFields) {
+		t.Fatalf("hash décodé %v, attendu %v", decodedRecord.HashFields, legacyRecord.HashFields)
+	}
+}
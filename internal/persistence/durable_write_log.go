@@ -0,0 +1,571 @@
+package persistence
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"redis-go/internal/protocol"
+)
+
+// durableLogSnapshotMarker est le nom de l'entrée qui marque, dans le journal, la position d'un snapshot RDB
+// Au démarrage, seules les écritures qui suivent le marqueur du snapshot chargé sont rejouées
+const durableLogSnapshotMarker = "#SNAPSHOT"
+
+// DurableWriteLog est le journal des écritures (write-ahead log) avec validation groupée
+// Chaque écriture est ajoutée en mémoire dans l'ordre d'exécution ; une goroutine dédiée écrit et
+// fsync les entrées par lots : toutes les écritures arrivées pendant un fsync partagent le suivant.
+// Les offsets sont logiques (octets ajoutés depuis l'ouverture) et ne reculent jamais.
+type DurableWriteLog struct {
+	filePath string
+
+	appendMutex      sync.Mutex // Sérialise l'exécution des écritures avec leur ajout au journal
+	pendingEntries   []byte
+	pendingCount     int64
+	appendedOffset   int64 // Fin logique des entrées ajoutées
+	compactionOffset int64 // Début du marqueur jusqu'où compacter le fichier (-1 = aucune demande)
+	resetRequested   bool  // Vider le fichier avant le prochain lot (snapshot restauré)
+
+	durableMutex   sync.Mutex
+	durableOffset  int64         // Fin logique des entrées écrites et fsync
+	durableChanged chan struct{} // Fermé (puis remplacé) à chaque progression
+	logFailure     error         // Première erreur d'écriture : le journal n'accepte plus rien
+
+	// Possédés par la goroutine d'écriture
+	logFile        *os.File
+	fileBaseOffset int64 // Offset logique du premier octet du fichier
+
+	writingStarted bool // Fixé par startWriting avant le lancement de la goroutine, lu par Stop
+
+	flushSignal   chan struct{}
+	stopSignal    chan struct{}
+	stoppedSignal chan struct{}
+	stopOnce      sync.Once
+
+	fsyncCount          atomic.Int64
+	fsyncTotalLatency   atomic.Int64 // Nanosecondes cumulées
+	fsyncLastLatency    atomic.Int64
+	fsyncMaximumLatency atomic.Int64
+	committedEntries    atomic.Int64
+	logFileBytes        atomic.Int64
+}
+
+// NewDurableWriteLog prépare le journal ; le fichier n'est lu et ouvert que par Open
+func NewDurableWriteLog(filePath string) *DurableWriteLog {
+	return &DurableWriteLog{
+		filePath:         filePath,
+		compactionOffset: -1,
+		durableChanged:   make(chan struct{}),
+		flushSignal:      make(chan struct{}, 1),
+		stopSignal:       make(chan struct{}),
+		stoppedSignal:    make(chan struct{}),
+	}
+}
+
+// encodeDurableLogEntry encode une commande en tableau RESP, comme dans le flux de réplication
+func encodeDurableLogEntry(commandArguments []string) []byte {
+	var encodedEntry bytes.Buffer
+	protocol.NewRedisSerializationProtocolEncoder(&encodedEntry).WriteArrayResponse(commandArguments)
+	return encodedEntry.Bytes()
+}
+
+// snapshotMarkerValue identifie un snapshot par sa date de création (0 = aucun snapshot)
+func snapshotMarkerValue(snapshotTime time.Time) string {
+	if snapshotTime.IsZero() {
+		return "0"
+	}
+	return strconv.FormatInt(snapshotTime.UnixNano(), 10)
+}
+
+// isSnapshotMarker indique si une entrée du journal est un marqueur de snapshot
+func isSnapshotMarker(logEntry []string) bool {
+	return len(logEntry) == 2 && logEntry[0] == durableLogSnapshotMarker
+}
+
+// durableLogScan décrit le contenu d'un journal existant
+type durableLogScan struct {
+	validLength     int64 // Taille de la partie lisible (une fin tronquée par un crash est ignorée)
+	markerFound     bool
+	markerPosition  int64 // Position du dernier marqueur du snapshot chargé
+	replayPosition  int64 // Position de la première entrée à rejouer
+	containsEntries bool
+}
+
+// scanDurableLog parcourt le fichier pour trouver le marqueur du snapshot chargé et la fin valide
+// Seule la dernière entrée peut être incomplète : une erreur au milieu du fichier est une corruption
+func scanDurableLog(logFile *os.File, expectedMarker string) (durableLogScan, error) {
+	var logScan durableLogScan
+	fileStatus, statError := logFile.Stat()
+	if statError != nil {
+		return logScan, statError
+	}
+	countingReader := &countingReader{source: logFile}
+	bufferedReader := bufio.NewReader(countingReader)
+	entryParser := protocol.NewRedisSerializationProtocolParser(bufferedReader)
+
+	for {
+		entryPosition := countingReader.readBytes - int64(bufferedReader.Buffered())
+		logEntry, parseError := entryParser.ParseIncomingCommand()
+		if parseError == io.EOF {
+			logScan.validLength = entryPosition
+			return logScan, nil
+		}
+		if parseError != nil {
+			// Entrée incomplète en fin de fichier (crash pendant l'écriture) : elle est ignorée
+			if countingReader.readBytes == fileStatus.Size() && bufferedReader.Buffered() == 0 {
+				logScan.validLength = entryPosition
+				return logScan, nil
+			}
+			return logScan, fmt.Errorf("journal corrompu à la position %d: %v", entryPosition, parseError)
+		}
+
+		logScan.containsEntries = true
+		if isSnapshotMarker(logEntry) && logEntry[1] == expectedMarker {
+			logScan.markerFound = true
+			logScan.markerPosition = entryPosition
+			logScan.replayPosition = countingReader.readBytes - int64(bufferedReader.Buffered())
+		}
+	}
+}
+
+// countingReader compte les octets lus pour retrouver la position des entrées
+type countingReader struct {
+	source    io.Reader
+	readBytes int64
+}
+
+// Read implémente io.Reader
+func (reader *countingReader) Read(buffer []byte) (int, error) {
+	readLength, readError := reader.source.Read(buffer)
+	reader.readBytes += int64(readLength)
+	return readLength, readError
+}
+
+// Open relit le journal, rejoue les écritures postérieures au snapshot chargé puis démarre l'écriture
+// snapshotTime est la date du snapshot RDB chargé (zéro si aucun) ; replayCommand applique une écriture
+func (writeLog *DurableWriteLog) Open(snapshotTime time.Time, replayCommand func(commandArguments []string)) error {
+	if mkdirError := os.MkdirAll(filepath.Dir(writeLog.filePath), 0755); mkdirError != nil {
+		return fmt.Errorf("création dossier: %v", mkdirError)
+	}
+	expectedMarker := snapshotMarkerValue(snapshotTime)
+
+	logFile, openError := os.OpenFile(writeLog.filePath, os.O_RDWR|os.O_CREATE, 0644)
+	if openError != nil {
+		return fmt.Errorf("ouverture journal: %v", openError)
+	}
+	logScan, scanError := scanDurableLog(logFile, expectedMarker)
+	if scanError != nil {
+		logFile.Close()
+		return fmt.Errorf("lecture journal: %v", scanError)
+	}
+
+	if fileStatus, statError := logFile.Stat(); statError == nil && fileStatus.Size() > logScan.validLength {
+		log.Printf("⚠️  Journal: fin incomplète ignorée (%d octets)", fileStatus.Size()-logScan.validLength)
+		if truncateError := logFile.Truncate(logScan.validLength); truncateError != nil {
+			logFile.Close()
+			return fmt.Errorf("troncature journal: %v", truncateError)
+		}
+	}
+
+	switch {
+	case logScan.markerFound:
+		replayedCount, replayError := writeLog.replayEntries(logFile, logScan.replayPosition, logScan.validLength, replayCommand)
+		if replayError != nil {
+			logFile.Close()
+			return fmt.Errorf("relecture journal: %v", replayError)
+		}
+		log.Printf("📜 Journal: %d écritures rejouées depuis %s", replayedCount, writeLog.filePath)
+		logFile.Close()
+		// Les entrées antérieures au marqueur sont couvertes par le snapshot : elles sont retirées du fichier
+		if logScan.markerPosition > 0 {
+			if rewriteError := rewriteDurableLogFrom(writeLog.filePath, logScan.markerPosition); rewriteError != nil {
+				return fmt.Errorf("compaction journal: %v", rewriteError)
+			}
+		}
+	case logScan.containsEntries:
+		// Le journal ne correspond pas au snapshot chargé (fichier RDB remplacé à la main, rdb-tool import...)
+		// Le rejouer pourrait appliquer deux fois des écritures : il est mis de côté
+		logFile.Close()
+		orphanPath := fmt.Sprintf("%s.orphan-%d", writeLog.filePath, time.Now().Unix())
+		if renameError := os.Rename(writeLog.filePath, orphanPath); renameError != nil {
+			return fmt.Errorf("mise de côté journal: %v", renameError)
+		}
+		log.Printf("⚠️  Journal: aucun marqueur pour le snapshot chargé, ancien journal conservé sous %s sans être rejoué", orphanPath)
+	default:
+		logFile.Close()
+	}
+
+	return writeLog.startWriting(expectedMarker)
+}
+
+// replayEntries applique les entrées situées entre startPosition et endPosition
+func (writeLog *DurableWriteLog) replayEntries(logFile *os.File, startPosition int64, endPosition int64, replayCommand func(commandArguments []string)) (int, error) {
+	entryParser := protocol.NewRedisSerializationProtocolParser(io.NewSectionReader(logFile, startPosition, endPosition-startPosition))
+	replayedCount := 0
+	for {
+		logEntry, parseError := entryParser.ParseIncomingCommand()
+		if parseError == io.EOF {
+			return replayedCount, nil
+		}
+		if parseError != nil {
+			return replayedCount, parseError
+		}
+		if len(logEntry) == 0 || isSnapshotMarker(logEntry) {
+			continue
+		}
+		replayCommand(logEntry)
+		replayedCount++
+	}
+}
+
+// rewriteDurableLogFrom remplace le journal par sa partie commençant à startPosition
+// Le nouveau fichier est écrit à côté puis renommé : un crash laisse l'ancien ou le nouveau, jamais un mélange
+func rewriteDurableLogFrom(filePath string, startPosition int64) error {
+	sourceFile, openError := os.Open(filePath)
+	if openError != nil {
+		return openError
+	}
+	defer sourceFile.Close()
+	if _, seekError := sourceFile.Seek(startPosition, io.SeekStart); seekError != nil {
+		return seekError
+	}
+
+	tempFile := filePath + ".tmp"
+	if copyError := copyFileContent(sourceFile, tempFile); copyError != nil {
+		os.Remove(tempFile)
+		return copyError
+	}
+	if renameError := os.Rename(tempFile, filePath); renameError != nil {
+		os.Remove(tempFile)
+		return renameError
+	}
+	return syncDirectory(filepath.Dir(filePath))
+}
+
+// copyFileContent copie le reste d'un fichier ouvert vers un nouveau fichier, écrit sur disque
+func copyFileContent(sourceFile *os.File, destinationPath string) error {
+	destinationFile, createError := os.Create(destinationPath)
+	if createError != nil {
+		return createError
+	}
+	defer destinationFile.Close()
+
+	if _, copyError := io.Copy(destinationFile, sourceFile); copyError != nil {
+		return copyError
+	}
+	return destinationFile.Sync()
+}
+
+// syncDirectory force l'écriture d'un renommage sur disque
+func syncDirectory(directoryPath string) error {
+	directory, openError := os.Open(directoryPath)
+	if openError != nil {
+		return openError
+	}
+	defer directory.Close()
+	return directory.Sync()
+}
+
+// startWriting ouvre le fichier en ajout et lance la goroutine d'écriture
+// Un journal vide commence par le marqueur du snapshot chargé
+func (writeLog *DurableWriteLog) startWriting(expectedMarker string) error {
+	logFile, openError := os.OpenFile(writeLog.filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if openError != nil {
+		return fmt.Errorf("ouverture journal: %v", openError)
+	}
+	fileStatus, statError := logFile.Stat()
+	if statError != nil {
+		logFile.Close()
+		return fmt.Errorf("lecture journal: %v", statError)
+	}
+
+	if fileStatus.Size() == 0 {
+		initialMarker := encodeDurableLogEntry([]string{durableLogSnapshotMarker, expectedMarker})
+		if _, writeError := logFile.Write(initialMarker); writeError != nil {
+			logFile.Close()
+			return fmt.Errorf("écriture journal: %v", writeError)
+		}
+		if syncError := logFile.Sync(); syncError != nil {
+			logFile.Close()
+			return fmt.Errorf("sync journal: %v", syncError)
+		}
+		fileStatus, _ = logFile.Stat()
+	}
+
+	writeLog.logFile = logFile
+	writeLog.appendedOffset = fileStatus.Size()
+	writeLog.durableOffset = fileStatus.Size()
+	writeLog.logFileBytes.Store(fileStatus.Size())
+	writeLog.writingStarted = true
+
+	go writeLog.runFlusher()
+	log.Printf("📜 Journal des écritures actif (%s, %d octets)", writeLog.filePath, fileStatus.Size())
+	return nil
+}
+
+// LogWrite exécute une écriture puis ajoute au journal la commande qu'elle retourne (rien si elle est vide),
+// sous le même verrou : l'ordre du journal est celui d'exécution et un snapshot ne peut pas s'intercaler entre les deux
+// Retourne l'offset à atteindre pour que cette écriture soit sur disque
+func (writeLog *DurableWriteLog) LogWrite(executeCommand func() ([]string, error)) (int64, error) {
+	writeLog.appendMutex.Lock()
+	defer writeLog.appendMutex.Unlock()
+
+	loggedCommand, executionError := executeCommand()
+	if len(loggedCommand) > 0 {
+		writeLog.appendEntryLocked(encodeDurableLogEntry(loggedCommand))
+	}
+	return writeLog.appendedOffset, executionError
+}
+
+// appendEntryLocked ajoute une entrée encodée au lot en attente et réveille l'écriture (appendMutex détenu)
+func (writeLog *DurableWriteLog) appendEntryLocked(encodedEntry []byte) {
+	writeLog.pendingEntries = append(writeLog.pendingEntries, encodedEntry...)
+	writeLog.pendingCount++
+	writeLog.appendedOffset += int64(len(encodedEntry))
+
+	select {
+	case writeLog.flushSignal <- struct{}{}:
+	default:
+	}
+}
+
+// MarkSnapshot crée un snapshot entre deux écritures et ajoute son marqueur au journal
+// beginSnapshot crée le snapshot et retourne sa date ; la fonction retourne le début et la fin du marqueur
+func (writeLog *DurableWriteLog) MarkSnapshot(beginSnapshot func() time.Time) (int64, int64) {
+	writeLog.appendMutex.Lock()
+	defer writeLog.appendMutex.Unlock()
+
+	markerOffset := writeLog.appendedOffset
+	writeLog.appendEntryLocked(encodeDurableLogEntry([]string{durableLogSnapshotMarker, snapshotMarkerValue(beginSnapshot())}))
+	return markerOffset, writeLog.appendedOffset
+}
+
+// CompactBefore retire du fichier les entrées antérieures au marqueur d'un snapshot sauvegardé
+func (writeLog *DurableWriteLog) CompactBefore(markerOffset int64) {
+	writeLog.appendMutex.Lock()
+	defer writeLog.appendMutex.Unlock()
+
+	writeLog.compactionOffset = markerOffset
+	select {
+	case writeLog.flushSignal <- struct{}{}:
+	default:
+	}
+}
+
+// Restart remplace les données hors du flux d'écritures (snapshot restauré, DEBUG RELOAD)
+// loadSnapshot charge les données et retourne la date du snapshot : le journal repart de son marqueur
+func (writeLog *DurableWriteLog) Restart(loadSnapshot func() (time.Time, error)) error {
+	writeLog.appendMutex.Lock()
+	defer writeLog.appendMutex.Unlock()
+
+	snapshotTime, loadError := loadSnapshot()
+	if loadError != nil {
+		return loadError
+	}
+	writeLog.pendingEntries = nil
+	writeLog.pendingCount = 0
+	writeLog.compactionOffset = -1
+	writeLog.resetRequested = true
+	writeLog.appendEntryLocked(encodeDurableLogEntry([]string{durableLogSnapshotMarker, snapshotMarkerValue(snapshotTime)}))
+	return nil
+}
+
+// runFlusher écrit les lots en attente jusqu'à l'arrêt du journal
+func (writeLog *DurableWriteLog) runFlusher() {
+	defer close(writeLog.stoppedSignal)
+	for {
+		select {
+		case <-writeLog.flushSignal:
+			writeLog.flushPendingEntries()
+		case <-writeLog.stopSignal:
+			writeLog.flushPendingEntries()
+			writeLog.logFile.Close()
+			return
+		}
+	}
+}
+
+// flushPendingEntries écrit et fsync le lot en attente, puis compacte le fichier si demandé
+func (writeLog *DurableWriteLog) flushPendingEntries() {
+	writeLog.appendMutex.Lock()
+	pendingBatch, batchCount, batchEndOffset := writeLog.pendingEntries, writeLog.pendingCount, writeLog.appendedOffset
+	compactionOffset, resetRequested := writeLog.compactionOffset, writeLog.resetRequested
+	writeLog.pendingEntries, writeLog.pendingCount = nil, 0
+	writeLog.compactionOffset, writeLog.resetRequested = -1, false
+	writeLog.appendMutex.Unlock()
+
+	if writeLog.Failure() != nil {
+		return
+	}
+
+	if resetRequested {
+		if truncateError := writeLog.logFile.Truncate(0); truncateError != nil {
+			writeLog.recordFailure(fmt.Errorf("troncature: %v", truncateError))
+			return
+		}
+		writeLog.fileBaseOffset = batchEndOffset - int64(len(pendingBatch))
+		writeLog.logFileBytes.Store(0)
+	}
+
+	if len(pendingBatch) > 0 {
+		if commitError := writeLog.commitBatch(pendingBatch, batchCount, batchEndOffset); commitError != nil {
+			writeLog.recordFailure(commitError)
+			return
+		}
+	}
+
+	if compactionOffset > writeLog.fileBaseOffset {
+		if compactionError := writeLog.compactFile(compactionOffset); compactionError != nil {
+			log.Printf("⚠️  Journal: compaction impossible: %v", compactionError)
+		}
+	}
+}
+
+// commitBatch écrit un lot puis le fsync ; les écritures qu'il contient deviennent durables
+func (writeLog *DurableWriteLog) commitBatch(pendingBatch []byte, batchCount int64, batchEndOffset int64) error {
+	if _, writeError := writeLog.logFile.Write(pendingBatch); writeError != nil {
+		return fmt.Errorf("écriture: %v", writeError)
+	}
+	syncStart := time.Now()
+	if syncError := writeLog.logFile.Sync(); syncError != nil {
+		return fmt.Errorf("fsync: %v", syncError)
+	}
+	syncLatency := int64(time.Since(syncStart))
+
+	writeLog.fsyncCount.Add(1)
+	writeLog.fsyncTotalLatency.Add(syncLatency)
+	writeLog.fsyncLastLatency.Store(syncLatency)
+	if syncLatency > writeLog.fsyncMaximumLatency.Load() {
+		writeLog.fsyncMaximumLatency.Store(syncLatency)
+	}
+	writeLog.committedEntries.Add(batchCount)
+	writeLog.logFileBytes.Add(int64(len(pendingBatch)))
+
+	writeLog.durableMutex.Lock()
+	writeLog.durableOffset = batchEndOffset
+	close(writeLog.durableChanged)
+	writeLog.durableChanged = make(chan struct{})
+	writeLog.durableMutex.Unlock()
+	return nil
+}
+
+// compactFile réécrit le fichier à partir du marqueur situé à l'offset logique markerOffset
+func (writeLog *DurableWriteLog) compactFile(markerOffset int64) error {
+	if rewriteError := rewriteDurableLogFrom(writeLog.filePath, markerOffset-writeLog.fileBaseOffset); rewriteError != nil {
+		return rewriteError
+	}
+
+	logFile, openError := os.OpenFile(writeLog.filePath, os.O_WRONLY|os.O_APPEND, 0644)
+	if openError != nil {
+		writeLog.recordFailure(fmt.Errorf("réouverture après compaction: %v", openError))
+		return openError
+	}
+	writeLog.logFile.Close()
+	writeLog.logFile = logFile
+	writeLog.fileBaseOffset = markerOffset
+	if fileStatus, statError := logFile.Stat(); statError == nil {
+		writeLog.logFileBytes.Store(fileStatus.Size())
+	}
+	return nil
+}
+
+// recordFailure mémorise la première erreur et réveille les clients en attente
+func (writeLog *DurableWriteLog) recordFailure(failure error) {
+	log.Printf("❌ Journal des écritures en erreur: %v", failure)
+
+	writeLog.durableMutex.Lock()
+	defer writeLog.durableMutex.Unlock()
+	if writeLog.logFailure == nil {
+		writeLog.logFailure = failure
+		close(writeLog.durableChanged)
+		writeLog.durableChanged = make(chan struct{})
+	}
+}
+
+// Failure retourne l'erreur qui a arrêté le journal (nil s'il fonctionne)
+func (writeLog *DurableWriteLog) Failure() error {
+	writeLog.durableMutex.Lock()
+	defer writeLog.durableMutex.Unlock()
+	return writeLog.logFailure
+}
+
+// WaitDurable attend que le journal soit sur disque jusqu'à targetOffset
+// Retourne false si le délai expire (timeout 0 = attente illimitée)
+func (writeLog *DurableWriteLog) WaitDurable(targetOffset int64, timeout time.Duration) (bool, error) {
+	var timeoutSignal <-chan time.Time
+	if timeout > 0 {
+		timeoutTimer := time.NewTimer(timeout)
+		defer timeoutTimer.Stop()
+		timeoutSignal = timeoutTimer.C
+	}
+
+	for {
+		writeLog.durableMutex.Lock()
+		durableOffset, logFailure, durableChanged := writeLog.durableOffset, writeLog.logFailure, writeLog.durableChanged
+		writeLog.durableMutex.Unlock()
+
+		if durableOffset >= targetOffset {
+			return true, nil
+		}
+		if logFailure != nil {
+			return false, logFailure
+		}
+		select {
+		case <-durableChanged:
+		case <-timeoutSignal:
+			return false, nil
+		}
+	}
+}
+
+// Stop écrit les dernières entrées et ferme le journal
+func (writeLog *DurableWriteLog) Stop() {
+	writeLog.stopOnce.Do(func() {
+		// logFile appartient à la goroutine d'écriture (la compaction le remplace) : il n'est pas lu ici
+		if !writeLog.writingStarted {
+			return
+		}
+		close(writeLog.stopSignal)
+		<-writeLog.stoppedSignal
+		log.Printf("📜 Journal des écritures fermé")
+	})
+}
+
+// GetInfoFields retourne les lignes du journal pour INFO persistence
+func (writeLog *DurableWriteLog) GetInfoFields() []string {
+	writeLog.appendMutex.Lock()
+	pendingBytes := len(writeLog.pendingEntries)
+	writeLog.appendMutex.Unlock()
+
+	logStatus := "ok"
+	if writeLog.Failure() != nil {
+		logStatus = "err"
+	}
+
+	fsyncCount := writeLog.fsyncCount.Load()
+	averageLatency, averageBatch := int64(0), 0.0
+	if fsyncCount > 0 {
+		averageLatency = writeLog.fsyncTotalLatency.Load() / fsyncCount
+		averageBatch = float64(writeLog.committedEntries.Load()) / float64(fsyncCount)
+	}
+
+	return []string{
+		"durable_log_enabled:1",
+		fmt.Sprintf("durable_log_status:%s", logStatus),
+		fmt.Sprintf("durable_log_file_bytes:%d", writeLog.logFileBytes.Load()),
+		fmt.Sprintf("durable_log_pending_bytes:%d", pendingBytes),
+		fmt.Sprintf("durable_log_fsync_count:%d", fsyncCount),
+		fmt.Sprintf("durable_log_fsync_last_latency_usec:%d", time.Duration(writeLog.fsyncLastLatency.Load()).Microseconds()),
+		fmt.Sprintf("durable_log_fsync_avg_latency_usec:%d", time.Duration(averageLatency).Microseconds()),
+		fmt.Sprintf("durable_log_fsync_max_latency_usec:%d", time.Duration(writeLog.fsyncMaximumLatency.Load()).Microseconds()),
+		fmt.Sprintf("durable_log_avg_writes_per_fsync:%.2f", averageBatch),
+	}
+}
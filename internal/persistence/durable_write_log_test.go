@@ -0,0 +1,235 @@
+package persistence
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// writeDurableLogFile écrit un journal à la main : chaque entrée est encodée comme par LogWrite
+func writeDurableLogFile(t *testing.T, filePath string, logEntries [][]string, trailingBytes string) {
+	t.Helper()
+	var fileContent bytes.Buffer
+	for _, logEntry := range logEntries {
+		fileContent.Write(encodeDurableLogEntry(logEntry))
+	}
+	fileContent.WriteString(trailingBytes)
+	if writeError := os.WriteFile(filePath, fileContent.Bytes(), 0644); writeError != nil {
+		t.Fatalf("écriture journal: %v", writeError)
+	}
+}
+
+// openDurableLog ouvre le journal et retourne les écritures rejouées ; il est arrêté en fin de test
+func openDurableLog(t *testing.T, filePath string, snapshotTime time.Time) (*DurableWriteLog, [][]string, error) {
+	t.Helper()
+	var replayedCommands [][]string
+	writeLog := NewDurableWriteLog(filePath)
+	openError := writeLog.Open(snapshotTime, func(commandArguments []string) {
+		replayedCommands = append(replayedCommands, commandArguments)
+	})
+	if openError == nil {
+		t.Cleanup(writeLog.Stop)
+	}
+	return writeLog, replayedCommands, openError
+}
+
+// markerEntry construit l'entrée marqueur d'un snapshot
+func markerEntry(snapshotTime time.Time) []string {
+	return []string{durableLogSnapshotMarker, snapshotMarkerValue(snapshotTime)}
+}
+
+// fileStartsWithEntry indique si le fichier commence par l'entrée donnée
+func fileStartsWithEntry(t *testing.T, filePath string, logEntry []string) bool {
+	t.Helper()
+	fileContent, readError := os.ReadFile(filePath)
+	if readError != nil {
+		t.Fatalf("lecture journal: %v", readError)
+	}
+	return bytes.HasPrefix(fileContent, encodeDurableLogEntry(logEntry))
+}
+
+func TestDurableWriteLogReplaysEntriesAfterLoadedSnapshotMarker(t *testing.T) {
+	firstSnapshot := time.Unix(1700000000, 0)
+	secondSnapshot := time.Unix(1700000100, 0)
+	logEntries := [][]string{
+		markerEntry(time.Time{}),
+		{"SET", "a", "1"},
+		markerEntry(firstSnapshot),
+		{"SET", "b", "2"},
+		markerEntry(secondSnapshot),
+		{"SET", "c", "3"},
+	}
+
+	testCases := []struct {
+		name             string
+		snapshotTime     time.Time
+		expectedReplayed [][]string
+		expectedStart    []string // Entrée par laquelle le fichier commence après compaction
+	}{
+		{"sans snapshot", time.Time{}, [][]string{{"SET", "a", "1"}, {"SET", "b", "2"}, {"SET", "c", "3"}}, markerEntry(time.Time{})},
+		{"premier snapshot", firstSnapshot, [][]string{{"SET", "b", "2"}, {"SET", "c", "3"}}, markerEntry(firstSnapshot)},
+		{"dernier snapshot", secondSnapshot, [][]string{{"SET", "c", "3"}}, markerEntry(secondSnapshot)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "durable.log")
+			writeDurableLogFile(t, filePath, logEntries, "")
+
+			_, replayedCommands, openError := openDurableLog(t, filePath, testCase.snapshotTime)
+			if openError != nil {
+				t.Fatalf("Open: %v", openError)
+			}
+			if !reflect.DeepEqual(replayedCommands, testCase.expectedReplayed) {
+				t.Fatalf("rejouées %v, attendu %v", replayedCommands, testCase.expectedReplayed)
+			}
+			if !fileStartsWithEntry(t, filePath, testCase.expectedStart) {
+				t.Fatalf("le journal ne commence pas par le marqueur du snapshot chargé %v", testCase.expectedStart)
+			}
+		})
+	}
+}
+
+func TestDurableWriteLogSetsAsideLogWithoutMatchingMarker(t *testing.T) {
+	logDirectory := t.TempDir()
+	filePath := filepath.Join(logDirectory, "durable.log")
+	writeDurableLogFile(t, filePath, [][]string{markerEntry(time.Unix(1700000000, 0)), {"SET", "a", "1"}}, "")
+
+	_, replayedCommands, openError := openDurableLog(t, filePath, time.Unix(1700000999, 0))
+	if openError != nil {
+		t.Fatalf("Open: %v", openError)
+	}
+	if len(replayedCommands) != 0 {
+		t.Fatalf("rejouées %v, attendu aucune (journal d'un autre snapshot)", replayedCommands)
+	}
+	orphanFiles, _ := filepath.Glob(filepath.Join(logDirectory, "durable.log.orphan-*"))
+	if len(orphanFiles) != 1 {
+		t.Fatalf("%d journal(aux) mis de côté, attendu 1", len(orphanFiles))
+	}
+	if !fileStartsWithEntry(t, filePath, markerEntry(time.Unix(1700000999, 0))) {
+		t.Fatalf("le nouveau journal ne commence pas par le marqueur du snapshot chargé")
+	}
+}
+
+func TestDurableWriteLogHandlesDamagedFiles(t *testing.T) {
+	testCases := []struct {
+		name             string
+		trailingBytes    string
+		followingEntries [][]string
+		expectError      bool
+		expectedReplayed [][]string
+	}{
+		{"fin tronquée par un crash", "*3\r\n$3\r\nSET\r\n$1\r\nb", nil, false, [][]string{{"SET", "a", "1"}}},
+		{"corruption au milieu", "garbage\r\n", [][]string{{"SET", "b", "2"}}, true, nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "durable.log")
+			var fileContent bytes.Buffer
+			fileContent.Write(encodeDurableLogEntry(markerEntry(time.Time{})))
+			fileContent.Write(encodeDurableLogEntry([]string{"SET", "a", "1"}))
+			validLength := fileContent.Len()
+			fileContent.WriteString(testCase.trailingBytes)
+			for _, logEntry := range testCase.followingEntries {
+				fileContent.Write(encodeDurableLogEntry(logEntry))
+			}
+			if writeError := os.WriteFile(filePath, fileContent.Bytes(), 0644); writeError != nil {
+				t.Fatalf("écriture journal: %v", writeError)
+			}
+
+			_, replayedCommands, openError := openDurableLog(t, filePath, time.Time{})
+			if testCase.expectError {
+				if openError == nil || !strings.Contains(openError.Error(), "corrompu") {
+					t.Fatalf("Open: %v, attendu une erreur de corruption", openError)
+				}
+				return
+			}
+			if openError != nil {
+				t.Fatalf("Open: %v", openError)
+			}
+			if !reflect.DeepEqual(replayedCommands, testCase.expectedReplayed) {
+				t.Fatalf("rejouées %v, attendu %v", replayedCommands, testCase.expectedReplayed)
+			}
+			if fileStatus, _ := os.Stat(filePath); fileStatus.Size() != int64(validLength) {
+				t.Fatalf("taille du journal %d, attendu %d (fin incomplète retirée)", fileStatus.Size(), validLength)
+			}
+		})
+	}
+}
+
+func TestDurableWriteLogCompactsBeforeSavedSnapshotAndReplaysTheRest(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "durable.log")
+	writeLog, _, openError := openDurableLog(t, filePath, time.Time{})
+	if openError != nil {
+		t.Fatalf("Open: %v", openError)
+	}
+
+	logCommand := func(commandArguments ...string) int64 {
+		writeOffset, logError := writeLog.LogWrite(func() ([]string, error) { return commandArguments, nil })
+		if logError != nil {
+			t.Fatalf("LogWrite: %v", logError)
+		}
+		return writeOffset
+	}
+
+	logCommand("SET", "avant", "1")
+	snapshotTime := time.Unix(1700000500, 0)
+	markerOffset, _ := writeLog.MarkSnapshot(func() time.Time { return snapshotTime })
+	lastOffset := logCommand("SET", "apres", "2")
+	if isDurable, waitError := writeLog.WaitDurable(lastOffset, 5*time.Second); !isDurable || waitError != nil {
+		t.Fatalf("WaitDurable: %v %v", isDurable, waitError)
+	}
+
+	writeLog.CompactBefore(markerOffset)
+	writeLog.Stop()
+	if !fileStartsWithEntry(t, filePath, markerEntry(snapshotTime)) {
+		t.Fatalf("le journal compacté ne commence pas par le marqueur du snapshot sauvegardé")
+	}
+
+	_, replayedCommands, reopenError := openDurableLog(t, filePath, snapshotTime)
+	if reopenError != nil {
+		t.Fatalf("réouverture: %v", reopenError)
+	}
+	if expectedReplayed := [][]string{{"SET", "apres", "2"}}; !reflect.DeepEqual(replayedCommands, expectedReplayed) {
+		t.Fatalf("rejouées %v, attendu %v", replayedCommands, expectedReplayed)
+	}
+}
+
+func TestDurableWriteLogRestartStartsFromRestoredSnapshot(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "durable.log")
+	writeLog, _, openError := openDurableLog(t, filePath, time.Time{})
+	if openError != nil {
+		t.Fatalf("Open: %v", openError)
+	}
+	writeLog.LogWrite(func() ([]string, error) { return []string{"SET", "perdue", "1"}, nil })
+
+	restoredSnapshot := time.Unix(1700000900, 0)
+	if restartError := writeLog.Restart(func() (time.Time, error) { return restoredSnapshot, nil }); restartError != nil {
+		t.Fatalf("Restart: %v", restartError)
+	}
+	writeLog.LogWrite(func() ([]string, error) { return []string{"SET", "gardee", "2"}, nil })
+	writeLog.Stop()
+
+	_, replayedCommands, reopenError := openDurableLog(t, filePath, restoredSnapshot)
+	if reopenError != nil {
+		t.Fatalf("réouverture: %v", reopenError)
+	}
+	if expectedReplayed := [][]string{{"SET", "gardee", "2"}}; !reflect.DeepEqual(replayedCommands, expectedReplayed) {
+		t.Fatalf("rejouées %v, attendu %v", replayedCommands, expectedReplayed)
+	}
+	if !fileStartsWithEntry(t, filePath, markerEntry(restoredSnapshot)) {
+		t.Fatalf("le journal ne repart pas du marqueur du snapshot restauré")
+	}
+}
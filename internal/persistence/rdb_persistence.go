@@ -1,49 +1,77 @@
 package persistence
 
 import (
+	"bufio"
 	"encoding/gob"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"redis-go/internal/config"
 	"redis-go/internal/storage"
 )
 
+// saveRuleCheckInterval est la fréquence d'évaluation des règles "save"
+const saveRuleCheckInterval = time.Second
+
+// failedSaveRetryDelay évite de relancer en boucle un BGSAVE qui vient d'échouer
+const failedSaveRetryDelay = 5 * time.Second
+
 // RDBPersistence gère la sauvegarde/restauration RDB
 type RDBPersistence struct {
-	filePath       string
-	saveInterval   time.Duration
-	storage        *storage.RedisInMemoryStorage
-	stopChannel    chan struct{}
-	saveInProgress bool
-	saveMutex      sync.Mutex
-	lastSaveTime   time.Time
-	totalSaves     int64
-	lastSaveStatus string
-	isShuttingDown bool
+	filePath            string
+	saveRules           []config.RDBSaveRule
+	storage             *storage.RedisInMemoryStorage
+	stopChannel         chan struct{}
+	saveInProgress      bool
+	saveMutex           sync.Mutex
+	lastSaveTime        time.Time
+	lastSaveAttemptTime time.Time
+	totalSaves          int64
+	lastSaveStatus      string
+	isShuttingDown      bool
+	compressionEnabled  atomic.Bool
+	lastSavePayload     int64 // Taille du dernier snapshot avant compression
+	lastSaveFileSize    int64 // Taille du dernier fichier écrit
+	retainedSnapshots   atomic.Int64
+	archivedSnapshots   map[string]SnapshotInfo // Description des snapshots conservés, par nom de fichier
+	archiveMutex        sync.Mutex
+	writeLog            *DurableWriteLog // Journal des écritures, compacté après chaque sauvegarde (nil = désactivé)
+	loadedSnapshotTime  time.Time        // Date du dernier snapshot chargé (zéro si aucun)
+}
+
+// snapshotSaveResult décrit une sauvegarde réussie
+type snapshotSaveResult struct {
+	savedChanges int64 // Changements couverts par le snapshot (relevés avant sa création)
+	payloadBytes int64
+	fileBytes    int64
 }
 
 // NewRDBPersistence crée une nouvelle instance de persistence RDB
-func NewRDBPersistence(filePath string, saveInterval time.Duration, storage *storage.RedisInMemoryStorage) *RDBPersistence {
+func NewRDBPersistence(filePath string, saveRules []config.RDBSaveRule, storage *storage.RedisInMemoryStorage) *RDBPersistence {
 	return &RDBPersistence{
-		filePath:       filePath,
-		saveInterval:   saveInterval,
-		storage:        storage,
-		stopChannel:    make(chan struct{}),
-		lastSaveStatus: "ok",
+		filePath:          filePath,
+		saveRules:         saveRules,
+		storage:           storage,
+		stopChannel:       make(chan struct{}),
+		lastSaveTime:      time.Now(), // Comme Redis : les règles comptent depuis le démarrage
+		lastSaveStatus:    "ok",
+		archivedSnapshots: make(map[string]SnapshotInfo),
 	}
 }
 
-// StartAutomaticSave démarre la sauvegarde automatique en arrière-plan
+// StartAutomaticSave démarre l'évaluation périodique des règles "save" en arrière-plan
 func (rdb *RDBPersistence) StartAutomaticSave() {
 	go func() {
-		ticker := time.NewTicker(rdb.saveInterval)
+		ticker := time.NewTicker(saveRuleCheckInterval)
 		defer ticker.Stop()
 
-		log.Printf("💾 RDB: Sauvegarde automatique démarrée (intervalle: %v)", rdb.saveInterval)
+		log.Printf("💾 RDB: Sauvegarde automatique démarrée (règles: %s)", rdb.describeSaveRules())
 
 		for {
 			select {
@@ -51,7 +79,12 @@ func (rdb *RDBPersistence) StartAutomaticSave() {
 				log.Printf("💾 RDB: Arrêt de la sauvegarde automatique")
 				return
 			case <-ticker.C:
-				if !rdb.isShuttingDown {
+				if rdb.isShuttingDown {
+					continue
+				}
+				if triggeredRule, shouldSave := rdb.findTriggeredSaveRule(); shouldSave {
+					log.Printf("💾 RDB: %d changements en %v, sauvegarde...",
+						triggeredRule.MinimumChanges, triggeredRule.Interval)
 					if err := rdb.BackgroundSave(); err != nil {
 						log.Printf("❌ RDB: Erreur sauvegarde automatique: %v", err)
 					}
@@ -61,6 +94,66 @@ func (rdb *RDBPersistence) StartAutomaticSave() {
 	}()
 }
 
+// findTriggeredSaveRule retourne la première règle satisfaite par le compteur de changements
+func (rdb *RDBPersistence) findTriggeredSaveRule() (config.RDBSaveRule, bool) {
+	changesSinceLastSave := rdb.storage.GetChangesSinceLastSave()
+
+	rdb.saveMutex.Lock()
+	defer rdb.saveMutex.Unlock()
+
+	if rdb.saveInProgress || changesSinceLastSave == 0 {
+		return config.RDBSaveRule{}, false
+	}
+	// Après un échec, on attend avant de retenter
+	if rdb.lastSaveStatus != "ok" && time.Since(rdb.lastSaveAttemptTime) < failedSaveRetryDelay {
+		return config.RDBSaveRule{}, false
+	}
+
+	elapsedSinceLastSave := time.Since(rdb.lastSaveTime)
+	for _, saveRule := range rdb.saveRules {
+		if changesSinceLastSave >= saveRule.MinimumChanges && elapsedSinceLastSave >= saveRule.Interval {
+			return saveRule, true
+		}
+	}
+	return config.RDBSaveRule{}, false
+}
+
+// SetSaveRules remplace à chaud les règles de sauvegarde automatique (CONFIG SET save)
+func (rdb *RDBPersistence) SetSaveRules(saveRules []config.RDBSaveRule) {
+	rdb.saveMutex.Lock()
+	rdb.saveRules = saveRules
+	rdb.saveMutex.Unlock()
+
+	log.Printf("💾 RDB: Nouvelles règles de sauvegarde: %s", rdb.describeSaveRules())
+}
+
+// SetDurableWriteLog associe le journal des écritures : chaque snapshot y inscrit un marqueur
+// et le journal est compacté jusqu'à ce marqueur une fois le fichier RDB remplacé
+func (rdb *RDBPersistence) SetDurableWriteLog(writeLog *DurableWriteLog) {
+	rdb.writeLog = writeLog
+}
+
+// LoadedSnapshotTime retourne la date du snapshot chargé au démarrage (zéro si aucun)
+func (rdb *RDBPersistence) LoadedSnapshotTime() time.Time {
+	return rdb.loadedSnapshotTime
+}
+
+// SetCompression active ou désactive la compression gzip des prochaines sauvegardes (rdbcompression)
+func (rdb *RDBPersistence) SetCompression(compressionEnabled bool) {
+	rdb.compressionEnabled.Store(compressionEnabled)
+}
+
+// describeSaveRules retourne les règles sous forme lisible pour les logs
+func (rdb *RDBPersistence) describeSaveRules() string {
+	rdb.saveMutex.Lock()
+	defer rdb.saveMutex.Unlock()
+
+	if len(rdb.saveRules) == 0 {
+		return "désactivées"
+	}
+	return config.FormatRDBSaveRules(rdb.saveRules)
+}
+
 // BackgroundSave effectue une sauvegarde non-bloquante (BGSAVE)
 func (rdb *RDBPersistence) BackgroundSave() error {
 	rdb.saveMutex.Lock()
@@ -73,18 +166,15 @@ func (rdb *RDBPersistence) BackgroundSave() error {
 
 	// Lancer la sauvegarde dans une goroutine séparée
 	go func() {
-		defer func() {
-			rdb.saveMutex.Lock()
-			rdb.saveInProgress = false
-			rdb.saveMutex.Unlock()
-		}()
-
-		if err := rdb.performSave(); err != nil {
-			log.Printf("❌ RDB: Erreur BGSAVE: %v", err)
-			rdb.lastSaveStatus = "error"
-		} else {
-			rdb.lastSaveStatus = "ok"
+		saveResult, saveError := rdb.performSave()
+		if saveError != nil {
+			log.Printf("❌ RDB: Erreur BGSAVE: %v", saveError)
 		}
+
+		rdb.saveMutex.Lock()
+		rdb.recordSaveResult(saveResult, saveError)
+		rdb.saveInProgress = false
+		rdb.saveMutex.Unlock()
 	}()
 
 	return nil
@@ -104,60 +194,147 @@ func (rdb *RDBPersistence) Save() error {
 		rdb.saveInProgress = false
 	}()
 
-	return rdb.performSave()
+	saveResult, saveError := rdb.performSave()
+	rdb.recordSaveResult(saveResult, saveError)
+	return saveError
+}
+
+// recordSaveResult met à jour les statistiques après une sauvegarde (saveMutex doit être détenu)
+// Le compteur de changements n'est décrémenté qu'en cas de succès
+func (rdb *RDBPersistence) recordSaveResult(saveResult snapshotSaveResult, saveError error) {
+	rdb.lastSaveAttemptTime = time.Now()
+	if saveError != nil {
+		rdb.lastSaveStatus = "error"
+		return
+	}
+
+	rdb.storage.AcknowledgeSavedChanges(saveResult.savedChanges)
+	rdb.lastSavePayload = saveResult.payloadBytes
+	rdb.lastSaveFileSize = saveResult.fileBytes
+	rdb.lastSaveTime = rdb.lastSaveAttemptTime
+	rdb.lastSaveStatus = "ok"
+	rdb.totalSaves++
 }
 
 // performSave effectue la sauvegarde réelle
-func (rdb *RDBPersistence) performSave() error {
+// Les clés sont écrites une à une depuis un curseur copy-on-write, sans matérialiser le snapshot
+func (rdb *RDBPersistence) performSave() (snapshotSaveResult, error) {
 	startTime := time.Now()
 	log.Printf("💾 RDB: Début sauvegarde...")
 
 	// Créer le dossier si nécessaire
 	if err := os.MkdirAll(filepath.Dir(rdb.filePath), 0755); err != nil {
-		return fmt.Errorf("création dossier: %v", err)
+		return snapshotSaveResult{}, fmt.Errorf("création dossier: %v", err)
 	}
 
 	// Fichier temporaire pour sauvegarde atomique
 	tempFile := rdb.filePath + ".tmp"
 	file, err := os.Create(tempFile)
 	if err != nil {
-		return fmt.Errorf("création fichier temp: %v", err)
+		return snapshotSaveResult{}, fmt.Errorf("création fichier temp: %v", err)
 	}
 	defer file.Close()
 
-	// Créer le snapshot des données
-	snapshot := rdb.storage.CreateSnapshot()
+	// Les écritures entre le relevé et le snapshot seront comptées deux fois : au pire une sauvegarde de plus
+	savedChanges := rdb.storage.GetChangesSinceLastSave()
+	snapshotCursor, markerOffset, markerEndOffset := rdb.beginSnapshot()
+	defer snapshotCursor.Close()
 
-	// Encoder les données
-	encoder := gob.NewEncoder(file)
-	if err := encoder.Encode(snapshot); err != nil {
+	snapshotWriter, err := rdb.writeSnapshot(file, snapshotCursor)
+	if err != nil {
 		os.Remove(tempFile)
-		return fmt.Errorf("encodage données: %v", err)
+		return snapshotSaveResult{}, fmt.Errorf("encodage données: %v", err)
 	}
 
 	// Forcer l'écriture sur disque
 	if err := file.Sync(); err != nil {
 		os.Remove(tempFile)
-		return fmt.Errorf("sync fichier: %v", err)
+		return snapshotSaveResult{}, fmt.Errorf("sync fichier: %v", err)
 	}
 
 	file.Close()
 
+	// Le marqueur doit être sur disque avant le fichier RDB : au redémarrage, le journal est rejoué à partir de lui
+	if rdb.writeLog != nil {
+		if _, waitError := rdb.writeLog.WaitDurable(markerEndOffset, 0); waitError != nil {
+			os.Remove(tempFile)
+			return snapshotSaveResult{}, fmt.Errorf("journal des écritures: %v", waitError)
+		}
+	}
+
 	// Remplacer le fichier principal atomiquement
 	if err := os.Rename(tempFile, rdb.filePath); err != nil {
 		os.Remove(tempFile)
-		return fmt.Errorf("remplacement fichier: %v", err)
+		return snapshotSaveResult{}, fmt.Errorf("remplacement fichier: %v", err)
 	}
 
-	// Mettre à jour les statistiques
-	rdb.lastSaveTime = time.Now()
-	rdb.totalSaves++
+	if rdb.writeLog != nil {
+		rdb.writeLog.CompactBefore(markerOffset)
+	}
+
+	rdb.archiveSnapshot(SnapshotInfo{
+		CreatedAt:  snapshotCursor.Timestamp,
+		KeyCount:   snapshotWriter.RecordCount(),
+		FileBytes:  snapshotWriter.FileBytes(),
+		Compressed: rdb.compressionEnabled.Load(),
+	})
 
 	duration := time.Since(startTime)
-	log.Printf("✅ RDB: Sauvegarde terminée (%s, %d clés, %v)",
-		rdb.filePath, len(snapshot.Data), duration)
+	log.Printf("✅ RDB: Sauvegarde terminée (%s, %d clés, %d octets, ratio %s, %d copiées pendant l'écriture, %v)",
+		rdb.filePath, snapshotWriter.RecordCount(), snapshotWriter.FileBytes(),
+		formatCompressionRatio(snapshotWriter.PayloadBytes(), snapshotWriter.FileBytes()),
+		snapshotCursor.PreservedCount(), duration)
+
+	return snapshotSaveResult{
+		savedChanges: savedChanges,
+		payloadBytes: snapshotWriter.PayloadBytes(),
+		fileBytes:    snapshotWriter.FileBytes(),
+	}, nil
+}
 
-	return nil
+// beginSnapshot crée le curseur copy-on-write ; avec le journal des écritures, le snapshot est pris
+// entre deux écritures et son marqueur est ajouté au journal (offsets de début et de fin du marqueur)
+func (rdb *RDBPersistence) beginSnapshot() (*storage.SnapshotCursor, int64, int64) {
+	if rdb.writeLog == nil {
+		return rdb.storage.BeginSnapshot(), -1, -1
+	}
+
+	var snapshotCursor *storage.SnapshotCursor
+	markerOffset, markerEndOffset := rdb.writeLog.MarkSnapshot(func() time.Time {
+		snapshotCursor = rdb.storage.BeginSnapshot()
+		return snapshotCursor.Timestamp
+	})
+	return snapshotCursor, markerOffset, markerEndOffset
+}
+
+// writeSnapshot écrit toutes les clés du curseur dans le fichier
+func (rdb *RDBPersistence) writeSnapshot(file io.Writer, snapshotCursor *storage.SnapshotCursor) (*SnapshotWriter, error) {
+	snapshotWriter, err := NewSnapshotWriter(file, snapshotCursor.Timestamp, rdb.compressionEnabled.Load())
+	if err != nil {
+		return nil, err
+	}
+	for {
+		snapshotRecord, recordAvailable := snapshotCursor.Next()
+		if !recordAvailable {
+			break
+		}
+		if err := snapshotWriter.WriteRecord(snapshotRecord); err != nil {
+			return nil, err
+		}
+	}
+	snapshotWriter.SetSearchIndexes(snapshotCursor.SearchIndexes)
+	if err := snapshotWriter.Close(); err != nil {
+		return nil, err
+	}
+	return snapshotWriter, nil
+}
+
+// formatCompressionRatio retourne le rapport taille brute / taille écrite (proche de 1 sans compression)
+func formatCompressionRatio(payloadBytes int64, fileBytes int64) string {
+	if payloadBytes == 0 || fileBytes == 0 {
+		return "0.00"
+	}
+	return fmt.Sprintf("%.2f", float64(payloadBytes)/float64(fileBytes))
 }
 
 // LoadSnapshot restaure les données depuis le fichier RDB
@@ -168,29 +345,81 @@ func (rdb *RDBPersistence) LoadSnapshot() error {
 	}
 
 	log.Printf("📥 RDB: Chargement depuis %s...", rdb.filePath)
+	return rdb.loadSnapshotFile(rdb.filePath)
+}
 
-	file, err := os.Open(rdb.filePath)
+// loadSnapshotFile remplace les données du stockage par le contenu d'un fichier de snapshot
+func (rdb *RDBPersistence) loadSnapshotFile(snapshotPath string) error {
+	file, err := os.Open(snapshotPath)
 	if err != nil {
 		return fmt.Errorf("ouverture fichier RDB: %v", err)
 	}
 	defer file.Close()
 
-	decoder := gob.NewDecoder(file)
-	var snapshot storage.StorageSnapshot
+	snapshotReader, err := NewSnapshotReader(file)
+	if err == ErrLegacySnapshotFormat {
+		return rdb.loadLegacySnapshot(file)
+	}
+	if err != nil {
+		return fmt.Errorf("décodage RDB: %v", err)
+	}
+
+	// Les clés sont chargées à la volée ; le stockage n'est remplacé qu'une fois le fichier lu en entier
+	snapshotLoader := rdb.storage.BeginSnapshotLoad()
+	expiredKeys := 0
+	for {
+		snapshotRecord, err := snapshotReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("décodage RDB: %v", err)
+		}
+		if !snapshotLoader.LoadRecord(snapshotRecord) {
+			expiredKeys++
+		}
+	}
+	snapshotLoader.LoadSearchIndexes(snapshotReader.SearchIndexes)
+	loadedKeys := snapshotLoader.Commit()
+	rdb.loadedSnapshotTime = snapshotReader.CreatedAt
+
+	log.Printf("✅ RDB: Données restaurées (%d clés, %d expirées ignorées, snapshot du %v)",
+		loadedKeys, expiredKeys, snapshotReader.CreatedAt.Format("2006-01-02 15:04:05"))
+
+	return nil
+}
 
-	if err := decoder.Decode(&snapshot); err != nil {
+// loadLegacySnapshot restaure un fichier écrit en un seul bloc gob par les versions précédentes
+func (rdb *RDBPersistence) loadLegacySnapshot(file *os.File) error {
+	var snapshot storage.StorageSnapshot
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("relecture fichier RDB: %v", err)
+	}
+	if err := gob.NewDecoder(bufio.NewReader(file)).Decode(&snapshot); err != nil {
 		return fmt.Errorf("décodage RDB: %v", err)
 	}
 
-	// Restaurer les données dans le storage
 	rdb.storage.RestoreFromSnapshot(snapshot)
-
-	log.Printf("✅ RDB: Données restaurées (%d clés, snapshot du %v)",
+	rdb.loadedSnapshotTime = snapshot.Timestamp
+	log.Printf("✅ RDB: Données restaurées depuis le format historique (%d clés, snapshot du %v), réécriture en flux à la prochaine sauvegarde",
 		len(snapshot.Data), snapshot.Timestamp.Format("2006-01-02 15:04:05"))
-
 	return nil
 }
 
+// replaceDataFromFile charge un fichier de snapshot hors du flux d'écritures (DEBUG RELOAD)
+// Le journal des écritures repart alors du marqueur de ce snapshot
+func (rdb *RDBPersistence) replaceDataFromFile(snapshotPath string) error {
+	if rdb.writeLog == nil {
+		return rdb.loadSnapshotFile(snapshotPath)
+	}
+	return rdb.writeLog.Restart(func() (time.Time, error) {
+		if loadError := rdb.loadSnapshotFile(snapshotPath); loadError != nil {
+			return time.Time{}, loadError
+		}
+		return rdb.loadedSnapshotTime, nil
+	})
+}
+
 // Stop arrête la persistence et effectue une sauvegarde finale
 func (rdb *RDBPersistence) Stop() {
 	rdb.isShuttingDown = true
@@ -216,6 +445,11 @@ func (rdb *RDBPersistence) GetStats() map[string]interface{} {
 		"rdb_last_bgsave_status":      rdb.lastSaveStatus,
 		"rdb_total_saves":             rdb.totalSaves,
 		"rdb_file_path":               rdb.filePath,
+		"rdb_compression_enabled":     rdb.compressionEnabled.Load(),
+		"rdb_last_save_raw_bytes":     rdb.lastSavePayload,
+		"rdb_last_save_file_bytes":    rdb.lastSaveFileSize,
+		"rdb_compression_ratio":       formatCompressionRatio(rdb.lastSavePayload, rdb.lastSaveFileSize),
+		"rdb_snapshot_retention":      rdb.retainedSnapshots.Load(),
 	}
 }
 
@@ -228,5 +462,7 @@ func (rdb *RDBPersistence) IsSaveInProgress() bool {
 
 // GetLastSaveTime retourne le timestamp de la dernière sauvegarde
 func (rdb *RDBPersistence) GetLastSaveTime() int64 {
+	rdb.saveMutex.Lock()
+	defer rdb.saveMutex.Unlock()
 	return rdb.lastSaveTime.Unix()
 }
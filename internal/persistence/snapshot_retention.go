@@ -0,0 +1,269 @@
+package persistence
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// archivedSnapshotTimeLayout horodate le nom des snapshots conservés (UTC, à la milliseconde)
+const archivedSnapshotTimeLayout = "20060102T150405.000Z"
+
+// SnapshotInfo décrit un snapshot conservé sur disque
+type SnapshotInfo struct {
+	Name       string
+	CreatedAt  time.Time
+	KeyCount   int
+	FileBytes  int64
+	Compressed bool
+}
+
+// SetRetention fixe le nombre de snapshots horodatés conservés (0 = seul le fichier courant est gardé)
+func (rdb *RDBPersistence) SetRetention(retainedSnapshots int) {
+	rdb.retainedSnapshots.Store(int64(retainedSnapshots))
+	if retainedSnapshots > 0 {
+		rdb.pruneArchivedSnapshots()
+	}
+}
+
+// archivedSnapshotPattern retourne le motif des snapshots conservés (ex: data/dump-*.rdb)
+func (rdb *RDBPersistence) archivedSnapshotPattern() string {
+	fileExtension := filepath.Ext(rdb.filePath)
+	return strings.TrimSuffix(rdb.filePath, fileExtension) + "-*" + fileExtension
+}
+
+// archivedSnapshotPath retourne le chemin du snapshot conservé pour une date de création
+func (rdb *RDBPersistence) archivedSnapshotPath(createdAt time.Time) string {
+	fileExtension := filepath.Ext(rdb.filePath)
+	return strings.TrimSuffix(rdb.filePath, fileExtension) + "-" + createdAt.UTC().Format(archivedSnapshotTimeLayout) + fileExtension
+}
+
+// parseArchivedSnapshotTime extrait la date de création du nom d'un snapshot conservé
+func (rdb *RDBPersistence) parseArchivedSnapshotTime(snapshotPath string) (time.Time, bool) {
+	fileExtension := filepath.Ext(rdb.filePath)
+	filePrefix := strings.TrimSuffix(filepath.Base(rdb.filePath), fileExtension) + "-"
+
+	snapshotName := filepath.Base(snapshotPath)
+	if !strings.HasPrefix(snapshotName, filePrefix) || !strings.HasSuffix(snapshotName, fileExtension) {
+		return time.Time{}, false
+	}
+	timestampText := strings.TrimSuffix(strings.TrimPrefix(snapshotName, filePrefix), fileExtension)
+	createdAt, parseError := time.Parse(archivedSnapshotTimeLayout, timestampText)
+	return createdAt, parseError == nil
+}
+
+// archiveSnapshot conserve une copie horodatée du fichier qui vient d'être écrit
+// Un lien physique suffit : la sauvegarde suivante remplace le fichier courant par renommage
+func (rdb *RDBPersistence) archiveSnapshot(snapshotInfo SnapshotInfo) {
+	if rdb.retainedSnapshots.Load() <= 0 {
+		return
+	}
+
+	archivePath := rdb.archivedSnapshotPath(snapshotInfo.CreatedAt)
+	if linkError := os.Link(rdb.filePath, archivePath); linkError != nil {
+		if copyError := copySnapshotFile(rdb.filePath, archivePath); copyError != nil {
+			log.Printf("❌ RDB: Impossible de conserver le snapshot %s: %v", archivePath, copyError)
+			return
+		}
+	}
+
+	snapshotInfo.Name = filepath.Base(archivePath)
+	rdb.archiveMutex.Lock()
+	rdb.archivedSnapshots[snapshotInfo.Name] = snapshotInfo
+	rdb.archiveMutex.Unlock()
+
+	rdb.pruneArchivedSnapshots()
+}
+
+// pruneArchivedSnapshots supprime les snapshots conservés au-delà de la limite (les plus anciens d'abord)
+func (rdb *RDBPersistence) pruneArchivedSnapshots() {
+	retainedSnapshots := int(rdb.retainedSnapshots.Load())
+	if retainedSnapshots <= 0 {
+		return
+	}
+
+	snapshotPaths, listError := rdb.listArchivedSnapshotPaths()
+	if listError != nil {
+		log.Printf("❌ RDB: Lecture des snapshots conservés impossible: %v", listError)
+		return
+	}
+	for _, expiredPath := range snapshotPaths[min(retainedSnapshots, len(snapshotPaths)):] {
+		if removeError := os.Remove(expiredPath); removeError != nil {
+			log.Printf("❌ RDB: Suppression de %s impossible: %v", expiredPath, removeError)
+			continue
+		}
+		rdb.archiveMutex.Lock()
+		delete(rdb.archivedSnapshots, filepath.Base(expiredPath))
+		rdb.archiveMutex.Unlock()
+		log.Printf("🗑️  RDB: Snapshot %s supprimé (rétention %d)", filepath.Base(expiredPath), retainedSnapshots)
+	}
+}
+
+// listArchivedSnapshotPaths retourne les snapshots conservés, du plus récent au plus ancien
+func (rdb *RDBPersistence) listArchivedSnapshotPaths() ([]string, error) {
+	candidatePaths, globError := filepath.Glob(rdb.archivedSnapshotPattern())
+	if globError != nil {
+		return nil, globError
+	}
+
+	creationTimes := make(map[string]time.Time)
+	snapshotPaths := make([]string, 0, len(candidatePaths))
+	for _, candidatePath := range candidatePaths {
+		if createdAt, isArchive := rdb.parseArchivedSnapshotTime(candidatePath); isArchive {
+			creationTimes[candidatePath] = createdAt
+			snapshotPaths = append(snapshotPaths, candidatePath)
+		}
+	}
+	sort.Slice(snapshotPaths, func(leftIndex, rightIndex int) bool {
+		return creationTimes[snapshotPaths[leftIndex]].After(creationTimes[snapshotPaths[rightIndex]])
+	})
+	return snapshotPaths, nil
+}
+
+// ListSnapshots décrit les snapshots conservés, du plus récent au plus ancien
+// Un fichier inconnu (écrit avant le démarrage) est relu une fois pour compter ses clés
+func (rdb *RDBPersistence) ListSnapshots() ([]SnapshotInfo, error) {
+	snapshotPaths, listError := rdb.listArchivedSnapshotPaths()
+	if listError != nil {
+		return nil, listError
+	}
+
+	snapshotInfos := make([]SnapshotInfo, 0, len(snapshotPaths))
+	for _, snapshotPath := range snapshotPaths {
+		snapshotName := filepath.Base(snapshotPath)
+
+		rdb.archiveMutex.Lock()
+		snapshotInfo, infoCached := rdb.archivedSnapshots[snapshotName]
+		rdb.archiveMutex.Unlock()
+
+		if !infoCached {
+			var describeError error
+			if snapshotInfo, describeError = describeSnapshotFile(snapshotPath); describeError != nil {
+				log.Printf("⚠️  RDB: Snapshot %s illisible: %v", snapshotName, describeError)
+				continue
+			}
+			snapshotInfo.CreatedAt, _ = rdb.parseArchivedSnapshotTime(snapshotPath)
+
+			rdb.archiveMutex.Lock()
+			rdb.archivedSnapshots[snapshotName] = snapshotInfo
+			rdb.archiveMutex.Unlock()
+		}
+		snapshotInfos = append(snapshotInfos, snapshotInfo)
+	}
+	return snapshotInfos, nil
+}
+
+// RestoreSnapshot remplace les données du serveur par celles d'un snapshot conservé
+// Le snapshot devient aussi le fichier courant, pour qu'un redémarrage reparte du même état
+func (rdb *RDBPersistence) RestoreSnapshot(snapshotName string) error {
+	if snapshotName != filepath.Base(snapshotName) {
+		return fmt.Errorf("nom de snapshot invalide '%s'", snapshotName)
+	}
+	snapshotPath := filepath.Join(filepath.Dir(rdb.filePath), snapshotName)
+	if _, isArchive := rdb.parseArchivedSnapshotTime(snapshotPath); !isArchive {
+		return fmt.Errorf("snapshot inconnu '%s'", snapshotName)
+	}
+	if _, statError := os.Stat(snapshotPath); statError != nil {
+		return fmt.Errorf("snapshot inconnu '%s'", snapshotName)
+	}
+
+	rdb.saveMutex.Lock()
+	defer rdb.saveMutex.Unlock()
+	if rdb.saveInProgress {
+		return fmt.Errorf("sauvegarde en cours, réessayez plus tard")
+	}
+	rdb.saveInProgress = true
+	defer func() {
+		rdb.saveInProgress = false
+	}()
+
+	// Vérifier le snapshot avant de toucher au fichier courant
+	snapshotInfo, describeError := describeSnapshotFile(snapshotPath)
+	if describeError != nil {
+		return fmt.Errorf("snapshot '%s' corrompu: %v", snapshotName, describeError)
+	}
+
+	tempFile := rdb.filePath + ".tmp"
+	if copyError := copySnapshotFile(snapshotPath, tempFile); copyError != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("copie du snapshot: %v", copyError)
+	}
+	if renameError := os.Rename(tempFile, rdb.filePath); renameError != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("remplacement fichier: %v", renameError)
+	}
+
+	if loadError := rdb.replaceDataFromFile(rdb.filePath); loadError != nil {
+		return loadError
+	}
+	rdb.lastSaveTime = time.Now()
+	log.Printf("⏪ RDB: Snapshot %s restauré (%d clés)", snapshotName, snapshotInfo.KeyCount)
+	return nil
+}
+
+// Reload sauvegarde les données puis les recharge depuis le fichier courant (DEBUG RELOAD)
+func (rdb *RDBPersistence) Reload() error {
+	if saveError := rdb.Save(); saveError != nil {
+		return saveError
+	}
+	return rdb.replaceDataFromFile(rdb.filePath)
+}
+
+// describeSnapshotFile relit un fichier de snapshot en entier pour le décrire et vérifier son intégrité
+func describeSnapshotFile(snapshotPath string) (SnapshotInfo, error) {
+	snapshotFile, openError := os.Open(snapshotPath)
+	if openError != nil {
+		return SnapshotInfo{}, openError
+	}
+	defer snapshotFile.Close()
+
+	fileStatus, statError := snapshotFile.Stat()
+	if statError != nil {
+		return SnapshotInfo{}, statError
+	}
+
+	snapshotReader, readerError := NewSnapshotReader(snapshotFile)
+	if readerError != nil {
+		return SnapshotInfo{}, readerError
+	}
+	for {
+		if _, recordError := snapshotReader.Next(); recordError == io.EOF {
+			break
+		} else if recordError != nil {
+			return SnapshotInfo{}, recordError
+		}
+	}
+
+	return SnapshotInfo{
+		Name:       filepath.Base(snapshotPath),
+		CreatedAt:  snapshotReader.CreatedAt,
+		KeyCount:   snapshotReader.RecordCount(),
+		FileBytes:  fileStatus.Size(),
+		Compressed: snapshotReader.Compressed,
+	}, nil
+}
+
+// copySnapshotFile copie un fichier de snapshot et force son écriture sur disque
+func copySnapshotFile(sourcePath string, destinationPath string) error {
+	sourceFile, openError := os.Open(sourcePath)
+	if openError != nil {
+		return openError
+	}
+	defer sourceFile.Close()
+
+	destinationFile, createError := os.Create(destinationPath)
+	if createError != nil {
+		return createError
+	}
+	defer destinationFile.Close()
+
+	if _, copyError := io.Copy(destinationFile, sourceFile); copyError != nil {
+		return copyError
+	}
+	return destinationFile.Sync()
+}
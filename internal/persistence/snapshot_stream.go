@@ -0,0 +1,228 @@
+package persistence
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"time"
+
+	"redis-go/internal/storage"
+)
+
+// snapshotFileMagic ouvre chaque fichier écrit par SnapshotWriter, suivi d'un octet d'options
+const snapshotFileMagic = "RGOSNAP"
+
+// snapshotGzipFlag indique que la suite du fichier est compressée avec gzip
+const snapshotGzipFlag byte = 1 << 0
+
+// snapshotStreamFormat identifie un flux gob écrit enregistrement par enregistrement
+const snapshotStreamFormat = "redis-go-snapshot-stream"
+
+// snapshotStreamVersion est la version courante du format en flux
+// Version 2 : enregistrements de type stream (les fichiers en version 1 restent lisibles)
+// Version 3 : enregistrements de type zset (index géographiques)
+// Version 4 : documents JSON
+// Version 5 : définitions des index secondaires (FT.CREATE) dans le marqueur de fin
+const snapshotStreamVersion = 5
+
+// ErrLegacySnapshotFormat signale un fichier gob d'un seul bloc (storage.StorageSnapshot)
+var ErrLegacySnapshotFormat = fmt.Errorf("format de snapshot historique")
+
+// snapshotFileHeader est le premier élément gob du flux
+// Ses champs ne recouvrent pas ceux de storage.StorageSnapshot, ce qui permet de reconnaître l'ancien format
+type snapshotFileHeader struct {
+	StreamFormat  string
+	FormatVersion int
+	CreatedAt     time.Time
+}
+
+// snapshotStreamEntry est un élément du flux : une clé, ou le marqueur de fin avec le nombre de clés écrites
+// et les index secondaires à recréer
+type snapshotStreamEntry struct {
+	Record        storage.SnapshotRecord
+	EndOfStream   bool
+	RecordCount   int
+	SearchIndexes []storage.SearchIndexDefinition
+}
+
+// byteCountingWriter compte les octets qui le traversent
+type byteCountingWriter struct {
+	destination  io.Writer
+	writtenBytes int64
+}
+
+func (countingWriter *byteCountingWriter) Write(data []byte) (int, error) {
+	writtenLength, writeError := countingWriter.destination.Write(data)
+	countingWriter.writtenBytes += int64(writtenLength)
+	return writtenLength, writeError
+}
+
+// SnapshotWriter écrit un snapshot clé par clé, avec compression gzip optionnelle
+type SnapshotWriter struct {
+	bufferedWriter *bufio.Writer
+	fileCounter    *byteCountingWriter // Octets écrits dans le fichier
+	payloadCounter *byteCountingWriter // Octets avant compression
+	gzipWriter     *gzip.Writer
+	encoder        *gob.Encoder
+	recordCount    int
+	searchIndexes  []storage.SearchIndexDefinition
+}
+
+// NewSnapshotWriter écrit l'en-tête du fichier et prépare l'écriture des clés
+func NewSnapshotWriter(destination io.Writer, createdAt time.Time, compressed bool) (*SnapshotWriter, error) {
+	bufferedWriter := bufio.NewWriter(destination)
+	snapshotWriter := &SnapshotWriter{
+		bufferedWriter: bufferedWriter,
+		fileCounter:    &byteCountingWriter{destination: bufferedWriter},
+	}
+
+	var fileFlags byte
+	if compressed {
+		fileFlags |= snapshotGzipFlag
+	}
+	if _, err := snapshotWriter.fileCounter.Write(append([]byte(snapshotFileMagic), fileFlags)); err != nil {
+		return nil, fmt.Errorf("écriture en-tête: %v", err)
+	}
+
+	var payloadDestination io.Writer = snapshotWriter.fileCounter
+	if compressed {
+		snapshotWriter.gzipWriter = gzip.NewWriter(snapshotWriter.fileCounter)
+		payloadDestination = snapshotWriter.gzipWriter
+	}
+	snapshotWriter.payloadCounter = &byteCountingWriter{destination: payloadDestination}
+	snapshotWriter.encoder = gob.NewEncoder(snapshotWriter.payloadCounter)
+
+	fileHeader := snapshotFileHeader{
+		StreamFormat:  snapshotStreamFormat,
+		FormatVersion: snapshotStreamVersion,
+		CreatedAt:     createdAt,
+	}
+	if err := snapshotWriter.encoder.Encode(fileHeader); err != nil {
+		return nil, fmt.Errorf("encodage en-tête: %v", err)
+	}
+	return snapshotWriter, nil
+}
+
+// WriteRecord ajoute une clé au flux
+func (snapshotWriter *SnapshotWriter) WriteRecord(snapshotRecord storage.SnapshotRecord) error {
+	if err := snapshotWriter.encoder.Encode(snapshotStreamEntry{Record: snapshotRecord}); err != nil {
+		return fmt.Errorf("encodage clé '%s': %v", snapshotRecord.Key, err)
+	}
+	snapshotWriter.recordCount++
+	return nil
+}
+
+// SetSearchIndexes indique les index secondaires à écrire dans le marqueur de fin
+func (snapshotWriter *SnapshotWriter) SetSearchIndexes(searchIndexes []storage.SearchIndexDefinition) {
+	snapshotWriter.searchIndexes = searchIndexes
+}
+
+// Close écrit le marqueur de fin, termine la compression et vide le tampon (le fichier reste ouvert)
+func (snapshotWriter *SnapshotWriter) Close() error {
+	endOfStream := snapshotStreamEntry{EndOfStream: true, RecordCount: snapshotWriter.recordCount, SearchIndexes: snapshotWriter.searchIndexes}
+	if err := snapshotWriter.encoder.Encode(endOfStream); err != nil {
+		return fmt.Errorf("encodage fin de flux: %v", err)
+	}
+	if snapshotWriter.gzipWriter != nil {
+		if err := snapshotWriter.gzipWriter.Close(); err != nil {
+			return fmt.Errorf("compression: %v", err)
+		}
+	}
+	return snapshotWriter.bufferedWriter.Flush()
+}
+
+// RecordCount retourne le nombre de clés écrites
+func (snapshotWriter *SnapshotWriter) RecordCount() int {
+	return snapshotWriter.recordCount
+}
+
+// PayloadBytes retourne la taille des données avant compression
+func (snapshotWriter *SnapshotWriter) PayloadBytes() int64 {
+	return snapshotWriter.payloadCounter.writtenBytes
+}
+
+// FileBytes retourne la taille écrite dans le fichier
+func (snapshotWriter *SnapshotWriter) FileBytes() int64 {
+	return snapshotWriter.fileCounter.writtenBytes
+}
+
+// SnapshotReader relit un snapshot clé par clé
+type SnapshotReader struct {
+	decoder       *gob.Decoder
+	CreatedAt     time.Time
+	Compressed    bool
+	SearchIndexes []storage.SearchIndexDefinition // Renseigné une fois io.EOF retourné par Next
+	recordCount   int
+	finished      bool
+}
+
+// NewSnapshotReader lit l'en-tête d'un fichier de snapshot
+// Les fichiers sans préfixe (flux non compressé des versions précédentes) sont acceptés ;
+// retourne ErrLegacySnapshotFormat pour un fichier écrit en un seul bloc
+func NewSnapshotReader(source io.Reader) (*SnapshotReader, error) {
+	bufferedReader := bufio.NewReader(source)
+	snapshotReader := &SnapshotReader{}
+
+	var payloadSource io.Reader = bufferedReader
+	filePrefix, _ := bufferedReader.Peek(len(snapshotFileMagic) + 1)
+	if bytes.HasPrefix(filePrefix, []byte(snapshotFileMagic)) {
+		if len(filePrefix) == len(snapshotFileMagic) {
+			return nil, fmt.Errorf("en-tête de snapshot tronqué")
+		}
+		fileFlags := filePrefix[len(snapshotFileMagic)]
+		bufferedReader.Discard(len(filePrefix))
+
+		if fileFlags&snapshotGzipFlag != 0 {
+			gzipReader, err := gzip.NewReader(bufferedReader)
+			if err != nil {
+				return nil, fmt.Errorf("décompression: %v", err)
+			}
+			payloadSource = gzipReader
+			snapshotReader.Compressed = true
+		}
+	}
+	snapshotReader.decoder = gob.NewDecoder(payloadSource)
+
+	var fileHeader snapshotFileHeader
+	if err := snapshotReader.decoder.Decode(&fileHeader); err != nil || fileHeader.StreamFormat != snapshotStreamFormat {
+		if snapshotReader.Compressed {
+			return nil, fmt.Errorf("en-tête de snapshot illisible: %v", err)
+		}
+		return nil, ErrLegacySnapshotFormat
+	}
+	if fileHeader.FormatVersion > snapshotStreamVersion {
+		return nil, fmt.Errorf("version de snapshot %d non supportée", fileHeader.FormatVersion)
+	}
+	snapshotReader.CreatedAt = fileHeader.CreatedAt
+	return snapshotReader, nil
+}
+
+// Next retourne la clé suivante, ou io.EOF une fois le marqueur de fin atteint et vérifié
+func (snapshotReader *SnapshotReader) Next() (storage.SnapshotRecord, error) {
+	if snapshotReader.finished {
+		return storage.SnapshotRecord{}, io.EOF
+	}
+
+	var streamEntry snapshotStreamEntry
+	if err := snapshotReader.decoder.Decode(&streamEntry); err != nil {
+		return storage.SnapshotRecord{}, fmt.Errorf("lecture clé %d: %v (fichier tronqué ?)", snapshotReader.recordCount+1, err)
+	}
+	if streamEntry.EndOfStream {
+		if streamEntry.RecordCount != snapshotReader.recordCount {
+			return storage.SnapshotRecord{}, fmt.Errorf("%d clés lues, %d annoncées", snapshotReader.recordCount, streamEntry.RecordCount)
+		}
+		snapshotReader.SearchIndexes = streamEntry.SearchIndexes
+		snapshotReader.finished = true
+		return storage.SnapshotRecord{}, io.EOF
+	}
+	snapshotReader.recordCount++
+	return streamEntry.Record, nil
+}
+
+// RecordCount retourne le nombre de clés lues jusqu'ici
+func (snapshotReader *SnapshotReader) RecordCount() int {
+	return snapshotReader.recordCount
+}
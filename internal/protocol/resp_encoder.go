@@ -45,6 +45,19 @@ func (redisEncoder *RedisSerializationProtocolEncoder) WriteNullBulkStringRespon
 	return writeError
 }
 
+// WriteNullArrayResponse écrit un array null (*-1\r\n), ex: XREAD sans résultat
+func (redisEncoder *RedisSerializationProtocolEncoder) WriteNullArrayResponse() error {
+	_, writeError := fmt.Fprintf(redisEncoder.outputWriter, "*-1\r\n")
+	return writeError
+}
+
+// WriteArrayHeaderResponse écrit uniquement l'en-tête d'un array (*3\r\n)
+// Les éléments sont ensuite écrits un par un, ce qui permet les arrays imbriqués
+func (redisEncoder *RedisSerializationProtocolEncoder) WriteArrayHeaderResponse(elementCount int) error {
+	_, writeError := fmt.Fprintf(redisEncoder.outputWriter, "*%d\r\n", elementCount)
+	return writeError
+}
+
 // WriteArrayResponse écrit un array (*2\r\n$3\r\nfoo\r\n$3\r\nbar\r\n)
 func (redisEncoder *RedisSerializationProtocolEncoder) WriteArrayResponse(arrayElements []string) error {
 	if _, writeError := fmt.Fprintf(redisEncoder.outputWriter, "*%d\r\n", len(arrayElements)); writeError != nil {
@@ -59,3 +72,9 @@ func (redisEncoder *RedisSerializationProtocolEncoder) WriteArrayResponse(arrayE
 
 	return nil
 }
+
+// WriteRawResponse écrit une réponse déjà encodée en RESP (ex: réponse préparée dans un tampon)
+func (redisEncoder *RedisSerializationProtocolEncoder) WriteRawResponse(encodedResponse []byte) error {
+	_, writeError := redisEncoder.outputWriter.Write(encodedResponse)
+	return writeError
+}
@@ -0,0 +1,468 @@
+package replication
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"redis-go/internal/persistence"
+	"redis-go/internal/protocol"
+	"redis-go/internal/session"
+)
+
+// Etats du lien vers le maître (ROLE)
+const (
+	masterLinkStateConnect    = "connect"
+	masterLinkStateConnecting = "connecting"
+	masterLinkStateSync       = "sync"
+	masterLinkStateConnected  = "connected"
+)
+
+// masterReconnectDelay est l'attente entre deux tentatives de connexion au maître
+const masterReconnectDelay = time.Second
+
+// MasterLink est le lien d'un réplica vers son maître : poignée de main, PSYNC,
+// chargement du snapshot puis application du flux de commandes
+type MasterLink struct {
+	replicationManager *ReplicationManager
+	masterHost         string
+	masterPort         int
+
+	linkMutex           sync.Mutex
+	linkState           string
+	masterConnection    net.Conn
+	lastInteractionTime time.Time
+	linkDownSince       time.Time
+	stopped             bool
+
+	writeMutex sync.Mutex // Les acquittements et les réponses à GETACK partagent la connexion
+	stopSignal chan struct{}
+}
+
+// newMasterLink prépare le lien vers un maître (démarré par run)
+func newMasterLink(replicationManager *ReplicationManager, masterHost string, masterPort int) *MasterLink {
+	return &MasterLink{
+		replicationManager: replicationManager,
+		masterHost:         masterHost,
+		masterPort:         masterPort,
+		linkState:          masterLinkStateConnect,
+		linkDownSince:      time.Now(),
+		stopSignal:         make(chan struct{}),
+	}
+}
+
+// masterAddress retourne l'adresse hôte:port du maître
+func (masterLink *MasterLink) masterAddress() string {
+	return net.JoinHostPort(masterLink.masterHost, strconv.Itoa(masterLink.masterPort))
+}
+
+// run maintient le lien jusqu'à stop, en se reconnectant après chaque coupure
+func (masterLink *MasterLink) run() {
+	for {
+		synchronizationError := masterLink.synchronizeWithMaster()
+
+		select {
+		case <-masterLink.stopSignal:
+			return
+		default:
+		}
+		log.Printf("⚠️  Lien de réplication avec %s interrompu: %v", masterLink.masterAddress(), synchronizationError)
+		masterLink.setLinkState(masterLinkStateConnect)
+
+		select {
+		case <-masterLink.stopSignal:
+			return
+		case <-time.After(masterReconnectDelay):
+		}
+	}
+}
+
+// stop coupe le lien (REPLICAOF NO ONE, changement de maître ou arrêt du serveur)
+func (masterLink *MasterLink) stop() {
+	masterLink.linkMutex.Lock()
+	defer masterLink.linkMutex.Unlock()
+
+	if masterLink.stopped {
+		return
+	}
+	masterLink.stopped = true
+	close(masterLink.stopSignal)
+	if masterLink.masterConnection != nil {
+		masterLink.masterConnection.Close()
+	}
+}
+
+// setLinkState change l'état du lien
+func (masterLink *MasterLink) setLinkState(linkState string) {
+	masterLink.linkMutex.Lock()
+	defer masterLink.linkMutex.Unlock()
+
+	if linkState == masterLinkStateConnected {
+		masterLink.lastInteractionTime = time.Now()
+	} else if masterLink.linkState == masterLinkStateConnected {
+		masterLink.linkDownSince = time.Now()
+	}
+	masterLink.linkState = linkState
+}
+
+// getLinkState retourne l'état du lien
+func (masterLink *MasterLink) getLinkState() string {
+	masterLink.linkMutex.Lock()
+	defer masterLink.linkMutex.Unlock()
+	return masterLink.linkState
+}
+
+// recordInteraction note la réception de données du maître
+func (masterLink *MasterLink) recordInteraction() {
+	masterLink.linkMutex.Lock()
+	defer masterLink.linkMutex.Unlock()
+	masterLink.lastInteractionTime = time.Now()
+}
+
+// getInfoFields retourne les lignes propres au réplica de la section INFO replication
+func (masterLink *MasterLink) getInfoFields() []string {
+	masterLink.linkMutex.Lock()
+	defer masterLink.linkMutex.Unlock()
+
+	linkStatus, syncInProgress := "down", 0
+	if masterLink.linkState == masterLinkStateConnected {
+		linkStatus = "up"
+	}
+	if masterLink.linkState == masterLinkStateSync {
+		syncInProgress = 1
+	}
+
+	lastInteractionSeconds := int64(-1)
+	if !masterLink.lastInteractionTime.IsZero() {
+		lastInteractionSeconds = int64(time.Since(masterLink.lastInteractionTime).Seconds())
+	}
+
+	infoFields := []string{
+		fmt.Sprintf("master_host:%s", masterLink.masterHost),
+		fmt.Sprintf("master_port:%d", masterLink.masterPort),
+		fmt.Sprintf("master_link_status:%s", linkStatus),
+		fmt.Sprintf("master_last_io_seconds_ago:%d", lastInteractionSeconds),
+		fmt.Sprintf("master_sync_in_progress:%d", syncInProgress),
+	}
+	if linkStatus == "down" {
+		infoFields = append(infoFields, fmt.Sprintf("master_link_down_since_seconds:%d", int64(time.Since(masterLink.linkDownSince).Seconds())))
+	}
+	return infoFields
+}
+
+// synchronizeWithMaster établit une connexion, se synchronise puis applique le flux jusqu'à la coupure
+func (masterLink *MasterLink) synchronizeWithMaster() error {
+	replicationManager := masterLink.replicationManager
+	replicationTimeout := time.Duration(replicationManager.replicationTimeout.Load())
+
+	masterLink.setLinkState(masterLinkStateConnecting)
+	masterConnection, dialError := net.DialTimeout("tcp", masterLink.masterAddress(), replicationTimeout)
+	if dialError != nil {
+		return dialError
+	}
+	defer masterConnection.Close()
+
+	masterLink.linkMutex.Lock()
+	if masterLink.stopped {
+		masterLink.linkMutex.Unlock()
+		return fmt.Errorf("lien arrêté")
+	}
+	masterLink.masterConnection = masterConnection
+	masterLink.linkMutex.Unlock()
+
+	masterReader := bufio.NewReader(masterConnection)
+	masterConnection.SetDeadline(time.Now().Add(replicationTimeout))
+	if handshakeError := masterLink.performHandshake(masterConnection, masterReader); handshakeError != nil {
+		return handshakeError
+	}
+
+	requestedReplicationID, requestedOffset := replicationManager.psyncArguments()
+	if writeError := masterLink.sendCommand(masterConnection, "PSYNC", requestedReplicationID, strconv.FormatInt(requestedOffset, 10)); writeError != nil {
+		return writeError
+	}
+	psyncReply, readError := readReplyLine(masterReader)
+	if readError != nil {
+		return readError
+	}
+
+	switch replyFields := strings.Fields(psyncReply); {
+	case len(replyFields) == 3 && replyFields[0] == "+FULLRESYNC":
+		syncOffset, parseError := strconv.ParseInt(replyFields[2], 10, 64)
+		if parseError != nil {
+			return fmt.Errorf("réponse FULLRESYNC invalide: %s", psyncReply)
+		}
+		masterLink.setLinkState(masterLinkStateSync)
+		if syncError := masterLink.receiveFullSync(masterConnection, masterReader, replyFields[1], syncOffset); syncError != nil {
+			return syncError
+		}
+	case len(replyFields) >= 1 && replyFields[0] == "+CONTINUE":
+		continuedReplicationID := ""
+		if len(replyFields) > 1 {
+			continuedReplicationID = replyFields[1]
+		}
+		replicationManager.continueReplication(continuedReplicationID)
+		log.Printf("🔁 Reprise partielle de la réplication depuis %s à l'offset %d", masterLink.masterAddress(), requestedOffset)
+	default:
+		return fmt.Errorf("réponse inattendue à PSYNC: %s", psyncReply)
+	}
+
+	masterConnection.SetDeadline(time.Time{})
+	masterLink.setLinkState(masterLinkStateConnected)
+	return masterLink.applyReplicationStream(masterConnection, masterReader)
+}
+
+// performHandshake envoie AUTH, PING et REPLCONF avant PSYNC
+func (masterLink *MasterLink) performHandshake(masterConnection net.Conn, masterReader *bufio.Reader) error {
+	replicationManager := masterLink.replicationManager
+	replicationManager.replicationMutex.Lock()
+	masterUser, masterPassword := replicationManager.masterUser, replicationManager.masterPassword
+	replicationManager.replicationMutex.Unlock()
+
+	handshakeCommands := [][]string{}
+	if masterPassword != "" {
+		if masterUser != "" {
+			handshakeCommands = append(handshakeCommands, []string{"AUTH", masterUser, masterPassword})
+		} else {
+			handshakeCommands = append(handshakeCommands, []string{"AUTH", masterPassword})
+		}
+	}
+	handshakeCommands = append(handshakeCommands,
+		[]string{"PING"},
+		[]string{"REPLCONF", "listening-port", strconv.Itoa(replicationManager.listeningPort)},
+		[]string{"REPLCONF", "capa", "eof", "capa", "psync2"})
+
+	for _, handshakeCommand := range handshakeCommands {
+		if writeError := masterLink.sendCommand(masterConnection, handshakeCommand...); writeError != nil {
+			return writeError
+		}
+		handshakeReply, readError := readReplyLine(masterReader)
+		if readError != nil {
+			return readError
+		}
+		if strings.HasPrefix(handshakeReply, "-") {
+			return fmt.Errorf("%s refusé par le maître: %s", handshakeCommand[0], strings.TrimPrefix(handshakeReply, "-"))
+		}
+	}
+	return nil
+}
+
+// receiveFullSync charge le snapshot envoyé par le maître puis adopte son identifiant et son offset
+// Les données actuelles restent servies pendant le chargement
+func (masterLink *MasterLink) receiveFullSync(masterConnection net.Conn, masterReader *bufio.Reader, replicationID string, syncOffset int64) error {
+	replicationManager := masterLink.replicationManager
+	replicationTimeout := time.Duration(replicationManager.replicationTimeout.Load())
+
+	transferHeader, readError := readReplyLine(masterReader)
+	if readError != nil {
+		return readError
+	}
+	if !strings.HasPrefix(transferHeader, "$EOF:") || len(transferHeader) != len("$EOF:")+40 {
+		return fmt.Errorf("en-tête de transfert inattendu: %s", transferHeader)
+	}
+
+	snapshotSource := &endMarkerReader{
+		sourceReader: masterReader,
+		endMarker:    []byte(strings.TrimPrefix(transferHeader, "$EOF:")),
+		beforeRead: func() {
+			masterConnection.SetReadDeadline(time.Now().Add(replicationTimeout))
+		},
+	}
+	snapshotReader, readerError := persistence.NewSnapshotReader(snapshotSource)
+	if readerError != nil {
+		return fmt.Errorf("snapshot du maître: %v", readerError)
+	}
+
+	snapshotLoader := replicationManager.redisStorage.BeginSnapshotLoad()
+	for {
+		snapshotRecord, recordError := snapshotReader.Next()
+		if recordError == io.EOF {
+			break
+		}
+		if recordError != nil {
+			return fmt.Errorf("snapshot du maître: %v", recordError)
+		}
+		snapshotLoader.LoadRecord(snapshotRecord)
+	}
+	if _, drainError := io.Copy(io.Discard, snapshotSource); drainError != nil {
+		return fmt.Errorf("fin du snapshot du maître: %v", drainError)
+	}
+
+	snapshotLoader.LoadSearchIndexes(snapshotReader.SearchIndexes)
+	loadedKeyCount := replicationManager.completeFullSync(snapshotLoader.Commit, replicationID, syncOffset)
+	log.Printf("✅ Synchronisation complète depuis %s: %d clés chargées (offset %d)", masterLink.masterAddress(), loadedKeyCount, syncOffset)
+	return nil
+}
+
+// applyReplicationStream applique les commandes du maître et envoie un acquittement par seconde
+func (masterLink *MasterLink) applyReplicationStream(masterConnection net.Conn, masterReader *bufio.Reader) error {
+	replicationManager := masterLink.replicationManager
+
+	// Session visible dans CLIENT LIST (flag M) ; ses réponses ne sont jamais envoyées
+	masterSession := replicationManager.sessionManager.RegisterSession(masterConnection)
+	defer replicationManager.sessionManager.UnregisterSession(masterSession)
+	masterSession.SetClientType(session.MasterClientType)
+	masterSession.SetAuthenticated(true)
+	masterSession.SetReplyMode(session.ClientReplyOff)
+
+	acknowledgementDone := make(chan struct{})
+	defer close(acknowledgementDone)
+	go func() {
+		acknowledgementTicker := time.NewTicker(time.Second)
+		defer acknowledgementTicker.Stop()
+		for {
+			select {
+			case <-acknowledgementDone:
+				return
+			case <-acknowledgementTicker.C:
+				masterLink.sendAcknowledgement(masterConnection)
+			}
+		}
+	}()
+	masterLink.sendAcknowledgement(masterConnection)
+
+	streamParser := protocol.NewRedisSerializationProtocolParser(masterReader)
+	for {
+		masterConnection.SetReadDeadline(time.Now().Add(time.Duration(replicationManager.replicationTimeout.Load())))
+		commandArguments, parseError := streamParser.ParseIncomingCommand()
+		if parseError != nil {
+			return parseError
+		}
+		if len(commandArguments) == 0 {
+			continue
+		}
+		masterLink.recordInteraction()
+		replicationManager.applyMasterCommand(masterSession, commandArguments)
+
+		// REPLCONF GETACK : le maître attend notre offset immédiatement
+		if len(commandArguments) >= 2 && strings.EqualFold(commandArguments[0], "REPLCONF") && strings.EqualFold(commandArguments[1], "GETACK") {
+			masterLink.sendAcknowledgement(masterConnection)
+		}
+	}
+}
+
+// sendAcknowledgement envoie REPLCONF ACK <offset traité>
+func (masterLink *MasterLink) sendAcknowledgement(masterConnection net.Conn) {
+	processedOffset := masterLink.replicationManager.ReplicationOffset()
+	masterLink.sendCommand(masterConnection, "REPLCONF", "ACK", strconv.FormatInt(processedOffset, 10))
+}
+
+// sendCommand écrit une commande RESP sur la connexion du maître
+func (masterLink *MasterLink) sendCommand(masterConnection net.Conn, commandArguments ...string) error {
+	masterLink.writeMutex.Lock()
+	defer masterLink.writeMutex.Unlock()
+	_, writeError := masterConnection.Write(encodeReplicationCommand(commandArguments))
+	return writeError
+}
+
+// readReplyLine lit une réponse d'une ligne (+OK, -ERR, +FULLRESYNC ...)
+// Les lignes vides envoyées par le maître pendant la préparation d'un snapshot sont ignorées
+func readReplyLine(masterReader *bufio.Reader) (string, error) {
+	for {
+		replyLine, readError := masterReader.ReadString('\n')
+		if readError != nil {
+			return "", readError
+		}
+		if replyLine = strings.TrimRight(replyLine, "\r\n"); replyLine != "" {
+			return replyLine, nil
+		}
+	}
+}
+
+// endMarkerReader lit un transfert "$EOF:<marqueur>" jusqu'au marqueur de fin exclu
+// Les octets suivants (début du flux de commandes) restent dans sourceReader
+type endMarkerReader struct {
+	sourceReader  *bufio.Reader
+	endMarker     []byte
+	beforeRead    func()
+	markerReached bool
+}
+
+func (markerReader *endMarkerReader) Read(readBuffer []byte) (int, error) {
+	if markerReader.markerReached {
+		return 0, io.EOF
+	}
+	markerReader.beforeRead()
+
+	// Au moins la longueur du marqueur doit être disponible pour le reconnaître
+	if _, peekError := markerReader.sourceReader.Peek(len(markerReader.endMarker)); peekError != nil {
+		return 0, fmt.Errorf("transfert interrompu avant le marqueur de fin: %v", peekError)
+	}
+	bufferedData, _ := markerReader.sourceReader.Peek(markerReader.sourceReader.Buffered())
+
+	if markerIndex := bytes.Index(bufferedData, markerReader.endMarker); markerIndex == 0 {
+		markerReader.sourceReader.Discard(len(markerReader.endMarker))
+		markerReader.markerReached = true
+		return 0, io.EOF
+	} else if markerIndex > 0 {
+		bufferedData = bufferedData[:markerIndex]
+	} else {
+		// Un début de marqueur peut se trouver en fin de tampon : on le garde pour la lecture suivante
+		bufferedData = bufferedData[:len(bufferedData)-len(markerReader.endMarker)+1]
+	}
+
+	copiedLength := copy(readBuffer, bufferedData)
+	markerReader.sourceReader.Discard(copiedLength)
+	return copiedLength, nil
+}
+
+// ReplicationOffset retourne l'offset du flux traité (maître : produit, réplica : appliqué)
+func (replicationManager *ReplicationManager) ReplicationOffset() int64 {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+	return replicationManager.replicationBacklog.EndOffset()
+}
+
+// psyncArguments retourne l'identifiant et l'offset à demander au maître
+// Un identifiant inconnu du maître déclenche simplement une synchronisation complète
+func (replicationManager *ReplicationManager) psyncArguments() (string, int64) {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+	return replicationManager.replicationID, replicationManager.replicationBacklog.EndOffset() + 1
+}
+
+// completeFullSync installe les données chargées et adopte l'identifiant et l'offset du maître
+// Les réplicas de ce serveur doivent se resynchroniser sur le nouvel historique
+func (replicationManager *ReplicationManager) completeFullSync(commitSnapshot func() int, replicationID string, syncOffset int64) int {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+
+	loadedKeyCount := commitSnapshot()
+	replicationManager.replicationID = replicationID
+	replicationManager.secondaryReplicationID = ""
+	replicationManager.secondaryReplicationOffset = -1
+	replicationManager.replicationBacklog.Reset(syncOffset)
+	replicationManager.disconnectReplicas()
+	return loadedKeyCount
+}
+
+// continueReplication traite +CONTINUE : si le maître a changé d'identifiant (bascule),
+// l'ancien reste accepté pour les réplicas de ce serveur
+func (replicationManager *ReplicationManager) continueReplication(continuedReplicationID string) {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+
+	if continuedReplicationID == "" || continuedReplicationID == replicationManager.replicationID {
+		return
+	}
+	replicationManager.secondaryReplicationID = replicationManager.replicationID
+	replicationManager.secondaryReplicationOffset = replicationManager.replicationBacklog.EndOffset() + 1
+	replicationManager.replicationID = continuedReplicationID
+	replicationManager.disconnectReplicas()
+}
+
+// applyMasterCommand exécute une commande du maître puis la relaie telle quelle
+// (backlog et réplicas de ce serveur) : les offsets restent identiques à ceux du maître
+func (replicationManager *ReplicationManager) applyMasterCommand(masterSession *session.ClientSession, commandArguments []string) {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+
+	replicationManager.commandApplier(masterSession, commandArguments)
+	replicationManager.feedReplicationStream(encodeReplicationCommand(commandArguments))
+}
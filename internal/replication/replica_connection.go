@@ -0,0 +1,291 @@
+package replication
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"redis-go/internal/persistence"
+	"redis-go/internal/session"
+)
+
+// replicaOutputBufferLimit est la taille maximale du flux en attente pour un réplica trop lent
+const replicaOutputBufferLimit = 256 * 1024 * 1024
+
+// Etats d'un réplica vus du maître (INFO replication)
+const (
+	replicaStateSendBulk = "send_bulk"
+	replicaStateOnline   = "online"
+)
+
+// ReplicaConnection est la connexion d'un réplica vue du maître
+// Le flux est mis en tampon puis écrit par une goroutine dédiée : une écriture cliente
+// n'attend jamais un réplica lent
+type ReplicaConnection struct {
+	clientSession *session.ClientSession
+	listeningPort int
+	ackOffset     atomic.Int64
+	lastAckTime   atomic.Int64 // UnixNano du dernier REPLCONF ACK
+	online        atomic.Bool
+
+	bufferMutex   sync.Mutex
+	pendingOutput []byte
+	outputSignal  chan struct{}
+	closedSignal  chan struct{}
+	closed        bool
+}
+
+// newReplicaConnection prépare la connexion d'un réplica qui vient d'envoyer PSYNC
+func newReplicaConnection(clientSession *session.ClientSession, listeningPort int) *ReplicaConnection {
+	replicaConnection := &ReplicaConnection{
+		clientSession: clientSession,
+		listeningPort: listeningPort,
+		outputSignal:  make(chan struct{}, 1),
+		closedSignal:  make(chan struct{}),
+	}
+	replicaConnection.lastAckTime.Store(time.Now().UnixNano())
+	return replicaConnection
+}
+
+// enqueue ajoute des octets au flux en attente ; un réplica trop en retard est déconnecté
+func (replicaConnection *ReplicaConnection) enqueue(streamData []byte) {
+	replicaConnection.bufferMutex.Lock()
+	defer replicaConnection.bufferMutex.Unlock()
+
+	if replicaConnection.closed {
+		return
+	}
+	if len(replicaConnection.pendingOutput)+len(streamData) > replicaOutputBufferLimit {
+		log.Printf("🐢 Réplica %s trop lent (%d octets en attente), déconnexion", replicaConnection.replicaAddress(), len(replicaConnection.pendingOutput))
+		replicaConnection.closeLocked()
+		return
+	}
+	replicaConnection.pendingOutput = append(replicaConnection.pendingOutput, streamData...)
+
+	select {
+	case replicaConnection.outputSignal <- struct{}{}:
+	default:
+	}
+}
+
+// startStreaming lance l'envoi du flux (après la réponse à PSYNC et l'éventuel snapshot)
+func (replicaConnection *ReplicaConnection) startStreaming() {
+	replicaConnection.online.Store(true)
+	replicaConnection.lastAckTime.Store(time.Now().UnixNano())
+	go replicaConnection.writeLoop()
+}
+
+// writeLoop écrit le flux en attente sur la connexion du réplica
+func (replicaConnection *ReplicaConnection) writeLoop() {
+	replicaSocket := replicaConnection.clientSession.Connection()
+	for {
+		select {
+		case <-replicaConnection.closedSignal:
+			return
+		case <-replicaConnection.outputSignal:
+		}
+
+		replicaConnection.bufferMutex.Lock()
+		pendingOutput := replicaConnection.pendingOutput
+		replicaConnection.pendingOutput = nil
+		replicaConnection.bufferMutex.Unlock()
+
+		if len(pendingOutput) == 0 {
+			continue
+		}
+		if _, writeError := replicaSocket.Write(pendingOutput); writeError != nil {
+			replicaConnection.close()
+			return
+		}
+	}
+}
+
+// acknowledge enregistre un REPLCONF ACK
+func (replicaConnection *ReplicaConnection) acknowledge(ackOffset int64) {
+	replicaConnection.ackOffset.Store(ackOffset)
+	replicaConnection.lastAckTime.Store(time.Now().UnixNano())
+}
+
+// acknowledgementLag retourne le temps écoulé depuis le dernier acquittement
+func (replicaConnection *ReplicaConnection) acknowledgementLag() time.Duration {
+	return time.Since(time.Unix(0, replicaConnection.lastAckTime.Load()))
+}
+
+// isOnline indique si la synchronisation initiale est terminée
+func (replicaConnection *ReplicaConnection) isOnline() bool {
+	return replicaConnection.online.Load()
+}
+
+// replicaAddress retourne l'adresse IP du réplica
+func (replicaConnection *ReplicaConnection) replicaAddress() string {
+	replicaHost, _, splitError := net.SplitHostPort(replicaConnection.clientSession.RemoteAddress)
+	if splitError != nil {
+		return replicaConnection.clientSession.RemoteAddress
+	}
+	return replicaHost
+}
+
+// describe retourne l'état du réplica pour ROLE et INFO replication
+func (replicaConnection *ReplicaConnection) describe() ReplicaDescription {
+	replicaState := replicaStateSendBulk
+	if replicaConnection.isOnline() {
+		replicaState = replicaStateOnline
+	}
+	return ReplicaDescription{
+		ReplicaAddress: replicaConnection.replicaAddress(),
+		ListeningPort:  replicaConnection.listeningPort,
+		ReplicaState:   replicaState,
+		AckOffset:      replicaConnection.ackOffset.Load(),
+		AckLag:         replicaConnection.acknowledgementLag(),
+	}
+}
+
+// close ferme la connexion du réplica ; le gestionnaire le retire via DetachReplica
+func (replicaConnection *ReplicaConnection) close() {
+	replicaConnection.bufferMutex.Lock()
+	defer replicaConnection.bufferMutex.Unlock()
+	replicaConnection.closeLocked()
+}
+
+// closeLocked ferme la connexion (bufferMutex détenu)
+func (replicaConnection *ReplicaConnection) closeLocked() {
+	if replicaConnection.closed {
+		return
+	}
+	replicaConnection.closed = true
+	replicaConnection.pendingOutput = nil
+	close(replicaConnection.closedSignal)
+	replicaConnection.clientSession.Close()
+}
+
+// RecordListeningPort mémorise le port annoncé par REPLCONF listening-port
+func (replicationManager *ReplicationManager) RecordListeningPort(clientSession *session.ClientSession, listeningPort int) {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+	replicationManager.announcedListeningPorts[clientSession.ClientIdentifier] = listeningPort
+}
+
+// AcknowledgeReplicaOffset traite REPLCONF ACK <offset> envoyé par un réplica
+func (replicationManager *ReplicationManager) AcknowledgeReplicaOffset(clientSession *session.ClientSession, ackOffset int64) {
+	replicationManager.replicationMutex.Lock()
+	replicaConnection := replicationManager.connectedReplicas[clientSession]
+	replicationManager.replicationMutex.Unlock()
+
+	if replicaConnection != nil {
+		replicaConnection.acknowledge(ackOffset)
+	}
+}
+
+// DetachReplica retire un réplica dont la connexion est fermée
+func (replicationManager *ReplicationManager) DetachReplica(clientSession *session.ClientSession) {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+
+	delete(replicationManager.announcedListeningPorts, clientSession.ClientIdentifier)
+	if replicaConnection, replicaExists := replicationManager.connectedReplicas[clientSession]; replicaExists {
+		replicaConnection.close()
+		delete(replicationManager.connectedReplicas, clientSession)
+		log.Printf("🔌 Réplica %s déconnecté", clientSession.RemoteAddress)
+	}
+}
+
+// CheckReplicaSyncAllowed retourne un message d'erreur si ce serveur ne peut pas servir de maître
+// Un réplica ne sert ses propres réplicas qu'une fois synchronisé avec son maître
+func (replicationManager *ReplicationManager) CheckReplicaSyncAllowed() string {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+
+	if replicationManager.masterLink != nil && replicationManager.masterLink.getLinkState() != masterLinkStateConnected {
+		return "NOMASTERLINK impossible de synchroniser un réplica tant que le lien avec le maître n'est pas établi"
+	}
+	return ""
+}
+
+// HandleReplicaSync répond à PSYNC replid offset : reprise partielle depuis le backlog si possible,
+// sinon synchronisation complète à partir d'un snapshot copy-on-write envoyé en flux
+// Les réponses sont écrites directement sur la connexion du réplica
+func (replicationManager *ReplicationManager) HandleReplicaSync(clientSession *session.ClientSession, requestedReplicationID string, requestedOffset int64) error {
+	replicaSocket := clientSession.Connection()
+
+	replicationManager.replicationMutex.Lock()
+	replicaConnection := newReplicaConnection(clientSession, replicationManager.announcedListeningPorts[clientSession.ClientIdentifier])
+
+	if backlogData, partialSyncPossible := replicationManager.partialResyncData(requestedReplicationID, requestedOffset); partialSyncPossible {
+		replicationID := replicationManager.replicationID
+		replicaConnection.ackOffset.Store(requestedOffset - 1)
+		replicaConnection.enqueue(backlogData)
+		replicationManager.connectedReplicas[clientSession] = replicaConnection
+		replicationManager.replicationMutex.Unlock()
+
+		if _, writeError := fmt.Fprintf(replicaSocket, "+CONTINUE %s\r\n", replicationID); writeError != nil {
+			return writeError
+		}
+		replicaConnection.startStreaming()
+		replicationManager.partialSyncCount.Add(1)
+		log.Printf("🔁 Réplica %s : reprise partielle à l'offset %d (%d octets du backlog)", clientSession.RemoteAddress, requestedOffset, len(backlogData))
+		return nil
+	}
+	if requestedReplicationID != "?" {
+		replicationManager.rejectedPartialSyncs.Add(1)
+	}
+
+	// Le snapshot est figé sous le même verrou que les écritures : il correspond exactement à syncOffset,
+	// et tout ce qui suit est mis en attente pour ce réplica
+	snapshotCursor := replicationManager.redisStorage.BeginSnapshot()
+	replicationID := replicationManager.replicationID
+	syncOffset := replicationManager.replicationBacklog.EndOffset()
+	replicaConnection.ackOffset.Store(syncOffset)
+	replicationManager.connectedReplicas[clientSession] = replicaConnection
+	replicationManager.replicationMutex.Unlock()
+	defer snapshotCursor.Close()
+
+	log.Printf("📤 Réplica %s : synchronisation complète (%d clés, offset %d)", clientSession.RemoteAddress, snapshotCursor.KeyCount(), syncOffset)
+	if _, writeError := fmt.Fprintf(replicaSocket, "+FULLRESYNC %s %d\r\n", replicationID, syncOffset); writeError != nil {
+		return writeError
+	}
+
+	// Format sans longueur préalable : $EOF:<marqueur>\r\n<snapshot><marqueur>
+	endMarker := newReplicationID()
+	bufferedSocket := bufio.NewWriter(replicaSocket)
+	fmt.Fprintf(bufferedSocket, "$EOF:%s\r\n", endMarker)
+	snapshotWriter, writerError := persistence.NewSnapshotWriter(bufferedSocket, snapshotCursor.Timestamp, false)
+	if writerError != nil {
+		return writerError
+	}
+	for {
+		snapshotRecord, recordAvailable := snapshotCursor.Next()
+		if !recordAvailable {
+			break
+		}
+		if writeError := snapshotWriter.WriteRecord(snapshotRecord); writeError != nil {
+			return writeError
+		}
+	}
+	snapshotWriter.SetSearchIndexes(snapshotCursor.SearchIndexes)
+	if closeError := snapshotWriter.Close(); closeError != nil {
+		return closeError
+	}
+	bufferedSocket.WriteString(endMarker)
+	if flushError := bufferedSocket.Flush(); flushError != nil {
+		return flushError
+	}
+
+	replicaConnection.startStreaming()
+	replicationManager.fullSyncCount.Add(1)
+	log.Printf("✅ Réplica %s synchronisé (%d clés, %d octets)", clientSession.RemoteAddress, snapshotWriter.RecordCount(), snapshotWriter.FileBytes())
+	return nil
+}
+
+// partialResyncData retourne la suite du flux si PSYNC peut reprendre à requestedOffset (verrou détenu)
+func (replicationManager *ReplicationManager) partialResyncData(requestedReplicationID string, requestedOffset int64) ([]byte, bool) {
+	knownReplicationID := requestedReplicationID == replicationManager.replicationID ||
+		(requestedReplicationID == replicationManager.secondaryReplicationID && requestedOffset <= replicationManager.secondaryReplicationOffset)
+	if !knownReplicationID || requestedReplicationID == "" {
+		return nil, false
+	}
+	return replicationManager.replicationBacklog.ReadFrom(requestedOffset)
+}
@@ -0,0 +1,95 @@
+package replication
+
+// ReplicationBacklog conserve les derniers octets du flux de réplication dans un tampon circulaire
+// Les offsets suivent la convention Redis : le premier octet du flux a l'offset 1
+// et endOffset (master_repl_offset) est l'offset du dernier octet écrit
+type ReplicationBacklog struct {
+	circularBuffer []byte
+	writePosition  int   // Prochaine position d'écriture dans le tampon
+	historyLength  int   // Nombre d'octets valides dans le tampon
+	endOffset      int64 // Offset du dernier octet écrit
+}
+
+// NewReplicationBacklog crée un backlog vide qui reprend le flux après endOffset
+func NewReplicationBacklog(backlogSize int, endOffset int64) *ReplicationBacklog {
+	return &ReplicationBacklog{
+		circularBuffer: make([]byte, backlogSize),
+		endOffset:      endOffset,
+	}
+}
+
+// Append ajoute des octets au flux ; les plus anciens sont écrasés quand le tampon est plein
+func (replicationBacklog *ReplicationBacklog) Append(streamData []byte) {
+	backlogSize := len(replicationBacklog.circularBuffer)
+	replicationBacklog.endOffset += int64(len(streamData))
+
+	if len(streamData) >= backlogSize {
+		copy(replicationBacklog.circularBuffer, streamData[len(streamData)-backlogSize:])
+		replicationBacklog.writePosition = 0
+		replicationBacklog.historyLength = backlogSize
+		return
+	}
+
+	copiedLength := copy(replicationBacklog.circularBuffer[replicationBacklog.writePosition:], streamData)
+	copy(replicationBacklog.circularBuffer, streamData[copiedLength:])
+	replicationBacklog.writePosition = (replicationBacklog.writePosition + len(streamData)) % backlogSize
+	replicationBacklog.historyLength = min(replicationBacklog.historyLength+len(streamData), backlogSize)
+}
+
+// ReadFrom retourne les octets du flux à partir de startOffset (inclus)
+// Retourne false si cet offset n'est plus (ou pas encore) couvert par le backlog
+func (replicationBacklog *ReplicationBacklog) ReadFrom(startOffset int64) ([]byte, bool) {
+	if startOffset < replicationBacklog.FirstByteOffset() || startOffset > replicationBacklog.endOffset+1 {
+		return nil, false
+	}
+
+	backlogSize := len(replicationBacklog.circularBuffer)
+	readLength := int(replicationBacklog.endOffset - startOffset + 1)
+	readPosition := (replicationBacklog.writePosition - readLength + backlogSize) % backlogSize
+
+	streamData := make([]byte, readLength)
+	copiedLength := copy(streamData, replicationBacklog.circularBuffer[readPosition:min(readPosition+readLength, backlogSize)])
+	copy(streamData[copiedLength:], replicationBacklog.circularBuffer)
+	return streamData, true
+}
+
+// Resize change la taille du backlog en conservant les octets les plus récents
+func (replicationBacklog *ReplicationBacklog) Resize(backlogSize int) {
+	if backlogSize == len(replicationBacklog.circularBuffer) {
+		return
+	}
+	keptLength := min(replicationBacklog.historyLength, backlogSize)
+	keptData, _ := replicationBacklog.ReadFrom(replicationBacklog.endOffset - int64(keptLength) + 1)
+
+	replicationBacklog.circularBuffer = make([]byte, backlogSize)
+	copy(replicationBacklog.circularBuffer, keptData)
+	replicationBacklog.writePosition = keptLength % backlogSize
+	replicationBacklog.historyLength = keptLength
+}
+
+// Reset vide le backlog ; le flux reprend après endOffset (après une synchronisation complète)
+func (replicationBacklog *ReplicationBacklog) Reset(endOffset int64) {
+	replicationBacklog.writePosition = 0
+	replicationBacklog.historyLength = 0
+	replicationBacklog.endOffset = endOffset
+}
+
+// FirstByteOffset retourne l'offset du plus ancien octet conservé
+func (replicationBacklog *ReplicationBacklog) FirstByteOffset() int64 {
+	return replicationBacklog.endOffset - int64(replicationBacklog.historyLength) + 1
+}
+
+// EndOffset retourne l'offset du dernier octet écrit (master_repl_offset)
+func (replicationBacklog *ReplicationBacklog) EndOffset() int64 {
+	return replicationBacklog.endOffset
+}
+
+// HistoryLength retourne le nombre d'octets conservés
+func (replicationBacklog *ReplicationBacklog) HistoryLength() int {
+	return replicationBacklog.historyLength
+}
+
+// Size retourne la capacité du backlog en octets
+func (replicationBacklog *ReplicationBacklog) Size() int {
+	return len(replicationBacklog.circularBuffer)
+}
@@ -0,0 +1,381 @@
+package replication
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"redis-go/internal/protocol"
+	"redis-go/internal/session"
+	"redis-go/internal/storage"
+)
+
+// ReplicationRole indique si le serveur est maître ou réplica
+type ReplicationRole int
+
+const (
+	MasterRole ReplicationRole = iota
+	ReplicaRole
+)
+
+// CommandApplier exécute une commande reçue du maître pour le compte de la session du lien
+type CommandApplier func(masterSession *session.ClientSession, commandArguments []string)
+
+// ReplicaDescription décrit un réplica connecté (ROLE, INFO replication)
+type ReplicaDescription struct {
+	ReplicaAddress string
+	ListeningPort  int
+	ReplicaState   string
+	AckOffset      int64
+	AckLag         time.Duration
+}
+
+// RoleDescription est l'état de réplication retourné par ROLE
+type RoleDescription struct {
+	Role              ReplicationRole
+	ReplicationOffset int64
+	MasterHost        string
+	MasterPort        int
+	LinkState         string
+	Replicas          []ReplicaDescription
+}
+
+// ReplicationManager gère les deux côtés de la réplication :
+// en maître, le flux des écritures envoyé aux réplicas et le backlog ;
+// en réplica, le lien vers le maître (voir MasterLink)
+type ReplicationManager struct {
+	redisStorage   *storage.RedisInMemoryStorage
+	sessionManager *session.ClientSessionManager
+	commandApplier CommandApplier
+	listeningPort  int
+
+	// replicationMutex sérialise les écritures avec leur ajout au flux : l'ordre du flux est celui
+	// de l'exécution, et un snapshot de synchronisation correspond exactement à un offset
+	replicationMutex           sync.Mutex
+	replicationRole            ReplicationRole
+	replicationID              string
+	secondaryReplicationID     string // Identifiant précédent, accepté par PSYNC jusqu'à secondaryReplicationOffset
+	secondaryReplicationOffset int64
+	replicationBacklog         *ReplicationBacklog
+	connectedReplicas          map[*session.ClientSession]*ReplicaConnection
+	announcedListeningPorts    map[int64]int // REPLCONF listening-port reçus avant PSYNC
+	masterLink                 *MasterLink
+	masterUser                 string
+	masterPassword             string
+	lastReplicaPingTime        time.Time
+
+	replicaReadOnly    atomic.Bool
+	pingReplicaPeriod  atomic.Int64 // time.Duration
+	replicationTimeout atomic.Int64 // time.Duration
+
+	fullSyncCount         atomic.Int64
+	partialSyncCount      atomic.Int64
+	rejectedPartialSyncs  atomic.Int64
+	replicationCronSignal chan struct{}
+}
+
+// NewReplicationManager crée le gestionnaire de réplication d'un serveur maître
+func NewReplicationManager(redisStorage *storage.RedisInMemoryStorage, sessionManager *session.ClientSessionManager, listeningPort int, backlogSize int) *ReplicationManager {
+	replicationManager := &ReplicationManager{
+		redisStorage:               redisStorage,
+		sessionManager:             sessionManager,
+		listeningPort:              listeningPort,
+		replicationRole:            MasterRole,
+		replicationID:              newReplicationID(),
+		secondaryReplicationOffset: -1,
+		replicationBacklog:         NewReplicationBacklog(backlogSize, 0),
+		connectedReplicas:          make(map[*session.ClientSession]*ReplicaConnection),
+		announcedListeningPorts:    make(map[int64]int),
+		replicationCronSignal:      make(chan struct{}),
+	}
+	replicationManager.replicaReadOnly.Store(true)
+	replicationManager.pingReplicaPeriod.Store(int64(10 * time.Second))
+	replicationManager.replicationTimeout.Store(int64(60 * time.Second))
+	return replicationManager
+}
+
+// newReplicationID génère un identifiant de réplication aléatoire (40 caractères hexadécimaux)
+func newReplicationID() string {
+	randomBytes := make([]byte, 20)
+	rand.Read(randomBytes)
+	return hex.EncodeToString(randomBytes)
+}
+
+// encodeReplicationCommand encode une commande telle qu'elle circule dans le flux de réplication
+func encodeReplicationCommand(commandArguments []string) []byte {
+	var encodedCommand bytes.Buffer
+	protocol.NewRedisSerializationProtocolEncoder(&encodedCommand).WriteArrayResponse(commandArguments)
+	return encodedCommand.Bytes()
+}
+
+// SetCommandApplier configure l'exécution des commandes reçues du maître
+func (replicationManager *ReplicationManager) SetCommandApplier(commandApplier CommandApplier) {
+	replicationManager.commandApplier = commandApplier
+}
+
+// SetReplicaReadOnly active ou désactive le refus des écritures clientes sur un réplica
+func (replicationManager *ReplicationManager) SetReplicaReadOnly(readOnly bool) {
+	replicationManager.replicaReadOnly.Store(readOnly)
+}
+
+// SetBacklogSize change la taille du backlog en conservant les octets les plus récents
+func (replicationManager *ReplicationManager) SetBacklogSize(backlogSize int) {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+	replicationManager.replicationBacklog.Resize(backlogSize)
+}
+
+// SetPingReplicaPeriod change l'intervalle des PING envoyés aux réplicas
+func (replicationManager *ReplicationManager) SetPingReplicaPeriod(pingPeriod time.Duration) {
+	replicationManager.pingReplicaPeriod.Store(int64(pingPeriod))
+}
+
+// SetReplicationTimeout change le délai au-delà duquel un lien silencieux est rompu
+func (replicationManager *ReplicationManager) SetReplicationTimeout(replicationTimeout time.Duration) {
+	replicationManager.replicationTimeout.Store(int64(replicationTimeout))
+}
+
+// SetMasterCredentials configure l'authentification auprès du maître (prise en compte à la prochaine connexion)
+func (replicationManager *ReplicationManager) SetMasterCredentials(masterUser string, masterPassword string) {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+	replicationManager.masterUser = masterUser
+	replicationManager.masterPassword = masterPassword
+}
+
+// IsReadOnlyReplica indique si les écritures des clients doivent être refusées
+func (replicationManager *ReplicationManager) IsReadOnlyReplica() bool {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+	return replicationManager.replicationRole == ReplicaRole && replicationManager.replicaReadOnly.Load()
+}
+
+// RoleName retourne "master" ou "replica"
+func (replicationManager *ReplicationManager) RoleName() string {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+	if replicationManager.replicationRole == ReplicaRole {
+		return "replica"
+	}
+	return "master"
+}
+
+// ExecuteWriteCommand exécute une écriture cliente et ajoute au flux de réplication la commande qu'elle retourne
+// C'est en général la commande elle-même, mais MIGRATE transmet la suppression des clés transférées ;
+// rien n'est transmis si elle est vide. Sur un réplica accessible en écriture, l'écriture reste locale (comme dans Redis)
+func (replicationManager *ReplicationManager) ExecuteWriteCommand(executeCommand func() ([]string, error)) error {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+
+	replicatedCommand, executionError := executeCommand()
+	if replicationManager.replicationRole == MasterRole && len(replicatedCommand) > 0 {
+		replicationManager.feedReplicationStream(encodeReplicationCommand(replicatedCommand))
+	}
+	return executionError
+}
+
+// feedReplicationStream ajoute des octets au backlog et les transmet aux réplicas (verrou détenu)
+func (replicationManager *ReplicationManager) feedReplicationStream(streamData []byte) {
+	replicationManager.replicationBacklog.Append(streamData)
+	for _, replicaConnection := range replicationManager.connectedReplicas {
+		replicaConnection.enqueue(streamData)
+	}
+}
+
+// ReplicateFrom fait du serveur un réplica de hôte:port ; retourne true s'il l'était déjà
+// Les données actuelles restent servies jusqu'à la fin de la synchronisation
+func (replicationManager *ReplicationManager) ReplicateFrom(masterHost string, masterPort int) bool {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+
+	currentLink := replicationManager.masterLink
+	if currentLink != nil && currentLink.masterHost == masterHost && currentLink.masterPort == masterPort {
+		return true
+	}
+	if currentLink != nil {
+		currentLink.stop()
+	}
+
+	replicationManager.replicationRole = ReplicaRole
+	replicationManager.masterLink = newMasterLink(replicationManager, masterHost, masterPort)
+	go replicationManager.masterLink.run()
+	log.Printf("🔁 Réplication depuis le maître %s", net.JoinHostPort(masterHost, fmt.Sprint(masterPort)))
+	return false
+}
+
+// PromoteToMaster arrête la réplication (REPLICAOF NO ONE) en conservant les données
+// L'ancien identifiant reste accepté par PSYNC : les autres réplicas du même maître peuvent
+// continuer depuis ce serveur sans synchronisation complète
+func (replicationManager *ReplicationManager) PromoteToMaster() {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+
+	if replicationManager.replicationRole == MasterRole {
+		return
+	}
+	replicationManager.masterLink.stop()
+	replicationManager.masterLink = nil
+	replicationManager.replicationRole = MasterRole
+
+	replicationManager.secondaryReplicationID = replicationManager.replicationID
+	replicationManager.secondaryReplicationOffset = replicationManager.replicationBacklog.EndOffset() + 1
+	replicationManager.replicationID = newReplicationID()
+	log.Printf("👑 Réplication arrêtée : le serveur redevient maître")
+}
+
+// InvalidateReplicationStream force une synchronisation complète de tous les réplicas
+// Utilisé quand les données changent hors du flux (ex: DEBUG RELOAD)
+func (replicationManager *ReplicationManager) InvalidateReplicationStream() {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+
+	if replicationManager.replicationRole == MasterRole {
+		replicationManager.replicationID = newReplicationID()
+		replicationManager.secondaryReplicationID = ""
+		replicationManager.secondaryReplicationOffset = -1
+	}
+	replicationManager.disconnectReplicas()
+}
+
+// disconnectReplicas ferme la connexion de tous les réplicas (verrou détenu)
+func (replicationManager *ReplicationManager) disconnectReplicas() {
+	for _, replicaConnection := range replicationManager.connectedReplicas {
+		replicaConnection.close()
+	}
+}
+
+// Start démarre la tâche périodique (PING vers les réplicas, délais d'acquittement)
+func (replicationManager *ReplicationManager) Start() {
+	go func() {
+		cronTicker := time.NewTicker(time.Second)
+		defer cronTicker.Stop()
+		for {
+			select {
+			case <-replicationManager.replicationCronSignal:
+				return
+			case <-cronTicker.C:
+				replicationManager.runReplicationCron()
+			}
+		}
+	}()
+}
+
+// Stop arrête la réplication : lien vers le maître, réplicas et tâche périodique
+func (replicationManager *ReplicationManager) Stop() {
+	close(replicationManager.replicationCronSignal)
+
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+	if replicationManager.masterLink != nil {
+		replicationManager.masterLink.stop()
+	}
+	replicationManager.disconnectReplicas()
+}
+
+// runReplicationCron envoie les PING périodiques et déconnecte les réplicas muets
+func (replicationManager *ReplicationManager) runReplicationCron() {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+
+	currentTime := time.Now()
+	// Le PING fait partie du flux : un réplica le relaie à ses propres réplicas
+	if replicationManager.replicationRole == MasterRole && len(replicationManager.connectedReplicas) > 0 &&
+		currentTime.Sub(replicationManager.lastReplicaPingTime) >= time.Duration(replicationManager.pingReplicaPeriod.Load()) {
+		replicationManager.feedReplicationStream(encodeReplicationCommand([]string{"PING"}))
+		replicationManager.lastReplicaPingTime = currentTime
+	}
+
+	replicationTimeout := time.Duration(replicationManager.replicationTimeout.Load())
+	for _, replicaConnection := range replicationManager.connectedReplicas {
+		if replicaConnection.isOnline() && replicaConnection.acknowledgementLag() > replicationTimeout {
+			log.Printf("⏱️  Réplica %s sans acquittement depuis %v, déconnexion", replicaConnection.replicaAddress(), replicationTimeout)
+			replicaConnection.close()
+		}
+	}
+}
+
+// DescribeRole retourne l'état de réplication (ROLE)
+func (replicationManager *ReplicationManager) DescribeRole() RoleDescription {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+
+	roleDescription := RoleDescription{
+		Role:              replicationManager.replicationRole,
+		ReplicationOffset: replicationManager.replicationBacklog.EndOffset(),
+		Replicas:          replicationManager.describeReplicas(),
+	}
+	if masterLink := replicationManager.masterLink; masterLink != nil {
+		roleDescription.MasterHost = masterLink.masterHost
+		roleDescription.MasterPort = masterLink.masterPort
+		roleDescription.LinkState = masterLink.getLinkState()
+	}
+	return roleDescription
+}
+
+// describeReplicas liste les réplicas connectés, triés par adresse (verrou détenu)
+func (replicationManager *ReplicationManager) describeReplicas() []ReplicaDescription {
+	replicaDescriptions := make([]ReplicaDescription, 0, len(replicationManager.connectedReplicas))
+	for _, replicaConnection := range replicationManager.connectedReplicas {
+		replicaDescriptions = append(replicaDescriptions, replicaConnection.describe())
+	}
+	sort.Slice(replicaDescriptions, func(firstIndex, secondIndex int) bool {
+		return fmt.Sprintf("%s:%d", replicaDescriptions[firstIndex].ReplicaAddress, replicaDescriptions[firstIndex].ListeningPort) <
+			fmt.Sprintf("%s:%d", replicaDescriptions[secondIndex].ReplicaAddress, replicaDescriptions[secondIndex].ListeningPort)
+	})
+	return replicaDescriptions
+}
+
+// GetInfoFields retourne les lignes "clé:valeur" de la section INFO replication
+func (replicationManager *ReplicationManager) GetInfoFields() []string {
+	replicationManager.replicationMutex.Lock()
+	defer replicationManager.replicationMutex.Unlock()
+
+	var infoFields []string
+	if masterLink := replicationManager.masterLink; masterLink != nil {
+		infoFields = append(infoFields, "role:slave")
+		infoFields = append(infoFields, masterLink.getInfoFields()...)
+		readOnly := 0
+		if replicationManager.replicaReadOnly.Load() {
+			readOnly = 1
+		}
+		infoFields = append(infoFields,
+			fmt.Sprintf("slave_repl_offset:%d", replicationManager.replicationBacklog.EndOffset()),
+			fmt.Sprintf("slave_read_only:%d", readOnly))
+	} else {
+		infoFields = append(infoFields, "role:master")
+	}
+
+	replicaDescriptions := replicationManager.describeReplicas()
+	infoFields = append(infoFields, fmt.Sprintf("connected_slaves:%d", len(replicaDescriptions)))
+	for replicaIndex, replicaDescription := range replicaDescriptions {
+		infoFields = append(infoFields, fmt.Sprintf("slave%d:ip=%s,port=%d,state=%s,offset=%d,lag=%d",
+			replicaIndex, replicaDescription.ReplicaAddress, replicaDescription.ListeningPort,
+			replicaDescription.ReplicaState, replicaDescription.AckOffset, int64(replicaDescription.AckLag.Seconds())))
+	}
+
+	replicationBacklog := replicationManager.replicationBacklog
+	secondaryReplicationID := replicationManager.secondaryReplicationID
+	if secondaryReplicationID == "" {
+		secondaryReplicationID = "0000000000000000000000000000000000000000"
+	}
+	infoFields = append(infoFields,
+		fmt.Sprintf("master_replid:%s", replicationManager.replicationID),
+		fmt.Sprintf("master_replid2:%s", secondaryReplicationID),
+		fmt.Sprintf("master_repl_offset:%d", replicationBacklog.EndOffset()),
+		fmt.Sprintf("second_repl_offset:%d", replicationManager.secondaryReplicationOffset),
+		"repl_backlog_active:1",
+		fmt.Sprintf("repl_backlog_size:%d", replicationBacklog.Size()),
+		fmt.Sprintf("repl_backlog_first_byte_offset:%d", replicationBacklog.FirstByteOffset()),
+		fmt.Sprintf("repl_backlog_histlen:%d", replicationBacklog.HistoryLength()),
+		fmt.Sprintf("sync_full:%d", replicationManager.fullSyncCount.Load()),
+		fmt.Sprintf("sync_partial_ok:%d", replicationManager.partialSyncCount.Load()),
+		fmt.Sprintf("sync_partial_err:%d", replicationManager.rejectedPartialSyncs.Load()))
+	return infoFields
+}
@@ -1,26 +1,29 @@
 package server
 
 import (
+	"errors"
 	"log"
 	"net"
-	"time"
 
 	"redis-go/internal/protocol"
+	"redis-go/internal/session"
 )
 
 // handleClientConnection gère une connexion client
-func (redisServerInstance *RedisServerInstance) handleClientConnection(clientConnection net.Conn) {
+func (redisServerInstance *RedisServerInstance) handleClientConnection(clientSession *session.ClientSession) {
+	clientConnection := clientSession.Connection()
+
 	defer redisServerInstance.activeGoroutines.Done()
 	defer func() {
-		log.Printf("🔌 Connexion fermée depuis %s", clientConnection.RemoteAddr())
+		log.Printf("🔌 Connexion fermée depuis %s", clientSession.RemoteAddress)
 		clientConnection.Close()
-		redisServerInstance.clientsMutex.Lock()
-		delete(redisServerInstance.connectedClients, clientConnection)
-		redisServerInstance.clientsMutex.Unlock()
+		redisServerInstance.replicationManager.DetachReplica(clientSession)
+		redisServerInstance.sessionManager.UnregisterSession(clientSession)
 	}()
 
 	protocolParser := protocol.NewRedisSerializationProtocolParser(clientConnection)
-	protocolEncoder := protocol.NewRedisSerializationProtocolEncoder(clientConnection)
+	// Les réponses passent par la session pour respecter CLIENT REPLY
+	protocolEncoder := protocol.NewRedisSerializationProtocolEncoder(clientSession)
 
 	// Boucle de traitement des commandes
 	for {
@@ -28,17 +31,12 @@ func (redisServerInstance *RedisServerInstance) handleClientConnection(clientCon
 		case <-redisServerInstance.shutdownSignal:
 			return
 		default:
-			// Définir un timeout pour éviter les blocages
-			clientConnection.SetReadDeadline(time.Now().Add(30 * time.Second))
-
-			// Parsing de la commande
+			// Pas de deadline de lecture : le timeout d'inactivité est géré par startIdleClientMonitor
 			parsedCommandArguments, parseError := protocolParser.ParseIncomingCommand()
 			if parseError != nil {
-				// Log différencié selon le type d'erreur
-				if networkError, isNetworkError := parseError.(net.Error); isNetworkError && networkError.Timeout() {
-					log.Printf("⏰ Timeout de connexion pour %s", clientConnection.RemoteAddr())
-				} else {
-					log.Printf("⚠️  Erreur de parsing depuis %s: %v", clientConnection.RemoteAddr(), parseError)
+				// Connexion fermée côté serveur (timeout, CLIENT KILL, arrêt) : rien à signaler
+				if !errors.Is(parseError, net.ErrClosed) {
+					log.Printf("⚠️  Erreur de parsing depuis %s: %v", clientSession.RemoteAddress, parseError)
 				}
 				return
 			}
@@ -52,13 +50,21 @@ func (redisServerInstance *RedisServerInstance) handleClientConnection(clientCon
 			receivedCommandArguments := parsedCommandArguments[1:]
 
 			// Log des commandes (optionnel, peut être verbeux)
-			// log.Printf("📝 Commande reçue de %s: %s %v", clientConnection.RemoteAddr(), receivedCommandName, receivedCommandArguments)
+			// log.Printf("📝 Commande reçue de %s: %s %v", clientSession.RemoteAddress, receivedCommandName, receivedCommandArguments)
+
+			// Mise à jour de la session (dernière commande, idle, CLIENT REPLY)
+			clientSession.BeginCommand(receivedCommandName, receivedCommandArguments)
 
 			// Exécution de la commande
-			if executionError := redisServerInstance.commandRegistry.ExecuteCommand(receivedCommandName, receivedCommandArguments, redisServerInstance.redisStorage, protocolEncoder); executionError != nil {
-				log.Printf("❌ Erreur d'exécution de commande pour %s: %v", clientConnection.RemoteAddr(), executionError)
+			if executionError := redisServerInstance.commandRegistry.ExecuteCommand(clientSession, receivedCommandName, receivedCommandArguments, redisServerInstance.redisStorage, protocolEncoder); executionError != nil {
+				log.Printf("❌ Erreur d'exécution de commande pour %s: %v", clientSession.RemoteAddress, executionError)
 				protocolEncoder.WriteErrorResponse("ERREUR : erreur interne du serveur")
 			}
+
+			// CLIENT KILL sur soi-même : fermeture après la réponse
+			if clientSession.ShouldCloseAfterReply() {
+				return
+			}
 		}
 	}
 }
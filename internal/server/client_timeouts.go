@@ -0,0 +1,88 @@
+package server
+
+import (
+	"crypto/tls"
+	"log"
+	"net"
+	"time"
+)
+
+// idleClientCheckInterval est la fréquence de vérification des clients inactifs
+const idleClientCheckInterval = time.Second
+
+// SetClientIdleTimeout modifie le timeout d'inactivité des clients à chaud (0 = jamais)
+func (redisServerInstance *RedisServerInstance) SetClientIdleTimeout(clientIdleTimeout time.Duration) {
+	redisServerInstance.clientIdleTimeout.Store(int64(clientIdleTimeout))
+}
+
+// GetClientIdleTimeout retourne le timeout d'inactivité courant
+func (redisServerInstance *RedisServerInstance) GetClientIdleTimeout() time.Duration {
+	return time.Duration(redisServerInstance.clientIdleTimeout.Load())
+}
+
+// SetTCPKeepAlivePeriod modifie l'intervalle keepalive TCP (appliqué aux nouvelles connexions)
+func (redisServerInstance *RedisServerInstance) SetTCPKeepAlivePeriod(keepAlivePeriod time.Duration) {
+	redisServerInstance.tcpKeepAlivePeriod.Store(int64(keepAlivePeriod))
+}
+
+// GetTCPKeepAlivePeriod retourne l'intervalle keepalive TCP courant
+func (redisServerInstance *RedisServerInstance) GetTCPKeepAlivePeriod() time.Duration {
+	return time.Duration(redisServerInstance.tcpKeepAlivePeriod.Load())
+}
+
+// applyTCPKeepAlive active le keepalive TCP sur une connexion acceptée (TLS compris)
+func (redisServerInstance *RedisServerInstance) applyTCPKeepAlive(clientConnection net.Conn) {
+	if tlsConnection, isTLSConnection := clientConnection.(*tls.Conn); isTLSConnection {
+		clientConnection = tlsConnection.NetConn()
+	}
+
+	tcpConnection, isTCPConnection := clientConnection.(*net.TCPConn)
+	if !isTCPConnection {
+		return
+	}
+
+	keepAlivePeriod := redisServerInstance.GetTCPKeepAlivePeriod()
+	if keepAlivePeriod <= 0 {
+		tcpConnection.SetKeepAlive(false)
+		return
+	}
+	tcpConnection.SetKeepAlive(true)
+	tcpConnection.SetKeepAlivePeriod(keepAlivePeriod)
+}
+
+// startIdleClientMonitor ferme périodiquement les clients inactifs depuis plus que le timeout
+// Le timeout étant relu à chaque tour, une modification à chaud s'applique aussi aux clients déjà connectés
+func (redisServerInstance *RedisServerInstance) startIdleClientMonitor() {
+	redisServerInstance.activeGoroutines.Add(1)
+	go func() {
+		defer redisServerInstance.activeGoroutines.Done()
+
+		idleCheckTicker := time.NewTicker(idleClientCheckInterval)
+		defer idleCheckTicker.Stop()
+
+		for {
+			select {
+			case <-redisServerInstance.shutdownSignal:
+				return
+			case <-idleCheckTicker.C:
+				redisServerInstance.closeIdleClients()
+			}
+		}
+	}()
+}
+
+// closeIdleClients ferme les clients inactifs non exemptés (bloqués, abonnés, réplication)
+func (redisServerInstance *RedisServerInstance) closeIdleClients() {
+	clientIdleTimeout := redisServerInstance.GetClientIdleTimeout()
+	if clientIdleTimeout <= 0 {
+		return
+	}
+
+	for _, clientSession := range redisServerInstance.sessionManager.ListSessions() {
+		if clientSession.IsExemptFromIdleTimeout() || clientSession.GetIdleDuration() <= clientIdleTimeout {
+			continue
+		}
+		log.Printf("⏰ Timeout de connexion pour %s (inactif depuis plus de %v)", clientSession.RemoteAddress, clientIdleTimeout)
+		clientSession.Close()
+	}
+}
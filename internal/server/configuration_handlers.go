@@ -0,0 +1,116 @@
+package server
+
+import (
+	"fmt"
+
+	"redis-go/internal/config"
+)
+
+// registerParameterChangeHandlers relie les paramètres modifiables à chaud aux sous-systèmes concernés
+func (redisServerInstance *RedisServerInstance) registerParameterChangeHandlers() {
+	parameterRegistry := redisServerInstance.parameterRegistry
+
+	parameterRegistry.OnParameterChange("timeout", func(serverConfiguration *config.ServerConfiguration) error {
+		redisServerInstance.SetClientIdleTimeout(serverConfiguration.NetworkConfiguration.ClientIdleTimeout)
+		return nil
+	})
+
+	parameterRegistry.OnParameterChange("tcp-keepalive", func(serverConfiguration *config.ServerConfiguration) error {
+		redisServerInstance.SetTCPKeepAlivePeriod(serverConfiguration.NetworkConfiguration.TCPKeepAlivePeriod)
+		return nil
+	})
+
+	parameterRegistry.OnParameterChange("maxclients", func(serverConfiguration *config.ServerConfiguration) error {
+		redisServerInstance.maximumConnections.Store(int64(serverConfiguration.PerformanceConfiguration.MaximumConnections))
+		return nil
+	})
+
+	parameterRegistry.OnParameterChange("hll-sparse-max-bytes", func(serverConfiguration *config.ServerConfiguration) error {
+		redisServerInstance.commandRegistry.SetHyperLogLogSparseMaximumBytes(serverConfiguration.PerformanceConfiguration.HyperLogLogSparseMaximumBytes)
+		return nil
+	})
+
+	parameterRegistry.OnParameterChange("expiry-check-interval", func(serverConfiguration *config.ServerConfiguration) error {
+		redisServerInstance.SetExpirationCheckInterval(serverConfiguration.MaintenanceConfiguration.ExpirationCheckInterval)
+		return nil
+	})
+
+	parameterRegistry.OnParameterChange("max-savepoints", func(serverConfiguration *config.ServerConfiguration) error {
+		redisServerInstance.commandRegistry.SetMaximumSavepoints(serverConfiguration.MaintenanceConfiguration.MaximumSavepoints)
+		return nil
+	})
+
+	parameterRegistry.OnParameterChange("save", func(serverConfiguration *config.ServerConfiguration) error {
+		if redisServerInstance.rdbPersistence == nil {
+			return fmt.Errorf("persistence RDB désactivée")
+		}
+		redisServerInstance.rdbPersistence.SetSaveRules(serverConfiguration.PersistenceConfiguration.RDBSaveRules)
+		return nil
+	})
+
+	parameterRegistry.OnParameterChange("rdbcompression", func(serverConfiguration *config.ServerConfiguration) error {
+		if redisServerInstance.rdbPersistence == nil {
+			return fmt.Errorf("persistence RDB désactivée")
+		}
+		redisServerInstance.rdbPersistence.SetCompression(serverConfiguration.PersistenceConfiguration.RDBCompression)
+		return nil
+	})
+
+	parameterRegistry.OnParameterChange("rdb-retention", func(serverConfiguration *config.ServerConfiguration) error {
+		if redisServerInstance.rdbPersistence == nil {
+			return fmt.Errorf("persistence RDB désactivée")
+		}
+		redisServerInstance.rdbPersistence.SetRetention(serverConfiguration.PersistenceConfiguration.RDBRetention)
+		return nil
+	})
+
+	parameterRegistry.OnParameterChange("requirepass", func(serverConfiguration *config.ServerConfiguration) error {
+		redisServerInstance.commandRegistry.SetRequiredPassword(serverConfiguration.SecurityConfiguration.RequirePassword)
+		return nil
+	})
+
+	masterCredentialsChangeHandler := func(serverConfiguration *config.ServerConfiguration) error {
+		redisServerInstance.replicationManager.SetMasterCredentials(
+			serverConfiguration.ReplicationConfiguration.MasterUser,
+			serverConfiguration.ReplicationConfiguration.MasterPassword)
+		return nil
+	}
+	parameterRegistry.OnParameterChange("masteruser", masterCredentialsChangeHandler)
+	parameterRegistry.OnParameterChange("masterauth", masterCredentialsChangeHandler)
+
+	parameterRegistry.OnParameterChange("replica-read-only", func(serverConfiguration *config.ServerConfiguration) error {
+		redisServerInstance.replicationManager.SetReplicaReadOnly(serverConfiguration.ReplicationConfiguration.ReplicaReadOnly)
+		return nil
+	})
+
+	parameterRegistry.OnParameterChange("repl-backlog-size", func(serverConfiguration *config.ServerConfiguration) error {
+		redisServerInstance.replicationManager.SetBacklogSize(serverConfiguration.ReplicationConfiguration.BacklogSize)
+		return nil
+	})
+
+	parameterRegistry.OnParameterChange("repl-ping-replica-period", func(serverConfiguration *config.ServerConfiguration) error {
+		redisServerInstance.replicationManager.SetPingReplicaPeriod(serverConfiguration.ReplicationConfiguration.PingReplicaPeriod)
+		return nil
+	})
+
+	parameterRegistry.OnParameterChange("repl-timeout", func(serverConfiguration *config.ServerConfiguration) error {
+		redisServerInstance.replicationManager.SetReplicationTimeout(serverConfiguration.ReplicationConfiguration.ReplicationTimeout)
+		return nil
+	})
+
+	parameterRegistry.OnParameterChange("raft-snapshot-threshold", func(serverConfiguration *config.ServerConfiguration) error {
+		if redisServerInstance.raftNode == nil {
+			return fmt.Errorf("mode raft désactivé")
+		}
+		redisServerInstance.raftNode.SetSnapshotThreshold(int64(serverConfiguration.RaftConfiguration.RaftSnapshotThreshold))
+		return nil
+	})
+
+	parameterRegistry.OnParameterChange("raft-linearizable-reads", func(serverConfiguration *config.ServerConfiguration) error {
+		if redisServerInstance.raftNode == nil {
+			return fmt.Errorf("mode raft désactivé")
+		}
+		redisServerInstance.commandRegistry.SetRaftLinearizableReads(serverConfiguration.RaftConfiguration.RaftLinearizableReads)
+		return nil
+	})
+}
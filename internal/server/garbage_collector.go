@@ -11,16 +11,21 @@ func (redisServerInstance *RedisServerInstance) startExpirationGarbageCollector(
 	go func() {
 		defer redisServerInstance.activeGoroutines.Done()
 
-		garbageCollectionTicker := time.NewTicker(redisServerInstance.serverConfiguration.MaintenanceConfiguration.ExpirationCheckInterval)
+		expirationCheckInterval := redisServerInstance.serverConfiguration.MaintenanceConfiguration.ExpirationCheckInterval
+		garbageCollectionTicker := time.NewTicker(expirationCheckInterval)
 		defer garbageCollectionTicker.Stop()
 
-		log.Printf("🧹 Garbage collector démarré (intervalle: %v)", redisServerInstance.serverConfiguration.MaintenanceConfiguration.ExpirationCheckInterval)
+		log.Printf("🧹 Garbage collector démarré (intervalle: %v)", expirationCheckInterval)
 
 		for {
 			select {
 			case <-redisServerInstance.shutdownSignal:
 				log.Printf("🧹 Arrêt du garbage collector")
 				return
+			case updatedInterval := <-redisServerInstance.expirationIntervalUpdates:
+				// Nouvel intervalle appliqué par CONFIG SET expiry-check-interval
+				garbageCollectionTicker.Reset(updatedInterval)
+				log.Printf("🧹 Garbage collector: nouvel intervalle %v", updatedInterval)
 			case <-garbageCollectionTicker.C:
 				// Nettoyage des clés expirées
 				cleanedKeyCount := redisServerInstance.redisStorage.CleanupExpiredKeys()
@@ -31,3 +36,13 @@ func (redisServerInstance *RedisServerInstance) startExpirationGarbageCollector(
 		}
 	}()
 }
+
+// SetExpirationCheckInterval modifie à chaud l'intervalle du garbage collector
+func (redisServerInstance *RedisServerInstance) SetExpirationCheckInterval(expirationCheckInterval time.Duration) {
+	// Seule la dernière valeur compte : une mise à jour non consommée est remplacée
+	select {
+	case <-redisServerInstance.expirationIntervalUpdates:
+	default:
+	}
+	redisServerInstance.expirationIntervalUpdates <- expirationCheckInterval
+}
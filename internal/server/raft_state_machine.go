@@ -0,0 +1,69 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"redis-go/internal/commands"
+	"redis-go/internal/persistence"
+	"redis-go/internal/storage"
+)
+
+// raftStorageStateMachine relie le journal Raft au stockage : les écritures validées passent par les
+// mêmes handlers que les commandes des clients, les snapshots utilisent le format des fichiers RDB
+type raftStorageStateMachine struct {
+	commandRegistry *commands.RedisCommandRegistry
+	redisStorage    *storage.RedisInMemoryStorage
+}
+
+// Apply exécute une écriture validée
+func (stateMachine *raftStorageStateMachine) Apply(commandArguments []string) []byte {
+	return stateMachine.commandRegistry.ApplyCommittedCommand(commandArguments, stateMachine.redisStorage)
+}
+
+// Snapshot copie le stockage (CreateSnapshot) et le sérialise en flux de snapshot compressé
+func (stateMachine *raftStorageStateMachine) Snapshot() ([]byte, error) {
+	storageSnapshot := stateMachine.redisStorage.CreateSnapshot()
+
+	var snapshotData bytes.Buffer
+	snapshotWriter, createError := persistence.NewSnapshotWriter(&snapshotData, storageSnapshot.Timestamp, true)
+	if createError != nil {
+		return nil, createError
+	}
+	for storageKey, storageValue := range storageSnapshot.Data {
+		if err := snapshotWriter.WriteRecord(storage.NewSnapshotRecord(storageKey, storageValue)); err != nil {
+			return nil, fmt.Errorf("écriture clé '%s': %v", storageKey, err)
+		}
+	}
+	snapshotWriter.SetSearchIndexes(storageSnapshot.SearchIndexes)
+	if err := snapshotWriter.Close(); err != nil {
+		return nil, err
+	}
+	return snapshotData.Bytes(), nil
+}
+
+// Restore remplace tout le stockage par le contenu d'un snapshot
+func (stateMachine *raftStorageStateMachine) Restore(snapshotData []byte) error {
+	snapshotReader, openError := persistence.NewSnapshotReader(bytes.NewReader(snapshotData))
+	if openError != nil {
+		return openError
+	}
+	storageSnapshot := storage.StorageSnapshot{
+		Data:      make(map[string]*storage.RedisStorageValue),
+		Timestamp: snapshotReader.CreatedAt,
+	}
+	for {
+		snapshotRecord, readError := snapshotReader.Next()
+		if readError == io.EOF {
+			break
+		}
+		if readError != nil {
+			return readError
+		}
+		storageSnapshot.Data[snapshotRecord.Key] = snapshotRecord.ToStorageValue()
+	}
+	storageSnapshot.SearchIndexes = snapshotReader.SearchIndexes
+	stateMachine.redisStorage.RestoreFromSnapshot(storageSnapshot)
+	return nil
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"redis-go/internal/session"
+)
+
+// replicationTestTimeout couvre la synchronisation initiale et une reconnexion (délai de reconnexion d'une seconde)
+const replicationTestTimeout = 10 * time.Second
+
+// startMasterAndReplica démarre un maître puis un réplica qui s'y connecte via replicaof
+func startMasterAndReplica(t *testing.T, beforeReplicaStarts func(masterClient *testRedisClient)) (*RedisServerInstance, *testRedisClient, *RedisServerInstance, *testRedisClient) {
+	t.Helper()
+	masterConfiguration := newTestServerConfiguration(t)
+	masterPort := masterConfiguration.NetworkConfiguration.PortNumber
+	masterInstance := startTestServer(t, masterConfiguration, masterPort)
+	masterClient := dialTestClient(t, masterPort)
+
+	if beforeReplicaStarts != nil {
+		beforeReplicaStarts(masterClient)
+	}
+
+	replicaConfiguration := newTestServerConfiguration(t)
+	replicaConfiguration.ReplicationConfiguration.ReplicaOfHost = "127.0.0.1"
+	replicaConfiguration.ReplicationConfiguration.ReplicaOfPort = masterPort
+	replicaConfiguration.ReplicationConfiguration.ReplicaReadOnly = true
+	replicaPort := replicaConfiguration.NetworkConfiguration.PortNumber
+	replicaInstance := startTestServer(t, replicaConfiguration, replicaPort)
+	replicaClient := dialTestClient(t, replicaPort)
+
+	waitForCondition(t, replicationTestTimeout, "lien de réplication établi", func() bool {
+		return replicationInfoField(t, replicaClient, "master_link_status") == "up"
+	})
+	return masterInstance, masterClient, replicaInstance, replicaClient
+}
+
+// replicationInfoField lit un champ de INFO replication
+func replicationInfoField(t *testing.T, testClient *testRedisClient, fieldName string) string {
+	t.Helper()
+	infoReply, isText := testClient.mustExecute(t, "INFO", "replication").(string)
+	if !isText {
+		t.Fatalf("INFO replication: réponse inattendue %#v", infoReply)
+	}
+	for _, infoLine := range strings.Split(infoReply, "\r\n") {
+		if fieldValue, hasPrefix := strings.CutPrefix(infoLine, fieldName+":"); hasPrefix {
+			return fieldValue
+		}
+	}
+	return ""
+}
+
+// replicationInfoCounter lit un compteur de INFO replication
+func replicationInfoCounter(t *testing.T, testClient *testRedisClient, fieldName string) int {
+	t.Helper()
+	counterValue, parseError := strconv.Atoi(replicationInfoField(t, testClient, fieldName))
+	if parseError != nil {
+		t.Fatalf("INFO replication: champ %s illisible: %v", fieldName, parseError)
+	}
+	return counterValue
+}
+
+// waitForReplicatedValue attend que le réplica serve la valeur écrite sur le maître
+func waitForReplicatedValue(t *testing.T, replicaClient *testRedisClient, key string, expectedValue string) {
+	t.Helper()
+	waitForCondition(t, replicationTestTimeout, fmt.Sprintf("%s=%s sur le réplica", key, expectedValue), func() bool {
+		return replicaClient.mustExecute(t, "GET", key) == expectedValue
+	})
+}
+
+// closeReplicaSessions coupe côté maître les connexions des réplicas, comme une panne réseau
+func closeReplicaSessions(masterInstance *RedisServerInstance) int {
+	closedSessions := 0
+	for _, clientSession := range masterInstance.sessionManager.ListSessions() {
+		if clientSession.GetClientType() == session.ReplicaClientType {
+			clientSession.Close()
+			closedSessions++
+		}
+	}
+	return closedSessions
+}
+
+func TestReplicaFullSyncReceivesExistingDataAndStream(t *testing.T) {
+	_, masterClient, _, replicaClient := startMasterAndReplica(t, func(masterClient *testRedisClient) {
+		for keyIndex := 0; keyIndex < 50; keyIndex++ {
+			masterClient.mustExecute(t, "SET", fmt.Sprintf("avant:%d", keyIndex), strconv.Itoa(keyIndex))
+		}
+		masterClient.mustExecute(t, "RPUSH", "liste", "a", "b", "c")
+	})
+
+	if fullSyncCount := replicationInfoCounter(t, masterClient, "sync_full"); fullSyncCount != 1 {
+		t.Fatalf("sync_full = %d, attendu 1", fullSyncCount)
+	}
+
+	// Données présentes avant la connexion : transférées par le snapshot de la synchronisation complète
+	waitForReplicatedValue(t, replicaClient, "avant:49", "49")
+	for keyIndex := 0; keyIndex < 50; keyIndex++ {
+		if replicatedValue := replicaClient.mustExecute(t, "GET", fmt.Sprintf("avant:%d", keyIndex)); replicatedValue != strconv.Itoa(keyIndex) {
+			t.Fatalf("avant:%d = %#v sur le réplica", keyIndex, replicatedValue)
+		}
+	}
+	if listLength := replicaClient.mustExecute(t, "LLEN", "liste"); listLength != int64(3) {
+		t.Fatalf("LLEN liste = %#v sur le réplica, attendu 3", listLength)
+	}
+
+	// Écritures postérieures : propagées par le flux de commandes
+	masterClient.mustExecute(t, "SET", "apres", "flux")
+	masterClient.mustExecute(t, "INCR", "compteur")
+	masterClient.mustExecute(t, "DEL", "avant:0")
+	waitForReplicatedValue(t, replicaClient, "apres", "flux")
+	waitForReplicatedValue(t, replicaClient, "compteur", "1")
+	if existsReply := replicaClient.mustExecute(t, "EXISTS", "avant:0"); existsReply != int64(0) {
+		t.Fatalf("EXISTS avant:0 = %#v sur le réplica, attendu supprimée", existsReply)
+	}
+
+	if replicaRole := replicationInfoField(t, replicaClient, "role"); replicaRole != "slave" {
+		t.Fatalf("role = %q sur le réplica", replicaRole)
+	}
+}
+
+func TestReplicaResumesWithPartialResyncFromBacklog(t *testing.T) {
+	masterInstance, masterClient, _, replicaClient := startMasterAndReplica(t, nil)
+
+	masterClient.mustExecute(t, "SET", "avant-coupure", "1")
+	waitForReplicatedValue(t, replicaClient, "avant-coupure", "1")
+
+	replicationIDBefore := replicationInfoField(t, masterClient, "master_replid")
+	if closedSessions := closeReplicaSessions(masterInstance); closedSessions != 1 {
+		t.Fatalf("%d connexion(s) de réplica coupée(s), attendu 1", closedSessions)
+	}
+
+	// Écritures pendant la coupure : le réplica doit les récupérer depuis le backlog
+	for keyIndex := 0; keyIndex < 20; keyIndex++ {
+		masterClient.mustExecute(t, "SET", fmt.Sprintf("pendant:%d", keyIndex), strconv.Itoa(keyIndex))
+	}
+
+	waitForCondition(t, replicationTestTimeout, "resynchronisation partielle acceptée", func() bool {
+		return replicationInfoCounter(t, masterClient, "sync_partial_ok") == 1
+	})
+	for keyIndex := 0; keyIndex < 20; keyIndex++ {
+		waitForReplicatedValue(t, replicaClient, fmt.Sprintf("pendant:%d", keyIndex), strconv.Itoa(keyIndex))
+	}
+
+	if fullSyncCount := replicationInfoCounter(t, masterClient, "sync_full"); fullSyncCount != 1 {
+		t.Fatalf("sync_full = %d après la reconnexion, attendu 1 (pas de nouvelle synchronisation complète)", fullSyncCount)
+	}
+	if replicationIDAfter := replicationInfoField(t, masterClient, "master_replid"); replicationIDAfter != replicationIDBefore {
+		t.Fatalf("master_replid a changé: %s -> %s", replicationIDBefore, replicationIDAfter)
+	}
+
+	masterClient.mustExecute(t, "SET", "apres-reprise", "ok")
+	waitForReplicatedValue(t, replicaClient, "apres-reprise", "ok")
+	waitForCondition(t, replicationTestTimeout, "offsets maître et réplica alignés", func() bool {
+		return replicationInfoField(t, masterClient, "master_repl_offset") == replicationInfoField(t, replicaClient, "master_repl_offset")
+	})
+}
+
+func TestReadOnlyReplicaRejectsClientWrites(t *testing.T) {
+	_, masterClient, _, replicaClient := startMasterAndReplica(t, nil)
+
+	for _, writeCommand := range [][]string{
+		{"SET", "interdit", "1"},
+		{"DEL", "interdit"},
+		{"RPUSH", "liste", "a"},
+		{"EXPIRE", "interdit", "10"},
+	} {
+		commandReply := replicaClient.mustExecute(t, writeCommand...)
+		replyError, isError := commandReply.(testReplyError)
+		if !isError || !strings.HasPrefix(string(replyError), "READONLY") {
+			t.Fatalf("%s sur le réplica: %#v, attendu une erreur READONLY", strings.Join(writeCommand, " "), commandReply)
+		}
+	}
+
+	// Les lectures restent servies et les écritures du maître continuent d'arriver
+	masterClient.mustExecute(t, "SET", "interdit", "maitre")
+	waitForReplicatedValue(t, replicaClient, "interdit", "maitre")
+	if existsReply := replicaClient.mustExecute(t, "EXISTS", "interdit"); existsReply != int64(1) {
+		t.Fatalf("EXISTS interdit = %#v sur le réplica", existsReply)
+	}
+}
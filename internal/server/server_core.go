@@ -1,12 +1,21 @@
 package server
 
 import (
+	"fmt"
+	"log"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"redis-go/internal/cluster"
 	"redis-go/internal/commands"
 	"redis-go/internal/config"
+	"redis-go/internal/consensus"
 	"redis-go/internal/persistence"
+	"redis-go/internal/protocol"
+	"redis-go/internal/replication"
+	"redis-go/internal/session"
 	"redis-go/internal/storage"
 )
 
@@ -16,40 +25,176 @@ type RedisServerInstance struct {
 	redisStorage        *storage.RedisInMemoryStorage
 	commandRegistry     *commands.RedisCommandRegistry
 	rdbPersistence      *persistence.RDBPersistence // Nouveau
-	networkListener     net.Listener
-	connectedClients    map[net.Conn]bool
-	clientsMutex        sync.RWMutex
+	replicationManager  *replication.ReplicationManager
+	durableWriteLog     *persistence.DurableWriteLog // nil si durable-log no
+	clusterManager      *cluster.ClusterManager      // nil si cluster-enabled no
+	raftNode            *consensus.RaftNode          // nil si raft-enabled no
+	raftSetupError      error                        // Configuration raft invalide : le démarrage est refusé
+	parameterRegistry   *config.ParameterRegistry
+	networkListeners    []net.Listener
+	tlsCertificates     *tlsCertificateStore
+	sessionManager      *session.ClientSessionManager
 	shutdownSignal      chan struct{}
 	activeGoroutines    sync.WaitGroup
+	clientIdleTimeout   atomic.Int64 // time.Duration, modifiable à chaud
+	tcpKeepAlivePeriod  atomic.Int64 // time.Duration, modifiable à chaud
+	maximumConnections  atomic.Int64 // modifiable à chaud (maxclients)
+
+	expirationIntervalUpdates chan time.Duration
 }
 
 // NewRedisServerInstance crée une nouvelle instance de serveur
 func NewRedisServerInstance(serverConfiguration *config.ServerConfiguration) *RedisServerInstance {
 	redisStorage := storage.NewRedisInMemoryStorage()
 	commandRegistry := commands.NewRedisCommandRegistry()
+	sessionManager := session.NewClientSessionManager()
 
 	redisServerInstance := &RedisServerInstance{
 		serverConfiguration: serverConfiguration,
 		redisStorage:        redisStorage,
 		commandRegistry:     commandRegistry,
-		connectedClients:    make(map[net.Conn]bool),
+		sessionManager:      sessionManager,
+		parameterRegistry:   config.NewParameterRegistry(serverConfiguration),
 		shutdownSignal:      make(chan struct{}),
+
+		expirationIntervalUpdates: make(chan time.Duration, 1),
+	}
+
+	redisServerInstance.SetClientIdleTimeout(serverConfiguration.NetworkConfiguration.ClientIdleTimeout)
+	redisServerInstance.SetTCPKeepAlivePeriod(serverConfiguration.NetworkConfiguration.TCPKeepAlivePeriod)
+	redisServerInstance.maximumConnections.Store(int64(serverConfiguration.PerformanceConfiguration.MaximumConnections))
+	commandRegistry.SetHyperLogLogSparseMaximumBytes(serverConfiguration.PerformanceConfiguration.HyperLogLogSparseMaximumBytes)
+	commandRegistry.SetMaximumSavepoints(serverConfiguration.MaintenanceConfiguration.MaximumSavepoints)
+
+	// Configurer les commandes CLIENT et l'authentification (ACL + requirepass)
+	commandRegistry.SetClientSessionManager(sessionManager)
+	if aclError := commandRegistry.ConfigureAccessControl(
+		serverConfiguration.SecurityConfiguration.RequirePassword,
+		serverConfiguration.SecurityConfiguration.ACLFilePath,
+	); aclError != nil {
+		log.Printf("⚠️  Erreur chargement ACL: %v", aclError)
 	}
 
 	// Initialiser la persistence RDB si activée
-	if serverConfiguration.PersistenceConfiguration.RDBEnabled {
+	// En mode raft, l'état est reconstruit depuis les snapshots et le journal du groupe
+	if serverConfiguration.PersistenceConfiguration.RDBEnabled && !serverConfiguration.RaftConfiguration.RaftEnabled {
 		redisServerInstance.rdbPersistence = persistence.NewRDBPersistence(
 			serverConfiguration.PersistenceConfiguration.RDBFilePath,
-			serverConfiguration.PersistenceConfiguration.RDBSaveInterval,
+			serverConfiguration.PersistenceConfiguration.RDBSaveRules,
 			redisStorage,
 		)
+		redisServerInstance.rdbPersistence.SetCompression(serverConfiguration.PersistenceConfiguration.RDBCompression)
+		redisServerInstance.rdbPersistence.SetRetention(serverConfiguration.PersistenceConfiguration.RDBRetention)
 
 		// Configurer les commandes RDB
 		commandRegistry.SetRDBPersistence(redisServerInstance.rdbPersistence)
 	}
 
+	// Journal des écritures : ouvert et rejoué au démarrage, après le chargement du snapshot
+	if serverConfiguration.PersistenceConfiguration.DurableLog {
+		redisServerInstance.durableWriteLog = persistence.NewDurableWriteLog(serverConfiguration.PersistenceConfiguration.DurableLogFile)
+		if redisServerInstance.rdbPersistence != nil {
+			redisServerInstance.rdbPersistence.SetDurableWriteLog(redisServerInstance.durableWriteLog)
+		}
+		commandRegistry.SetDurableWriteLog(redisServerInstance.durableWriteLog)
+	}
+
+	// Réplication : toujours disponible, le serveur démarre maître sauf si replicaof est configuré
+	replicationConfiguration := serverConfiguration.ReplicationConfiguration
+	redisServerInstance.replicationManager = replication.NewReplicationManager(
+		redisStorage,
+		sessionManager,
+		serverConfiguration.NetworkConfiguration.PortNumber,
+		replicationConfiguration.BacklogSize,
+	)
+	redisServerInstance.replicationManager.SetReplicaReadOnly(replicationConfiguration.ReplicaReadOnly)
+	redisServerInstance.replicationManager.SetPingReplicaPeriod(replicationConfiguration.PingReplicaPeriod)
+	redisServerInstance.replicationManager.SetReplicationTimeout(replicationConfiguration.ReplicationTimeout)
+	redisServerInstance.replicationManager.SetMasterCredentials(replicationConfiguration.MasterUser, replicationConfiguration.MasterPassword)
+	redisServerInstance.replicationManager.SetCommandApplier(func(masterSession *session.ClientSession, commandArguments []string) {
+		masterSession.BeginCommand(commandArguments[0], commandArguments[1:])
+		commandRegistry.ExecuteCommand(masterSession, commandArguments[0], commandArguments[1:], redisStorage, protocol.NewRedisSerializationProtocolEncoder(masterSession))
+	})
+	commandRegistry.SetReplicationManager(redisServerInstance.replicationManager)
+
+	// Mode cluster : la topologie est chargée au démarrage, un fichier invalide empêche de démarrer
+	if clusterConfiguration := serverConfiguration.ClusterConfiguration; clusterConfiguration.ClusterEnabled {
+		announcedPort := serverConfiguration.NetworkConfiguration.PortNumber
+		if announcedPort == 0 {
+			announcedPort = serverConfiguration.NetworkConfiguration.TLSConfiguration.PortNumber
+		}
+		redisServerInstance.clusterManager = cluster.NewClusterManager(
+			clusterConfiguration.ClusterTopologyFile,
+			clusterConfiguration.ClusterNodeIdentifier,
+			announcedPort,
+		)
+		commandRegistry.SetClusterManager(redisServerInstance.clusterManager)
+	}
+
+	// Mode raft : le nœud est démarré avec le serveur, une configuration invalide empêche de démarrer
+	if serverConfiguration.RaftConfiguration.RaftEnabled {
+		redisServerInstance.raftSetupError = redisServerInstance.createRaftNode()
+	}
+
+	// Application à chaud des paramètres modifiés par CONFIG SET
+	redisServerInstance.registerParameterChangeHandlers()
+	commandRegistry.SetParameterRegistry(redisServerInstance.parameterRegistry)
+
 	// Démarrage du garbage collector pour les clés expirées
 	redisServerInstance.startExpirationGarbageCollector()
 
+	// Surveillance des clients inactifs (timeout)
+	redisServerInstance.startIdleClientMonitor()
+
 	return redisServerInstance
 }
+
+// createRaftNode prépare le nœud Raft et son transport TCP d'après la configuration
+func (redisServerInstance *RedisServerInstance) createRaftNode() error {
+	raftConfiguration := redisServerInstance.serverConfiguration.RaftConfiguration
+	if redisServerInstance.serverConfiguration.PersistenceConfiguration.DurableLog {
+		return fmt.Errorf("durable-log et raft-enabled sont incompatibles : le journal Raft assure déjà la durabilité")
+	}
+	raftPeers, parseError := consensus.ParseRaftPeers(raftConfiguration.RaftPeers)
+	if parseError != nil {
+		return fmt.Errorf("raft-peers: %v", parseError)
+	}
+	if redisServerInstance.serverConfiguration.PersistenceConfiguration.RDBEnabled {
+		log.Printf("💾 Mode raft : persistence RDB ignorée, l'état est rétabli depuis %s", raftConfiguration.RaftDataDirectory)
+	}
+	if len(raftPeers) != 1 && len(raftPeers) != 3 && len(raftPeers) != 5 {
+		log.Printf("⚠️  Groupe raft de %d membres : 3 ou 5 membres sont recommandés", len(raftPeers))
+	}
+
+	var localRaftAddress string
+	for _, raftPeer := range raftPeers {
+		if raftPeer.NodeID == raftConfiguration.RaftNodeIdentifier {
+			localRaftAddress = raftPeer.RaftAddress
+		}
+	}
+	if localRaftAddress == "" {
+		return fmt.Errorf("raft-node-id '%s' absent de raft-peers", raftConfiguration.RaftNodeIdentifier)
+	}
+	electionTimeout := time.Duration(raftConfiguration.RaftElectionTimeout) * time.Millisecond
+	raftTransport := consensus.NewTCPRaftTransport(localRaftAddress, electionTimeout/2)
+	raftStateMachine := &raftStorageStateMachine{
+		commandRegistry: redisServerInstance.commandRegistry,
+		redisStorage:    redisServerInstance.redisStorage,
+	}
+	raftNode, nodeError := consensus.NewRaftNode(consensus.RaftConfiguration{
+		NodeID:            raftConfiguration.RaftNodeIdentifier,
+		Peers:             raftPeers,
+		DataDirectory:     raftConfiguration.RaftDataDirectory,
+		ElectionTimeout:   electionTimeout,
+		HeartbeatInterval: electionTimeout / 10,
+		SnapshotThreshold: int64(raftConfiguration.RaftSnapshotThreshold),
+	}, raftTransport, raftStateMachine)
+	if nodeError != nil {
+		return nodeError
+	}
+
+	redisServerInstance.raftNode = raftNode
+	redisServerInstance.commandRegistry.SetRaftNode(raftNode, 2*electionTimeout)
+	redisServerInstance.commandRegistry.SetRaftLinearizableReads(raftConfiguration.RaftLinearizableReads)
+	return nil
+}
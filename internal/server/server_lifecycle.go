@@ -1,67 +1,175 @@
 package server
 
 import (
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net"
+	"time"
 )
 
-// StartRedisServer démarre le serveur TCP
+// StartRedisServer démarre les listeners (TCP en clair et/ou TLS) et bloque jusqu'à l'arrêt
 func (redisServerInstance *RedisServerInstance) StartRedisServer() error {
+	// Sans topologie valide, le nœud ne saurait pas quels slots il sert
+	if redisServerInstance.clusterManager != nil {
+		if topologyError := redisServerInstance.clusterManager.Reload(); topologyError != nil {
+			return fmt.Errorf("topologie cluster: %v", topologyError)
+		}
+	}
+
+	// Mode raft : l'état est rétabli depuis le dernier snapshot Raft, puis complété par le journal du groupe
+	if redisServerInstance.serverConfiguration.RaftConfiguration.RaftEnabled {
+		if redisServerInstance.raftSetupError != nil {
+			return fmt.Errorf("mode raft: %v", redisServerInstance.raftSetupError)
+		}
+		if startError := redisServerInstance.raftNode.Start(); startError != nil {
+			return fmt.Errorf("mode raft: %v", startError)
+		}
+	}
+
 	// Charger les données depuis RDB si disponible
 	if redisServerInstance.rdbPersistence != nil {
 		if err := redisServerInstance.rdbPersistence.LoadSnapshot(); err != nil {
 			log.Printf("⚠️  Erreur chargement RDB: %v", err)
 		}
+	}
+
+	// Rejouer les écritures journalisées après ce snapshot : sans cela, des écritures acquittées seraient perdues
+	if redisServerInstance.durableWriteLog != nil {
+		if openError := redisServerInstance.openDurableWriteLog(); openError != nil {
+			return fmt.Errorf("journal des écritures: %v", openError)
+		}
+	}
 
-		// Démarrer la sauvegarde automatique
+	// Démarrer la sauvegarde automatique
+	if redisServerInstance.rdbPersistence != nil {
 		redisServerInstance.rdbPersistence.StartAutomaticSave()
 	}
 
-	serverAddress := fmt.Sprintf("%s:%d",
-		redisServerInstance.serverConfiguration.NetworkConfiguration.HostAddress,
-		redisServerInstance.serverConfiguration.NetworkConfiguration.PortNumber)
+	// Réplication : tâche périodique, puis connexion au maître si replicaof est configuré (hors mode raft)
+	redisServerInstance.replicationManager.Start()
+	if replicationConfiguration := redisServerInstance.serverConfiguration.ReplicationConfiguration; replicationConfiguration.ReplicaOfHost != "" && redisServerInstance.raftNode == nil {
+		redisServerInstance.replicationManager.ReplicateFrom(replicationConfiguration.ReplicaOfHost, replicationConfiguration.ReplicaOfPort)
+	}
+
+	if listenError := redisServerInstance.openNetworkListeners(); listenError != nil {
+		redisServerInstance.closeNetworkListeners()
+		return listenError
+	}
+
+	// Une boucle d'acceptation par listener, toutes partagent la gestion des clients
+	for _, networkListener := range redisServerInstance.networkListeners {
+		go redisServerInstance.acceptClientConnections(networkListener)
+	}
+
+	<-redisServerInstance.shutdownSignal
+	return nil
+}
+
+// openDurableWriteLog rejoue le journal des écritures sur les données chargées puis l'ouvre en écriture
+func (redisServerInstance *RedisServerInstance) openDurableWriteLog() error {
+	var loadedSnapshotTime time.Time
+	if redisServerInstance.rdbPersistence != nil {
+		loadedSnapshotTime = redisServerInstance.rdbPersistence.LoadedSnapshotTime()
+	}
+
+	return redisServerInstance.durableWriteLog.Open(loadedSnapshotTime, func(commandArguments []string) {
+		if !redisServerInstance.commandRegistry.ReplayLoggedCommand(commandArguments, redisServerInstance.redisStorage) {
+			log.Printf("⚠️  Journal: commande inconnue '%s' ignorée", commandArguments[0])
+		}
+	})
+}
+
+// openNetworkListeners ouvre le port en clair (si PortNumber > 0) et le port TLS (si configuré)
+func (redisServerInstance *RedisServerInstance) openNetworkListeners() error {
+	networkConfiguration := redisServerInstance.serverConfiguration.NetworkConfiguration
+
+	if networkConfiguration.PortNumber > 0 {
+		serverAddress := fmt.Sprintf("%s:%d", networkConfiguration.HostAddress, networkConfiguration.PortNumber)
+		networkListener, listenError := net.Listen("tcp", serverAddress)
+		if listenError != nil {
+			return fmt.Errorf("impossible d'écouter sur %s: %v", serverAddress, listenError)
+		}
+		redisServerInstance.networkListeners = append(redisServerInstance.networkListeners, networkListener)
+		log.Printf("🚀 Serveur Redis-Go en écoute sur %s", serverAddress)
+	}
+
+	if networkConfiguration.UnixSocketPath != "" {
+		unixListener, listenError := openUnixSocketListener(networkConfiguration.UnixSocketPath, networkConfiguration.UnixSocketPermission)
+		if listenError != nil {
+			return listenError
+		}
+		redisServerInstance.networkListeners = append(redisServerInstance.networkListeners, unixListener)
+		log.Printf("🧦 Serveur Redis-Go en écoute sur le socket Unix %s", networkConfiguration.UnixSocketPath)
+	}
 
-	networkListener, listenError := net.Listen("tcp", serverAddress)
-	if listenError != nil {
-		return fmt.Errorf("impossible d'écouter sur %s: %v", serverAddress, listenError)
+	if networkConfiguration.TLSConfiguration.PortNumber > 0 {
+		certificateStore, certificateError := newTLSCertificateStore(networkConfiguration.TLSConfiguration)
+		if certificateError != nil {
+			return certificateError
+		}
+		serverTLSConfiguration, configurationError := certificateStore.buildServerTLSConfiguration()
+		if configurationError != nil {
+			return configurationError
+		}
+
+		tlsAddress := fmt.Sprintf("%s:%d", networkConfiguration.HostAddress, networkConfiguration.TLSConfiguration.PortNumber)
+		tlsListener, listenError := tls.Listen("tcp", tlsAddress, serverTLSConfiguration)
+		if listenError != nil {
+			return fmt.Errorf("impossible d'écouter (TLS) sur %s: %v", tlsAddress, listenError)
+		}
+		redisServerInstance.tlsCertificates = certificateStore
+		redisServerInstance.networkListeners = append(redisServerInstance.networkListeners, tlsListener)
+		log.Printf("🔐 Serveur Redis-Go en écoute TLS sur %s (clients: %s)", tlsAddress, networkConfiguration.TLSConfiguration.ClientAuthentication)
 	}
 
-	redisServerInstance.networkListener = networkListener
-	log.Printf("🚀 Serveur Redis-Go en écoute sur %s", serverAddress)
+	if len(redisServerInstance.networkListeners) == 0 {
+		return fmt.Errorf("aucun port d'écoute configuré (REDIS_PORT, REDIS_TLS_PORT et REDIS_UNIXSOCKET désactivés)")
+	}
+	return nil
+}
 
-	// Boucle d'acceptation des connexions
+// acceptClientConnections est la boucle d'acceptation d'un listener
+func (redisServerInstance *RedisServerInstance) acceptClientConnections(networkListener net.Listener) {
 	for {
 		clientConnection, acceptError := networkListener.Accept()
 		if acceptError != nil {
 			select {
 			case <-redisServerInstance.shutdownSignal:
 				// Arrêt normal du serveur
-				return nil
+				return
 			default:
 				log.Printf("❌ Erreur lors de l'acceptation de connexion: %v", acceptError)
 				continue
 			}
 		}
 
-		log.Printf("🔗 Nouvelle connexion depuis %s", clientConnection.RemoteAddr())
-
 		// Vérification du nombre maximum de connexions
-		redisServerInstance.clientsMutex.Lock()
-		if len(redisServerInstance.connectedClients) >= redisServerInstance.serverConfiguration.PerformanceConfiguration.MaximumConnections {
-			redisServerInstance.clientsMutex.Unlock()
+		maximumConnections := redisServerInstance.maximumConnections.Load()
+		if int64(redisServerInstance.sessionManager.GetSessionCount()) >= maximumConnections {
 			clientConnection.Close()
-			log.Printf("🚫 Connexion refusée: limite atteinte (%d connexions max)",
-				redisServerInstance.serverConfiguration.PerformanceConfiguration.MaximumConnections)
+			log.Printf("🚫 Connexion refusée: limite atteinte (%d connexions max)", maximumConnections)
 			continue
 		}
 
-		redisServerInstance.connectedClients[clientConnection] = true
-		redisServerInstance.clientsMutex.Unlock()
+		redisServerInstance.applyTCPKeepAlive(clientConnection)
+		clientSession := redisServerInstance.sessionManager.RegisterSession(clientConnection)
+		log.Printf("🔗 Nouvelle connexion depuis %s", clientSession.RemoteAddress)
+		clientSession.SetAuthenticated(!redisServerInstance.commandRegistry.RequiresAuthentication())
 
 		// Gestion du client dans une goroutine séparée
 		redisServerInstance.activeGoroutines.Add(1)
-		go redisServerInstance.handleClientConnection(clientConnection)
+		go redisServerInstance.handleClientConnection(clientSession)
+	}
+}
+
+// closeNetworkListeners ferme tous les listeners ouverts et supprime le fichier du socket Unix
+func (redisServerInstance *RedisServerInstance) closeNetworkListeners() {
+	for _, networkListener := range redisServerInstance.networkListeners {
+		networkListener.Close()
+		if networkListener.Addr().Network() == "unix" {
+			removeUnixSocketFile(networkListener.Addr().String())
+		}
 	}
 }
 
@@ -70,17 +178,13 @@ func (redisServerInstance *RedisServerInstance) StopRedisServer() error {
 	log.Printf("⏹️  Arrêt du serveur en cours...")
 	close(redisServerInstance.shutdownSignal)
 
-	if redisServerInstance.networkListener != nil {
-		redisServerInstance.networkListener.Close()
-	}
+	redisServerInstance.closeNetworkListeners()
+
+	// Arrêt du lien vers le maître et des flux vers les réplicas
+	redisServerInstance.replicationManager.Stop()
 
 	// Fermeture de toutes les connexions clients
-	redisServerInstance.clientsMutex.Lock()
-	connectedClientCount := len(redisServerInstance.connectedClients)
-	for clientConnection := range redisServerInstance.connectedClients {
-		clientConnection.Close()
-	}
-	redisServerInstance.clientsMutex.Unlock()
+	connectedClientCount := redisServerInstance.sessionManager.CloseAllSessions()
 
 	if connectedClientCount > 0 {
 		log.Printf("🔌 Fermeture de %d connexions clients...", connectedClientCount)
@@ -91,6 +195,16 @@ func (redisServerInstance *RedisServerInstance) StopRedisServer() error {
 		redisServerInstance.rdbPersistence.Stop()
 	}
 
+	// Le nœud raft s'arrête après les clients : plus aucune écriture ne peut lui être proposée
+	if redisServerInstance.raftNode != nil {
+		redisServerInstance.raftNode.Stop()
+	}
+
+	// Fermeture du journal après la sauvegarde finale, qui y inscrit son marqueur
+	if redisServerInstance.durableWriteLog != nil {
+		redisServerInstance.durableWriteLog.Stop()
+	}
+
 	// Attente de la fin de toutes les goroutines
 	redisServerInstance.activeGoroutines.Wait()
 
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"redis-go/internal/config"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// reserveTCPPort retourne un port libre de la boucle locale : le serveur l'ouvrira lui-même au démarrage
+func reserveTCPPort(t *testing.T) int {
+	t.Helper()
+	reservationListener, listenError := net.Listen("tcp", "127.0.0.1:0")
+	if listenError != nil {
+		t.Fatalf("réservation d'un port: %v", listenError)
+	}
+	defer reservationListener.Close()
+	return reservationListener.Addr().(*net.TCPAddr).Port
+}
+
+// newTestServerConfiguration retourne une configuration sans persistence écoutant sur un port éphémère
+func newTestServerConfiguration(t *testing.T) *config.ServerConfiguration {
+	t.Helper()
+	serverConfiguration := config.LoadServerConfiguration()
+	serverConfiguration.NetworkConfiguration.HostAddress = "127.0.0.1"
+	serverConfiguration.NetworkConfiguration.PortNumber = reserveTCPPort(t)
+	serverConfiguration.NetworkConfiguration.UnixSocketPath = ""
+	serverConfiguration.NetworkConfiguration.TLSConfiguration.PortNumber = 0
+	serverConfiguration.PersistenceConfiguration.RDBEnabled = false
+	serverConfiguration.PersistenceConfiguration.DurableLog = false
+	serverConfiguration.SecurityConfiguration.RequirePassword = ""
+	serverConfiguration.SecurityConfiguration.ACLFilePath = ""
+	serverConfiguration.ReplicationConfiguration.ReplicaOfHost = ""
+	serverConfiguration.ReplicationConfiguration.ReplicaOfPort = 0
+	serverConfiguration.ClusterConfiguration.ClusterEnabled = false
+	serverConfiguration.RaftConfiguration.RaftEnabled = false
+	serverConfiguration.ConfigurationFilePath = ""
+	return serverConfiguration
+}
+
+// startTestServer démarre une instance et attend que listeningPort accepte des connexions ; elle est arrêtée en fin de test
+func startTestServer(t *testing.T, serverConfiguration *config.ServerConfiguration, listeningPort int) *RedisServerInstance {
+	t.Helper()
+	redisServerInstance := NewRedisServerInstance(serverConfiguration)
+
+	startResult := make(chan error, 1)
+	go func() {
+		startResult <- redisServerInstance.StartRedisServer()
+	}()
+
+	listeningAddress := net.JoinHostPort("127.0.0.1", strconv.Itoa(listeningPort))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		select {
+		case startError := <-startResult:
+			t.Fatalf("le serveur n'a pas démarré: %v", startError)
+		default:
+		}
+		probeConnection, dialError := net.DialTimeout("tcp", listeningAddress, 100*time.Millisecond)
+		if dialError == nil {
+			probeConnection.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("le serveur n'écoute pas sur %s: %v", listeningAddress, dialError)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	t.Cleanup(func() {
+		redisServerInstance.StopRedisServer()
+	})
+	return redisServerInstance
+}
+
+// testReplyError est une réponse d'erreur RESP (-ERR ..., -READONLY ...)
+type testReplyError string
+
+// testRedisClient est un client RESP minimal pour piloter un serveur depuis les tests
+type testRedisClient struct {
+	clientConnection net.Conn
+	replyReader      *bufio.Reader
+}
+
+// dialTestClient ouvre une connexion en clair vers le port indiqué
+func dialTestClient(t *testing.T, listeningPort int) *testRedisClient {
+	t.Helper()
+	clientConnection, dialError := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(listeningPort)), time.Second)
+	if dialError != nil {
+		t.Fatalf("connexion au port %d: %v", listeningPort, dialError)
+	}
+	return newTestClient(t, clientConnection)
+}
+
+// newTestClient enveloppe une connexion déjà établie (en clair ou TLS)
+func newTestClient(t *testing.T, clientConnection net.Conn) *testRedisClient {
+	t.Helper()
+	t.Cleanup(func() {
+		clientConnection.Close()
+	})
+	return &testRedisClient{clientConnection: clientConnection, replyReader: bufio.NewReader(clientConnection)}
+}
+
+// execute envoie une commande et retourne sa réponse, ou l'erreur d'entrée/sortie
+func (testClient *testRedisClient) execute(commandArguments ...string) (interface{}, error) {
+	var encodedCommand strings.Builder
+	fmt.Fprintf(&encodedCommand, "*%d\r\n", len(commandArguments))
+	for _, commandArgument := range commandArguments {
+		fmt.Fprintf(&encodedCommand, "$%d\r\n%s\r\n", len(commandArgument), commandArgument)
+	}
+
+	testClient.clientConnection.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, writeError := io.WriteString(testClient.clientConnection, encodedCommand.String()); writeError != nil {
+		return nil, writeError
+	}
+	return testClient.readReply()
+}
+
+// mustExecute envoie une commande et fait échouer le test sur une erreur d'entrée/sortie
+func (testClient *testRedisClient) mustExecute(t *testing.T, commandArguments ...string) interface{} {
+	t.Helper()
+	commandReply, executeError := testClient.execute(commandArguments...)
+	if executeError != nil {
+		t.Fatalf("%s: %v", strings.Join(commandArguments, " "), executeError)
+	}
+	return commandReply
+}
+
+// readReply décode une réponse RESP : string, int64, nil, []interface{} ou testReplyError
+func (testClient *testRedisClient) readReply() (interface{}, error) {
+	replyLine, readError := testClient.replyReader.ReadString('\n')
+	if readError != nil {
+		return nil, readError
+	}
+	replyLine = strings.TrimSuffix(replyLine, "\r\n")
+	if replyLine == "" {
+		return nil, fmt.Errorf("réponse vide")
+	}
+
+	switch replyLine[0] {
+	case '+':
+		return replyLine[1:], nil
+	case '-':
+		return testReplyError(replyLine[1:]), nil
+	case ':':
+		return strconv.ParseInt(replyLine[1:], 10, 64)
+	case '$':
+		bulkLength, parseError := strconv.Atoi(replyLine[1:])
+		if parseError != nil {
+			return nil, parseError
+		}
+		if bulkLength < 0 {
+			return nil, nil
+		}
+		bulkContent := make([]byte, bulkLength+2)
+		if _, readError := io.ReadFull(testClient.replyReader, bulkContent); readError != nil {
+			return nil, readError
+		}
+		return string(bulkContent[:bulkLength]), nil
+	case '*':
+		elementCount, parseError := strconv.Atoi(replyLine[1:])
+		if parseError != nil {
+			return nil, parseError
+		}
+		if elementCount < 0 {
+			return nil, nil
+		}
+		replyElements := make([]interface{}, 0, elementCount)
+		for elementIndex := 0; elementIndex < elementCount; elementIndex++ {
+			replyElement, elementError := testClient.readReply()
+			if elementError != nil {
+				return nil, elementError
+			}
+			replyElements = append(replyElements, replyElement)
+		}
+		return replyElements, nil
+	default:
+		return nil, fmt.Errorf("type de réponse inattendu: %q", replyLine)
+	}
+}
+
+// waitForCondition relance condition jusqu'à ce qu'elle soit vraie ou que le délai expire
+func waitForCondition(t *testing.T, timeout time.Duration, description string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("délai dépassé en attendant: %s", description)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"redis-go/internal/config"
+)
+
+// tlsCertificateStore conserve le certificat et l'autorité courants, rechargeables à chaud (SIGHUP)
+type tlsCertificateStore struct {
+	tlsConfiguration   config.TLSConfiguration
+	serverCertificate  *tls.Certificate
+	clientAuthorityCAs *x509.CertPool
+	storeMutex         sync.RWMutex
+}
+
+// newTLSCertificateStore charge les certificats une première fois
+func newTLSCertificateStore(tlsConfiguration config.TLSConfiguration) (*tlsCertificateStore, error) {
+	certificateStore := &tlsCertificateStore{tlsConfiguration: tlsConfiguration}
+	if reloadError := certificateStore.reloadCertificates(); reloadError != nil {
+		return nil, reloadError
+	}
+	return certificateStore, nil
+}
+
+// reloadCertificates relit le certificat, la clé et l'autorité depuis le disque
+// En cas d'erreur, les certificats précédents restent en place
+func (certificateStore *tlsCertificateStore) reloadCertificates() error {
+	serverCertificate, loadError := tls.LoadX509KeyPair(certificateStore.tlsConfiguration.CertificateFile, certificateStore.tlsConfiguration.KeyFile)
+	if loadError != nil {
+		return fmt.Errorf("chargement certificat TLS: %v", loadError)
+	}
+
+	var clientAuthorityCAs *x509.CertPool
+	if certificateStore.tlsConfiguration.CACertificateFile != "" {
+		authorityContent, readError := os.ReadFile(certificateStore.tlsConfiguration.CACertificateFile)
+		if readError != nil {
+			return fmt.Errorf("lecture autorité TLS: %v", readError)
+		}
+		clientAuthorityCAs = x509.NewCertPool()
+		if !clientAuthorityCAs.AppendCertsFromPEM(authorityContent) {
+			return fmt.Errorf("aucun certificat valide dans %s", certificateStore.tlsConfiguration.CACertificateFile)
+		}
+	}
+
+	certificateStore.storeMutex.Lock()
+	certificateStore.serverCertificate = &serverCertificate
+	certificateStore.clientAuthorityCAs = clientAuthorityCAs
+	certificateStore.storeMutex.Unlock()
+	return nil
+}
+
+// buildServerTLSConfiguration construit la configuration tls.Config du listener
+// Chaque handshake récupère les certificats courants, ce qui permet le rechargement sans redémarrage
+func (certificateStore *tlsCertificateStore) buildServerTLSConfiguration() (*tls.Config, error) {
+	minimumVersion, versionError := parseTLSVersion(certificateStore.tlsConfiguration.MinimumVersion)
+	if versionError != nil {
+		return nil, versionError
+	}
+
+	cipherSuites, cipherError := parseTLSCipherSuites(certificateStore.tlsConfiguration.CipherSuites)
+	if cipherError != nil {
+		return nil, cipherError
+	}
+
+	clientAuthenticationType, authenticationError := parseTLSClientAuthentication(certificateStore.tlsConfiguration.ClientAuthentication)
+	if authenticationError != nil {
+		return nil, authenticationError
+	}
+	if clientAuthenticationType != tls.NoClientCert && certificateStore.tlsConfiguration.CACertificateFile == "" {
+		return nil, fmt.Errorf("la vérification des certificats clients exige un fichier d'autorité (REDIS_TLS_CA_CERT_FILE)")
+	}
+
+	return &tls.Config{
+		MinVersion: minimumVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			certificateStore.storeMutex.RLock()
+			defer certificateStore.storeMutex.RUnlock()
+
+			return &tls.Config{
+				Certificates: []tls.Certificate{*certificateStore.serverCertificate},
+				ClientCAs:    certificateStore.clientAuthorityCAs,
+				ClientAuth:   clientAuthenticationType,
+				MinVersion:   minimumVersion,
+				CipherSuites: cipherSuites,
+			}, nil
+		},
+	}, nil
+}
+
+// parseTLSVersion convertit "1.2" / "1.3" en constante crypto/tls
+func parseTLSVersion(versionName string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(versionName), "tlsv") {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("version TLS minimale non supportée '%s' (1.2 ou 1.3)", versionName)
+	}
+}
+
+// parseTLSCipherSuites convertit les noms de suites (ex: TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256) en identifiants
+func parseTLSCipherSuites(cipherSuiteNames []string) ([]uint16, error) {
+	if len(cipherSuiteNames) == 0 {
+		return nil, nil
+	}
+
+	knownCipherSuites := make(map[string]uint16)
+	for _, cipherSuite := range tls.CipherSuites() {
+		knownCipherSuites[cipherSuite.Name] = cipherSuite.ID
+	}
+
+	cipherSuites := make([]uint16, 0, len(cipherSuiteNames))
+	for _, cipherSuiteName := range cipherSuiteNames {
+		cipherSuiteIdentifier, suiteExists := knownCipherSuites[cipherSuiteName]
+		if !suiteExists {
+			return nil, fmt.Errorf("suite de chiffrement inconnue ou non sûre '%s'", cipherSuiteName)
+		}
+		cipherSuites = append(cipherSuites, cipherSuiteIdentifier)
+	}
+	return cipherSuites, nil
+}
+
+// parseTLSClientAuthentication convertit no / optional / yes en mode de vérification client
+func parseTLSClientAuthentication(authenticationMode string) (tls.ClientAuthType, error) {
+	switch strings.ToLower(authenticationMode) {
+	case "no":
+		return tls.NoClientCert, nil
+	case "optional":
+		return tls.VerifyClientCertIfGiven, nil
+	case "yes", "":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("mode d'authentification client TLS inconnu '%s' (no, optional ou yes)", authenticationMode)
+	}
+}
+
+// ReloadTLSCertificates recharge les certificats TLS depuis le disque (déclenché par SIGHUP)
+func (redisServerInstance *RedisServerInstance) ReloadTLSCertificates() error {
+	if redisServerInstance.tlsCertificates == nil {
+		return fmt.Errorf("TLS non activé")
+	}
+	if reloadError := redisServerInstance.tlsCertificates.reloadCertificates(); reloadError != nil {
+		return reloadError
+	}
+	log.Printf("🔐 TLS: certificats rechargés")
+	return nil
+}
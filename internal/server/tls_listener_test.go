@@ -0,0 +1,288 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testCertificateAuthority est une autorité de test capable de signer des certificats serveur et client
+type testCertificateAuthority struct {
+	authorityCertificate *x509.Certificate
+	authorityKey         *ecdsa.PrivateKey
+	authorityPEM         []byte
+}
+
+// newTestCertificateAuthority génère une autorité auto-signée
+func newTestCertificateAuthority(t *testing.T, commonName string) *testCertificateAuthority {
+	t.Helper()
+	authorityKey, keyError := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if keyError != nil {
+		t.Fatalf("clé de l'autorité: %v", keyError)
+	}
+	authorityTemplate := &x509.Certificate{
+		SerialNumber:          randomCertificateSerial(t),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	authorityDER, signError := x509.CreateCertificate(rand.Reader, authorityTemplate, authorityTemplate, &authorityKey.PublicKey, authorityKey)
+	if signError != nil {
+		t.Fatalf("certificat de l'autorité: %v", signError)
+	}
+	authorityCertificate, parseError := x509.ParseCertificate(authorityDER)
+	if parseError != nil {
+		t.Fatalf("lecture du certificat de l'autorité: %v", parseError)
+	}
+	return &testCertificateAuthority{
+		authorityCertificate: authorityCertificate,
+		authorityKey:         authorityKey,
+		authorityPEM:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: authorityDER}),
+	}
+}
+
+// certificatePool retourne un pool ne contenant que cette autorité
+func (certificateAuthority *testCertificateAuthority) certificatePool() *x509.CertPool {
+	authorityPool := x509.NewCertPool()
+	authorityPool.AddCert(certificateAuthority.authorityCertificate)
+	return authorityPool
+}
+
+// issueCertificate signe un certificat feuille et retourne le certificat et la clé au format PEM
+func (certificateAuthority *testCertificateAuthority) issueCertificate(t *testing.T, commonName string, extendedKeyUsage x509.ExtKeyUsage) (certificatePEM []byte, keyPEM []byte) {
+	t.Helper()
+	leafKey, keyError := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if keyError != nil {
+		t.Fatalf("clé de %s: %v", commonName, keyError)
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber: randomCertificateSerial(t),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{extendedKeyUsage},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	leafDER, signError := x509.CreateCertificate(rand.Reader, leafTemplate, certificateAuthority.authorityCertificate, &leafKey.PublicKey, certificateAuthority.authorityKey)
+	if signError != nil {
+		t.Fatalf("certificat de %s: %v", commonName, signError)
+	}
+	leafKeyDER, marshalError := x509.MarshalECPrivateKey(leafKey)
+	if marshalError != nil {
+		t.Fatalf("encodage de la clé de %s: %v", commonName, marshalError)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: leafKeyDER})
+}
+
+// issueClientCertificate signe un certificat client directement utilisable par crypto/tls
+func (certificateAuthority *testCertificateAuthority) issueClientCertificate(t *testing.T, commonName string) tls.Certificate {
+	t.Helper()
+	certificatePEM, keyPEM := certificateAuthority.issueCertificate(t, commonName, x509.ExtKeyUsageClientAuth)
+	clientCertificate, loadError := tls.X509KeyPair(certificatePEM, keyPEM)
+	if loadError != nil {
+		t.Fatalf("certificat client %s: %v", commonName, loadError)
+	}
+	return clientCertificate
+}
+
+// randomCertificateSerial tire un numéro de série : deux certificats successifs sont distinguables
+func randomCertificateSerial(t *testing.T) *big.Int {
+	t.Helper()
+	serialNumber, randomError := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 62))
+	if randomError != nil {
+		t.Fatalf("numéro de série: %v", randomError)
+	}
+	return serialNumber
+}
+
+// writeTestFile écrit un fichier PEM dans le répertoire du test
+func writeTestFile(t *testing.T, filePath string, fileContent []byte) {
+	t.Helper()
+	if writeError := os.WriteFile(filePath, fileContent, 0600); writeError != nil {
+		t.Fatalf("écriture de %s: %v", filePath, writeError)
+	}
+}
+
+// tlsTestServer regroupe un serveur TLS de test, son autorité et ses fichiers de certificats
+type tlsTestServer struct {
+	serverInstance       *RedisServerInstance
+	certificateAuthority *testCertificateAuthority
+	tlsPort              int
+	certificateFilePath  string
+	keyFilePath          string
+}
+
+// startTLSTestServer démarre un serveur écoutant uniquement en TLS avec le mode d'authentification client donné
+func startTLSTestServer(t *testing.T, clientAuthentication string) *tlsTestServer {
+	t.Helper()
+	certificateDirectory := t.TempDir()
+	certificateAuthority := newTestCertificateAuthority(t, "redis-go test CA")
+	serverCertificatePEM, serverKeyPEM := certificateAuthority.issueCertificate(t, "redis-go server", x509.ExtKeyUsageServerAuth)
+
+	testServer := &tlsTestServer{
+		certificateAuthority: certificateAuthority,
+		certificateFilePath:  filepath.Join(certificateDirectory, "server.crt"),
+		keyFilePath:          filepath.Join(certificateDirectory, "server.key"),
+	}
+	authorityFilePath := filepath.Join(certificateDirectory, "ca.crt")
+	writeTestFile(t, testServer.certificateFilePath, serverCertificatePEM)
+	writeTestFile(t, testServer.keyFilePath, serverKeyPEM)
+	writeTestFile(t, authorityFilePath, certificateAuthority.authorityPEM)
+
+	serverConfiguration := newTestServerConfiguration(t)
+	serverConfiguration.NetworkConfiguration.PortNumber = 0
+	testServer.tlsPort = reserveTCPPort(t)
+	serverConfiguration.NetworkConfiguration.TLSConfiguration.PortNumber = testServer.tlsPort
+	serverConfiguration.NetworkConfiguration.TLSConfiguration.CertificateFile = testServer.certificateFilePath
+	serverConfiguration.NetworkConfiguration.TLSConfiguration.KeyFile = testServer.keyFilePath
+	serverConfiguration.NetworkConfiguration.TLSConfiguration.CACertificateFile = authorityFilePath
+	serverConfiguration.NetworkConfiguration.TLSConfiguration.MinimumVersion = "1.2"
+	serverConfiguration.NetworkConfiguration.TLSConfiguration.CipherSuites = nil
+	serverConfiguration.NetworkConfiguration.TLSConfiguration.ClientAuthentication = clientAuthentication
+
+	testServer.serverInstance = startTestServer(t, serverConfiguration, testServer.tlsPort)
+	return testServer
+}
+
+// dialTLS établit une connexion TLS vérifiant le serveur avec l'autorité de test
+// Le certificat client est toujours présenté : avec Certificates, crypto/tls n'enverrait pas un certificat
+// dont l'autorité ne figure pas dans la liste annoncée par le serveur
+func (testServer *tlsTestServer) dialTLS(clientCertificates []tls.Certificate) (*tls.Conn, error) {
+	return tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(testServer.tlsPort)), &tls.Config{
+		RootCAs:    testServer.certificateAuthority.certificatePool(),
+		ServerName: "127.0.0.1",
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if len(clientCertificates) == 0 {
+				return &tls.Certificate{}, nil
+			}
+			return &clientCertificates[0], nil
+		},
+	})
+}
+
+// pingOverTLS se connecte puis envoie PING ; en TLS 1.3 un certificat client refusé n'apparaît qu'à la première lecture
+func (testServer *tlsTestServer) pingOverTLS(t *testing.T, clientCertificates []tls.Certificate) (interface{}, error) {
+	t.Helper()
+	tlsConnection, dialError := testServer.dialTLS(clientCertificates)
+	if dialError != nil {
+		return nil, dialError
+	}
+	return newTestClient(t, tlsConnection).execute("PING")
+}
+
+func TestTLSListenerAcceptsClientWithTrustedCertificate(t *testing.T) {
+	testServer := startTLSTestServer(t, "yes")
+	clientCertificate := testServer.certificateAuthority.issueClientCertificate(t, "client autorisé")
+
+	pingReply, pingError := testServer.pingOverTLS(t, []tls.Certificate{clientCertificate})
+	if pingError != nil {
+		t.Fatalf("PING en mTLS: %v", pingError)
+	}
+	if pingReply != "PONG" {
+		t.Fatalf("PING en mTLS = %#v, attendu PONG", pingReply)
+	}
+}
+
+func TestTLSListenerRejectsClientsWithoutTrustedCertificate(t *testing.T) {
+	testServer := startTLSTestServer(t, "yes")
+	unknownAuthority := newTestCertificateAuthority(t, "autorité inconnue")
+	untrustedCertificate := unknownAuthority.issueClientCertificate(t, "client inconnu")
+
+	for _, testCase := range []struct {
+		description        string
+		clientCertificates []tls.Certificate
+	}{
+		{"sans certificat client", nil},
+		{"certificat signé par une autorité inconnue", []tls.Certificate{untrustedCertificate}},
+	} {
+		if pingReply, pingError := testServer.pingOverTLS(t, testCase.clientCertificates); pingError == nil {
+			t.Fatalf("%s: connexion acceptée (réponse %#v), attendu un refus du handshake", testCase.description, pingReply)
+		}
+	}
+}
+
+func TestTLSListenerOptionalClientAuthentication(t *testing.T) {
+	testServer := startTLSTestServer(t, "optional")
+
+	if pingReply, pingError := testServer.pingOverTLS(t, nil); pingError != nil || pingReply != "PONG" {
+		t.Fatalf("PING sans certificat client en mode optional = %#v (%v), attendu PONG", pingReply, pingError)
+	}
+
+	// Un certificat présenté est tout de même vérifié
+	untrustedCertificate := newTestCertificateAuthority(t, "autorité inconnue").issueClientCertificate(t, "client inconnu")
+	if pingReply, pingError := testServer.pingOverTLS(t, []tls.Certificate{untrustedCertificate}); pingError == nil {
+		t.Fatalf("certificat d'une autorité inconnue accepté en mode optional (réponse %#v)", pingReply)
+	}
+}
+
+func TestTLSListenerServesReloadedCertificate(t *testing.T) {
+	testServer := startTLSTestServer(t, "yes")
+	clientCertificates := []tls.Certificate{testServer.certificateAuthority.issueClientCertificate(t, "client autorisé")}
+
+	initialConnection, dialError := testServer.dialTLS(clientCertificates)
+	if dialError != nil {
+		t.Fatalf("connexion initiale: %v", dialError)
+	}
+	initialClient := newTestClient(t, initialConnection)
+	if pingReply := initialClient.mustExecute(t, "PING"); pingReply != "PONG" {
+		t.Fatalf("PING initial = %#v", pingReply)
+	}
+	initialSerial := initialConnection.ConnectionState().PeerCertificates[0].SerialNumber
+
+	// Rotation du certificat serveur sur disque puis rechargement à chaud
+	renewedCertificatePEM, renewedKeyPEM := testServer.certificateAuthority.issueCertificate(t, "redis-go server renouvelé", x509.ExtKeyUsageServerAuth)
+	writeTestFile(t, testServer.certificateFilePath, renewedCertificatePEM)
+	writeTestFile(t, testServer.keyFilePath, renewedKeyPEM)
+	if reloadError := testServer.serverInstance.ReloadTLSCertificates(); reloadError != nil {
+		t.Fatalf("rechargement des certificats: %v", reloadError)
+	}
+
+	renewedConnection, dialError := testServer.dialTLS(clientCertificates)
+	if dialError != nil {
+		t.Fatalf("connexion après rechargement: %v", dialError)
+	}
+	renewedClient := newTestClient(t, renewedConnection)
+	if pingReply := renewedClient.mustExecute(t, "PING"); pingReply != "PONG" {
+		t.Fatalf("PING après rechargement = %#v", pingReply)
+	}
+	renewedSerial := renewedConnection.ConnectionState().PeerCertificates[0].SerialNumber
+	if renewedSerial.Cmp(initialSerial) == 0 {
+		t.Fatalf("le serveur présente toujours l'ancien certificat (série %s)", initialSerial)
+	}
+
+	// Les connexions établies avant le rechargement ne sont pas coupées
+	if pingReply := initialClient.mustExecute(t, "PING"); pingReply != "PONG" {
+		t.Fatalf("PING sur la connexion initiale après rechargement = %#v", pingReply)
+	}
+
+	// Un rechargement invalide est refusé et laisse le certificat courant en place
+	writeTestFile(t, testServer.certificateFilePath, []byte("pas un certificat"))
+	if reloadError := testServer.serverInstance.ReloadTLSCertificates(); reloadError == nil {
+		t.Fatalf("rechargement d'un certificat invalide accepté")
+	}
+	afterFailedReloadConnection, dialError := testServer.dialTLS(clientCertificates)
+	if dialError != nil {
+		t.Fatalf("connexion après un rechargement refusé: %v", dialError)
+	}
+	newTestClient(t, afterFailedReloadConnection).mustExecute(t, "PING")
+	if currentSerial := afterFailedReloadConnection.ConnectionState().PeerCertificates[0].SerialNumber; currentSerial.Cmp(renewedSerial) != 0 {
+		t.Fatalf("certificat servi après un rechargement refusé: série %s, attendu %s", currentSerial, renewedSerial)
+	}
+}
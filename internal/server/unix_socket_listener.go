@@ -0,0 +1,64 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"time"
+)
+
+// unixSocketProbeTimeout borne la tentative de connexion sur un socket existant
+const unixSocketProbeTimeout = 500 * time.Millisecond
+
+// openUnixSocketListener écoute sur un socket Unix après nettoyage d'un éventuel fichier périmé
+func openUnixSocketListener(unixSocketPath string, unixSocketPermission os.FileMode) (net.Listener, error) {
+	if cleanupError := removeStaleUnixSocket(unixSocketPath); cleanupError != nil {
+		return nil, cleanupError
+	}
+
+	unixListener, listenError := net.Listen("unix", unixSocketPath)
+	if listenError != nil {
+		return nil, fmt.Errorf("impossible d'écouter sur le socket Unix %s: %v", unixSocketPath, listenError)
+	}
+
+	if unixSocketPermission != 0 {
+		if chmodError := os.Chmod(unixSocketPath, unixSocketPermission); chmodError != nil {
+			unixListener.Close()
+			return nil, fmt.Errorf("permissions du socket Unix %s: %v", unixSocketPath, chmodError)
+		}
+	}
+	return unixListener, nil
+}
+
+// removeStaleUnixSocket supprime un socket laissé par un arrêt brutal
+// Un socket qui accepte encore des connexions appartient à un autre serveur et n'est pas supprimé
+func removeStaleUnixSocket(unixSocketPath string) error {
+	fileInformation, statError := os.Lstat(unixSocketPath)
+	if os.IsNotExist(statError) {
+		return nil
+	}
+	if statError != nil {
+		return fmt.Errorf("socket Unix %s: %v", unixSocketPath, statError)
+	}
+
+	if fileInformation.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s existe et n'est pas un socket Unix", unixSocketPath)
+	}
+
+	if probeConnection, dialError := net.DialTimeout("unix", unixSocketPath, unixSocketProbeTimeout); dialError == nil {
+		probeConnection.Close()
+		return fmt.Errorf("le socket Unix %s est déjà utilisé par un autre processus", unixSocketPath)
+	}
+
+	log.Printf("🧹 Suppression du socket Unix périmé %s", unixSocketPath)
+	return removeUnixSocketFile(unixSocketPath)
+}
+
+// removeUnixSocketFile supprime le fichier du socket (absent = pas d'erreur)
+func removeUnixSocketFile(unixSocketPath string) error {
+	if removeError := os.Remove(unixSocketPath); removeError != nil && !os.IsNotExist(removeError) {
+		return fmt.Errorf("suppression du socket Unix %s: %v", unixSocketPath, removeError)
+	}
+	return nil
+}
@@ -0,0 +1,380 @@
+package session
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClientReplyMode représente le mode de réponse choisi via CLIENT REPLY
+type ClientReplyMode int
+
+const (
+	ClientReplyOn ClientReplyMode = iota
+	ClientReplyOff
+	ClientReplySkip
+)
+
+// ClientType représente la catégorie d'un client (utilisée par CLIENT LIST TYPE)
+type ClientType int
+
+const (
+	NormalClientType ClientType = iota
+	ReplicaClientType
+	MasterClientType
+	PubSubClientType
+)
+
+// ClientSession représente l'état d'une connexion client
+type ClientSession struct {
+	ClientIdentifier int64
+	RemoteAddress    string
+	LocalAddress     string
+	CreationTime     time.Time
+	UnixSocket       bool
+
+	clientConnection     net.Conn
+	sessionMutex         sync.RWMutex
+	clientName           string
+	userName             string
+	clientType           ClientType
+	databaseIndex        int
+	lastCommandName      string
+	lastInteractionTime  time.Time
+	noEvictEnabled       bool
+	blocked              bool
+	replyMode            ClientReplyMode
+	skipNextReply        bool
+	suppressCurrentReply bool
+	closeAfterReply      bool
+	authenticated        bool
+	durableWrites        bool  // CLIENT DURABILITY SYNC : réponse aux écritures une fois sur disque
+	writeLogOffset       int64 // Offset du journal à atteindre pour que les écritures du client soient durables
+	askingEnabled        bool  // ASKING : la prochaine commande peut viser un slot en cours d'import
+
+	closedSignal chan struct{} // Fermé par Close : réveille les commandes bloquantes
+	closeOnce    sync.Once
+}
+
+// NewClientSession crée une nouvelle session pour une connexion acceptée
+func NewClientSession(clientIdentifier int64, clientConnection net.Conn) *ClientSession {
+	currentTime := time.Now()
+	remoteAddress := clientConnection.RemoteAddr().String()
+	localAddress := clientConnection.LocalAddr().String()
+
+	// Les clients Unix n'ont pas d'adresse : Redis affiche "chemin:0"
+	unixSocket := clientConnection.LocalAddr().Network() == "unix"
+	if unixSocket {
+		localAddress += ":0"
+		remoteAddress = localAddress
+	}
+
+	return &ClientSession{
+		ClientIdentifier:    clientIdentifier,
+		RemoteAddress:       remoteAddress,
+		LocalAddress:        localAddress,
+		CreationTime:        currentTime,
+		UnixSocket:          unixSocket,
+		clientConnection:    clientConnection,
+		userName:            "default",
+		clientType:          NormalClientType,
+		lastInteractionTime: currentTime,
+		closedSignal:        make(chan struct{}),
+	}
+}
+
+// Write implémente io.Writer : les réponses sont ignorées si CLIENT REPLY les a désactivées
+func (clientSession *ClientSession) Write(responseBytes []byte) (int, error) {
+	clientSession.sessionMutex.RLock()
+	replySuppressed := clientSession.suppressCurrentReply
+	clientSession.sessionMutex.RUnlock()
+
+	if replySuppressed {
+		return len(responseBytes), nil
+	}
+	return clientSession.clientConnection.Write(responseBytes)
+}
+
+// Connection retourne la connexion réseau sous-jacente
+func (clientSession *ClientSession) Connection() net.Conn {
+	return clientSession.clientConnection
+}
+
+// BeginCommand enregistre la commande en cours et prépare le filtrage des réponses
+func (clientSession *ClientSession) BeginCommand(commandName string, commandArguments []string) {
+	clientSession.sessionMutex.Lock()
+	defer clientSession.sessionMutex.Unlock()
+
+	clientSession.lastCommandName = formatCommandName(commandName, commandArguments)
+	clientSession.lastInteractionTime = time.Now()
+
+	// CLIENT REPLY OFF supprime toutes les réponses, SKIP seulement la suivante
+	clientSession.suppressCurrentReply = clientSession.replyMode == ClientReplyOff || clientSession.skipNextReply
+	clientSession.skipNextReply = false
+}
+
+// formatCommandName produit le nom affiché dans CLIENT LIST (ex: client|list)
+func formatCommandName(commandName string, commandArguments []string) string {
+	lowerCommandName := strings.ToLower(commandName)
+	switch lowerCommandName {
+	case "client", "config", "acl", "cluster":
+		if len(commandArguments) > 0 {
+			return lowerCommandName + "|" + strings.ToLower(commandArguments[0])
+		}
+	}
+	return lowerCommandName
+}
+
+// SetReplyMode applique CLIENT REPLY ON|OFF|SKIP
+// La réponse de la commande CLIENT REPLY elle-même n'est envoyée qu'en mode ON
+func (clientSession *ClientSession) SetReplyMode(replyMode ClientReplyMode) {
+	clientSession.sessionMutex.Lock()
+	defer clientSession.sessionMutex.Unlock()
+
+	switch replyMode {
+	case ClientReplySkip:
+		clientSession.replyMode = ClientReplyOn
+		clientSession.skipNextReply = true
+		clientSession.suppressCurrentReply = true
+	case ClientReplyOff:
+		clientSession.replyMode = ClientReplyOff
+		clientSession.suppressCurrentReply = true
+	default:
+		clientSession.replyMode = ClientReplyOn
+		clientSession.suppressCurrentReply = false
+	}
+}
+
+// GetClientName retourne le nom défini via CLIENT SETNAME
+func (clientSession *ClientSession) GetClientName() string {
+	clientSession.sessionMutex.RLock()
+	defer clientSession.sessionMutex.RUnlock()
+	return clientSession.clientName
+}
+
+// SetClientName définit le nom du client
+func (clientSession *ClientSession) SetClientName(clientName string) {
+	clientSession.sessionMutex.Lock()
+	defer clientSession.sessionMutex.Unlock()
+	clientSession.clientName = clientName
+}
+
+// GetUserName retourne l'utilisateur associé à la connexion
+func (clientSession *ClientSession) GetUserName() string {
+	clientSession.sessionMutex.RLock()
+	defer clientSession.sessionMutex.RUnlock()
+	return clientSession.userName
+}
+
+// SetUserName change l'utilisateur associé à la connexion
+func (clientSession *ClientSession) SetUserName(userName string) {
+	clientSession.sessionMutex.Lock()
+	defer clientSession.sessionMutex.Unlock()
+	clientSession.userName = userName
+}
+
+// IsAuthenticated indique si le client s'est authentifié (ou si aucune authentification n'était requise)
+func (clientSession *ClientSession) IsAuthenticated() bool {
+	clientSession.sessionMutex.RLock()
+	defer clientSession.sessionMutex.RUnlock()
+	return clientSession.authenticated
+}
+
+// SetAuthenticated change l'état d'authentification de la connexion
+func (clientSession *ClientSession) SetAuthenticated(authenticated bool) {
+	clientSession.sessionMutex.Lock()
+	defer clientSession.sessionMutex.Unlock()
+	clientSession.authenticated = authenticated
+}
+
+// GetClientType retourne la catégorie du client
+func (clientSession *ClientSession) GetClientType() ClientType {
+	clientSession.sessionMutex.RLock()
+	defer clientSession.sessionMutex.RUnlock()
+	return clientSession.clientType
+}
+
+// SetClientType change la catégorie du client (replica, pubsub...)
+func (clientSession *ClientSession) SetClientType(clientType ClientType) {
+	clientSession.sessionMutex.Lock()
+	defer clientSession.sessionMutex.Unlock()
+	clientSession.clientType = clientType
+}
+
+// SetDurableWrites applique CLIENT DURABILITY SYNC|ASYNC
+func (clientSession *ClientSession) SetDurableWrites(durableWrites bool) {
+	clientSession.sessionMutex.Lock()
+	defer clientSession.sessionMutex.Unlock()
+	clientSession.durableWrites = durableWrites
+}
+
+// DurableWritesEnabled indique si les écritures du client attendent le disque avant de répondre
+func (clientSession *ClientSession) DurableWritesEnabled() bool {
+	clientSession.sessionMutex.RLock()
+	defer clientSession.sessionMutex.RUnlock()
+	return clientSession.durableWrites
+}
+
+// RecordWriteLogOffset mémorise la position de la dernière écriture du client dans le journal
+func (clientSession *ClientSession) RecordWriteLogOffset(writeLogOffset int64) {
+	clientSession.sessionMutex.Lock()
+	defer clientSession.sessionMutex.Unlock()
+	clientSession.writeLogOffset = writeLogOffset
+}
+
+// GetWriteLogOffset retourne la position de la dernière écriture du client dans le journal (0 si aucune)
+func (clientSession *ClientSession) GetWriteLogOffset() int64 {
+	clientSession.sessionMutex.RLock()
+	defer clientSession.sessionMutex.RUnlock()
+	return clientSession.writeLogOffset
+}
+
+// SetAsking active ASKING pour la prochaine commande du client
+func (clientSession *ClientSession) SetAsking() {
+	clientSession.sessionMutex.Lock()
+	defer clientSession.sessionMutex.Unlock()
+	clientSession.askingEnabled = true
+}
+
+// ConsumeAsking indique si ASKING précédait la commande courante et le désactive : il ne vaut qu'une fois
+func (clientSession *ClientSession) ConsumeAsking() bool {
+	clientSession.sessionMutex.Lock()
+	defer clientSession.sessionMutex.Unlock()
+	askingEnabled := clientSession.askingEnabled
+	clientSession.askingEnabled = false
+	return askingEnabled
+}
+
+// SetNoEvict active ou désactive le flag CLIENT NO-EVICT
+func (clientSession *ClientSession) SetNoEvict(noEvictEnabled bool) {
+	clientSession.sessionMutex.Lock()
+	defer clientSession.sessionMutex.Unlock()
+	clientSession.noEvictEnabled = noEvictEnabled
+}
+
+// SetBlocked indique que le client attend dans une commande bloquante (exempté du timeout)
+func (clientSession *ClientSession) SetBlocked(blocked bool) {
+	clientSession.sessionMutex.Lock()
+	defer clientSession.sessionMutex.Unlock()
+	clientSession.blocked = blocked
+}
+
+// IsExemptFromIdleTimeout indique si le timeout d'inactivité ne s'applique pas à ce client
+// Comme dans Redis : clients bloqués, abonnés pub/sub, réplicas et maître
+func (clientSession *ClientSession) IsExemptFromIdleTimeout() bool {
+	clientSession.sessionMutex.RLock()
+	defer clientSession.sessionMutex.RUnlock()
+	return clientSession.blocked || clientSession.clientType != NormalClientType
+}
+
+// GetIdleDuration retourne le temps écoulé depuis la dernière commande
+func (clientSession *ClientSession) GetIdleDuration() time.Duration {
+	clientSession.sessionMutex.RLock()
+	defer clientSession.sessionMutex.RUnlock()
+	return time.Since(clientSession.lastInteractionTime)
+}
+
+// RequestCloseAfterReply demande la fermeture de la connexion une fois la réponse envoyée
+func (clientSession *ClientSession) RequestCloseAfterReply() {
+	clientSession.sessionMutex.Lock()
+	defer clientSession.sessionMutex.Unlock()
+	clientSession.closeAfterReply = true
+}
+
+// ShouldCloseAfterReply indique si la connexion doit être fermée après la réponse courante
+func (clientSession *ClientSession) ShouldCloseAfterReply() bool {
+	clientSession.sessionMutex.RLock()
+	defer clientSession.sessionMutex.RUnlock()
+	return clientSession.closeAfterReply
+}
+
+// Close ferme la connexion du client
+func (clientSession *ClientSession) Close() error {
+	clientSession.closeOnce.Do(func() { close(clientSession.closedSignal) })
+	return clientSession.clientConnection.Close()
+}
+
+// Closed retourne un canal fermé quand la session est fermée (arrêt du serveur, CLIENT KILL)
+func (clientSession *ClientSession) Closed() <-chan struct{} {
+	return clientSession.closedSignal
+}
+
+// GetFlags retourne les flags au format CLIENT LIST (N = normal, e = no-evict...)
+func (clientSession *ClientSession) GetFlags() string {
+	clientSession.sessionMutex.RLock()
+	defer clientSession.sessionMutex.RUnlock()
+
+	var sessionFlags strings.Builder
+	switch clientSession.clientType {
+	case ReplicaClientType:
+		sessionFlags.WriteByte('S')
+	case MasterClientType:
+		sessionFlags.WriteByte('M')
+	case PubSubClientType:
+		sessionFlags.WriteByte('P')
+	}
+	if clientSession.askingEnabled {
+		sessionFlags.WriteByte('A')
+	}
+	if clientSession.blocked {
+		sessionFlags.WriteByte('b')
+	}
+	if clientSession.noEvictEnabled {
+		sessionFlags.WriteByte('e')
+	}
+	if clientSession.UnixSocket {
+		sessionFlags.WriteByte('U')
+	}
+	if sessionFlags.Len() == 0 {
+		return "N"
+	}
+	return sessionFlags.String()
+}
+
+// ClientTypeName retourne le nom utilisé par CLIENT LIST TYPE
+func ClientTypeName(clientType ClientType) string {
+	switch clientType {
+	case ReplicaClientType:
+		return "replica"
+	case MasterClientType:
+		return "master"
+	case PubSubClientType:
+		return "pubsub"
+	default:
+		return "normal"
+	}
+}
+
+// FormatClientInfo produit la ligne décrivant le client (CLIENT LIST / CLIENT INFO)
+func (clientSession *ClientSession) FormatClientInfo() string {
+	sessionFlags := clientSession.GetFlags()
+
+	clientSession.sessionMutex.RLock()
+	defer clientSession.sessionMutex.RUnlock()
+
+	currentTime := time.Now()
+	lastCommandName := clientSession.lastCommandName
+	if lastCommandName == "" {
+		lastCommandName = "NULL"
+	}
+
+	replyModeName := "on"
+	if clientSession.replyMode == ClientReplyOff {
+		replyModeName = "off"
+	}
+
+	return fmt.Sprintf("id=%d addr=%s laddr=%s name=%s age=%d idle=%d flags=%s db=%d cmd=%s user=%s reply=%s",
+		clientSession.ClientIdentifier,
+		clientSession.RemoteAddress,
+		clientSession.LocalAddress,
+		clientSession.clientName,
+		int64(currentTime.Sub(clientSession.CreationTime).Seconds()),
+		int64(currentTime.Sub(clientSession.lastInteractionTime).Seconds()),
+		sessionFlags,
+		clientSession.databaseIndex,
+		lastCommandName,
+		clientSession.userName,
+		replyModeName)
+}
@@ -0,0 +1,77 @@
+package session
+
+import (
+	"net"
+	"sort"
+	"sync"
+)
+
+// ClientSessionManager référence toutes les sessions clients actives
+type ClientSessionManager struct {
+	registeredSessions   map[int64]*ClientSession
+	nextClientIdentifier int64
+	managerMutex         sync.RWMutex
+}
+
+// NewClientSessionManager crée un gestionnaire de sessions vide
+func NewClientSessionManager() *ClientSessionManager {
+	return &ClientSessionManager{
+		registeredSessions: make(map[int64]*ClientSession),
+	}
+}
+
+// RegisterSession crée et enregistre la session d'une nouvelle connexion
+func (sessionManager *ClientSessionManager) RegisterSession(clientConnection net.Conn) *ClientSession {
+	sessionManager.managerMutex.Lock()
+	defer sessionManager.managerMutex.Unlock()
+
+	sessionManager.nextClientIdentifier++
+	clientSession := NewClientSession(sessionManager.nextClientIdentifier, clientConnection)
+	sessionManager.registeredSessions[clientSession.ClientIdentifier] = clientSession
+	return clientSession
+}
+
+// UnregisterSession retire une session (connexion fermée)
+func (sessionManager *ClientSessionManager) UnregisterSession(clientSession *ClientSession) {
+	sessionManager.managerMutex.Lock()
+	defer sessionManager.managerMutex.Unlock()
+	delete(sessionManager.registeredSessions, clientSession.ClientIdentifier)
+}
+
+// GetSessionCount retourne le nombre de sessions actives
+func (sessionManager *ClientSessionManager) GetSessionCount() int {
+	sessionManager.managerMutex.RLock()
+	defer sessionManager.managerMutex.RUnlock()
+	return len(sessionManager.registeredSessions)
+}
+
+// FindSessionByIdentifier retourne la session correspondant à un ID, ou nil
+func (sessionManager *ClientSessionManager) FindSessionByIdentifier(clientIdentifier int64) *ClientSession {
+	sessionManager.managerMutex.RLock()
+	defer sessionManager.managerMutex.RUnlock()
+	return sessionManager.registeredSessions[clientIdentifier]
+}
+
+// ListSessions retourne toutes les sessions triées par ID
+func (sessionManager *ClientSessionManager) ListSessions() []*ClientSession {
+	sessionManager.managerMutex.RLock()
+	activeSessions := make([]*ClientSession, 0, len(sessionManager.registeredSessions))
+	for _, clientSession := range sessionManager.registeredSessions {
+		activeSessions = append(activeSessions, clientSession)
+	}
+	sessionManager.managerMutex.RUnlock()
+
+	sort.Slice(activeSessions, func(firstIndex, secondIndex int) bool {
+		return activeSessions[firstIndex].ClientIdentifier < activeSessions[secondIndex].ClientIdentifier
+	})
+	return activeSessions
+}
+
+// CloseAllSessions ferme toutes les connexions et retourne leur nombre
+func (sessionManager *ClientSessionManager) CloseAllSessions() int {
+	activeSessions := sessionManager.ListSessions()
+	for _, clientSession := range activeSessions {
+		clientSession.Close()
+	}
+	return len(activeSessions)
+}
@@ -0,0 +1,464 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Erreurs retournées par les opérations bitmap
+var (
+	ErrBitmapWrongType = errors.New("cette clé ne contient pas une chaîne de caractères")
+)
+
+// MaximumBitmapBits est le nombre maximal de bits d'une chaîne manipulée bit à bit (512 Mo, comme Redis)
+const MaximumBitmapBits = uint64(1) << 32
+
+// BitwiseOperation désigne l'opération de BITOP
+type BitwiseOperation int
+
+const (
+	BitwiseAnd BitwiseOperation = iota
+	BitwiseOr
+	BitwiseXor
+	BitwiseNot
+)
+
+// BitfieldOverflow est le comportement de BITFIELD SET/INCRBY en cas de dépassement
+type BitfieldOverflow int
+
+const (
+	BitfieldOverflowWrap BitfieldOverflow = iota // Modulo 2^largeur (défaut)
+	BitfieldOverflowSaturate
+	BitfieldOverflowFail // La valeur n'est pas modifiée et le résultat est nul
+)
+
+// BitfieldOperationKind désigne une sous-commande de BITFIELD
+type BitfieldOperationKind int
+
+const (
+	BitfieldGet BitfieldOperationKind = iota
+	BitfieldSet
+	BitfieldIncrementBy
+)
+
+// BitfieldOperation décrit une sous-commande de BITFIELD : un entier de Width bits (1-64 signé, 1-63 non signé)
+// lu ou écrit à partir du bit BitOffset ; Value est la valeur de SET ou l'incrément de INCRBY
+type BitfieldOperation struct {
+	OperationKind BitfieldOperationKind
+	Signed        bool
+	Width         uint
+	BitOffset     uint64
+	Value         int64
+	Overflow      BitfieldOverflow
+}
+
+// BitfieldResult est le résultat d'une sous-commande ; Failed indique un dépassement refusé (OVERFLOW FAIL)
+type BitfieldResult struct {
+	Value  int64
+	Failed bool
+}
+
+// SetBit écrit un bit (le bit 0 est le bit de poids fort du premier octet) et retourne sa valeur précédente
+// La chaîne est étendue avec des octets nuls si nécessaire ; le TTL est conservé
+func (redisStorage *RedisInMemoryStorage) SetBit(storageKey string, bitOffset uint64, bitValue bool) (int, error) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	stringValue, _, isString := redisStorage.lookupString(storageKey)
+	if !isString {
+		return 0, ErrBitmapWrongType
+	}
+
+	stringBytes := extendBitmapBytes(stringValue, bitOffset+1)
+	previousBit := readBitmapBit(stringBytes, bitOffset)
+	writeBitmapBit(stringBytes, bitOffset, bitValue)
+	redisStorage.storeStringBytes(storageKey, stringBytes)
+	return previousBit, nil
+}
+
+// GetBit lit un bit ; un bit au-delà de la fin de la chaîne (ou d'une clé absente) vaut 0
+func (redisStorage *RedisInMemoryStorage) GetBit(storageKey string, bitOffset uint64) (int, error) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	stringValue, _, isString := redisStorage.lookupString(storageKey)
+	if !isString {
+		return 0, ErrBitmapWrongType
+	}
+	if bitOffset >= uint64(len(stringValue))*8 {
+		return 0, nil
+	}
+	return readBitmapBit([]byte(stringValue[bitOffset/8:bitOffset/8+1]), bitOffset%8), nil
+}
+
+// CountBits compte les bits à 1 de la chaîne, éventuellement entre start et end inclus
+// Les bornes s'expriment en octets, ou en bits avec bitUnit, et acceptent les indices négatifs
+func (redisStorage *RedisInMemoryStorage) CountBits(storageKey string, hasRange bool, rangeStart int64, rangeEnd int64, bitUnit bool) (int64, error) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	stringValue, _, isString := redisStorage.lookupString(storageKey)
+	if !isString {
+		return 0, ErrBitmapWrongType
+	}
+
+	firstBit, lastBit := uint64(0), uint64(len(stringValue))*8
+	if hasRange {
+		var rangeEmpty bool
+		firstBit, lastBit, rangeEmpty = resolveBitmapRange(int64(len(stringValue)), rangeStart, rangeEnd, bitUnit)
+		if rangeEmpty {
+			return 0, nil
+		}
+	}
+
+	var bitCount int64
+	for bitOffset := firstBit; bitOffset < lastBit; {
+		if bitOffset%8 == 0 && bitOffset+8 <= lastBit {
+			bitCount += int64(bits.OnesCount8(stringValue[bitOffset/8]))
+			bitOffset += 8
+			continue
+		}
+		bitCount += int64((stringValue[bitOffset/8] >> (7 - bitOffset%8)) & 1)
+		bitOffset++
+	}
+	return bitCount, nil
+}
+
+// FindBitPosition retourne la position du premier bit valant bitValue, ou -1
+// Sans fin explicite, une recherche de 0 dans une chaîne remplie de 1 retourne le premier bit après la chaîne
+func (redisStorage *RedisInMemoryStorage) FindBitPosition(storageKey string, bitValue bool, hasStart bool, rangeStart int64, hasEnd bool, rangeEnd int64, bitUnit bool) (int64, error) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	stringValue, stringExists, isString := redisStorage.lookupString(storageKey)
+	if !isString {
+		return 0, ErrBitmapWrongType
+	}
+	if !stringExists {
+		if bitValue {
+			return -1, nil
+		}
+		return 0, nil
+	}
+
+	if !hasStart {
+		rangeStart = 0
+	}
+	if !hasEnd {
+		rangeEnd = -1
+	}
+	firstBit, lastBit, rangeEmpty := resolveBitmapRange(int64(len(stringValue)), rangeStart, rangeEnd, bitUnit)
+	if rangeEmpty {
+		return -1, nil
+	}
+
+	// Octets entièrement hors recherche : 0x00 quand on cherche un 1, 0xFF quand on cherche un 0
+	skippedByte := byte(0x00)
+	if !bitValue {
+		skippedByte = 0xFF
+	}
+	for bitOffset := firstBit; bitOffset < lastBit; {
+		if bitOffset%8 == 0 && bitOffset+8 <= lastBit && stringValue[bitOffset/8] == skippedByte {
+			bitOffset += 8
+			continue
+		}
+		if ((stringValue[bitOffset/8]>>(7-bitOffset%8))&1 == 1) == bitValue {
+			return int64(bitOffset), nil
+		}
+		bitOffset++
+	}
+
+	if !bitValue && !hasEnd {
+		return int64(lastBit), nil
+	}
+	return -1, nil
+}
+
+// ApplyBitwiseOperation calcule AND, OR, XOR ou NOT des clés sources et range le résultat dans destinationKey
+// Les sources absentes valent une chaîne de zéros ; un résultat vide supprime la destination
+// Retourne la longueur de la chaîne écrite
+func (redisStorage *RedisInMemoryStorage) ApplyBitwiseOperation(bitwiseOperation BitwiseOperation, destinationKey string, sourceKeys []string) (int64, error) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	sourceValues := make([]string, len(sourceKeys))
+	resultLength := 0
+	for sourceIndex, sourceKey := range sourceKeys {
+		sourceValue, _, isString := redisStorage.lookupString(sourceKey)
+		if !isString {
+			return 0, ErrBitmapWrongType
+		}
+		sourceValues[sourceIndex] = sourceValue
+		if len(sourceValue) > resultLength {
+			resultLength = len(sourceValue)
+		}
+	}
+
+	if resultLength == 0 {
+		if _, destinationExists := redisStorage.storageData[destinationKey]; destinationExists {
+			delete(redisStorage.storageData, destinationKey)
+			redisStorage.incrementChanges()
+		}
+		return 0, nil
+	}
+
+	resultBytes := make([]byte, resultLength)
+	for byteIndex := range resultBytes {
+		resultByte := sourceByteAt(sourceValues[0], byteIndex)
+		switch bitwiseOperation {
+		case BitwiseNot:
+			resultByte = ^resultByte
+		case BitwiseAnd:
+			for _, sourceValue := range sourceValues[1:] {
+				resultByte &= sourceByteAt(sourceValue, byteIndex)
+			}
+		case BitwiseOr:
+			for _, sourceValue := range sourceValues[1:] {
+				resultByte |= sourceByteAt(sourceValue, byteIndex)
+			}
+		case BitwiseXor:
+			for _, sourceValue := range sourceValues[1:] {
+				resultByte ^= sourceByteAt(sourceValue, byteIndex)
+			}
+		}
+		resultBytes[byteIndex] = resultByte
+	}
+
+	// Comme SET, la destination est remplacée et perd son TTL
+	redisStorage.storageData[destinationKey] = &RedisStorageValue{
+		StoredData: string(resultBytes),
+		DataType:   RedisStringType,
+	}
+	redisStorage.incrementChanges()
+	return int64(resultLength), nil
+}
+
+// ApplyBitfieldOperations exécute les sous-commandes de BITFIELD dans l'ordre, atomiquement
+// Dès qu'une écriture est demandée, la chaîne est créée ou étendue jusqu'au dernier bit écrit,
+// même si le dépassement est ensuite refusé (comme Redis)
+func (redisStorage *RedisInMemoryStorage) ApplyBitfieldOperations(storageKey string, bitfieldOperations []BitfieldOperation) ([]BitfieldResult, error) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	stringValue, _, isString := redisStorage.lookupString(storageKey)
+	if !isString {
+		return nil, ErrBitmapWrongType
+	}
+
+	var requiredBits uint64
+	hasWrites := false
+	for _, bitfieldOperation := range bitfieldOperations {
+		if bitfieldOperation.OperationKind == BitfieldGet {
+			continue
+		}
+		hasWrites = true
+		requiredBits = max(requiredBits, bitfieldOperation.BitOffset+uint64(bitfieldOperation.Width))
+	}
+
+	stringBytes := []byte(stringValue)
+	if hasWrites {
+		stringBytes = extendBitmapBytes(stringValue, requiredBits)
+	}
+
+	bitfieldResults := make([]BitfieldResult, len(bitfieldOperations))
+	for operationIndex, bitfieldOperation := range bitfieldOperations {
+		currentValue := readBitfieldValue(stringBytes, bitfieldOperation)
+		if bitfieldOperation.OperationKind == BitfieldGet {
+			bitfieldResults[operationIndex] = BitfieldResult{Value: currentValue}
+			continue
+		}
+
+		newValue, overflowed := bitfieldOperation.Value, false
+		if bitfieldOperation.OperationKind == BitfieldIncrementBy {
+			newValue, overflowed = applyBitfieldIncrement(currentValue, bitfieldOperation.Value, bitfieldOperation)
+		} else {
+			newValue, overflowed = applyBitfieldIncrement(bitfieldOperation.Value, 0, bitfieldOperation)
+		}
+		if overflowed && bitfieldOperation.Overflow == BitfieldOverflowFail {
+			bitfieldResults[operationIndex] = BitfieldResult{Failed: true}
+			continue
+		}
+
+		writeBitfieldValue(stringBytes, bitfieldOperation, newValue)
+		if bitfieldOperation.OperationKind == BitfieldSet {
+			bitfieldResults[operationIndex] = BitfieldResult{Value: currentValue}
+		} else {
+			bitfieldResults[operationIndex] = BitfieldResult{Value: newValue}
+		}
+	}
+
+	if hasWrites {
+		redisStorage.storeStringBytes(storageKey, stringBytes)
+	}
+	return bitfieldResults, nil
+}
+
+// lookupString retourne la chaîne d'une clé ; une clé absente ou expirée est une chaîne vide
+// isString est false si la clé contient un autre type
+func (redisStorage *RedisInMemoryStorage) lookupString(storageKey string) (stringValue string, stringExists bool, isString bool) {
+	storageValue, keyExists := redisStorage.storageData[storageKey]
+	if !keyExists || (storageValue.ExpirationTime != nil && time.Now().After(*storageValue.ExpirationTime)) {
+		return "", false, true
+	}
+	if storageValue.DataType != RedisStringType {
+		return "", false, false
+	}
+	return storageValue.StoredData.(string), true, true
+}
+
+// storeStringBytes remplace le contenu d'une chaîne en conservant son TTL, ou crée la clé
+func (redisStorage *RedisInMemoryStorage) storeStringBytes(storageKey string, stringBytes []byte) {
+	storageValue, keyExists := redisStorage.storageData[storageKey]
+	if keyExists && (storageValue.ExpirationTime == nil || time.Now().Before(*storageValue.ExpirationTime)) {
+		redisStorage.preserveValueForSnapshots(storageKey, storageValue)
+		storageValue.StoredData = string(stringBytes)
+	} else {
+		redisStorage.storageData[storageKey] = &RedisStorageValue{
+			StoredData: string(stringBytes),
+			DataType:   RedisStringType,
+		}
+	}
+	redisStorage.incrementChanges()
+}
+
+// extendBitmapBytes copie la chaîne en l'étendant avec des octets nuls pour contenir requiredBits bits
+func extendBitmapBytes(stringValue string, requiredBits uint64) []byte {
+	requiredLength := int((requiredBits + 7) / 8)
+	if requiredLength < len(stringValue) {
+		requiredLength = len(stringValue)
+	}
+	stringBytes := make([]byte, requiredLength)
+	copy(stringBytes, stringValue)
+	return stringBytes
+}
+
+// resolveBitmapRange convertit des bornes inclusives (octets ou bits, négatives depuis la fin)
+// en intervalle de bits [firstBit, lastBit) ; rangeEmpty si l'intervalle ne contient aucun bit
+func resolveBitmapRange(stringLength int64, rangeStart int64, rangeEnd int64, bitUnit bool) (firstBit uint64, lastBit uint64, rangeEmpty bool) {
+	totalLength := stringLength
+	if bitUnit {
+		totalLength = stringLength * 8
+	}
+	if rangeStart < 0 {
+		rangeStart += totalLength
+	}
+	if rangeEnd < 0 {
+		rangeEnd += totalLength
+	}
+	rangeStart = max(rangeStart, 0)
+	rangeEnd = max(rangeEnd, 0)
+	rangeEnd = min(rangeEnd, totalLength-1)
+	if totalLength == 0 || rangeStart > rangeEnd {
+		return 0, 0, true
+	}
+	if bitUnit {
+		return uint64(rangeStart), uint64(rangeEnd) + 1, false
+	}
+	return uint64(rangeStart) * 8, (uint64(rangeEnd) + 1) * 8, false
+}
+
+// sourceByteAt retourne l'octet d'une source de BITOP, ou 0 au-delà de sa fin
+func sourceByteAt(sourceValue string, byteIndex int) byte {
+	if byteIndex < len(sourceValue) {
+		return sourceValue[byteIndex]
+	}
+	return 0
+}
+
+// readBitmapBit lit un bit d'un tableau d'octets suffisamment long
+func readBitmapBit(stringBytes []byte, bitOffset uint64) int {
+	return int((stringBytes[bitOffset/8] >> (7 - bitOffset%8)) & 1)
+}
+
+// writeBitmapBit écrit un bit d'un tableau d'octets suffisamment long
+func writeBitmapBit(stringBytes []byte, bitOffset uint64, bitValue bool) {
+	bitMask := byte(1) << (7 - bitOffset%8)
+	if bitValue {
+		stringBytes[bitOffset/8] |= bitMask
+	} else {
+		stringBytes[bitOffset/8] &^= bitMask
+	}
+}
+
+// readBitfieldValue lit l'entier d'une sous-commande BITFIELD ; les bits au-delà de la chaîne valent 0
+func readBitfieldValue(stringBytes []byte, bitfieldOperation BitfieldOperation) int64 {
+	var rawValue uint64
+	for bitIndex := uint64(0); bitIndex < uint64(bitfieldOperation.Width); bitIndex++ {
+		bitOffset := bitfieldOperation.BitOffset + bitIndex
+		rawValue <<= 1
+		if bitOffset/8 < uint64(len(stringBytes)) {
+			rawValue |= uint64(readBitmapBit(stringBytes, bitOffset))
+		}
+	}
+	if bitfieldOperation.Signed {
+		return signExtendBitfield(rawValue, bitfieldOperation.Width)
+	}
+	return int64(rawValue)
+}
+
+// writeBitfieldValue écrit les Width bits de poids faible de fieldValue
+func writeBitfieldValue(stringBytes []byte, bitfieldOperation BitfieldOperation, fieldValue int64) {
+	for bitIndex := uint64(0); bitIndex < uint64(bitfieldOperation.Width); bitIndex++ {
+		shift := uint64(bitfieldOperation.Width) - 1 - bitIndex
+		writeBitmapBit(stringBytes, bitfieldOperation.BitOffset+bitIndex, (uint64(fieldValue)>>shift)&1 == 1)
+	}
+}
+
+// signExtendBitfield interprète les width bits de poids faible comme un entier signé
+func signExtendBitfield(rawValue uint64, width uint) int64 {
+	if width == 64 {
+		return int64(rawValue)
+	}
+	shift := 64 - width
+	return int64(rawValue<<shift) >> shift
+}
+
+// applyBitfieldIncrement calcule fieldValue + increment dans un entier de Width bits selon le mode de dépassement
+// SET passe par ici avec un incrément nul pour ramener la valeur dans l'intervalle ; overflowed signale un dépassement
+func applyBitfieldIncrement(fieldValue int64, increment int64, bitfieldOperation BitfieldOperation) (int64, bool) {
+	width := bitfieldOperation.Width
+	wrappedValue := uint64(fieldValue) + uint64(increment) // Exact modulo 2^64, donc modulo 2^largeur
+
+	if bitfieldOperation.Signed {
+		maximumValue := int64(math.MaxInt64)
+		if width < 64 {
+			maximumValue = int64(1)<<(width-1) - 1
+		}
+		minimumValue := -maximumValue - 1
+
+		sumValue := fieldValue + increment
+		sumOverflowed := (increment > 0 && sumValue < fieldValue) || (increment < 0 && sumValue > fieldValue)
+		switch {
+		case (sumOverflowed && increment > 0) || (!sumOverflowed && sumValue > maximumValue):
+			if bitfieldOperation.Overflow == BitfieldOverflowSaturate {
+				return maximumValue, true
+			}
+			return signExtendBitfield(wrappedValue, width), true
+		case (sumOverflowed && increment < 0) || (!sumOverflowed && sumValue < minimumValue):
+			if bitfieldOperation.Overflow == BitfieldOverflowSaturate {
+				return minimumValue, true
+			}
+			return signExtendBitfield(wrappedValue, width), true
+		}
+		return sumValue, false
+	}
+
+	// Non signé : une valeur négative de SET est vue comme un très grand entier, comme dans Redis
+	maximumValue := uint64(1)<<width - 1
+	unsignedValue := uint64(fieldValue)
+	switch {
+	case unsignedValue > maximumValue || (increment > 0 && uint64(increment) > maximumValue-unsignedValue):
+		if bitfieldOperation.Overflow == BitfieldOverflowSaturate {
+			return int64(maximumValue), true
+		}
+		return int64(wrappedValue & maximumValue), true
+	case increment < 0 && uint64(-(increment+1))+1 > unsignedValue:
+		if bitfieldOperation.Overflow == BitfieldOverflowSaturate {
+			return 0, true
+		}
+		return int64(wrappedValue & maximumValue), true
+	}
+	return int64(unsignedValue + uint64(increment)), false
+}
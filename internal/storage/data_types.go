@@ -11,8 +11,32 @@ const (
 	RedisSetType
 	RedisHashType
 	RedisZSetType
+	RedisStreamType
+	RedisJSONType
 )
 
+// TypeName retourne le nom du type tel qu'affiché par la commande TYPE
+func (dataType RedisDataType) TypeName() string {
+	switch dataType {
+	case RedisStringType:
+		return "string"
+	case RedisListType:
+		return "list"
+	case RedisSetType:
+		return "set"
+	case RedisHashType:
+		return "hash"
+	case RedisZSetType:
+		return "zset"
+	case RedisStreamType:
+		return "stream"
+	case RedisJSONType:
+		return "ReJSON-RL" // Nom du type de RedisJSON, reconnu par les clients
+	default:
+		return "none"
+	}
+}
+
 // RedisStorageValue représente une valeur stockée avec son type et TTL
 type RedisStorageValue struct {
 	StoredData     interface{}
@@ -34,3 +58,39 @@ type RedisSetStructure struct {
 type RedisHashStructure struct {
 	HashFields map[string]string
 }
+
+// RedisJSONDocument représente un document JSON ; Root est un *JSONObject, un *JSONArray ou un scalaire
+type RedisJSONDocument struct {
+	Root interface{}
+}
+
+// RedisSortedSetStructure représente un sorted set Redis : membres triés par score puis par nom
+// Les index géographiques en sont un cas particulier (score = geohash de 52 bits)
+type RedisSortedSetStructure struct {
+	MemberScores  map[string]float64
+	SortedMembers []SortedSetMember // Toujours triés : recherche par intervalle de scores en O(log n)
+}
+
+// SortedSetMember est un membre de sorted set et son score
+type SortedSetMember struct {
+	Member string
+	Score  float64
+}
+
+// RedisStreamStructure représente un stream Redis : entrées triées par identifiant croissant
+type RedisStreamStructure struct {
+	StreamEntries   []StreamEntry
+	LastGeneratedID StreamEntryID // Ne recule jamais, même si les dernières entrées sont supprimées
+}
+
+// StreamEntryID est l'identifiant ms-seq d'une entrée de stream
+type StreamEntryID struct {
+	Milliseconds uint64
+	Sequence     uint64
+}
+
+// StreamEntry est une entrée de stream : son identifiant et ses paires champ/valeur
+type StreamEntry struct {
+	EntryID     StreamEntryID
+	FieldValues []string
+}
@@ -0,0 +1,376 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Erreurs retournées par les recherches géographiques
+var (
+	ErrGeoMemberNotFound = errors.New("membre introuvable dans l'index géographique")
+)
+
+// Constantes des geohash, identiques à celles de Redis : les scores sont échangeables avec un vrai Redis
+const (
+	GeoLongitudeMinimum = -180.0
+	GeoLongitudeMaximum = 180.0
+	GeoLatitudeMinimum  = -85.05112878 // Limites de la projection Web Mercator
+	GeoLatitudeMaximum  = 85.05112878
+
+	geohashStepCount          = 26 // 26 bits par coordonnée, soit un geohash de 52 bits
+	geoEarthRadiusMeters      = 6372797.560856
+	geoMaximumSearchCells     = 16 // Cellules de geohash examinées au plus par recherche
+	geohashStandardAlphabet   = "0123456789bcdefghjkmnpqrstuvwxyz"
+	geohashStandardCharacters = 11
+)
+
+// GeoCoordinates est une position en degrés
+type GeoCoordinates struct {
+	Longitude float64
+	Latitude  float64
+}
+
+// GeoSortOrder est l'ordre des résultats d'une recherche
+type GeoSortOrder int
+
+const (
+	GeoSortNone GeoSortOrder = iota
+	GeoSortAscending
+	GeoSortDescending
+)
+
+// GeoSearchQuery décrit une recherche GEOSEARCH : centre (coordonnées ou membre) et forme (cercle ou rectangle)
+type GeoSearchQuery struct {
+	CenterMember      string // Utilisé si FromMember
+	FromMember        bool
+	CenterCoordinates GeoCoordinates
+	ByBox             bool
+	RadiusMeters      float64
+	WidthMeters       float64
+	HeightMeters      float64
+	SortOrder         GeoSortOrder
+	Count             int  // 0 = pas de limite
+	AnyMatches        bool // COUNT n ANY : s'arrêter dès n résultats trouvés
+}
+
+// GeoSearchResult est un membre trouvé par une recherche
+type GeoSearchResult struct {
+	Member         string
+	DistanceMeters float64
+	Geohash        uint64
+	Coordinates    GeoCoordinates
+}
+
+// ValidGeoCoordinates indique si la position est indexable
+func ValidGeoCoordinates(geoCoordinates GeoCoordinates) bool {
+	return geoCoordinates.Longitude >= GeoLongitudeMinimum && geoCoordinates.Longitude <= GeoLongitudeMaximum &&
+		geoCoordinates.Latitude >= GeoLatitudeMinimum && geoCoordinates.Latitude <= GeoLatitudeMaximum
+}
+
+// EncodeGeohash calcule le geohash de 52 bits d'une position : bits de longitude et de latitude entrelacés,
+// longitude en tête ; il sert de score dans le sorted set
+func EncodeGeohash(geoCoordinates GeoCoordinates) uint64 {
+	latitudeIndex := geohashCellIndex(geoCoordinates.Latitude, GeoLatitudeMinimum, GeoLatitudeMaximum, geohashStepCount)
+	longitudeIndex := geohashCellIndex(geoCoordinates.Longitude, GeoLongitudeMinimum, GeoLongitudeMaximum, geohashStepCount)
+	return interleaveGeohashBits(uint64(latitudeIndex), uint64(longitudeIndex))
+}
+
+// DecodeGeohash retourne le centre de la cellule désignée par un geohash de 52 bits
+func DecodeGeohash(geohash uint64) GeoCoordinates {
+	return decodeGeohashWithRanges(geohash, GeoLatitudeMinimum, GeoLatitudeMaximum)
+}
+
+// GeohashFromScore convertit un score de sorted set en geohash (les scores hors bornes sont ramenés dans l'intervalle)
+func GeohashFromScore(memberScore float64) uint64 {
+	if memberScore <= 0 || math.IsNaN(memberScore) {
+		return 0
+	}
+	return min(uint64(memberScore), uint64(1)<<(2*geohashStepCount)-1)
+}
+
+// StandardGeohashString retourne le geohash texte de 11 caractères (latitudes de -90 à 90, comme geohash.org)
+func StandardGeohashString(geohash uint64) string {
+	cellCenter := DecodeGeohash(geohash)
+	latitudeIndex := geohashCellIndex(cellCenter.Latitude, -90, 90, geohashStepCount)
+	longitudeIndex := geohashCellIndex(cellCenter.Longitude, GeoLongitudeMinimum, GeoLongitudeMaximum, geohashStepCount)
+	standardHash := interleaveGeohashBits(uint64(latitudeIndex), uint64(longitudeIndex))
+
+	hashCharacters := make([]byte, geohashStandardCharacters)
+	for characterIndex := range hashCharacters {
+		alphabetIndex := 0 // Le 11e caractère demanderait 55 bits : il vaut toujours '0', comme dans Redis
+		if characterIndex < geohashStandardCharacters-1 {
+			alphabetIndex = int(standardHash>>(2*geohashStepCount-(characterIndex+1)*5)) & 0x1f
+		}
+		hashCharacters[characterIndex] = geohashStandardAlphabet[alphabetIndex]
+	}
+	return string(hashCharacters)
+}
+
+// GeoDistanceMeters calcule la distance entre deux positions (formule de haversine, rayon terrestre de Redis)
+func GeoDistanceMeters(firstPosition, secondPosition GeoCoordinates) float64 {
+	firstLatitude := degreesToRadians(firstPosition.Latitude)
+	secondLatitude := degreesToRadians(secondPosition.Latitude)
+	latitudeSine := math.Sin((secondLatitude - firstLatitude) / 2)
+	longitudeSine := math.Sin(degreesToRadians(secondPosition.Longitude-firstPosition.Longitude) / 2)
+	return 2 * geoEarthRadiusMeters * math.Asin(math.Sqrt(latitudeSine*latitudeSine+math.Cos(firstLatitude)*math.Cos(secondLatitude)*longitudeSine*longitudeSine))
+}
+
+// SearchGeoMembers exécute une recherche GEOSEARCH sur un index géographique
+func (redisStorage *RedisInMemoryStorage) SearchGeoMembers(storageKey string, searchQuery GeoSearchQuery) ([]GeoSearchResult, error) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	return redisStorage.searchGeoMembers(storageKey, searchQuery)
+}
+
+// SearchAndStoreGeoMembers exécute une recherche et range les membres trouvés dans destinationKey (GEOSEARCHSTORE)
+// Les scores sont les geohash, ou les distances exprimées dans l'unité donnée avec storeDistances
+// Sans résultat, la destination est supprimée ; retourne le nombre de membres rangés
+func (redisStorage *RedisInMemoryStorage) SearchAndStoreGeoMembers(destinationKey string, sourceKey string, searchQuery GeoSearchQuery, storeDistances bool, metersPerUnit float64) (int, error) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	searchResults, searchError := redisStorage.searchGeoMembers(sourceKey, searchQuery)
+	if searchError != nil {
+		return 0, searchError
+	}
+
+	if len(searchResults) == 0 {
+		if _, destinationExists := redisStorage.storageData[destinationKey]; destinationExists {
+			delete(redisStorage.storageData, destinationKey)
+			redisStorage.incrementChanges()
+		}
+		return 0, nil
+	}
+
+	storedMembers := make([]SortedSetMember, len(searchResults))
+	for resultIndex, searchResult := range searchResults {
+		storedMembers[resultIndex] = SortedSetMember{Member: searchResult.Member, Score: float64(searchResult.Geohash)}
+		if storeDistances {
+			storedMembers[resultIndex].Score = searchResult.DistanceMeters / metersPerUnit
+		}
+	}
+	// Comme pour SET, la destination est remplacée et perd son TTL
+	redisStorage.storageData[destinationKey] = &RedisStorageValue{
+		StoredData: NewSortedSetStructure(storedMembers),
+		DataType:   RedisZSetType,
+	}
+	redisStorage.incrementChanges()
+	return len(storedMembers), nil
+}
+
+// searchGeoMembers parcourt les cellules de geohash qui recouvrent la zone, chacune étant un intervalle de scores
+// contigu du sorted set, puis filtre exactement chaque candidat ; le verrou doit être tenu par l'appelant
+func (redisStorage *RedisInMemoryStorage) searchGeoMembers(storageKey string, searchQuery GeoSearchQuery) ([]GeoSearchResult, error) {
+	sortedSetStructure, sortedSetExists, isSortedSet := redisStorage.lookupSortedSet(storageKey)
+	if !isSortedSet {
+		return nil, ErrSortedSetWrongType
+	}
+
+	searchCenter := searchQuery.CenterCoordinates
+	if searchQuery.FromMember {
+		memberScore, memberExists := 0.0, false
+		if sortedSetExists {
+			memberScore, memberExists = sortedSetStructure.MemberScores[searchQuery.CenterMember]
+		}
+		if !memberExists {
+			return nil, ErrGeoMemberNotFound
+		}
+		searchCenter = DecodeGeohash(GeohashFromScore(memberScore))
+	}
+	if !sortedSetExists {
+		return nil, nil
+	}
+
+	var searchResults []GeoSearchResult
+	for _, scoreRange := range geoSearchScoreRanges(searchCenter, searchQuery) {
+		for memberIndex := sortedSetStructure.searchScore(scoreRange[0]); memberIndex < len(sortedSetStructure.SortedMembers); memberIndex++ {
+			sortedSetMember := sortedSetStructure.SortedMembers[memberIndex]
+			if sortedSetMember.Score >= scoreRange[1] {
+				break
+			}
+			memberGeohash := GeohashFromScore(sortedSetMember.Score)
+			memberPosition := DecodeGeohash(memberGeohash)
+			distanceMeters, insideArea := geoSearchDistance(searchCenter, memberPosition, searchQuery)
+			if !insideArea {
+				continue
+			}
+			searchResults = append(searchResults, GeoSearchResult{
+				Member:         sortedSetMember.Member,
+				DistanceMeters: distanceMeters,
+				Geohash:        memberGeohash,
+				Coordinates:    memberPosition,
+			})
+			if searchQuery.AnyMatches && searchQuery.Count > 0 && len(searchResults) == searchQuery.Count {
+				break
+			}
+		}
+		if searchQuery.AnyMatches && searchQuery.Count > 0 && len(searchResults) == searchQuery.Count {
+			break
+		}
+	}
+
+	// Comme Redis, COUNT sans ANY trie par distance croissante pour garder les plus proches
+	sortOrder := searchQuery.SortOrder
+	if sortOrder == GeoSortNone && searchQuery.Count > 0 && !searchQuery.AnyMatches {
+		sortOrder = GeoSortAscending
+	}
+	switch sortOrder {
+	case GeoSortAscending:
+		sort.SliceStable(searchResults, func(firstIndex, secondIndex int) bool {
+			return searchResults[firstIndex].DistanceMeters < searchResults[secondIndex].DistanceMeters
+		})
+	case GeoSortDescending:
+		sort.SliceStable(searchResults, func(firstIndex, secondIndex int) bool {
+			return searchResults[firstIndex].DistanceMeters > searchResults[secondIndex].DistanceMeters
+		})
+	}
+	if searchQuery.Count > 0 && len(searchResults) > searchQuery.Count {
+		searchResults = searchResults[:searchQuery.Count]
+	}
+	return searchResults, nil
+}
+
+// geoSearchDistance retourne la distance au centre et indique si la position est dans la zone recherchée
+// Pour un rectangle, la largeur se mesure le long du parallèle de la position, comme dans Redis
+func geoSearchDistance(searchCenter GeoCoordinates, memberPosition GeoCoordinates, searchQuery GeoSearchQuery) (float64, bool) {
+	if searchQuery.ByBox {
+		latitudeDistance := geoEarthRadiusMeters * math.Abs(degreesToRadians(memberPosition.Latitude-searchCenter.Latitude))
+		if latitudeDistance > searchQuery.HeightMeters/2 {
+			return 0, false
+		}
+		longitudeDistance := GeoDistanceMeters(GeoCoordinates{Longitude: searchCenter.Longitude, Latitude: memberPosition.Latitude}, memberPosition)
+		if longitudeDistance > searchQuery.WidthMeters/2 {
+			return 0, false
+		}
+		return GeoDistanceMeters(searchCenter, memberPosition), true
+	}
+	distanceMeters := GeoDistanceMeters(searchCenter, memberPosition)
+	return distanceMeters, distanceMeters <= searchQuery.RadiusMeters
+}
+
+// geoSearchScoreRanges retourne les intervalles de scores [début, fin) des cellules qui recouvrent la zone
+// La résolution est la plus fine pour laquelle au plus geoMaximumSearchCells cellules suffisent
+func geoSearchScoreRanges(searchCenter GeoCoordinates, searchQuery GeoSearchQuery) [][2]float64 {
+	latitudeReach, longitudeReach := searchQuery.RadiusMeters, searchQuery.RadiusMeters
+	if searchQuery.ByBox {
+		latitudeReach, longitudeReach = searchQuery.HeightMeters/2, searchQuery.WidthMeters/2
+	}
+
+	// Écart maximal de latitude, puis de longitude au parallèle le plus proche du pôle dans la bande
+	latitudeDelta := radiansToDegrees(latitudeReach / geoEarthRadiusMeters)
+	lowestLatitude := max(searchCenter.Latitude-latitudeDelta, GeoLatitudeMinimum)
+	highestLatitude := min(searchCenter.Latitude+latitudeDelta, GeoLatitudeMaximum)
+	polewardLatitude := min(math.Max(math.Abs(searchCenter.Latitude-latitudeDelta), math.Abs(searchCenter.Latitude+latitudeDelta)), 90)
+
+	fullLongitude := true
+	longitudeDelta := 0.0
+	if halfAngle := longitudeReach / (2 * geoEarthRadiusMeters); halfAngle < math.Pi/2 {
+		if sineRatio := math.Sin(halfAngle) / math.Cos(degreesToRadians(polewardLatitude)); sineRatio < 1 {
+			longitudeDelta = radiansToDegrees(2 * math.Asin(sineRatio))
+			fullLongitude = false
+		}
+	}
+
+	for searchStep := geohashStepCount; ; searchStep-- {
+		cellsPerAxis := int64(1) << searchStep
+		lowestLatitudeIndex := geohashCellIndex(lowestLatitude, GeoLatitudeMinimum, GeoLatitudeMaximum, searchStep)
+		highestLatitudeIndex := geohashCellIndex(highestLatitude, GeoLatitudeMinimum, GeoLatitudeMaximum, searchStep)
+
+		firstLongitudeIndex, longitudeCellCount := int64(0), cellsPerAxis
+		if !fullLongitude {
+			cellWidth := (GeoLongitudeMaximum - GeoLongitudeMinimum) / float64(cellsPerAxis)
+			firstLongitudeIndex = int64(math.Floor((searchCenter.Longitude - longitudeDelta - GeoLongitudeMinimum) / cellWidth))
+			lastLongitudeIndex := int64(math.Floor((searchCenter.Longitude + longitudeDelta - GeoLongitudeMinimum) / cellWidth))
+			longitudeCellCount = min(lastLongitudeIndex-firstLongitudeIndex+1, cellsPerAxis)
+		}
+
+		latitudeCellCount := highestLatitudeIndex - lowestLatitudeIndex + 1
+		if searchStep > 1 && latitudeCellCount*longitudeCellCount > geoMaximumSearchCells {
+			continue
+		}
+
+		// Une cellule de niveau searchStep couvre les geohash de 52 bits qui partagent ses 2 × searchStep premiers bits
+		rangeShift := uint(2 * (geohashStepCount - searchStep))
+		scoreRanges := make([][2]float64, 0, latitudeCellCount*longitudeCellCount)
+		for latitudeIndex := lowestLatitudeIndex; latitudeIndex <= highestLatitudeIndex; latitudeIndex++ {
+			for longitudeOffset := int64(0); longitudeOffset < longitudeCellCount; longitudeOffset++ {
+				longitudeIndex := ((firstLongitudeIndex+longitudeOffset)%cellsPerAxis + cellsPerAxis) % cellsPerAxis
+				cellPrefix := interleaveGeohashBits(uint64(latitudeIndex), uint64(longitudeIndex))
+				scoreRanges = append(scoreRanges, [2]float64{
+					float64(cellPrefix << rangeShift),
+					float64((cellPrefix + 1) << rangeShift),
+				})
+			}
+		}
+		return scoreRanges
+	}
+}
+
+// geohashCellIndex retourne l'indice de la cellule contenant value parmi 2^step cellules de [minimum, maximum]
+func geohashCellIndex(value float64, minimum float64, maximum float64, step int) int64 {
+	cellCount := int64(1) << step
+	cellIndex := int64((value - minimum) / (maximum - minimum) * float64(cellCount))
+	return min(max(cellIndex, 0), cellCount-1)
+}
+
+// decodeGeohashWithRanges retourne le centre de la cellule, ramené dans les bornes
+func decodeGeohashWithRanges(geohash uint64, latitudeMinimum float64, latitudeMaximum float64) GeoCoordinates {
+	latitudeIndex, longitudeIndex := deinterleaveGeohashBits(geohash)
+	cellCount := float64(uint64(1) << geohashStepCount)
+	latitudeScale := latitudeMaximum - latitudeMinimum
+	longitudeScale := GeoLongitudeMaximum - GeoLongitudeMinimum
+
+	cellLatitudeMinimum := latitudeMinimum + float64(latitudeIndex)/cellCount*latitudeScale
+	cellLatitudeMaximum := latitudeMinimum + float64(latitudeIndex+1)/cellCount*latitudeScale
+	cellLongitudeMinimum := GeoLongitudeMinimum + float64(longitudeIndex)/cellCount*longitudeScale
+	cellLongitudeMaximum := GeoLongitudeMinimum + float64(longitudeIndex+1)/cellCount*longitudeScale
+
+	return GeoCoordinates{
+		Longitude: min(max((cellLongitudeMinimum+cellLongitudeMaximum)/2, GeoLongitudeMinimum), GeoLongitudeMaximum),
+		Latitude:  min(max((cellLatitudeMinimum+cellLatitudeMaximum)/2, latitudeMinimum), latitudeMaximum),
+	}
+}
+
+// interleaveGeohashBits place les bits de latitude aux positions paires et ceux de longitude aux positions impaires
+func interleaveGeohashBits(latitudeIndex uint64, longitudeIndex uint64) uint64 {
+	return spreadGeohashBits(latitudeIndex) | spreadGeohashBits(longitudeIndex)<<1
+}
+
+// deinterleaveGeohashBits sépare les indices de latitude (bits pairs) et de longitude (bits impairs)
+func deinterleaveGeohashBits(geohash uint64) (latitudeIndex uint64, longitudeIndex uint64) {
+	return compactGeohashBits(geohash), compactGeohashBits(geohash >> 1)
+}
+
+// spreadGeohashBits intercale un bit nul après chacun des 32 bits de poids faible
+func spreadGeohashBits(value uint64) uint64 {
+	value &= 0xFFFFFFFF
+	value = (value | value<<16) & 0x0000FFFF0000FFFF
+	value = (value | value<<8) & 0x00FF00FF00FF00FF
+	value = (value | value<<4) & 0x0F0F0F0F0F0F0F0F
+	value = (value | value<<2) & 0x3333333333333333
+	value = (value | value<<1) & 0x5555555555555555
+	return value
+}
+
+// compactGeohashBits est l'inverse de spreadGeohashBits
+func compactGeohashBits(value uint64) uint64 {
+	value &= 0x5555555555555555
+	value = (value | value>>1) & 0x3333333333333333
+	value = (value | value>>2) & 0x0F0F0F0F0F0F0F0F
+	value = (value | value>>4) & 0x00FF00FF00FF00FF
+	value = (value | value>>8) & 0x0000FFFF0000FFFF
+	value = (value | value>>16) & 0x00000000FFFFFFFF
+	return value
+}
+
+// degreesToRadians convertit des degrés en radians
+func degreesToRadians(angleDegrees float64) float64 {
+	return angleDegrees * math.Pi / 180
+}
+
+// radiansToDegrees convertit des radians en degrés
+func radiansToDegrees(angleRadians float64) float64 {
+	return angleRadians * 180 / math.Pi
+}
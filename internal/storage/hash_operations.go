@@ -21,11 +21,13 @@ func (redisStorage *RedisInMemoryStorage) SetHashField(hashKey string, fieldName
 		if storageValue.DataType != RedisHashType {
 			return false
 		}
+		redisStorage.preserveValueForSnapshots(hashKey, storageValue)
 		redisHashStructure = storageValue.StoredData.(*RedisHashStructure)
 	}
 
 	_, fieldAlreadyExists := redisHashStructure.HashFields[fieldName]
 	redisHashStructure.HashFields[fieldName] = fieldValue
+	redisStorage.reindexHashKey(hashKey)
 	if !fieldAlreadyExists {
 		redisStorage.incrementChanges()
 	}
@@ -87,6 +89,7 @@ func (redisStorage *RedisInMemoryStorage) DeleteHashFields(hashKey string, field
 		return -1 // Erreur de type
 	}
 
+	redisStorage.preserveValueForSnapshots(hashKey, storageValue)
 	redisHashStructure := storageValue.StoredData.(*RedisHashStructure)
 	deletedCount := 0
 
@@ -103,6 +106,7 @@ func (redisStorage *RedisInMemoryStorage) DeleteHashFields(hashKey string, field
 	}
 
 	if deletedCount > 0 {
+		redisStorage.reindexHashKey(hashKey)
 		redisStorage.incrementChanges()
 	}
 
@@ -192,6 +196,7 @@ func (redisStorage *RedisInMemoryStorage) IncrementHashField(hashKey string, fie
 		if storageValue.DataType != RedisHashType {
 			return nil // Erreur de type
 		}
+		redisStorage.preserveValueForSnapshots(hashKey, storageValue)
 		redisHashStructure = storageValue.StoredData.(*RedisHashStructure)
 	}
 
@@ -208,6 +213,7 @@ func (redisStorage *RedisInMemoryStorage) IncrementHashField(hashKey string, fie
 	// Incrémenter et stocker
 	newValue := currentValue + increment
 	redisHashStructure.HashFields[fieldName] = strconv.FormatInt(newValue, 10)
+	redisStorage.reindexHashKey(hashKey)
 	redisStorage.incrementChanges()
 
 	return &newValue
@@ -232,6 +238,7 @@ func (redisStorage *RedisInMemoryStorage) IncrementHashFieldFloat(hashKey string
 		if storageValue.DataType != RedisHashType {
 			return nil // Erreur de type
 		}
+		redisStorage.preserveValueForSnapshots(hashKey, storageValue)
 		redisHashStructure = storageValue.StoredData.(*RedisHashStructure)
 	}
 
@@ -248,6 +255,7 @@ func (redisStorage *RedisInMemoryStorage) IncrementHashFieldFloat(hashKey string
 	// Incrémenter et stocker
 	newValue := currentValue + increment
 	redisHashStructure.HashFields[fieldName] = strconv.FormatFloat(newValue, 'f', -1, 64)
+	redisStorage.reindexHashKey(hashKey)
 	redisStorage.incrementChanges()
 
 	return &newValue
@@ -0,0 +1,388 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// Erreurs retournées par les opérations HyperLogLog
+var (
+	ErrHyperLogLogInvalid = errors.New("la clé ne contient pas une HyperLogLog valide")
+)
+
+// Format des HyperLogLog, identique à celui de Redis pour que DUMP / RESTORE et les fichiers
+// restent échangeables : en-tête "HYLL" + encodage + 3 octets nuls + cardinalité en cache (8 octets,
+// little endian, bit de poids fort du dernier octet = cache invalide), puis les 16384 registres
+const (
+	hyperLogLogPrecision        = 14
+	hyperLogLogRegisterCount    = 1 << hyperLogLogPrecision
+	hyperLogLogRegisterBits     = 6
+	hyperLogLogHashBits         = 64 - hyperLogLogPrecision
+	hyperLogLogHeaderSize       = 16
+	hyperLogLogDenseSize        = hyperLogLogHeaderSize + (hyperLogLogRegisterCount*hyperLogLogRegisterBits+7)/8
+	hyperLogLogEncodingDense    = 0
+	hyperLogLogEncodingSparse   = 1
+	hyperLogLogSparseMaxValue   = 32 // Au-delà, un registre ne peut être codé qu'en dense
+	hyperLogLogSparseMaxZeroRun = 64
+	hyperLogLogSparseMaxLongRun = hyperLogLogRegisterCount
+	hyperLogLogSparseMaxValRun  = 4
+	hyperLogLogAlphaInfinity    = 0.721347520444481703680 // 1 / (2 ln 2)
+	hyperLogLogHashSeed         = 0xadc83b19
+)
+
+// hyperLogLogRegisters contient les registres décodés, quel que soit l'encodage stocké
+type hyperLogLogRegisters [hyperLogLogRegisterCount]uint8
+
+// AddHyperLogLogElements ajoute des éléments à une HyperLogLog, créée vide (encodage sparse) si la clé n'existe pas
+// La représentation sparse passe en dense dès qu'elle dépasse sparseMaximumBytes ou qu'un registre dépasse 32
+// Retourne true si un registre a changé ou si la clé a été créée
+func (redisStorage *RedisInMemoryStorage) AddHyperLogLogElements(storageKey string, elements []string, sparseMaximumBytes int) (bool, error) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	stringValue, stringExists, isString := redisStorage.lookupString(storageKey)
+	if !isString {
+		return false, ErrHyperLogLogInvalid
+	}
+
+	var registers *hyperLogLogRegisters
+	storedDense := false
+	if stringExists {
+		var validEncoding bool
+		if registers, storedDense, validEncoding = decodeHyperLogLog(stringValue); !validEncoding {
+			return false, ErrHyperLogLogInvalid
+		}
+	} else {
+		registers = &hyperLogLogRegisters{}
+	}
+
+	registersChanged := false
+	for _, element := range elements {
+		registerIndex, runLength := hyperLogLogPattern(element)
+		if runLength > registers[registerIndex] {
+			registers[registerIndex] = runLength
+			registersChanged = true
+		}
+	}
+
+	if !registersChanged && stringExists {
+		return false, nil
+	}
+	// Une HyperLogLog neuve a une cardinalité en cache valide (0), comme dans Redis
+	encodedValue := encodeHyperLogLog(registers, storedDense, sparseMaximumBytes, registersChanged)
+	redisStorage.storeStringBytes(storageKey, encodedValue)
+	return true, nil
+}
+
+// CountHyperLogLogs estime le nombre d'éléments distincts ajoutés aux clés (union à la volée si plusieurs)
+// Les clés absentes comptent comme des HyperLogLog vides ; la cardinalité en cache n'est jamais réécrite
+func (redisStorage *RedisInMemoryStorage) CountHyperLogLogs(storageKeys []string) (int64, error) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	if len(storageKeys) == 1 {
+		stringValue, stringExists, isString := redisStorage.lookupString(storageKeys[0])
+		if !isString {
+			return 0, ErrHyperLogLogInvalid
+		}
+		if !stringExists {
+			return 0, nil
+		}
+		registers, _, validEncoding := decodeHyperLogLog(stringValue)
+		if !validEncoding {
+			return 0, ErrHyperLogLogInvalid
+		}
+		if cachedCardinality, cacheValid := cachedHyperLogLogCardinality(stringValue); cacheValid {
+			return int64(cachedCardinality), nil
+		}
+		return int64(estimateHyperLogLogCardinality(registers)), nil
+	}
+
+	mergedRegisters, _, mergeError := redisStorage.mergeHyperLogLogRegisters(storageKeys)
+	if mergeError != nil {
+		return 0, mergeError
+	}
+	return int64(estimateHyperLogLogCardinality(mergedRegisters)), nil
+}
+
+// MergeHyperLogLogs range dans destinationKey l'union de la destination (si elle existe) et des sources
+// Le résultat reste sparse si aucune entrée n'était dense et qu'il tient dans sparseMaximumBytes ; le TTL est conservé
+func (redisStorage *RedisInMemoryStorage) MergeHyperLogLogs(destinationKey string, sourceKeys []string, sparseMaximumBytes int) error {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	mergedKeys := append([]string{destinationKey}, sourceKeys...)
+	mergedRegisters, anyDense, mergeError := redisStorage.mergeHyperLogLogRegisters(mergedKeys)
+	if mergeError != nil {
+		return mergeError
+	}
+
+	redisStorage.storeStringBytes(destinationKey, encodeHyperLogLog(mergedRegisters, anyDense, sparseMaximumBytes, true))
+	return nil
+}
+
+// mergeHyperLogLogRegisters calcule le maximum registre par registre des clés existantes
+// anyDense indique qu'au moins une entrée était encodée en dense
+func (redisStorage *RedisInMemoryStorage) mergeHyperLogLogRegisters(storageKeys []string) (*hyperLogLogRegisters, bool, error) {
+	mergedRegisters := &hyperLogLogRegisters{}
+	anyDense := false
+	for _, storageKey := range storageKeys {
+		stringValue, stringExists, isString := redisStorage.lookupString(storageKey)
+		if !isString {
+			return nil, false, ErrHyperLogLogInvalid
+		}
+		if !stringExists {
+			continue
+		}
+		registers, storedDense, validEncoding := decodeHyperLogLog(stringValue)
+		if !validEncoding {
+			return nil, false, ErrHyperLogLogInvalid
+		}
+		anyDense = anyDense || storedDense
+		for registerIndex, registerValue := range registers {
+			mergedRegisters[registerIndex] = max(mergedRegisters[registerIndex], registerValue)
+		}
+	}
+	return mergedRegisters, anyDense, nil
+}
+
+// hyperLogLogPattern retourne le registre d'un élément et la longueur de la série de zéros (+1) du reste du hash
+func hyperLogLogPattern(element string) (int, uint8) {
+	elementHash := murmurHash64A([]byte(element), hyperLogLogHashSeed)
+	registerIndex := int(elementHash & (hyperLogLogRegisterCount - 1))
+	remainingHash := elementHash>>hyperLogLogPrecision | uint64(1)<<hyperLogLogHashBits
+	return registerIndex, uint8(bits.TrailingZeros64(remainingHash) + 1)
+}
+
+// murmurHash64A est la fonction de hachage utilisée par Redis pour les HyperLogLog
+func murmurHash64A(keyBytes []byte, seed uint64) uint64 {
+	const multiplier = 0xc6a4a7935bd1e995
+	const shift = 47
+
+	hashValue := seed ^ (uint64(len(keyBytes)) * multiplier)
+	blockCount := len(keyBytes) / 8
+	for blockIndex := 0; blockIndex < blockCount; blockIndex++ {
+		block := binary.LittleEndian.Uint64(keyBytes[blockIndex*8:])
+		block *= multiplier
+		block ^= block >> shift
+		block *= multiplier
+		hashValue ^= block
+		hashValue *= multiplier
+	}
+
+	tailBytes := keyBytes[blockCount*8:]
+	if len(tailBytes) > 0 {
+		for tailIndex := len(tailBytes) - 1; tailIndex >= 0; tailIndex-- {
+			hashValue ^= uint64(tailBytes[tailIndex]) << (8 * tailIndex)
+		}
+		hashValue *= multiplier
+	}
+
+	hashValue ^= hashValue >> shift
+	hashValue *= multiplier
+	hashValue ^= hashValue >> shift
+	return hashValue
+}
+
+// decodeHyperLogLog vérifie l'en-tête et décode les registres ; validEncoding est false pour toute autre chaîne
+func decodeHyperLogLog(stringValue string) (registers *hyperLogLogRegisters, storedDense bool, validEncoding bool) {
+	if len(stringValue) < hyperLogLogHeaderSize || stringValue[:4] != "HYLL" {
+		return nil, false, false
+	}
+	registers = &hyperLogLogRegisters{}
+
+	switch stringValue[4] {
+	case hyperLogLogEncodingDense:
+		if len(stringValue) != hyperLogLogDenseSize {
+			return nil, false, false
+		}
+		denseBytes := stringValue[hyperLogLogHeaderSize:]
+		for registerIndex := range registers {
+			registers[registerIndex] = readDenseRegister(denseBytes, registerIndex)
+		}
+		return registers, true, true
+
+	case hyperLogLogEncodingSparse:
+		registerIndex := 0
+		sparseBytes := stringValue[hyperLogLogHeaderSize:]
+		for byteIndex := 0; byteIndex < len(sparseBytes); byteIndex++ {
+			opcode := sparseBytes[byteIndex]
+			switch {
+			case opcode&0xc0 == 0x00: // ZERO : 00xxxxxx, 1 à 64 registres nuls
+				registerIndex += int(opcode&0x3f) + 1
+			case opcode&0xc0 == 0x40: // XZERO : 01xxxxxx yyyyyyyy, 1 à 16384 registres nuls
+				if byteIndex+1 >= len(sparseBytes) {
+					return nil, false, false
+				}
+				byteIndex++
+				registerIndex += (int(opcode&0x3f)<<8 | int(sparseBytes[byteIndex])) + 1
+			default: // VAL : 1vvvvvxx, 1 à 4 registres valant 1 à 32
+				registerValue := (opcode>>2)&0x1f + 1
+				runLength := int(opcode&0x03) + 1
+				if registerIndex+runLength > hyperLogLogRegisterCount {
+					return nil, false, false
+				}
+				for runIndex := 0; runIndex < runLength; runIndex++ {
+					registers[registerIndex+runIndex] = registerValue
+				}
+				registerIndex += runLength
+			}
+			if registerIndex > hyperLogLogRegisterCount {
+				return nil, false, false
+			}
+		}
+		if registerIndex != hyperLogLogRegisterCount {
+			return nil, false, false
+		}
+		return registers, false, true
+	}
+	return nil, false, false
+}
+
+// encodeHyperLogLog produit la chaîne stockée : sparse si preferDense est faux et que les registres le permettent
+// invalidateCache marque la cardinalité en cache comme périmée
+func encodeHyperLogLog(registers *hyperLogLogRegisters, preferDense bool, sparseMaximumBytes int, invalidateCache bool) []byte {
+	headerBytes := make([]byte, hyperLogLogHeaderSize, hyperLogLogDenseSize)
+	copy(headerBytes, "HYLL")
+	if invalidateCache {
+		headerBytes[15] |= 0x80
+	}
+
+	if !preferDense {
+		if sparseBytes, fitsSparse := encodeSparseRegisters(registers, sparseMaximumBytes-hyperLogLogHeaderSize); fitsSparse {
+			headerBytes[4] = hyperLogLogEncodingSparse
+			return append(headerBytes, sparseBytes...)
+		}
+	}
+
+	headerBytes[4] = hyperLogLogEncodingDense
+	encodedValue := headerBytes[:hyperLogLogDenseSize]
+	for registerIndex, registerValue := range registers {
+		writeDenseRegister(encodedValue[hyperLogLogHeaderSize:], registerIndex, registerValue)
+	}
+	return encodedValue
+}
+
+// encodeSparseRegisters code les registres en opcodes ZERO / XZERO / VAL
+// fitsSparse est faux si un registre dépasse 32 ou si le résultat dépasse maximumBytes
+func encodeSparseRegisters(registers *hyperLogLogRegisters, maximumBytes int) ([]byte, bool) {
+	var sparseBytes []byte
+	for registerIndex := 0; registerIndex < hyperLogLogRegisterCount; {
+		registerValue := registers[registerIndex]
+		runLength := 1
+		for registerIndex+runLength < hyperLogLogRegisterCount && registers[registerIndex+runLength] == registerValue {
+			runLength++
+		}
+		registerIndex += runLength
+
+		switch {
+		case registerValue == 0:
+			for runLength > 0 {
+				if runLength > hyperLogLogSparseMaxZeroRun {
+					longRun := min(runLength, hyperLogLogSparseMaxLongRun)
+					sparseBytes = append(sparseBytes, 0x40|byte((longRun-1)>>8), byte(longRun-1))
+					runLength -= longRun
+				} else {
+					sparseBytes = append(sparseBytes, byte(runLength-1))
+					runLength = 0
+				}
+			}
+		case registerValue > hyperLogLogSparseMaxValue:
+			return nil, false
+		default:
+			for runLength > 0 {
+				valueRun := min(runLength, hyperLogLogSparseMaxValRun)
+				sparseBytes = append(sparseBytes, 0x80|(registerValue-1)<<2|byte(valueRun-1))
+				runLength -= valueRun
+			}
+		}
+		if len(sparseBytes) > maximumBytes {
+			return nil, false
+		}
+	}
+	return sparseBytes, true
+}
+
+// readDenseRegister lit le registre de 6 bits numéro registerIndex (bits de poids faible d'abord, comme Redis)
+func readDenseRegister(denseBytes string, registerIndex int) uint8 {
+	bitPosition := registerIndex * hyperLogLogRegisterBits
+	byteIndex, bitShift := bitPosition/8, uint(bitPosition%8)
+	registerBits := uint(denseBytes[byteIndex]) >> bitShift
+	if byteIndex+1 < len(denseBytes) {
+		registerBits |= uint(denseBytes[byteIndex+1]) << (8 - bitShift)
+	}
+	return uint8(registerBits & 0x3f)
+}
+
+// writeDenseRegister écrit le registre de 6 bits numéro registerIndex
+func writeDenseRegister(denseBytes []byte, registerIndex int, registerValue uint8) {
+	bitPosition := registerIndex * hyperLogLogRegisterBits
+	byteIndex, bitShift := bitPosition/8, uint(bitPosition%8)
+	denseBytes[byteIndex] &^= byte(0x3f << bitShift)
+	denseBytes[byteIndex] |= registerValue << bitShift
+	if byteIndex+1 < len(denseBytes) {
+		denseBytes[byteIndex+1] &^= byte(0x3f >> (8 - bitShift))
+		denseBytes[byteIndex+1] |= registerValue >> (8 - bitShift)
+	}
+}
+
+// cachedHyperLogLogCardinality lit la cardinalité en cache de l'en-tête
+func cachedHyperLogLogCardinality(stringValue string) (uint64, bool) {
+	if stringValue[15]&0x80 != 0 {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64([]byte(stringValue[8:16])), true
+}
+
+// estimateHyperLogLogCardinality applique l'estimateur d'Ertl utilisé par Redis (erreur standard ~0,81 %)
+func estimateHyperLogLogCardinality(registers *hyperLogLogRegisters) uint64 {
+	var registerHistogram [64]int
+	for _, registerValue := range registers {
+		registerHistogram[registerValue]++
+	}
+
+	registerCount := float64(hyperLogLogRegisterCount)
+	estimate := registerCount * hyperLogLogTau((registerCount-float64(registerHistogram[hyperLogLogHashBits+1]))/registerCount)
+	for histogramIndex := hyperLogLogHashBits; histogramIndex >= 1; histogramIndex-- {
+		estimate += float64(registerHistogram[histogramIndex])
+		estimate *= 0.5
+	}
+	estimate += registerCount * hyperLogLogSigma(float64(registerHistogram[0])/registerCount)
+	return uint64(math.Round(hyperLogLogAlphaInfinity * registerCount * registerCount / estimate))
+}
+
+// hyperLogLogSigma est la fonction sigma de l'estimateur (correction des registres nuls)
+func hyperLogLogSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	sum, power := x, 1.0
+	for {
+		x *= x
+		previousSum := sum
+		sum += x * power
+		power += power
+		if previousSum == sum {
+			return sum
+		}
+	}
+}
+
+// hyperLogLogTau est la fonction tau de l'estimateur (correction des registres saturés)
+func hyperLogLogTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	sum, power := 1-x, 1.0
+	for {
+		x = math.Sqrt(x)
+		previousSum := sum
+		power *= 0.5
+		sum -= (1 - x) * (1 - x) * power
+		if previousSum == sum {
+			return sum / 3
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// storedHyperLogLog retourne la chaîne stockée sous une clé HyperLogLog
+func storedHyperLogLog(t *testing.T, redisStorage *RedisInMemoryStorage, storageKey string) string {
+	t.Helper()
+	storageValue := redisStorage.GetKeyValue(storageKey)
+	if storageValue == nil {
+		t.Fatalf("clé %s absente", storageKey)
+	}
+	return storageValue.StoredData.(string)
+}
+
+// addDistinctElements ajoute elementCount éléments distincts préfixés par elementPrefix
+func addDistinctElements(t *testing.T, redisStorage *RedisInMemoryStorage, storageKey string, elementPrefix string, elementCount int, sparseMaximumBytes int) {
+	t.Helper()
+	elements := make([]string, 0, elementCount)
+	for elementIndex := 0; elementIndex < elementCount; elementIndex++ {
+		elements = append(elements, fmt.Sprintf("%s:%d", elementPrefix, elementIndex))
+	}
+	if _, addError := redisStorage.AddHyperLogLogElements(storageKey, elements, sparseMaximumBytes); addError != nil {
+		t.Fatalf("AddHyperLogLogElements: %v", addError)
+	}
+}
+
+func TestEmptyHyperLogLogUsesRedisSparseLayout(t *testing.T) {
+	redisStorage := NewRedisInMemoryStorage()
+	if _, addError := redisStorage.AddHyperLogLogElements("hll", nil, 3000); addError != nil {
+		t.Fatalf("AddHyperLogLogElements: %v", addError)
+	}
+	// En-tête "HYLL", encodage sparse, cache valide à 0, puis un XZERO couvrant les 16384 registres
+	expectedValue := "HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x7f\xff"
+	if storedValue := storedHyperLogLog(t, redisStorage, "hll"); storedValue != expectedValue {
+		t.Fatalf("HyperLogLog vide %q, attendu %q", storedValue, expectedValue)
+	}
+}
+
+func TestHyperLogLogSparseToDenseConversion(t *testing.T) {
+	testCases := []struct {
+		name               string
+		elementCount       int
+		sparseMaximumBytes int
+		expectedEncoding   byte
+	}{
+		{"peu d'éléments", 20, 3000, hyperLogLogEncodingSparse},
+		{"limite sparse dépassée", 2000, 3000, hyperLogLogEncodingDense},
+		{"limite sparse à zéro", 1, 0, hyperLogLogEncodingDense},
+		{"limite sparse élevée", 2000, 100000, hyperLogLogEncodingSparse},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			redisStorage := NewRedisInMemoryStorage()
+			addDistinctElements(t, redisStorage, "hll", "e", testCase.elementCount, testCase.sparseMaximumBytes)
+			storedValue := storedHyperLogLog(t, redisStorage, "hll")
+			if storedValue[4] != testCase.expectedEncoding {
+				t.Fatalf("encodage %d, attendu %d (taille %d)", storedValue[4], testCase.expectedEncoding, len(storedValue))
+			}
+			if testCase.expectedEncoding == hyperLogLogEncodingDense && len(storedValue) != hyperLogLogDenseSize {
+				t.Fatalf("taille dense %d, attendu %d", len(storedValue), hyperLogLogDenseSize)
+			}
+			if testCase.expectedEncoding == hyperLogLogEncodingSparse && len(storedValue) > testCase.sparseMaximumBytes {
+				t.Fatalf("taille sparse %d au-delà de la limite %d", len(storedValue), testCase.sparseMaximumBytes)
+			}
+		})
+	}
+}
+
+func TestHyperLogLogConversionKeepsRegisters(t *testing.T) {
+	redisStorage := NewRedisInMemoryStorage()
+	addDistinctElements(t, redisStorage, "sparse", "e", 500, 100000)
+	addDistinctElements(t, redisStorage, "dense", "e", 500, 0)
+
+	sparseRegisters, sparseStoredDense, sparseValid := decodeHyperLogLog(storedHyperLogLog(t, redisStorage, "sparse"))
+	denseRegisters, denseStoredDense, denseValid := decodeHyperLogLog(storedHyperLogLog(t, redisStorage, "dense"))
+	if !sparseValid || !denseValid || sparseStoredDense || !denseStoredDense {
+		t.Fatalf("décodage: sparse (valide %v, dense %v), dense (valide %v, dense %v)", sparseValid, sparseStoredDense, denseValid, denseStoredDense)
+	}
+	if *sparseRegisters != *denseRegisters {
+		t.Fatalf("les registres diffèrent entre les encodages sparse et dense")
+	}
+
+	// Un registre au-delà de 32 ne peut pas être codé en sparse
+	overflowingRegisters := &hyperLogLogRegisters{}
+	overflowingRegisters[42] = hyperLogLogSparseMaxValue + 1
+	if encodedValue := encodeHyperLogLog(overflowingRegisters, false, 100000, true); encodedValue[4] != hyperLogLogEncodingDense {
+		t.Fatalf("registre de valeur %d encodé en %d, attendu dense", overflowingRegisters[42], encodedValue[4])
+	}
+}
+
+func TestHyperLogLogMergeEncoding(t *testing.T) {
+	testCases := []struct {
+		name             string
+		denseSource      bool
+		expectedEncoding byte
+	}{
+		{"sources sparse", false, hyperLogLogEncodingSparse},
+		{"une source dense", true, hyperLogLogEncodingDense},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			redisStorage := NewRedisInMemoryStorage()
+			addDistinctElements(t, redisStorage, "a", "a", 50, 3000)
+			secondSourceMaximumBytes := 3000
+			if testCase.denseSource {
+				secondSourceMaximumBytes = 0
+			}
+			addDistinctElements(t, redisStorage, "b", "b", 50, secondSourceMaximumBytes)
+
+			if mergeError := redisStorage.MergeHyperLogLogs("union", []string{"a", "b"}, 3000); mergeError != nil {
+				t.Fatalf("MergeHyperLogLogs: %v", mergeError)
+			}
+			if mergedValue := storedHyperLogLog(t, redisStorage, "union"); mergedValue[4] != testCase.expectedEncoding {
+				t.Fatalf("union encodée en %d, attendu %d", mergedValue[4], testCase.expectedEncoding)
+			}
+			unionCount, countError := redisStorage.CountHyperLogLogs([]string{"union"})
+			if countError != nil || math.Abs(float64(unionCount)-100) > 5 {
+				t.Fatalf("PFCOUNT union = %d (%v), attendu environ 100", unionCount, countError)
+			}
+		})
+	}
+}
+
+func TestHyperLogLogCardinalityEstimate(t *testing.T) {
+	for _, elementCount := range []int{1, 100, 10000, 100000} {
+		redisStorage := NewRedisInMemoryStorage()
+		addDistinctElements(t, redisStorage, "hll", "x", elementCount, 3000)
+		estimatedCount, countError := redisStorage.CountHyperLogLogs([]string{"hll"})
+		if countError != nil {
+			t.Fatalf("CountHyperLogLogs: %v", countError)
+		}
+		// Erreur standard de 0,81 % pour 16384 registres : 3 % laisse une marge confortable
+		if relativeError := math.Abs(float64(estimatedCount)-float64(elementCount)) / float64(elementCount); relativeError > 0.03 {
+			t.Errorf("%d éléments estimés à %d (erreur %.2f %%)", elementCount, estimatedCount, relativeError*100)
+		}
+	}
+}
+
+func TestDecodeHyperLogLogRejectsInvalidValues(t *testing.T) {
+	header := func(encoding byte) string {
+		return "HYLL" + string([]byte{encoding}) + "\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"
+	}
+	testCases := []struct {
+		name        string
+		stringValue string
+	}{
+		{"chaîne ordinaire", "bonjour"},
+		{"en-tête seul", header(hyperLogLogEncodingSparse)},
+		{"encodage inconnu", header(7) + "\x7f\xff"},
+		{"dense tronqué", header(hyperLogLogEncodingDense) + "\x00\x00"},
+		{"sparse incomplet", header(hyperLogLogEncodingSparse) + "\x7f\xfe"},
+		{"sparse trop long", header(hyperLogLogEncodingSparse) + "\x7f\xff\x00"},
+		{"XZERO tronqué", header(hyperLogLogEncodingSparse) + "\x7f"},
+	}
+
+	for _, testCase := range testCases {
+		if _, _, validEncoding := decodeHyperLogLog(testCase.stringValue); validEncoding {
+			t.Errorf("%s: %q accepté comme HyperLogLog", testCase.name, testCase.stringValue)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// JSONMaximumNestingDepth est la profondeur maximale d'un document JSON, comme dans RedisJSON
+const JSONMaximumNestingDepth = 128
+
+// Erreurs retournées par l'analyse des documents JSON
+var (
+	ErrJSONInvalidDocument = errors.New("JSON invalide")
+	ErrJSONTooDeep         = fmt.Errorf("profondeur d'imbrication JSON supérieure à %d", JSONMaximumNestingDepth)
+)
+
+// JSONObject est un objet JSON qui conserve l'ordre d'insertion de ses membres, comme RedisJSON
+// Les valeurs sont des *JSONObject, *JSONArray, string, json.Number, bool ou nil (null)
+type JSONObject struct {
+	MemberNames  []string
+	MemberValues map[string]interface{}
+}
+
+// JSONArray est un tableau JSON
+type JSONArray struct {
+	Elements []interface{}
+}
+
+// JSONFormat décrit la mise en forme de JSON.GET (INDENT, NEWLINE, SPACE) ; la valeur zéro donne un JSON compact
+type JSONFormat struct {
+	Indent  string
+	Newline string
+	Space   string
+}
+
+// NewJSONObject crée un objet JSON vide
+func NewJSONObject() *JSONObject {
+	return &JSONObject{MemberValues: make(map[string]interface{})}
+}
+
+// SetMember ajoute un membre en fin d'objet, ou remplace sa valeur sans changer sa position
+func (jsonObject *JSONObject) SetMember(memberName string, memberValue interface{}) {
+	if _, memberExists := jsonObject.MemberValues[memberName]; !memberExists {
+		jsonObject.MemberNames = append(jsonObject.MemberNames, memberName)
+	}
+	jsonObject.MemberValues[memberName] = memberValue
+}
+
+// DeleteMember retire un membre et retourne false s'il n'existait pas
+func (jsonObject *JSONObject) DeleteMember(memberName string) bool {
+	if _, memberExists := jsonObject.MemberValues[memberName]; !memberExists {
+		return false
+	}
+	delete(jsonObject.MemberValues, memberName)
+	for memberIndex, existingName := range jsonObject.MemberNames {
+		if existingName == memberName {
+			jsonObject.MemberNames = append(jsonObject.MemberNames[:memberIndex], jsonObject.MemberNames[memberIndex+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// ParseJSONValue analyse un texte JSON complet (une seule valeur, espaces autour acceptés)
+func ParseJSONValue(jsonText string) (interface{}, error) {
+	jsonDecoder := json.NewDecoder(strings.NewReader(jsonText))
+	jsonDecoder.UseNumber()
+
+	parsedValue, parseError := decodeJSONValue(jsonDecoder, 0)
+	if parseError != nil {
+		return nil, parseError
+	}
+	if _, trailingError := jsonDecoder.Token(); trailingError != io.EOF {
+		return nil, ErrJSONInvalidDocument
+	}
+	return parsedValue, nil
+}
+
+// decodeJSONValue lit une valeur jeton par jeton pour conserver l'ordre des membres d'objet
+// Si un membre apparaît plusieurs fois, la dernière valeur l'emporte
+func decodeJSONValue(jsonDecoder *json.Decoder, nestingDepth int) (interface{}, error) {
+	if nestingDepth > JSONMaximumNestingDepth {
+		return nil, ErrJSONTooDeep
+	}
+	jsonToken, tokenError := jsonDecoder.Token()
+	if tokenError != nil {
+		return nil, ErrJSONInvalidDocument
+	}
+
+	switch typedToken := jsonToken.(type) {
+	case json.Delim:
+		switch typedToken {
+		case '{':
+			jsonObject := NewJSONObject()
+			for jsonDecoder.More() {
+				nameToken, nameError := jsonDecoder.Token()
+				memberName, isString := nameToken.(string)
+				if nameError != nil || !isString {
+					return nil, ErrJSONInvalidDocument
+				}
+				memberValue, valueError := decodeJSONValue(jsonDecoder, nestingDepth+1)
+				if valueError != nil {
+					return nil, valueError
+				}
+				jsonObject.SetMember(memberName, memberValue)
+			}
+			if _, closingError := jsonDecoder.Token(); closingError != nil {
+				return nil, ErrJSONInvalidDocument
+			}
+			return jsonObject, nil
+		case '[':
+			jsonArray := &JSONArray{Elements: []interface{}{}}
+			for jsonDecoder.More() {
+				elementValue, valueError := decodeJSONValue(jsonDecoder, nestingDepth+1)
+				if valueError != nil {
+					return nil, valueError
+				}
+				jsonArray.Elements = append(jsonArray.Elements, elementValue)
+			}
+			if _, closingError := jsonDecoder.Token(); closingError != nil {
+				return nil, ErrJSONInvalidDocument
+			}
+			return jsonArray, nil
+		}
+		return nil, ErrJSONInvalidDocument
+	case string, json.Number, bool, nil:
+		return typedToken, nil
+	}
+	return nil, ErrJSONInvalidDocument
+}
+
+// SerializeJSONValue écrit une valeur JSON, compacte ou mise en forme selon jsonFormat
+func SerializeJSONValue(jsonValue interface{}, jsonFormat JSONFormat) string {
+	var serializedValue strings.Builder
+	writeJSONValue(&serializedValue, jsonValue, jsonFormat, 0)
+	return serializedValue.String()
+}
+
+// writeJSONValue écrit récursivement une valeur ; nestingDepth sert à l'indentation
+func writeJSONValue(serializedValue *strings.Builder, jsonValue interface{}, jsonFormat JSONFormat, nestingDepth int) {
+	switch typedValue := jsonValue.(type) {
+	case *JSONObject:
+		if len(typedValue.MemberNames) == 0 {
+			serializedValue.WriteString("{}")
+			return
+		}
+		serializedValue.WriteByte('{')
+		for memberIndex, memberName := range typedValue.MemberNames {
+			if memberIndex > 0 {
+				serializedValue.WriteByte(',')
+			}
+			writeJSONLineBreak(serializedValue, jsonFormat, nestingDepth+1)
+			writeJSONString(serializedValue, memberName)
+			serializedValue.WriteByte(':')
+			serializedValue.WriteString(jsonFormat.Space)
+			writeJSONValue(serializedValue, typedValue.MemberValues[memberName], jsonFormat, nestingDepth+1)
+		}
+		writeJSONLineBreak(serializedValue, jsonFormat, nestingDepth)
+		serializedValue.WriteByte('}')
+	case *JSONArray:
+		if len(typedValue.Elements) == 0 {
+			serializedValue.WriteString("[]")
+			return
+		}
+		serializedValue.WriteByte('[')
+		for elementIndex, elementValue := range typedValue.Elements {
+			if elementIndex > 0 {
+				serializedValue.WriteByte(',')
+			}
+			writeJSONLineBreak(serializedValue, jsonFormat, nestingDepth+1)
+			writeJSONValue(serializedValue, elementValue, jsonFormat, nestingDepth+1)
+		}
+		writeJSONLineBreak(serializedValue, jsonFormat, nestingDepth)
+		serializedValue.WriteByte(']')
+	case string:
+		writeJSONString(serializedValue, typedValue)
+	case json.Number:
+		serializedValue.WriteString(typedValue.String())
+	case bool:
+		serializedValue.WriteString(strconv.FormatBool(typedValue))
+	default:
+		serializedValue.WriteString("null")
+	}
+}
+
+// writeJSONLineBreak écrit NEWLINE puis INDENT répété nestingDepth fois
+func writeJSONLineBreak(serializedValue *strings.Builder, jsonFormat JSONFormat, nestingDepth int) {
+	serializedValue.WriteString(jsonFormat.Newline)
+	for indentLevel := 0; indentLevel < nestingDepth; indentLevel++ {
+		serializedValue.WriteString(jsonFormat.Indent)
+	}
+}
+
+// writeJSONString écrit une chaîne JSON échappée (sans échappement HTML de <, > et &)
+func writeJSONString(serializedValue *strings.Builder, stringValue string) {
+	var encodedString bytes.Buffer
+	jsonEncoder := json.NewEncoder(&encodedString)
+	jsonEncoder.SetEscapeHTML(false)
+	jsonEncoder.Encode(stringValue)
+	serializedValue.Write(bytes.TrimSuffix(encodedString.Bytes(), []byte("\n")))
+}
+
+// CloneJSONValue copie en profondeur une valeur JSON (les scalaires sont immuables)
+func CloneJSONValue(jsonValue interface{}) interface{} {
+	switch typedValue := jsonValue.(type) {
+	case *JSONObject:
+		clonedObject := &JSONObject{
+			MemberNames:  append(make([]string, 0, len(typedValue.MemberNames)), typedValue.MemberNames...),
+			MemberValues: make(map[string]interface{}, len(typedValue.MemberValues)),
+		}
+		for memberName, memberValue := range typedValue.MemberValues {
+			clonedObject.MemberValues[memberName] = CloneJSONValue(memberValue)
+		}
+		return clonedObject
+	case *JSONArray:
+		clonedArray := &JSONArray{Elements: make([]interface{}, len(typedValue.Elements))}
+		for elementIndex, elementValue := range typedValue.Elements {
+			clonedArray.Elements[elementIndex] = CloneJSONValue(elementValue)
+		}
+		return clonedArray
+	default:
+		return jsonValue
+	}
+}
+
+// JSONValueTypeName retourne le nom du type d'une valeur tel qu'affiché par JSON.TYPE
+func JSONValueTypeName(jsonValue interface{}) string {
+	switch typedValue := jsonValue.(type) {
+	case *JSONObject:
+		return "object"
+	case *JSONArray:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		if isJSONInteger(typedValue) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// isJSONInteger indique si un nombre est écrit sans partie décimale ni exposant
+func isJSONInteger(jsonNumber json.Number) bool {
+	return !strings.ContainsAny(jsonNumber.String(), ".eE")
+}
+
+// addJSONNumbers additionne deux nombres JSON : en entier si les deux le sont et que le résultat tient sur
+// 64 bits, en flottant sinon ; un résultat infini est refusé
+func addJSONNumbers(currentNumber json.Number, incrementNumber json.Number) (json.Number, error) {
+	if isJSONInteger(currentNumber) && isJSONInteger(incrementNumber) {
+		currentInteger, currentError := currentNumber.Int64()
+		incrementInteger, incrementError := incrementNumber.Int64()
+		sumInteger := currentInteger + incrementInteger
+		overflow := (incrementInteger > 0 && sumInteger < currentInteger) || (incrementInteger < 0 && sumInteger > currentInteger)
+		if currentError == nil && incrementError == nil && !overflow {
+			return json.Number(strconv.FormatInt(sumInteger, 10)), nil
+		}
+	}
+
+	currentFloat, currentError := currentNumber.Float64()
+	incrementFloat, incrementError := incrementNumber.Float64()
+	sumFloat := currentFloat + incrementFloat
+	if currentError != nil || incrementError != nil || math.IsInf(sumFloat, 0) || math.IsNaN(sumFloat) {
+		return "", errors.New("le résultat n'est pas un nombre fini")
+	}
+	return formatJSONFloat(sumFloat), nil
+}
+
+// formatJSONFloat écrit un flottant qui reste de type number (2.0 et non 2)
+func formatJSONFloat(floatValue float64) json.Number {
+	if absoluteValue := math.Abs(floatValue); absoluteValue != 0 && (absoluteValue < 1e-5 || absoluteValue >= 1e16) {
+		return json.Number(strconv.FormatFloat(floatValue, 'e', -1, 64))
+	}
+	formattedFloat := strconv.FormatFloat(floatValue, 'f', -1, 64)
+	if !strings.Contains(formattedFloat, ".") {
+		formattedFloat += ".0"
+	}
+	return json.Number(formattedFloat)
+}
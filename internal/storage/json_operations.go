@@ -0,0 +1,397 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// Erreurs retournées par les opérations sur les documents JSON
+var (
+	ErrJSONWrongType            = errors.New("cette clé ne contient pas un document JSON")
+	ErrJSONKeyNotFound          = errors.New("impossible d'effectuer cette opération sur une clé qui n'existe pas")
+	ErrJSONNewDocumentNotAtRoot = errors.New("un nouveau document doit être créé à la racine ($ ou .)")
+	ErrJSONPathNotFound         = errors.New("le chemin n'existe pas dans le document")
+)
+
+// JSONSetCondition reprend les options NX / XX de JSON.SET
+type JSONSetCondition int
+
+const (
+	JSONSetAlways    JSONSetCondition = iota
+	JSONSetIfMissing                  // NX : seulement si le chemin n'existe pas
+	JSONSetIfExists                   // XX : seulement si le chemin existe
+)
+
+// JSONPathResult est le résultat d'une commande pour une des valeurs désignées par le chemin
+// Applicable est faux si la valeur n'a pas le type attendu par la commande (réponse nulle)
+type JSONPathResult struct {
+	Applicable bool
+	Value      interface{} // Nouveau nombre, élément retiré, noms des membres ou nom du type selon la commande
+	Length     int         // Longueur du tableau ou de la chaîne ; pour ARRPOP, longueur avant le retrait
+}
+
+// SetJSONValue implémente JSON.SET : crée le document à la racine, remplace les valeurs désignées par le chemin,
+// ou ajoute le membre final du chemin aux objets désignés par le reste du chemin
+// Retourne false si la condition NX / XX n'est pas remplie ou si aucun emplacement ne convient
+func (redisStorage *RedisInMemoryStorage) SetJSONValue(storageKey string, jsonPath *JSONPath, newValue interface{}, setCondition JSONSetCondition) (bool, error) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	jsonDocument, documentExists, isJSON := redisStorage.lookupJSONDocument(storageKey)
+	if !isJSON {
+		return false, ErrJSONWrongType
+	}
+	if !documentExists {
+		if !jsonPath.IsRoot() {
+			return false, ErrJSONNewDocumentNotAtRoot
+		}
+		if setCondition == JSONSetIfExists {
+			return false, nil
+		}
+		redisStorage.storageData[storageKey] = &RedisStorageValue{
+			StoredData: &RedisJSONDocument{Root: newValue},
+			DataType:   RedisJSONType,
+		}
+		redisStorage.incrementChanges()
+		return true, nil
+	}
+
+	if pathMatches := jsonPath.evaluate(jsonDocument.Root); len(pathMatches) > 0 {
+		if setCondition == JSONSetIfMissing {
+			return false, nil
+		}
+		redisStorage.preserveValueForSnapshots(storageKey, redisStorage.storageData[storageKey])
+		for matchIndex, pathMatch := range pathMatches {
+			replacementValue := newValue
+			if matchIndex > 0 {
+				replacementValue = CloneJSONValue(newValue)
+			}
+			replaceJSONMatch(jsonDocument, pathMatch, replacementValue)
+		}
+		redisStorage.incrementChanges()
+		return true, nil
+	}
+
+	// Chemin absent : seul un membre d'objet peut être créé, dans des objets existants
+	if setCondition == JSONSetIfExists {
+		return false, nil
+	}
+	lastSegment := jsonPath.pathSegments[len(jsonPath.pathSegments)-1]
+	var parentObjects []*JSONObject
+	if lastSegment.segmentKind == jsonPathMemberSegment && !lastSegment.recursiveDescent {
+		parentPath := jsonPath.pathSegments[:len(jsonPath.pathSegments)-1]
+		for _, parentMatch := range evaluateJSONPathSegments(parentPath, []jsonPathMatch{{matchedValue: jsonDocument.Root}}) {
+			if parentObject, isObject := parentMatch.matchedValue.(*JSONObject); isObject {
+				parentObjects = append(parentObjects, parentObject)
+			}
+		}
+	}
+	if len(parentObjects) == 0 {
+		if jsonPath.IsLegacy {
+			return false, ErrJSONPathNotFound
+		}
+		return false, nil
+	}
+
+	redisStorage.preserveValueForSnapshots(storageKey, redisStorage.storageData[storageKey])
+	for parentIndex, parentObject := range parentObjects {
+		memberValue := newValue
+		if parentIndex > 0 {
+			memberValue = CloneJSONValue(newValue)
+		}
+		parentObject.SetMember(lastSegment.memberName, memberValue)
+	}
+	redisStorage.incrementChanges()
+	return true, nil
+}
+
+// GetJSONValues retourne une copie des valeurs désignées par chaque chemin (JSON.GET)
+func (redisStorage *RedisInMemoryStorage) GetJSONValues(storageKey string, jsonPaths []*JSONPath) ([][]interface{}, bool, error) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	jsonDocument, documentExists, isJSON := redisStorage.lookupJSONDocument(storageKey)
+	if !isJSON {
+		return nil, false, ErrJSONWrongType
+	}
+	if !documentExists {
+		return nil, false, nil
+	}
+
+	pathValues := make([][]interface{}, len(jsonPaths))
+	for pathIndex, jsonPath := range jsonPaths {
+		pathValues[pathIndex] = cloneJSONMatches(jsonPath.evaluate(jsonDocument.Root))
+	}
+	return pathValues, true, nil
+}
+
+// GetJSONValuesForKeys retourne une copie des valeurs désignées par le chemin dans chaque clé (JSON.MGET)
+// documentFound[i] est faux si la clé n'existe pas ou ne contient pas un document JSON
+func (redisStorage *RedisInMemoryStorage) GetJSONValuesForKeys(storageKeys []string, jsonPath *JSONPath) ([][]interface{}, []bool) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	keyValues := make([][]interface{}, len(storageKeys))
+	documentFound := make([]bool, len(storageKeys))
+	for keyIndex, storageKey := range storageKeys {
+		jsonDocument, documentExists, _ := redisStorage.lookupJSONDocument(storageKey)
+		if documentExists {
+			keyValues[keyIndex] = cloneJSONMatches(jsonPath.evaluate(jsonDocument.Root))
+			documentFound[keyIndex] = true
+		}
+	}
+	return keyValues, documentFound
+}
+
+// DeleteJSONValues retire les valeurs désignées par le chemin (JSON.DEL) ; la racine supprime la clé
+// Retourne le nombre de valeurs retirées
+func (redisStorage *RedisInMemoryStorage) DeleteJSONValues(storageKey string, jsonPath *JSONPath) (int, error) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	jsonDocument, documentExists, isJSON := redisStorage.lookupJSONDocument(storageKey)
+	if !isJSON {
+		return 0, ErrJSONWrongType
+	}
+	if !documentExists {
+		return 0, nil
+	}
+	if jsonPath.IsRoot() {
+		delete(redisStorage.storageData, storageKey)
+		redisStorage.incrementChanges()
+		return 1, nil
+	}
+
+	pathMatches := jsonPath.evaluate(jsonDocument.Root)
+	if len(pathMatches) == 0 {
+		return 0, nil
+	}
+	redisStorage.preserveValueForSnapshots(storageKey, redisStorage.storageData[storageKey])
+
+	// Les éléments d'un même tableau sont retirés du dernier au premier pour garder les indices valides
+	sort.SliceStable(pathMatches, func(firstIndex, secondIndex int) bool {
+		return pathMatches[firstIndex].elementIndex > pathMatches[secondIndex].elementIndex
+	})
+	deletedCount := 0
+	for _, pathMatch := range pathMatches {
+		switch typedContainer := pathMatch.container.(type) {
+		case *JSONObject:
+			if typedContainer.DeleteMember(pathMatch.memberName) {
+				deletedCount++
+			}
+		case *JSONArray:
+			if pathMatch.elementIndex < len(typedContainer.Elements) {
+				typedContainer.Elements = append(typedContainer.Elements[:pathMatch.elementIndex], typedContainer.Elements[pathMatch.elementIndex+1:]...)
+				deletedCount++
+			}
+		}
+	}
+	redisStorage.incrementChanges()
+	return deletedCount, nil
+}
+
+// IncrementJSONNumbers ajoute increment à chaque nombre désigné par le chemin (JSON.NUMINCRBY)
+// Si un résultat n'est pas fini, aucune valeur n'est modifiée
+func (redisStorage *RedisInMemoryStorage) IncrementJSONNumbers(storageKey string, jsonPath *JSONPath, increment json.Number) ([]JSONPathResult, error) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	jsonDocument, lookupError := redisStorage.lookupExistingJSONDocument(storageKey)
+	if lookupError != nil {
+		return nil, lookupError
+	}
+
+	pathMatches := jsonPath.evaluate(jsonDocument.Root)
+	pathResults := make([]JSONPathResult, len(pathMatches))
+	numbersChanged := false
+	for matchIndex, pathMatch := range pathMatches {
+		currentNumber, isNumber := pathMatch.matchedValue.(json.Number)
+		if !isNumber {
+			continue
+		}
+		incrementedNumber, additionError := addJSONNumbers(currentNumber, increment)
+		if additionError != nil {
+			return nil, additionError
+		}
+		pathResults[matchIndex] = JSONPathResult{Applicable: true, Value: incrementedNumber}
+		numbersChanged = true
+	}
+
+	if numbersChanged {
+		redisStorage.preserveValueForSnapshots(storageKey, redisStorage.storageData[storageKey])
+		for matchIndex, pathMatch := range pathMatches {
+			if pathResults[matchIndex].Applicable {
+				replaceJSONMatch(jsonDocument, pathMatch, pathResults[matchIndex].Value)
+			}
+		}
+		redisStorage.incrementChanges()
+	}
+	return pathResults, nil
+}
+
+// AppendJSONArrayValues ajoute des valeurs à la fin de chaque tableau désigné par le chemin (JSON.ARRAPPEND)
+func (redisStorage *RedisInMemoryStorage) AppendJSONArrayValues(storageKey string, jsonPath *JSONPath, appendedValues []interface{}) ([]JSONPathResult, error) {
+	return redisStorage.updateJSONMatches(storageKey, jsonPath, func(jsonDocument *RedisJSONDocument, pathMatch jsonPathMatch) JSONPathResult {
+		jsonArray, isArray := pathMatch.matchedValue.(*JSONArray)
+		if !isArray {
+			return JSONPathResult{}
+		}
+		for _, appendedValue := range appendedValues {
+			jsonArray.Elements = append(jsonArray.Elements, CloneJSONValue(appendedValue))
+		}
+		return JSONPathResult{Applicable: true, Length: len(jsonArray.Elements)}
+	})
+}
+
+// PopJSONArrayValues retire l'élément d'indice elementIndex de chaque tableau désigné par le chemin (JSON.ARRPOP)
+// Un indice négatif part de la fin ; un indice hors limites est ramené au premier ou au dernier élément
+func (redisStorage *RedisInMemoryStorage) PopJSONArrayValues(storageKey string, jsonPath *JSONPath, elementIndex int) ([]JSONPathResult, error) {
+	return redisStorage.updateJSONMatches(storageKey, jsonPath, func(jsonDocument *RedisJSONDocument, pathMatch jsonPathMatch) JSONPathResult {
+		jsonArray, isArray := pathMatch.matchedValue.(*JSONArray)
+		if !isArray {
+			return JSONPathResult{}
+		}
+		arrayLength := len(jsonArray.Elements)
+		if arrayLength == 0 {
+			return JSONPathResult{Applicable: true}
+		}
+		popIndex := elementIndex
+		if popIndex < 0 {
+			popIndex += arrayLength
+		}
+		popIndex = min(max(popIndex, 0), arrayLength-1)
+
+		poppedValue := jsonArray.Elements[popIndex]
+		jsonArray.Elements = append(jsonArray.Elements[:popIndex], jsonArray.Elements[popIndex+1:]...)
+		return JSONPathResult{Applicable: true, Value: poppedValue, Length: arrayLength}
+	})
+}
+
+// AppendJSONStrings ajoute un suffixe à chaque chaîne désignée par le chemin (JSON.STRAPPEND)
+// Length est la nouvelle longueur en octets
+func (redisStorage *RedisInMemoryStorage) AppendJSONStrings(storageKey string, jsonPath *JSONPath, stringSuffix string) ([]JSONPathResult, error) {
+	return redisStorage.updateJSONMatches(storageKey, jsonPath, func(jsonDocument *RedisJSONDocument, pathMatch jsonPathMatch) JSONPathResult {
+		currentString, isString := pathMatch.matchedValue.(string)
+		if !isString {
+			return JSONPathResult{}
+		}
+		replaceJSONMatch(jsonDocument, pathMatch, currentString+stringSuffix)
+		return JSONPathResult{Applicable: true, Length: len(currentString) + len(stringSuffix)}
+	})
+}
+
+// GetJSONArrayLengths retourne la longueur de chaque tableau désigné par le chemin (JSON.ARRLEN)
+func (redisStorage *RedisInMemoryStorage) GetJSONArrayLengths(storageKey string, jsonPath *JSONPath) ([]JSONPathResult, bool, error) {
+	return redisStorage.readJSONMatches(storageKey, jsonPath, func(matchedValue interface{}) JSONPathResult {
+		jsonArray, isArray := matchedValue.(*JSONArray)
+		if !isArray {
+			return JSONPathResult{}
+		}
+		return JSONPathResult{Applicable: true, Length: len(jsonArray.Elements)}
+	})
+}
+
+// GetJSONObjectKeys retourne les noms des membres de chaque objet désigné par le chemin (JSON.OBJKEYS)
+func (redisStorage *RedisInMemoryStorage) GetJSONObjectKeys(storageKey string, jsonPath *JSONPath) ([]JSONPathResult, bool, error) {
+	return redisStorage.readJSONMatches(storageKey, jsonPath, func(matchedValue interface{}) JSONPathResult {
+		jsonObject, isObject := matchedValue.(*JSONObject)
+		if !isObject {
+			return JSONPathResult{}
+		}
+		memberNames := append(make([]string, 0, len(jsonObject.MemberNames)), jsonObject.MemberNames...)
+		return JSONPathResult{Applicable: true, Value: memberNames, Length: len(memberNames)}
+	})
+}
+
+// GetJSONTypes retourne le type de chaque valeur désignée par le chemin (JSON.TYPE)
+func (redisStorage *RedisInMemoryStorage) GetJSONTypes(storageKey string, jsonPath *JSONPath) ([]JSONPathResult, bool, error) {
+	return redisStorage.readJSONMatches(storageKey, jsonPath, func(matchedValue interface{}) JSONPathResult {
+		return JSONPathResult{Applicable: true, Value: JSONValueTypeName(matchedValue)}
+	})
+}
+
+// updateJSONMatches applique updateFunction à chaque valeur désignée par le chemin, sous verrou d'écriture
+// La clé doit exister ; le compteur de modifications n'avance que si au moins une valeur a été modifiée
+func (redisStorage *RedisInMemoryStorage) updateJSONMatches(storageKey string, jsonPath *JSONPath, updateFunction func(jsonDocument *RedisJSONDocument, pathMatch jsonPathMatch) JSONPathResult) ([]JSONPathResult, error) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	jsonDocument, lookupError := redisStorage.lookupExistingJSONDocument(storageKey)
+	if lookupError != nil {
+		return nil, lookupError
+	}
+
+	pathMatches := jsonPath.evaluate(jsonDocument.Root)
+	if len(pathMatches) == 0 {
+		return nil, nil
+	}
+	redisStorage.preserveValueForSnapshots(storageKey, redisStorage.storageData[storageKey])
+
+	pathResults := make([]JSONPathResult, len(pathMatches))
+	documentChanged := false
+	for matchIndex, pathMatch := range pathMatches {
+		pathResults[matchIndex] = updateFunction(jsonDocument, pathMatch)
+		documentChanged = documentChanged || pathResults[matchIndex].Applicable
+	}
+	if documentChanged {
+		redisStorage.incrementChanges()
+	}
+	return pathResults, nil
+}
+
+// readJSONMatches applique readFunction à chaque valeur désignée par le chemin, sous verrou de lecture
+// documentExists est faux si la clé n'existe pas
+func (redisStorage *RedisInMemoryStorage) readJSONMatches(storageKey string, jsonPath *JSONPath, readFunction func(matchedValue interface{}) JSONPathResult) ([]JSONPathResult, bool, error) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	jsonDocument, documentExists, isJSON := redisStorage.lookupJSONDocument(storageKey)
+	if !isJSON {
+		return nil, false, ErrJSONWrongType
+	}
+	if !documentExists {
+		return nil, false, nil
+	}
+
+	pathMatches := jsonPath.evaluate(jsonDocument.Root)
+	pathResults := make([]JSONPathResult, len(pathMatches))
+	for matchIndex, pathMatch := range pathMatches {
+		pathResults[matchIndex] = readFunction(pathMatch.matchedValue)
+	}
+	return pathResults, true, nil
+}
+
+// lookupJSONDocument retourne le document d'une clé ; isJSON est false si la clé contient un autre type
+func (redisStorage *RedisInMemoryStorage) lookupJSONDocument(storageKey string) (jsonDocument *RedisJSONDocument, documentExists bool, isJSON bool) {
+	storageValue, keyExists := redisStorage.storageData[storageKey]
+	if !keyExists || (storageValue.ExpirationTime != nil && time.Now().After(*storageValue.ExpirationTime)) {
+		return nil, false, true
+	}
+	if storageValue.DataType != RedisJSONType {
+		return nil, false, false
+	}
+	return storageValue.StoredData.(*RedisJSONDocument), true, true
+}
+
+// lookupExistingJSONDocument retourne le document d'une clé qui doit exister (commandes de modification)
+func (redisStorage *RedisInMemoryStorage) lookupExistingJSONDocument(storageKey string) (*RedisJSONDocument, error) {
+	jsonDocument, documentExists, isJSON := redisStorage.lookupJSONDocument(storageKey)
+	if !isJSON {
+		return nil, ErrJSONWrongType
+	}
+	if !documentExists {
+		return nil, ErrJSONKeyNotFound
+	}
+	return jsonDocument, nil
+}
+
+// cloneJSONMatches copie les valeurs trouvées pour qu'elles restent valides une fois le verrou relâché
+func cloneJSONMatches(pathMatches []jsonPathMatch) []interface{} {
+	clonedValues := make([]interface{}, len(pathMatches))
+	for matchIndex, pathMatch := range pathMatches {
+		clonedValues[matchIndex] = CloneJSONValue(pathMatch.matchedValue)
+	}
+	return clonedValues
+}
@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrJSONInvalidPath signale un chemin dont la syntaxe n'est pas reconnue
+var ErrJSONInvalidPath = errors.New("syntaxe de chemin JSON invalide")
+
+// jsonPathSegmentKind est le type de sélection d'un segment de chemin
+type jsonPathSegmentKind int
+
+const (
+	jsonPathMemberSegment   jsonPathSegmentKind = iota // .nom ou ['nom']
+	jsonPathIndexSegment                               // [n], négatif depuis la fin
+	jsonPathWildcardSegment                            // .* ou [*]
+)
+
+// jsonPathSegment est une étape du chemin ; recursiveDescent l'applique aussi à tous les descendants (..)
+type jsonPathSegment struct {
+	segmentKind      jsonPathSegmentKind
+	memberName       string
+	elementIndex     int
+	recursiveDescent bool
+}
+
+// JSONPath est un chemin analysé
+// Un chemin JSONPath commence par $ et ses commandes répondent pour chaque valeur trouvée ;
+// un chemin historique (., .a.b, a[0]) ne désigne qu'une valeur, comme dans RedisJSON 1
+type JSONPath struct {
+	PathText     string
+	IsLegacy     bool
+	pathSegments []jsonPathSegment
+}
+
+// jsonPathMatch est une valeur désignée par un chemin et l'emplacement qui la contient
+// container vaut nil pour la racine du document
+type jsonPathMatch struct {
+	container    interface{}
+	memberName   string
+	elementIndex int
+	matchedValue interface{}
+}
+
+// ParseJSONPath analyse un chemin JSONPath ($.a.b[0], $..nom, $.*) ou historique (., .a.b[0], a.b)
+func ParseJSONPath(pathText string) (*JSONPath, error) {
+	jsonPath := &JSONPath{PathText: pathText}
+	remainingPath := pathText
+	if strings.HasPrefix(remainingPath, "$") {
+		remainingPath = remainingPath[1:]
+	} else {
+		jsonPath.IsLegacy = true
+		if remainingPath == "." {
+			return jsonPath, nil
+		}
+		if remainingPath != "" && remainingPath[0] != '.' && remainingPath[0] != '[' {
+			remainingPath = "." + remainingPath
+		}
+	}
+
+	for remainingPath != "" {
+		var pathSegment jsonPathSegment
+		switch {
+		case strings.HasPrefix(remainingPath, ".."):
+			pathSegment.recursiveDescent = true
+			remainingPath = remainingPath[2:]
+			if strings.HasPrefix(remainingPath, "[") {
+				break
+			}
+			fallthrough
+		case strings.HasPrefix(remainingPath, "."):
+			remainingPath = strings.TrimPrefix(remainingPath, ".")
+			nameLength := strings.IndexAny(remainingPath, ".[")
+			if nameLength < 0 {
+				nameLength = len(remainingPath)
+			}
+			if nameLength == 0 {
+				return nil, ErrJSONInvalidPath
+			}
+			pathSegment.segmentKind, pathSegment.memberName = jsonPathMemberSegment, remainingPath[:nameLength]
+			if pathSegment.memberName == "*" {
+				pathSegment.segmentKind = jsonPathWildcardSegment
+			}
+			jsonPath.pathSegments = append(jsonPath.pathSegments, pathSegment)
+			remainingPath = remainingPath[nameLength:]
+			continue
+		case !strings.HasPrefix(remainingPath, "["):
+			return nil, ErrJSONInvalidPath
+		}
+
+		selectorEnd, selectorSegment, selectorError := parseJSONPathSelector(remainingPath)
+		if selectorError != nil {
+			return nil, selectorError
+		}
+		selectorSegment.recursiveDescent = pathSegment.recursiveDescent
+		jsonPath.pathSegments = append(jsonPath.pathSegments, selectorSegment)
+		remainingPath = remainingPath[selectorEnd:]
+	}
+	return jsonPath, nil
+}
+
+// parseJSONPathSelector analyse un sélecteur entre crochets : [n], [*], ['nom'] ou ["nom"]
+// Retourne la position qui suit le crochet fermant
+func parseJSONPathSelector(remainingPath string) (int, jsonPathSegment, error) {
+	if len(remainingPath) > 1 && (remainingPath[1] == '\'' || remainingPath[1] == '"') {
+		quoteCharacter := remainingPath[1]
+		var memberName strings.Builder
+		for characterIndex := 2; characterIndex < len(remainingPath); characterIndex++ {
+			switch remainingPath[characterIndex] {
+			case '\\':
+				characterIndex++
+				if characterIndex < len(remainingPath) {
+					memberName.WriteByte(remainingPath[characterIndex])
+				}
+			case quoteCharacter:
+				if characterIndex+1 >= len(remainingPath) || remainingPath[characterIndex+1] != ']' {
+					return 0, jsonPathSegment{}, ErrJSONInvalidPath
+				}
+				return characterIndex + 2, jsonPathSegment{segmentKind: jsonPathMemberSegment, memberName: memberName.String()}, nil
+			default:
+				memberName.WriteByte(remainingPath[characterIndex])
+			}
+		}
+		return 0, jsonPathSegment{}, ErrJSONInvalidPath
+	}
+
+	closingBracket := strings.IndexByte(remainingPath, ']')
+	if closingBracket < 0 {
+		return 0, jsonPathSegment{}, ErrJSONInvalidPath
+	}
+	selectorText := strings.TrimSpace(remainingPath[1:closingBracket])
+	if selectorText == "*" {
+		return closingBracket + 1, jsonPathSegment{segmentKind: jsonPathWildcardSegment}, nil
+	}
+	elementIndex, parseError := strconv.Atoi(selectorText)
+	if parseError != nil {
+		return 0, jsonPathSegment{}, ErrJSONInvalidPath
+	}
+	return closingBracket + 1, jsonPathSegment{segmentKind: jsonPathIndexSegment, elementIndex: elementIndex}, nil
+}
+
+// IsRoot indique si le chemin désigne la racine du document
+func (jsonPath *JSONPath) IsRoot() bool {
+	return len(jsonPath.pathSegments) == 0
+}
+
+// evaluate retourne les valeurs désignées par le chemin, dans l'ordre du document
+func (jsonPath *JSONPath) evaluate(rootValue interface{}) []jsonPathMatch {
+	return evaluateJSONPathSegments(jsonPath.pathSegments, []jsonPathMatch{{matchedValue: rootValue}})
+}
+
+// evaluateJSONPathSegments applique les segments un par un à partir des valeurs de départ
+func evaluateJSONPathSegments(pathSegments []jsonPathSegment, currentMatches []jsonPathMatch) []jsonPathMatch {
+	for _, pathSegment := range pathSegments {
+		var nextMatches []jsonPathMatch
+		for _, currentMatch := range currentMatches {
+			selectionRoots := []interface{}{currentMatch.matchedValue}
+			if pathSegment.recursiveDescent {
+				selectionRoots = collectJSONDescendants(currentMatch.matchedValue, nil)
+			}
+			for _, selectionRoot := range selectionRoots {
+				nextMatches = appendJSONSegmentMatches(nextMatches, selectionRoot, pathSegment)
+			}
+		}
+		currentMatches = nextMatches
+	}
+	return currentMatches
+}
+
+// collectJSONDescendants retourne la valeur puis tous ses descendants conteneurs, en profondeur d'abord
+func collectJSONDescendants(jsonValue interface{}, collectedValues []interface{}) []interface{} {
+	switch typedValue := jsonValue.(type) {
+	case *JSONObject:
+		collectedValues = append(collectedValues, typedValue)
+		for _, memberName := range typedValue.MemberNames {
+			collectedValues = collectJSONDescendants(typedValue.MemberValues[memberName], collectedValues)
+		}
+	case *JSONArray:
+		collectedValues = append(collectedValues, typedValue)
+		for _, elementValue := range typedValue.Elements {
+			collectedValues = collectJSONDescendants(elementValue, collectedValues)
+		}
+	}
+	return collectedValues
+}
+
+// appendJSONSegmentMatches ajoute les enfants de containerValue sélectionnés par le segment
+func appendJSONSegmentMatches(segmentMatches []jsonPathMatch, containerValue interface{}, pathSegment jsonPathSegment) []jsonPathMatch {
+	switch typedContainer := containerValue.(type) {
+	case *JSONObject:
+		switch pathSegment.segmentKind {
+		case jsonPathMemberSegment:
+			if memberValue, memberExists := typedContainer.MemberValues[pathSegment.memberName]; memberExists {
+				segmentMatches = append(segmentMatches, jsonPathMatch{container: typedContainer, memberName: pathSegment.memberName, matchedValue: memberValue})
+			}
+		case jsonPathWildcardSegment:
+			for _, memberName := range typedContainer.MemberNames {
+				segmentMatches = append(segmentMatches, jsonPathMatch{container: typedContainer, memberName: memberName, matchedValue: typedContainer.MemberValues[memberName]})
+			}
+		}
+	case *JSONArray:
+		switch pathSegment.segmentKind {
+		case jsonPathIndexSegment:
+			elementIndex := pathSegment.elementIndex
+			if elementIndex < 0 {
+				elementIndex += len(typedContainer.Elements)
+			}
+			if elementIndex >= 0 && elementIndex < len(typedContainer.Elements) {
+				segmentMatches = append(segmentMatches, jsonPathMatch{container: typedContainer, elementIndex: elementIndex, matchedValue: typedContainer.Elements[elementIndex]})
+			}
+		case jsonPathWildcardSegment:
+			for elementIndex, elementValue := range typedContainer.Elements {
+				segmentMatches = append(segmentMatches, jsonPathMatch{container: typedContainer, elementIndex: elementIndex, matchedValue: elementValue})
+			}
+		}
+	}
+	return segmentMatches
+}
+
+// replaceJSONMatch remplace la valeur désignée par un résultat de chemin
+func replaceJSONMatch(jsonDocument *RedisJSONDocument, pathMatch jsonPathMatch, newValue interface{}) {
+	switch typedContainer := pathMatch.container.(type) {
+	case *JSONObject:
+		typedContainer.MemberValues[pathMatch.memberName] = newValue
+	case *JSONArray:
+		typedContainer.Elements[pathMatch.elementIndex] = newValue
+	default:
+		jsonDocument.Root = newValue
+	}
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// jsonPathTestDocument sert de document commun aux tests d'évaluation
+const jsonPathTestDocument = `{"nom":"a","tags":["x","y","z"],"magasin":{"nom":"b","livres":[{"nom":"c","prix":8},{"prix":12}]},"a.b":1}`
+
+// evaluateJSONPathText analyse un chemin et retourne les valeurs trouvées, sérialisées en JSON compact
+func evaluateJSONPathText(t *testing.T, pathText string) []string {
+	t.Helper()
+	rootValue, parseError := ParseJSONValue(jsonPathTestDocument)
+	if parseError != nil {
+		t.Fatalf("ParseJSONValue: %v", parseError)
+	}
+	jsonPath, pathError := ParseJSONPath(pathText)
+	if pathError != nil {
+		t.Fatalf("ParseJSONPath(%q): %v", pathText, pathError)
+	}
+	var serializedMatches []string
+	for _, pathMatch := range jsonPath.evaluate(rootValue) {
+		serializedMatches = append(serializedMatches, SerializeJSONValue(pathMatch.matchedValue, JSONFormat{}))
+	}
+	return serializedMatches
+}
+
+func TestParseJSONPathDistinguishesLegacyPaths(t *testing.T) {
+	testCases := []struct {
+		pathText       string
+		expectedLegacy bool
+		expectedRoot   bool
+	}{
+		{"$", false, true},
+		{".", true, true},
+		{"$.nom", false, false},
+		{".nom", true, false},
+		{"nom", true, false},
+		{"[0]", true, false},
+		{"$..nom", false, false},
+	}
+
+	for _, testCase := range testCases {
+		jsonPath, pathError := ParseJSONPath(testCase.pathText)
+		if pathError != nil {
+			t.Fatalf("ParseJSONPath(%q): %v", testCase.pathText, pathError)
+		}
+		if jsonPath.IsLegacy != testCase.expectedLegacy || jsonPath.IsRoot() != testCase.expectedRoot {
+			t.Errorf("%q: historique %v racine %v, attendu %v et %v", testCase.pathText, jsonPath.IsLegacy, jsonPath.IsRoot(), testCase.expectedLegacy, testCase.expectedRoot)
+		}
+	}
+}
+
+func TestParseJSONPathRejectsInvalidSyntax(t *testing.T) {
+	for _, pathText := range []string{"$.", "$.a.", "$..", "$[", "$[abc]", "$['nom'", "$['nom'x]", "$nom", "$.a[0"} {
+		if _, pathError := ParseJSONPath(pathText); !errors.Is(pathError, ErrJSONInvalidPath) {
+			t.Errorf("ParseJSONPath(%q): %v, attendu ErrJSONInvalidPath", pathText, pathError)
+		}
+	}
+}
+
+func TestJSONPathEvaluation(t *testing.T) {
+	testCases := []struct {
+		name            string
+		pathText        string
+		expectedMatches []string
+	}{
+		{"racine", "$", []string{jsonPathTestDocument}},
+		{"membre", "$.nom", []string{`"a"`}},
+		{"membre historique", "magasin.nom", []string{`"b"`}},
+		{"membre absent", "$.absent", nil},
+		{"indice", "$.tags[1]", []string{`"y"`}},
+		{"indice négatif", "$.tags[-1]", []string{`"z"`}},
+		{"indice hors limites", "$.tags[3]", nil},
+		{"indice sur un objet", "$.magasin[0]", nil},
+		{"membre sur un tableau", "$.tags.nom", nil},
+		{"joker de tableau", "$.tags[*]", []string{`"x"`, `"y"`, `"z"`}},
+		{"joker d'objet", "$.magasin.*", []string{`"b"`, `[{"nom":"c","prix":8},{"prix":12}]`}},
+		{"nom entre crochets", "$['a.b']", []string{"1"}},
+		{"nom entre guillemets échappé", `$["a\.b"]`, []string{"1"}},
+		{"chemin imbriqué", "$.magasin.livres[0].prix", []string{"8"}},
+		{"descente récursive", "$..nom", []string{`"a"`, `"b"`, `"c"`}},
+		{"descente récursive avec indice", "$..livres[1].prix", []string{"12"}},
+		{"descente récursive entre crochets", "$..[0]", []string{`"x"`, `{"nom":"c","prix":8}`}},
+		{"descente récursive jusqu'aux feuilles", "$..prix", []string{"8", "12"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if serializedMatches := evaluateJSONPathText(t, testCase.pathText); !reflect.DeepEqual(serializedMatches, testCase.expectedMatches) {
+				t.Fatalf("%s = %v, attendu %v", testCase.pathText, serializedMatches, testCase.expectedMatches)
+			}
+		})
+	}
+}
+
+func TestReplaceJSONMatchUpdatesContainer(t *testing.T) {
+	rootValue, parseError := ParseJSONValue(jsonPathTestDocument)
+	if parseError != nil {
+		t.Fatalf("ParseJSONValue: %v", parseError)
+	}
+	jsonDocument := &RedisJSONDocument{Root: rootValue}
+
+	for _, pathText := range []string{"$..prix", "$.tags[-1]"} {
+		jsonPath, _ := ParseJSONPath(pathText)
+		for _, pathMatch := range jsonPath.evaluate(jsonDocument.Root) {
+			replaceJSONMatch(jsonDocument, pathMatch, nil)
+		}
+	}
+	expectedDocument := `{"nom":"a","tags":["x","y",null],"magasin":{"nom":"b","livres":[{"nom":"c","prix":null},{"prix":null}]},"a.b":1}`
+	if serializedDocument := SerializeJSONValue(jsonDocument.Root, JSONFormat{}); serializedDocument != expectedDocument {
+		t.Fatalf("document %s, attendu %s", serializedDocument, expectedDocument)
+	}
+
+	rootPath, _ := ParseJSONPath("$")
+	replaceJSONMatch(jsonDocument, rootPath.evaluate(jsonDocument.Root)[0], "remplacée")
+	if jsonDocument.Root != "remplacée" {
+		t.Fatalf("racine %v après remplacement, attendu \"remplacée\"", jsonDocument.Root)
+	}
+}
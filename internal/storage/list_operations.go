@@ -21,6 +21,7 @@ func (redisStorage *RedisInMemoryStorage) PushElementsToList(listKey string, new
 		if storageValue.DataType != RedisListType {
 			return -1 // Erreur de type
 		}
+		redisStorage.preserveValueForSnapshots(listKey, storageValue)
 		redisListStructure = storageValue.StoredData.(*RedisListStructure)
 	}
 
@@ -54,6 +55,7 @@ func (redisStorage *RedisInMemoryStorage) PopElementFromList(listKey string, pop
 		return "", false
 	}
 
+	redisStorage.preserveValueForSnapshots(listKey, storageValue)
 	redisListStructure := storageValue.StoredData.(*RedisListStructure)
 	if len(redisListStructure.ListElements) == 0 {
 		return "", false
@@ -154,6 +156,7 @@ func (redisStorage *RedisInMemoryStorage) SetListElement(listKey string, index i
 		return -1 // Erreur de type
 	}
 
+	redisStorage.preserveValueForSnapshots(listKey, storageValue)
 	redisListStructure := storageValue.StoredData.(*RedisListStructure)
 	listLength := len(redisListStructure.ListElements)
 
@@ -186,6 +189,7 @@ func (redisStorage *RedisInMemoryStorage) RemoveListElements(listKey string, cou
 		return -1 // Erreur de type
 	}
 
+	redisStorage.preserveValueForSnapshots(listKey, storageValue)
 	redisListStructure := storageValue.StoredData.(*RedisListStructure)
 	originalElements := redisListStructure.ListElements
 	newElements := make([]string, 0, len(originalElements))
@@ -251,6 +255,7 @@ func (redisStorage *RedisInMemoryStorage) InsertIntoList(listKey string, insertB
 		return -1 // Erreur de type
 	}
 
+	redisStorage.preserveValueForSnapshots(listKey, storageValue)
 	redisListStructure := storageValue.StoredData.(*RedisListStructure)
 	originalElements := redisListStructure.ListElements
 
@@ -301,6 +306,7 @@ func (redisStorage *RedisInMemoryStorage) TrimList(listKey string, startIndex, s
 		return -1 // Erreur de type
 	}
 
+	redisStorage.preserveValueForSnapshots(listKey, storageValue)
 	redisListStructure := storageValue.StoredData.(*RedisListStructure)
 	listLength := len(redisListStructure.ListElements)
 
@@ -25,6 +25,35 @@ func (redisStorage *RedisInMemoryStorage) FindKeysByPattern(searchPattern string
 	return matchingKeys
 }
 
+// FindKeysMatching retourne les clés (non expirées) acceptées par keyFilter
+// maximumKeys limite le nombre de clés retournées (0 = toutes)
+func (redisStorage *RedisInMemoryStorage) FindKeysMatching(keyFilter func(storageKey string) bool, maximumKeys int) []string {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	var matchingKeys []string
+	currentTime := time.Now()
+
+	for storageKey, storageValue := range redisStorage.storageData {
+		if storageValue.ExpirationTime != nil && currentTime.After(*storageValue.ExpirationTime) {
+			continue
+		}
+		if keyFilter(storageKey) {
+			matchingKeys = append(matchingKeys, storageKey)
+			if maximumKeys > 0 && len(matchingKeys) >= maximumKeys {
+				break
+			}
+		}
+	}
+
+	return matchingKeys
+}
+
+// MatchGlobPattern expose le pattern matching style Redis aux autres packages (ACL, CONFIG GET...)
+func MatchGlobPattern(searchPattern, targetString string) bool {
+	return matchesGlobPattern(searchPattern, targetString)
+}
+
 // matchesGlobPattern implémente le pattern matching style Redis avec *, ?, et [...]
 func matchesGlobPattern(searchPattern, targetString string) bool {
 	return matchGlobRecursive(searchPattern, targetString, 0, 0)
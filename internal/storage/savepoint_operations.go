@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Erreurs retournées par les opérations sur les savepoints
+var (
+	ErrSavepointExists        = errors.New("un savepoint porte déjà ce nom")
+	ErrSavepointNotFound      = errors.New("savepoint inconnu")
+	ErrSavepointLimitExceeded = errors.New("nombre maximal de savepoints atteint")
+)
+
+// storageSavepoint est une image nommée du stockage (SNAPSHOT CREATE)
+// Comme un curseur de snapshot, elle ne contient que des pointeurs vers les valeurs : une valeur n'est
+// copiée que lorsqu'une écriture veut la modifier sur place (copy-on-write)
+type storageSavepoint struct {
+	savedValues    map[string]*RedisStorageValue
+	createdAt      time.Time
+	preservedCount int
+}
+
+// SavepointInfo décrit un savepoint pour SNAPSHOT LIST
+type SavepointInfo struct {
+	Name           string
+	CreatedAt      time.Time
+	KeyCount       int
+	PreservedCount int // Valeurs copiées parce qu'elles ont été modifiées depuis la création
+}
+
+// CreateSavepoint fige l'état courant du stockage sous un nom
+// Seuls les pointeurs sont copiés ; maximumSavepoints borne le nombre de savepoints conservés
+func (redisStorage *RedisInMemoryStorage) CreateSavepoint(savepointName string, maximumSavepoints int) error {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	if _, savepointExists := redisStorage.savepoints[savepointName]; savepointExists {
+		return ErrSavepointExists
+	}
+	if len(redisStorage.savepoints) >= maximumSavepoints {
+		return ErrSavepointLimitExceeded
+	}
+
+	currentTime := time.Now()
+	savepoint := &storageSavepoint{
+		savedValues: make(map[string]*RedisStorageValue, len(redisStorage.storageData)),
+		createdAt:   currentTime,
+	}
+	for storageKey, storageValue := range redisStorage.storageData {
+		if storageValue.ExpirationTime != nil && !currentTime.Before(*storageValue.ExpirationTime) {
+			continue
+		}
+		savepoint.savedValues[storageKey] = storageValue
+	}
+
+	if redisStorage.savepoints == nil {
+		redisStorage.savepoints = make(map[string]*storageSavepoint)
+	}
+	redisStorage.savepoints[savepointName] = savepoint
+	return nil
+}
+
+// RollbackToSavepoint remplace atomiquement tout le stockage par le contenu d'un savepoint
+// Les TTL sont relatifs : une clé qui avait 60 s à vivre à la création en a de nouveau 60 après le retour
+// Le savepoint est conservé et peut resservir ; retourne le nombre de clés restaurées
+func (redisStorage *RedisInMemoryStorage) RollbackToSavepoint(savepointName string) (int, error) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	savepoint, savepointExists := redisStorage.savepoints[savepointName]
+	if !savepointExists {
+		return 0, ErrSavepointNotFound
+	}
+
+	currentTime := time.Now()
+	restoredData := make(map[string]*RedisStorageValue, len(savepoint.savedValues))
+	for storageKey, savedValue := range savepoint.savedValues {
+		if savedValue.ExpirationTime == nil {
+			// Valeur partagée avec le savepoint : la prochaine modification sur place la copiera pour lui
+			restoredData[storageKey] = savedValue
+			continue
+		}
+		// Le TTL est recalculé sur une nouvelle valeur : celle du savepoint doit garder son expiration d'origine
+		restoredExpiration := currentTime.Add(savedValue.ExpirationTime.Sub(savepoint.createdAt))
+		restoredData[storageKey] = &RedisStorageValue{
+			StoredData:     copyStoredData(savedValue.StoredData, savedValue.DataType),
+			DataType:       savedValue.DataType,
+			ExpirationTime: &restoredExpiration,
+		}
+	}
+
+	redisStorage.storageData = restoredData
+	redisStorage.rebuildSearchIndexes()
+	redisStorage.incrementChanges()
+	return len(restoredData), nil
+}
+
+// DropSavepoint supprime un savepoint et retourne false s'il n'existait pas
+func (redisStorage *RedisInMemoryStorage) DropSavepoint(savepointName string) bool {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	_, savepointExists := redisStorage.savepoints[savepointName]
+	delete(redisStorage.savepoints, savepointName)
+	return savepointExists
+}
+
+// ListSavepoints retourne les savepoints du plus ancien au plus récent
+func (redisStorage *RedisInMemoryStorage) ListSavepoints() []SavepointInfo {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	savepointInfos := make([]SavepointInfo, 0, len(redisStorage.savepoints))
+	for savepointName, savepoint := range redisStorage.savepoints {
+		savepointInfos = append(savepointInfos, SavepointInfo{
+			Name:           savepointName,
+			CreatedAt:      savepoint.createdAt,
+			KeyCount:       len(savepoint.savedValues),
+			PreservedCount: savepoint.preservedCount,
+		})
+	}
+	sort.Slice(savepointInfos, func(firstIndex, secondIndex int) bool {
+		if !savepointInfos[firstIndex].CreatedAt.Equal(savepointInfos[secondIndex].CreatedAt) {
+			return savepointInfos[firstIndex].CreatedAt.Before(savepointInfos[secondIndex].CreatedAt)
+		}
+		return savepointInfos[firstIndex].Name < savepointInfos[secondIndex].Name
+	})
+	return savepointInfos
+}
@@ -0,0 +1,349 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Erreurs retournées par les opérations sur les index secondaires
+var (
+	ErrSearchIndexExists  = errors.New("l'index existe déjà")
+	ErrSearchIndexUnknown = errors.New("index inconnu")
+)
+
+// SearchFieldType est le type d'un champ du schéma d'un index (FT.CREATE ... SCHEMA)
+type SearchFieldType int
+
+const (
+	SearchTextField    SearchFieldType = iota // Texte découpé en mots, recherche plein texte
+	SearchTagField                            // Valeurs exactes séparées par TagSeparator, sans distinction de casse
+	SearchNumericField                        // Nombre, recherche par intervalle
+)
+
+// TypeName retourne le nom du type tel qu'écrit dans FT.CREATE
+func (fieldType SearchFieldType) TypeName() string {
+	switch fieldType {
+	case SearchTagField:
+		return "TAG"
+	case SearchNumericField:
+		return "NUMERIC"
+	default:
+		return "TEXT"
+	}
+}
+
+// SearchFieldDefinition décrit un champ de hash indexé
+type SearchFieldDefinition struct {
+	FieldName    string
+	FieldType    SearchFieldType
+	TagSeparator string // TAG uniquement, "," par défaut
+	Sortable     bool   // Accepté pour compatibilité : tout champ du schéma peut servir à SORTBY
+}
+
+// SearchIndexDefinition décrit un index : les hashes dont la clé commence par un des préfixes
+// (tous les hashes si la liste est vide) et les champs indexés
+type SearchIndexDefinition struct {
+	IndexName    string
+	KeyPrefixes  []string
+	SchemaFields []SearchFieldDefinition
+}
+
+// schemaField retourne la définition d'un champ du schéma
+func (indexDefinition *SearchIndexDefinition) schemaField(fieldName string) (SearchFieldDefinition, bool) {
+	for _, fieldDefinition := range indexDefinition.SchemaFields {
+		if fieldDefinition.FieldName == fieldName {
+			return fieldDefinition, true
+		}
+	}
+	return SearchFieldDefinition{}, false
+}
+
+// searchIndex est un index secondaire maintenu à chaque écriture sur un hash
+// Les entrées d'une clé supprimée sans passer par reindexHashKey (expiration paresseuse) restent jusqu'à la
+// prochaine écriture sur cette clé : la recherche ne retient que les clés qui sont encore des hashes vivants
+type searchIndex struct {
+	indexDefinition   SearchIndexDefinition
+	indexedDocuments  map[string]map[string]string              // Clé -> valeurs des champs du schéma lors de l'indexation
+	termPostings      map[string]map[string]map[string]struct{} // Champ TEXT ou TAG -> mot ou tag -> clés
+	numericFieldIndex map[string]*RedisSortedSetStructure       // Champ NUMERIC -> clés ordonnées par valeur
+}
+
+// newSearchIndex crée un index vide
+func newSearchIndex(indexDefinition SearchIndexDefinition) *searchIndex {
+	createdIndex := &searchIndex{
+		indexDefinition:   indexDefinition,
+		indexedDocuments:  make(map[string]map[string]string),
+		termPostings:      make(map[string]map[string]map[string]struct{}),
+		numericFieldIndex: make(map[string]*RedisSortedSetStructure),
+	}
+	for _, fieldDefinition := range indexDefinition.SchemaFields {
+		if fieldDefinition.FieldType == SearchNumericField {
+			createdIndex.numericFieldIndex[fieldDefinition.FieldName] = &RedisSortedSetStructure{MemberScores: make(map[string]float64)}
+		} else {
+			createdIndex.termPostings[fieldDefinition.FieldName] = make(map[string]map[string]struct{})
+		}
+	}
+	return createdIndex
+}
+
+// matchesKeyPrefix indique si une clé relève de l'index
+func (index *searchIndex) matchesKeyPrefix(storageKey string) bool {
+	if len(index.indexDefinition.KeyPrefixes) == 0 {
+		return true
+	}
+	for _, keyPrefix := range index.indexDefinition.KeyPrefixes {
+		if strings.HasPrefix(storageKey, keyPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldTerms retourne les mots (TEXT) ou les tags (TAG) d'une valeur de champ
+func fieldTerms(fieldDefinition SearchFieldDefinition, fieldValue string) []string {
+	if fieldDefinition.FieldType == SearchTagField {
+		return splitSearchTags(fieldValue, fieldDefinition.TagSeparator)
+	}
+	return tokenizeSearchText(fieldValue)
+}
+
+// tokenizeSearchText découpe un texte en mots en minuscules, sans doublons
+func tokenizeSearchText(textValue string) []string {
+	textWords := strings.FieldsFunc(strings.ToLower(textValue), func(character rune) bool {
+		return !unicode.IsLetter(character) && !unicode.IsDigit(character)
+	})
+	return uniqueSearchTerms(textWords)
+}
+
+// splitSearchTags découpe une valeur de champ TAG ; les tags sont comparés sans distinction de casse
+func splitSearchTags(tagValue string, tagSeparator string) []string {
+	var tagValues []string
+	for _, tagText := range strings.Split(tagValue, tagSeparator) {
+		if tagText = strings.ToLower(strings.TrimSpace(tagText)); tagText != "" {
+			tagValues = append(tagValues, tagText)
+		}
+	}
+	return uniqueSearchTerms(tagValues)
+}
+
+// uniqueSearchTerms retire les doublons en conservant l'ordre
+func uniqueSearchTerms(searchTerms []string) []string {
+	seenTerms := make(map[string]struct{}, len(searchTerms))
+	uniqueTerms := searchTerms[:0]
+	for _, searchTerm := range searchTerms {
+		if _, alreadySeen := seenTerms[searchTerm]; !alreadySeen {
+			seenTerms[searchTerm] = struct{}{}
+			uniqueTerms = append(uniqueTerms, searchTerm)
+		}
+	}
+	return uniqueTerms
+}
+
+// addDocument indexe les champs du schéma présents dans un hash
+// Une valeur non numérique d'un champ NUMERIC n'est pas indexée
+func (index *searchIndex) addDocument(storageKey string, hashFields map[string]string) {
+	indexedFields := make(map[string]string)
+	for _, fieldDefinition := range index.indexDefinition.SchemaFields {
+		fieldValue, fieldExists := hashFields[fieldDefinition.FieldName]
+		if !fieldExists {
+			continue
+		}
+		indexedFields[fieldDefinition.FieldName] = fieldValue
+
+		if fieldDefinition.FieldType == SearchNumericField {
+			if numericValue, parseError := strconv.ParseFloat(strings.TrimSpace(fieldValue), 64); parseError == nil {
+				index.numericFieldIndex[fieldDefinition.FieldName].setMemberScore(storageKey, numericValue)
+			}
+			continue
+		}
+		fieldPostings := index.termPostings[fieldDefinition.FieldName]
+		for _, searchTerm := range fieldTerms(fieldDefinition, fieldValue) {
+			if fieldPostings[searchTerm] == nil {
+				fieldPostings[searchTerm] = make(map[string]struct{})
+			}
+			fieldPostings[searchTerm][storageKey] = struct{}{}
+		}
+	}
+	index.indexedDocuments[storageKey] = indexedFields
+}
+
+// removeDocument retire une clé de l'index à partir des valeurs mémorisées lors de son indexation
+func (index *searchIndex) removeDocument(storageKey string) {
+	indexedFields, documentIndexed := index.indexedDocuments[storageKey]
+	if !documentIndexed {
+		return
+	}
+	for _, fieldDefinition := range index.indexDefinition.SchemaFields {
+		fieldValue, fieldExists := indexedFields[fieldDefinition.FieldName]
+		if !fieldExists {
+			continue
+		}
+		if fieldDefinition.FieldType == SearchNumericField {
+			index.numericFieldIndex[fieldDefinition.FieldName].removeMember(storageKey)
+			continue
+		}
+		fieldPostings := index.termPostings[fieldDefinition.FieldName]
+		for _, searchTerm := range fieldTerms(fieldDefinition, fieldValue) {
+			delete(fieldPostings[searchTerm], storageKey)
+			if len(fieldPostings[searchTerm]) == 0 {
+				delete(fieldPostings, searchTerm)
+			}
+		}
+	}
+	delete(index.indexedDocuments, storageKey)
+}
+
+// liveHashFields retourne les champs d'une clé si elle contient un hash non expiré (verrou déjà pris)
+func (redisStorage *RedisInMemoryStorage) liveHashFields(storageKey string, currentTime time.Time) (map[string]string, bool) {
+	storageValue, keyExists := redisStorage.storageData[storageKey]
+	if !keyExists || storageValue.DataType != RedisHashType {
+		return nil, false
+	}
+	if storageValue.ExpirationTime != nil && currentTime.After(*storageValue.ExpirationTime) {
+		return nil, false
+	}
+	return storageValue.StoredData.(*RedisHashStructure).HashFields, true
+}
+
+// reindexHashKey met les index à jour après une écriture sur une clé (verrou en écriture déjà pris)
+// La clé est retirée de chaque index puis indexée de nouveau si elle contient toujours un hash
+func (redisStorage *RedisInMemoryStorage) reindexHashKey(storageKey string) {
+	if len(redisStorage.searchIndexes) == 0 {
+		return
+	}
+	hashFields, isLiveHash := redisStorage.liveHashFields(storageKey, time.Now())
+	for _, index := range redisStorage.searchIndexes {
+		index.removeDocument(storageKey)
+		if isLiveHash && index.matchesKeyPrefix(storageKey) {
+			index.addDocument(storageKey, hashFields)
+		}
+	}
+}
+
+// populateSearchIndex indexe tous les hashes existants (verrou en écriture déjà pris)
+func (redisStorage *RedisInMemoryStorage) populateSearchIndex(index *searchIndex) {
+	currentTime := time.Now()
+	for storageKey := range redisStorage.storageData {
+		if !index.matchesKeyPrefix(storageKey) {
+			continue
+		}
+		if hashFields, isLiveHash := redisStorage.liveHashFields(storageKey, currentTime); isLiveHash {
+			index.addDocument(storageKey, hashFields)
+		}
+	}
+}
+
+// rebuildSearchIndexes reconstruit les index après un remplacement complet des données
+// (FLUSHALL, retour à un savepoint) ; verrou en écriture déjà pris
+func (redisStorage *RedisInMemoryStorage) rebuildSearchIndexes() {
+	for indexName, index := range redisStorage.searchIndexes {
+		rebuiltIndex := newSearchIndex(index.indexDefinition)
+		redisStorage.populateSearchIndex(rebuiltIndex)
+		redisStorage.searchIndexes[indexName] = rebuiltIndex
+	}
+}
+
+// CreateSearchIndex crée un index et y ajoute les hashes déjà présents
+func (redisStorage *RedisInMemoryStorage) CreateSearchIndex(indexDefinition SearchIndexDefinition) error {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	if _, indexExists := redisStorage.searchIndexes[indexDefinition.IndexName]; indexExists {
+		return ErrSearchIndexExists
+	}
+	if redisStorage.searchIndexes == nil {
+		redisStorage.searchIndexes = make(map[string]*searchIndex)
+	}
+	createdIndex := newSearchIndex(indexDefinition)
+	redisStorage.populateSearchIndex(createdIndex)
+	redisStorage.searchIndexes[indexDefinition.IndexName] = createdIndex
+	return nil
+}
+
+// DropSearchIndex supprime un index ; avec deleteDocuments (DD), les hashes indexés sont supprimés aussi
+func (redisStorage *RedisInMemoryStorage) DropSearchIndex(indexName string, deleteDocuments bool) error {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	droppedIndex, indexExists := redisStorage.searchIndexes[indexName]
+	if !indexExists {
+		return ErrSearchIndexUnknown
+	}
+	delete(redisStorage.searchIndexes, indexName)
+	if !deleteDocuments {
+		return nil
+	}
+
+	currentTime := time.Now()
+	for storageKey := range droppedIndex.indexedDocuments {
+		if _, isLiveHash := redisStorage.liveHashFields(storageKey, currentTime); !isLiveHash {
+			continue
+		}
+		delete(redisStorage.storageData, storageKey)
+		redisStorage.reindexHashKey(storageKey)
+		redisStorage.incrementChanges()
+	}
+	return nil
+}
+
+// ListSearchIndexes retourne les noms des index, triés
+func (redisStorage *RedisInMemoryStorage) ListSearchIndexes() []string {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	indexNames := make([]string, 0, len(redisStorage.searchIndexes))
+	for indexName := range redisStorage.searchIndexes {
+		indexNames = append(indexNames, indexName)
+	}
+	sort.Strings(indexNames)
+	return indexNames
+}
+
+// GetSearchIndexInfo retourne la définition d'un index et le nombre de hashes qu'il contient
+func (redisStorage *RedisInMemoryStorage) GetSearchIndexInfo(indexName string) (SearchIndexDefinition, int, error) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	index, indexExists := redisStorage.searchIndexes[indexName]
+	if !indexExists {
+		return SearchIndexDefinition{}, 0, ErrSearchIndexUnknown
+	}
+	documentCount := 0
+	currentTime := time.Now()
+	for storageKey := range index.indexedDocuments {
+		if _, isLiveHash := redisStorage.liveHashFields(storageKey, currentTime); isLiveHash {
+			documentCount++
+		}
+	}
+	return index.indexDefinition, documentCount, nil
+}
+
+// searchIndexDefinitions retourne les définitions des index, triées par nom (verrou déjà pris)
+// Les définitions ne sont jamais modifiées après FT.CREATE : elles peuvent être partagées
+func (redisStorage *RedisInMemoryStorage) searchIndexDefinitions() []SearchIndexDefinition {
+	if len(redisStorage.searchIndexes) == 0 {
+		return nil
+	}
+	indexDefinitions := make([]SearchIndexDefinition, 0, len(redisStorage.searchIndexes))
+	for _, index := range redisStorage.searchIndexes {
+		indexDefinitions = append(indexDefinitions, index.indexDefinition)
+	}
+	sort.Slice(indexDefinitions, func(leftIndex, rightIndex int) bool {
+		return indexDefinitions[leftIndex].IndexName < indexDefinitions[rightIndex].IndexName
+	})
+	return indexDefinitions
+}
+
+// replaceSearchIndexes remplace les index par ceux d'un snapshot chargé et les remplit (verrou déjà pris)
+func (redisStorage *RedisInMemoryStorage) replaceSearchIndexes(indexDefinitions []SearchIndexDefinition) {
+	redisStorage.searchIndexes = make(map[string]*searchIndex, len(indexDefinitions))
+	for _, indexDefinition := range indexDefinitions {
+		loadedIndex := newSearchIndex(indexDefinition)
+		redisStorage.populateSearchIndex(loadedIndex)
+		redisStorage.searchIndexes[indexDefinition.IndexName] = loadedIndex
+	}
+}
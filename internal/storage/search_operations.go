@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SearchOptions regroupe les options de FT.SEARCH
+type SearchOptions struct {
+	SortFieldName  string // Vide : résultats dans l'ordre des clés
+	SortDescending bool
+	ResultOffset   int
+	ResultLimit    int
+	ReturnFields   []string                     // nil : tous les champs du hash
+	NoContent      bool                         // Clés seulement, sans champs
+	KeyFilter      func(storageKey string) bool // nil : toutes les clés ; sinon seules les clés acceptées sont retournées et comptées
+}
+
+// SearchDocumentField est un champ retourné pour un résultat
+type SearchDocumentField struct {
+	FieldName  string
+	FieldValue string
+}
+
+// SearchDocument est un hash trouvé par FT.SEARCH
+type SearchDocument struct {
+	Key            string
+	DocumentFields []SearchDocumentField
+}
+
+// SearchResult contient le nombre total de hashes trouvés et la page demandée (LIMIT)
+type SearchResult struct {
+	TotalCount int
+	Documents  []SearchDocument
+}
+
+// SearchIndex exécute une requête sur un index
+// Les clés candidates sont lues dans l'index, puis seules celles qui contiennent encore un hash non expiré sont retenues
+func (redisStorage *RedisInMemoryStorage) SearchIndex(indexName string, searchQuery *SearchQuery, searchOptions SearchOptions) (SearchResult, error) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	index, indexExists := redisStorage.searchIndexes[indexName]
+	if !indexExists {
+		return SearchResult{}, ErrSearchIndexUnknown
+	}
+	if validationError := searchQuery.rootNode.validateFields(&index.indexDefinition); validationError != nil {
+		return SearchResult{}, validationError
+	}
+	var sortField SearchFieldDefinition
+	if searchOptions.SortFieldName != "" {
+		var fieldExists bool
+		if sortField, fieldExists = index.indexDefinition.schemaField(searchOptions.SortFieldName); !fieldExists {
+			return SearchResult{}, fmt.Errorf("champ de tri '%s' absent du schéma de l'index", searchOptions.SortFieldName)
+		}
+	}
+
+	currentTime := time.Now()
+	matchedHashes := make(map[string]map[string]string)
+	for storageKey := range searchQuery.rootNode.matchingKeys(index) {
+		if searchOptions.KeyFilter != nil && !searchOptions.KeyFilter(storageKey) {
+			continue
+		}
+		if hashFields, isLiveHash := redisStorage.liveHashFields(storageKey, currentTime); isLiveHash {
+			matchedHashes[storageKey] = hashFields
+		}
+	}
+	matchedKeys := make([]string, 0, len(matchedHashes))
+	for storageKey := range matchedHashes {
+		matchedKeys = append(matchedKeys, storageKey)
+	}
+	if searchOptions.SortFieldName == "" {
+		sort.Strings(matchedKeys)
+	} else {
+		sortSearchResults(matchedKeys, matchedHashes, sortField, searchOptions.SortDescending)
+	}
+
+	searchResult := SearchResult{TotalCount: len(matchedKeys)}
+	pageStart := min(max(searchOptions.ResultOffset, 0), len(matchedKeys))
+	pageEnd := min(pageStart+max(searchOptions.ResultLimit, 0), len(matchedKeys))
+	for _, storageKey := range matchedKeys[pageStart:pageEnd] {
+		searchResult.Documents = append(searchResult.Documents, buildSearchDocument(storageKey, matchedHashes[storageKey], searchOptions))
+	}
+	return searchResult, nil
+}
+
+// sortSearchResults trie les clés selon un champ : numériquement pour un champ NUMERIC, sans distinction
+// de casse sinon ; les hashes sans valeur exploitable viennent en dernier, les égalités sont départagées par la clé
+func sortSearchResults(matchedKeys []string, matchedHashes map[string]map[string]string, sortField SearchFieldDefinition, sortDescending bool) {
+	type sortableValue struct {
+		hasValue     bool
+		numericValue float64
+		textValue    string
+	}
+	sortValues := make(map[string]sortableValue, len(matchedKeys))
+	for _, storageKey := range matchedKeys {
+		fieldValue, fieldExists := matchedHashes[storageKey][sortField.FieldName]
+		if !fieldExists {
+			continue
+		}
+		if sortField.FieldType == SearchNumericField {
+			if numericValue, parseError := strconv.ParseFloat(strings.TrimSpace(fieldValue), 64); parseError == nil {
+				sortValues[storageKey] = sortableValue{hasValue: true, numericValue: numericValue}
+			}
+			continue
+		}
+		sortValues[storageKey] = sortableValue{hasValue: true, textValue: strings.ToLower(fieldValue)}
+	}
+
+	sort.Slice(matchedKeys, func(leftIndex, rightIndex int) bool {
+		leftValue, rightValue := sortValues[matchedKeys[leftIndex]], sortValues[matchedKeys[rightIndex]]
+		if leftValue.hasValue != rightValue.hasValue {
+			return leftValue.hasValue
+		}
+		if leftValue.hasValue {
+			comparison := 0
+			if sortField.FieldType == SearchNumericField {
+				if leftValue.numericValue < rightValue.numericValue {
+					comparison = -1
+				} else if leftValue.numericValue > rightValue.numericValue {
+					comparison = 1
+				}
+			} else {
+				comparison = strings.Compare(leftValue.textValue, rightValue.textValue)
+			}
+			if sortDescending {
+				comparison = -comparison
+			}
+			if comparison != 0 {
+				return comparison < 0
+			}
+		}
+		return matchedKeys[leftIndex] < matchedKeys[rightIndex]
+	})
+}
+
+// buildSearchDocument copie les champs demandés d'un hash trouvé (RETURN) ; sans RETURN, tous les champs
+// sont retournés dans l'ordre alphabétique
+func buildSearchDocument(storageKey string, hashFields map[string]string, searchOptions SearchOptions) SearchDocument {
+	searchDocument := SearchDocument{Key: storageKey}
+	if searchOptions.NoContent {
+		return searchDocument
+	}
+
+	returnedFields := searchOptions.ReturnFields
+	if returnedFields == nil {
+		returnedFields = make([]string, 0, len(hashFields))
+		for fieldName := range hashFields {
+			returnedFields = append(returnedFields, fieldName)
+		}
+		sort.Strings(returnedFields)
+	}
+	searchDocument.DocumentFields = []SearchDocumentField{}
+	for _, fieldName := range returnedFields {
+		if fieldValue, fieldExists := hashFields[fieldName]; fieldExists {
+			searchDocument.DocumentFields = append(searchDocument.DocumentFields, SearchDocumentField{FieldName: fieldName, FieldValue: fieldValue})
+		}
+	}
+	return searchDocument
+}
@@ -0,0 +1,395 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrSearchQuerySyntax signale une requête FT.SEARCH mal formée
+var ErrSearchQuerySyntax = errors.New("erreur de syntaxe dans la requête")
+
+// searchQueryNodeKind est le type d'un nœud de requête
+type searchQueryNodeKind int
+
+const (
+	searchMatchAllNode     searchQueryNodeKind = iota // *
+	searchIntersectionNode                            // a b : tous les enfants
+	searchUnionNode                                   // a | b : au moins un enfant
+	searchNegationNode                                // -a : tout sauf l'enfant
+	searchTextTermNode                                // mot, dans un champ TEXT ou dans tous
+	searchTagFilterNode                               // @champ:{a | b}
+	searchNumericRangeNode                            // @champ:[min max]
+)
+
+// searchQueryNode est un nœud de l'arbre d'une requête analysée
+type searchQueryNode struct {
+	nodeKind      searchQueryNodeKind
+	childNodes    []*searchQueryNode
+	fieldName     string   // Vide pour un mot cherché dans tous les champs TEXT
+	searchTerms   []string // Mot (un seul) ou tags acceptés
+	minimumValue  float64
+	maximumValue  float64
+	minimumIsOpen bool // ( devant la borne : exclue
+	maximumIsOpen bool
+}
+
+// SearchQuery est une requête FT.SEARCH analysée
+// Syntaxe : des termes juxtaposés doivent tous correspondre (ET), | sépare des alternatives (OU, moins
+// prioritaire que ET), les parenthèses groupent et - exclut ; un terme est un mot, @champ:mot,
+// @champ:(mots), @champ:{tag | tag}, @champ:[min max] (bornes -inf, +inf, ( pour exclure) ou *
+type SearchQuery struct {
+	QueryText string
+	rootNode  *searchQueryNode
+}
+
+// searchQueryParser lit une requête caractère par caractère
+type searchQueryParser struct {
+	queryRunes    []rune
+	queryPosition int
+}
+
+// ParseSearchQuery analyse le texte d'une requête
+func ParseSearchQuery(queryText string) (*SearchQuery, error) {
+	queryParser := &searchQueryParser{queryRunes: []rune(queryText)}
+	rootNode, parseError := queryParser.parseUnion("")
+	if parseError != nil {
+		return nil, parseError
+	}
+	if queryParser.skipSpaces(); queryParser.queryPosition < len(queryParser.queryRunes) {
+		return nil, queryParser.syntaxError()
+	}
+	return &SearchQuery{QueryText: queryText, rootNode: rootNode}, nil
+}
+
+// syntaxError décrit la position où l'analyse s'est arrêtée
+func (queryParser *searchQueryParser) syntaxError() error {
+	return fmt.Errorf("%w à la position %d", ErrSearchQuerySyntax, queryParser.queryPosition+1)
+}
+
+// skipSpaces avance jusqu'au prochain caractère significatif
+func (queryParser *searchQueryParser) skipSpaces() {
+	for queryParser.queryPosition < len(queryParser.queryRunes) && unicode.IsSpace(queryParser.queryRunes[queryParser.queryPosition]) {
+		queryParser.queryPosition++
+	}
+}
+
+// peekRune retourne le prochain caractère significatif sans le consommer (0 en fin de requête)
+func (queryParser *searchQueryParser) peekRune() rune {
+	queryParser.skipSpaces()
+	if queryParser.queryPosition >= len(queryParser.queryRunes) {
+		return 0
+	}
+	return queryParser.queryRunes[queryParser.queryPosition]
+}
+
+// expectRune consomme un caractère attendu
+func (queryParser *searchQueryParser) expectRune(expectedRune rune) error {
+	if queryParser.peekRune() != expectedRune {
+		return queryParser.syntaxError()
+	}
+	queryParser.queryPosition++
+	return nil
+}
+
+// parseUnion lit des intersections séparées par | ; fieldScope est le champ imposé aux mots (@champ:(...))
+func (queryParser *searchQueryParser) parseUnion(fieldScope string) (*searchQueryNode, error) {
+	unionNode := &searchQueryNode{nodeKind: searchUnionNode}
+	for {
+		intersectionNode, parseError := queryParser.parseIntersection(fieldScope)
+		if parseError != nil {
+			return nil, parseError
+		}
+		unionNode.childNodes = append(unionNode.childNodes, intersectionNode)
+		if queryParser.peekRune() != '|' {
+			break
+		}
+		queryParser.queryPosition++
+	}
+	if len(unionNode.childNodes) == 1 {
+		return unionNode.childNodes[0], nil
+	}
+	return unionNode, nil
+}
+
+// parseIntersection lit des termes juxtaposés jusqu'à |, ) ou la fin de la requête
+func (queryParser *searchQueryParser) parseIntersection(fieldScope string) (*searchQueryNode, error) {
+	intersectionNode := &searchQueryNode{nodeKind: searchIntersectionNode}
+	for {
+		switch queryParser.peekRune() {
+		case 0, '|', ')':
+			if len(intersectionNode.childNodes) == 0 {
+				return nil, queryParser.syntaxError()
+			}
+			if len(intersectionNode.childNodes) == 1 {
+				return intersectionNode.childNodes[0], nil
+			}
+			return intersectionNode, nil
+		}
+		termNode, parseError := queryParser.parseTerm(fieldScope)
+		if parseError != nil {
+			return nil, parseError
+		}
+		intersectionNode.childNodes = append(intersectionNode.childNodes, termNode)
+	}
+}
+
+// parseTerm lit un terme : -terme, (requête), *, @champ:filtre ou mot
+func (queryParser *searchQueryParser) parseTerm(fieldScope string) (*searchQueryNode, error) {
+	switch queryParser.peekRune() {
+	case '-':
+		queryParser.queryPosition++
+		negatedNode, parseError := queryParser.parseTerm(fieldScope)
+		if parseError != nil {
+			return nil, parseError
+		}
+		return &searchQueryNode{nodeKind: searchNegationNode, childNodes: []*searchQueryNode{negatedNode}}, nil
+	case '(':
+		queryParser.queryPosition++
+		groupNode, parseError := queryParser.parseUnion(fieldScope)
+		if parseError != nil {
+			return nil, parseError
+		}
+		return groupNode, queryParser.expectRune(')')
+	case '*':
+		queryParser.queryPosition++
+		return &searchQueryNode{nodeKind: searchMatchAllNode}, nil
+	case '@':
+		queryParser.queryPosition++
+		return queryParser.parseFieldFilter()
+	}
+
+	searchWord := queryParser.readWord()
+	if searchWord == "" {
+		return nil, queryParser.syntaxError()
+	}
+	return &searchQueryNode{nodeKind: searchTextTermNode, fieldName: fieldScope, searchTerms: []string{strings.ToLower(searchWord)}}, nil
+}
+
+// readWord lit une suite de lettres et de chiffres
+func (queryParser *searchQueryParser) readWord() string {
+	wordStart := queryParser.queryPosition
+	for queryParser.queryPosition < len(queryParser.queryRunes) {
+		character := queryParser.queryRunes[queryParser.queryPosition]
+		if !unicode.IsLetter(character) && !unicode.IsDigit(character) {
+			break
+		}
+		queryParser.queryPosition++
+	}
+	return string(queryParser.queryRunes[wordStart:queryParser.queryPosition])
+}
+
+// readUntil lit jusqu'au caractère fermant (exclu) et le consomme ; \ échappe le caractère suivant
+func (queryParser *searchQueryParser) readUntil(closingRune rune) (string, error) {
+	var readText strings.Builder
+	for queryParser.queryPosition < len(queryParser.queryRunes) {
+		character := queryParser.queryRunes[queryParser.queryPosition]
+		queryParser.queryPosition++
+		switch character {
+		case closingRune:
+			return readText.String(), nil
+		case '\\':
+			if queryParser.queryPosition < len(queryParser.queryRunes) {
+				readText.WriteRune(queryParser.queryRunes[queryParser.queryPosition])
+				queryParser.queryPosition++
+			}
+		default:
+			readText.WriteRune(character)
+		}
+	}
+	return "", queryParser.syntaxError()
+}
+
+// parseFieldFilter lit ce qui suit @ : nom du champ, deux-points puis {tags}, [intervalle], (requête) ou mot
+func (queryParser *searchQueryParser) parseFieldFilter() (*searchQueryNode, error) {
+	fieldStart := queryParser.queryPosition
+	for queryParser.queryPosition < len(queryParser.queryRunes) && queryParser.queryRunes[queryParser.queryPosition] != ':' {
+		if unicode.IsSpace(queryParser.queryRunes[queryParser.queryPosition]) {
+			return nil, queryParser.syntaxError()
+		}
+		queryParser.queryPosition++
+	}
+	fieldName := string(queryParser.queryRunes[fieldStart:queryParser.queryPosition])
+	if fieldName == "" || queryParser.queryPosition >= len(queryParser.queryRunes) {
+		return nil, queryParser.syntaxError()
+	}
+	queryParser.queryPosition++
+
+	switch queryParser.peekRune() {
+	case '{':
+		queryParser.queryPosition++
+		tagList, readError := queryParser.readUntil('}')
+		if readError != nil {
+			return nil, readError
+		}
+		tagValues := splitSearchTags(tagList, "|")
+		if len(tagValues) == 0 {
+			return nil, queryParser.syntaxError()
+		}
+		return &searchQueryNode{nodeKind: searchTagFilterNode, fieldName: fieldName, searchTerms: tagValues}, nil
+	case '[':
+		queryParser.queryPosition++
+		rangeText, readError := queryParser.readUntil(']')
+		if readError != nil {
+			return nil, readError
+		}
+		return parseSearchNumericRange(fieldName, rangeText)
+	case '(':
+		queryParser.queryPosition++
+		groupNode, parseError := queryParser.parseUnion(fieldName)
+		if parseError != nil {
+			return nil, parseError
+		}
+		return groupNode, queryParser.expectRune(')')
+	}
+
+	searchWord := queryParser.readWord()
+	if searchWord == "" {
+		return nil, queryParser.syntaxError()
+	}
+	return &searchQueryNode{nodeKind: searchTextTermNode, fieldName: fieldName, searchTerms: []string{strings.ToLower(searchWord)}}, nil
+}
+
+// parseSearchNumericRange analyse « min max » ; une borne précédée de ( est exclue
+func parseSearchNumericRange(fieldName string, rangeText string) (*searchQueryNode, error) {
+	rangeBounds := strings.Fields(rangeText)
+	if len(rangeBounds) != 2 {
+		return nil, fmt.Errorf("%w : intervalle numérique [%s] invalide (attendu: [min max])", ErrSearchQuerySyntax, rangeText)
+	}
+	rangeNode := &searchQueryNode{nodeKind: searchNumericRangeNode, fieldName: fieldName}
+	var minimumError, maximumError error
+	rangeNode.minimumValue, rangeNode.minimumIsOpen, minimumError = parseSearchRangeBound(rangeBounds[0])
+	rangeNode.maximumValue, rangeNode.maximumIsOpen, maximumError = parseSearchRangeBound(rangeBounds[1])
+	if minimumError != nil || maximumError != nil {
+		return nil, fmt.Errorf("%w : borne numérique invalide dans [%s]", ErrSearchQuerySyntax, rangeText)
+	}
+	return rangeNode, nil
+}
+
+// parseSearchRangeBound lit une borne : nombre, -inf, +inf, éventuellement précédée de (
+func parseSearchRangeBound(boundText string) (float64, bool, error) {
+	boundIsOpen := strings.HasPrefix(boundText, "(")
+	boundText = strings.TrimPrefix(boundText, "(")
+	switch strings.ToLower(boundText) {
+	case "-inf":
+		return math.Inf(-1), boundIsOpen, nil
+	case "+inf", "inf":
+		return math.Inf(1), boundIsOpen, nil
+	}
+	boundValue, parseError := strconv.ParseFloat(boundText, 64)
+	if parseError != nil || math.IsNaN(boundValue) {
+		return 0, false, ErrSearchQuerySyntax
+	}
+	return boundValue, boundIsOpen, nil
+}
+
+// validateFields vérifie que chaque champ nommé dans la requête existe dans le schéma avec le bon type
+func (queryNode *searchQueryNode) validateFields(indexDefinition *SearchIndexDefinition) error {
+	for _, childNode := range queryNode.childNodes {
+		if validationError := childNode.validateFields(indexDefinition); validationError != nil {
+			return validationError
+		}
+	}
+
+	var expectedType SearchFieldType
+	switch queryNode.nodeKind {
+	case searchTextTermNode:
+		if queryNode.fieldName == "" {
+			return nil
+		}
+		expectedType = SearchTextField
+	case searchTagFilterNode:
+		expectedType = SearchTagField
+	case searchNumericRangeNode:
+		expectedType = SearchNumericField
+	default:
+		return nil
+	}
+	fieldDefinition, fieldExists := indexDefinition.schemaField(queryNode.fieldName)
+	if !fieldExists {
+		return fmt.Errorf("champ '%s' absent du schéma de l'index", queryNode.fieldName)
+	}
+	if fieldDefinition.FieldType != expectedType {
+		return fmt.Errorf("le champ '%s' est de type %s, %s attendu par ce filtre", queryNode.fieldName, fieldDefinition.FieldType.TypeName(), expectedType.TypeName())
+	}
+	return nil
+}
+
+// matchingKeys retourne les clés de l'index qui satisfont le nœud
+func (queryNode *searchQueryNode) matchingKeys(index *searchIndex) map[string]struct{} {
+	matchedKeys := make(map[string]struct{})
+	switch queryNode.nodeKind {
+	case searchMatchAllNode:
+		for storageKey := range index.indexedDocuments {
+			matchedKeys[storageKey] = struct{}{}
+		}
+	case searchIntersectionNode:
+		// Les négations sont appliquées en dernier, en retirant leurs clés du résultat
+		var negatedNodes []*searchQueryNode
+		firstPositiveNode := true
+		for _, childNode := range queryNode.childNodes {
+			if childNode.nodeKind == searchNegationNode {
+				negatedNodes = append(negatedNodes, childNode.childNodes[0])
+				continue
+			}
+			childKeys := childNode.matchingKeys(index)
+			if firstPositiveNode {
+				matchedKeys, firstPositiveNode = childKeys, false
+				continue
+			}
+			for storageKey := range matchedKeys {
+				if _, keyMatched := childKeys[storageKey]; !keyMatched {
+					delete(matchedKeys, storageKey)
+				}
+			}
+		}
+		if firstPositiveNode {
+			matchedKeys = (&searchQueryNode{nodeKind: searchMatchAllNode}).matchingKeys(index)
+		}
+		for _, negatedNode := range negatedNodes {
+			for storageKey := range negatedNode.matchingKeys(index) {
+				delete(matchedKeys, storageKey)
+			}
+		}
+	case searchUnionNode:
+		for _, childNode := range queryNode.childNodes {
+			for storageKey := range childNode.matchingKeys(index) {
+				matchedKeys[storageKey] = struct{}{}
+			}
+		}
+	case searchNegationNode:
+		return (&searchQueryNode{nodeKind: searchIntersectionNode, childNodes: []*searchQueryNode{queryNode}}).matchingKeys(index)
+	case searchTextTermNode:
+		for _, fieldDefinition := range index.indexDefinition.SchemaFields {
+			if fieldDefinition.FieldType != SearchTextField || (queryNode.fieldName != "" && fieldDefinition.FieldName != queryNode.fieldName) {
+				continue
+			}
+			for storageKey := range index.termPostings[fieldDefinition.FieldName][queryNode.searchTerms[0]] {
+				matchedKeys[storageKey] = struct{}{}
+			}
+		}
+	case searchTagFilterNode:
+		for _, tagValue := range queryNode.searchTerms {
+			for storageKey := range index.termPostings[queryNode.fieldName][tagValue] {
+				matchedKeys[storageKey] = struct{}{}
+			}
+		}
+	case searchNumericRangeNode:
+		numericValues := index.numericFieldIndex[queryNode.fieldName]
+		lowerBound := queryNode.minimumValue
+		if queryNode.minimumIsOpen {
+			lowerBound = math.Nextafter(lowerBound, math.Inf(1))
+		}
+		for memberIndex := numericValues.searchScore(lowerBound); memberIndex < len(numericValues.SortedMembers); memberIndex++ {
+			sortedMember := numericValues.SortedMembers[memberIndex]
+			if sortedMember.Score > queryNode.maximumValue || (queryNode.maximumIsOpen && sortedMember.Score == queryNode.maximumValue) {
+				break
+			}
+			matchedKeys[sortedMember.Member] = struct{}{}
+		}
+	}
+	return matchedKeys
+}
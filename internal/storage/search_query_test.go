@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// describeSearchQueryNode écrit l'arbre d'une requête sous une forme compacte et lisible
+func describeSearchQueryNode(queryNode *searchQueryNode) string {
+	describeChildren := func() string {
+		childDescriptions := make([]string, 0, len(queryNode.childNodes))
+		for _, childNode := range queryNode.childNodes {
+			childDescriptions = append(childDescriptions, describeSearchQueryNode(childNode))
+		}
+		return strings.Join(childDescriptions, " ")
+	}
+	describeBound := func(boundValue float64, boundIsOpen bool) string {
+		if boundIsOpen {
+			return fmt.Sprintf("(%v", boundValue)
+		}
+		return fmt.Sprint(boundValue)
+	}
+
+	switch queryNode.nodeKind {
+	case searchMatchAllNode:
+		return "*"
+	case searchIntersectionNode:
+		return "ET(" + describeChildren() + ")"
+	case searchUnionNode:
+		return "OU(" + describeChildren() + ")"
+	case searchNegationNode:
+		return "NON(" + describeChildren() + ")"
+	case searchTextTermNode:
+		if queryNode.fieldName == "" {
+			return queryNode.searchTerms[0]
+		}
+		return "@" + queryNode.fieldName + ":" + queryNode.searchTerms[0]
+	case searchTagFilterNode:
+		return "@" + queryNode.fieldName + ":{" + strings.Join(queryNode.searchTerms, "|") + "}"
+	case searchNumericRangeNode:
+		return "@" + queryNode.fieldName + ":[" + describeBound(queryNode.minimumValue, queryNode.minimumIsOpen) + " " + describeBound(queryNode.maximumValue, queryNode.maximumIsOpen) + "]"
+	}
+	return "?"
+}
+
+func TestParseSearchQueryBuildsExpectedTree(t *testing.T) {
+	testCases := []struct {
+		queryText    string
+		expectedTree string
+	}{
+		{"*", "*"},
+		{"Bonjour", "bonjour"},
+		{"a b", "ET(a b)"},
+		{"a b | c", "OU(ET(a b) c)"},
+		{"a (b | c)", "ET(a OU(b c))"},
+		{"-a b", "ET(NON(a) b)"},
+		{"-(a | b)", "NON(OU(a b))"},
+		{"@titre:Redis", "@titre:redis"},
+		{"@titre:(a | b c)", "OU(@titre:a ET(@titre:b @titre:c))"},
+		{"@tags:{ Rouge | vert | rouge }", "@tags:{rouge|vert}"},
+		{`@tags:{a\}b}`, "@tags:{a}b}"},
+		{"@prix:[10 20]", "@prix:[10 20]"},
+		{"@prix:[(10 (20]", "@prix:[(10 (20]"},
+		{"@prix:[-inf +inf]", fmt.Sprintf("@prix:[%v %v]", math.Inf(-1), math.Inf(1))},
+		{"  café   été ", "ET(café été)"},
+	}
+
+	for _, testCase := range testCases {
+		searchQuery, parseError := ParseSearchQuery(testCase.queryText)
+		if parseError != nil {
+			t.Errorf("ParseSearchQuery(%q): %v", testCase.queryText, parseError)
+			continue
+		}
+		if queryTree := describeSearchQueryNode(searchQuery.rootNode); queryTree != testCase.expectedTree {
+			t.Errorf("%q analysée en %s, attendu %s", testCase.queryText, queryTree, testCase.expectedTree)
+		}
+	}
+}
+
+func TestParseSearchQueryRejectsInvalidSyntax(t *testing.T) {
+	for _, queryText := range []string{"", "   ", "a |", "| a", "(a", "a)", "()", "-", "@", "@titre", "@:a", "@ti tre:a", "@titre:", "@tags:{}", "@tags:{a", "@prix:[10]", "@prix:[10 20", "@prix:[a 20]", "@prix:[10 nan]", "a & b"} {
+		if _, parseError := ParseSearchQuery(queryText); !errors.Is(parseError, ErrSearchQuerySyntax) {
+			t.Errorf("ParseSearchQuery(%q): %v, attendu ErrSearchQuerySyntax", queryText, parseError)
+		}
+	}
+}
+
+func TestSearchQueryMatchesIndexedHashes(t *testing.T) {
+	redisStorage := NewRedisInMemoryStorage()
+	indexedHashes := map[string]map[string]string{
+		"doc:1": {"titre": "Redis en Go", "tags": "base,cache", "prix": "10"},
+		"doc:2": {"titre": "Go avancé", "tags": "langage", "prix": "20"},
+		"doc:3": {"titre": "Cache Redis", "tags": "cache", "prix": "30"},
+		"autre": {"titre": "Redis hors index", "prix": "10"},
+	}
+	for storageKey, hashFields := range indexedHashes {
+		for fieldName, fieldValue := range hashFields {
+			redisStorage.SetHashField(storageKey, fieldName, fieldValue)
+		}
+	}
+	createError := redisStorage.CreateSearchIndex(SearchIndexDefinition{
+		IndexName:   "idx",
+		KeyPrefixes: []string{"doc:"},
+		SchemaFields: []SearchFieldDefinition{
+			{FieldName: "titre", FieldType: SearchTextField},
+			{FieldName: "tags", FieldType: SearchTagField, TagSeparator: ","},
+			{FieldName: "prix", FieldType: SearchNumericField},
+		},
+	})
+	if createError != nil {
+		t.Fatalf("CreateSearchIndex: %v", createError)
+	}
+
+	testCases := []struct {
+		queryText    string
+		expectedKeys []string
+	}{
+		{"*", []string{"doc:1", "doc:2", "doc:3"}},
+		{"redis", []string{"doc:1", "doc:3"}},
+		{"redis go", []string{"doc:1"}},
+		{"redis | avancé", []string{"doc:1", "doc:2", "doc:3"}},
+		{"-redis", []string{"doc:2"}},
+		{"go -@tags:{base}", []string{"doc:2"}},
+		{"@titre:cache", []string{"doc:3"}},
+		{"@tags:{CACHE}", []string{"doc:1", "doc:3"}},
+		{"@tags:{base | langage}", []string{"doc:1", "doc:2"}},
+		{"@prix:[10 20]", []string{"doc:1", "doc:2"}},
+		{"@prix:[(10 +inf]", []string{"doc:2", "doc:3"}},
+		{"@prix:[-inf (30]", []string{"doc:1", "doc:2"}},
+		{"@prix:[15 (20]", nil},
+		{"absent", nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.queryText, func(t *testing.T) {
+			searchQuery, parseError := ParseSearchQuery(testCase.queryText)
+			if parseError != nil {
+				t.Fatalf("ParseSearchQuery: %v", parseError)
+			}
+			searchResult, searchError := redisStorage.SearchIndex("idx", searchQuery, SearchOptions{ResultLimit: 10, NoContent: true})
+			if searchError != nil {
+				t.Fatalf("SearchIndex: %v", searchError)
+			}
+			var foundKeys []string
+			for _, searchDocument := range searchResult.Documents {
+				foundKeys = append(foundKeys, searchDocument.Key)
+			}
+			sort.Strings(foundKeys)
+			if !reflect.DeepEqual(foundKeys, testCase.expectedKeys) || searchResult.TotalCount != len(testCase.expectedKeys) {
+				t.Fatalf("clés %v (total %d), attendu %v", foundKeys, searchResult.TotalCount, testCase.expectedKeys)
+			}
+		})
+	}
+}
+
+func TestSearchQueryRejectsFieldsOutsideSchema(t *testing.T) {
+	redisStorage := NewRedisInMemoryStorage()
+	createError := redisStorage.CreateSearchIndex(SearchIndexDefinition{
+		IndexName: "idx",
+		SchemaFields: []SearchFieldDefinition{
+			{FieldName: "titre", FieldType: SearchTextField},
+			{FieldName: "prix", FieldType: SearchNumericField},
+		},
+	})
+	if createError != nil {
+		t.Fatalf("CreateSearchIndex: %v", createError)
+	}
+
+	testCases := []struct {
+		queryText     string
+		expectedError string
+	}{
+		{"@absent:mot", "absent du schéma"},
+		{"@titre:[1 2]", "de type TEXT"},
+		{"@prix:{a}", "de type NUMERIC"},
+		{"a (b | -@prix:mot)", "de type NUMERIC"},
+	}
+
+	for _, testCase := range testCases {
+		searchQuery, parseError := ParseSearchQuery(testCase.queryText)
+		if parseError != nil {
+			t.Fatalf("ParseSearchQuery(%q): %v", testCase.queryText, parseError)
+		}
+		if _, searchError := redisStorage.SearchIndex("idx", searchQuery, SearchOptions{ResultLimit: 10}); searchError == nil || !strings.Contains(searchError.Error(), testCase.expectedError) {
+			t.Errorf("%q: %v, attendu une erreur contenant %q", testCase.queryText, searchError, testCase.expectedError)
+		}
+	}
+}
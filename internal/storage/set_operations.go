@@ -19,6 +19,7 @@ func (redisStorage *RedisInMemoryStorage) AddMembersToSet(setKey string, newMemb
 		if storageValue.DataType != RedisSetType {
 			return -1
 		}
+		redisStorage.preserveValueForSnapshots(setKey, storageValue)
 		redisSetStructure = storageValue.StoredData.(*RedisSetStructure)
 	}
 
@@ -92,6 +93,7 @@ func (redisStorage *RedisInMemoryStorage) RemoveMembersFromSet(setKey string, me
 		return -1 // Erreur de type
 	}
 
+	redisStorage.preserveValueForSnapshots(setKey, storageValue)
 	redisSetStructure := storageValue.StoredData.(*RedisSetStructure)
 	removedCount := 0
 
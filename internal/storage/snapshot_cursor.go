@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+// SnapshotRecord est la forme sérialisable d'une clé (champs typés, sans interface{} pour gob)
+type SnapshotRecord struct {
+	Key            string
+	DataType       RedisDataType
+	ExpirationTime *time.Time
+	StringValue    string
+	ListElements   []string
+	SetMembers     []string
+	HashFields     map[string]string
+	StreamEntries  []StreamEntry
+	StreamLastID   StreamEntryID
+	SortedMembers  []SortedSetMember // Triés par score puis par nom
+	JSONDocument   string            // Document JSON compact
+}
+
+// SnapshotCursor parcourt une image figée du stockage sans bloquer les écritures
+// L'image ne contient que des pointeurs : une valeur n'est copiée que si une écriture
+// veut la modifier sur place avant que le curseur ne l'ait lue (copy-on-write)
+type SnapshotCursor struct {
+	redisStorage   *RedisInMemoryStorage
+	snapshotKeys   []string
+	pendingValues  map[string]*RedisStorageValue
+	preservedCount int
+	cursorPosition int
+	Timestamp      time.Time
+	SearchIndexes  []SearchIndexDefinition // Index secondaires à recréer au chargement
+}
+
+// BeginSnapshot fige l'état courant du stockage
+// Le verrou d'écriture n'est détenu que le temps de copier les pointeurs, pas les valeurs
+func (redisStorage *RedisInMemoryStorage) BeginSnapshot() *SnapshotCursor {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	snapshotCursor := &SnapshotCursor{
+		redisStorage:  redisStorage,
+		snapshotKeys:  make([]string, 0, len(redisStorage.storageData)),
+		pendingValues: make(map[string]*RedisStorageValue, len(redisStorage.storageData)),
+		Timestamp:     time.Now(),
+		SearchIndexes: redisStorage.searchIndexDefinitions(),
+	}
+	for storageKey, storageValue := range redisStorage.storageData {
+		snapshotCursor.snapshotKeys = append(snapshotCursor.snapshotKeys, storageKey)
+		snapshotCursor.pendingValues[storageKey] = storageValue
+	}
+
+	if redisStorage.activeSnapshotCursors == nil {
+		redisStorage.activeSnapshotCursors = make(map[*SnapshotCursor]struct{})
+	}
+	redisStorage.activeSnapshotCursors[snapshotCursor] = struct{}{}
+	return snapshotCursor
+}
+
+// Next retourne la clé suivante de l'image, ou false quand le parcours est terminé
+// Les clés déjà expirées au moment du snapshot sont ignorées
+func (snapshotCursor *SnapshotCursor) Next() (SnapshotRecord, bool) {
+	redisStorage := snapshotCursor.redisStorage
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	for snapshotCursor.cursorPosition < len(snapshotCursor.snapshotKeys) {
+		storageKey := snapshotCursor.snapshotKeys[snapshotCursor.cursorPosition]
+		snapshotCursor.cursorPosition++
+
+		storageValue := snapshotCursor.pendingValues[storageKey]
+		delete(snapshotCursor.pendingValues, storageKey)
+
+		if storageValue.ExpirationTime != nil && !snapshotCursor.Timestamp.Before(*storageValue.ExpirationTime) {
+			continue
+		}
+		return NewSnapshotRecord(storageKey, storageValue), true
+	}
+	return SnapshotRecord{}, false
+}
+
+// KeyCount retourne le nombre de clés figées (expirées comprises)
+func (snapshotCursor *SnapshotCursor) KeyCount() int {
+	return len(snapshotCursor.snapshotKeys)
+}
+
+// PreservedCount retourne le nombre de valeurs copiées à cause d'écritures pendant le parcours
+func (snapshotCursor *SnapshotCursor) PreservedCount() int {
+	redisStorage := snapshotCursor.redisStorage
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+	return snapshotCursor.preservedCount
+}
+
+// Close libère le curseur : les écritures n'ont plus à copier pour lui
+func (snapshotCursor *SnapshotCursor) Close() {
+	redisStorage := snapshotCursor.redisStorage
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	delete(redisStorage.activeSnapshotCursors, snapshotCursor)
+	snapshotCursor.pendingValues = nil
+	snapshotCursor.cursorPosition = len(snapshotCursor.snapshotKeys)
+}
+
+// preserveValueForSnapshots copie une valeur avant sa modification sur place si un curseur ne l'a pas encore lue
+// ou si un savepoint la référence ; une seule copie est faite et partagée, elle n'est jamais modifiée sur place
+// Doit être appelée avec le verrou d'écriture ; les valeurs remplacées ou supprimées n'en ont pas besoin
+func (redisStorage *RedisInMemoryStorage) preserveValueForSnapshots(storageKey string, storageValue *RedisStorageValue) {
+	var preservedValue *RedisStorageValue
+	preservedCopy := func() *RedisStorageValue {
+		if preservedValue == nil {
+			preservedValue = &RedisStorageValue{
+				StoredData:     copyStoredData(storageValue.StoredData, storageValue.DataType),
+				DataType:       storageValue.DataType,
+				ExpirationTime: copyTime(storageValue.ExpirationTime),
+			}
+		}
+		return preservedValue
+	}
+
+	for snapshotCursor := range redisStorage.activeSnapshotCursors {
+		if snapshotCursor.pendingValues[storageKey] != storageValue {
+			continue
+		}
+		snapshotCursor.pendingValues[storageKey] = preservedCopy()
+		snapshotCursor.preservedCount++
+	}
+	for _, savepoint := range redisStorage.savepoints {
+		if savepoint.savedValues[storageKey] != storageValue {
+			continue
+		}
+		savepoint.savedValues[storageKey] = preservedCopy()
+		savepoint.preservedCount++
+	}
+}
+
+// NewSnapshotRecord convertit une valeur en enregistrement (copie des données)
+func NewSnapshotRecord(storageKey string, storageValue *RedisStorageValue) SnapshotRecord {
+	snapshotRecord := SnapshotRecord{
+		Key:            storageKey,
+		DataType:       storageValue.DataType,
+		ExpirationTime: copyTime(storageValue.ExpirationTime),
+	}
+
+	switch storageValue.DataType {
+	case RedisStringType:
+		snapshotRecord.StringValue = storageValue.StoredData.(string)
+	case RedisListType:
+		listElements := storageValue.StoredData.(*RedisListStructure).ListElements
+		snapshotRecord.ListElements = append(make([]string, 0, len(listElements)), listElements...)
+	case RedisSetType:
+		setElements := storageValue.StoredData.(*RedisSetStructure).SetElements
+		snapshotRecord.SetMembers = make([]string, 0, len(setElements))
+		for setMember := range setElements {
+			snapshotRecord.SetMembers = append(snapshotRecord.SetMembers, setMember)
+		}
+		sort.Strings(snapshotRecord.SetMembers)
+	case RedisHashType:
+		hashFields := storageValue.StoredData.(*RedisHashStructure).HashFields
+		snapshotRecord.HashFields = make(map[string]string, len(hashFields))
+		for fieldName, fieldValue := range hashFields {
+			snapshotRecord.HashFields[fieldName] = fieldValue
+		}
+	case RedisStreamType:
+		streamStructure := storageValue.StoredData.(*RedisStreamStructure)
+		snapshotRecord.StreamEntries = append(make([]StreamEntry, 0, len(streamStructure.StreamEntries)), streamStructure.StreamEntries...)
+		snapshotRecord.StreamLastID = streamStructure.LastGeneratedID
+	case RedisZSetType:
+		sortedMembers := storageValue.StoredData.(*RedisSortedSetStructure).SortedMembers
+		snapshotRecord.SortedMembers = append(make([]SortedSetMember, 0, len(sortedMembers)), sortedMembers...)
+	case RedisJSONType:
+		snapshotRecord.JSONDocument = SerializeJSONValue(storageValue.StoredData.(*RedisJSONDocument).Root, JSONFormat{})
+	}
+	return snapshotRecord
+}
+
+// ToStorageValue reconstruit la valeur stockée décrite par l'enregistrement
+func (snapshotRecord SnapshotRecord) ToStorageValue() *RedisStorageValue {
+	storageValue := &RedisStorageValue{
+		DataType:       snapshotRecord.DataType,
+		ExpirationTime: copyTime(snapshotRecord.ExpirationTime),
+	}
+
+	switch snapshotRecord.DataType {
+	case RedisListType:
+		storageValue.StoredData = &RedisListStructure{
+			ListElements: append(make([]string, 0, len(snapshotRecord.ListElements)), snapshotRecord.ListElements...),
+		}
+	case RedisSetType:
+		setElements := make(map[string]bool, len(snapshotRecord.SetMembers))
+		for _, setMember := range snapshotRecord.SetMembers {
+			setElements[setMember] = true
+		}
+		storageValue.StoredData = &RedisSetStructure{SetElements: setElements}
+	case RedisHashType:
+		hashFields := make(map[string]string, len(snapshotRecord.HashFields))
+		for fieldName, fieldValue := range snapshotRecord.HashFields {
+			hashFields[fieldName] = fieldValue
+		}
+		storageValue.StoredData = &RedisHashStructure{HashFields: hashFields}
+	case RedisStreamType:
+		storageValue.StoredData = &RedisStreamStructure{
+			StreamEntries:   append(make([]StreamEntry, 0, len(snapshotRecord.StreamEntries)), snapshotRecord.StreamEntries...),
+			LastGeneratedID: snapshotRecord.StreamLastID,
+		}
+	case RedisZSetType:
+		storageValue.StoredData = NewSortedSetStructure(snapshotRecord.SortedMembers)
+	case RedisJSONType:
+		// Le document a été écrit par SerializeJSONValue : un texte illisible ne peut venir que d'un fichier altéré
+		documentRoot, _ := ParseJSONValue(snapshotRecord.JSONDocument)
+		storageValue.StoredData = &RedisJSONDocument{Root: documentRoot}
+	default:
+		storageValue.StoredData = snapshotRecord.StringValue
+	}
+	return storageValue
+}
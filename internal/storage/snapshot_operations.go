@@ -4,38 +4,34 @@ import "time"
 
 // StorageSnapshot représente un snapshot complet du stockage
 type StorageSnapshot struct {
-	Data      map[string]*RedisStorageValue `json:"data"`
-	Timestamp time.Time                     `json:"timestamp"`
-	Version   string                        `json:"version"`
+	Data          map[string]*RedisStorageValue `json:"data"`
+	Timestamp     time.Time                     `json:"timestamp"`
+	Version       string                        `json:"version"`
+	SearchIndexes []SearchIndexDefinition       `json:"search_indexes,omitempty"`
 }
 
 // CreateSnapshot crée un snapshot complet du stockage
+// Les valeurs sont copiées une à une via un curseur : les écritures ne sont pas bloquées pendant la copie
 func (redisStorage *RedisInMemoryStorage) CreateSnapshot() StorageSnapshot {
-	redisStorage.storageMutex.RLock()
-	defer redisStorage.storageMutex.RUnlock()
+	snapshotCursor := redisStorage.BeginSnapshot()
+	defer snapshotCursor.Close()
 
 	snapshot := StorageSnapshot{
-		Data:      make(map[string]*RedisStorageValue),
-		Timestamp: time.Now(),
-		Version:   "1.0",
+		Data:          make(map[string]*RedisStorageValue, snapshotCursor.KeyCount()),
+		Timestamp:     snapshotCursor.Timestamp,
+		Version:       "1.0",
+		SearchIndexes: snapshotCursor.SearchIndexes,
 	}
-
-	// Copier toutes les données valides (non expirées)
-	currentTime := time.Now()
-	for key, value := range redisStorage.storageData {
-		if value.ExpirationTime == nil || currentTime.Before(*value.ExpirationTime) {
-			// Copie profonde de la valeur
-			snapshot.Data[key] = &RedisStorageValue{
-				StoredData:     copyStoredData(value.StoredData, value.DataType),
-				DataType:       value.DataType,
-				ExpirationTime: copyTime(value.ExpirationTime),
-			}
+	for {
+		snapshotRecord, recordAvailable := snapshotCursor.Next()
+		if !recordAvailable {
+			break
 		}
+		snapshot.Data[snapshotRecord.Key] = snapshotRecord.ToStorageValue()
 	}
 
-	// Reset le compteur de changements après snapshot
-	redisStorage.changesSinceLastSave = 0
-
+	// Le compteur n'est pas remis à zéro ici : la persistence appelle AcknowledgeSavedChanges
+	// une fois l'écriture réussie, pour qu'un échec de sauvegarde ne perde pas les changements
 	return snapshot
 }
 
@@ -61,10 +57,69 @@ func (redisStorage *RedisInMemoryStorage) RestoreFromSnapshot(snapshot StorageSn
 		}
 	}
 
+	redisStorage.replaceSearchIndexes(snapshot.SearchIndexes)
+
 	// Reset le compteur de changements après restauration
 	redisStorage.changesSinceLastSave = 0
 }
 
+// SnapshotLoader reconstruit le stockage clé par clé depuis un flux d'enregistrements
+// Les clés sont chargées directement dans la table qui remplacera celle du stockage à la validation
+type SnapshotLoader struct {
+	redisStorage  *RedisInMemoryStorage
+	loadedData    map[string]*RedisStorageValue
+	loadTime      time.Time
+	searchIndexes []SearchIndexDefinition
+}
+
+// BeginSnapshotLoad prépare un chargement ; le stockage courant reste servi jusqu'à Commit
+func (redisStorage *RedisInMemoryStorage) BeginSnapshotLoad() *SnapshotLoader {
+	return &SnapshotLoader{
+		redisStorage: redisStorage,
+		loadedData:   make(map[string]*RedisStorageValue),
+		loadTime:     time.Now(),
+	}
+}
+
+// LoadRecord ajoute une clé ; retourne false si elle a expiré depuis la sauvegarde
+func (snapshotLoader *SnapshotLoader) LoadRecord(snapshotRecord SnapshotRecord) bool {
+	if snapshotRecord.ExpirationTime != nil && !snapshotLoader.loadTime.Before(*snapshotRecord.ExpirationTime) {
+		return false
+	}
+	snapshotLoader.loadedData[snapshotRecord.Key] = snapshotRecord.ToStorageValue()
+	return true
+}
+
+// LoadSearchIndexes indique les index à recréer sur les clés chargées ; ils remplacent les index courants à Commit
+func (snapshotLoader *SnapshotLoader) LoadSearchIndexes(searchIndexes []SearchIndexDefinition) {
+	snapshotLoader.searchIndexes = searchIndexes
+}
+
+// Commit remplace le contenu du stockage par les clés chargées et retourne leur nombre
+func (snapshotLoader *SnapshotLoader) Commit() int {
+	redisStorage := snapshotLoader.redisStorage
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	redisStorage.storageData = snapshotLoader.loadedData
+	redisStorage.replaceSearchIndexes(snapshotLoader.searchIndexes)
+	redisStorage.changesSinceLastSave = 0
+	return len(snapshotLoader.loadedData)
+}
+
+// DumpKeyRecord retourne une copie de la valeur d'une clé sous forme d'enregistrement (DUMP, MIGRATE)
+// La copie est faite sous verrou : elle ne peut pas observer une écriture à moitié appliquée
+func (redisStorage *RedisInMemoryStorage) DumpKeyRecord(storageKey string) (SnapshotRecord, bool) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	storageValue, keyExists := redisStorage.storageData[storageKey]
+	if !keyExists || (storageValue.ExpirationTime != nil && time.Now().After(*storageValue.ExpirationTime)) {
+		return SnapshotRecord{}, false
+	}
+	return NewSnapshotRecord(storageKey, storageValue), true
+}
+
 // copyStoredData effectue une copie profonde des données selon leur type
 func copyStoredData(data interface{}, dataType RedisDataType) interface{} {
 	switch dataType {
@@ -101,6 +156,28 @@ func copyStoredData(data interface{}, dataType RedisDataType) interface{} {
 		}
 		return copy
 
+	case RedisStreamType:
+		// Les paires champ/valeur d'une entrée ne sont jamais modifiées : seul le tableau des entrées est copié
+		original := data.(*RedisStreamStructure)
+		return &RedisStreamStructure{
+			StreamEntries:   append(make([]StreamEntry, 0, len(original.StreamEntries)), original.StreamEntries...),
+			LastGeneratedID: original.LastGeneratedID,
+		}
+
+	case RedisZSetType:
+		original := data.(*RedisSortedSetStructure)
+		copy := &RedisSortedSetStructure{
+			MemberScores:  make(map[string]float64, len(original.MemberScores)),
+			SortedMembers: append(make([]SortedSetMember, 0, len(original.SortedMembers)), original.SortedMembers...),
+		}
+		for member, score := range original.MemberScores {
+			copy.MemberScores[member] = score
+		}
+		return copy
+
+	case RedisJSONType:
+		return &RedisJSONDocument{Root: CloneJSONValue(data.(*RedisJSONDocument).Root)}
+
 	default:
 		// Pour les types non supportés, retourner tel quel
 		return data
@@ -123,6 +200,18 @@ func (redisStorage *RedisInMemoryStorage) GetChangesSinceLastSave() int64 {
 	return redisStorage.changesSinceLastSave
 }
 
+// AcknowledgeSavedChanges retire du compteur les changements couverts par une sauvegarde réussie
+// Les écritures arrivées pendant la sauvegarde restent comptées
+func (redisStorage *RedisInMemoryStorage) AcknowledgeSavedChanges(savedChanges int64) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	redisStorage.changesSinceLastSave -= savedChanges
+	if redisStorage.changesSinceLastSave < 0 {
+		redisStorage.changesSinceLastSave = 0
+	}
+}
+
 // incrementChanges incrémente le compteur de changements
 func (redisStorage *RedisInMemoryStorage) incrementChanges() {
 	redisStorage.changesSinceLastSave++
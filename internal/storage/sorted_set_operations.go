@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Erreurs retournées par les opérations sur les sorted sets
+var (
+	ErrSortedSetWrongType = errors.New("cette clé ne contient pas un sorted set")
+)
+
+// SortedSetAddOptions reprend les options NX / XX / CH de ZADD et GEOADD
+type SortedSetAddOptions struct {
+	OnlyNewMembers      bool // NX : ne jamais modifier un membre existant
+	OnlyExistingMembers bool // XX : ne jamais ajouter de membre
+}
+
+// AddSortedSetMembers ajoute des membres ou met à jour leur score
+// Retourne le nombre de membres ajoutés et le nombre de membres existants dont le score a changé
+func (redisStorage *RedisInMemoryStorage) AddSortedSetMembers(storageKey string, sortedSetMembers []SortedSetMember, addOptions SortedSetAddOptions) (int, int, error) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	sortedSetStructure, sortedSetExists, isSortedSet := redisStorage.lookupSortedSet(storageKey)
+	if !isSortedSet {
+		return 0, 0, ErrSortedSetWrongType
+	}
+
+	if sortedSetExists {
+		redisStorage.preserveValueForSnapshots(storageKey, redisStorage.storageData[storageKey])
+	} else {
+		if addOptions.OnlyExistingMembers {
+			return 0, 0, nil
+		}
+		sortedSetStructure = &RedisSortedSetStructure{MemberScores: make(map[string]float64)}
+	}
+
+	addedCount, updatedCount := 0, 0
+	for _, sortedSetMember := range sortedSetMembers {
+		currentScore, memberExists := sortedSetStructure.MemberScores[sortedSetMember.Member]
+		if (memberExists && addOptions.OnlyNewMembers) || (!memberExists && addOptions.OnlyExistingMembers) {
+			continue
+		}
+		if memberExists && currentScore == sortedSetMember.Score {
+			continue
+		}
+		sortedSetStructure.setMemberScore(sortedSetMember.Member, sortedSetMember.Score)
+		if memberExists {
+			updatedCount++
+		} else {
+			addedCount++
+		}
+	}
+
+	// Comme dans Redis, une clé n'est jamais créée vide
+	if !sortedSetExists && len(sortedSetStructure.SortedMembers) > 0 {
+		redisStorage.storageData[storageKey] = &RedisStorageValue{
+			StoredData: sortedSetStructure,
+			DataType:   RedisZSetType,
+		}
+	}
+	if addedCount+updatedCount > 0 {
+		redisStorage.incrementChanges()
+	}
+	return addedCount, updatedCount, nil
+}
+
+// RemoveSortedSetMembers retire des membres et supprime la clé si elle devient vide
+func (redisStorage *RedisInMemoryStorage) RemoveSortedSetMembers(storageKey string, memberNames []string) (int, error) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	sortedSetStructure, sortedSetExists, isSortedSet := redisStorage.lookupSortedSet(storageKey)
+	if !isSortedSet {
+		return 0, ErrSortedSetWrongType
+	}
+	if !sortedSetExists {
+		return 0, nil
+	}
+
+	redisStorage.preserveValueForSnapshots(storageKey, redisStorage.storageData[storageKey])
+	removedCount := 0
+	for _, memberName := range memberNames {
+		if sortedSetStructure.removeMember(memberName) {
+			removedCount++
+		}
+	}
+	if len(sortedSetStructure.SortedMembers) == 0 {
+		delete(redisStorage.storageData, storageKey)
+	}
+	if removedCount > 0 {
+		redisStorage.incrementChanges()
+	}
+	return removedCount, nil
+}
+
+// GetSortedSetScores retourne le score de chaque membre demandé ; memberFound[i] est faux si le membre est absent
+func (redisStorage *RedisInMemoryStorage) GetSortedSetScores(storageKey string, memberNames []string) ([]float64, []bool, error) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	sortedSetStructure, sortedSetExists, isSortedSet := redisStorage.lookupSortedSet(storageKey)
+	if !isSortedSet {
+		return nil, nil, ErrSortedSetWrongType
+	}
+
+	memberScores := make([]float64, len(memberNames))
+	memberFound := make([]bool, len(memberNames))
+	if !sortedSetExists {
+		return memberScores, memberFound, nil
+	}
+	for memberIndex, memberName := range memberNames {
+		memberScores[memberIndex], memberFound[memberIndex] = sortedSetStructure.MemberScores[memberName]
+	}
+	return memberScores, memberFound, nil
+}
+
+// GetSortedSetCardinality retourne le nombre de membres (0 si la clé n'existe pas)
+func (redisStorage *RedisInMemoryStorage) GetSortedSetCardinality(storageKey string) (int, error) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	sortedSetStructure, sortedSetExists, isSortedSet := redisStorage.lookupSortedSet(storageKey)
+	if !isSortedSet {
+		return 0, ErrSortedSetWrongType
+	}
+	if !sortedSetExists {
+		return 0, nil
+	}
+	return len(sortedSetStructure.SortedMembers), nil
+}
+
+// lookupSortedSet retourne le sorted set d'une clé ; isSortedSet est false si la clé contient un autre type
+func (redisStorage *RedisInMemoryStorage) lookupSortedSet(storageKey string) (sortedSetStructure *RedisSortedSetStructure, sortedSetExists bool, isSortedSet bool) {
+	storageValue, keyExists := redisStorage.storageData[storageKey]
+	if !keyExists || (storageValue.ExpirationTime != nil && time.Now().After(*storageValue.ExpirationTime)) {
+		return nil, false, true
+	}
+	if storageValue.DataType != RedisZSetType {
+		return nil, false, false
+	}
+	return storageValue.StoredData.(*RedisSortedSetStructure), true, true
+}
+
+// NewSortedSetStructure construit un sorted set à partir de membres dans un ordre quelconque
+// Si un membre apparaît plusieurs fois, le dernier score l'emporte
+func NewSortedSetStructure(sortedSetMembers []SortedSetMember) *RedisSortedSetStructure {
+	sortedSetStructure := &RedisSortedSetStructure{MemberScores: make(map[string]float64, len(sortedSetMembers))}
+	for _, sortedSetMember := range sortedSetMembers {
+		sortedSetStructure.MemberScores[sortedSetMember.Member] = sortedSetMember.Score
+	}
+	sortedSetStructure.SortedMembers = make([]SortedSetMember, 0, len(sortedSetStructure.MemberScores))
+	for memberName, memberScore := range sortedSetStructure.MemberScores {
+		sortedSetStructure.SortedMembers = append(sortedSetStructure.SortedMembers, SortedSetMember{Member: memberName, Score: memberScore})
+	}
+	sort.Slice(sortedSetStructure.SortedMembers, func(firstIndex, secondIndex int) bool {
+		return sortedSetMemberLess(sortedSetStructure.SortedMembers[firstIndex], sortedSetStructure.SortedMembers[secondIndex])
+	})
+	return sortedSetStructure
+}
+
+// setMemberScore insère un membre ou déplace un membre existant à la position de son nouveau score
+func (sortedSetStructure *RedisSortedSetStructure) setMemberScore(memberName string, memberScore float64) {
+	sortedSetStructure.removeMember(memberName)
+	newMember := SortedSetMember{Member: memberName, Score: memberScore}
+	insertIndex := sort.Search(len(sortedSetStructure.SortedMembers), func(memberIndex int) bool {
+		return !sortedSetMemberLess(sortedSetStructure.SortedMembers[memberIndex], newMember)
+	})
+	sortedSetStructure.SortedMembers = append(sortedSetStructure.SortedMembers, SortedSetMember{})
+	copy(sortedSetStructure.SortedMembers[insertIndex+1:], sortedSetStructure.SortedMembers[insertIndex:])
+	sortedSetStructure.SortedMembers[insertIndex] = newMember
+	sortedSetStructure.MemberScores[memberName] = memberScore
+}
+
+// removeMember retire un membre et retourne false s'il n'existait pas
+func (sortedSetStructure *RedisSortedSetStructure) removeMember(memberName string) bool {
+	memberScore, memberExists := sortedSetStructure.MemberScores[memberName]
+	if !memberExists {
+		return false
+	}
+	removedMember := SortedSetMember{Member: memberName, Score: memberScore}
+	removeIndex := sort.Search(len(sortedSetStructure.SortedMembers), func(memberIndex int) bool {
+		return !sortedSetMemberLess(sortedSetStructure.SortedMembers[memberIndex], removedMember)
+	})
+	sortedSetStructure.SortedMembers = append(sortedSetStructure.SortedMembers[:removeIndex], sortedSetStructure.SortedMembers[removeIndex+1:]...)
+	delete(sortedSetStructure.MemberScores, memberName)
+	return true
+}
+
+// searchScore retourne l'indice du premier membre dont le score est >= minimumScore
+func (sortedSetStructure *RedisSortedSetStructure) searchScore(minimumScore float64) int {
+	return sort.Search(len(sortedSetStructure.SortedMembers), func(memberIndex int) bool {
+		return sortedSetStructure.SortedMembers[memberIndex].Score >= minimumScore
+	})
+}
+
+// sortedSetMemberLess ordonne par score puis par nom de membre, comme Redis
+func sortedSetMemberLess(firstMember, secondMember SortedSetMember) bool {
+	if firstMember.Score != secondMember.Score {
+		return firstMember.Score < secondMember.Score
+	}
+	return firstMember.Member < secondMember.Member
+}
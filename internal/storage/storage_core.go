@@ -10,6 +10,11 @@ type RedisInMemoryStorage struct {
 	storageData          map[string]*RedisStorageValue
 	storageMutex         sync.RWMutex
 	changesSinceLastSave int64 // Nouveau: compteur pour RDB
+
+	activeSnapshotCursors map[*SnapshotCursor]struct{}          // Snapshots en cours de parcours (copy-on-write)
+	streamWaiters         map[string]map[*StreamWaiter]struct{} // Clients bloqués par XREAD BLOCK, par stream
+	savepoints            map[string]*storageSavepoint          // Savepoints nommés (SNAPSHOT CREATE), copy-on-write
+	searchIndexes         map[string]*searchIndex               // Index secondaires sur les hashes (FT.CREATE)
 }
 
 // NewRedisInMemoryStorage crée une nouvelle instance de stockage
@@ -36,6 +41,7 @@ func (redisStorage *RedisInMemoryStorage) SetKeyValue(storageKey string, keyData
 		DataType:       dataType,
 		ExpirationTime: expirationTime,
 	}
+	redisStorage.reindexHashKey(storageKey)
 
 	// Incrémenter le compteur de changements
 	redisStorage.incrementChanges()
@@ -70,11 +76,64 @@ func (redisStorage *RedisInMemoryStorage) DeleteKeyValue(storageKey string) bool
 	_, keyExists := redisStorage.storageData[storageKey]
 	if keyExists {
 		delete(redisStorage.storageData, storageKey)
+		redisStorage.reindexHashKey(storageKey)
 		redisStorage.incrementChanges()
 	}
 	return keyExists
 }
 
+// RenameKey déplace la valeur (et son TTL) de sourceKey vers destinationKey
+// Avec onlyIfAbsent (RENAMENX), rien n'est fait si destinationKey existe déjà
+// Retourne (false, false) si sourceKey n'existe pas, (false, true) si destinationKey bloque le renommage
+func (redisStorage *RedisInMemoryStorage) RenameKey(sourceKey, destinationKey string, onlyIfAbsent bool) (keyRenamed bool, sourceExists bool) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	currentTime := time.Now()
+	storageValue, keyExists := redisStorage.storageData[sourceKey]
+	if !keyExists || (storageValue.ExpirationTime != nil && currentTime.After(*storageValue.ExpirationTime)) {
+		return false, false
+	}
+	if onlyIfAbsent {
+		destinationValue, destinationExists := redisStorage.storageData[destinationKey]
+		if destinationExists && (destinationValue.ExpirationTime == nil || currentTime.Before(*destinationValue.ExpirationTime)) {
+			return false, true
+		}
+	}
+	if sourceKey == destinationKey {
+		return true, true
+	}
+
+	// La valeur pourra être modifiée sur place sous son nouveau nom : les snapshots en cours
+	// qui la référencent encore sous l'ancien nom en gardent une copie
+	redisStorage.preserveValueForSnapshots(sourceKey, storageValue)
+	delete(redisStorage.storageData, sourceKey)
+	redisStorage.storageData[destinationKey] = storageValue
+	redisStorage.reindexHashKey(sourceKey)
+	redisStorage.reindexHashKey(destinationKey)
+	redisStorage.incrementChanges()
+	return true, true
+}
+
+// RestoreKeyValue installe une valeur reconstruite (RESTORE)
+// Sans replaceExisting, rien n'est fait si la clé existe déjà et la fonction retourne false
+func (redisStorage *RedisInMemoryStorage) RestoreKeyValue(storageKey string, storageValue *RedisStorageValue, replaceExisting bool) bool {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	if !replaceExisting {
+		existingValue, keyExists := redisStorage.storageData[storageKey]
+		if keyExists && (existingValue.ExpirationTime == nil || time.Now().Before(*existingValue.ExpirationTime)) {
+			return false
+		}
+	}
+
+	redisStorage.storageData[storageKey] = storageValue
+	redisStorage.reindexHashKey(storageKey)
+	redisStorage.incrementChanges()
+	return true
+}
+
 // CheckKeyExists vérifie si une clé existe et n'a pas expiré
 func (redisStorage *RedisInMemoryStorage) CheckKeyExists(storageKey string) bool {
 	redisStorage.storageMutex.RLock()
@@ -123,6 +182,7 @@ func (redisStorage *RedisInMemoryStorage) CleanupExpiredKeys() int {
 	for storageKey, storageValue := range redisStorage.storageData {
 		if storageValue.ExpirationTime != nil && currentTime.After(*storageValue.ExpirationTime) {
 			delete(redisStorage.storageData, storageKey)
+			redisStorage.reindexHashKey(storageKey)
 			cleanedKeyCount++
 		}
 	}
@@ -142,6 +202,7 @@ func (redisStorage *RedisInMemoryStorage) FlushAllKeys() {
 
 	keyCount := len(redisStorage.storageData)
 	redisStorage.storageData = make(map[string]*RedisStorageValue)
+	redisStorage.rebuildSearchIndexes()
 
 	// Compter comme un changement majeur
 	if keyCount > 0 {
@@ -195,6 +256,7 @@ func (redisStorage *RedisInMemoryStorage) SetKeyValueIfNotExists(storageKey stri
 		DataType:       dataType,
 		ExpirationTime: nil, // SETNX ne définit pas de TTL
 	}
+	redisStorage.reindexHashKey(storageKey)
 
 	redisStorage.incrementChanges()
 	return true
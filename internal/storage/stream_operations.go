@@ -0,0 +1,394 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Erreurs retournées par AddStreamEntry
+var (
+	ErrStreamWrongType      = errors.New("cette clé ne contient pas un stream")
+	ErrStreamIDZero         = errors.New("l'identifiant doit être supérieur à 0-0")
+	ErrStreamIDTooSmall     = errors.New("l'identifiant est inférieur ou égal au dernier identifiant du stream")
+	ErrStreamIDSpaceExhaust = errors.New("plus aucun identifiant disponible après le dernier identifiant du stream")
+)
+
+// MaximumStreamEntryID est le plus grand identifiant possible (borne "+")
+var MaximumStreamEntryID = StreamEntryID{Milliseconds: math.MaxUint64, Sequence: math.MaxUint64}
+
+// StreamIDRequest décrit l'identifiant demandé à XADD
+type StreamIDRequest struct {
+	AutoGenerate      bool          // "*" : millisecondes et séquence générées
+	AutoSequence      bool          // "ms-*" : seule la séquence est générée
+	EntryID           StreamEntryID // Identifiant explicite (ou millisecondes de "ms-*")
+	ClockMilliseconds uint64        // Horloge utilisée par "*", fixée avant propagation pour que tous les nœuds génèrent le même identifiant
+}
+
+// StreamTrimOptions décrit le plafonnement MAXLEN / MINID de XADD et XTRIM
+// "~" est accepté mais le plafonnement reste exact : seule LIMIT borne le nombre d'entrées retirées
+type StreamTrimOptions struct {
+	TrimEnabled     bool
+	TrimByMinimumID bool
+	MaximumLength   int64
+	MinimumID       StreamEntryID
+	Limit           int64 // 0 = sans limite
+}
+
+// StreamWaiter est réveillé quand une entrée est ajoutée à l'un des streams qu'il surveille (XREAD BLOCK)
+type StreamWaiter struct {
+	watchedKeys   []string
+	Notifications chan struct{}
+}
+
+// String retourne l'identifiant au format ms-seq
+func (entryID StreamEntryID) String() string {
+	return strconv.FormatUint(entryID.Milliseconds, 10) + "-" + strconv.FormatUint(entryID.Sequence, 10)
+}
+
+// Compare retourne -1, 0 ou 1 selon que l'identifiant est inférieur, égal ou supérieur à l'autre
+func (entryID StreamEntryID) Compare(otherID StreamEntryID) int {
+	switch {
+	case entryID.Milliseconds < otherID.Milliseconds:
+		return -1
+	case entryID.Milliseconds > otherID.Milliseconds:
+		return 1
+	case entryID.Sequence < otherID.Sequence:
+		return -1
+	case entryID.Sequence > otherID.Sequence:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Next retourne l'identifiant suivant, false si l'identifiant est déjà le plus grand
+func (entryID StreamEntryID) Next() (StreamEntryID, bool) {
+	switch {
+	case entryID.Sequence < math.MaxUint64:
+		return StreamEntryID{Milliseconds: entryID.Milliseconds, Sequence: entryID.Sequence + 1}, true
+	case entryID.Milliseconds < math.MaxUint64:
+		return StreamEntryID{Milliseconds: entryID.Milliseconds + 1}, true
+	default:
+		return entryID, false
+	}
+}
+
+// Previous retourne l'identifiant précédent, false si l'identifiant est 0-0
+func (entryID StreamEntryID) Previous() (StreamEntryID, bool) {
+	switch {
+	case entryID.Sequence > 0:
+		return StreamEntryID{Milliseconds: entryID.Milliseconds, Sequence: entryID.Sequence - 1}, true
+	case entryID.Milliseconds > 0:
+		return StreamEntryID{Milliseconds: entryID.Milliseconds - 1, Sequence: math.MaxUint64}, true
+	default:
+		return entryID, false
+	}
+}
+
+// ParseStreamEntryID lit un identifiant "ms-seq" ; "ms" seul prend la séquence missingSequence
+func ParseStreamEntryID(idText string, missingSequence uint64) (StreamEntryID, bool) {
+	millisecondsText, sequenceText, hasSequence := strings.Cut(idText, "-")
+	milliseconds, parseError := strconv.ParseUint(millisecondsText, 10, 64)
+	if parseError != nil {
+		return StreamEntryID{}, false
+	}
+	if !hasSequence {
+		return StreamEntryID{Milliseconds: milliseconds, Sequence: missingSequence}, true
+	}
+	sequence, parseError := strconv.ParseUint(sequenceText, 10, 64)
+	if parseError != nil {
+		return StreamEntryID{}, false
+	}
+	return StreamEntryID{Milliseconds: milliseconds, Sequence: sequence}, true
+}
+
+// AddStreamEntry ajoute une entrée (XADD) puis applique le plafonnement demandé
+// Retourne false sans erreur si le stream n'existe pas et que createIfMissing est faux (NOMKSTREAM)
+func (redisStorage *RedisInMemoryStorage) AddStreamEntry(streamKey string, idRequest StreamIDRequest, fieldValues []string, createIfMissing bool, trimOptions StreamTrimOptions) (StreamEntryID, bool, error) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	streamStructure, streamExists, isStream := redisStorage.lookupStream(streamKey)
+	if !isStream {
+		return StreamEntryID{}, false, ErrStreamWrongType
+	}
+	if !streamExists && !createIfMissing {
+		return StreamEntryID{}, false, nil
+	}
+
+	var lastGeneratedID StreamEntryID
+	if streamExists {
+		lastGeneratedID = streamStructure.LastGeneratedID
+	}
+	newEntryID, idError := generateStreamEntryID(idRequest, lastGeneratedID)
+	if idError != nil {
+		return StreamEntryID{}, false, idError
+	}
+
+	if streamExists {
+		redisStorage.preserveValueForSnapshots(streamKey, redisStorage.storageData[streamKey])
+	} else {
+		streamStructure = &RedisStreamStructure{}
+		redisStorage.storageData[streamKey] = &RedisStorageValue{
+			StoredData: streamStructure,
+			DataType:   RedisStreamType,
+		}
+	}
+
+	streamStructure.StreamEntries = append(streamStructure.StreamEntries, StreamEntry{
+		EntryID:     newEntryID,
+		FieldValues: append([]string(nil), fieldValues...),
+	})
+	streamStructure.LastGeneratedID = newEntryID
+	trimStreamEntries(streamStructure, trimOptions)
+	redisStorage.incrementChanges()
+
+	for streamWaiter := range redisStorage.streamWaiters[streamKey] {
+		select {
+		case streamWaiter.Notifications <- struct{}{}:
+		default:
+		}
+	}
+	return newEntryID, true, nil
+}
+
+// generateStreamEntryID calcule l'identifiant d'une nouvelle entrée à partir du dernier identifiant généré
+func generateStreamEntryID(idRequest StreamIDRequest, lastGeneratedID StreamEntryID) (StreamEntryID, error) {
+	switch {
+	case idRequest.AutoGenerate:
+		if idRequest.ClockMilliseconds > lastGeneratedID.Milliseconds {
+			return StreamEntryID{Milliseconds: idRequest.ClockMilliseconds}, nil
+		}
+		// Horloge en retard ou même milliseconde : on prolonge le dernier identifiant
+		nextID, nextExists := lastGeneratedID.Next()
+		if !nextExists {
+			return StreamEntryID{}, ErrStreamIDSpaceExhaust
+		}
+		return nextID, nil
+
+	case idRequest.AutoSequence:
+		requestedMilliseconds := idRequest.EntryID.Milliseconds
+		switch {
+		case requestedMilliseconds < lastGeneratedID.Milliseconds:
+			return StreamEntryID{}, ErrStreamIDTooSmall
+		case requestedMilliseconds == lastGeneratedID.Milliseconds:
+			if lastGeneratedID.Sequence == math.MaxUint64 {
+				return StreamEntryID{}, ErrStreamIDTooSmall
+			}
+			return StreamEntryID{Milliseconds: requestedMilliseconds, Sequence: lastGeneratedID.Sequence + 1}, nil
+		default:
+			return StreamEntryID{Milliseconds: requestedMilliseconds}, nil
+		}
+
+	default:
+		if idRequest.EntryID == (StreamEntryID{}) {
+			return StreamEntryID{}, ErrStreamIDZero
+		}
+		if idRequest.EntryID.Compare(lastGeneratedID) <= 0 {
+			return StreamEntryID{}, ErrStreamIDTooSmall
+		}
+		return idRequest.EntryID, nil
+	}
+}
+
+// trimStreamEntries retire les entrées les plus anciennes selon MAXLEN ou MINID et retourne leur nombre
+func trimStreamEntries(streamStructure *RedisStreamStructure, trimOptions StreamTrimOptions) int {
+	if !trimOptions.TrimEnabled {
+		return 0
+	}
+
+	removedCount := 0
+	if trimOptions.TrimByMinimumID {
+		removedCount = sort.Search(len(streamStructure.StreamEntries), func(entryIndex int) bool {
+			return streamStructure.StreamEntries[entryIndex].EntryID.Compare(trimOptions.MinimumID) >= 0
+		})
+	} else if excessCount := int64(len(streamStructure.StreamEntries)) - trimOptions.MaximumLength; excessCount > 0 {
+		removedCount = int(excessCount)
+	}
+	if trimOptions.Limit > 0 && int64(removedCount) > trimOptions.Limit {
+		removedCount = int(trimOptions.Limit)
+	}
+
+	// Le début du tableau sous-jacent est libéré à la prochaine réallocation par append
+	streamStructure.StreamEntries = streamStructure.StreamEntries[removedCount:]
+	return removedCount
+}
+
+// TrimStream applique XTRIM et retourne le nombre d'entrées retirées, -1 si la clé n'est pas un stream
+func (redisStorage *RedisInMemoryStorage) TrimStream(streamKey string, trimOptions StreamTrimOptions) int {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	streamStructure, streamExists, isStream := redisStorage.lookupStream(streamKey)
+	if !isStream {
+		return -1
+	}
+	if !streamExists {
+		return 0
+	}
+
+	redisStorage.preserveValueForSnapshots(streamKey, redisStorage.storageData[streamKey])
+	removedCount := trimStreamEntries(streamStructure, trimOptions)
+	if removedCount > 0 {
+		redisStorage.incrementChanges()
+	}
+	return removedCount
+}
+
+// DeleteStreamEntries supprime des entrées par identifiant (XDEL)
+// Retourne le nombre d'entrées supprimées, -1 si la clé n'est pas un stream
+func (redisStorage *RedisInMemoryStorage) DeleteStreamEntries(streamKey string, entryIDs []StreamEntryID) int {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	streamStructure, streamExists, isStream := redisStorage.lookupStream(streamKey)
+	if !isStream {
+		return -1
+	}
+	if !streamExists {
+		return 0
+	}
+
+	redisStorage.preserveValueForSnapshots(streamKey, redisStorage.storageData[streamKey])
+	deletedCount := 0
+	for _, entryID := range entryIDs {
+		entryIndex := searchStreamEntry(streamStructure.StreamEntries, entryID)
+		if entryIndex == len(streamStructure.StreamEntries) || streamStructure.StreamEntries[entryIndex].EntryID != entryID {
+			continue
+		}
+		streamStructure.StreamEntries = append(streamStructure.StreamEntries[:entryIndex], streamStructure.StreamEntries[entryIndex+1:]...)
+		deletedCount++
+	}
+	if deletedCount > 0 {
+		redisStorage.incrementChanges()
+	}
+	return deletedCount
+}
+
+// GetStreamLength retourne le nombre d'entrées, -1 si la clé n'est pas un stream
+func (redisStorage *RedisInMemoryStorage) GetStreamLength(streamKey string) int {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	streamStructure, streamExists, isStream := redisStorage.lookupStream(streamKey)
+	if !isStream {
+		return -1
+	}
+	if !streamExists {
+		return 0
+	}
+	return len(streamStructure.StreamEntries)
+}
+
+// GetStreamLastID retourne le dernier identifiant généré (0-0 si le stream n'existe pas)
+// Retourne false si la clé n'est pas un stream
+func (redisStorage *RedisInMemoryStorage) GetStreamLastID(streamKey string) (StreamEntryID, bool) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	streamStructure, streamExists, isStream := redisStorage.lookupStream(streamKey)
+	if !isStream {
+		return StreamEntryID{}, false
+	}
+	if !streamExists {
+		return StreamEntryID{}, true
+	}
+	return streamStructure.LastGeneratedID, true
+}
+
+// GetStreamEntriesInRange retourne les entrées dont l'identifiant est compris entre startID et endID inclus
+// reverse parcourt de endID vers startID ; maximumCount <= 0 = sans limite
+// Retourne false si la clé n'est pas un stream
+func (redisStorage *RedisInMemoryStorage) GetStreamEntriesInRange(streamKey string, startID, endID StreamEntryID, maximumCount int, reverse bool) ([]StreamEntry, bool) {
+	redisStorage.storageMutex.RLock()
+	defer redisStorage.storageMutex.RUnlock()
+
+	streamStructure, streamExists, isStream := redisStorage.lookupStream(streamKey)
+	if !isStream {
+		return nil, false
+	}
+	if !streamExists || startID.Compare(endID) > 0 {
+		return []StreamEntry{}, true
+	}
+
+	streamEntries := streamStructure.StreamEntries
+	firstIndex := searchStreamEntry(streamEntries, startID)
+	lastIndex := sort.Search(len(streamEntries), func(entryIndex int) bool {
+		return streamEntries[entryIndex].EntryID.Compare(endID) > 0
+	})
+	if firstIndex >= lastIndex {
+		return []StreamEntry{}, true
+	}
+
+	selectedCount := lastIndex - firstIndex
+	if maximumCount > 0 && selectedCount > maximumCount {
+		selectedCount = maximumCount
+	}
+	rangeEntries := make([]StreamEntry, 0, selectedCount)
+	for entryOffset := 0; entryOffset < selectedCount; entryOffset++ {
+		if reverse {
+			rangeEntries = append(rangeEntries, streamEntries[lastIndex-1-entryOffset])
+		} else {
+			rangeEntries = append(rangeEntries, streamEntries[firstIndex+entryOffset])
+		}
+	}
+	return rangeEntries, true
+}
+
+// WatchStreams inscrit une attente sur des streams ; à appeler avant la lecture pour ne manquer aucun ajout
+func (redisStorage *RedisInMemoryStorage) WatchStreams(streamKeys []string) *StreamWaiter {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	streamWaiter := &StreamWaiter{
+		watchedKeys:   streamKeys,
+		Notifications: make(chan struct{}, 1),
+	}
+	if redisStorage.streamWaiters == nil {
+		redisStorage.streamWaiters = make(map[string]map[*StreamWaiter]struct{})
+	}
+	for _, streamKey := range streamKeys {
+		if redisStorage.streamWaiters[streamKey] == nil {
+			redisStorage.streamWaiters[streamKey] = make(map[*StreamWaiter]struct{})
+		}
+		redisStorage.streamWaiters[streamKey][streamWaiter] = struct{}{}
+	}
+	return streamWaiter
+}
+
+// UnwatchStreams retire une attente inscrite par WatchStreams
+func (redisStorage *RedisInMemoryStorage) UnwatchStreams(streamWaiter *StreamWaiter) {
+	redisStorage.storageMutex.Lock()
+	defer redisStorage.storageMutex.Unlock()
+
+	for _, streamKey := range streamWaiter.watchedKeys {
+		delete(redisStorage.streamWaiters[streamKey], streamWaiter)
+		if len(redisStorage.streamWaiters[streamKey]) == 0 {
+			delete(redisStorage.streamWaiters, streamKey)
+		}
+	}
+}
+
+// lookupStream retourne le stream d'une clé (verrou détenu par l'appelant)
+// streamExists est faux si la clé est absente ou expirée, isStream est faux si elle contient un autre type
+func (redisStorage *RedisInMemoryStorage) lookupStream(streamKey string) (streamStructure *RedisStreamStructure, streamExists bool, isStream bool) {
+	storageValue, keyExists := redisStorage.storageData[streamKey]
+	if !keyExists || (storageValue.ExpirationTime != nil && time.Now().After(*storageValue.ExpirationTime)) {
+		return nil, false, true
+	}
+	if storageValue.DataType != RedisStreamType {
+		return nil, false, false
+	}
+	return storageValue.StoredData.(*RedisStreamStructure), true, true
+}
+
+// searchStreamEntry retourne l'indice de la première entrée dont l'identifiant est >= entryID
+func searchStreamEntry(streamEntries []StreamEntry, entryID StreamEntryID) int {
+	return sort.Search(len(streamEntries), func(entryIndex int) bool {
+		return streamEntries[entryIndex].EntryID.Compare(entryID) >= 0
+	})
+}
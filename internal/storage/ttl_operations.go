@@ -37,6 +37,12 @@ func (redisStorage *RedisInMemoryStorage) GetKeyTTL(storageKey string, inMillise
 // SetKeyExpiration définit un TTL sur une clé existante
 // Retourne true si la clé existe et que le TTL a été défini, false sinon
 func (redisStorage *RedisInMemoryStorage) SetKeyExpiration(storageKey string, timeToLive time.Duration) bool {
+	return redisStorage.SetKeyExpirationAt(storageKey, time.Now().Add(timeToLive))
+}
+
+// SetKeyExpirationAt définit une date d'expiration absolue sur une clé existante
+// Une date déjà passée supprime la clé (comme PEXPIREAT) ; retourne true si la clé existait, false sinon
+func (redisStorage *RedisInMemoryStorage) SetKeyExpirationAt(storageKey string, expirationTime time.Time) bool {
 	redisStorage.storageMutex.Lock()
 	defer redisStorage.storageMutex.Unlock()
 
@@ -53,9 +59,18 @@ func (redisStorage *RedisInMemoryStorage) SetKeyExpiration(storageKey string, ti
 		return false
 	}
 
-	// Définir la nouvelle expiration
-	newExpirationTime := currentTime.Add(timeToLive)
-	storageValue.ExpirationTime = &newExpirationTime
+	// Date déjà passée : la clé expire immédiatement
+	if !expirationTime.After(currentTime) {
+		delete(redisStorage.storageData, storageKey)
+		redisStorage.reindexHashKey(storageKey)
+		redisStorage.incrementChanges()
+		return true
+	}
+
+	// Copie pour les snapshots en cours avant modification sur place
+	redisStorage.preserveValueForSnapshots(storageKey, storageValue)
+
+	storageValue.ExpirationTime = &expirationTime
 
 	return true
 }
@@ -82,6 +97,9 @@ func (redisStorage *RedisInMemoryStorage) RemoveKeyExpiration(storageKey string)
 	// Vérifier si la clé avait un TTL
 	hadTTL := storageValue.ExpirationTime != nil
 
+	// Copie pour les snapshots en cours avant modification sur place
+	redisStorage.preserveValueForSnapshots(storageKey, storageValue)
+
 	// Supprimer le TTL
 	storageValue.ExpirationTime = nil
 
@@ -12,8 +12,15 @@ import (
 )
 
 func main() {
-	// Chargement de la configuration depuis les variables d'environnement ou valeurs par défaut
-	serverConfiguration := config.LoadServerConfiguration()
+	// Chargement de la configuration : défauts < variables REDIS_* < fichier < arguments --param valeur
+	// Usage : redis-go [/chemin/redis.conf] [--param valeur ...]
+	serverConfiguration, configurationError := config.LoadServerConfigurationFromArguments(os.Args[1:])
+	if configurationError != nil {
+		log.Fatalf("❌ Configuration invalide: %v", configurationError)
+	}
+	if serverConfiguration.ConfigurationFilePath != "" {
+		log.Printf("📄 Configuration chargée depuis %s", serverConfiguration.ConfigurationFilePath)
+	}
 
 	// Création du serveur Redis
 	redisServerInstance := server.NewRedisServerInstance(serverConfiguration)
@@ -22,11 +29,23 @@ func main() {
 	systemInterruptSignal := make(chan os.Signal, 1)
 	signal.Notify(systemInterruptSignal, os.Interrupt, syscall.SIGTERM)
 
+	// SIGHUP recharge les certificats TLS sans couper les connexions
+	certificateReloadSignal := make(chan os.Signal, 1)
+	signal.Notify(certificateReloadSignal, syscall.SIGHUP)
+	go func() {
+		for range certificateReloadSignal {
+			if reloadError := redisServerInstance.ReloadTLSCertificates(); reloadError != nil {
+				log.Printf("⚠️  Rechargement TLS impossible: %v", reloadError)
+			}
+		}
+	}()
+
 	// Démarrage du serveur dans une goroutine séparée
 	go func() {
-		log.Printf("🎯 Démarrage du serveur Redis-Go sur %s:%d",
+		log.Printf("🎯 Démarrage du serveur Redis-Go sur %s (port %d, port TLS %d)",
 			serverConfiguration.NetworkConfiguration.HostAddress,
-			serverConfiguration.NetworkConfiguration.PortNumber)
+			serverConfiguration.NetworkConfiguration.PortNumber,
+			serverConfiguration.NetworkConfiguration.TLSConfiguration.PortNumber)
 		if startupError := redisServerInstance.StartRedisServer(); startupError != nil {
 			log.Fatalf("❌ Impossible de démarrer le serveur: %v", startupError)
 		}